@@ -311,7 +311,7 @@ func (a *App) Run() (err error) {
 		return err
 	}
 
-	a.ToMainMenu()
+	a.ToBlizzardIntro()
 
 	if err := a.renderer.Run(a.update, a.advance, 800, 600, windowTitle); err != nil {
 		return err
@@ -591,6 +591,13 @@ func (a *App) updateInitError(target d2interface.Surface) error {
 	return nil
 }
 
+// ToBlizzardIntro forces the game to transition to the Blizzard Intro, which goes on to the
+// Main Menu
+func (a *App) ToBlizzardIntro() {
+	a.screen.SetNextScreen(d2gamescreen.CreateBlizzardIntro(a, a.asset, a.renderer, a.inputManager, a.audio,
+		*a.Options.LogLevel))
+}
+
 // ToMainMenu forces the game to transition to the Main Menu
 func (a *App) ToMainMenu(errorMessageOptional ...string) {
 	buildInfo := d2gamescreen.BuildInfo{Branch: a.gitBranch, Commit: a.gitCommit}
@@ -103,3 +103,26 @@ func (node *ConstantCalculation) Eval() int {
 func (node *ConstantCalculation) String() string {
 	return strconv.Itoa(node.Value)
 }
+
+// Resolver returns the value of a property referenced by a calculation, like the level of a
+// skill or one of its parameters.
+type Resolver func(reference *PropertyReferenceCalculation) int
+
+// EvalWith evaluates the calculation, with the values of the properties it references given by
+// the resolver.
+func EvalWith(calc Calculation, resolve Resolver) int {
+	switch node := calc.(type) {
+	case nil:
+		return 0
+	case *BinaryCalculation:
+		return node.Op(EvalWith(node.Left, resolve), EvalWith(node.Right, resolve))
+	case *UnaryCalculation:
+		return node.Op(EvalWith(node.Child, resolve))
+	case *TernaryCalculation:
+		return node.Op(EvalWith(node.Left, resolve), EvalWith(node.Middle, resolve), EvalWith(node.Right, resolve))
+	case *PropertyReferenceCalculation:
+		return resolve(node)
+	default:
+		return calc.Eval()
+	}
+}
@@ -0,0 +1,208 @@
+package d2compression
+
+import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2datautils"
+)
+
+// Parameters of the streams written by PkImplode. Literals are stored uncoded and the largest
+// dictionary is used. See blast.c by Mark Adler for a description of the format.
+const (
+	pkLiteralsUncoded = 0
+	pkDictionaryBits  = 6
+
+	pkMinMatch          = 3
+	pkMaxMatch          = 518
+	pkEndOfStream       = 519
+	pkDistanceCodeCount = 64
+	pkMaxDistance       = pkDistanceCodeCount << pkDictionaryBits
+
+	pkHashBits     = 12
+	pkMaxHashChain = 64
+	pkLiteralBits  = 8
+	pkMaxCodeBits  = 13
+	pkCompactCount = 4 // a compact code length is a repeat count in the high and a length in the low nibble
+	pkCompactMask  = 15
+	pkHashShiftA   = 8
+	pkHashShiftB   = 4
+)
+
+// pkEncoder writes the bit stream of an imploded file
+type pkEncoder struct {
+	*d2datautils.StreamWriter
+	bitCount int
+
+	lengthCodes, distanceCodes []pkCode
+	lengthBase, lengthExtra    []int
+}
+
+// pkCode is a huffman code of the PKWARE library, it is written with inverted bits
+type pkCode struct {
+	code   uint16
+	length int
+}
+
+// PkImplode compresses the data with the PKWARE Data Compression Library "implode" method, the
+// result can be decompressed with blast (the PKWARE "explode" method).
+func PkImplode(data []byte) []byte {
+	e := &pkEncoder{
+		StreamWriter:  d2datautils.CreateStreamWriter(),
+		lengthCodes:   pkHuffmanCodes([]byte{2, 35, 36, 53, 38, 23}),                   //nolint:gomnd // code lengths
+		distanceCodes: pkHuffmanCodes([]byte{2, 20, 53, 230, 247, 151, 248}),           //nolint:gomnd // code lengths
+		lengthBase:    []int{3, 2, 4, 5, 6, 7, 8, 9, 10, 12, 16, 24, 40, 72, 136, 264}, //nolint:gomnd // table
+		lengthExtra:   []int{0, 0, 0, 0, 0, 0, 0, 0, 1, 2, 3, 4, 5, 6, 7, 8},           //nolint:gomnd // table
+	}
+
+	e.PushBytes(pkLiteralsUncoded, pkDictionaryBits)
+
+	head := make([]int, 1<<pkHashBits)
+	for idx := range head {
+		head[idx] = -1
+	}
+
+	chain := make([]int, len(data))
+	insert := func(pos int) {
+		if pos+pkMinMatch <= len(data) {
+			h := pkHash(data[pos:])
+			chain[pos], head[h] = head[h], pos
+		}
+	}
+
+	for pos := 0; pos < len(data); {
+		length, distance := pkFindMatch(data, pos, head, chain)
+
+		if length < pkMinMatch {
+			e.pushLiteral(data[pos])
+			insert(pos)
+			pos++
+
+			continue
+		}
+
+		e.pushMatch(length, distance)
+
+		for end := pos + length; pos < end; pos++ {
+			insert(pos)
+		}
+	}
+
+	// the end of the stream is marked with a match of a length which is out of range
+	e.pushBits(1, 1)
+	e.pushLength(pkEndOfStream)
+
+	// pad the last byte
+	for e.bitCount%pkLiteralBits != 0 {
+		e.pushBits(0, 1)
+	}
+
+	return e.GetBytes()
+}
+
+func pkHash(b []byte) int {
+	return (int(b[0])<<pkHashShiftA ^ int(b[1])<<pkHashShiftB ^ int(b[2])) & (1<<pkHashBits - 1)
+}
+
+// pkFindMatch returns the longest match for the data at pos in the dictionary
+func pkFindMatch(data []byte, pos int, head, chain []int) (length, distance int) {
+	if pos+pkMinMatch > len(data) {
+		return 0, 0
+	}
+
+	maxLength := len(data) - pos
+	if maxLength > pkMaxMatch {
+		maxLength = pkMaxMatch
+	}
+
+	candidate := head[pkHash(data[pos:])]
+
+	for steps := 0; candidate >= 0 && pos-candidate <= pkMaxDistance && steps < pkMaxHashChain; steps++ {
+		l := 0
+		for l < maxLength && data[candidate+l] == data[pos+l] {
+			l++
+		}
+
+		if l > length {
+			length, distance = l, pos-candidate
+		}
+
+		if length == maxLength {
+			break
+		}
+
+		candidate = chain[candidate]
+	}
+
+	return length, distance
+}
+
+func (e *pkEncoder) pushBits(value uint32, count int) {
+	e.PushBits32(value, count)
+	e.bitCount += count
+}
+
+// pushCode writes a huffman code, the PKWARE library stores them with inverted bits
+func (e *pkEncoder) pushCode(c pkCode) {
+	for bit := c.length - 1; bit >= 0; bit-- {
+		e.pushBits(uint32(c.code>>uint(bit)&1)^1, 1)
+	}
+}
+
+func (e *pkEncoder) pushLiteral(b byte) {
+	e.pushBits(0, 1)
+	e.pushBits(uint32(b), pkLiteralBits)
+}
+
+func (e *pkEncoder) pushLength(length int) {
+	for symbol, base := range e.lengthBase {
+		if length >= base && length < base+1<<uint(e.lengthExtra[symbol]) {
+			e.pushCode(e.lengthCodes[symbol])
+			e.pushBits(uint32(length-base), e.lengthExtra[symbol])
+
+			return
+		}
+	}
+}
+
+func (e *pkEncoder) pushMatch(length, distance int) {
+	e.pushBits(1, 1)
+	e.pushLength(length)
+
+	// matches of two bytes would use less low bits, they are never written
+	distance--
+
+	e.pushCode(e.distanceCodes[distance>>pkDictionaryBits])
+	e.pushBits(uint32(distance&(1<<pkDictionaryBits-1)), pkDictionaryBits)
+}
+
+// pkHuffmanCodes expands the compact code lengths used by the PKWARE library into canonical
+// huffman codes. Every byte of the compact form repeats a code length one or more times.
+func pkHuffmanCodes(compact []byte) []pkCode {
+	codes := make([]pkCode, 0)
+
+	for _, b := range compact {
+		for repeat := int(b>>pkCompactCount) + 1; repeat > 0; repeat-- {
+			codes = append(codes, pkCode{length: int(b & pkCompactMask)})
+		}
+	}
+
+	var count [pkMaxCodeBits + 1]uint16
+
+	for idx := range codes {
+		count[codes[idx].length]++
+	}
+
+	var next [pkMaxCodeBits + 1]uint16
+
+	code := uint16(0)
+
+	for length := 1; length <= pkMaxCodeBits; length++ {
+		next[length] = code
+		code = (code + count[length]) << 1
+	}
+
+	for idx := range codes {
+		codes[idx].code = next[codes[idx].length]
+		next[codes[idx].length]++
+	}
+
+	return codes
+}
@@ -0,0 +1,404 @@
+package d2video
+
+import (
+	"errors"
+	"math"
+	"math/bits"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2datautils"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math"
+)
+
+const (
+	binkAudioQuantCount     = 96
+	binkAudioQuantStep      = 0.15289164787221953823 // 0.0664 / log10(e)
+	binkAudioSampleScale    = 32768
+	binkAudioOverlapDivisor = 16
+	binkAudioCoefficientRun = 8
+	binkAudioRunLengthB     = 16 // the 'b' revision uses runs of a fixed length
+	binkAudioBandBits       = 8
+	binkAudioWidthBits      = 4
+	binkAudioFloatPower     = 5
+	binkAudioFloatMantissa  = 23
+	binkAudioPacketHeader   = 32 // the number of decoded samples, which is not needed
+	binkAudioBlockAlign     = 32
+	binkAudioRateLow        = 22050
+	binkAudioRateHigh       = 44100
+	binkAudioFrameBitsLow   = 9
+	binkAudioFrameBitsMid   = 10
+	binkAudioFrameBitsHigh  = 11
+)
+
+var errBinkInvalidAudio = errors.New("invalid bink audio data")
+
+// binkAudioCriticalFrequencies returns the upper limits of the frequency bands, every band
+// has its own quantizer
+func binkAudioCriticalFrequencies() []int {
+	return []int{
+		100, 200, 300, 400, 510, 630, 770, 920, 1080, 1270, 1480, 1720, 2000, 2320, 2700, 3150,
+		3700, 4400, 5300, 6400, 7700, 9500, 12000, 15500, 24500,
+	}
+}
+
+// binkAudioRunLengths returns the lengths of the runs of coefficients which share a bit width,
+// in units of 8 coefficients
+func binkAudioRunLengths() []int {
+	return []int{2, 3, 4, 5, 6, 8, 9, 10, 11, 12, 13, 14, 15, 16, 32, 64}
+}
+
+// binkAudioDecoder decodes the packets of an audio track. DCT tracks code every channel on its
+// own, RDFT tracks code the interleaved samples of all channels as one channel.
+type binkAudioDecoder struct {
+	useDCT      bool
+	revisionB   bool
+	channels    int // the number of coded channels
+	frameLen    int
+	overlapLen  int
+	root        float64
+	quantTable  [binkAudioQuantCount]float64
+	bands       []int
+	first       bool
+	previous    [][]float64
+	coeffs      [][]float64
+	transform   *binkFFT
+	transformed []complex128
+}
+
+func newBinkAudioDecoder(track *BinkAudioTrack, revision byte) *binkAudioDecoder {
+	a := &binkAudioDecoder{
+		useDCT:    track.Algorithm == BinkAudioAlgorithmDCT,
+		revisionB: revision == binkRevisionB,
+		channels:  int(track.AudioChannels),
+		first:     true,
+	}
+
+	sampleRate := int(track.AudioSampleRateHz)
+
+	frameLenBits := binkAudioFrameBitsHigh
+
+	switch {
+	case sampleRate < binkAudioRateLow:
+		frameLenBits = binkAudioFrameBitsLow
+	case sampleRate < binkAudioRateHigh:
+		frameLenBits = binkAudioFrameBitsMid
+	}
+
+	if !a.useDCT {
+		// the samples of all channels are interleaved before the transform
+		sampleRate *= a.channels
+
+		if !a.revisionB {
+			frameLenBits += bits.Len(uint(a.channels)) - 1
+		}
+
+		a.channels = 1
+	}
+
+	a.frameLen = 1 << uint(frameLenBits)
+	a.overlapLen = a.frameLen / binkAudioOverlapDivisor
+
+	if a.useDCT {
+		a.root = float64(a.frameLen) / (math.Sqrt(float64(a.frameLen)) * binkAudioSampleScale)
+		a.transform = newBinkFFT(a.frameLen * 2) //nolint:gomnd // the DCT is computed with a FFT of twice the size
+	} else {
+		a.root = 2 / (math.Sqrt(float64(a.frameLen)) * binkAudioSampleScale) //nolint:gomnd // scale of the RDFT
+		a.transform = newBinkFFT(a.frameLen)
+	}
+
+	for idx := range a.quantTable {
+		a.quantTable[idx] = math.Exp(float64(idx)*binkAudioQuantStep) * a.root
+	}
+
+	a.initBands(sampleRate)
+
+	a.previous = make([][]float64, a.channels)
+	a.coeffs = make([][]float64, a.channels)
+
+	for ch := 0; ch < a.channels; ch++ {
+		a.previous[ch] = make([]float64, a.overlapLen)
+		a.coeffs[ch] = make([]float64, a.frameLen)
+	}
+
+	a.transformed = make([]complex128, a.transform.size)
+
+	return a
+}
+
+// initBands sets the first coefficient of every frequency band
+func (a *binkAudioDecoder) initBands(sampleRate int) {
+	frequencies := binkAudioCriticalFrequencies()
+	halfRate := (sampleRate + 1) / 2 //nolint:gomnd // nyquist frequency
+
+	numBands := 1
+	for ; numBands < len(frequencies); numBands++ {
+		if halfRate <= frequencies[numBands-1] {
+			break
+		}
+	}
+
+	a.bands = make([]int, numBands+1)
+	a.bands[0] = 2
+
+	for idx := 1; idx < numBands; idx++ {
+		a.bands[idx] = (frequencies[idx-1] * a.frameLen / halfRate) &^ 1
+	}
+
+	a.bands[numBands] = a.frameLen
+}
+
+// decodePacket decodes an audio packet into interleaved samples between -1 and 1
+func (a *binkAudioDecoder) decodePacket(packet []byte) (samples []float32, err error) {
+	defer func() {
+		// the bit reader panics when the packet ends in the middle of a block
+		if r := recover(); r != nil {
+			err = errBinkInvalidAudio
+		}
+	}()
+
+	bm := d2datautils.CreateBitMuncher(packet, 0)
+	totalBits := len(packet) * bitsPerByte
+
+	bm.SkipBits(binkAudioPacketHeader)
+
+	for bm.Offset() < totalBits {
+		a.decodeBlock(bm)
+		samples = a.appendSamples(samples)
+
+		if rest := bm.Offset() % binkAudioBlockAlign; rest != 0 {
+			bm.SkipBits(binkAudioBlockAlign - rest)
+		}
+	}
+
+	return samples, nil
+}
+
+// decodeBlock decodes the coefficients of a block and transforms them into samples
+func (a *binkAudioDecoder) decodeBlock(bm *d2datautils.BitMuncher) {
+	if a.useDCT {
+		bm.SkipBits(2) //nolint:gomnd // unused bits
+	}
+
+	for ch := 0; ch < a.channels; ch++ {
+		coeffs := a.coeffs[ch]
+
+		a.decodeCoefficients(bm, coeffs)
+
+		if a.useDCT {
+			coeffs[0] *= 2
+			a.inverseDCT(coeffs)
+		} else {
+			a.inverseRDFT(coeffs)
+		}
+	}
+
+	// the start of the block is blended with the end of the previous block
+	for ch := 0; ch < a.channels; ch++ {
+		count := float64(a.overlapLen * a.channels)
+
+		if !a.first {
+			for idx, j := 0, ch; idx < a.overlapLen; idx, j = idx+1, j+a.channels {
+				a.coeffs[ch][idx] = (a.previous[ch][idx]*(count-float64(j)) + a.coeffs[ch][idx]*float64(j)) / count
+			}
+		}
+
+		copy(a.previous[ch], a.coeffs[ch][a.frameLen-a.overlapLen:])
+	}
+
+	a.first = false
+}
+
+// decodeCoefficients reads the quantized coefficients of a channel
+func (a *binkAudioDecoder) decodeCoefficients(bm *d2datautils.BitMuncher, coeffs []float64) {
+	if a.revisionB {
+		coeffs[0] = float64(math.Float32frombits(bm.GetBits(32))) * a.root //nolint:gomnd // float32
+		coeffs[1] = float64(math.Float32frombits(bm.GetBits(32))) * a.root //nolint:gomnd // float32
+	} else {
+		coeffs[0] = readBinkAudioFloat(bm) * a.root
+		coeffs[1] = readBinkAudioFloat(bm) * a.root
+	}
+
+	quant := make([]float64, len(a.bands)-1)
+	for idx := range quant {
+		quant[idx] = a.quantTable[d2math.MinInt(int(bm.GetBits(binkAudioBandBits)), binkAudioQuantCount-1)]
+	}
+
+	runLengths := binkAudioRunLengths()
+	band, q := 0, quant[0]
+
+	for idx := 2; idx < a.frameLen; {
+		end := idx + binkAudioRunLengthB
+
+		if !a.revisionB {
+			end = idx + binkAudioCoefficientRun
+
+			if bm.GetBit() == 1 {
+				end = idx + runLengths[bm.GetBits(binkNibbleBits)]*binkAudioCoefficientRun
+			}
+		}
+
+		end = d2math.MinInt(end, a.frameLen)
+
+		width := int(bm.GetBits(binkAudioWidthBits))
+		if width == 0 {
+			for ; idx < end; idx++ {
+				coeffs[idx] = 0
+			}
+
+			for a.bands[band] < idx {
+				q = quant[band]
+				band++
+			}
+
+			continue
+		}
+
+		for ; idx < end; idx++ {
+			if a.bands[band] == idx {
+				q = quant[band]
+				band++
+			}
+
+			coeffs[idx] = 0
+
+			if coeff := float64(bm.GetBits(width)); coeff != 0 {
+				coeffs[idx] = q * coeff
+
+				if bm.GetBit() == 1 {
+					coeffs[idx] = -coeffs[idx]
+				}
+			}
+		}
+	}
+}
+
+// readBinkAudioFloat reads a float with a 5 bit exponent, a 23 bit mantissa and a sign bit
+func readBinkAudioFloat(bm *d2datautils.BitMuncher) float64 {
+	power := int(bm.GetBits(binkAudioFloatPower))
+	value := math.Ldexp(float64(bm.GetBits(binkAudioFloatMantissa)), power-binkAudioFloatMantissa)
+
+	if bm.GetBit() == 1 {
+		value = -value
+	}
+
+	return value
+}
+
+// inverseDCT transforms the coefficients with a DCT-III, in place
+func (a *binkAudioDecoder) inverseDCT(coeffs []float64) {
+	n := a.frameLen
+	z := a.transformed
+
+	for idx := range z {
+		z[idx] = 0
+	}
+
+	for idx := 0; idx < n; idx++ {
+		value := coeffs[idx]
+		if idx == 0 {
+			value /= 2
+		}
+
+		angle := -math.Pi * float64(idx) / float64(2*n)
+		z[idx] = complex(value*math.Cos(angle), value*math.Sin(angle))
+	}
+
+	a.transform.forward(z)
+
+	for idx := 0; idx < n; idx++ {
+		coeffs[idx] = real(z[idx]) * 2 / float64(n)
+	}
+}
+
+// inverseRDFT transforms the coefficients of a real DFT into samples, in place. The first two
+// coefficients are the real values of the lowest and highest frequency, the other pairs are
+// the real and imaginary parts of the frequencies between them.
+func (a *binkAudioDecoder) inverseRDFT(coeffs []float64) {
+	n := a.frameLen
+	z := a.transformed
+
+	for idx := range z {
+		z[idx] = 0
+	}
+
+	z[0] = complex(coeffs[0]/2, 0)
+	z[n/2] = complex(coeffs[1]/2, 0)
+
+	for k := 1; k < n/2; k++ {
+		z[k] = complex(coeffs[2*k], coeffs[2*k+1])
+	}
+
+	a.transform.forward(z)
+
+	for idx := 0; idx < n; idx++ {
+		coeffs[idx] = real(z[idx])
+	}
+}
+
+// appendSamples appends the samples of the last block which are not blended with the next one
+func (a *binkAudioDecoder) appendSamples(samples []float32) []float32 {
+	length := a.frameLen - a.overlapLen
+
+	if a.channels == 1 {
+		for _, value := range a.coeffs[0][:length] {
+			samples = append(samples, float32(value))
+		}
+
+		return samples
+	}
+
+	for idx := 0; idx < length; idx++ {
+		for ch := 0; ch < a.channels; ch++ {
+			samples = append(samples, float32(a.coeffs[ch][idx]))
+		}
+	}
+
+	return samples
+}
+
+// binkFFT is a radix 2 fast fourier transform
+type binkFFT struct {
+	size     int
+	twiddles []complex128
+	reversed []int
+}
+
+func newBinkFFT(size int) *binkFFT {
+	f := &binkFFT{
+		size:     size,
+		twiddles: make([]complex128, size/2), //nolint:gomnd // half of the unit circle
+		reversed: make([]int, size),
+	}
+
+	for idx := range f.twiddles {
+		angle := -2 * math.Pi * float64(idx) / float64(size)
+		f.twiddles[idx] = complex(math.Cos(angle), math.Sin(angle))
+	}
+
+	shift := uint(bits.UintSize - bits.Len(uint(size)) + 1)
+	for idx := range f.reversed {
+		f.reversed[idx] = int(bits.Reverse(uint(idx)) >> shift)
+	}
+
+	return f
+}
+
+// forward computes the discrete fourier transform with negative exponents, in place
+func (f *binkFFT) forward(data []complex128) {
+	for idx, rev := range f.reversed {
+		if idx < rev {
+			data[idx], data[rev] = data[rev], data[idx]
+		}
+	}
+
+	for half := 1; half < f.size; half <<= 1 {
+		step := f.size / (half * 2) //nolint:gomnd // butterflies of two halves
+
+		for start := 0; start < f.size; start += half * 2 {
+			for idx := 0; idx < half; idx++ {
+				odd := data[start+idx+half] * f.twiddles[idx*step]
+				data[start+idx+half] = data[start+idx] - odd
+				data[start+idx] += odd
+			}
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package d2video
+
+import (
+	"image"
+)
+
+// The IDCT and pixel operations of the bink video codec
+
+const (
+	binkIDCTA1        = 2896 // 1/sqrt(2) with 12 fraction bits
+	binkIDCTA2        = 2217
+	binkIDCTA3        = 3784
+	binkIDCTA4        = -5352
+	binkIDCTShift     = 11
+	binkIDCTRowShift  = 8
+	binkIDCTRowRound  = 0x7F
+	binkBlockRowShift = 3 // the row of a pixel index of a block
+	binkBlockColumn   = 7 // the mask of the column of a pixel index of a block
+)
+
+func binkIDCTMul(x, y int32) int32 {
+	return (x * y) >> binkIDCTShift
+}
+
+// binkIDCTTransform transforms the 8 values of a row or column, step is the distance between
+// the values. Rows are rounded and scaled down.
+func binkIDCTTransform(dst, src []int32, step int, row bool) {
+	s := func(idx int) int32 {
+		return src[idx*step]
+	}
+
+	a0 := s(0) + s(4)
+	a1 := s(0) - s(4)
+	a2 := s(2) + s(6)
+	a3 := binkIDCTMul(binkIDCTA1, s(2)-s(6))
+	a4 := s(5) + s(3)
+	a5 := s(5) - s(3)
+	a6 := s(1) + s(7)
+	a7 := s(1) - s(7)
+	b0 := a4 + a6
+	b1 := binkIDCTMul(binkIDCTA3, a5+a7)
+	b2 := binkIDCTMul(binkIDCTA4, a5) - b0 + b1
+	b3 := binkIDCTMul(binkIDCTA1, a6-a4) - b2
+	b4 := binkIDCTMul(binkIDCTA2, a7) + b3 - b1
+
+	values := [binkBlockSize]int32{
+		a0 + a2 + b0, a1 + a3 - a2 + b2, a1 - a3 + a2 + b3, a0 - a2 - b4,
+		a0 - a2 + b4, a1 - a3 + a2 - b3, a1 + a3 - a2 - b2, a0 + a2 - b0,
+	}
+
+	for idx, value := range values {
+		if row {
+			value = (value + binkIDCTRowRound) >> binkIDCTRowShift
+		}
+
+		dst[idx*step] = value
+	}
+}
+
+// binkIDCT transforms the coefficients of a block into pixel values, in place
+func binkIDCT(block *[binkBlockPixels]int32) {
+	var temp [binkBlockPixels]int32
+
+	for column := 0; column < binkBlockSize; column++ {
+		hasAC := false
+
+		for y := 1; y < binkBlockSize; y++ {
+			hasAC = hasAC || block[column+y*binkBlockSize] != 0
+		}
+
+		if !hasAC {
+			for y := 0; y < binkBlockSize; y++ {
+				temp[column+y*binkBlockSize] = block[column]
+			}
+
+			continue
+		}
+
+		binkIDCTTransform(temp[column:], block[column:], binkBlockSize, false)
+	}
+
+	for y := 0; y < binkBlockSize; y++ {
+		binkIDCTTransform(block[y*binkBlockSize:], temp[y*binkBlockSize:], 1, true)
+	}
+}
+
+// idctPut writes the pixels of a DCT block
+func idctPut(plane *binkPlane, dst int, block *[binkBlockPixels]int32) {
+	binkIDCT(block)
+
+	for idx, value := range block {
+		plane.pixels[plane.offset(dst, idx)] = clipUint8(value)
+	}
+}
+
+// idctAdd adds the values of a DCT block to the pixels of a block
+func idctAdd(plane *binkPlane, dst int, block *[binkBlockPixels]int32) {
+	binkIDCT(block)
+	addPixels(plane, dst, block)
+}
+
+// addPixels adds the values of a block to its pixels, like the reference decoder the pixels
+// wrap around instead of being clipped
+func addPixels(plane *binkPlane, dst int, block *[binkBlockPixels]int32) {
+	for idx, value := range block {
+		plane.pixels[plane.offset(dst, idx)] += byte(value)
+	}
+}
+
+// copyBlock copies an 8x8 block, the blocks may overlap
+func copyBlock(plane *binkPlane, dst int, src *binkPlane, ref int) {
+	var pixels [binkBlockPixels]byte
+
+	for idx := range pixels {
+		pixels[idx] = src.pixels[src.offset(ref, idx)]
+	}
+
+	for idx, value := range pixels {
+		plane.pixels[plane.offset(dst, idx)] = value
+	}
+}
+
+// fillBlock fills a square block with a color
+func fillBlock(plane *binkPlane, dst int, color byte, size int) {
+	for y := 0; y < size; y++ {
+		row := plane.pixels[dst+y*plane.stride:]
+
+		for x := 0; x < size; x++ {
+			row[x] = color
+		}
+	}
+}
+
+// offset returns the offset of a pixel of the 8x8 block at dst
+func (p *binkPlane) offset(dst, idx int) int {
+	return dst + idx&binkBlockColumn + (idx>>binkBlockRowShift)*p.stride
+}
+
+func clipUint8(value int32) byte {
+	switch {
+	case value < 0:
+		return 0
+	case value > 0xFF:
+		return 0xFF
+	}
+
+	return byte(value)
+}
+
+// toRGBA converts a picture to RGBA, with the BT.601 coefficients for limited range YUV
+func (p *binkPicture) toRGBA(img *image.RGBA, grayscale bool) {
+	luma, blue, red, alpha := p.planes[0], p.planes[1], p.planes[2], p.planes[binkAlphaPlane]
+	bounds := img.Bounds()
+
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			c := 298 * (int32(luma.pixels[x+y*luma.stride]) - 16) //nolint:gomnd // BT.601
+			d, e := int32(0), int32(0)
+
+			if !grayscale {
+				chroma := x>>1 + (y>>1)*blue.stride
+				d = int32(blue.pixels[chroma]) - 128 //nolint:gomnd // BT.601
+				e = int32(red.pixels[chroma]) - 128  //nolint:gomnd // BT.601
+			}
+
+			pixel := img.Pix[x*4+y*img.Stride:]                  //nolint:gomnd // RGBA
+			pixel[0] = clipUint8((c + 409*e + 128) >> 8)         //nolint:gomnd // BT.601
+			pixel[1] = clipUint8((c - 100*d - 208*e + 128) >> 8) //nolint:gomnd // BT.601
+			pixel[2] = clipUint8((c + 516*d + 128) >> 8)         //nolint:gomnd // BT.601
+			pixel[3] = 0xFF
+
+			if alpha != nil {
+				pixel[3] = alpha.pixels[x+y*alpha.stride]
+			}
+		}
+	}
+}
@@ -0,0 +1,314 @@
+package d2video
+
+import (
+	"math"
+)
+
+// The tables of the bink video codec. They are returned by functions, so the decoder can keep
+// its own copy instead of sharing global state.
+
+const (
+	binkTreeCount    = 16
+	binkTreeSymbols  = 16
+	binkPatternCount = 16
+	binkBlockPixels  = 64
+	binkQuantCount   = 16
+	binkQuantShift   = 18 // the scale factors have 30 fraction bits, the quantizers have 12
+)
+
+// binkTreeCodes returns the codes of the 16 huffman trees used by the bundles. The codes are
+// read from the least significant bit.
+func binkTreeCodes() [binkTreeCount][binkTreeSymbols]uint8 {
+	return [binkTreeCount][binkTreeSymbols]uint8{
+		{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0A, 0x0B, 0x0C, 0x0D, 0x0E, 0x0F},
+		{0x00, 0x01, 0x03, 0x05, 0x07, 0x09, 0x0B, 0x0D, 0x0F, 0x13, 0x15, 0x17, 0x19, 0x1B, 0x1D, 0x1F},
+		{0x00, 0x02, 0x01, 0x09, 0x05, 0x15, 0x0D, 0x1D, 0x03, 0x13, 0x0B, 0x1B, 0x07, 0x17, 0x0F, 0x1F},
+		{0x00, 0x02, 0x06, 0x01, 0x09, 0x05, 0x0D, 0x1D, 0x03, 0x13, 0x0B, 0x1B, 0x07, 0x17, 0x0F, 0x1F},
+		{0x00, 0x04, 0x02, 0x06, 0x01, 0x09, 0x05, 0x0D, 0x03, 0x13, 0x0B, 0x1B, 0x07, 0x17, 0x0F, 0x1F},
+		{0x00, 0x04, 0x02, 0x0A, 0x06, 0x0E, 0x01, 0x09, 0x05, 0x0D, 0x03, 0x0B, 0x07, 0x17, 0x0F, 0x1F},
+		{0x00, 0x02, 0x0A, 0x06, 0x0E, 0x01, 0x09, 0x05, 0x0D, 0x03, 0x0B, 0x1B, 0x07, 0x17, 0x0F, 0x1F},
+		{0x00, 0x01, 0x05, 0x03, 0x13, 0x0B, 0x1B, 0x3B, 0x07, 0x27, 0x17, 0x37, 0x0F, 0x2F, 0x1F, 0x3F},
+		{0x00, 0x01, 0x03, 0x13, 0x0B, 0x2B, 0x1B, 0x3B, 0x07, 0x27, 0x17, 0x37, 0x0F, 0x2F, 0x1F, 0x3F},
+		{0x00, 0x01, 0x05, 0x0D, 0x03, 0x13, 0x0B, 0x1B, 0x07, 0x27, 0x17, 0x37, 0x0F, 0x2F, 0x1F, 0x3F},
+		{0x00, 0x02, 0x01, 0x05, 0x0D, 0x03, 0x13, 0x0B, 0x1B, 0x07, 0x17, 0x37, 0x0F, 0x2F, 0x1F, 0x3F},
+		{0x00, 0x01, 0x09, 0x05, 0x0D, 0x03, 0x13, 0x0B, 0x1B, 0x07, 0x17, 0x37, 0x0F, 0x2F, 0x1F, 0x3F},
+		{0x00, 0x02, 0x01, 0x03, 0x13, 0x0B, 0x1B, 0x3B, 0x07, 0x27, 0x17, 0x37, 0x0F, 0x2F, 0x1F, 0x3F},
+		{0x00, 0x01, 0x05, 0x03, 0x07, 0x27, 0x17, 0x37, 0x0F, 0x4F, 0x2F, 0x6F, 0x1F, 0x5F, 0x3F, 0x7F},
+		{0x00, 0x01, 0x05, 0x03, 0x07, 0x17, 0x37, 0x77, 0x0F, 0x4F, 0x2F, 0x6F, 0x1F, 0x5F, 0x3F, 0x7F},
+		{0x00, 0x02, 0x01, 0x05, 0x03, 0x07, 0x27, 0x17, 0x37, 0x0F, 0x2F, 0x6F, 0x1F, 0x5F, 0x3F, 0x7F},
+	}
+}
+
+// binkTreeLengths returns the lengths (in bits) of the codes returned by binkTreeCodes
+func binkTreeLengths() [binkTreeCount][binkTreeSymbols]uint8 {
+	return [binkTreeCount][binkTreeSymbols]uint8{
+		{4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4},
+		{1, 4, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5},
+		{2, 2, 4, 4, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5},
+		{2, 3, 3, 4, 4, 4, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5},
+		{3, 3, 3, 3, 4, 4, 4, 4, 5, 5, 5, 5, 5, 5, 5, 5},
+		{3, 3, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 5, 5, 5, 5},
+		{2, 4, 4, 4, 4, 4, 4, 4, 4, 4, 5, 5, 5, 5, 5, 5},
+		{1, 3, 3, 5, 5, 5, 6, 6, 6, 6, 6, 6, 6, 6, 6, 6},
+		{1, 2, 5, 5, 6, 6, 6, 6, 6, 6, 6, 6, 6, 6, 6, 6},
+		{1, 3, 4, 4, 5, 5, 5, 5, 6, 6, 6, 6, 6, 6, 6, 6},
+		{2, 2, 3, 4, 4, 5, 5, 5, 5, 5, 6, 6, 6, 6, 6, 6},
+		{1, 4, 4, 4, 4, 5, 5, 5, 5, 5, 6, 6, 6, 6, 6, 6},
+		{2, 2, 2, 5, 5, 5, 6, 6, 6, 6, 6, 6, 6, 6, 6, 6},
+		{1, 3, 3, 3, 6, 6, 6, 6, 7, 7, 7, 7, 7, 7, 7, 7},
+		{1, 3, 3, 3, 5, 6, 7, 7, 7, 7, 7, 7, 7, 7, 7, 7},
+		{2, 2, 3, 3, 3, 6, 6, 6, 6, 6, 7, 7, 7, 7, 7, 7},
+	}
+}
+
+// binkScan returns the order in which the coefficients of a DCT block are stored
+func binkScan() [binkBlockPixels]uint8 {
+	return [binkBlockPixels]uint8{
+		0, 1, 8, 9, 2, 3, 10, 11,
+		4, 5, 12, 13, 6, 7, 14, 15,
+		20, 21, 28, 29, 22, 23, 30, 31,
+		16, 17, 24, 25, 32, 33, 40, 41,
+		34, 35, 42, 43, 48, 49, 56, 57,
+		50, 51, 58, 59, 18, 19, 26, 27,
+		36, 37, 44, 45, 38, 39, 46, 47,
+		52, 53, 60, 61, 54, 55, 62, 63,
+	}
+}
+
+// binkPatterns returns the orders in which the pixels of a run block are filled
+func binkPatterns() [binkPatternCount][binkBlockPixels]uint8 {
+	return [binkPatternCount][binkBlockPixels]uint8{
+		{
+			0x00, 0x08, 0x10, 0x18, 0x20, 0x28, 0x30, 0x38,
+			0x39, 0x31, 0x29, 0x21, 0x19, 0x11, 0x09, 0x01,
+			0x02, 0x0A, 0x12, 0x1A, 0x22, 0x2A, 0x32, 0x3A,
+			0x3B, 0x33, 0x2B, 0x23, 0x1B, 0x13, 0x0B, 0x03,
+			0x04, 0x0C, 0x14, 0x1C, 0x24, 0x2C, 0x34, 0x3C,
+			0x3D, 0x35, 0x2D, 0x25, 0x1D, 0x15, 0x0D, 0x05,
+			0x06, 0x0E, 0x16, 0x1E, 0x26, 0x2E, 0x36, 0x3E,
+			0x3F, 0x37, 0x2F, 0x27, 0x1F, 0x17, 0x0F, 0x07,
+		},
+		{
+			0x3B, 0x3A, 0x39, 0x38, 0x30, 0x31, 0x32, 0x33,
+			0x2B, 0x2A, 0x29, 0x28, 0x20, 0x21, 0x22, 0x23,
+			0x1B, 0x1A, 0x19, 0x18, 0x10, 0x11, 0x12, 0x13,
+			0x0B, 0x0A, 0x09, 0x08, 0x00, 0x01, 0x02, 0x03,
+			0x04, 0x05, 0x06, 0x07, 0x0F, 0x0E, 0x0D, 0x0C,
+			0x14, 0x15, 0x16, 0x17, 0x1F, 0x1E, 0x1D, 0x1C,
+			0x24, 0x25, 0x26, 0x27, 0x2F, 0x2E, 0x2D, 0x2C,
+			0x34, 0x35, 0x36, 0x37, 0x3F, 0x3E, 0x3D, 0x3C,
+		},
+		{
+			0x19, 0x11, 0x12, 0x1A, 0x1B, 0x13, 0x0B, 0x03,
+			0x02, 0x0A, 0x09, 0x01, 0x00, 0x08, 0x10, 0x18,
+			0x20, 0x28, 0x30, 0x38, 0x39, 0x31, 0x29, 0x2A,
+			0x32, 0x3A, 0x3B, 0x33, 0x2B, 0x23, 0x22, 0x21,
+			0x1D, 0x15, 0x16, 0x1E, 0x1F, 0x17, 0x0F, 0x07,
+			0x06, 0x0E, 0x0D, 0x05, 0x04, 0x0C, 0x14, 0x1C,
+			0x24, 0x2C, 0x34, 0x3C, 0x3D, 0x35, 0x2D, 0x2E,
+			0x36, 0x3E, 0x3F, 0x37, 0x2F, 0x27, 0x26, 0x25,
+		},
+		{
+			0x03, 0x0B, 0x02, 0x0A, 0x01, 0x09, 0x00, 0x08,
+			0x10, 0x18, 0x11, 0x19, 0x12, 0x1A, 0x13, 0x1B,
+			0x23, 0x2B, 0x22, 0x2A, 0x21, 0x29, 0x20, 0x28,
+			0x30, 0x38, 0x31, 0x39, 0x32, 0x3A, 0x33, 0x3B,
+			0x3C, 0x34, 0x3D, 0x35, 0x3E, 0x36, 0x3F, 0x37,
+			0x2F, 0x27, 0x2E, 0x26, 0x2D, 0x25, 0x2C, 0x24,
+			0x1C, 0x14, 0x1D, 0x15, 0x1E, 0x16, 0x1F, 0x17,
+			0x0F, 0x07, 0x0E, 0x06, 0x0D, 0x05, 0x0C, 0x04,
+		},
+		{
+			0x18, 0x19, 0x10, 0x11, 0x08, 0x09, 0x00, 0x01,
+			0x02, 0x03, 0x0A, 0x0B, 0x12, 0x13, 0x1A, 0x1B,
+			0x1C, 0x1D, 0x14, 0x15, 0x0C, 0x0D, 0x04, 0x05,
+			0x06, 0x07, 0x0E, 0x0F, 0x16, 0x17, 0x1E, 0x1F,
+			0x26, 0x27, 0x2E, 0x2F, 0x36, 0x37, 0x3E, 0x3F,
+			0x3C, 0x3D, 0x34, 0x35, 0x2C, 0x2D, 0x24, 0x25,
+			0x22, 0x23, 0x2A, 0x2B, 0x32, 0x33, 0x3A, 0x3B,
+			0x38, 0x39, 0x30, 0x31, 0x28, 0x29, 0x20, 0x21,
+		},
+		{
+			0x00, 0x08, 0x01, 0x09, 0x02, 0x0A, 0x03, 0x0B,
+			0x13, 0x1B, 0x12, 0x1A, 0x11, 0x19, 0x10, 0x18,
+			0x20, 0x28, 0x21, 0x29, 0x22, 0x2A, 0x23, 0x2B,
+			0x33, 0x3B, 0x32, 0x3A, 0x31, 0x39, 0x30, 0x38,
+			0x3C, 0x34, 0x3D, 0x35, 0x3E, 0x36, 0x3F, 0x37,
+			0x2F, 0x27, 0x2E, 0x26, 0x2D, 0x25, 0x2C, 0x24,
+			0x1F, 0x17, 0x1E, 0x16, 0x1D, 0x15, 0x1C, 0x14,
+			0x0C, 0x04, 0x0D, 0x05, 0x0E, 0x06, 0x0F, 0x07,
+		},
+		{
+			0x00, 0x08, 0x10, 0x18, 0x19, 0x1A, 0x1B, 0x13,
+			0x0B, 0x03, 0x02, 0x01, 0x09, 0x11, 0x12, 0x0A,
+			0x04, 0x0C, 0x14, 0x1C, 0x1D, 0x1E, 0x1F, 0x17,
+			0x0F, 0x07, 0x06, 0x05, 0x0D, 0x15, 0x16, 0x0E,
+			0x24, 0x2C, 0x34, 0x3C, 0x3D, 0x3E, 0x3F, 0x37,
+			0x2F, 0x27, 0x26, 0x25, 0x2D, 0x35, 0x36, 0x2E,
+			0x20, 0x28, 0x30, 0x38, 0x39, 0x3A, 0x3B, 0x33,
+			0x2B, 0x23, 0x22, 0x21, 0x29, 0x31, 0x32, 0x2A,
+		},
+		{
+			0x00, 0x08, 0x09, 0x01, 0x02, 0x03, 0x0B, 0x0A,
+			0x13, 0x1B, 0x1A, 0x12, 0x11, 0x10, 0x18, 0x19,
+			0x21, 0x20, 0x28, 0x29, 0x2A, 0x22, 0x23, 0x2B,
+			0x33, 0x3B, 0x3A, 0x32, 0x31, 0x39, 0x38, 0x30,
+			0x34, 0x3C, 0x3D, 0x35, 0x36, 0x3E, 0x3F, 0x37,
+			0x2F, 0x27, 0x26, 0x2E, 0x2D, 0x2C, 0x24, 0x25,
+			0x1D, 0x1C, 0x14, 0x15, 0x16, 0x1E, 0x1F, 0x17,
+			0x0E, 0x0F, 0x07, 0x06, 0x05, 0x0D, 0x0C, 0x04,
+		},
+		{
+			0x18, 0x10, 0x08, 0x00, 0x01, 0x02, 0x03, 0x0B,
+			0x13, 0x1B, 0x1A, 0x19, 0x11, 0x0A, 0x09, 0x12,
+			0x1C, 0x14, 0x0C, 0x04, 0x05, 0x06, 0x07, 0x0F,
+			0x17, 0x1F, 0x1E, 0x1D, 0x15, 0x0E, 0x0D, 0x16,
+			0x3C, 0x34, 0x2C, 0x24, 0x25, 0x26, 0x27, 0x2F,
+			0x37, 0x3F, 0x3E, 0x3D, 0x35, 0x2E, 0x2D, 0x36,
+			0x38, 0x30, 0x28, 0x20, 0x21, 0x22, 0x23, 0x2B,
+			0x33, 0x3B, 0x3A, 0x39, 0x31, 0x2A, 0x29, 0x32,
+		},
+		{
+			0x00, 0x08, 0x09, 0x01, 0x02, 0x0A, 0x12, 0x11,
+			0x10, 0x18, 0x19, 0x1A, 0x1B, 0x13, 0x0B, 0x03,
+			0x04, 0x0C, 0x0D, 0x05, 0x06, 0x0E, 0x16, 0x15,
+			0x14, 0x1C, 0x1D, 0x1E, 0x1F, 0x17, 0x0F, 0x07,
+			0x20, 0x28, 0x29, 0x21, 0x22, 0x2A, 0x32, 0x31,
+			0x30, 0x38, 0x39, 0x3A, 0x3B, 0x33, 0x2B, 0x23,
+			0x24, 0x2C, 0x2D, 0x25, 0x26, 0x2E, 0x36, 0x35,
+			0x34, 0x3C, 0x3D, 0x3E, 0x3F, 0x37, 0x2F, 0x27,
+		},
+		{
+			0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07,
+			0x0F, 0x0E, 0x0D, 0x0C, 0x0B, 0x0A, 0x09, 0x08,
+			0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17,
+			0x1F, 0x1E, 0x1D, 0x1C, 0x1B, 0x1A, 0x19, 0x18,
+			0x20, 0x21, 0x22, 0x23, 0x24, 0x25, 0x26, 0x27,
+			0x2F, 0x2E, 0x2D, 0x2C, 0x2B, 0x2A, 0x29, 0x28,
+			0x30, 0x31, 0x32, 0x33, 0x34, 0x35, 0x36, 0x37,
+			0x3F, 0x3E, 0x3D, 0x3C, 0x3B, 0x3A, 0x39, 0x38,
+		},
+		{
+			0x18, 0x19, 0x1A, 0x1B, 0x13, 0x12, 0x11, 0x10,
+			0x08, 0x09, 0x0A, 0x0B, 0x03, 0x02, 0x01, 0x00,
+			0x04, 0x05, 0x06, 0x07, 0x0F, 0x0E, 0x0D, 0x0C,
+			0x14, 0x15, 0x16, 0x17, 0x1F, 0x1E, 0x1D, 0x1C,
+			0x24, 0x25, 0x26, 0x27, 0x2F, 0x2E, 0x2D, 0x2C,
+			0x34, 0x35, 0x36, 0x37, 0x3F, 0x3E, 0x3D, 0x3C,
+			0x38, 0x39, 0x3A, 0x3B, 0x33, 0x32, 0x31, 0x30,
+			0x28, 0x29, 0x2A, 0x2B, 0x23, 0x22, 0x21, 0x20,
+		},
+		{
+			0x00, 0x08, 0x09, 0x01, 0x02, 0x0A, 0x0B, 0x03,
+			0x04, 0x0C, 0x0D, 0x05, 0x06, 0x0E, 0x0F, 0x07,
+			0x17, 0x1F, 0x1E, 0x16, 0x15, 0x1D, 0x1C, 0x14,
+			0x13, 0x1B, 0x1A, 0x12, 0x11, 0x19, 0x18, 0x10,
+			0x20, 0x28, 0x29, 0x21, 0x22, 0x2A, 0x2B, 0x23,
+			0x24, 0x2C, 0x2D, 0x25, 0x26, 0x2E, 0x2F, 0x27,
+			0x37, 0x3F, 0x3E, 0x36, 0x35, 0x3D, 0x3C, 0x34,
+			0x33, 0x3B, 0x3A, 0x32, 0x31, 0x39, 0x38, 0x30,
+		},
+		{
+			0x00, 0x08, 0x10, 0x18, 0x20, 0x28, 0x30, 0x38,
+			0x39, 0x3A, 0x3B, 0x3C, 0x3D, 0x3E, 0x3F, 0x37,
+			0x2F, 0x27, 0x1F, 0x17, 0x0F, 0x07, 0x06, 0x05,
+			0x04, 0x03, 0x02, 0x01, 0x09, 0x11, 0x19, 0x21,
+			0x29, 0x31, 0x32, 0x33, 0x34, 0x35, 0x36, 0x2E,
+			0x26, 0x1E, 0x16, 0x0E, 0x0D, 0x0C, 0x0B, 0x0A,
+			0x12, 0x1A, 0x22, 0x2A, 0x2B, 0x2C, 0x2D, 0x25,
+			0x1D, 0x15, 0x14, 0x13, 0x1B, 0x23, 0x24, 0x1C,
+		},
+		{
+			0x00, 0x01, 0x08, 0x10, 0x09, 0x02, 0x03, 0x0A,
+			0x11, 0x18, 0x20, 0x19, 0x12, 0x0B, 0x04, 0x05,
+			0x0C, 0x13, 0x1A, 0x21, 0x28, 0x30, 0x29, 0x22,
+			0x1B, 0x14, 0x0D, 0x06, 0x07, 0x0E, 0x15, 0x1C,
+			0x23, 0x2A, 0x31, 0x38, 0x39, 0x32, 0x2B, 0x24,
+			0x1D, 0x16, 0x0F, 0x17, 0x1E, 0x25, 0x2C, 0x33,
+			0x3A, 0x3B, 0x34, 0x2D, 0x26, 0x1F, 0x27, 0x2E,
+			0x35, 0x3C, 0x3D, 0x36, 0x2F, 0x37, 0x3E, 0x3F,
+		},
+		{
+			0x1C, 0x24, 0x23, 0x1B, 0x13, 0x14, 0x15, 0x1D,
+			0x25, 0x2D, 0x2C, 0x2B, 0x2A, 0x22, 0x1A, 0x12,
+			0x0A, 0x0B, 0x0C, 0x0D, 0x0E, 0x16, 0x1E, 0x26,
+			0x2E, 0x36, 0x35, 0x34, 0x33, 0x32, 0x31, 0x29,
+			0x21, 0x19, 0x11, 0x09, 0x01, 0x02, 0x03, 0x04,
+			0x05, 0x06, 0x07, 0x0F, 0x17, 0x1F, 0x27, 0x2F,
+			0x37, 0x3F, 0x3E, 0x3D, 0x3C, 0x3B, 0x3A, 0x39,
+			0x38, 0x30, 0x28, 0x20, 0x18, 0x10, 0x08, 0x00,
+		},
+	}
+}
+
+// binkIntraSeed returns the quantizer matrix of intra blocks, in natural order
+func binkIntraSeed() [binkBlockPixels]int64 {
+	return [binkBlockPixels]int64{
+		16, 16, 16, 19, 16, 19, 22, 22,
+		22, 22, 26, 24, 26, 22, 22, 27,
+		27, 27, 26, 26, 26, 29, 29, 29,
+		27, 27, 27, 26, 34, 34, 34, 29,
+		29, 29, 29, 27, 37, 34, 32, 32,
+		29, 29, 29, 27, 37, 37, 38, 37,
+		34, 32, 32, 29, 40, 40, 40, 38,
+		37, 37, 35, 35, 46, 46, 46, 43,
+	}
+}
+
+// binkInterSeed returns the quantizer matrix of inter blocks, in natural order
+func binkInterSeed() [binkBlockPixels]int64 {
+	return [binkBlockPixels]int64{
+		16, 17, 17, 18, 18, 18, 19, 19,
+		19, 19, 20, 20, 20, 20, 20, 21,
+		21, 21, 21, 21, 21, 22, 22, 22,
+		22, 22, 22, 22, 23, 23, 23, 23,
+		23, 23, 23, 23, 24, 24, 24, 25,
+		24, 24, 24, 25, 26, 26, 26, 26,
+		25, 27, 27, 27, 27, 27, 28, 28,
+		28, 28, 30, 30, 30, 31, 31, 33,
+	}
+}
+
+// binkQuantizers returns the 16 quantizer tables for a quantizer matrix, in scan order. The
+// tables are scaled with the factors of the AAN IDCT, each table uses a larger step size.
+func binkQuantizers(seed [binkBlockPixels]int64) [binkQuantCount][binkBlockPixels]int32 {
+	numerators := [binkQuantCount]int64{1, 4, 5, 2, 7, 8, 3, 7, 4, 9, 5, 6, 7, 8, 9, 10}
+	denominators := [binkQuantCount]int64{1, 3, 3, 1, 3, 3, 1, 2, 1, 2, 1, 1, 1, 1, 1, 1}
+
+	var aan [8]float64
+
+	for i := range aan {
+		aan[i] = 1
+		if i > 0 {
+			aan[i] = math.Sqrt2 * math.Cos(float64(i)*math.Pi/16) //nolint:gomnd // AAN scale factors
+		}
+	}
+
+	scan := binkScan()
+
+	var result [binkQuantCount][binkBlockPixels]int32
+
+	for q := range result {
+		for k, pos := range scan {
+			scale := int64(float64(1<<30) * aan[pos>>3] * aan[pos&7]) //nolint:gomnd // 30 fraction bits
+
+			result[q][k] = int32(seed[pos] * scale * numerators[q] / (denominators[q] << binkQuantShift))
+		}
+	}
+
+	return result
+}
+
+// binkbRunBits returns the number of bits of the run lengths of 'b' revision run blocks, by
+// the number of pixels which are already filled
+func binkbRunBits() [binkBlockPixels]int {
+	var result [binkBlockPixels]int
+
+	for i := range result {
+		for 1<<uint(result[i]) < binkBlockPixels-i {
+			result[i]++
+		}
+	}
+
+	return result
+}
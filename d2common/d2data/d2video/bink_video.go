@@ -0,0 +1,1029 @@
+package d2video
+
+import (
+	"errors"
+	"math/bits"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2datautils"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math"
+)
+
+// Block types of the revisions after 'b'
+const (
+	binkBlockSkip = iota
+	binkBlockScaled
+	binkBlockMotion
+	binkBlockRun
+	binkBlockResidue
+	binkBlockIntra
+	binkBlockFill
+	binkBlockInter
+	binkBlockPattern
+	binkBlockRaw
+)
+
+// Bundles of the revisions after 'b'
+const (
+	binkSrcBlockTypes = iota
+	binkSrcSubBlockTypes
+	binkSrcColors
+	binkSrcPattern
+	binkSrcXOff
+	binkSrcYOff
+	binkSrcIntraDC
+	binkSrcInterDC
+	binkSrcRun
+	binkSrcCount
+)
+
+const (
+	binkBlockSize    = 8
+	binkMacroBlock   = 16
+	binkPlaneCount   = 4 // Y, U, V and alpha
+	binkAlphaPlane   = 3
+	binkMaxTreeBits  = 7
+	binkNibbleBits   = 4
+	binkDCStartBits  = 11
+	binkResidueBits  = 7
+	binkRunSymbols   = 12 // block type symbols from 12 up repeat the last block type
+	binkPlaneAlign   = 32
+	binkBundleMinLen = 511
+	binkColorsPerRow = 64
+	binkRunsPerRow   = 48
+	binkDCMin        = -32768
+	binkDCMax        = 32767
+	binkRevisionB    = 'b'
+	binkRevisionH    = 'h'
+	binkRevisionI    = 'i'
+	bitsPerByte      = 8
+)
+
+var errBinkInvalidData = errors.New("invalid bink video data")
+
+// binkTree is a huffman tree, it maps the symbols of one of the static trees to values
+type binkTree struct {
+	vlc     int
+	symbols [binkTreeSymbols]uint8
+}
+
+// binkBundle is a stream of values which are used by the blocks of a plane. The values are
+// decoded row by row, when they are needed.
+type binkBundle struct {
+	length   int // number of bits of the number of values to decode
+	tree     binkTree
+	data     []int
+	decoded  int // index of the next value to decode, or -1 if all values of the plane are decoded
+	position int // index of the next value to use
+}
+
+// binkPlane is a plane of a picture, with a size which is a multiple of 16 pixels
+type binkPlane struct {
+	pixels []byte
+	stride int
+}
+
+// binkPicture is a decoded frame in YUV 4:2:0 format, with an optional alpha plane
+type binkPicture struct {
+	planes [binkPlaneCount]*binkPlane
+}
+
+// binkVideoDecoder decodes the video planes of the frames of a bink video
+type binkVideoDecoder struct {
+	revision byte
+	width    int
+	height   int
+	hasAlpha bool
+	frameNum int
+
+	bundles    [binkbSrcCount]binkBundle
+	colorHigh  [binkTreeSymbols]binkTree
+	lastColor  int
+	vlcLookup  [binkTreeCount][binkMaxTreeBits + 1][1 << binkMaxTreeBits]int8
+	scan       [binkBlockPixels]uint8
+	patterns   [binkPatternCount][binkBlockPixels]uint8
+	intraQuant [binkQuantCount][binkBlockPixels]int32
+	interQuant [binkQuantCount][binkBlockPixels]int32
+	runBits    [binkBlockPixels]int
+
+	current, last *binkPicture
+}
+
+func newBinkVideoDecoder(revision byte, width, height int, hasAlpha bool) *binkVideoDecoder {
+	d := &binkVideoDecoder{
+		revision:   revision,
+		width:      width,
+		height:     height,
+		hasAlpha:   hasAlpha,
+		scan:       binkScan(),
+		patterns:   binkPatterns(),
+		intraQuant: binkQuantizers(binkIntraSeed()),
+		interQuant: binkQuantizers(binkInterSeed()),
+		runBits:    binkbRunBits(),
+	}
+
+	codes, lengths := binkTreeCodes(), binkTreeLengths()
+
+	for tree := range d.vlcLookup {
+		for length := range d.vlcLookup[tree] {
+			for code := range d.vlcLookup[tree][length] {
+				d.vlcLookup[tree][length][code] = -1
+			}
+		}
+
+		for symbol := 0; symbol < binkTreeSymbols; symbol++ {
+			d.vlcLookup[tree][lengths[tree][symbol]][codes[tree][symbol]] = int8(symbol)
+		}
+	}
+
+	blocks := ((width + binkBlockSize - 1) / binkBlockSize) * ((height + binkBlockSize - 1) / binkBlockSize)
+	for idx := range d.bundles {
+		d.bundles[idx].data = make([]int, blocks*binkBlockPixels)
+	}
+
+	d.current = d.newPicture()
+
+	if revision > binkRevisionB {
+		d.last = d.newPicture()
+	} else {
+		// the 'b' revision decodes every frame into the previous one
+		d.last = d.current
+	}
+
+	return d
+}
+
+// newPicture allocates the planes of a picture, the planes are padded to a multiple of 16
+// pixels, so scaled blocks at the right and bottom border fit
+func (d *binkVideoDecoder) newPicture() *binkPicture {
+	picture := &binkPicture{}
+
+	for idx := range picture.planes {
+		if idx == binkAlphaPlane && !d.hasAlpha {
+			continue
+		}
+
+		bw, bh := d.blockCounts(idx == 1 || idx == 2)
+		stride := alignInt(bw, 2) * binkBlockSize //nolint:gomnd // two blocks per scaled block
+
+		picture.planes[idx] = &binkPlane{
+			pixels: make([]byte, stride*alignInt(bh, 2)*binkBlockSize), //nolint:gomnd // see above
+			stride: stride,
+		}
+	}
+
+	return picture
+}
+
+// blockCounts returns the number of 8x8 blocks in a row and column of a plane
+func (d *binkVideoDecoder) blockCounts(chroma bool) (bw, bh int) {
+	if chroma {
+		return (d.width + binkMacroBlock - 1) / binkMacroBlock, (d.height + binkMacroBlock - 1) / binkMacroBlock
+	}
+
+	return (d.width + binkBlockSize - 1) / binkBlockSize, (d.height + binkBlockSize - 1) / binkBlockSize
+}
+
+// decodeFrame decodes the video packet of a frame, the result is returned by picture
+func (d *binkVideoDecoder) decodeFrame(packet []byte) (err error) {
+	defer func() {
+		// the bit reader and the bundles panic when the packet ends before the planes are decoded
+		if r := recover(); r != nil {
+			err = errBinkInvalidData
+		}
+	}()
+
+	bm := d2datautils.CreateBitMuncher(packet, 0)
+	totalBits := len(packet) * bitsPerByte
+
+	if d.hasAlpha {
+		if d.revision >= binkRevisionI {
+			bm.SkipBits(binkPlaneAlign)
+		}
+
+		if err := d.decodePlane(bm, binkAlphaPlane, false); err != nil {
+			return err
+		}
+	}
+
+	if d.revision >= binkRevisionI {
+		bm.SkipBits(binkPlaneAlign)
+	}
+
+	d.frameNum++
+
+	for plane := 0; plane < 3; plane++ {
+		planeIdx := plane
+
+		// the chroma planes are stored as V, U from revision 'h' on
+		if plane != 0 && d.revision >= binkRevisionH {
+			planeIdx ^= 3
+		}
+
+		if d.revision > binkRevisionB {
+			err = d.decodePlane(bm, planeIdx, plane != 0)
+		} else {
+			err = d.decodePlaneB(bm, planeIdx, d.frameNum == 1, plane != 0)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if bm.Offset() >= totalBits {
+			break
+		}
+	}
+
+	if d.revision > binkRevisionB {
+		d.current, d.last = d.last, d.current
+	}
+
+	return nil
+}
+
+// picture returns the last decoded picture
+func (d *binkVideoDecoder) picture() *binkPicture {
+	return d.last
+}
+
+// initLengths sets the number of bits which store the number of values of the bundles
+func (d *binkVideoDecoder) initLengths(width, bw int) {
+	width = alignInt(width, binkBlockSize)
+
+	length := func(count int) int {
+		return bits.Len(uint(count + binkBundleMinLen))
+	}
+
+	blocks := width / binkBlockSize
+
+	d.bundles[binkSrcBlockTypes].length = length(blocks)
+	d.bundles[binkSrcSubBlockTypes].length = length(width / binkMacroBlock)
+	d.bundles[binkSrcColors].length = length(bw * binkColorsPerRow)
+	d.bundles[binkSrcIntraDC].length = length(blocks)
+	d.bundles[binkSrcInterDC].length = length(blocks)
+	d.bundles[binkSrcXOff].length = length(blocks)
+	d.bundles[binkSrcYOff].length = length(blocks)
+	d.bundles[binkSrcPattern].length = length(bw * binkBlockSize)
+	d.bundles[binkSrcRun].length = length(bw * binkRunsPerRow)
+}
+
+// readTree reads the mapping of the symbols of a huffman tree
+func (d *binkVideoDecoder) readTree(bm *d2datautils.BitMuncher, tree *binkTree) {
+	tree.vlc = int(bm.GetBits(binkNibbleBits))
+
+	if tree.vlc == 0 {
+		for idx := range tree.symbols {
+			tree.symbols[idx] = uint8(idx)
+		}
+
+		return
+	}
+
+	if bm.GetBit() == 1 {
+		// the first symbols are listed, the others follow in ascending order
+		var listed [binkTreeSymbols]bool
+
+		length := int(bm.GetBits(3)) //nolint:gomnd // binary data
+
+		for idx := 0; idx <= length; idx++ {
+			tree.symbols[idx] = uint8(bm.GetBits(binkNibbleBits))
+			listed[tree.symbols[idx]] = true
+		}
+
+		for symbol := 0; symbol < binkTreeSymbols && length < binkTreeSymbols-1; symbol++ {
+			if !listed[symbol] {
+				length++
+				tree.symbols[length] = uint8(symbol)
+			}
+		}
+
+		return
+	}
+
+	// the symbols are shuffled with the steps of a merge sort
+	steps := int(bm.GetBits(2)) //nolint:gomnd // binary data
+	in, out := make([]uint8, binkTreeSymbols), make([]uint8, binkTreeSymbols)
+
+	for idx := range in {
+		in[idx] = uint8(idx)
+	}
+
+	for step := 0; step <= steps; step++ {
+		size := 1 << uint(step)
+
+		for start := 0; start < binkTreeSymbols; start += size << 1 {
+			binkMerge(bm, out[start:], in[start:], size)
+		}
+
+		in, out = out, in
+	}
+
+	copy(tree.symbols[:], in)
+}
+
+func binkMerge(bm *d2datautils.BitMuncher, dst, src []uint8, size int) {
+	first, second := src[:size], src[size:size*2]
+
+	for len(first) > 0 && len(second) > 0 {
+		if bm.GetBit() == 0 {
+			dst[0], first = first[0], first[1:]
+		} else {
+			dst[0], second = second[0], second[1:]
+		}
+
+		dst = dst[1:]
+	}
+
+	dst = dst[copy(dst, first):]
+	copy(dst, second)
+}
+
+// readHuffman reads a symbol with a huffman tree
+func (d *binkVideoDecoder) readHuffman(bm *d2datautils.BitMuncher, tree *binkTree) int {
+	lookup := &d.vlcLookup[tree.vlc]
+	code := 0
+
+	for length := 1; length <= binkMaxTreeBits; length++ {
+		code |= int(bm.GetBit()) << uint(length-1)
+
+		if symbol := lookup[length][code]; symbol >= 0 {
+			return int(tree.symbols[symbol])
+		}
+	}
+
+	return 0
+}
+
+// readBundle starts reading the values of a bundle for a plane
+func (d *binkVideoDecoder) readBundle(bm *d2datautils.BitMuncher, bundleNum int) {
+	if bundleNum == binkSrcColors {
+		for idx := range d.colorHigh {
+			d.readTree(bm, &d.colorHigh[idx])
+		}
+
+		d.lastColor = 0
+	}
+
+	if bundleNum != binkSrcIntraDC && bundleNum != binkSrcInterDC {
+		d.readTree(bm, &d.bundles[bundleNum].tree)
+	}
+
+	d.bundles[bundleNum].decoded = 0
+	d.bundles[bundleNum].position = 0
+}
+
+// readCount returns the number of values of a bundle which are decoded next. It returns 0 if
+// values which were decoded before are not used yet, or if all values are decoded.
+func (b *binkBundle) readCount(bm *d2datautils.BitMuncher) (int, error) {
+	if b.decoded < 0 || b.decoded > b.position {
+		return 0, nil
+	}
+
+	count := int(bm.GetBits(b.length))
+	if count == 0 {
+		b.decoded = -1
+		return 0, nil
+	}
+
+	if b.decoded+count > len(b.data) {
+		return 0, errBinkInvalidData
+	}
+
+	return count, nil
+}
+
+// value returns the next value of a bundle
+func (b *binkBundle) value() int {
+	value := b.data[b.position]
+	b.position++
+
+	return value
+}
+
+func (b *binkBundle) fill(value, count int) {
+	for end := b.decoded + count; b.decoded < end; b.decoded++ {
+		b.data[b.decoded] = value
+	}
+}
+
+// readBundles reads the values of the bundles which are used by the next row of blocks
+func (d *binkVideoDecoder) readBundles(bm *d2datautils.BitMuncher) error {
+	readers := []func() error{
+		func() error { return d.readBlockTypes(bm, &d.bundles[binkSrcBlockTypes]) },
+		func() error { return d.readBlockTypes(bm, &d.bundles[binkSrcSubBlockTypes]) },
+		func() error { return d.readColors(bm) },
+		func() error { return d.readPatterns(bm) },
+		func() error { return d.readMotionValues(bm, &d.bundles[binkSrcXOff]) },
+		func() error { return d.readMotionValues(bm, &d.bundles[binkSrcYOff]) },
+		func() error { return readDCs(bm, &d.bundles[binkSrcIntraDC], false) },
+		func() error { return readDCs(bm, &d.bundles[binkSrcInterDC], true) },
+		func() error { return d.readRuns(bm) },
+	}
+
+	for _, read := range readers {
+		if err := read(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *binkVideoDecoder) readRuns(bm *d2datautils.BitMuncher) error {
+	b := &d.bundles[binkSrcRun]
+
+	count, err := b.readCount(bm)
+	if count == 0 {
+		return err
+	}
+
+	if bm.GetBit() == 1 {
+		b.fill(int(bm.GetBits(binkNibbleBits)), count)
+		return nil
+	}
+
+	for end := b.decoded + count; b.decoded < end; b.decoded++ {
+		b.data[b.decoded] = d.readHuffman(bm, &b.tree)
+	}
+
+	return nil
+}
+
+func (d *binkVideoDecoder) readMotionValues(bm *d2datautils.BitMuncher, b *binkBundle) error {
+	count, err := b.readCount(bm)
+	if count == 0 {
+		return err
+	}
+
+	if bm.GetBit() == 1 {
+		b.fill(readSign(bm, int(bm.GetBits(binkNibbleBits))), count)
+		return nil
+	}
+
+	for end := b.decoded + count; b.decoded < end; b.decoded++ {
+		b.data[b.decoded] = readSign(bm, d.readHuffman(bm, &b.tree))
+	}
+
+	return nil
+}
+
+func (d *binkVideoDecoder) readBlockTypes(bm *d2datautils.BitMuncher, b *binkBundle) error {
+	count, err := b.readCount(bm)
+	if count == 0 {
+		return err
+	}
+
+	if bm.GetBit() == 1 {
+		b.fill(int(bm.GetBits(binkNibbleBits)), count)
+		return nil
+	}
+
+	runLengths := []int{4, 8, 12, 32} //nolint:gomnd // run lengths of the symbols 12 to 15
+	last := 0
+
+	for end := b.decoded + count; b.decoded < end; {
+		value := d.readHuffman(bm, &b.tree)
+		if value < binkRunSymbols {
+			last = value
+			b.data[b.decoded] = value
+			b.decoded++
+
+			continue
+		}
+
+		run := runLengths[value-binkRunSymbols]
+		if end-b.decoded < run {
+			return errBinkInvalidData
+		}
+
+		b.fill(last, run)
+	}
+
+	return nil
+}
+
+func (d *binkVideoDecoder) readPatterns(bm *d2datautils.BitMuncher) error {
+	b := &d.bundles[binkSrcPattern]
+
+	count, err := b.readCount(bm)
+	if count == 0 {
+		return err
+	}
+
+	for end := b.decoded + count; b.decoded < end; b.decoded++ {
+		low := d.readHuffman(bm, &b.tree)
+		b.data[b.decoded] = low | d.readHuffman(bm, &b.tree)<<binkNibbleBits
+	}
+
+	return nil
+}
+
+func (d *binkVideoDecoder) readColors(bm *d2datautils.BitMuncher) error {
+	b := &d.bundles[binkSrcColors]
+
+	count, err := b.readCount(bm)
+	if count == 0 {
+		return err
+	}
+
+	readColor := func() int {
+		d.lastColor = d.readHuffman(bm, &d.colorHigh[d.lastColor])
+		value := d.lastColor<<binkNibbleBits | d.readHuffman(bm, &b.tree)
+
+		if d.revision < binkRevisionI {
+			// older revisions store the colors as signed values
+			sign := int(int8(value)) >> 7 //nolint:gomnd // sign bit
+			value = ((value & 0x7F) ^ sign) - sign + 0x80
+		}
+
+		return value
+	}
+
+	if bm.GetBit() == 1 {
+		b.fill(readColor(), count)
+		return nil
+	}
+
+	for end := b.decoded + count; b.decoded < end; b.decoded++ {
+		b.data[b.decoded] = readColor()
+	}
+
+	return nil
+}
+
+// readDCs reads the DC values of intra or inter blocks, which are stored as differences
+func readDCs(bm *d2datautils.BitMuncher, b *binkBundle, hasSign bool) error {
+	count, err := b.readCount(bm)
+	if count == 0 {
+		return err
+	}
+
+	startBits := binkDCStartBits
+	if hasSign {
+		startBits--
+	}
+
+	value := int(bm.GetBits(startBits))
+	if hasSign {
+		value = readSign(bm, value)
+	}
+
+	b.data[b.decoded] = value
+	b.decoded++
+	count--
+
+	for idx := 0; idx < count; idx += binkBlockSize {
+		length := d2math.MinInt(count-idx, binkBlockSize)
+		size := int(bm.GetBits(binkNibbleBits))
+
+		for j := 0; j < length; j++ {
+			if size != 0 {
+				value += readSign(bm, int(bm.GetBits(size)))
+
+				if value < binkDCMin || value > binkDCMax {
+					return errBinkInvalidData
+				}
+			}
+
+			b.data[b.decoded] = value
+			b.decoded++
+		}
+	}
+
+	return nil
+}
+
+// readSign reads the sign of a value which is not 0
+func readSign(bm *d2datautils.BitMuncher, value int) int {
+	if value != 0 && bm.GetBit() == 1 {
+		return -value
+	}
+
+	return value
+}
+
+// decodePlane decodes a plane of a frame of revision 'c' and later
+// nolint:gocyclo,funlen // a switch over the block types
+func (d *binkVideoDecoder) decodePlane(bm *d2datautils.BitMuncher, planeIdx int, chroma bool) error {
+	plane, prev := d.current.planes[planeIdx], d.last.planes[planeIdx]
+	bw, bh := d.blockCounts(chroma)
+	width := d.width
+
+	if chroma {
+		width >>= 1
+	}
+
+	d.initLengths(d2math.MaxInt(width, binkBlockSize), bw)
+
+	for idx := 0; idx < binkSrcCount; idx++ {
+		d.readBundle(bm, idx)
+	}
+
+	refEnd := (bw-1)*binkBlockSize + (bh-1)*binkBlockSize*plane.stride
+
+	for by := 0; by < bh; by++ {
+		if err := d.readBundles(bm); err != nil {
+			return err
+		}
+
+		for bx := 0; bx < bw; bx++ {
+			dst := by*binkBlockSize*plane.stride + bx*binkBlockSize
+			blockType := d.bundles[binkSrcBlockTypes].value()
+
+			// a scaled block in an odd row is part of the scaled block of the row above
+			if by&1 == 1 && blockType == binkBlockScaled {
+				bx++
+				continue
+			}
+
+			var err error
+
+			switch blockType {
+			case binkBlockSkip:
+				copyBlock(plane, dst, prev, dst)
+			case binkBlockScaled:
+				err = d.decodeScaledBlock(bm, plane, dst)
+				bx++
+			case binkBlockMotion, binkBlockResidue, binkBlockInter:
+				err = d.decodeMotionBlock(bm, blockType, plane, prev, dst, refEnd)
+			case binkBlockRun:
+				err = d.decodeRunBlock(bm, d.putter(plane, dst), d.runLength)
+			case binkBlockIntra:
+				var block [binkBlockPixels]int32
+
+				block[0] = int32(d.bundles[binkSrcIntraDC].value())
+
+				if err = d.readDCTBlock(bm, &block, &d.intraQuant, -1); err == nil {
+					idctPut(plane, dst, &block)
+				}
+			case binkBlockFill:
+				fillBlock(plane, dst, byte(d.bundles[binkSrcColors].value()), binkBlockSize)
+			case binkBlockPattern:
+				d.decodePatternBlock(d.putter(plane, dst))
+			case binkBlockRaw:
+				d.decodeRawBlock(d.putter(plane, dst))
+			default:
+				err = errBinkInvalidData
+			}
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	alignPlane(bm)
+
+	return nil
+}
+
+// putter returns a function which sets the pixels of the 8x8 block at dst
+func (d *binkVideoDecoder) putter(plane *binkPlane, dst int) func(idx int, value byte) {
+	return func(idx int, value byte) {
+		plane.pixels[plane.offset(dst, idx)] = value
+	}
+}
+
+// runLength returns the length of the next run of a run block
+func (d *binkVideoDecoder) runLength(*d2datautils.BitMuncher, int) int {
+	return d.bundles[binkSrcRun].value() + 1
+}
+
+// decodeScaledBlock decodes a 16x16 block, which is stored as an 8x8 block
+func (d *binkVideoDecoder) decodeScaledBlock(bm *d2datautils.BitMuncher, plane *binkPlane, dst int) error {
+	var pixels [binkBlockPixels]byte
+
+	put := func(idx int, value byte) {
+		pixels[idx] = value
+	}
+
+	switch d.bundles[binkSrcSubBlockTypes].value() {
+	case binkBlockRun:
+		if err := d.decodeRunBlock(bm, put, d.runLength); err != nil {
+			return err
+		}
+	case binkBlockIntra:
+		var block [binkBlockPixels]int32
+
+		block[0] = int32(d.bundles[binkSrcIntraDC].value())
+
+		if err := d.readDCTBlock(bm, &block, &d.intraQuant, -1); err != nil {
+			return err
+		}
+
+		binkIDCT(&block)
+
+		for idx, value := range block {
+			pixels[idx] = clipUint8(value)
+		}
+	case binkBlockFill:
+		fillBlock(plane, dst, byte(d.bundles[binkSrcColors].value()), binkMacroBlock)
+		return nil
+	case binkBlockPattern:
+		d.decodePatternBlock(put)
+	case binkBlockRaw:
+		d.decodeRawBlock(put)
+	default:
+		return errBinkInvalidData
+	}
+
+	// every pixel of the 8x8 block becomes 2x2 pixels
+	for idx, value := range pixels {
+		offset := dst + (idx&binkBlockColumn)*2 + (idx>>binkBlockRowShift)*2*plane.stride //nolint:gomnd // 2x2 pixels
+		plane.pixels[offset] = value
+		plane.pixels[offset+1] = value
+		plane.pixels[offset+plane.stride] = value
+		plane.pixels[offset+plane.stride+1] = value
+	}
+
+	return nil
+}
+
+// decodeMotionBlock decodes a block which copies a block of the previous frame, and adds the
+// residue or DCT coefficients to it
+func (d *binkVideoDecoder) decodeMotionBlock(bm *d2datautils.BitMuncher, blockType int, plane, prev *binkPlane,
+	dst, refEnd int) error {
+	ref := dst + d.bundles[binkSrcXOff].value() + d.bundles[binkSrcYOff].value()*plane.stride
+	if ref < 0 || ref > refEnd {
+		return errBinkInvalidData
+	}
+
+	copyBlock(plane, dst, prev, ref)
+
+	var block [binkBlockPixels]int32
+
+	switch blockType {
+	case binkBlockResidue:
+		readResidue(bm, &block, &d.scan, int(bm.GetBits(binkResidueBits)))
+		addPixels(plane, dst, &block)
+	case binkBlockInter:
+		block[0] = int32(d.bundles[binkSrcInterDC].value())
+
+		if err := d.readDCTBlock(bm, &block, &d.interQuant, -1); err != nil {
+			return err
+		}
+
+		idctAdd(plane, dst, &block)
+	}
+
+	return nil
+}
+
+// decodeRunBlock fills the pixels of a block in the order of a pattern, with runs of a color
+// or of single colors. runLength returns the length of the run which starts at a pixel.
+func (d *binkVideoDecoder) decodeRunBlock(bm *d2datautils.BitMuncher, put func(idx int, value byte),
+	runLength func(bm *d2datautils.BitMuncher, pos int) int) error {
+	scan := d.patterns[bm.GetBits(binkNibbleBits)]
+	colors := &d.bundles[binkSrcColors]
+	pos := 0
+
+	for pos < binkBlockPixels-1 {
+		sameColor := false
+
+		// the 'b' revision stores the mode before the length of the run
+		if d.revision == binkRevisionB {
+			sameColor = bm.GetBit() == 1
+		}
+
+		run := runLength(bm, pos)
+		if pos+run > binkBlockPixels {
+			return errBinkInvalidData
+		}
+
+		if d.revision != binkRevisionB {
+			sameColor = bm.GetBit() == 1
+		}
+
+		if sameColor {
+			value := byte(colors.value())
+
+			for end := pos + run; pos < end; pos++ {
+				put(int(scan[pos]), value)
+			}
+
+			continue
+		}
+
+		for end := pos + run; pos < end; pos++ {
+			put(int(scan[pos]), byte(colors.value()))
+		}
+	}
+
+	if pos == binkBlockPixels-1 {
+		put(int(scan[pos]), byte(colors.value()))
+	}
+
+	return nil
+}
+
+// decodePatternBlock fills a block with two colors, every row has a mask which selects them
+func (d *binkVideoDecoder) decodePatternBlock(put func(idx int, value byte)) {
+	colors := [2]byte{byte(d.bundles[binkSrcColors].value()), byte(d.bundles[binkSrcColors].value())}
+
+	for y := 0; y < binkBlockSize; y++ {
+		mask := d.bundles[binkSrcPattern].value()
+
+		for x := 0; x < binkBlockSize; x++ {
+			put(x+y*binkBlockSize, colors[mask&1])
+			mask >>= 1
+		}
+	}
+}
+
+// decodeRawBlock fills a block with the next 64 colors
+func (d *binkVideoDecoder) decodeRawBlock(put func(idx int, value byte)) {
+	for idx := 0; idx < binkBlockPixels; idx++ {
+		put(idx, byte(d.bundles[binkSrcColors].value()))
+	}
+}
+
+// readDCTBlock reads and unquantizes the AC coefficients of a DCT block. The quantizer is
+// read from the stream if q is -1.
+func (d *binkVideoDecoder) readDCTBlock(bm *d2datautils.BitMuncher, block *[binkBlockPixels]int32,
+	quantizers *[binkQuantCount][binkBlockPixels]int32, q int) error {
+	var coefficients [binkBlockPixels]int
+
+	count := readDCTCoefficients(bm, block, &d.scan, &coefficients)
+
+	if q == -1 {
+		q = int(bm.GetBits(binkNibbleBits))
+	}
+
+	if q < 0 || q >= binkQuantCount {
+		return errBinkInvalidData
+	}
+
+	quant := &quantizers[q]
+
+	block[0] = (block[0] * quant[0]) >> binkIDCTShift
+
+	for _, idx := range coefficients[:count] {
+		pos := d.scan[idx]
+		block[pos] = (block[pos] * quant[idx]) >> binkIDCTShift
+	}
+
+	return nil
+}
+
+// readDCTCoefficients reads the AC coefficients of a DCT block, one bit plane after the other.
+// It returns the number of coefficients, their indexes in scan order are stored in coefficients.
+func readDCTCoefficients(bm *d2datautils.BitMuncher, block *[binkBlockPixels]int32, scan *[binkBlockPixels]uint8,
+	coefficients *[binkBlockPixels]int) int {
+	list := newBinkCoefficientList([]binkCoefficientNode{{4, 0}, {24, 0}, {44, 0}, {1, 3}, {2, 3}, {3, 3}})
+	count := 0
+
+	for bitCount := int(bm.GetBits(binkNibbleBits)) - 1; bitCount >= 0; bitCount-- {
+		list.walk(bm, func(coefficient int) bool {
+			var value int32
+
+			if bitCount == 0 {
+				value = 1 - int32(bm.GetBit())<<1
+			} else {
+				value = int32(readSign(bm, int(bm.GetBits(bitCount))|1<<uint(bitCount)))
+			}
+
+			block[scan[coefficient]] = value
+			coefficients[count] = coefficient
+			count++
+
+			return false
+		})
+	}
+
+	return count
+}
+
+// readResidue reads the residue of a motion block, the values are stored one bit plane after
+// the other, until masksCount bits are set
+func readResidue(bm *d2datautils.BitMuncher, block *[binkBlockPixels]int32, scan *[binkBlockPixels]uint8,
+	masksCount int) {
+	list := newBinkCoefficientList([]binkCoefficientNode{{4, 0}, {24, 0}, {44, 0}, {0, 2}})
+	nonZero := make([]int, 0, binkBlockPixels)
+
+	for mask := int32(1) << bm.GetBits(3); mask != 0; mask >>= 1 { //nolint:gomnd // binary data
+		for _, pos := range nonZero {
+			if bm.GetBit() == 0 {
+				continue
+			}
+
+			if block[pos] < 0 {
+				block[pos] -= mask
+			} else {
+				block[pos] += mask
+			}
+
+			if masksCount--; masksCount < 0 {
+				return
+			}
+		}
+
+		done := list.walk(bm, func(coefficient int) bool {
+			pos := int(scan[coefficient])
+			nonZero = append(nonZero, pos)
+			block[pos] = int32(readSign(bm, int(mask)))
+			masksCount--
+
+			return masksCount < 0
+		})
+
+		if done {
+			return
+		}
+	}
+}
+
+// Modes of the nodes of a coefficient list
+const (
+	binkNodeGroup16 = iota // a group of 16 coefficients
+	binkNodeSplit          // the first of 4 groups of 4, after a group of 16 is split
+	binkNodeGroup4         // a group of 4 coefficients
+	binkNodeSingle         // a single coefficient
+)
+
+// binkCoefficientNode is an entry of the list of coefficients of a DCT or residue block, which
+// are not set yet
+type binkCoefficientNode struct {
+	coefficient int
+	mode        int
+}
+
+// binkCoefficientList is the list of the coefficients which are not set yet. Single
+// coefficients are added at the start of the list, groups at the end.
+type binkCoefficientList struct {
+	nodes      [binkBlockPixels * 2]binkCoefficientNode
+	start, end int
+}
+
+func newBinkCoefficientList(initial []binkCoefficientNode) *binkCoefficientList {
+	list := &binkCoefficientList{start: binkBlockPixels, end: binkBlockPixels}
+
+	for _, node := range initial {
+		list.nodes[list.end] = node
+		list.end++
+	}
+
+	return list
+}
+
+// walk reads the flags of the nodes of the list for one bit plane, store is called for every
+// coefficient which gets a value. The walk stops when store returns true.
+func (l *binkCoefficientList) walk(bm *d2datautils.BitMuncher, store func(coefficient int) bool) bool {
+	for pos := l.start; pos < l.end; {
+		node := l.nodes[pos]
+
+		if (node.coefficient == 0 && node.mode == binkNodeGroup16) || bm.GetBit() == 0 {
+			pos++
+			continue
+		}
+
+		coefficient := node.coefficient
+
+		switch node.mode {
+		case binkNodeGroup16, binkNodeGroup4:
+			if node.mode == binkNodeGroup16 {
+				// the first group of 4 is read now, the node is split into the others later
+				l.nodes[pos] = binkCoefficientNode{coefficient + 4, binkNodeSplit} //nolint:gomnd // next group
+			} else {
+				l.nodes[pos] = binkCoefficientNode{}
+				pos++
+			}
+
+			for end := coefficient + 4; coefficient < end; coefficient++ { //nolint:gomnd // group of 4
+				if bm.GetBit() == 1 {
+					l.start--
+					l.nodes[l.start] = binkCoefficientNode{coefficient, binkNodeSingle}
+				} else if store(coefficient) {
+					return true
+				}
+			}
+		case binkNodeSplit:
+			l.nodes[pos].mode = binkNodeGroup4
+
+			for idx := 0; idx < 3; idx++ { //nolint:gomnd // the other 3 groups of 4
+				coefficient += 4
+				l.nodes[l.end] = binkCoefficientNode{coefficient, binkNodeGroup4}
+				l.end++
+			}
+		case binkNodeSingle:
+			l.nodes[pos] = binkCoefficientNode{}
+			pos++
+
+			if store(coefficient) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// alignPlane skips the padding after a plane, the next plane starts at a multiple of 32 bits
+func alignPlane(bm *d2datautils.BitMuncher) {
+	if rest := bm.Offset() % binkPlaneAlign; rest != 0 {
+		bm.SkipBits(binkPlaneAlign - rest)
+	}
+}
+
+func alignInt(value, alignment int) int {
+	return (value + alignment - 1) / alignment * alignment
+}
@@ -0,0 +1,169 @@
+package d2video
+
+import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2datautils"
+)
+
+// The 'b' revision uses other block types and stores the bundles without huffman trees. Its
+// bundles share the slots of the bundles of the later revisions where they have the same meaning.
+const (
+	binkbSrcIntraQ = binkSrcCount + iota
+	binkbSrcInterQ
+	binkbSrcInterCoefs
+	binkbSrcCount
+)
+
+// Block types of the 'b' revision
+const (
+	binkbBlockSkip = iota
+	binkbBlockRun
+	binkbBlockIntra
+	binkbBlockResidue
+	binkbBlockInter
+	binkbBlockFill
+	binkbBlockPattern
+	binkbBlockMotion
+	binkbBlockRaw
+)
+
+const (
+	binkbBundleLength = 13
+	binkbKeyFrameBias = -15 // the vertical offset of the motion vectors of the first frame
+)
+
+// binkbBundle describes how the values of a bundle of the 'b' revision are stored
+type binkbBundle struct {
+	slot   int
+	bits   int
+	signed bool
+}
+
+// binkbBundles returns the bundles of the 'b' revision, in the order they are read
+func binkbBundles() []binkbBundle {
+	return []binkbBundle{
+		{binkSrcBlockTypes, 4, false},
+		{binkSrcColors, 8, false},
+		{binkSrcPattern, 8, false},
+		{binkSrcXOff, 5, true},
+		{binkSrcYOff, 5, true},
+		{binkSrcIntraDC, 11, false},
+		{binkSrcInterDC, 11, true},
+		{binkbSrcIntraQ, 4, false},
+		{binkbSrcInterQ, 4, false},
+		{binkbSrcInterCoefs, 7, false},
+	}
+}
+
+// readBundleB reads the values of a bundle of the 'b' revision which are used by the next row
+// of blocks, signed values are stored with a bias
+func (d *binkVideoDecoder) readBundleB(bm *d2datautils.BitMuncher, bundle binkbBundle) error {
+	b := &d.bundles[bundle.slot]
+
+	count, err := b.readCount(bm)
+	if count == 0 {
+		return err
+	}
+
+	bias := 0
+	if bundle.signed {
+		bias = 1 << uint(bundle.bits-1)
+	}
+
+	for end := b.decoded + count; b.decoded < end; b.decoded++ {
+		b.data[b.decoded] = int(bm.GetBits(bundle.bits)) - bias
+	}
+
+	return nil
+}
+
+// decodePlaneB decodes a plane of a frame of the 'b' revision, the plane is updated in place
+// nolint:gocyclo,funlen // a switch over the block types
+func (d *binkVideoDecoder) decodePlaneB(bm *d2datautils.BitMuncher, planeIdx int, key, chroma bool) error {
+	plane := d.current.planes[planeIdx]
+	bw, bh := d.blockCounts(chroma)
+	bundles := binkbBundles()
+
+	ybias := 0
+	if key {
+		ybias = binkbKeyFrameBias
+	}
+
+	for _, bundle := range bundles {
+		d.bundles[bundle.slot].length = binkbBundleLength
+		d.bundles[bundle.slot].decoded = 0
+		d.bundles[bundle.slot].position = 0
+	}
+
+	runLength := func(bm *d2datautils.BitMuncher, pos int) int {
+		return int(bm.GetBits(d.runBits[pos])) + 1
+	}
+
+	for by := 0; by < bh; by++ {
+		for _, bundle := range bundles {
+			if err := d.readBundleB(bm, bundle); err != nil {
+				return err
+			}
+		}
+
+		for bx := 0; bx < bw; bx++ {
+			dst := by*binkBlockSize*plane.stride + bx*binkBlockSize
+
+			var (
+				block [binkBlockPixels]int32
+				err   error
+			)
+
+			switch blockType := d.bundles[binkSrcBlockTypes].value(); blockType {
+			case binkbBlockSkip:
+			case binkbBlockRun:
+				err = d.decodeRunBlock(bm, d.putter(plane, dst), runLength)
+			case binkbBlockIntra:
+				block[0] = int32(d.bundles[binkSrcIntraDC].value())
+
+				if err = d.readDCTBlock(bm, &block, &d.intraQuant, d.bundles[binkbSrcIntraQ].value()); err == nil {
+					idctPut(plane, dst, &block)
+				}
+			case binkbBlockResidue:
+				d.motionB(plane, dst, ybias)
+				readResidue(bm, &block, &d.scan, d.bundles[binkbSrcInterCoefs].value())
+				addPixels(plane, dst, &block)
+			case binkbBlockInter:
+				d.motionB(plane, dst, ybias)
+				block[0] = int32(d.bundles[binkSrcInterDC].value())
+
+				if err = d.readDCTBlock(bm, &block, &d.interQuant, d.bundles[binkbSrcInterQ].value()); err == nil {
+					idctAdd(plane, dst, &block)
+				}
+			case binkbBlockFill:
+				fillBlock(plane, dst, byte(d.bundles[binkSrcColors].value()), binkBlockSize)
+			case binkbBlockPattern:
+				d.decodePatternBlock(d.putter(plane, dst))
+			case binkbBlockMotion:
+				d.motionB(plane, dst, ybias)
+			case binkbBlockRaw:
+				d.decodeRawBlock(d.putter(plane, dst))
+			default:
+				err = errBinkInvalidData
+			}
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	alignPlane(bm)
+
+	return nil
+}
+
+// motionB copies a block of the plane to dst, blocks which are out of the plane are ignored
+func (d *binkVideoDecoder) motionB(plane *binkPlane, dst, ybias int) {
+	ref := dst + d.bundles[binkSrcXOff].value() + (d.bundles[binkSrcYOff].value()+ybias)*plane.stride
+
+	if ref < 0 || ref+(binkBlockSize-1)*plane.stride+binkBlockSize > len(plane.pixels) {
+		return
+	}
+
+	copyBlock(plane, dst, plane, ref)
+}
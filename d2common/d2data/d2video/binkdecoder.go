@@ -2,7 +2,8 @@ package d2video
 
 import (
 	"errors"
-	"log"
+	"image"
+	"io"
 
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2datautils"
 )
@@ -33,9 +34,14 @@ const (
 const (
 	numHeaderBytes            = 3
 	bikHeaderStr              = "BIK"
-	numAudioTrackUnknownBytes = 2
+	numAudioTrackMaxSizeBytes = 4
+	keyframeFlag              = 1
+	minimumRevision           = 'b'
+	maximumRevision           = 'i'
 )
 
+var errInvalidFrameIndex = errors.New("invalid bink frame index")
+
 // BinkAudioAlgorithm represents the type of bink audio algorithm
 type BinkAudioAlgorithm uint32
 
@@ -73,9 +79,24 @@ type BinkDecoder struct {
 	videoCodecRevision    byte
 	HasAlphaPlane         bool
 	Grayscale             bool
+	video                 *binkVideoDecoder
+	audio                 []*binkAudioDecoder
+	frame                 BinkFrame
+}
+
+// BinkFrame is a decoded frame of a bink video
+type BinkFrame struct {
+	// Index is the number of the frame
+	Index int
 
-	// Mask bit 0, as this is defined as a keyframe
+	// Keyframe is true if the frame does not depend on the previous frames
+	Keyframe bool
 
+	// Image is the picture of the frame, it is reused by the next frame
+	Image *image.RGBA
+
+	// Audio contains the interleaved samples of every audio track, between -1 and 1
+	Audio [][]float32
 }
 
 // CreateBinkDecoder returns a new instance of the bink decoder
@@ -84,31 +105,100 @@ func CreateBinkDecoder(source []byte) (*BinkDecoder, error) {
 		streamReader: d2datautils.CreateStreamReader(source),
 	}
 
-	err := result.loadHeaderInformation()
+	if err := result.loadHeaderInformation(); err != nil {
+		return result, err
+	}
+
+	if result.videoCodecRevision < minimumRevision || result.videoCodecRevision > maximumRevision {
+		return result, errors.New("unsupported bink video revision")
+	}
+
+	result.frame.Image = image.NewRGBA(image.Rect(0, 0, int(result.VideoWidth), int(result.VideoHeight)))
+	result.frame.Audio = make([][]float32, len(result.AudioTracks))
+	result.Rewind()
 
-	return result, err
+	return result, nil
 }
 
-// GetNextFrame gets the next frame
-func (v *BinkDecoder) GetNextFrame() error {
-	//nolint:gocritic // v.streamReader.SetPosition(uint64(v.FrameIndexTable[i] & 0xFFFFFFFE))
-	lengthOfAudioPackets, err := v.streamReader.ReadUInt32()
-	if err != nil {
-		return err
+// NumberOfFrames returns the number of frames of the video
+func (v *BinkDecoder) NumberOfFrames() int {
+	return int(v.numberOfFrames)
+}
+
+// Rewind restarts the video at the first frame
+func (v *BinkDecoder) Rewind() {
+	v.frameIndex = 0
+	v.video = newBinkVideoDecoder(v.videoCodecRevision, int(v.VideoWidth), int(v.VideoHeight), v.HasAlphaPlane)
+	v.audio = make([]*binkAudioDecoder, len(v.AudioTracks))
+
+	for idx := range v.AudioTracks {
+		v.audio[idx] = newBinkAudioDecoder(&v.AudioTracks[idx], v.videoCodecRevision)
 	}
+}
 
-	samplesInPacket, err := v.streamReader.ReadUInt32()
-	if err != nil {
-		return err
+// GetNextFrame decodes the next frame, it returns io.EOF after the last frame. The returned
+// frame is reused by the next call.
+func (v *BinkDecoder) GetNextFrame() (*BinkFrame, error) {
+	if v.frameIndex >= v.numberOfFrames {
+		return nil, io.EOF
 	}
 
-	v.streamReader.SkipBytes(int(lengthOfAudioPackets) - 4) //nolint:gomnd // decode magic
+	start := v.FrameIndexTable[v.frameIndex] &^ keyframeFlag
+	end := v.FrameIndexTable[v.frameIndex+1] &^ keyframeFlag
 
-	log.Printf("Frame %d:\tSamp: %d", v.frameIndex, samplesInPacket)
+	if end < start || uint64(end) > v.streamReader.Size() {
+		return nil, errInvalidFrameIndex
+	}
 
+	v.frame.Index = int(v.frameIndex)
+	v.frame.Keyframe = v.FrameIndexTable[v.frameIndex]&keyframeFlag != 0
 	v.frameIndex++
 
-	return nil
+	v.streamReader.SetPosition(uint64(start))
+	remaining := end - start
+
+	// every audio track has a packet, which starts with its size, the rest of the frame is video
+	for idx, decoder := range v.audio {
+		size, err := v.streamReader.ReadUInt32()
+		if err != nil {
+			return nil, err
+		}
+
+		if size+4 > remaining {
+			return nil, errInvalidFrameIndex
+		}
+
+		remaining -= size + 4
+
+		packet, err := v.streamReader.ReadBytes(int(size))
+		if err != nil {
+			return nil, err
+		}
+
+		v.frame.Audio[idx] = v.frame.Audio[idx][:0]
+
+		// packets shorter than the sample count are empty
+		if size < 4 {
+			continue
+		}
+
+		if v.frame.Audio[idx], err = decoder.decodePacket(packet); err != nil {
+			return nil, err
+		}
+	}
+
+	packet, err := v.streamReader.ReadBytes(int(remaining))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := v.video.decodeFrame(packet); err != nil {
+		return nil, err
+	}
+
+	v.video.picture().toRGBA(v.frame.Image, v.Grayscale)
+
+	return &v.frame, nil
 }
 
 //nolint:gomnd,funlen,gocyclo // Decoder magic, can't help the long function length for now
@@ -189,14 +279,8 @@ func (v *BinkDecoder) loadHeaderInformation() error {
 
 	v.AudioTracks = make([]BinkAudioTrack, numberOfAudioTracks)
 
-	for i := 0; i < int(numberOfAudioTracks); i++ {
-		v.streamReader.SkipBytes(numAudioTrackUnknownBytes)
-
-		v.AudioTracks[i].AudioChannels, err = v.streamReader.ReadUInt16()
-		if err != nil {
-			return err
-		}
-	}
+	// the largest decoded size of the audio packets is not needed
+	v.streamReader.SkipBytes(numAudioTrackMaxSizeBytes * int(numberOfAudioTracks))
 
 	for i := 0; i < int(numberOfAudioTracks); i++ {
 		v.AudioTracks[i].AudioSampleRateHz, err = v.streamReader.ReadUInt16()
@@ -212,6 +296,11 @@ func (v *BinkDecoder) loadHeaderInformation() error {
 		}
 
 		v.AudioTracks[i].Stereo = ((flags >> 13) & 0x1) == 1
+		v.AudioTracks[i].AudioChannels = 1
+
+		if v.AudioTracks[i].Stereo {
+			v.AudioTracks[i].AudioChannels = 2
+		}
 		v.AudioTracks[i].Algorithm = BinkAudioAlgorithm((flags >> 12) & 0x1)
 	}
 
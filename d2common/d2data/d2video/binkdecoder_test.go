@@ -0,0 +1,365 @@
+package d2video
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2datautils"
+)
+
+// binkTestWriter writes a bit stream and keeps track of the number of bits, so planes can be
+// aligned to 32 bits
+type binkTestWriter struct {
+	*d2datautils.StreamWriter
+	count int
+}
+
+func (w *binkTestWriter) push(value uint32, bits int) {
+	w.PushBits32(value, bits)
+	w.count += bits
+}
+
+func (w *binkTestWriter) align() {
+	for w.count%binkPlaneAlign != 0 {
+		w.push(0, 1)
+	}
+}
+
+// binkTestPlane is a plane with one row of blocks. Every bundle uses the identity tree, the
+// values of a bundle are decoded at once.
+type binkTestPlane struct {
+	subLength  int // the number of bits of the count of the sub block types
+	blockTypes []int
+	colors     []int
+	patterns   []int
+	intraDCs   []int
+	blockBits  func(w *binkTestWriter)
+}
+
+func (p *binkTestPlane) write(w *binkTestWriter) {
+	const (
+		length = 10
+		trees  = 23 // the bundles without DC values and the 16 trees of the high nibbles of the colors
+	)
+
+	for idx := 0; idx < trees; idx++ {
+		w.push(0, binkNibbleBits)
+	}
+
+	w.push(uint32(len(p.blockTypes)), length)
+	w.push(0, 1)
+
+	for _, blockType := range p.blockTypes {
+		w.push(uint32(blockType), binkNibbleBits)
+	}
+
+	w.push(0, p.subLength)
+
+	w.push(uint32(len(p.colors)), length)
+
+	if len(p.colors) > 0 {
+		w.push(0, 1)
+	}
+
+	for _, color := range p.colors {
+		w.push(uint32(color>>binkNibbleBits), binkNibbleBits)
+		w.push(uint32(color&0xF), binkNibbleBits)
+	}
+
+	w.push(uint32(len(p.patterns)), length)
+
+	for _, pattern := range p.patterns {
+		w.push(uint32(pattern&0xF), binkNibbleBits)
+		w.push(uint32(pattern>>binkNibbleBits), binkNibbleBits)
+	}
+
+	w.push(0, length) // x offsets
+	w.push(0, length) // y offsets
+
+	w.push(uint32(len(p.intraDCs)), length)
+
+	for _, dc := range p.intraDCs {
+		w.push(uint32(dc), binkDCStartBits)
+	}
+
+	w.push(0, length) // inter DCs
+	w.push(0, length) // runs
+
+	if p.blockBits != nil {
+		p.blockBits(w)
+	}
+
+	w.align()
+}
+
+// binkTestVideo returns the packet of a 16x8 video frame with the given planes
+func binkTestVideo(luma, cr, cb *binkTestPlane) []byte {
+	w := &binkTestWriter{StreamWriter: d2datautils.CreateStreamWriter()}
+
+	w.push(0, binkPlaneAlign)
+	luma.write(w)
+	cr.write(w)
+	cb.write(w)
+
+	return w.GetBytes()
+}
+
+// binkTestAudio returns an audio packet of a 22050Hz mono DCT track with one block, all
+// samples of the block have the value 0.5
+func binkTestAudio() []byte {
+	w := &binkTestWriter{StreamWriter: d2datautils.CreateStreamWriter()}
+
+	w.push(0, binkAudioPacketHeader)
+	w.push(0, 2) //nolint:gomnd // unused bits
+
+	// 2^18 as the first coefficient, 0 as the second
+	w.push(19, binkAudioFloatPower)
+	w.push(1<<22, binkAudioFloatMantissa)
+	w.push(0, 1)
+	w.push(0, binkAudioFloatPower+binkAudioFloatMantissa+1)
+
+	for band := 0; band < 23; band++ {
+		w.push(0, binkAudioBandBits)
+	}
+
+	// two runs of 512 coefficients, which are all 0
+	for run := 0; run < 2; run++ {
+		w.push(1, 1)
+		w.push(15, binkNibbleBits)
+		w.push(0, binkAudioWidthBits)
+	}
+
+	w.align()
+
+	return w.GetBytes()
+}
+
+// getExampleBink returns a 16x8 bink video with an audio track and two frames
+func getExampleBink() []byte {
+	const (
+		blockIntra   = 5
+		blockPattern = 8
+		blockFill    = 6
+		blockSkip    = 0
+	)
+
+	dctBits := func(w *binkTestWriter) {
+		w.push(0, binkNibbleBits) // no coefficients
+		w.push(0, binkNibbleBits) // quantizer
+	}
+
+	firstFrame := binkTestVideo(
+		&binkTestPlane{
+			subLength:  10,
+			blockTypes: []int{blockIntra, blockPattern},
+			colors:     []int{16, 235},
+			patterns:   []int{0x0F, 0x0F, 0x0F, 0x0F, 0x0F, 0x0F, 0x0F, 0x0F},
+			intraDCs:   []int{1024},
+			blockBits:  dctBits,
+		},
+		&binkTestPlane{subLength: 9, blockTypes: []int{blockFill}, colors: []int{160}},
+		&binkTestPlane{subLength: 9, blockTypes: []int{blockFill}, colors: []int{128}},
+	)
+
+	secondFrame := binkTestVideo(
+		&binkTestPlane{subLength: 10, blockTypes: []int{blockSkip, blockFill}, colors: []int{50}},
+		&binkTestPlane{subLength: 9, blockTypes: []int{blockSkip}},
+		&binkTestPlane{subLength: 9, blockTypes: []int{blockSkip}},
+	)
+
+	audio := binkTestAudio()
+
+	frames := d2datautils.CreateStreamWriter()
+	frames.PushUint32(uint32(len(audio)))
+	frames.PushBytes(audio...)
+	frames.PushBytes(firstFrame...)
+
+	secondStart := len(frames.GetBytes())
+
+	frames.PushUint32(0)
+	frames.PushBytes(secondFrame...)
+
+	const headerSize = 68
+
+	frameData := frames.GetBytes()
+	header := d2datautils.CreateStreamWriter()
+	header.PushBytes([]byte("BIKi")...)
+
+	for _, value := range []uint32{
+		uint32(headerSize + len(frameData) - 8), 2, uint32(len(frameData)), 2, // size, frames, largest frame
+		16, 8, 25, 1, 0, // width, height, fps, video flags
+		1, 0, // audio tracks, the largest decoded audio packet
+	} {
+		header.PushUint32(value)
+	}
+
+	header.PushUint16(22050)
+	header.PushUint16(1 << 12) // DCT
+	header.PushUint32(0)
+
+	header.PushUint32(headerSize | keyframeFlag)
+	header.PushUint32(uint32(headerSize + secondStart))
+	header.PushUint32(uint32(headerSize + len(frameData)))
+
+	return append(header.GetBytes(), frameData...)
+}
+
+func checkBinkPixel(t *testing.T, frame *BinkFrame, x, y int, expected [4]byte) {
+	t.Helper()
+
+	offset := x*4 + y*frame.Image.Stride
+	pixel := frame.Image.Pix[offset : offset+4]
+
+	if pixel[0] != expected[0] || pixel[1] != expected[1] || pixel[2] != expected[2] || pixel[3] != expected[3] {
+		t.Errorf("frame %d, pixel %d,%d: expected %v, got %v", frame.Index, x, y, expected, pixel)
+	}
+}
+
+func TestBinkDecoder_GetNextFrame(t *testing.T) {
+	decoder, err := CreateBinkDecoder(getExampleBink())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if decoder.NumberOfFrames() != 2 || decoder.AudioTracks[0].AudioChannels != 1 {
+		t.Fatalf("unexpected header: %d frames, %d channels", decoder.NumberOfFrames(), decoder.AudioTracks[0].AudioChannels)
+	}
+
+	frame, err := decoder.GetNextFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !frame.Keyframe || frame.Image.Bounds().Dx() != 16 || frame.Image.Bounds().Dy() != 8 {
+		t.Fatal("unexpected first frame")
+	}
+
+	// the DC block has Y=128, the chroma planes have Cr=160 and Cb=128
+	checkBinkPixel(t, frame, 0, 0, [4]byte{182, 104, 130, 255})
+	checkBinkPixel(t, frame, 7, 7, [4]byte{182, 104, 130, 255})
+
+	// the pattern block has Y=235 in the left and Y=16 in the right half
+	checkBinkPixel(t, frame, 8, 3, [4]byte{255, 229, 255, 255})
+	checkBinkPixel(t, frame, 12, 3, [4]byte{51, 0, 0, 255})
+
+	if len(frame.Audio[0]) != 960 {
+		t.Fatalf("expected 960 samples, got %d", len(frame.Audio[0]))
+	}
+
+	for _, sample := range frame.Audio[0] {
+		if math.Abs(float64(sample)-0.5) > 1e-4 {
+			t.Fatalf("expected samples of 0.5, got %f", sample)
+		}
+	}
+
+	frame, err = decoder.GetNextFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the left block is skipped, the right block is filled with Y=50
+	checkBinkPixel(t, frame, 0, 0, [4]byte{182, 104, 130, 255})
+	checkBinkPixel(t, frame, 12, 3, [4]byte{91, 14, 40, 255})
+
+	if frame.Keyframe || len(frame.Audio[0]) != 0 {
+		t.Error("unexpected second frame")
+	}
+
+	if _, err := decoder.GetNextFrame(); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+
+	decoder.Rewind()
+
+	if frame, err = decoder.GetNextFrame(); err != nil || frame.Index != 0 {
+		t.Errorf("expected the first frame after Rewind, got %v", err)
+	}
+}
+
+// TestBinkDecoder_Samples decodes the Bink videos in testdata. The cinematics of the game can
+// not be distributed, a video of a Diablo 2 install can be copied into testdata to test it.
+func TestBinkDecoder_Samples(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "*.bik"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(paths) == 0 {
+		t.Skip("no Bink videos in testdata")
+	}
+
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(filepath.Clean(path))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			checkBinkSample(t, data)
+		})
+	}
+}
+
+// checkBinkSample decodes every frame of the video, checks the amount of sound of every audio
+// track against the length of the video and checks that the video decodes the same again
+func checkBinkSample(t *testing.T, data []byte) {
+	decoder, err := CreateBinkDecoder(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	samples := make([]int, len(decoder.AudioTracks))
+
+	var first []byte
+
+	for idx := 0; idx < decoder.NumberOfFrames(); idx++ {
+		frame, err := decoder.GetNextFrame()
+		if err != nil {
+			t.Fatalf("frame %d: %v", idx, err)
+		}
+
+		if frame.Image.Bounds().Dx() != int(decoder.VideoWidth) || frame.Image.Bounds().Dy() != int(decoder.VideoHeight) {
+			t.Fatalf("frame %d: unexpected size %v", idx, frame.Image.Bounds())
+		}
+
+		if idx == 0 {
+			first = append([]byte(nil), frame.Image.Pix...)
+		}
+
+		for track := range frame.Audio {
+			for _, sample := range frame.Audio[track] {
+				if math.IsNaN(float64(sample)) || math.Abs(float64(sample)) > 2 {
+					t.Fatalf("frame %d: unexpected sample %f", idx, sample)
+				}
+			}
+
+			samples[track] += len(frame.Audio[track])
+		}
+	}
+
+	if _, err := decoder.GetNextFrame(); err != io.EOF {
+		t.Errorf("expected io.EOF after the last frame, got %v", err)
+	}
+
+	seconds := float64(decoder.NumberOfFrames()) / float64(decoder.FPS)
+
+	for track, count := range samples {
+		header := decoder.AudioTracks[track]
+		expected := seconds * float64(header.AudioSampleRateHz) * float64(header.AudioChannels)
+
+		// the sound may start before the first frame, by up to one second
+		if math.Abs(float64(count)-expected) > float64(header.AudioSampleRateHz)*float64(header.AudioChannels) {
+			t.Errorf("track %d: expected about %.0f samples, got %d", track, expected, count)
+		}
+	}
+
+	decoder.Rewind()
+
+	if frame, err := decoder.GetNextFrame(); err != nil || !bytes.Equal(frame.Image.Pix, first) {
+		t.Errorf("expected the first frame to be decoded the same after Rewind, got %v", err)
+	}
+}
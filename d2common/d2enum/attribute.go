@@ -0,0 +1,12 @@
+package d2enum
+
+// Attribute is an attribute of a hero, which a player spends stat points on
+type Attribute int
+
+// Attributes
+const (
+	AttributeStrength Attribute = iota
+	AttributeDexterity
+	AttributeVitality
+	AttributeEnergy
+)
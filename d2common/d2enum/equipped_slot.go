@@ -16,4 +16,6 @@ const (
 	EquippedSlotNeck
 	EquippedSlotBelt
 	EquippedSlotGloves
+	EquippedSlotRightArmSwap // the right arm of the second weapon set
+	EquippedSlotLeftArmSwap  // the left arm of the second weapon set
 )
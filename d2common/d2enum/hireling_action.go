@@ -0,0 +1,16 @@
+package d2enum
+
+// HirelingAction is what a player asks the server to do with a hireling, or what happened to
+// the hireling of a player
+type HirelingAction int
+
+// Hireling actions
+const (
+	HirelingList HirelingAction = iota
+	HirelingHire
+	HirelingEquip
+	HirelingUnequip
+	HirelingResurrect
+	HirelingLevelUp // sent by the server when the hireling gains a level
+	HirelingDeath   // sent by the server when the hireling dies
+)
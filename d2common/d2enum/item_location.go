@@ -0,0 +1,28 @@
+package d2enum
+
+// ItemLocation is where an item is, as saved with the item
+type ItemLocation int
+
+// Item locations
+const (
+	ItemLocationStored ItemLocation = iota
+	ItemLocationEquipped
+	ItemLocationBelt
+	ItemLocationGround
+	ItemLocationCursor
+	_
+	ItemLocationSocket
+)
+
+// ItemStorage is the storage panel of a stored item, as saved with the item
+type ItemStorage int
+
+// Item storage panels
+const (
+	ItemStorageNone ItemStorage = iota
+	ItemStorageInventory
+	_
+	_
+	ItemStorageCube
+	ItemStorageStash
+)
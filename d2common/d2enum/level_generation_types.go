@@ -11,7 +11,7 @@ type LevelGenerationType int
 
 // Level generation types
 const (
-	LevelTypeRandomMaze LevelGenerationType = iota
+	LevelTypeRandomMaze LevelGenerationType = iota + 1
 	LevelTypePreset
 	LevelTypeWilderness
 )
@@ -0,0 +1,16 @@
+package d2enum
+
+// MonsterActionType is an action the AI of the server decided a monster takes
+type MonsterActionType int
+
+// Monster actions
+const (
+	// MonsterActionNone keeps the monster doing what it did
+	MonsterActionNone MonsterActionType = iota
+	// MonsterActionMove moves the monster along a path
+	MonsterActionMove
+	// MonsterActionAttack attacks the target of the monster
+	MonsterActionAttack
+	// MonsterActionResurrect brings a dead monster back to life
+	MonsterActionResurrect
+)
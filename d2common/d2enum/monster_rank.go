@@ -0,0 +1,18 @@
+package d2enum
+
+// MonsterRank is the rank of a spawned monster, which decides its bonuses and modifiers
+type MonsterRank int
+
+// Monster ranks
+const (
+	// MonsterRankNormal is a regular monster of a pack
+	MonsterRankNormal MonsterRank = iota
+	// MonsterRankChampion is a monster of a champion pack
+	MonsterRankChampion
+	// MonsterRankUnique is the leader of a unique pack, with a random name
+	MonsterRankUnique
+	// MonsterRankSuperUnique is a named monster of SuperUniques.txt
+	MonsterRankSuperUnique
+	// MonsterRankMinion follows a unique or super unique monster
+	MonsterRankMinion
+)
@@ -0,0 +1,31 @@
+package d2enum
+
+// ShrineType is the Code of Shrines.txt, which selects the effect of a shrine
+type ShrineType int
+
+// Shrine types
+const (
+	ShrineNone ShrineType = iota
+	ShrineRefilling
+	ShrineHealth
+	ShrineMana
+	ShrineHealthExchange
+	ShrineManaExchange
+	ShrineArmor
+	ShrineCombat
+	ShrineResistFire
+	ShrineResistCold
+	ShrineResistLightning
+	ShrineResistPoison
+	ShrineSkill
+	ShrineManaRecharge
+	ShrineStamina
+	ShrineExperience
+	ShrineEnirhs
+	ShrinePortal
+	ShrineGem
+	ShrineFire
+	ShrineMonster
+	ShrineExploding
+	ShrinePoison
+)
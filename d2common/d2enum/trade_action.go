@@ -0,0 +1,12 @@
+package d2enum
+
+// TradeAction is what a player does with an item at a vendor
+type TradeAction int
+
+// Trade actions
+const (
+	TradeBuy TradeAction = iota
+	TradeSell
+	TradeRepair
+	TradeGamble
+)
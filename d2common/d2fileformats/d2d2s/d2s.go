@@ -0,0 +1,463 @@
+package d2d2s
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2datautils"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+)
+
+const (
+	// Signature is the magic number every D2S file starts with
+	Signature uint32 = 0xAA55AA55
+
+	// Version is the save version written by Diablo II 1.10 and later, the only one supported
+	Version uint32 = 0x60
+)
+
+// Status flags
+const (
+	StatusHardcore  byte = 1 << 2
+	StatusDied      byte = 1 << 3
+	StatusExpansion byte = 1 << 5
+	StatusLadder    byte = 1 << 6
+)
+
+const (
+	numDifficulties     = 3
+	numHotkeys          = 16
+	numAppearanceBytes  = 32
+	numClassSkills      = 30
+	numQuestBytes       = 96
+	numWaypointBytes    = 24
+	numNPCBytes         = 48
+	maxNameLength       = 16
+	difficultyActiveBit = 0x80
+	difficultyActMask   = 0x07
+)
+
+// Offsets and sizes of the fixed length part of the file
+const (
+	offsetChecksum    = 12
+	offsetName        = 20
+	offsetStatus      = 36
+	offsetClass       = 40
+	offsetLevel       = 43
+	offsetTimestamp   = 48
+	offsetHotkeys     = 56
+	offsetMouseSkills = 120
+	offsetAppearance  = 136
+	offsetDifficulty  = 168
+	offsetMapID       = 171
+	offsetMercenary   = 177
+	offsetQuests      = 335
+	offsetWaypoints   = 633
+	offsetNPCs        = 713
+	offsetStats       = 765
+
+	questHeaderSize    = 10
+	waypointHeaderSize = 8
+	npcHeaderSize      = 4
+	sectionHeaderSize  = 2
+)
+
+const (
+	questVersion    = 6
+	questSize       = 298
+	waypointVersion = 1
+	waypointSize    = 80
+	npcSize         = 52
+)
+
+// nolint:gochecknoglobals // section magic values
+var (
+	magicQuests    = []byte("Woo!")
+	magicWaypoints = []byte("WS")
+	magicNPCs      = []byte{0x01, 0x77}
+	magicStats     = []byte("gf")
+	magicSkills    = []byte("if")
+	magicItems     = []byte("JM")
+
+	// emptyItemLists is an empty corpse item list followed by the empty mercenary and golem sections
+	emptyItemLists = []byte{'J', 'M', 0, 0, 'j', 'f', 'k', 'f', 0}
+)
+
+// classes lists the heroes in the order of the class byte of the D2S file
+// nolint:gochecknoglobals // lookup table
+var classes = [...]d2enum.Hero{
+	d2enum.HeroAmazon,
+	d2enum.HeroSorceress,
+	d2enum.HeroNecromancer,
+	d2enum.HeroPaladin,
+	d2enum.HeroBarbarian,
+	d2enum.HeroDruid,
+	d2enum.HeroAssassin,
+}
+
+// Mercenary holds the hireling data of the character
+type Mercenary struct {
+	Dead       uint16
+	ID         uint32
+	NameID     uint16
+	Type       uint16
+	Experience uint32
+}
+
+// D2S represents a Diablo II character save file
+type D2S struct {
+	Version        uint32
+	ActiveWeapon   uint32
+	Name           string
+	Status         byte
+	Progression    byte
+	Class          d2enum.Hero
+	Level          byte
+	Timestamp      uint32
+	Hotkeys        [numHotkeys]uint32
+	LeftSkill      uint32
+	RightSkill     uint32
+	LeftSwapSkill  uint32
+	RightSwapSkill uint32
+	Appearance     [numAppearanceBytes]byte
+	Difficulty     [numDifficulties]byte
+	MapID          uint32
+	Mercenary      Mercenary
+	Quests         [numDifficulties][numQuestBytes]byte
+	Waypoints      [numDifficulties][numWaypointBytes]byte
+	NPCs           [numNPCBytes]byte
+	Stats          Stats
+	Skills         [numClassSkills]byte
+
+	// ItemCount is the number of items in the player item list
+	ItemCount uint16
+	// ItemData holds the raw item lists, beginning with the first item of the player item list
+	// and including the corpse, mercenary and golem sections
+	ItemData []byte
+
+	// header bytes whose meaning is unknown, kept so that files round-trip unchanged
+	header []byte
+}
+
+// New creates a new D2S for a level 1 hero of the given class
+func New(name string, class d2enum.Hero) *D2S {
+	d := &D2S{
+		Version:  Version,
+		Name:     name,
+		Status:   StatusExpansion,
+		Class:    class,
+		Level:    1,
+		header:   make([]byte, offsetQuests),
+		ItemData: append([]byte{}, emptyItemLists...),
+	}
+
+	d.Difficulty[d2enum.DifficultyNormal] = difficultyActiveBit
+
+	for difficulty := range d.Waypoints {
+		// the Rogue Encampment waypoint is always active
+		d.Waypoints[difficulty][0] = 0x02
+		d.Waypoints[difficulty][1] = 0x01
+		d.Waypoints[difficulty][2] = 0x01
+	}
+
+	d.Stats[StatLevel] = 1
+
+	return d
+}
+
+// Load parses the given D2S file data
+func Load(data []byte) (*D2S, error) {
+	if len(data) < offsetStats+len(magicStats) {
+		return nil, errors.New("d2s: file is too small")
+	}
+
+	d := &D2S{header: make([]byte, offsetQuests)}
+	copy(d.header, data[:offsetQuests])
+
+	if sig := binary.LittleEndian.Uint32(d.header); sig != Signature {
+		return nil, fmt.Errorf("d2s: invalid signature %X", sig)
+	}
+
+	if d.Version = binary.LittleEndian.Uint32(d.header[4:]); d.Version != Version {
+		return nil, fmt.Errorf("d2s: unsupported version %X", d.Version)
+	}
+
+	stored := binary.LittleEndian.Uint32(d.header[offsetChecksum:])
+	if sum := Checksum(data); sum != stored {
+		return nil, fmt.Errorf("d2s: checksum mismatch, expected %X but got %X", stored, sum)
+	}
+
+	if err := d.loadHeader(); err != nil {
+		return nil, err
+	}
+
+	if err := d.loadProgress(data); err != nil {
+		return nil, err
+	}
+
+	offset, err := d.Stats.load(data, offsetStats)
+	if err != nil {
+		return nil, err
+	}
+
+	return d, d.loadSkillsAndItems(data[offset:])
+}
+
+func (d *D2S) loadHeader() error {
+	h := d.header
+
+	d.ActiveWeapon = binary.LittleEndian.Uint32(h[16:])
+	d.Name = strings.TrimRight(string(h[offsetName:offsetName+maxNameLength]), "\x00")
+	d.Status = h[offsetStatus]
+	d.Progression = h[offsetStatus+1]
+
+	if int(h[offsetClass]) >= len(classes) {
+		return fmt.Errorf("d2s: unknown class %d", h[offsetClass])
+	}
+
+	d.Class = classes[h[offsetClass]]
+	d.Level = h[offsetLevel]
+	d.Timestamp = binary.LittleEndian.Uint32(h[offsetTimestamp:])
+
+	for idx := range d.Hotkeys {
+		d.Hotkeys[idx] = binary.LittleEndian.Uint32(h[offsetHotkeys+idx*4:])
+	}
+
+	d.LeftSkill = binary.LittleEndian.Uint32(h[offsetMouseSkills:])
+	d.RightSkill = binary.LittleEndian.Uint32(h[offsetMouseSkills+4:])
+	d.LeftSwapSkill = binary.LittleEndian.Uint32(h[offsetMouseSkills+8:])
+	d.RightSwapSkill = binary.LittleEndian.Uint32(h[offsetMouseSkills+12:])
+
+	copy(d.Appearance[:], h[offsetAppearance:])
+	copy(d.Difficulty[:], h[offsetDifficulty:])
+	d.MapID = binary.LittleEndian.Uint32(h[offsetMapID:])
+
+	d.Mercenary = Mercenary{
+		Dead:       binary.LittleEndian.Uint16(h[offsetMercenary:]),
+		ID:         binary.LittleEndian.Uint32(h[offsetMercenary+2:]),
+		NameID:     binary.LittleEndian.Uint16(h[offsetMercenary+6:]),
+		Type:       binary.LittleEndian.Uint16(h[offsetMercenary+8:]),
+		Experience: binary.LittleEndian.Uint32(h[offsetMercenary+10:]),
+	}
+
+	return nil
+}
+
+func (d *D2S) loadProgress(data []byte) error {
+	if !bytes.Equal(data[offsetQuests:offsetQuests+len(magicQuests)], magicQuests) {
+		return errors.New("d2s: quest section not found")
+	}
+
+	for difficulty := range d.Quests {
+		from := offsetQuests + questHeaderSize + difficulty*numQuestBytes
+		copy(d.Quests[difficulty][:], data[from:])
+	}
+
+	if !bytes.Equal(data[offsetWaypoints:offsetWaypoints+len(magicWaypoints)], magicWaypoints) {
+		return errors.New("d2s: waypoint section not found")
+	}
+
+	for difficulty := range d.Waypoints {
+		from := offsetWaypoints + waypointHeaderSize + difficulty*numWaypointBytes
+		copy(d.Waypoints[difficulty][:], data[from:])
+	}
+
+	if !bytes.Equal(data[offsetNPCs:offsetNPCs+len(magicNPCs)], magicNPCs) {
+		return errors.New("d2s: npc section not found")
+	}
+
+	copy(d.NPCs[:], data[offsetNPCs+npcHeaderSize:])
+
+	return nil
+}
+
+func (d *D2S) loadSkillsAndItems(data []byte) error {
+	const minSize = sectionHeaderSize + numClassSkills + sectionHeaderSize + 2
+
+	if len(data) < minSize || !bytes.Equal(data[:sectionHeaderSize], magicSkills) {
+		return errors.New("d2s: skill section not found")
+	}
+
+	copy(d.Skills[:], data[sectionHeaderSize:])
+	data = data[sectionHeaderSize+numClassSkills:]
+
+	if !bytes.Equal(data[:sectionHeaderSize], magicItems) {
+		return errors.New("d2s: item section not found")
+	}
+
+	d.ItemCount = binary.LittleEndian.Uint16(data[sectionHeaderSize:])
+	d.ItemData = append([]byte{}, data[sectionHeaderSize+2:]...)
+
+	return nil
+}
+
+// Marshal encodes the D2S to a byte slice, updating the file size and checksum
+func (d *D2S) Marshal() []byte {
+	sw := d2datautils.CreateStreamWriter()
+
+	sw.PushBytes(d.marshalHeader()...)
+
+	sw.PushBytes(magicQuests...)
+	sw.PushUint32(questVersion)
+	sw.PushUint16(questSize)
+
+	for difficulty := range d.Quests {
+		sw.PushBytes(d.Quests[difficulty][:]...)
+	}
+
+	sw.PushBytes(magicWaypoints...)
+	sw.PushUint32(waypointVersion)
+	sw.PushUint16(waypointSize)
+
+	for difficulty := range d.Waypoints {
+		sw.PushBytes(d.Waypoints[difficulty][:]...)
+	}
+
+	sw.PushBytes(magicNPCs...)
+	sw.PushUint16(npcSize)
+	sw.PushBytes(d.NPCs[:]...)
+
+	sw.PushBytes(magicStats...)
+	sw.PushBytes(d.Stats.marshal()...)
+
+	sw.PushBytes(magicSkills...)
+	sw.PushBytes(d.Skills[:]...)
+
+	sw.PushBytes(magicItems...)
+	sw.PushUint16(d.ItemCount)
+	sw.PushBytes(d.ItemData...)
+
+	data := sw.GetBytes()
+
+	binary.LittleEndian.PutUint32(data[8:], uint32(len(data)))
+	binary.LittleEndian.PutUint32(data[offsetChecksum:], Checksum(data))
+
+	return data
+}
+
+func (d *D2S) marshalHeader() []byte {
+	h := make([]byte, offsetQuests)
+	copy(h, d.header)
+
+	binary.LittleEndian.PutUint32(h, Signature)
+	binary.LittleEndian.PutUint32(h[4:], d.Version)
+	binary.LittleEndian.PutUint32(h[16:], d.ActiveWeapon)
+
+	name := make([]byte, maxNameLength)
+	copy(name[:maxNameLength-1], d.Name)
+	copy(h[offsetName:], name)
+
+	h[offsetStatus] = d.Status
+	h[offsetStatus+1] = d.Progression
+	h[offsetClass] = classByte(d.Class)
+	h[offsetLevel] = d.Level
+	binary.LittleEndian.PutUint32(h[offsetTimestamp:], d.Timestamp)
+
+	for idx := range d.Hotkeys {
+		binary.LittleEndian.PutUint32(h[offsetHotkeys+idx*4:], d.Hotkeys[idx])
+	}
+
+	binary.LittleEndian.PutUint32(h[offsetMouseSkills:], d.LeftSkill)
+	binary.LittleEndian.PutUint32(h[offsetMouseSkills+4:], d.RightSkill)
+	binary.LittleEndian.PutUint32(h[offsetMouseSkills+8:], d.LeftSwapSkill)
+	binary.LittleEndian.PutUint32(h[offsetMouseSkills+12:], d.RightSwapSkill)
+
+	copy(h[offsetAppearance:], d.Appearance[:])
+	copy(h[offsetDifficulty:], d.Difficulty[:])
+	binary.LittleEndian.PutUint32(h[offsetMapID:], d.MapID)
+
+	binary.LittleEndian.PutUint16(h[offsetMercenary:], d.Mercenary.Dead)
+	binary.LittleEndian.PutUint32(h[offsetMercenary+2:], d.Mercenary.ID)
+	binary.LittleEndian.PutUint16(h[offsetMercenary+6:], d.Mercenary.NameID)
+	binary.LittleEndian.PutUint16(h[offsetMercenary+8:], d.Mercenary.Type)
+	binary.LittleEndian.PutUint32(h[offsetMercenary+10:], d.Mercenary.Experience)
+
+	return h
+}
+
+// CurrentDifficulty returns the difficulty and act (starting at 1) the character was last played in
+func (d *D2S) CurrentDifficulty() (difficulty d2enum.DifficultyType, act int) {
+	for idx := range d.Difficulty {
+		if d.Difficulty[idx]&difficultyActiveBit != 0 {
+			return d2enum.DifficultyType(idx), int(d.Difficulty[idx]&difficultyActMask) + 1
+		}
+	}
+
+	return d2enum.DifficultyNormal, 1
+}
+
+// SetCurrentDifficulty sets the difficulty and act (starting at 1) the character is played in
+func (d *D2S) SetCurrentDifficulty(difficulty d2enum.DifficultyType, act int) {
+	for idx := range d.Difficulty {
+		d.Difficulty[idx] &^= difficultyActiveBit
+	}
+
+	if act < 1 {
+		act = 1
+	}
+
+	d.Difficulty[difficulty] = difficultyActiveBit | byte(act-1)&difficultyActMask
+}
+
+// IsExpansion returns true if this is a Lord of Destruction character
+func (d *D2S) IsExpansion() bool {
+	return d.Status&StatusExpansion != 0
+}
+
+// FirstClassSkillID returns the skill ID of the first of the 30 class skills of the given hero.
+// The skill section of the file stores the skill points of the class skills in this order.
+func FirstClassSkillID(hero d2enum.Hero) int {
+	switch hero {
+	case d2enum.HeroAmazon:
+		return 6
+	case d2enum.HeroSorceress:
+		return 36
+	case d2enum.HeroNecromancer:
+		return 66
+	case d2enum.HeroPaladin:
+		return 96
+	case d2enum.HeroBarbarian:
+		return 126
+	case d2enum.HeroDruid:
+		return 221
+	case d2enum.HeroAssassin:
+		return 251
+	}
+
+	return 0
+}
+
+// NumClassSkills is the number of skills in the skill section of the file
+func NumClassSkills() int {
+	return numClassSkills
+}
+
+// Checksum computes the checksum of the given file data, treating the checksum field as zero
+func Checksum(data []byte) uint32 {
+	var sum uint32
+
+	for idx := range data {
+		b := data[idx]
+
+		if idx >= offsetChecksum && idx < offsetChecksum+4 {
+			b = 0
+		}
+
+		sum = (sum<<1 | sum>>31) + uint32(b)
+	}
+
+	return sum
+}
+
+func classByte(hero d2enum.Hero) byte {
+	for idx := range classes {
+		if classes[idx] == hero {
+			return byte(idx)
+		}
+	}
+
+	return 0
+}
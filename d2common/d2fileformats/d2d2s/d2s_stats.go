@@ -0,0 +1,118 @@
+package d2d2s
+
+import (
+	"errors"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2datautils"
+)
+
+// StatID is the ID of a character stat in the stat section of the file
+type StatID int
+
+// Character stats, the IDs match the rows of ItemStatCost.txt
+const (
+	StatStrength StatID = iota
+	StatEnergy
+	StatDexterity
+	StatVitality
+	StatStatPoints
+	StatSkillPoints
+	StatLife
+	StatMaxLife
+	StatMana
+	StatMaxMana
+	StatStamina
+	StatMaxStamina
+	StatLevel
+	StatExperience
+	StatGold
+	StatStashGold
+	numStats
+)
+
+const (
+	statIDBits     = 9
+	statTerminator = 0x1FF
+
+	// life, mana and stamina are stored as fixed point numbers
+	fixedPointShift = 8
+
+	bitsPerByte = 8
+)
+
+// statBits is the number of bits used to save each stat (CSvBits in ItemStatCost.txt)
+// nolint:gochecknoglobals // lookup table
+var statBits = [numStats]int{10, 10, 10, 10, 10, 8, 21, 21, 21, 21, 21, 21, 7, 32, 25, 25}
+
+// Stats holds the values of the character stats. Life, mana and stamina are fixed point
+// numbers, use the Get/SetFixed methods to access them as whole numbers.
+type Stats [numStats]uint32
+
+// GetFixed returns the whole number value of a fixed point stat
+func (s *Stats) GetFixed(id StatID) int {
+	return int(s[id] >> fixedPointShift)
+}
+
+// SetFixed sets a fixed point stat to the given whole number value
+func (s *Stats) SetFixed(id StatID, value int) {
+	s[id] = uint32(value) << fixedPointShift
+}
+
+// load reads the stat section which starts at the given offset and returns the offset
+// of the first byte after the section
+func (s *Stats) load(data []byte, offset int) (int, error) {
+	if string(data[offset:offset+len(magicStats)]) != string(magicStats) {
+		return 0, errors.New("d2s: stat section not found")
+	}
+
+	offset += len(magicStats)
+	bm := d2datautils.CreateBitMuncher(data, offset*bitsPerByte)
+
+	for {
+		if bm.Offset()+statIDBits > len(data)*bitsPerByte {
+			return 0, errors.New("d2s: unexpected end of stat section")
+		}
+
+		id := bm.GetBits(statIDBits)
+		if id == statTerminator {
+			break
+		}
+
+		if id >= uint32(numStats) {
+			return 0, errors.New("d2s: unknown stat in stat section")
+		}
+
+		if bm.Offset()+statBits[id] > len(data)*bitsPerByte {
+			return 0, errors.New("d2s: unexpected end of stat section")
+		}
+
+		s[id] = bm.GetBits(statBits[id])
+	}
+
+	return (bm.Offset() + bitsPerByte - 1) / bitsPerByte, nil
+}
+
+// marshal encodes the non-zero stats, the result is padded to a whole byte
+func (s *Stats) marshal() []byte {
+	sw := d2datautils.CreateStreamWriter()
+	bitCount := 0
+
+	for id := range s {
+		if s[id] == 0 {
+			continue
+		}
+
+		sw.PushBits16(uint16(id), statIDBits)
+		sw.PushBits32(s[id], statBits[id])
+		bitCount += statIDBits + statBits[id]
+	}
+
+	sw.PushBits16(statTerminator, statIDBits)
+	bitCount += statIDBits
+
+	for ; bitCount%bitsPerByte != 0; bitCount++ {
+		sw.PushBit(false)
+	}
+
+	return sw.GetBytes()
+}
@@ -0,0 +1,85 @@
+package d2d2s
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+)
+
+func testSave() *D2S {
+	d := New("Tester", d2enum.HeroPaladin)
+
+	d.Level = 24
+	d.LeftSkill = 0
+	d.RightSkill = 97
+	d.Stats[StatStrength] = 75
+	d.Stats[StatDexterity] = 35
+	d.Stats[StatVitality] = 120
+	d.Stats[StatEnergy] = 15
+	d.Stats.SetFixed(StatLife, 420)
+	d.Stats.SetFixed(StatMaxLife, 430)
+	d.Stats[StatLevel] = 24
+	d.Stats[StatExperience] = 1234567
+	d.Stats[StatGold] = 9000
+	d.Skills[1] = 20
+	d.Quests[0][2] = 0x01
+	d.SetCurrentDifficulty(d2enum.DifficultyNightmare, 3)
+
+	return d
+}
+
+func TestD2S_MarshalLoad(t *testing.T) {
+	d := testSave()
+	data := d.Marshal()
+
+	loaded, err := Load(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if loaded.Name != d.Name || loaded.Class != d.Class || loaded.Level != d.Level {
+		t.Errorf("header mismatch: %s %s %d", loaded.Name, loaded.Class, loaded.Level)
+	}
+
+	if loaded.Stats != d.Stats {
+		t.Errorf("stats mismatch: expected %v, got %v", d.Stats, loaded.Stats)
+	}
+
+	if loaded.Skills != d.Skills || loaded.Quests != d.Quests || loaded.Waypoints != d.Waypoints {
+		t.Error("skill or progress sections mismatch")
+	}
+
+	if loaded.Stats.GetFixed(StatLife) != 420 {
+		t.Errorf("expected life 420, got %d", loaded.Stats.GetFixed(StatLife))
+	}
+
+	if difficulty, act := loaded.CurrentDifficulty(); difficulty != d2enum.DifficultyNightmare || act != 3 {
+		t.Errorf("expected nightmare act 3, got %d act %d", difficulty, act)
+	}
+
+	if !bytes.Equal(loaded.Marshal(), data) {
+		t.Error("marshaled data does not match loaded data")
+	}
+}
+
+func TestD2S_SectionOffsets(t *testing.T) {
+	data := testSave().Marshal()
+
+	if !bytes.Equal(data[offsetWaypoints:offsetWaypoints+2], magicWaypoints) {
+		t.Error("waypoint section is not at the expected offset")
+	}
+
+	if !bytes.Equal(data[offsetStats:offsetStats+2], magicStats) {
+		t.Error("stat section is not at the expected offset")
+	}
+}
+
+func TestD2S_Checksum(t *testing.T) {
+	data := testSave().Marshal()
+	data[offsetLevel]++
+
+	if _, err := Load(data); err == nil {
+		t.Error("expected checksum error for modified file")
+	}
+}
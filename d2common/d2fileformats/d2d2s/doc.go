@@ -0,0 +1,3 @@
+// Package d2d2s contains the logic for loading and saving the original Diablo II
+// character save (D2S) files.
+package d2d2s
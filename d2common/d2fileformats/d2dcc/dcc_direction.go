@@ -43,11 +43,16 @@ type DCCDirection struct {
 	PixelBuffer                []DCCPixelBufferEntry
 }
 
+// getCrazyBitTable returns the bit widths of the frame header fields, a direction header
+// stores an index into this table for every field
+func getCrazyBitTable() []byte {
+	return []byte{0, 1, 2, 4, 6, 8, 10, 12, 14, 16, 20, 24, 26, 28, 30, 32} // nolint:gomnd // constant
+}
+
 // CreateDCCDirection creates an instance of a DCCDirection.
 // nolint:funlen // no need to reduce
 func CreateDCCDirection(bm *d2datautils.BitMuncher, file *DCC) *DCCDirection {
-	// nolint:gomnd // constant
-	var crazyBitTable = []byte{0, 1, 2, 4, 6, 8, 10, 12, 14, 16, 20, 24, 26, 28, 30, 32}
+	var crazyBitTable = getCrazyBitTable()
 
 	result := &DCCDirection{
 		OutSizeCoded:     int(bm.GetUInt32()),
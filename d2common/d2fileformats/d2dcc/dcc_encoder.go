@@ -0,0 +1,548 @@
+package d2dcc
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2datautils"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2geom"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math"
+)
+
+const (
+	defaultDCCVersion = 6
+	dccHeaderSize     = 15 // signature, version, direction count and three int32 values
+	directionSizeLen  = 4  // the size of a direction is stored before its bit stream
+
+	compressionEqualCells   = 0x2
+	compressionEncodingType = 0x1
+
+	bitTableIndexBits   = 4
+	bitstreamSizeBits   = 20
+	maxBitstreamSize    = 1<<bitstreamSizeBits - 1
+	compressionFlagBits = 2
+	paletteSize         = 256
+
+	cellColors          = 4
+	pixelMaskBits       = 4
+	pixelMaskAllColors  = 0x0F
+	rawPixelBits        = 8
+	displacementBits    = 4
+	maxDisplacement     = 15
+	bitsPerByte         = 8
+	singleColorCodeBits = 1
+	multiColorCodeBits  = 2
+)
+
+// dccBitstream collects the bits of one of the bit streams of a direction
+type dccBitstream struct {
+	bits []bool
+}
+
+func (b *dccBitstream) push(value uint32, count int) {
+	for i := 0; i < count; i++ {
+		b.bits = append(b.bits, (value>>uint(i))&1 == 1)
+	}
+}
+
+func (b *dccBitstream) pushSigned(value, count int) {
+	b.push(uint32(int32(value)), count)
+}
+
+func (b *dccBitstream) append(other *dccBitstream) {
+	b.bits = append(b.bits, other.bits...)
+}
+
+// dccDirectionEncoder encodes the frames of a direction. It keeps track of the cell buffer and
+// the pixels of the direction exactly like CreateDCCDirection does while decoding, so cells
+// which the decoder can copy from a previous frame are stored as equal cells.
+type dccDirectionEncoder struct {
+	direction *DCCDirection
+	palette   [paletteSize]bool
+	pixels    []byte
+	targets   [][]byte
+
+	equalCells, pixelMask, encodingType, rawPixelCodes dccBitstream
+	displacements, pixelCodes                          dccBitstream
+}
+
+// Marshal encodes the DCC. The pixels of a frame are taken from its PixelData, which has either
+// the size of the frame (Width * Height) or the size of the box of its direction, like the
+// frames created by Load. Only the frame headers (Width, Height, XOffset, YOffset and
+// NumberOfCodedBytes) and the pixels are encoded. A cell of 4x4 pixels can not have more than
+// 4 colors, including the transparent color 0.
+func (d *DCC) Marshal() ([]byte, error) {
+	directions := make([][]byte, len(d.Directions))
+	totalSize := 0
+
+	for idx, direction := range d.Directions {
+		if len(direction.Frames) != d.FramesPerDirection {
+			return nil, fmt.Errorf("direction %d has %d frames, expected %d", idx, len(direction.Frames), d.FramesPerDirection)
+		}
+
+		data, err := encodeDirection(direction)
+		if err != nil {
+			return nil, fmt.Errorf("direction %d: %v", idx, err)
+		}
+
+		directions[idx] = data
+		totalSize += len(data)
+	}
+
+	version := d.Version
+	if version == 0 {
+		version = defaultDCCVersion
+	}
+
+	sw := d2datautils.CreateStreamWriter()
+
+	sw.PushBytes(dccFileSignature, byte(version), byte(len(d.Directions)))
+	sw.PushInt32(int32(d.FramesPerDirection))
+	sw.PushInt32(1)
+	sw.PushInt32(int32(totalSize))
+
+	offset := dccHeaderSize + len(directions)*directionSizeLen
+
+	for _, data := range directions {
+		sw.PushInt32(int32(offset))
+		offset += len(data)
+	}
+
+	for _, data := range directions {
+		sw.PushBytes(data...)
+	}
+
+	return sw.GetBytes(), nil
+}
+
+// encodeDirection returns the byte aligned data of a direction
+func encodeDirection(source *DCCDirection) ([]byte, error) {
+	e, err := newDirectionEncoder(source)
+	if err != nil {
+		return nil, err
+	}
+
+	for frameIdx, frame := range e.direction.Frames {
+		for cellIdx := range frame.Cells {
+			if err := e.encodeCell(e.targets[frameIdx], &frame.Cells[cellIdx]); err != nil {
+				return nil, fmt.Errorf("frame %d: %v", frameIdx, err)
+			}
+		}
+	}
+
+	body, err := e.bitstream()
+	if err != nil {
+		return nil, err
+	}
+
+	sw := d2datautils.CreateStreamWriter()
+	size := directionSizeLen + (len(body.bits)+bitsPerByte-1)/bitsPerByte
+
+	sw.PushUint32(uint32(size))
+
+	for _, bit := range body.bits {
+		sw.PushBit(bit)
+	}
+
+	if padding := len(body.bits) % bitsPerByte; padding != 0 {
+		sw.PushBits(0, bitsPerByte-padding)
+	}
+
+	return sw.GetBytes(), nil
+}
+
+// newDirectionEncoder creates the direction box, the cells and the palette of the direction
+// and converts the pixels of the frames to palette entry indexes
+func newDirectionEncoder(source *DCCDirection) (*dccDirectionEncoder, error) {
+	if len(source.Frames) == 0 {
+		return nil, errors.New("a direction needs at least one frame")
+	}
+
+	e := &dccDirectionEncoder{
+		direction: &DCCDirection{
+			CompressionFlags: compressionEqualCells | compressionEncodingType,
+			Frames:           make([]*DCCDirectionFrame, len(source.Frames)),
+		},
+		targets: make([][]byte, len(source.Frames)),
+	}
+
+	if err := e.createFrames(source); err != nil {
+		return nil, err
+	}
+
+	// the transparent color is always in the palette, as palette entry index 0
+	e.palette[0] = true
+
+	for idx, frame := range source.Frames {
+		pixels, err := e.framePixels(frame, e.direction.Frames[idx])
+		if err != nil {
+			return nil, fmt.Errorf("frame %d: %v", idx, err)
+		}
+
+		for _, pixel := range pixels {
+			e.palette[pixel] = true
+		}
+
+		e.targets[idx] = pixels
+	}
+
+	var paletteIndexes [paletteSize]byte
+
+	for color, count := 0, 0; color < paletteSize; color++ {
+		if e.palette[color] {
+			e.direction.PaletteEntries[count] = byte(color)
+			paletteIndexes[color] = byte(count)
+			count++
+		}
+	}
+
+	for _, pixels := range e.targets {
+		for idx := range pixels {
+			pixels[idx] = paletteIndexes[pixels[idx]]
+		}
+	}
+
+	e.direction.calculateCells()
+
+	for _, cell := range e.direction.Cells {
+		cell.LastWidth = -1
+		cell.LastHeight = -1
+	}
+
+	for _, frame := range e.direction.Frames {
+		frame.recalculateCells(e.direction)
+	}
+
+	e.pixels = make([]byte, e.direction.Box.Width*e.direction.Box.Height)
+
+	return e, nil
+}
+
+// createFrames copies the frame headers and calculates the boxes of the frames and direction
+func (e *dccDirectionEncoder) createFrames(source *DCCDirection) error {
+	minx, miny, maxx, maxy := baseMinx, baseMiny, baseMaxx, baseMaxy
+
+	for idx, frame := range source.Frames {
+		switch {
+		case frame == nil:
+			return fmt.Errorf("frame %d is nil", idx)
+		case frame.FrameIsBottomUp:
+			return fmt.Errorf("frame %d: bottom up frames are not supported", idx)
+		case frame.NumberOfOptionalBytes > 0:
+			return fmt.Errorf("frame %d: optional data is not supported", idx)
+		case frame.Width < 0 || frame.Height < 0 || frame.NumberOfCodedBytes < 0:
+			return fmt.Errorf("frame %d: invalid frame header", idx)
+		}
+
+		header := &DCCDirectionFrame{
+			Width:              frame.Width,
+			Height:             frame.Height,
+			XOffset:            frame.XOffset,
+			YOffset:            frame.YOffset,
+			NumberOfCodedBytes: frame.NumberOfCodedBytes,
+			Box: d2geom.Rectangle{
+				Left:   frame.XOffset,
+				Top:    frame.YOffset - frame.Height + 1,
+				Width:  frame.Width,
+				Height: frame.Height,
+			},
+			valid: true,
+		}
+
+		minx = d2math.MinInt(minx, header.Box.Left)
+		miny = d2math.MinInt(miny, header.Box.Top)
+		maxx = d2math.MaxInt(maxx, header.Box.Right())
+		maxy = d2math.MaxInt(maxy, header.Box.Bottom())
+
+		e.direction.Frames[idx] = header
+	}
+
+	e.direction.Box = d2geom.Rectangle{Left: minx, Top: miny, Width: maxx - minx, Height: maxy - miny}
+
+	return nil
+}
+
+// framePixels returns a copy of the pixels of a frame, with the size of the direction box
+func (e *dccDirectionEncoder) framePixels(source, frame *DCCDirectionFrame) ([]byte, error) {
+	box := e.direction.Box
+	pixels := make([]byte, box.Width*box.Height)
+
+	switch len(source.PixelData) {
+	case len(pixels):
+		copy(pixels, source.PixelData)
+	case frame.Width * frame.Height:
+		for y := 0; y < frame.Height; y++ {
+			offset := (frame.Box.Top-box.Top+y)*box.Width + frame.Box.Left - box.Left
+			copy(pixels[offset:offset+frame.Width], source.PixelData[y*frame.Width:(y+1)*frame.Width])
+		}
+	default:
+		return nil, errors.New("the pixel data has neither the size of the frame nor of the direction")
+	}
+
+	return pixels, nil
+}
+
+// encodeCell writes a cell of a frame to the bit streams
+func (e *dccDirectionEncoder) encodeCell(target []byte, cell *DCCCell) error {
+	bufferCell := e.direction.Cells[cell.XOffset/cellsPerRow+(cell.YOffset/cellsPerRow)*e.direction.HorizontalCellCount]
+
+	defer func() {
+		bufferCell.LastWidth = cell.Width
+		bufferCell.LastHeight = cell.Height
+		bufferCell.LastXOffset = cell.XOffset
+		bufferCell.LastYOffset = cell.YOffset
+	}()
+
+	// a buffer cell which was already used can be copied to the new position of the cell
+	if bufferCell.LastWidth >= 0 {
+		if e.copyCell(bufferCell, cell, target) {
+			e.equalCells.push(1, 1)
+			return nil
+		}
+
+		e.equalCells.push(0, 1)
+		e.pixelMask.push(pixelMaskAllColors, pixelMaskBits)
+	}
+
+	colors, transparent := e.cellColors(target, cell)
+	if len(colors) > cellColors || (len(colors) == cellColors && transparent) {
+		return fmt.Errorf("the cell at %d,%d has more than %d colors", cell.XOffset, cell.YOffset, cellColors)
+	}
+
+	e.encodePixelValues(colors)
+
+	// the decoder assigns the values in descending order, the remaining values are 0
+	var values [cellColors]byte
+
+	for idx := range colors {
+		values[idx] = colors[len(colors)-1-idx]
+	}
+
+	e.forEachPixel(cell, func(idx int) {
+		e.pixels[idx] = target[idx]
+	})
+
+	if values[0] == values[1] {
+		return nil
+	}
+
+	codeBits := multiColorCodeBits
+	if values[1] == values[2] {
+		codeBits = singleColorCodeBits
+	}
+
+	e.forEachPixel(cell, func(idx int) {
+		code := 0
+		for values[code] != target[idx] {
+			code++
+		}
+
+		e.pixelCodes.push(uint32(code), codeBits)
+	})
+
+	return nil
+}
+
+// copyCell does what the decoder does for an equal cell. It returns true if this results in
+// the target pixels, otherwise the pixels of the direction are restored.
+func (e *dccDirectionEncoder) copyCell(bufferCell, cell *DCCCell, target []byte) bool {
+	width := e.direction.Box.Width
+	sameSize := cell.Width == bufferCell.LastWidth && cell.Height == bufferCell.LastHeight
+	previous := make([]byte, 0, cell.Width*cell.Height)
+	equal := true
+
+	for y := 0; y < cell.Height; y++ {
+		for x := 0; x < cell.Width; x++ {
+			idx := cell.XOffset + x + (cell.YOffset+y)*width
+			previous = append(previous, e.pixels[idx])
+
+			if sameSize {
+				e.pixels[idx] = e.pixels[bufferCell.LastXOffset+x+(bufferCell.LastYOffset+y)*width]
+			} else {
+				e.pixels[idx] = 0
+			}
+
+			equal = equal && e.pixels[idx] == target[idx]
+		}
+	}
+
+	if equal {
+		return true
+	}
+
+	e.forEachPixel(cell, func(idx int) {
+		e.pixels[idx], previous = previous[0], previous[1:]
+	})
+
+	return false
+}
+
+// encodePixelValues writes the colors of a cell either as raw pixel codes or as displacements,
+// whichever is shorter. The colors are followed by a repeated value if there are less than 4.
+func (e *dccDirectionEncoder) encodePixelValues(colors []byte) {
+	stack := make([]uint32, 0, cellColors)
+	last := uint32(0)
+
+	for _, color := range colors {
+		stack = append(stack, uint32(color))
+		last = uint32(color)
+	}
+
+	if len(stack) < cellColors {
+		stack = append(stack, last)
+	}
+
+	displacements := make([]uint32, 0, len(stack))
+	last = 0
+
+	for _, value := range stack {
+		displacement := value - last
+		for ; displacement >= maxDisplacement; displacement -= maxDisplacement {
+			displacements = append(displacements, maxDisplacement)
+		}
+
+		displacements = append(displacements, displacement)
+		last = value
+	}
+
+	if len(stack)*rawPixelBits < len(displacements)*displacementBits {
+		e.encodingType.push(1, 1)
+
+		for _, value := range stack {
+			e.rawPixelCodes.push(value, rawPixelBits)
+		}
+
+		return
+	}
+
+	e.encodingType.push(0, 1)
+
+	for _, displacement := range displacements {
+		e.displacements.push(displacement, displacementBits)
+	}
+}
+
+// cellColors returns the sorted palette entry indexes, other than 0, which are used in a cell,
+// and whether the cell has transparent pixels
+func (e *dccDirectionEncoder) cellColors(target []byte, cell *DCCCell) (colors []byte, transparent bool) {
+	var used [paletteSize]bool
+
+	e.forEachPixel(cell, func(idx int) {
+		used[target[idx]] = true
+	})
+
+	for color := 1; color < paletteSize; color++ {
+		if used[color] {
+			colors = append(colors, byte(color))
+		}
+	}
+
+	return colors, used[0]
+}
+
+// forEachPixel calls fn with the index of every pixel of the cell in the direction box, in the
+// order used by the decoder
+func (e *dccDirectionEncoder) forEachPixel(cell *DCCCell, fn func(idx int)) {
+	for y := 0; y < cell.Height; y++ {
+		for x := 0; x < cell.Width; x++ {
+			fn(cell.XOffset + x + (cell.YOffset+y)*e.direction.Box.Width)
+		}
+	}
+}
+
+// bitstream returns the direction header, the frame headers and the bit streams of the cells
+func (e *dccDirectionEncoder) bitstream() (*dccBitstream, error) {
+	for _, stream := range []*dccBitstream{&e.equalCells, &e.pixelMask, &e.encodingType, &e.rawPixelCodes} {
+		if len(stream.bits) > maxBitstreamSize {
+			return nil, errors.New("the direction is too large")
+		}
+	}
+
+	var widthBits, heightBits, xOffsetBits, yOffsetBits, codedBytesBits int
+
+	for _, frame := range e.direction.Frames {
+		widthBits = d2math.MaxInt(widthBits, unsignedBitCount(frame.Width))
+		heightBits = d2math.MaxInt(heightBits, unsignedBitCount(frame.Height))
+		xOffsetBits = d2math.MaxInt(xOffsetBits, signedBitCount(frame.XOffset))
+		yOffsetBits = d2math.MaxInt(yOffsetBits, signedBitCount(frame.YOffset))
+		codedBytesBits = d2math.MaxInt(codedBytesBits, unsignedBitCount(frame.NumberOfCodedBytes))
+	}
+
+	result := &dccBitstream{}
+	crazyBitTable := getCrazyBitTable()
+	fieldBits := []*int{&e.direction.Variable0Bits, &widthBits, &heightBits, &xOffsetBits, &yOffsetBits,
+		&e.direction.OptionalDataBits, &codedBytesBits}
+
+	result.push(uint32(e.direction.CompressionFlags), compressionFlagBits)
+
+	for _, bits := range fieldBits {
+		idx := 0
+		for int(crazyBitTable[idx]) < *bits {
+			idx++
+		}
+
+		*bits = int(crazyBitTable[idx])
+
+		result.push(uint32(idx), bitTableIndexBits)
+	}
+
+	for _, frame := range e.direction.Frames {
+		result.push(uint32(frame.Width), widthBits)
+		result.push(uint32(frame.Height), heightBits)
+		result.pushSigned(frame.XOffset, xOffsetBits)
+		result.pushSigned(frame.YOffset, yOffsetBits)
+		result.push(uint32(frame.NumberOfCodedBytes), codedBytesBits)
+		result.push(0, 1) // FrameIsBottomUp
+	}
+
+	result.push(uint32(len(e.equalCells.bits)), bitstreamSizeBits)
+	result.push(uint32(len(e.pixelMask.bits)), bitstreamSizeBits)
+	result.push(uint32(len(e.encodingType.bits)), bitstreamSizeBits)
+	result.push(uint32(len(e.rawPixelCodes.bits)), bitstreamSizeBits)
+
+	for _, valid := range e.palette {
+		result.push(boolToUint32(valid), 1)
+	}
+
+	for _, stream := range []*dccBitstream{&e.equalCells, &e.pixelMask, &e.encodingType, &e.rawPixelCodes,
+		&e.displacements, &e.pixelCodes} {
+		result.append(stream)
+	}
+
+	return result, nil
+}
+
+// unsignedBitCount returns the number of bits needed to store the value
+func unsignedBitCount(value int) int {
+	bits := 0
+	for value>>uint(bits) != 0 {
+		bits++
+	}
+
+	return bits
+}
+
+// signedBitCount returns the number of bits needed to store the value with a sign bit. A single
+// bit stores 0 and -1.
+func signedBitCount(value int) int {
+	switch value {
+	case 0:
+		return 0
+	case -1:
+		return 1
+	}
+
+	if value < 0 {
+		value = -value - 1
+	}
+
+	return unsignedBitCount(value) + 1
+}
+
+func boolToUint32(value bool) uint32 {
+	if value {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,117 @@
+package d2dcc
+
+import (
+	"bytes"
+	"testing"
+)
+
+// getExampleDCC returns a DCC with frames which move, change their size and repeat, the pixel
+// data of the frames has the size of the frames
+func getExampleDCC() *DCC {
+	const framesPerDirection = 5
+
+	palette := [][]byte{{0, 17, 42, 200}, {0, 1, 2, 3}}
+	dcc := &DCC{
+		Version:            6,
+		NumberOfDirections: len(palette),
+		FramesPerDirection: framesPerDirection,
+	}
+
+	for _, colors := range palette {
+		direction := &DCCDirection{}
+
+		for frameIdx := 0; frameIdx < framesPerDirection; frameIdx++ {
+			// the second frame is the same as the first one
+			step := frameIdx
+			if frameIdx > 0 {
+				step--
+			}
+
+			frame := &DCCDirectionFrame{
+				Width:   13 + step*3,
+				Height:  9 + step,
+				XOffset: -10 + step*2,
+				YOffset: 5 - step,
+			}
+
+			frame.PixelData = make([]byte, frame.Width*frame.Height)
+
+			for y := 0; y < frame.Height; y++ {
+				for x := 0; x < frame.Width; x++ {
+					frame.PixelData[x+y*frame.Width] = colors[((x+y)/3+step)%len(colors)]
+				}
+			}
+
+			direction.Frames = append(direction.Frames, frame)
+		}
+
+		dcc.Directions = append(dcc.Directions, direction)
+	}
+
+	return dcc
+}
+
+func TestDCCMarshal(t *testing.T) {
+	example := getExampleDCC()
+
+	data, err := example.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if loaded.NumberOfDirections != example.NumberOfDirections || loaded.FramesPerDirection != example.FramesPerDirection {
+		t.Fatal("encoded and decoded DCC don't have the same number of frames")
+	}
+
+	for dirIdx, direction := range loaded.Directions {
+		box := direction.Box
+
+		for frameIdx, frame := range direction.Frames {
+			expected := example.Directions[dirIdx].Frames[frameIdx]
+
+			if frame.Width != expected.Width || frame.Height != expected.Height ||
+				frame.XOffset != expected.XOffset || frame.YOffset != expected.YOffset {
+				t.Fatalf("direction %d, frame %d: frame headers are not the same", dirIdx, frameIdx)
+			}
+
+			for y := 0; y < frame.Height; y++ {
+				offset := (frame.Box.Top-box.Top+y)*box.Width + frame.Box.Left - box.Left
+				row := frame.PixelData[offset : offset+frame.Width]
+
+				if !bytes.Equal(row, expected.PixelData[y*frame.Width:(y+1)*frame.Width]) {
+					t.Fatalf("direction %d, frame %d: row %d is not the same", dirIdx, frameIdx, y)
+				}
+			}
+		}
+	}
+
+	// frames created by Load have the size of the direction box, encoding them again gives the same file
+	reencoded, err := loaded.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(data, reencoded) {
+		t.Error("encoding a decoded DCC gives a different file")
+	}
+}
+
+func TestDCCMarshalTooManyColors(t *testing.T) {
+	dcc := &DCC{
+		FramesPerDirection: 1,
+		Directions: []*DCCDirection{{Frames: []*DCCDirectionFrame{{
+			Width:     4,
+			Height:    2,
+			PixelData: []byte{1, 2, 3, 4, 0, 0, 0, 0},
+		}}}},
+	}
+
+	if _, err := dcc.Marshal(); err == nil {
+		t.Error("expected an error for a cell with 5 colors")
+	}
+}
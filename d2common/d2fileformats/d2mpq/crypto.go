@@ -115,7 +115,7 @@ func hashString(key string, hashType uint32) uint32 {
 	return seed1
 }
 
-//nolint:unused,deadcode,gomnd // will use this for creating mpq's
+//nolint:gomnd // Encryption magic
 func encrypt(data []uint32, seed uint32) {
 	seed2 := uint32(0xeeeeeeee)
 
@@ -129,3 +129,18 @@ func encrypt(data []uint32, seed uint32) {
 		data[i] = result
 	}
 }
+
+//nolint:gomnd // Encryption magic
+func encryptBytes(data []byte, seed uint32) {
+	seed2 := uint32(0xEEEEEEEE)
+
+	for i := 0; i < len(data)-3; i += 4 {
+		seed2 += cryptoLookup(0x400 + (seed & 0xFF))
+		plain := binary.LittleEndian.Uint32(data[i : i+4])
+		result := plain ^ (seed + seed2)
+		seed = ((^seed << 21) + 0x11111111) | (seed >> 11)
+		seed2 = plain + seed2 + (seed2 << 5) + 3
+
+		binary.LittleEndian.PutUint32(data[i:i+4], result)
+	}
+}
@@ -33,7 +33,7 @@ func CreateStream(mpq *MPQ, block *Block, fileName string) (*Stream, error) {
 		Index: 0xFFFFFFFF, //nolint:gomnd // MPQ magic
 	}
 
-	if s.Block.HasFlag(FileFixKey) {
+	if s.Block.HasFlag(FileEncrypted) {
 		s.Block.calculateEncryptionSeed(fileName)
 	}
 
@@ -155,21 +155,30 @@ func (v *Stream) bufferData() (err error) {
 }
 
 func (v *Stream) loadSingleUnit() (err error) {
-	if _, err = v.MPQ.file.Seek(int64(v.MPQ.header.HeaderSize), io.SeekStart); err != nil {
+	if _, err = v.MPQ.file.Seek(int64(v.Block.FilePosition), io.SeekStart); err != nil {
 		return err
 	}
 
-	fileData := make([]byte, v.Size)
+	fileData := make([]byte, v.Block.CompressedFileSize)
 
-	if _, err = v.MPQ.file.Read(fileData); err != nil {
+	if _, err = io.ReadFull(v.MPQ.file, fileData); err != nil {
 		return err
 	}
 
-	if v.Size == v.Block.UncompressedFileSize {
+	if v.Block.HasFlag(FileEncrypted) && v.Block.UncompressedFileSize > 3 {
+		decryptBytes(fileData, v.Block.EncryptionSeed)
+	}
+
+	if v.Block.CompressedFileSize == v.Block.UncompressedFileSize {
 		v.Data = fileData
 		return nil
 	}
 
+	if v.Block.HasFlag(FileImplode) {
+		v.Data, err = pkDecompress(fileData)
+		return err
+	}
+
 	v.Data, err = decompressMulti(fileData, v.Block.UncompressedFileSize)
 
 	return err
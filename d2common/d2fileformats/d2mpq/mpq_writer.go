@@ -0,0 +1,383 @@
+package d2mpq
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2compression"
+)
+
+// Compression is the method used to compress the sectors of a file in an MPQ archive
+type Compression int
+
+// Compression methods
+const (
+	// CompressionNone stores the file uncompressed
+	CompressionNone Compression = iota
+	// CompressionImplode compresses the file with the PKWARE Data Compression Library
+	CompressionImplode
+	// CompressionZlib compresses the file with zlib (deflate)
+	CompressionZlib
+)
+
+const (
+	headerSize          = 32
+	defaultBlockSize    = 3 // sector size of 0x200 << 3 = 4096 bytes, as used by the Diablo II archives
+	minHashTableEntries = 16
+
+	compressionTypeZlib = 0x02
+
+	hashTableEmpty = 0xFFFFFFFF
+
+	listfileName   = "(listfile)"
+	attributesName = "(attributes)"
+)
+
+// FileOptions define how a file is stored in an MPQ archive
+type FileOptions struct {
+	Compression Compression
+	Encrypt     bool
+	// FixKey alters the encryption key of an encrypted file with its position in the archive
+	FixKey bool
+}
+
+type writerFile struct {
+	name    string
+	data    []byte
+	options FileOptions
+}
+
+// Writer creates MPQ archives. Files are kept in memory until the archive is saved, the hash
+// table, block table and (listfile) are generated when saving.
+type Writer struct {
+	files     map[string]*writerFile
+	blockSize uint16
+	// ListfileOptions define how the generated (listfile) is stored
+	ListfileOptions FileOptions
+}
+
+// NewWriter creates a Writer for a new, empty MPQ archive
+func NewWriter() *Writer {
+	return &Writer{
+		files:           make(map[string]*writerFile),
+		blockSize:       defaultBlockSize,
+		ListfileOptions: FileOptions{Compression: CompressionImplode},
+	}
+}
+
+// NewWriterFromMPQ creates a Writer which contains all files of the given archive which are
+// named in its (listfile). The files are stored with the given options when saving.
+func NewWriterFromMPQ(mpq *MPQ, options FileOptions) (*Writer, error) {
+	w := NewWriter()
+	w.blockSize = mpq.header.BlockSize
+
+	list, err := mpq.Listfile()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, fileName := range list {
+		if fileName == "" || strings.EqualFold(fileName, listfileName) || strings.EqualFold(fileName, attributesName) {
+			continue
+		}
+
+		data, err := mpq.ReadFile(fileName)
+		if err != nil {
+			return nil, err
+		}
+
+		w.AddFile(fileName, data, options)
+	}
+
+	return w, nil
+}
+
+func writerKey(fileName string) string {
+	return strings.ToUpper(fileName)
+}
+
+func cleanFileName(fileName string) string {
+	return strings.TrimLeft(strings.ReplaceAll(fileName, "/", `\`), `\`)
+}
+
+// AddFile adds a file to the archive, or replaces the file if the archive contains it already.
+// Both slashes and backslashes can be used as path separators.
+func (w *Writer) AddFile(fileName string, data []byte, options FileOptions) {
+	fileName = cleanFileName(fileName)
+
+	w.files[writerKey(fileName)] = &writerFile{
+		name:    fileName,
+		data:    data,
+		options: options,
+	}
+}
+
+// DeleteFile removes a file from the archive, it returns false if the archive does not contain it
+func (w *Writer) DeleteFile(fileName string) bool {
+	key := writerKey(cleanFileName(fileName))
+
+	if _, found := w.files[key]; !found {
+		return false
+	}
+
+	delete(w.files, key)
+
+	return true
+}
+
+// Contains returns true if the archive contains the given file
+func (w *Writer) Contains(fileName string) bool {
+	_, found := w.files[writerKey(cleanFileName(fileName))]
+	return found
+}
+
+// Files returns the sorted names of the files in the archive
+func (w *Writer) Files() []string {
+	names := make([]string, 0, len(w.files))
+
+	for _, file := range w.files {
+		if !strings.EqualFold(file.name, listfileName) {
+			names = append(names, file.name)
+		}
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Save writes the archive to the given file
+func (w *Writer) Save(fileName string) error {
+	f, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.WriteTo(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// WriteTo writes the archive to out
+func (w *Writer) WriteTo(out io.Writer) (int64, error) {
+	names := w.Files()
+	files := make([]*writerFile, 0, len(names)+1)
+
+	for _, name := range names {
+		files = append(files, w.files[writerKey(name)])
+	}
+
+	files = append(files, &writerFile{
+		name:    listfileName,
+		data:    []byte(strings.Join(names, "\r\n")),
+		options: w.ListfileOptions,
+	})
+
+	data := new(bytes.Buffer)
+	blocks := make([]*Block, len(files))
+	sectorSize := uint32(0x200) << w.blockSize //nolint:gomnd // MPQ magic
+
+	for idx, file := range files {
+		block, fileData, err := file.encode(uint32(headerSize+data.Len()), sectorSize)
+		if err != nil {
+			return 0, err
+		}
+
+		blocks[idx] = block
+
+		data.Write(fileData)
+	}
+
+	hashTable := buildHashTable(files)
+	blockTable := make([]uint32, 0, len(blocks)*4) //nolint:gomnd // 4 values per block
+
+	for _, block := range blocks {
+		blockTable = append(blockTable, block.FilePosition, block.CompressedFileSize,
+			block.UncompressedFileSize, uint32(block.Flags))
+	}
+
+	encrypt(hashTable, hashString("(hash table)", 3))   //nolint:gomnd // MPQ magic
+	encrypt(blockTable, hashString("(block table)", 3)) //nolint:gomnd // MPQ magic
+
+	hashTableOffset := uint32(headerSize + data.Len())
+	blockTableOffset := hashTableOffset + uint32(len(hashTable)*4) //nolint:gomnd // uint32 size
+
+	header := Header{
+		Magic:             [4]byte{'M', 'P', 'Q', 0x1A},
+		HeaderSize:        headerSize,
+		ArchiveSize:       blockTableOffset + uint32(len(blockTable)*4), //nolint:gomnd // uint32 size
+		FormatVersion:     0,
+		BlockSize:         w.blockSize,
+		HashTableOffset:   hashTableOffset,
+		BlockTableOffset:  blockTableOffset,
+		HashTableEntries:  uint32(len(hashTable) / 4), //nolint:gomnd // 4 values per hash
+		BlockTableEntries: uint32(len(blocks)),
+	}
+
+	archive := new(bytes.Buffer)
+
+	for _, v := range []interface{}{header, data.Bytes(), hashTable, blockTable} {
+		if err := binary.Write(archive, binary.LittleEndian, v); err != nil {
+			return 0, err
+		}
+	}
+
+	return archive.WriteTo(out)
+}
+
+// buildHashTable returns the hash table for the files, the index of a file in the block table
+// is the same as its index in files
+func buildHashTable(files []*writerFile) []uint32 {
+	entries := uint32(minHashTableEntries)
+	for entries < uint32(len(files))*2 {
+		entries <<= 1
+	}
+
+	table := make([]uint32, entries*4) //nolint:gomnd // 4 values per hash
+	for idx := range table {
+		table[idx] = hashTableEmpty
+	}
+
+	for blockIndex, file := range files {
+		slot := hashString(file.name, 0) & (entries - 1)
+
+		// collisions are resolved by using the next free slot
+		for table[slot*4+3] != hashTableEmpty {
+			slot = (slot + 1) & (entries - 1)
+		}
+
+		entry := table[slot*4 : slot*4+4]
+		entry[0] = hashString(file.name, 1)
+		entry[1] = hashString(file.name, 2) //nolint:gomnd // hash type
+		entry[2] = 0                        // locale and platform
+		entry[3] = uint32(blockIndex)
+	}
+
+	return table
+}
+
+// encode returns the block table entry and the data of the file, when it is stored at the given
+// position of the archive
+func (f *writerFile) encode(position, sectorSize uint32) (*Block, []byte, error) {
+	block := &Block{
+		FilePosition:         position,
+		UncompressedFileSize: uint32(len(f.data)),
+		Flags:                FileExists,
+		FileName:             f.name,
+	}
+
+	// empty files have no sectors which could be compressed or encrypted
+	if len(f.data) == 0 {
+		return block, nil, nil
+	}
+
+	switch f.options.Compression {
+	case CompressionNone:
+	case CompressionImplode:
+		block.Flags |= FileImplode
+	case CompressionZlib:
+		block.Flags |= FileCompress
+	default:
+		return nil, nil, errors.New("unknown compression method")
+	}
+
+	if f.options.Encrypt {
+		block.Flags |= FileEncrypted
+
+		if f.options.FixKey {
+			block.Flags |= FileFixKey
+		}
+
+		block.calculateEncryptionSeed(f.name)
+	}
+
+	sectors, err := f.sectors(sectorSize)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	out := new(bytes.Buffer)
+
+	if f.options.Compression != CompressionNone {
+		// compressed sectors have different sizes, their offsets are stored before the sectors
+		offsets := make([]uint32, len(sectors)+1)
+		offsets[0] = uint32(len(offsets) * 4) //nolint:gomnd // uint32 size
+
+		for idx := range sectors {
+			offsets[idx+1] = offsets[idx] + uint32(len(sectors[idx]))
+		}
+
+		if block.HasFlag(FileEncrypted) {
+			encrypt(offsets, block.EncryptionSeed-1)
+		}
+
+		if err := binary.Write(out, binary.LittleEndian, offsets); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for idx := range sectors {
+		if block.HasFlag(FileEncrypted) && block.UncompressedFileSize > 3 {
+			encryptBytes(sectors[idx], uint32(idx)+block.EncryptionSeed)
+		}
+
+		out.Write(sectors[idx])
+	}
+
+	block.CompressedFileSize = uint32(out.Len())
+
+	return block, out.Bytes(), nil
+}
+
+// sectors splits the file into sectors and compresses them. Sectors which do not get smaller
+// when compressed are stored uncompressed.
+func (f *writerFile) sectors(sectorSize uint32) ([][]byte, error) {
+	sectors := make([][]byte, 0, (uint32(len(f.data))+sectorSize-1)/sectorSize)
+
+	for start := 0; start < len(f.data); start += int(sectorSize) {
+		end := start + int(sectorSize)
+		if end > len(f.data) {
+			end = len(f.data)
+		}
+
+		sector := make([]byte, end-start)
+		copy(sector, f.data[start:end])
+
+		var compressed []byte
+
+		switch f.options.Compression {
+		case CompressionImplode:
+			compressed = d2compression.PkImplode(sector)
+		case CompressionZlib:
+			buffer := bytes.NewBuffer([]byte{compressionTypeZlib})
+			z := zlib.NewWriter(buffer)
+
+			if _, err := z.Write(sector); err != nil {
+				return nil, err
+			}
+
+			if err := z.Close(); err != nil {
+				return nil, err
+			}
+
+			compressed = buffer.Bytes()
+		}
+
+		if compressed != nil && len(compressed) < len(sector) {
+			sector = compressed
+		}
+
+		sectors = append(sectors, sector)
+	}
+
+	return sectors, nil
+}
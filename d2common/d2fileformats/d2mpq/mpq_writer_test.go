@@ -0,0 +1,99 @@
+package d2mpq
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// testFileData returns a tab separated text, which compresses well
+func testFileData(size int) []byte {
+	data := new(bytes.Buffer)
+
+	for row := 0; data.Len() < size; row++ {
+		data.WriteString("item\t" + strconv.Itoa(row) + "\t" + strconv.Itoa(row*row%97) + "\tlevel\r\n")
+	}
+
+	return data.Bytes()[:size]
+}
+
+func TestWriter_SaveAndRead(t *testing.T) {
+	dir, err := ioutil.TempDir("", "d2mpq")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	files := map[string]struct {
+		data    []byte
+		options FileOptions
+	}{
+		`data\global\excel\armor.txt`:   {testFileData(10000), FileOptions{Compression: CompressionImplode}},
+		`data\global\excel\weapons.txt`: {testFileData(9000), FileOptions{Compression: CompressionZlib}},
+		`data\global\ui\raw.dc6`:        {testFileData(5000), FileOptions{}},
+		`data\local\encrypted.tbl`:      {testFileData(7000), FileOptions{Compression: CompressionImplode, Encrypt: true}},
+		`data\local\fixkey.tbl`:         {testFileData(4099), FileOptions{Encrypt: true, FixKey: true}},
+		`data\local\short.txt`:          {[]byte("abc"), FileOptions{Compression: CompressionZlib, Encrypt: true}},
+		`data\local\empty.txt`:          {[]byte{}, FileOptions{Compression: CompressionImplode}},
+	}
+
+	w := NewWriter()
+
+	for name, file := range files {
+		w.AddFile(name, file.data, file.options)
+	}
+
+	w.AddFile("data/global/deleted.txt", []byte("deleted"), FileOptions{})
+
+	if !w.DeleteFile(`DATA\GLOBAL\DELETED.TXT`) {
+		t.Error("expected the file to be deleted")
+	}
+
+	archivePath := filepath.Join(dir, "patch.mpq")
+	if err := w.Save(archivePath); err != nil {
+		t.Fatal(err)
+	}
+
+	mpq, err := FromFile(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() { _ = mpq.Close() }()
+
+	for name, file := range files {
+		data, err := mpq.ReadFile(name)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+
+		if !bytes.Equal(data, file.data) {
+			t.Errorf("%s: data does not match", name)
+		}
+
+		block, _ := mpq.getFileBlockData(name)
+		if compressed := file.options.Compression != CompressionNone && len(file.data) > 3; compressed &&
+			block.CompressedFileSize >= block.UncompressedFileSize {
+			t.Errorf("%s: file is not compressed", name)
+		}
+	}
+
+	if mpq.Contains(`data\global\deleted.txt`) {
+		t.Error("deleted file is in the archive")
+	}
+
+	list, err := mpq.Listfile()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(list, w.Files()) {
+		t.Errorf("expected listfile %v, got %v", w.Files(), list)
+	}
+}
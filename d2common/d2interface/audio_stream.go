@@ -0,0 +1,8 @@
+package d2interface
+
+// AudioStream plays the samples which are queued to it, like the sound of a video
+type AudioStream interface {
+	// QueueSamples queues samples between -1 and 1, interleaved by channel
+	QueueSamples(samples []float32)
+	Close() error
+}
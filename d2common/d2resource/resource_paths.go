@@ -33,6 +33,8 @@ const (
 
 	// --- Video Paths ---
 
+	BlizzardIntro = "/data/local/video/BlizNorth640x480.bik"
+
 	Act1Intro = "/data/local/video/" + LanguageTableToken + "/d2intro640x292.bik"
 	Act2Intro = "/data/local/video/" + LanguageTableToken + "/act02start640x292.bik"
 	Act3Intro = "/data/local/video/" + LanguageTableToken + "/act03start640x292.bik"
@@ -123,6 +123,20 @@ func (eap *AudioProvider) SetVolumes(bgmVolume, sfxVolume float64) {
 	eap.bgmVolume = bgmVolume
 }
 
+// CreateAudioStream creates an audio stream which plays samples of the given sample rate and
+// number of channels at the volume of the music
+func (eap *AudioProvider) CreateAudioStream(rate, channels int) (d2interface.AudioStream, error) {
+	stream, err := newAudioStream(eap.audioContext, rate, channels)
+	if err != nil {
+		return nil, err
+	}
+
+	stream.player.SetVolume(eap.bgmVolume)
+	stream.player.Play()
+
+	return stream, nil
+}
+
 // createSoundEffect creates a new instance of ebiten's sound effect implementation.
 func (eap *AudioProvider) createSoundEffect(sfx string, context *audio.Context,
 	loop bool) *SoundEffect {
@@ -0,0 +1,107 @@
+package ebiten
+
+import (
+	"errors"
+	"io"
+	"math"
+	"sync"
+
+	"github.com/hajimehoshi/ebiten/v2/audio"
+)
+
+const (
+	bytesPerSample = 2
+	outputChannels = 2
+	bytesPerFrame  = bytesPerSample * outputChannels
+)
+
+// AudioStream is the ebiten implementation of an audio stream. The queued samples are resampled
+// to the sample rate of the audio context and played in stereo, silence is played while no
+// samples are queued.
+type AudioStream struct {
+	player   *audio.Player
+	samples  []float32
+	channels int
+	step     float64 // the number of queued frames played per frame of the audio context
+	position float64 // the position in the first queued frame
+	closed   bool
+	lock     sync.Mutex
+}
+
+var _ io.Reader = &AudioStream{}
+
+func newAudioStream(context *audio.Context, rate, channels int) (*AudioStream, error) {
+	if rate <= 0 || channels <= 0 {
+		return nil, errors.New("invalid audio stream format")
+	}
+
+	stream := &AudioStream{
+		channels: channels,
+		step:     float64(rate) / float64(context.SampleRate()),
+	}
+
+	player, err := audio.NewPlayer(context, stream)
+	if err != nil {
+		return nil, err
+	}
+
+	stream.player = player
+
+	return stream, nil
+}
+
+// QueueSamples queues samples between -1 and 1, interleaved by channel
+func (s *AudioStream) QueueSamples(samples []float32) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.samples = append(s.samples, samples...)
+}
+
+// Close stops the audio stream
+func (s *AudioStream) Close() error {
+	s.lock.Lock()
+	s.closed = true
+	s.samples = nil
+	s.lock.Unlock()
+
+	return s.player.Close()
+}
+
+// Read writes the queued samples as 16 bit stereo samples for the audio player
+func (s *AudioStream) Read(buf []byte) (int, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed {
+		return 0, io.EOF
+	}
+
+	n := len(buf) / bytesPerFrame * bytesPerFrame
+
+	for offset := 0; offset < n; offset += bytesPerFrame {
+		left, right := float32(0), float32(0)
+
+		if frame := int(s.position) * s.channels; frame+s.channels <= len(s.samples) {
+			left, right = s.samples[frame], s.samples[frame+s.channels-1]
+			s.position += s.step
+		}
+
+		putSample(buf[offset:], left)
+		putSample(buf[offset+bytesPerSample:], right)
+	}
+
+	if played := int(s.position); played > 0 {
+		s.samples = s.samples[played*s.channels:]
+		s.position -= float64(played)
+	}
+
+	return n, nil
+}
+
+func putSample(buf []byte, sample float32) {
+	value := int16(math.Max(-1, math.Min(1, float64(sample))) * math.MaxInt16)
+
+	buf[0] = byte(value)
+	buf[1] = byte(value >> bitsPerByte)
+}
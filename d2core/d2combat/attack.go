@@ -0,0 +1,117 @@
+package d2combat
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math"
+)
+
+const (
+	minHitChance = 5
+	maxHitChance = 95
+	percent      = 100
+)
+
+// DamageRange is the minimum and maximum damage of one damage type
+type DamageRange struct {
+	Min, Max int
+}
+
+// Roll returns a random damage in the range
+func (r DamageRange) Roll(rng *rand.Rand) int {
+	if r.Max <= r.Min {
+		return r.Min
+	}
+
+	return r.Min + rng.Intn(r.Max-r.Min+1)
+}
+
+// Attack is an attack of an entity, with a weapon or a skill
+type Attack struct {
+	Level        int
+	AttackRating int
+	Physical     DamageRange
+	Element      Element
+	Elemental    DamageRange
+	AlwaysHits   bool // spells hit regardless of the defense of the target
+}
+
+// Defender is an entity which is attacked
+type Defender struct {
+	Level       int
+	Defense     int
+	Resistances map[Element]int // in percent
+}
+
+// Hit is the outcome of an attack
+type Hit struct {
+	Missed    bool
+	Physical  int
+	Elemental int
+}
+
+// Damage returns the total damage of the hit
+func (h Hit) Damage() int {
+	return h.Physical + h.Elemental
+}
+
+// HitChance returns the chance (in percent) of an attack to hit:
+// 200% * AR / (AR + Defense) * AttackerLevel / (AttackerLevel + DefenderLevel),
+// but at least 5% and at most 95%.
+func HitChance(attackRating, defense, attackerLevel, defenderLevel int) int {
+	const factor = 2 * percent
+
+	if attackRating <= 0 || attackerLevel <= 0 {
+		return minHitChance
+	}
+
+	chance := factor * attackRating * attackerLevel /
+		((attackRating + d2math.MaxInt(defense, 0)) * (attackerLevel + d2math.MaxInt(defenderLevel, 0)))
+
+	return d2math.MaxInt(minHitChance, d2math.MinInt(maxHitChance, chance))
+}
+
+// Resolve rolls whether the attack hits the defender, and the damage it deals after the
+// resistances of the defender
+func Resolve(rng *rand.Rand, attack *Attack, defender *Defender) Hit {
+	if !attack.AlwaysHits {
+		chance := HitChance(attack.AttackRating, defender.Defense, attack.Level, defender.Level)
+		if rng.Intn(percent) >= chance {
+			return Hit{Missed: true}
+		}
+	}
+
+	return Hit{
+		Physical:  Resist(attack.Physical.Roll(rng), defender.Resistances[ElementPhysical]),
+		Elemental: Resist(attack.Elemental.Roll(rng), defender.Resistances[attack.Element]),
+	}
+}
+
+// LevelDamage returns the damage of a skill or a missile at the given level. The damage per
+// level of the first column of Skills.txt and Missiles.txt is added for the levels 2 to 8, of
+// the second for 9 to 16, of the third for 17 to 22, of the fourth for 23 to 28 and of the
+// last for every level from 29 on.
+func LevelDamage(damage int, perLevel [5]int, level int) int {
+	lastLevels := [len(perLevel)]int{8, 16, 22, 28, math.MaxInt32}
+	previous := 1
+
+	for idx, last := range lastLevels {
+		if level <= previous {
+			break
+		}
+
+		damage += (d2math.MinInt(level, last) - previous) * perLevel[idx]
+		previous = last
+	}
+
+	return damage
+}
+
+// ShiftDamage converts a damage of Skills.txt or Missiles.txt to life points. The damage is
+// given in 1/256 of a life point, shifted left by the HitShift column.
+func ShiftDamage(damage, hitShift int) int {
+	const fractionBits = 8
+
+	return damage << uint(hitShift) >> fractionBits
+}
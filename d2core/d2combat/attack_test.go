@@ -0,0 +1,114 @@
+package d2combat
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestHitChance(t *testing.T) {
+	tests := []struct {
+		attackRating, defense, attackerLevel, defenderLevel int
+		expected                                            int
+	}{
+		{100, 100, 10, 10, 50},
+		{300, 100, 10, 10, 75},
+		{10, 1000, 1, 30, minHitChance},
+		{10000, 0, 50, 1, maxHitChance},
+		{0, 10, 1, 1, minHitChance},
+	}
+
+	for _, test := range tests {
+		chance := HitChance(test.attackRating, test.defense, test.attackerLevel, test.defenderLevel)
+		if chance != test.expected {
+			t.Errorf("%+v: expected %d%%, got %d%%", test, test.expected, chance)
+		}
+	}
+}
+
+func TestResist(t *testing.T) {
+	tests := []struct {
+		damage, resistance, expected int
+	}{
+		{100, 0, 100},
+		{100, 75, 25},
+		{100, 100, 0},
+		{100, -50, 150},
+		{100, -300, 200},
+	}
+
+	for _, test := range tests {
+		if damage := Resist(test.damage, test.resistance); damage != test.expected {
+			t.Errorf("%d with %d%% resistance: expected %d, got %d", test.damage, test.resistance, test.expected, damage)
+		}
+	}
+}
+
+func TestLevelDamage(t *testing.T) {
+	perLevel := [5]int{1, 2, 3, 4, 5}
+
+	tests := []struct {
+		level, expected int
+	}{
+		{1, 10},
+		{2, 11},
+		{8, 17},
+		{9, 19},
+		{16, 33},
+		{22, 51},
+		{28, 75},
+		{30, 85},
+	}
+
+	for _, test := range tests {
+		if damage := LevelDamage(10, perLevel, test.level); damage != test.expected {
+			t.Errorf("level %d: expected %d, got %d", test.level, test.expected, damage)
+		}
+	}
+}
+
+func TestShiftDamage(t *testing.T) {
+	if damage := ShiftDamage(12, 8); damage != 12 {
+		t.Errorf("expected 12, got %d", damage)
+	}
+
+	if damage := ShiftDamage(512, 0); damage != 2 {
+		t.Errorf("expected 2, got %d", damage)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	attack := &Attack{
+		Level:      1,
+		Physical:   DamageRange{Min: 10, Max: 20},
+		Element:    ElementFire,
+		Elemental:  DamageRange{Min: 40, Max: 40},
+		AlwaysHits: true,
+	}
+	defender := &Defender{
+		Level:       99,
+		Defense:     10000,
+		Resistances: map[Element]int{ElementPhysical: 50, ElementFire: 75},
+	}
+
+	for idx := 0; idx < 100; idx++ {
+		hit := Resolve(rng, attack, defender)
+		if hit.Missed || hit.Physical < 5 || hit.Physical > 10 || hit.Elemental != 10 {
+			t.Fatalf("unexpected hit %+v", hit)
+		}
+	}
+
+	attack.AlwaysHits = false
+	misses := 0
+
+	for idx := 0; idx < 1000; idx++ {
+		if Resolve(rng, attack, defender).Missed {
+			misses++
+		}
+	}
+
+	// the attack hits with the minimum chance of 5%
+	if misses < 900 {
+		t.Errorf("expected about 950 misses, got %d", misses)
+	}
+}
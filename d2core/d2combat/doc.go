@@ -0,0 +1,2 @@
+// Package d2combat resolves attacks between entities: hit chance, damage and resistances.
+package d2combat
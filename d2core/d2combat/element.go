@@ -0,0 +1,35 @@
+package d2combat
+
+// Element is the code of a damage type in ElemType.txt
+type Element string
+
+// Elements
+const (
+	ElementPhysical  Element = "" // not in ElemType.txt, the resistance is damage reduction
+	ElementFire      Element = "fire"
+	ElementLightning Element = "ltng"
+	ElementMagic     Element = "mag"
+	ElementCold      Element = "cold"
+	ElementPoison    Element = "pois"
+)
+
+const (
+	// maxResistance is the highest resistance which does not make an entity immune
+	maxResistance = 99
+	// minResistance is the lowest resistance, it doubles the damage
+	minResistance = -100
+)
+
+// Resist returns the damage which is left after the resistance (in percent) is applied. An
+// entity with a resistance of 100 or more is immune.
+func Resist(damage, resistance int) int {
+	if resistance > maxResistance {
+		return 0
+	}
+
+	if resistance < minResistance {
+		resistance = minResistance
+	}
+
+	return damage * (percent - resistance) / percent
+}
@@ -0,0 +1,111 @@
+package d2hero
+
+import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+)
+
+const (
+	// monsters up to this many levels away from the level of a hero give their full experience
+	fullExperienceLevels = 5
+
+	// the experience of a monster killed by a party grows by this percent for every other hero
+	partyExperienceBonus = 35
+
+	// the Ratio of Experience.txt is the experience a hero gets, in 1024ths
+	experienceRatioDivisor = 1024
+
+	percent = 100
+)
+
+// lowLevelExperiencePercents returns the percent of its experience a monster gives to a hero,
+// when the monster is 6, 7, 8, 9 or more levels lower than the hero
+func lowLevelExperiencePercents() []int {
+	return []int{81, 62, 43, 24, 5}
+}
+
+// LevelDifferenceExperience returns the experience a hero of the given level gets from a monster
+// of the given level. Monsters much lower than the hero give only a part of their experience,
+// and the experience of monsters much higher than the hero is scaled by the levels.
+func LevelDifferenceExperience(experience, heroLevel, monsterLevel int) int {
+	switch difference := heroLevel - monsterLevel; {
+	case difference > fullExperienceLevels:
+		percents := lowLevelExperiencePercents()
+
+		idx := difference - fullExperienceLevels - 1
+		if idx >= len(percents) {
+			idx = len(percents) - 1
+		}
+
+		return experience * percents[idx] / percent
+	case -difference > fullExperienceLevels:
+		return experience * heroLevel / monsterLevel
+	default:
+		return experience
+	}
+}
+
+// PartyExperience splits the experience of a monster between the heroes of the given levels,
+// which killed it together. Every other hero adds to the experience of the monster, the heroes
+// get a share of it by their level.
+func PartyExperience(experience int, levels []int) []int {
+	shares := make([]int, len(levels))
+	if len(levels) == 0 {
+		return shares
+	}
+
+	total := experience * (percent + partyExperienceBonus*(len(levels)-1)) / percent
+	levelSum := 0
+
+	for _, level := range levels {
+		levelSum += level
+	}
+
+	for idx, level := range levels {
+		if levelSum > 0 {
+			shares[idx] = total * level / levelSum
+		} else {
+			shares[idx] = total / len(levels)
+		}
+	}
+
+	return shares
+}
+
+// AddExperience adds experience to a hero, scaled by the Ratio of Experience.txt at the level of
+// the hero. The hero levels up for every breakpoint of Experience.txt it reaches, and gets the
+// stat points, life, mana and stamina per level of CharStats.txt and a skill point for every
+// level. Its life, mana and stamina are refilled when it levels up. The number of levels gained
+// is returned.
+func (f *HeroStateFactory) AddExperience(hero d2enum.Hero, stats *HeroStatsState, experience int) int {
+	classStats, found := f.asset.Records.Character.Stats[hero]
+	if !found || classStats == nil || experience <= 0 {
+		return 0
+	}
+
+	if breakpoint := f.asset.Records.Character.Experience[stats.Level]; breakpoint != nil && breakpoint.Ratio > 0 {
+		experience = experience * breakpoint.Ratio / experienceRatioDivisor
+	}
+
+	stats.Experience += experience
+
+	if maxExperience := f.maxExperience(hero); stats.Experience > maxExperience {
+		stats.Experience = maxExperience
+	}
+
+	level := f.levelForExperience(hero, stats.Experience)
+	gained := level - stats.Level
+
+	if gained <= 0 {
+		return 0
+	}
+
+	stats.Level = level
+	stats.NextLevelExp = f.experienceBreakpoint(hero, level)
+	stats.StatsPoints += gained * classStats.StatPerLevel
+	stats.SkillPoints += gained * skillPointsPerLevel
+
+	stats.MaxHealth, stats.MaxMana, stats.MaxStamina = maxVitals(stats, classStats)
+	stats.Health, stats.Mana, stats.Stamina = stats.MaxHealth, stats.MaxMana, float64(stats.MaxStamina)
+
+	return gained
+}
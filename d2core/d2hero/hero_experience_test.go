@@ -0,0 +1,98 @@
+package d2hero
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+)
+
+func TestHeroStateFactory_AddExperience(t *testing.T) {
+	factory := testHeroStateFactory(t)
+	records := factory.asset.Records
+
+	records.Character.Stats = d2records.CharStats{
+		d2enum.HeroSorceress: {InitVit: 10, InitEne: 35, LifePerVit: 2, ManaPerEne: 2, LifePerLevel: 1,
+			ManaPerLevel: 2, InitStamina: 74, StaminaPerLevel: 1, StatPerLevel: 5},
+	}
+	records.Character.MaxLevel = d2records.ExperienceMaxLevels{d2enum.HeroSorceress: 4}
+	records.Character.Experience = d2records.ExperienceBreakpoints{
+		1: {Level: 1, HeroBreakpoints: map[d2enum.Hero]int{d2enum.HeroSorceress: 500}, Ratio: 1024},
+		2: {Level: 2, HeroBreakpoints: map[d2enum.Hero]int{d2enum.HeroSorceress: 1500}, Ratio: 1024},
+		3: {Level: 3, HeroBreakpoints: map[d2enum.Hero]int{d2enum.HeroSorceress: 3000}, Ratio: 512},
+	}
+
+	stats := factory.CreateHeroStatsState(d2enum.HeroSorceress, records.Character.Stats[d2enum.HeroSorceress])
+	stats.Health = 1
+
+	if gained := factory.AddExperience(d2enum.HeroSorceress, stats, 1600); gained != 2 || stats.Level != 3 ||
+		stats.StatsPoints != 10 || stats.SkillPoints != 2 || stats.NextLevelExp != 3000 {
+		t.Fatalf("expected two levels gained, got %d levels with %+v", gained, stats)
+	}
+
+	if stats.MaxHealth != 22 || stats.Health != stats.MaxHealth || stats.MaxMana != 74 || stats.MaxStamina != 76 {
+		t.Errorf("expected the life, mana and stamina per level, got %+v", stats)
+	}
+
+	// the experience at level 3 is halved, and can not go beyond the maximum level
+	if gained := factory.AddExperience(d2enum.HeroSorceress, stats, 10000); gained != 1 || stats.Level != 4 ||
+		stats.Experience != 3000 {
+		t.Errorf("expected the maximum level, got %d levels with %+v", gained, stats)
+	}
+
+	if err := factory.SpendStatPoint(d2enum.HeroSorceress, stats, d2enum.AttributeVitality); err != nil ||
+		stats.Vitality != 11 || stats.MaxHealth != 25 || stats.StatsPoints != 14 {
+		t.Errorf("expected the point spent on vitality, got %v with %+v", err, stats)
+	}
+
+	if experience := LevelDifferenceExperience(100, 20, 12); experience != 43 {
+		t.Errorf("expected the experience of a monster 8 levels lower to be 43, got %d", experience)
+	}
+
+	if experience := LevelDifferenceExperience(100, 10, 40); experience != 25 {
+		t.Errorf("expected the experience of a monster 30 levels higher to be 25, got %d", experience)
+	}
+
+	if shares := PartyExperience(100, []int{10, 30}); !reflect.DeepEqual(shares, []int{33, 101}) {
+		t.Errorf("expected the party experience to be shared by level, got %v", shares)
+	}
+}
+
+func TestHeroStateFactory_SpendSkillPoint(t *testing.T) {
+	factory := testHeroStateFactory(t)
+
+	fireBolt := &HeroSkill{SkillRecord: &d2records.SkillRecord{ID: 36, Skill: "Fire Bolt", Charclass: "sor",
+		Maxlvl: 20, Reqlevel: 1}}
+	fireBall := &HeroSkill{SkillRecord: &d2records.SkillRecord{ID: 47, Skill: "Fire Ball", Charclass: "sor",
+		Maxlvl: 20, Reqlevel: 12, Reqskill1: "Fire Bolt"}}
+
+	state := &HeroState{
+		Stats:  &HeroStatsState{Level: 12, SkillPoints: 2},
+		Skills: map[int]*HeroSkill{36: fireBolt, 47: fireBall, 0: {SkillRecord: &d2records.SkillRecord{}}},
+	}
+
+	if err := factory.SpendSkillPoint(state, 47); err == nil {
+		t.Error("expected fire ball to require fire bolt")
+	}
+
+	if err := factory.SpendSkillPoint(state, 0); err == nil {
+		t.Error("expected no points to be spent on a skill of no class")
+	}
+
+	for _, skillID := range []int{36, 47} {
+		if err := factory.SpendSkillPoint(state, skillID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if fireBall.SkillPoints != 1 || state.Stats.SkillPoints != 0 {
+		t.Errorf("expected the points spent on fire bolt and fire ball, got %d left", state.Stats.SkillPoints)
+	}
+
+	state.Stats.Level, state.Stats.SkillPoints, fireBall.SkillPoints = 11, 1, 0
+
+	if err := factory.SpendSkillPoint(state, 47); err == nil {
+		t.Error("expected fire ball to require level 12")
+	}
+}
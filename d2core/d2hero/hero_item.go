@@ -0,0 +1,119 @@
+package d2hero
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2inventory"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2item/diablo2item"
+)
+
+// HeroItem is an item the hero owns, wherever it is kept: the inventory grid, the belt, the
+// stash, the cube, or one of the equipped and weapon swap slots.
+// Only the item in the format of the original saves is written to game saves and network
+// packets. It holds where the item is kept, and the item is loaded from it by LoadItems.
+type HeroItem struct {
+	ID   string            `json:"id"`
+	Data []byte            `json:"data"`
+	Item *diablo2item.Item `json:"-"`
+}
+
+// NewHeroItem creates a HeroItem with a new ID for an item
+func NewHeroItem(item *diablo2item.Item) *HeroItem {
+	return &HeroItem{ID: uuid.New().String(), Item: item}
+}
+
+// MarshalJSON saves the item, with where it is kept now, before writing the HeroItem
+func (h *HeroItem) MarshalJSON() ([]byte, error) {
+	type heroItem HeroItem
+
+	saved := heroItem(*h)
+
+	if h.Item != nil {
+		saved.Data = h.Item.Serialize()
+	}
+
+	return json.Marshal(saved)
+}
+
+// LoadItems loads the items of the hero from their saved data, using the records of the
+// asset manager. Items which can not be loaded are dropped, the first error is returned.
+func (f *HeroStateFactory) LoadItems(items []*HeroItem) ([]*HeroItem, error) {
+	if items == nil {
+		return nil, nil
+	}
+
+	var firstErr error
+
+	loaded := make([]*HeroItem, 0, len(items))
+
+	for _, heroItem := range items {
+		if heroItem == nil {
+			continue
+		}
+
+		if heroItem.Item == nil {
+			item, err := f.item.Deserialize(heroItem.Data)
+			if err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("item %s: %v", heroItem.ID, err)
+				}
+
+				continue
+			}
+
+			heroItem.Item = item
+		}
+
+		loaded = append(loaded, heroItem)
+	}
+
+	return loaded, firstErr
+}
+
+// CreateEquippedItems creates the items of the given codes, equipped in their slots. It is used
+// for the items heroes start with, and to migrate the saves made before the items of a hero
+// were saved, which only have the codes of the worn items.
+func (f *HeroStateFactory) CreateEquippedItems(codes map[d2enum.EquippedSlot]string) []*HeroItem {
+	slots := make([]d2enum.EquippedSlot, 0, len(codes))
+
+	for slot := range codes {
+		slots = append(slots, slot)
+	}
+
+	sort.Slice(slots, func(a, b int) bool { return slots[a] < slots[b] })
+
+	items := make([]*HeroItem, 0, len(slots))
+
+	for _, slot := range slots {
+		item, err := f.item.NewItem(codes[slot])
+		if err != nil {
+			continue
+		}
+
+		item.Identify()
+		item.Location = d2enum.ItemLocationEquipped
+		item.SetSlotType(slot)
+
+		items = append(items, NewHeroItem(item))
+	}
+
+	return items
+}
+
+// EquippedItems returns the equipped items among the items of the hero
+func EquippedItems(items []*HeroItem) d2inventory.CharacterEquipment {
+	loaded := make([]*diablo2item.Item, 0, len(items))
+
+	for _, heroItem := range items {
+		if heroItem != nil {
+			loaded = append(loaded, heroItem.Item)
+		}
+	}
+
+	return d2inventory.NewCharacterEquipment(loaded...)
+}
@@ -0,0 +1,102 @@
+package d2hero
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2util"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2asset"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+)
+
+// testHeroStateFactory creates a hero state factory with empty records, each test fills in the
+// records it reads
+func testHeroStateFactory(t *testing.T) *HeroStateFactory {
+	asset, err := d2asset.NewAssetManager(d2util.LogLevelNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	factory, err := NewHeroStateFactory(asset, d2util.LogLevelNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return factory
+}
+
+func TestHeroStateFactory_LoadItems(t *testing.T) {
+	factory := testHeroStateFactory(t)
+	records := factory.asset.Records
+
+	records.Item.All = d2records.CommonItems{
+		"cap": {Code: "cap", Type: "helm", Durability: 12},
+		"sst": {Code: "sst", Type: "staf", Durability: 20},
+	}
+	records.Item.Types = map[string]*d2records.ItemTypeRecord{"helm": {Code: "helm"}, "staf": {Code: "staf"}}
+
+	dir, err := ioutil.TempDir("", "d2hero")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	// a save made before the items of the hero were saved
+	filePath := filepath.Join(dir, "0.od2")
+	oldSave := `{"heroName":"Old","heroType":1,"equipment":{"head":{"itemCode":"cap"},` +
+		`"rightHand":{"itemCode":"sst"},"leftHand":{"itemCode":"sst"}}}`
+
+	if err := ioutil.WriteFile(filePath, []byte(oldSave), writefilePermission); err != nil {
+		t.Fatal(err)
+	}
+
+	state := factory.LoadHeroState(filePath)
+	if state == nil || len(state.Items) != 2 {
+		t.Fatalf("expected the equipment to be migrated to items, got %+v", state)
+	}
+
+	staff := state.Items[1].Item
+	if staff.CommonCode != "sst" || staff.Location != d2enum.ItemLocationEquipped ||
+		staff.SlotType() != d2enum.EquippedSlotRightArm {
+		t.Errorf("expected the staff to be equipped once, got %s in %d", staff.CommonCode, staff.SlotType())
+	}
+
+	staff.Location, staff.Storage, staff.GridX, staff.GridY = d2enum.ItemLocationStored, d2enum.ItemStorageCube, 1, 2
+	staff.SetSlotType(d2enum.EquippedSlotNone)
+
+	if err := factory.Save(state); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := factory.LoadHeroState(filePath)
+	if loaded == nil || len(loaded.Items) != 2 || loaded.Items[0].ID != state.Items[0].ID {
+		t.Fatalf("expected the saved items to be loaded, got %+v", loaded)
+	}
+
+	if item := loaded.Items[1].Item; item.CommonCode != "sst" || item.Storage != d2enum.ItemStorageCube ||
+		item.GridX != 1 || item.GridY != 2 {
+		t.Errorf("expected the staff to be loaded in the cube, got %s in %d at %d,%d", item.CommonCode,
+			item.Storage, item.GridX, item.GridY)
+	}
+
+	data, err := json.Marshal(loaded.Items)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var items []*HeroItem
+	if err := json.Unmarshal(data, &items); err != nil {
+		t.Fatal(err)
+	}
+
+	items[1].Data = items[1].Data[:1]
+
+	if items, err = factory.LoadItems(items); err == nil || len(items) != 1 {
+		t.Errorf("expected the broken item to be dropped, got %d items", len(items))
+	}
+}
@@ -0,0 +1,102 @@
+package d2hero
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+)
+
+// the reasons a stat or a skill point can not be spent
+var (
+	errNoStatPoints      = errors.New("no stat points left")
+	errNoSkillPoints     = errors.New("no skill points left")
+	errUnknownSkill      = errors.New("not a skill of the class of the hero")
+	errSkillMaxLevel     = errors.New("the skill has its maximum level")
+	errSkillLevel        = errors.New("the level of the hero is too low for the skill")
+	errSkillRequirements = errors.New("the skills required by the skill are not learned")
+)
+
+// SpendStatPoint spends a stat point of a hero on one of its attributes. The life, mana and
+// stamina an attribute gives are added to the maximum and the current values.
+func (f *HeroStateFactory) SpendStatPoint(hero d2enum.Hero, stats *HeroStatsState, attribute d2enum.Attribute) error {
+	classStats, found := f.asset.Records.Character.Stats[hero]
+	if !found || classStats == nil {
+		return fmt.Errorf("unknown hero type %d", hero)
+	}
+
+	if stats.StatsPoints <= 0 {
+		return errNoStatPoints
+	}
+
+	switch attribute {
+	case d2enum.AttributeStrength:
+		stats.Strength++
+	case d2enum.AttributeDexterity:
+		stats.Dexterity++
+	case d2enum.AttributeVitality:
+		stats.Vitality++
+	case d2enum.AttributeEnergy:
+		stats.Energy++
+	default:
+		return fmt.Errorf("unknown attribute %d", attribute)
+	}
+
+	stats.StatsPoints--
+
+	maxHealth, maxMana, maxStamina := maxVitals(stats, classStats)
+
+	stats.Health += maxHealth - stats.MaxHealth
+	stats.Mana += maxMana - stats.MaxMana
+	stats.Stamina += float64(maxStamina - stats.MaxStamina)
+	stats.MaxHealth, stats.MaxMana, stats.MaxStamina = maxHealth, maxMana, maxStamina
+
+	return nil
+}
+
+// SpendSkillPoint spends a skill point of a hero on one of its class skills. The hero must have
+// the reqlevel of Skills.txt, and points in the skills of reqskill1 to reqskill3.
+func (f *HeroStateFactory) SpendSkillPoint(state *HeroState, skillID int) error {
+	skill, found := state.Skills[skillID]
+	if !found || skill == nil || skill.SkillRecord == nil || skill.Charclass == "" {
+		return errUnknownSkill
+	}
+
+	if state.Stats.SkillPoints <= 0 {
+		return errNoSkillPoints
+	}
+
+	if skill.Maxlvl > 0 && skill.SkillPoints >= skill.Maxlvl {
+		return errSkillMaxLevel
+	}
+
+	if state.Stats.Level < skill.Reqlevel {
+		return errSkillLevel
+	}
+
+	for _, required := range []string{skill.Reqskill1, skill.Reqskill2, skill.Reqskill3} {
+		if required != "" && !hasSkillPoints(state.Skills, required) {
+			return errSkillRequirements
+		}
+	}
+
+	skill.SkillPoints++
+	state.Stats.SkillPoints--
+
+	if skill.Shallow != nil {
+		skill.Shallow.SkillPoints = skill.SkillPoints
+	}
+
+	return nil
+}
+
+// hasSkillPoints returns true if points are spent on the skill of the given name
+func hasSkillPoints(skills map[int]*HeroSkill, name string) bool {
+	for _, skill := range skills {
+		if skill != nil && skill.SkillRecord != nil && skill.Skill == name {
+			return skill.SkillPoints > 0
+		}
+	}
+
+	return false
+}
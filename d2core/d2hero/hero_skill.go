@@ -43,3 +43,9 @@ func (hs *HeroSkill) UnmarshalJSON(data []byte) error {
 
 	return nil
 }
+
+// NewShallowHeroSkill creates a HeroSkill which only holds the ID and the points of a skill, like
+// a HeroSkill read from a save. The records of the skill are set when the hero state is loaded.
+func NewShallowHeroSkill(skillID, skillPoints int) *HeroSkill {
+	return &HeroSkill{Shallow: &shallowHeroSkill{SkillID: skillID, SkillPoints: skillPoints}}
+}
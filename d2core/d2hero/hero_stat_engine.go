@@ -0,0 +1,497 @@
+package d2hero
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2calculation"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2inventory"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2item/diablo2item"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2state"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2stats"
+)
+
+// the stats of ItemStatCost.txt the stat engine derives the values of a hero from
+const (
+	statLevel          = "level"
+	statStrength       = "strength"
+	statDexterity      = "dexterity"
+	statVitality       = "vitality"
+	statEnergy         = "energy"
+	statMaxLife        = "maxhp"
+	statMaxMana        = "maxmana"
+	statMaxStamina     = "maxstamina"
+	statMaxLifePercent = "item_maxhp_percent"
+	statMaxManaPercent = "item_maxmana_percent"
+	statDefense        = "armorclass"
+	statDefensePercent = "item_armor_percent"
+	statAttackRating   = "tohit"
+	statAllSkills      = "item_allskills"
+
+	// the maximum resistances are the stats of the resistances with this prefix
+	maxResistancePrefix = "max"
+)
+
+const (
+	attackRatingPerDexterity = 5
+	baseAttackRating         = -35
+	dexterityPerDefense      = 4
+
+	// the resistances of a hero can not go beyond this percent, plus its maximum resistances
+	maxResistance = 75
+	minResistance = -100
+
+	// the partial bonuses of a set are given from two worn items of the set on
+	minSetItems = 2
+
+	// the add func of SetItems.txt, which gives the bonuses of a set item for specific worn
+	// items of its set or for the number of worn items of its set
+	setItemAddFnItems = 1
+	setItemAddFnCount = 2
+
+	// diminishingLevels and diminishingPercent scale the dm12 to dm78 of Skills.txt
+	diminishingLevels  = 6
+	diminishingPercent = 110
+
+	skillReference = "skill"
+)
+
+// StatEngine derives the final stats of a hero. The level and attributes of the hero, the stats
+// of the items it wears with the bonuses of their sets, the stats of its passive skills and the
+// stats of the states it is in are merged into one stat list. The life, mana, stamina, defense
+// and attack rating the hero has by the coefficients of CharStats.txt are added to the list.
+// The stats are only computed again by Update, which is called when any of them changed.
+type StatEngine struct {
+	factory    *HeroStateFactory
+	hero       d2enum.Hero
+	difficulty d2enum.DifficultyType // the difficulty of the game, which lowers the resistances
+	states     *d2state.Manager
+	list       d2stats.StatList
+	values     map[string]int // the stats of the list, by name
+	bonus      map[string]int // the stats of the passive skills and states, which scale all the others
+}
+
+// NewStatEngine creates the stat engine of a hero of the given class, in the given states. The
+// engine has no stats until it is updated.
+func (f *HeroStateFactory) NewStatEngine(hero d2enum.Hero, states *d2state.Manager) *StatEngine {
+	return &StatEngine{
+		factory: f,
+		hero:    hero,
+		states:  states,
+		list:    f.stat.NewStatList(),
+		values:  make(map[string]int),
+		bonus:   make(map[string]int),
+	}
+}
+
+// SetDifficulty sets the difficulty of the game the hero is in
+func (e *StatEngine) SetDifficulty(difficulty d2enum.DifficultyType) {
+	e.difficulty = difficulty
+}
+
+// Update computes the stats of the hero again, from its stats, skills and the items it wears
+func (e *StatEngine) Update(stats *HeroStatsState, skills map[int]*HeroSkill,
+	equipment d2inventory.CharacterEquipment) {
+	list := equipment.StatList(e.baseStatList(stats))
+	list.AppendStatList(e.setBonusStatList(equipment))
+
+	bonus := e.factory.stat.NewStatList()
+	if e.states != nil {
+		bonus.AppendStatList(e.states.StatList(e.factory.stat))
+	}
+
+	// the items and the states add to the levels of the passive skills
+	skillBonus := statValues(list.ReduceStats())[statAllSkills] + statValues(bonus)[statAllSkills]
+	bonus.AppendStatList(e.passiveStatList(skills, equipment.Weapon(), skillBonus))
+
+	list.AppendStatList(bonus)
+	list = list.ReduceStats()
+
+	// the life, mana, stamina, defense and attack rating are derived from the final attributes
+	e.bonus = statValues(bonus.ReduceStats())
+	e.values = statValues(list)
+
+	list.AppendStatList(e.derivedStatList(stats, equipment))
+	e.list = list.ReduceStats()
+	e.values = statValues(e.list)
+}
+
+// StatList returns the final stats of the hero
+func (e *StatEngine) StatList() d2stats.StatList {
+	return e.list
+}
+
+// Stat returns the final value of a stat of ItemStatCost.txt with a single value, or zero if the
+// hero does not have the stat
+func (e *StatEngine) Stat(name string) int {
+	return e.values[name]
+}
+
+// Attribute returns the final value of an attribute of the hero
+func (e *StatEngine) Attribute(attribute d2enum.Attribute) int {
+	switch attribute {
+	case d2enum.AttributeStrength:
+		return e.values[statStrength]
+	case d2enum.AttributeDexterity:
+		return e.values[statDexterity]
+	case d2enum.AttributeVitality:
+		return e.values[statVitality]
+	case d2enum.AttributeEnergy:
+		return e.values[statEnergy]
+	default:
+		return 0
+	}
+}
+
+// MaxLife returns the maximum life of the hero, at least 1
+func (e *StatEngine) MaxLife() int {
+	life := e.values[statMaxLife] * (percent + e.values[statMaxLifePercent]) / percent
+
+	if life < 1 {
+		return 1
+	}
+
+	return life
+}
+
+// MaxMana returns the maximum mana of the hero
+func (e *StatEngine) MaxMana() int {
+	return e.values[statMaxMana] * (percent + e.values[statMaxManaPercent]) / percent
+}
+
+// MaxStamina returns the maximum stamina of the hero
+func (e *StatEngine) MaxStamina() int {
+	return e.values[statMaxStamina]
+}
+
+// Defense returns the defense of the hero. The enhanced defense of the items only counts for
+// the items themselves, the enhanced defense of the skills and states counts for all the
+// defense of the hero.
+func (e *StatEngine) Defense() int {
+	return e.values[statDefense] * (percent + e.bonus[statDefensePercent]) / percent
+}
+
+// AttackRating returns the attack rating of the hero, without the bonus to the attack rating in
+// percent of the item_tohit_percent stat
+func (e *StatEngine) AttackRating() int {
+	return e.values[statAttackRating]
+}
+
+// Resistance returns the resistance of the hero of the given stat, like fireresist, lowered by
+// the ResistPenalty of DifficultyLevels.txt for the difficulty of the game. It can not go beyond
+// 75 percent, plus the maximum resistance of the stat, like maxfireresist, and not below -100
+// percent.
+func (e *StatEngine) Resistance(stat string) int {
+	resistance := e.values[stat]
+
+	// the penalty is negative, like -40 in nightmare and -100 in hell
+	if record := e.factory.asset.Records.DifficultyLevels[e.difficulty]; record != nil {
+		resistance += record.ResistancePenalty
+	}
+
+	return d2math.ClampInt(resistance, minResistance, maxResistance+e.values[maxResistancePrefix+stat])
+}
+
+// baseStatList returns the level and the attributes of the hero
+func (e *StatEngine) baseStatList(stats *HeroStatsState) d2stats.StatList {
+	return e.newStatList(map[string]int{
+		statLevel:     stats.Level,
+		statStrength:  stats.Strength,
+		statDexterity: stats.Dexterity,
+		statVitality:  stats.Vitality,
+		statEnergy:    stats.Energy,
+	})
+}
+
+// derivedStatList returns the life, mana, stamina, defense and attack rating the hero has by
+// its final attributes and the coefficients of CharStats.txt. The defense of the worn items is
+// added to the defense of the dexterity.
+func (e *StatEngine) derivedStatList(stats *HeroStatsState, equipment d2inventory.CharacterEquipment) d2stats.StatList {
+	classStats := e.factory.asset.Records.Character.Stats[e.hero]
+	if classStats == nil {
+		return e.factory.stat.NewStatList()
+	}
+
+	final := *stats
+	final.Vitality, final.Energy = e.values[statVitality], e.values[statEnergy]
+
+	life, mana, stamina := maxVitals(&final, classStats)
+	defense := e.values[statDexterity] / dexterityPerDefense
+
+	for _, item := range equipment.Items() {
+		if worn, ok := item.(*diablo2item.Item); ok {
+			defense += worn.Defense()
+		}
+	}
+
+	return e.newStatList(map[string]int{
+		statMaxLife:      life,
+		statMaxMana:      mana,
+		statMaxStamina:   stamina,
+		statDefense:      defense,
+		statAttackRating: e.values[statDexterity]*attackRatingPerDexterity + baseAttackRating + classStats.ToHitFactor,
+	})
+}
+
+// setBonusStatList returns the bonuses of the sets the hero wears items of. The partial bonuses
+// of Sets.txt are given from two worn items of a set on, the full bonus when all the items of
+// the set are worn. Every worn set item adds its own bonuses of SetItems.txt for the other worn
+// items of its set.
+func (e *StatEngine) setBonusStatList(equipment d2inventory.CharacterEquipment) d2stats.StatList {
+	records := e.factory.asset.Records.Item
+	worn := make(map[string]map[string]*d2records.SetItemRecord)
+
+	for _, item := range equipment.Items() {
+		setItem, ok := item.(*diablo2item.Item)
+		if !ok || setItem.SetItemRecord() == nil {
+			continue
+		}
+
+		record := setItem.SetItemRecord()
+		if worn[record.SetKey] == nil {
+			worn[record.SetKey] = make(map[string]*d2records.SetItemRecord)
+		}
+
+		// wearing the same item of a set twice counts once
+		worn[record.SetKey][record.SetItemKey] = record
+	}
+
+	descriptors := make([]*d2records.PropertyDescriptor, 0)
+
+	for setKey, setItems := range worn {
+		count := len(setItems)
+		if count < minSetItems {
+			continue
+		}
+
+		if set := records.Sets[setKey]; set != nil {
+			for idx := 0; idx < len(set.Properties.PartialA) && idx+minSetItems <= count; idx++ {
+				descriptors = append(descriptors, set.Properties.PartialA[idx], set.Properties.PartialB[idx])
+			}
+
+			if count == setSize(records.SetItems, setKey) {
+				descriptors = append(descriptors, set.Properties.Full...)
+			}
+		}
+
+		for _, record := range setItems {
+			descriptors = append(descriptors, setItemBonuses(records.SetItems, record, setItems)...)
+		}
+	}
+
+	return e.factory.item.PropertyStatList(descriptors)
+}
+
+// setItemBonuses returns the bonuses of SetItems.txt a worn set item adds for the other worn
+// items of its set. With add func 1 the bonus at index n is given when the n-th other item of
+// the set, in the order of SetItems.txt, is worn. With add func 2 it is given when n+2 items of
+// the set are worn.
+func setItemBonuses(setItems d2records.SetItems, record *d2records.SetItemRecord,
+	worn map[string]*d2records.SetItemRecord) []*d2records.PropertyDescriptor {
+	descriptors := make([]*d2records.PropertyDescriptor, 0)
+
+	switch record.AddFn {
+	case setItemAddFnItems:
+		for idx, other := range otherSetItems(setItems, record) {
+			if idx >= len(record.SetPropertiesLevel1) {
+				break
+			}
+
+			if worn[other.SetItemKey] != nil {
+				descriptors = append(descriptors, record.SetPropertiesLevel1[idx], record.SetPropertiesLevel2[idx])
+			}
+		}
+	case setItemAddFnCount:
+		for idx := 0; idx < len(record.SetPropertiesLevel1) && idx+minSetItems <= len(worn); idx++ {
+			descriptors = append(descriptors, record.SetPropertiesLevel1[idx], record.SetPropertiesLevel2[idx])
+		}
+	}
+
+	return descriptors
+}
+
+// otherSetItems returns the other items of the set of a set item, in the order of SetItems.txt
+func otherSetItems(setItems d2records.SetItems, record *d2records.SetItemRecord) []*d2records.SetItemRecord {
+	others := make([]*d2records.SetItemRecord, 0)
+
+	for _, other := range setItems {
+		if other.SetKey == record.SetKey && other.SetItemKey != record.SetItemKey {
+			others = append(others, other)
+		}
+	}
+
+	sort.Slice(others, func(i, j int) bool {
+		return others[i].Index < others[j].Index
+	})
+
+	return others
+}
+
+// setSize returns the number of items of a set
+func setSize(setItems d2records.SetItems, setKey string) int {
+	size := 0
+
+	for _, record := range setItems {
+		if record.SetKey == setKey {
+			size++
+		}
+	}
+
+	return size
+}
+
+// passiveStatList returns the stats of the passive skills of the hero: the passivestat1 to
+// passivestat5 of Skills.txt, with the values of passivecalc1 to passivecalc5 at the levels of
+// the skills. A skill with a passiveitype only counts while the hero attacks with a weapon of
+// the item type.
+func (e *StatEngine) passiveStatList(skills map[int]*HeroSkill, weapon *diablo2item.Item,
+	skillBonus int) d2stats.StatList {
+	values := make(map[string]int)
+
+	for _, skill := range skills {
+		if skill == nil || skill.SkillRecord == nil || !skill.Passive || skill.SkillPoints <= 0 {
+			continue
+		}
+
+		if skill.Passiveitype != "" && !e.isItemType(weapon, skill.Passiveitype) {
+			continue
+		}
+
+		resolve := skillResolver(skill, skills, skillBonus)
+
+		for _, passive := range []struct {
+			stat string
+			calc d2calculation.Calculation
+		}{
+			{skill.Passivestat1, skill.Passivecalc1},
+			{skill.Passivestat2, skill.Passivecalc2},
+			{skill.Passivestat3, skill.Passivecalc3},
+			{skill.Passivestat4, skill.Passivecalc4},
+			{skill.Passivestat5, skill.Passivecalc5},
+		} {
+			if passive.stat != "" {
+				values[passive.stat] += d2calculation.EvalWith(passive.calc, resolve)
+			}
+		}
+	}
+
+	return e.newStatList(values)
+}
+
+// isItemType returns true if the item is of the given item type of ItemTypes.txt, or of a type
+// equivalent to it
+func (e *StatEngine) isItemType(item *diablo2item.Item, itemType string) bool {
+	if item == nil || item.CommonRecord() == nil {
+		return false
+	}
+
+	for _, equivalent := range e.factory.asset.Records.FindEquivalentTypesByItemCommonRecord(item.CommonRecord()) {
+		if equivalent == itemType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// newStatList returns a stat list of the given stats of ItemStatCost.txt with single values
+func (e *StatEngine) newStatList(values map[string]int) d2stats.StatList {
+	list := e.factory.stat.NewStatList()
+
+	for name, value := range values {
+		if stat := e.factory.stat.NewStat(name, float64(value)); stat != nil {
+			list.Push(stat)
+		}
+	}
+
+	return list
+}
+
+// statValues returns the stats with a single value of a stat list, by name
+func statValues(list d2stats.StatList) map[string]int {
+	values := make(map[string]int)
+
+	for _, stat := range list.Stats() {
+		if stat != nil && len(stat.Values()) == 1 {
+			values[stat.Name()] += stat.Values()[0].Int()
+		}
+	}
+
+	return values
+}
+
+// skillResolver returns the resolver of the properties referenced by the calculations of a
+// skill. The bonus adds to the levels of the skills the hero has points in.
+func skillResolver(skill *HeroSkill, skills map[int]*HeroSkill, bonus int) d2calculation.Resolver {
+	return func(reference *d2calculation.PropertyReferenceCalculation) int {
+		referenced := skill
+
+		if reference.Type != skillReference {
+			return 0
+		}
+
+		if reference.Name != "" && reference.Name != skill.Skill {
+			referenced = skillByName(skills, reference.Name)
+		}
+
+		if referenced == nil || referenced.SkillRecord == nil {
+			return 0
+		}
+
+		level := referenced.SkillPoints
+		if level > 0 {
+			level += bonus
+		}
+
+		return skillValue(referenced.SkillRecord, reference.Qualifier, level, referenced.SkillPoints)
+	}
+}
+
+// skillByName returns the skill of the given name
+func skillByName(skills map[int]*HeroSkill, name string) *HeroSkill {
+	for _, skill := range skills {
+		if skill != nil && skill.SkillRecord != nil && skill.Skill == name {
+			return skill
+		}
+	}
+
+	return nil
+}
+
+// skillValue returns the value of a qualifier of the calculations of Skills.txt for a skill at
+// a level. lvl is the level with the bonuses to the skill, blvl the points spent on the skill,
+// par1 to par8 are the params of the skill, ln12 to ln78 grow linear by the level from the
+// first param with the second param and dm12 to dm78 grow diminishing by the level from the
+// first param to the second param.
+func skillValue(record *d2records.SkillRecord, qualifier string, level, baseLevel int) int {
+	params := []int{record.Param1, record.Param2, record.Param3, record.Param4,
+		record.Param5, record.Param6, record.Param7, record.Param8}
+
+	param := func(digit byte) int {
+		idx := int(digit - '1')
+		if idx < 0 || idx >= len(params) {
+			return 0
+		}
+
+		return params[idx]
+	}
+
+	switch {
+	case qualifier == "lvl":
+		return level
+	case qualifier == "blvl":
+		return baseLevel
+	case len(qualifier) == len("par1") && strings.HasPrefix(qualifier, "par"):
+		return param(qualifier[3])
+	case len(qualifier) == len("ln12") && strings.HasPrefix(qualifier, "ln"):
+		return param(qualifier[2]) + (level-1)*param(qualifier[3])
+	case len(qualifier) == len("dm12") && strings.HasPrefix(qualifier, "dm"):
+		low, high := param(qualifier[2]), param(qualifier[3])
+		return low + (high-low)*(diminishingPercent*level/(level+diminishingLevels))/percent
+	}
+
+	return 0
+}
@@ -0,0 +1,179 @@
+package d2hero
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2calculation/d2parser"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2inventory"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2state"
+)
+
+func TestStatEngine_Update(t *testing.T) {
+	factory := testHeroStateFactory(t)
+	records := factory.asset.Records
+
+	records.Character.Stats = d2records.CharStats{
+		d2enum.HeroSorceress: {InitVit: 10, LifePerVit: 2, LifePerLevel: 1, ManaPerEne: 2, ManaPerLevel: 2,
+			InitStamina: 74, StaminaPerVit: 1, StaminaPerLevel: 1, ToHitFactor: 20},
+	}
+
+	staffRecord := &d2records.ItemCommonRecord{Code: "sst", Type: "staf", Durability: 20}
+
+	records.Item.All = d2records.CommonItems{
+		"cap":            {Code: "cap", Type: "helm", Durability: 12, MinAC: 4, MaxAC: 4},
+		staffRecord.Code: staffRecord,
+	}
+	records.Item.Types = map[string]*d2records.ItemTypeRecord{"helm": {Code: "helm"}, "staf": {Code: "staf"}}
+	records.Item.Equivalency = d2records.ItemEquivalenceMap{"weap": {staffRecord}, "staf": {staffRecord}}
+
+	records.Item.Stats = make(map[string]*d2records.ItemStatCostRecord)
+	for _, name := range []string{"level", "strength", "dexterity", "vitality", "energy", "maxhp", "maxmana",
+		"maxstamina", "armorclass", "tohit", "item_armor_percent", "fireresist", "item_allskills"} {
+		records.Item.Stats[name] = &d2records.ItemStatCostRecord{Name: name, DescFnID: 1}
+	}
+
+	records.Properties = map[string]*d2records.PropertyRecord{}
+	for code, stat := range map[string]string{"str": "strength", "vit": "vitality", "hp": "maxhp",
+		"res-fire": "fireresist"} {
+		records.Properties[code] = &d2records.PropertyRecord{Code: code,
+			Stats: [7]*d2records.PropertyStatRecord{{FunctionID: 1, StatCode: stat}}}
+	}
+
+	// the cap adds vitality for the two worn items of its set, the set adds life for two items
+	// and fire resistance for all of its items
+	records.Item.SetItems = d2records.SetItems{
+		"Test Cap": {SetItemKey: "Test Cap", SetKey: "Test Set", ItemCode: "cap", AddFn: 2,
+			Properties:          [9]*d2records.SetItemProperty{{Code: "str", Min: 5, Max: 5}},
+			SetPropertiesLevel1: [5]*d2records.SetItemProperty{{Code: "vit", Min: 3, Max: 3}}},
+		"Test Staff": {SetItemKey: "Test Staff", SetKey: "Test Set", ItemCode: "sst"},
+	}
+	records.Item.Sets = d2records.Sets{"Test Set": {Key: "Test Set"}}
+	records.Item.Sets["Test Set"].Properties.PartialA = []*d2records.SetProperty{{Code: "hp", Min: 10, Max: 10}}
+	records.Item.Sets["Test Set"].Properties.PartialB = []*d2records.SetProperty{nil}
+	records.Item.Sets["Test Set"].Properties.Full = []*d2records.SetProperty{{Code: "res-fire", Min: 30, Max: 30}}
+
+	helm, err := factory.item.NewItem("cap", "Test Cap")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	staff, err := factory.item.NewItem("sst", "Test Staff")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	helm.Location, staff.Location = d2enum.ItemLocationEquipped, d2enum.ItemLocationEquipped
+	helm.SetSlotType(d2enum.EquippedSlotHead)
+	staff.SetSlotType(d2enum.EquippedSlotRightArm)
+
+	parser := d2parser.New()
+	parser.SetCurrentReference("skill", "Staff Mastery")
+
+	mastery := &HeroSkill{SkillPoints: 3, SkillRecord: &d2records.SkillRecord{ID: 1, Skill: "Staff Mastery",
+		Passive: true, Passiveitype: "staf", Passivestat1: "tohit", Passivecalc1: parser.Parse("ln12"),
+		Param1: 20, Param2: 5}}
+
+	states := d2state.NewManager()
+	states.Set(&d2records.StateRecord{State: "shrine"}, map[string]int{"item_armor_percent": 50, "fireresist": 60},
+		time.Time{})
+
+	stats := &HeroStatsState{Level: 3, Strength: 10, Dexterity: 25, Vitality: 10, Energy: 35}
+
+	engine := factory.NewStatEngine(d2enum.HeroSorceress, states)
+	engine.Update(stats, map[int]*HeroSkill{1: mastery}, d2inventory.NewCharacterEquipment(helm, staff))
+
+	if engine.Attribute(d2enum.AttributeStrength) != 15 || engine.Attribute(d2enum.AttributeVitality) != 13 {
+		t.Errorf("expected the strength of the cap and the vitality of the set, got %d and %d",
+			engine.Attribute(d2enum.AttributeStrength), engine.Attribute(d2enum.AttributeVitality))
+	}
+
+	if engine.MaxLife() != 38 || engine.MaxMana() != 74 || engine.MaxStamina() != 79 {
+		t.Errorf("expected the life, mana and stamina of the final attributes, got %d, %d and %d",
+			engine.MaxLife(), engine.MaxMana(), engine.MaxStamina())
+	}
+
+	// the dexterity and the cap give 10 defense, enhanced by the state
+	if engine.Defense() != 15 || engine.AttackRating() != 140 {
+		t.Errorf("expected the defense of the state and the attack rating of the mastery, got %d and %d",
+			engine.Defense(), engine.AttackRating())
+	}
+
+	if engine.Resistance("fireresist") != 75 {
+		t.Errorf("expected the fire resistance to be capped, got %d", engine.Resistance("fireresist"))
+	}
+
+	// the penalty of the difficulty is applied before the cap
+	records.DifficultyLevels = d2records.DifficultyLevels{d2enum.DifficultyHell: {ResistancePenalty: -100}}
+	engine.SetDifficulty(d2enum.DifficultyHell)
+
+	if engine.Resistance("fireresist") != -10 {
+		t.Errorf("expected the fire resistance of hell, got %d", engine.Resistance("fireresist"))
+	}
+
+	engine.SetDifficulty(d2enum.DifficultyNormal)
+
+	// without the staff the set is broken, and the mastery does not count
+	engine.Update(stats, map[int]*HeroSkill{1: mastery}, d2inventory.NewCharacterEquipment(helm))
+
+	if engine.MaxLife() != 22 || engine.AttackRating() != 110 || engine.Resistance("fireresist") != 60 {
+		t.Errorf("expected no set bonuses and no mastery, got %d life, %d attack rating and %d fire resistance",
+			engine.MaxLife(), engine.AttackRating(), engine.Resistance("fireresist"))
+	}
+}
+
+func TestStatEngine_SetItemBonuses(t *testing.T) {
+	factory := testHeroStateFactory(t)
+	records := factory.asset.Records
+
+	records.Item.All = d2records.CommonItems{
+		"cap": {Code: "cap", Type: "helm", Durability: 12},
+		"sst": {Code: "sst", Type: "staf", Durability: 20},
+	}
+	records.Item.Types = map[string]*d2records.ItemTypeRecord{"helm": {Code: "helm"}, "staf": {Code: "staf"}}
+	records.Item.Stats = map[string]*d2records.ItemStatCostRecord{
+		"strength":  {Name: "strength", DescFnID: 1},
+		"dexterity": {Name: "dexterity", DescFnID: 1},
+	}
+	records.Properties = map[string]*d2records.PropertyRecord{
+		"str": {Code: "str", Stats: [7]*d2records.PropertyStatRecord{{FunctionID: 1, StatCode: "strength"}}},
+		"dex": {Code: "dex", Stats: [7]*d2records.PropertyStatRecord{{FunctionID: 1, StatCode: "dexterity"}}},
+	}
+
+	// the staff adds strength for the gloves and for the cap, the cap adds dexterity for two and
+	// for three worn items of the set
+	records.Item.SetItems = d2records.SetItems{
+		"Test Gloves": {Index: 0, SetItemKey: "Test Gloves", SetKey: "Test Set", ItemCode: "lgl"},
+		"Test Cap": {Index: 1, SetItemKey: "Test Cap", SetKey: "Test Set", ItemCode: "cap", AddFn: 2,
+			SetPropertiesLevel1: [5]*d2records.SetItemProperty{{Code: "dex", Min: 3, Max: 3},
+				{Code: "dex", Min: 7, Max: 7}}},
+		"Test Staff": {Index: 2, SetItemKey: "Test Staff", SetKey: "Test Set", ItemCode: "sst", AddFn: 1,
+			SetPropertiesLevel1: [5]*d2records.SetItemProperty{{Code: "str", Min: 1, Max: 1},
+				{Code: "str", Min: 2, Max: 2}}},
+	}
+	records.Item.Sets = d2records.Sets{"Test Set": {Key: "Test Set"}}
+
+	helm, err := factory.item.NewItem("cap", "Test Cap")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	staff, err := factory.item.NewItem("sst", "Test Staff")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	helm.Location, staff.Location = d2enum.ItemLocationEquipped, d2enum.ItemLocationEquipped
+	helm.SetSlotType(d2enum.EquippedSlotHead)
+	staff.SetSlotType(d2enum.EquippedSlotRightArm)
+
+	engine := factory.NewStatEngine(d2enum.HeroSorceress, nil)
+	engine.Update(&HeroStatsState{Strength: 10, Dexterity: 10}, nil, d2inventory.NewCharacterEquipment(helm, staff))
+
+	if engine.Attribute(d2enum.AttributeStrength) != 12 || engine.Attribute(d2enum.AttributeDexterity) != 13 {
+		t.Errorf("expected the strength for the worn cap and the dexterity for two worn items, got %d and %d",
+			engine.Attribute(d2enum.AttributeStrength), engine.Attribute(d2enum.AttributeDexterity))
+	}
+}
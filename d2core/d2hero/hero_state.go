@@ -2,22 +2,41 @@ package d2hero
 
 import (
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hireling"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2inventory"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2state"
 )
 
 // HeroState stores the state of the player
 type HeroState struct {
-	HeroName   string                         `json:"heroName"`
-	HeroType   d2enum.Hero                    `json:"heroType"`
-	Act        int                            `json:"act"`
-	FilePath   string                         `json:"-"`
-	Equipment  d2inventory.CharacterEquipment `json:"equipment"`
-	Stats      *HeroStatsState                `json:"stats"`
-	Skills     map[int]*HeroSkill             `json:"skills"`
-	X          float64                        `json:"x"`
-	Y          float64                        `json:"y"`
-	LeftSkill  int                            `json:"leftSkill"`
-	RightSkill int                            `json:"rightSkill"`
-	Gold       int                            `json:"Gold"`
-	Difficulty d2enum.DifficultyType          `json:"difficulty"`
+	HeroName   string                      `json:"heroName"`
+	HeroType   d2enum.Hero                 `json:"heroType"`
+	Act        int                         `json:"act"`
+	FilePath   string                      `json:"-"`
+	Equipment  *d2inventory.SavedEquipment `json:"equipment,omitempty"` // only in the saves made before items were saved
+	Stats      *HeroStatsState             `json:"stats"`
+	Skills     map[int]*HeroSkill          `json:"skills"`
+	X          float64                     `json:"x"`
+	Y          float64                     `json:"y"`
+	LeftSkill  int                         `json:"leftSkill"`
+	RightSkill int                         `json:"rightSkill"`
+	Gold       int                         `json:"Gold"`
+	Difficulty d2enum.DifficultyType       `json:"difficulty"`
+	States     []d2state.SavedState        `json:"states,omitempty"` // the timed states of States.txt the hero is in
+	Hireling   *d2hireling.Hireling        `json:"hireling,omitempty"`
+	Items      []*HeroItem                 `json:"items"`
+	Progress   *HeroProgress               `json:"progress,omitempty"` // only for heroes imported from D2S files
+
+	// RemoteKey is the secret a hero is known by on the servers it joins. The saves a server
+	// keeps for remote heroes hold the hash of the key of the client which owns them.
+	RemoteKey string `json:"remoteKey,omitempty"`
+}
+
+// HeroProgress holds the sections of an original Diablo II character save which the HeroState
+// does not track, so that they are kept when the hero is exported again
+type HeroProgress struct {
+	Quests    []byte `json:"quests"`    // the quest flags of the three difficulties
+	Waypoints []byte `json:"waypoints"` // the waypoints of the three difficulties
+	NPCs      []byte `json:"npcs"`      // the introductions and congratulations of the NPCs
+	ItemLists []byte `json:"itemLists"` // the corpse, mercenary and golem item lists
 }
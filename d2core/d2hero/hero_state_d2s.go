@@ -0,0 +1,193 @@
+package d2hero
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2fileformats/d2d2s"
+)
+
+// LoadD2S loads an original Diablo II character save file and converts it to a HeroState
+func (f *HeroStateFactory) LoadD2S(filePath string) (*HeroState, error) {
+	data, err := ioutil.ReadFile(filepath.Clean(filePath))
+	if err != nil {
+		return nil, err
+	}
+
+	return f.ImportD2S(data)
+}
+
+// ImportD2S converts the data of an original Diablo II character save file to a HeroState.
+// The items of the player item list become the items of the hero. The quest, waypoint and NPC
+// sections and the other item lists are kept as they are in the progress of the hero.
+func (f *HeroStateFactory) ImportD2S(data []byte) (*HeroState, error) {
+	save, err := d2d2s.Load(data)
+	if err != nil {
+		return nil, err
+	}
+
+	classStats := f.asset.Records.Character.Stats[save.Class]
+
+	skills, err := f.CreateHeroSkillsState(classStats, save.Class)
+	if err != nil {
+		return nil, err
+	}
+
+	firstSkillID := d2d2s.FirstClassSkillID(save.Class)
+
+	for idx := range save.Skills {
+		if skill, found := skills[firstSkillID+idx]; found {
+			skill.SkillPoints = int(save.Skills[idx])
+			skill.Shallow.SkillPoints = skill.SkillPoints
+		}
+	}
+
+	items, size, err := f.item.DeserializeList(save.ItemData, int(save.ItemCount))
+	if err != nil {
+		return nil, fmt.Errorf("d2s: %v", err)
+	}
+
+	heroItems := make([]*HeroItem, len(items))
+	for idx := range items {
+		heroItems[idx] = NewHeroItem(items[idx])
+	}
+
+	difficulty, act := save.CurrentDifficulty()
+
+	result := &HeroState{
+		HeroName:   save.Name,
+		HeroType:   save.Class,
+		Act:        act,
+		Items:      heroItems,
+		Stats:      f.heroStatsFromD2S(&save.Stats),
+		Skills:     skills,
+		LeftSkill:  int(save.LeftSkill),
+		RightSkill: int(save.RightSkill),
+		Gold:       int(save.Stats[d2d2s.StatGold]),
+		Difficulty: difficulty,
+		Progress:   heroProgressFromD2S(save, save.ItemData[size:]),
+	}
+
+	result.Stats.NextLevelExp = f.asset.Records.GetExperienceBreakpoint(save.Class, result.Stats.Level)
+
+	return result, nil
+}
+
+func (f *HeroStateFactory) heroStatsFromD2S(stats *d2d2s.Stats) *HeroStatsState {
+	result := &HeroStatsState{
+		Level:       int(stats[d2d2s.StatLevel]),
+		Experience:  int(stats[d2d2s.StatExperience]),
+		Strength:    int(stats[d2d2s.StatStrength]),
+		Energy:      int(stats[d2d2s.StatEnergy]),
+		Dexterity:   int(stats[d2d2s.StatDexterity]),
+		Vitality:    int(stats[d2d2s.StatVitality]),
+		StatsPoints: int(stats[d2d2s.StatStatPoints]),
+		SkillPoints: int(stats[d2d2s.StatSkillPoints]),
+		Health:      stats.GetFixed(d2d2s.StatLife),
+		MaxHealth:   stats.GetFixed(d2d2s.StatMaxLife),
+		Mana:        stats.GetFixed(d2d2s.StatMana),
+		MaxMana:     stats.GetFixed(d2d2s.StatMaxMana),
+		MaxStamina:  stats.GetFixed(d2d2s.StatMaxStamina),
+	}
+
+	result.Stamina = float64(result.MaxStamina)
+
+	return result
+}
+
+// heroProgressFromD2S keeps the quest, waypoint and NPC sections of a save, and the item lists
+// which follow the player item list
+func heroProgressFromD2S(save *d2d2s.D2S, itemLists []byte) *HeroProgress {
+	progress := &HeroProgress{
+		NPCs:      append([]byte{}, save.NPCs[:]...),
+		ItemLists: append([]byte{}, itemLists...),
+	}
+
+	for difficulty := range save.Quests {
+		progress.Quests = append(progress.Quests, save.Quests[difficulty][:]...)
+		progress.Waypoints = append(progress.Waypoints, save.Waypoints[difficulty][:]...)
+	}
+
+	return progress
+}
+
+// ExportD2S converts the HeroState to an original Diablo II character save file.
+// The items of the hero are written to the player item list. The progress of a hero which was
+// not imported from a D2S file is exported as for a new character.
+func (f *HeroStateFactory) ExportD2S(state *HeroState) []byte {
+	save := d2d2s.New(state.HeroName, state.HeroType)
+
+	save.LeftSkill = uint32(state.LeftSkill)
+	save.RightSkill = uint32(state.RightSkill)
+	save.SetCurrentDifficulty(state.Difficulty, state.Act)
+
+	if stats := state.Stats; stats != nil {
+		save.Level = byte(stats.Level)
+		save.Stats[d2d2s.StatLevel] = uint32(stats.Level)
+		save.Stats[d2d2s.StatExperience] = uint32(stats.Experience)
+		save.Stats[d2d2s.StatStrength] = uint32(stats.Strength)
+		save.Stats[d2d2s.StatEnergy] = uint32(stats.Energy)
+		save.Stats[d2d2s.StatDexterity] = uint32(stats.Dexterity)
+		save.Stats[d2d2s.StatVitality] = uint32(stats.Vitality)
+		save.Stats[d2d2s.StatStatPoints] = uint32(stats.StatsPoints)
+		save.Stats[d2d2s.StatSkillPoints] = uint32(stats.SkillPoints)
+		save.Stats.SetFixed(d2d2s.StatLife, stats.Health)
+		save.Stats.SetFixed(d2d2s.StatMaxLife, stats.MaxHealth)
+		save.Stats.SetFixed(d2d2s.StatMana, stats.Mana)
+		save.Stats.SetFixed(d2d2s.StatMaxMana, stats.MaxMana)
+		save.Stats.SetFixed(d2d2s.StatStamina, stats.MaxStamina)
+		save.Stats.SetFixed(d2d2s.StatMaxStamina, stats.MaxStamina)
+	}
+
+	save.Stats[d2d2s.StatGold] = uint32(state.Gold)
+
+	firstSkillID := d2d2s.FirstClassSkillID(state.HeroType)
+
+	for idx := 0; idx < d2d2s.NumClassSkills(); idx++ {
+		if skill, found := state.Skills[firstSkillID+idx]; found && skill != nil {
+			save.Skills[idx] = byte(skill.SkillPoints)
+		}
+	}
+
+	itemLists := save.ItemData
+
+	if progress := state.Progress; progress != nil {
+		quests, waypoints := progress.Quests, progress.Waypoints
+
+		for difficulty := range save.Quests {
+			quests = quests[copy(save.Quests[difficulty][:], quests):]
+			waypoints = waypoints[copy(save.Waypoints[difficulty][:], waypoints):]
+		}
+
+		copy(save.NPCs[:], progress.NPCs)
+
+		if len(progress.ItemLists) > 0 {
+			itemLists = progress.ItemLists
+		}
+	}
+
+	save.ItemCount, save.ItemData = 0, nil
+
+	for _, heroItem := range state.Items {
+		if heroItem == nil {
+			continue
+		}
+
+		data := heroItem.Data
+		if heroItem.Item != nil {
+			data = heroItem.Item.Serialize()
+		}
+
+		if len(data) == 0 {
+			continue
+		}
+
+		save.ItemCount++
+		save.ItemData = append(save.ItemData, data...)
+	}
+
+	save.ItemData = append(save.ItemData, itemLists...)
+
+	return save.Marshal()
+}
@@ -0,0 +1,81 @@
+package d2hero
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2fileformats/d2d2s"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+)
+
+func TestHeroStateFactory_ImportD2S(t *testing.T) {
+	factory := testHeroStateFactory(t)
+	records := factory.asset.Records
+
+	records.Character.Stats = d2records.CharStats{d2enum.HeroSorceress: {}}
+	records.Character.Experience = d2records.ExperienceBreakpoints{
+		12: {Level: 12, HeroBreakpoints: map[d2enum.Hero]int{d2enum.HeroSorceress: 60000}},
+	}
+	records.Skill.Details = d2records.SkillDetails{0: {ID: 0, Skill: "Attack", Skilldesc: "attack"}}
+	records.Skill.Descriptions = d2records.SkillDescriptions{"attack": {Name: "attack"}}
+	records.Item.All = d2records.CommonItems{
+		"cap": {Code: "cap", Type: "helm", Durability: 12},
+		"sst": {Code: "sst", Type: "staf", Durability: 20},
+	}
+	records.Item.Types = map[string]*d2records.ItemTypeRecord{"helm": {Code: "helm"}, "staf": {Code: "staf"}}
+
+	helm, err := factory.item.NewItem("cap")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	staff, err := factory.item.NewItem("sst")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	helm.Location = d2enum.ItemLocationEquipped
+	helm.SetSlotType(d2enum.EquippedSlotHead)
+	staff.Location, staff.Storage, staff.GridX, staff.GridY = d2enum.ItemLocationStored, d2enum.ItemStorageStash, 2, 1
+
+	save := d2d2s.New("Importer", d2enum.HeroSorceress)
+	save.Stats[d2d2s.StatLevel] = 12
+	save.Quests[d2enum.DifficultyNormal][4] = 0x01
+	save.Waypoints[d2enum.DifficultyNightmare][3] = 0x07
+	save.NPCs[9] = 0x02
+	save.ItemCount = 2
+	save.ItemData = append(append(helm.Serialize(), staff.Serialize()...), save.ItemData...)
+
+	state, err := factory.ImportD2S(save.Marshal())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(state.Items) != 2 || state.Items[0].Item.CommonCode != "cap" ||
+		state.Items[0].Item.SlotType() != d2enum.EquippedSlotHead || state.Items[1].Item.GridX != 2 ||
+		state.Items[1].Item.Storage != d2enum.ItemStorageStash {
+		t.Fatalf("expected the equipped cap and the stashed staff, got %+v", state.Items)
+	}
+
+	exported := factory.ExportD2S(state)
+
+	imported, err := factory.ImportD2S(exported)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(factory.ExportD2S(imported), exported) {
+		t.Error("expected the imported hero to be exported the same")
+	}
+
+	loaded, err := d2d2s.Load(exported)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if loaded.ItemCount != 2 || loaded.Quests != save.Quests || loaded.Waypoints != save.Waypoints ||
+		loaded.NPCs != save.NPCs || !bytes.Equal(loaded.ItemData, save.ItemData) {
+		t.Errorf("expected the items and the progress to be exported, got %d items", loaded.ItemCount)
+	}
+}
@@ -1,6 +1,8 @@
 package d2hero
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -10,37 +12,59 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/google/uuid"
+
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2inventory"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2item/diablo2item"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2stats/diablo2stats"
 
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2util"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2asset"
 )
 
 const (
 	mkdirPermission     = 0750
 	writefilePermission = 0600
+
+	// the heroes of remote clients are saved apart from the heroes of the player
+	remoteSavesDir = "Remote"
+
+	logPrefix = "Hero State Factory"
 )
 
 // NewHeroStateFactory creates a new HeroStateFactory and initializes it.
-func NewHeroStateFactory(asset *d2asset.AssetManager) (*HeroStateFactory, error) {
-	inventoryItemFactory, err := d2inventory.NewInventoryItemFactory(asset)
+func NewHeroStateFactory(asset *d2asset.AssetManager, l d2util.LogLevel) (*HeroStateFactory, error) {
+	itemFactory, err := diablo2item.NewItemFactory(asset)
+	if err != nil {
+		return nil, err
+	}
+
+	statFactory, err := diablo2stats.NewStatFactory(asset)
 	if err != nil {
 		return nil, err
 	}
 
 	factory := &HeroStateFactory{
-		asset:                asset,
-		InventoryItemFactory: inventoryItemFactory,
+		asset: asset,
+		item:  itemFactory,
+		stat:  statFactory,
 	}
 
+	factory.logger = d2util.NewLogger()
+	factory.logger.SetLevel(l)
+	factory.logger.SetPrefix(logPrefix)
+
 	return factory, nil
 }
 
 // HeroStateFactory is responsible for creating player state objects
 type HeroStateFactory struct {
-	asset *d2asset.AssetManager
-	*d2inventory.InventoryItemFactory
+	asset  *d2asset.AssetManager
+	item   *diablo2item.ItemFactory
+	stat   *diablo2stats.StatFactory
+	logger *d2util.Logger
 }
 
 // CreateHeroState creates a HeroState instance and returns a pointer to it
@@ -50,12 +74,12 @@ func (f *HeroStateFactory) CreateHeroState(
 	statsState *HeroStatsState,
 ) (*HeroState, error) {
 	result := &HeroState{
-		HeroName:  heroName,
-		HeroType:  hero,
-		Act:       1,
-		Stats:     statsState,
-		Equipment: f.DefaultHeroItems[hero],
-		FilePath:  "",
+		HeroName: heroName,
+		HeroType: hero,
+		Act:      1,
+		Stats:    statsState,
+		Items:    f.CreateEquippedItems(d2inventory.DefaultHeroObjects()[hero]),
+		FilePath: "",
 	}
 
 	defaultStats := f.asset.Records.Character.Stats[hero]
@@ -215,6 +239,15 @@ func (f *HeroStateFactory) LoadHeroState(filePath string) *HeroState {
 		hs.SkillPoints = hs.Shallow.SkillPoints
 	}
 
+	if result.Items == nil && result.Equipment != nil {
+		// the saves made before the items of the hero were saved only have the equipment,
+		// the items are written the next time the hero is saved
+		result.Items = f.CreateEquippedItems(result.Equipment.Slots())
+		result.Equipment = nil
+	} else if result.Items, err = f.LoadItems(result.Items); err != nil {
+		f.logger.Errorf("failed to load the items of %s, err: %v", result.HeroName, err)
+	}
+
 	return result
 }
 
@@ -227,6 +260,101 @@ func (f *HeroStateFactory) getGameBaseSavePath() (string, error) {
 	return path.Join(configDir, "OpenDiablo2/Saves"), nil
 }
 
+// RemoteKey returns the key the hero is known by on the servers it joins. The key is created and
+// saved with the hero the first time it joins a server.
+func (f *HeroStateFactory) RemoteKey(state *HeroState) (string, error) {
+	if state.RemoteKey != "" {
+		return state.RemoteKey, nil
+	}
+
+	state.RemoteKey = uuid.New().String()
+
+	if err := f.Save(state); err != nil {
+		return "", err
+	}
+
+	return state.RemoteKey, nil
+}
+
+// LoadRemoteHeroState loads the hero a server saved for a remote client, by the name and class of
+// the hero. A hero the server has not saved yet starts as a new hero of the class. Only the name
+// and the class are taken from the hero state a remote client sends, so the level, experience,
+// items and gold of a remote hero are always the ones it earned on the server.
+//
+// A saved hero is only loaded for the client which sends the key of the hero which was saved, and
+// with the same name, so a hero can not be taken by another client which picks the same name.
+func (f *HeroStateFactory) LoadRemoteHeroState(heroName string, hero d2enum.Hero, key string) (*HeroState, error) {
+	classStats, found := f.asset.Records.Character.Stats[hero]
+	if !found || classStats == nil {
+		return nil, fmt.Errorf("unknown hero type %d", hero)
+	}
+
+	if key == "" {
+		return nil, fmt.Errorf("no key sent for %s", heroName)
+	}
+
+	fileName := remoteSaveFileName(heroName, hero)
+	if fileName == "" {
+		return nil, fmt.Errorf("invalid hero name %q", heroName)
+	}
+
+	basePath, err := f.getGameBaseSavePath()
+	if err != nil {
+		return nil, err
+	}
+
+	filePath := path.Join(basePath, remoteSavesDir, fileName)
+
+	if _, err := os.Stat(filePath); err == nil {
+		state := f.LoadHeroState(filePath)
+		if state == nil || state.HeroType != hero {
+			return nil, fmt.Errorf("failed to load the save of %s", heroName)
+		}
+
+		if state.RemoteKey != remoteKeyHash(key) || state.HeroName != heroName {
+			return nil, fmt.Errorf("the hero %s is owned by another client", heroName)
+		}
+
+		return state, nil
+	}
+
+	state, err := f.CreateHeroState(heroName, hero, f.CreateHeroStatsState(hero, classStats))
+	if err != nil {
+		return nil, err
+	}
+
+	state.FilePath = filePath
+	state.RemoteKey = remoteKeyHash(key)
+
+	return state, nil
+}
+
+// remoteKeyHash returns the hash of the key of a remote hero, which is saved instead of the key
+func remoteKeyHash(key string) string {
+	hash := sha256.Sum256([]byte(key))
+
+	return hex.EncodeToString(hash[:])
+}
+
+// remoteSaveFileName returns the file name of the save of a remote hero, with only the letters,
+// digits, dashes and underscores of its name
+func remoteSaveFileName(heroName string, hero d2enum.Hero) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		}
+
+		return -1
+	}, heroName)
+
+	if name == "" {
+		return ""
+	}
+
+	return strings.ToLower(hero.GetToken3()) + "_" + name + ".od2"
+}
+
 func (f *HeroStateFactory) getFirstFreeFileName() string {
 	i := 0
 	basePath, _ := f.getGameBaseSavePath()
@@ -0,0 +1,87 @@
+package d2hero
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+)
+
+func TestHeroStateFactory_LoadRemoteHeroState(t *testing.T) {
+	factory := testHeroStateFactory(t)
+	factory.asset.Records.Character.Stats = d2records.CharStats{d2enum.HeroSorceress: {InitVit: 10}}
+	factory.asset.Records.Skill.Details = d2records.SkillDetails{0: {ID: 0, Skill: "Attack", Skilldesc: "attack"}}
+	factory.asset.Records.Skill.Descriptions = d2records.SkillDescriptions{"attack": {Name: "attack"}}
+	factory.asset.Records.Character.Experience = d2records.ExperienceBreakpoints{
+		1: {Level: 1, HeroBreakpoints: map[d2enum.Hero]int{d2enum.HeroSorceress: 500}},
+	}
+
+	dir, err := ioutil.TempDir("", "d2hero")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	for _, key := range []string{"XDG_CONFIG_HOME", "HOME"} {
+		defer os.Setenv(key, os.Getenv(key))
+
+		if err := os.Setenv(key, dir); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := factory.LoadRemoteHeroState("../..", d2enum.HeroSorceress, "key"); err == nil {
+		t.Error("expected an error for a hero name without letters")
+	}
+
+	if _, err := factory.LoadRemoteHeroState("Tester", d2enum.HeroSorceress, ""); err == nil {
+		t.Error("expected an error for a client without a key")
+	}
+
+	state, err := factory.LoadRemoteHeroState("Remote/../Tester", d2enum.HeroSorceress, "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if state.Stats.Level != 1 || state.Stats.Experience != 0 || filepath.Dir(state.FilePath) !=
+		filepath.Join(dir, "OpenDiablo2/Saves", remoteSavesDir) {
+		t.Fatalf("expected a new hero saved with the remote heroes, got level %d in %s",
+			state.Stats.Level, state.FilePath)
+	}
+
+	state.Stats.Level, state.Stats.Experience = 12, 60000
+
+	if err := factory.Save(state); err != nil {
+		t.Fatal(err)
+	}
+
+	if state.RemoteKey == "key" {
+		t.Error("expected the hash of the key to be saved instead of the key")
+	}
+
+	// another client which picks the same name, or a name saved to the same file
+	if _, err := factory.LoadRemoteHeroState("Remote/../Tester", d2enum.HeroSorceress, "other"); err == nil {
+		t.Error("expected an error loading the hero with the key of another client")
+	}
+
+	if _, err := factory.LoadRemoteHeroState("RemoteTester", d2enum.HeroSorceress, "key"); err == nil {
+		t.Error("expected an error loading the hero with another name")
+	}
+
+	loaded, err := factory.LoadRemoteHeroState("Remote/../Tester", d2enum.HeroSorceress, "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if loaded.Stats.Experience != 60000 || loaded.FilePath != state.FilePath {
+		t.Errorf("expected the saved hero to be loaded, got %d experience", loaded.Stats.Experience)
+	}
+
+	if heroes, err := factory.GetAllHeroStates(); err != nil || len(heroes) != 0 {
+		t.Errorf("expected the remote heroes not to be listed with the heroes of the player, got %d", len(heroes))
+	}
+}
@@ -0,0 +1,289 @@
+package d2hero
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hireling"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+)
+
+const (
+	// stat and skill points which are rewarded by quests over all three difficulties
+	questStatPoints  = 15
+	questSkillPoints = 12
+
+	skillPointsPerLevel = 1
+	goldPerLevel        = 10000
+
+	minAct = 1
+	maxAct = 5
+)
+
+// heroStateSanitizer clamps values of a hero state and remembers if any value was changed
+type heroStateSanitizer struct {
+	corrected bool
+}
+
+func (s *heroStateSanitizer) clamp(value *int, min, max int) {
+	switch {
+	case *value < min:
+		*value = min
+	case *value > max:
+		*value = max
+	default:
+		return
+	}
+
+	s.corrected = true
+}
+
+func (s *heroStateSanitizer) set(value *int, v int) {
+	if *value != v {
+		*value = v
+		s.corrected = true
+	}
+}
+
+// SanitizeHeroState makes the given hero state consistent with the class records and the
+// experience of the hero, so that a server only works with values which can be earned in
+// the game. Level, maximum life, mana and stamina are derived from the experience and the
+// attributes of the hero, so the hero state has to come from a save the server trusts, see
+// LoadRemoteHeroState. It returns true if any value had to be corrected, and an error if
+// the hero state can not be used at all.
+func (f *HeroStateFactory) SanitizeHeroState(state *HeroState) (bool, error) {
+	classStats, found := f.asset.Records.Character.Stats[state.HeroType]
+	if !found || classStats == nil {
+		return false, fmt.Errorf("unknown hero type %d", state.HeroType)
+	}
+
+	s := &heroStateSanitizer{}
+
+	if state.Stats == nil {
+		state.Stats = f.CreateHeroStatsState(state.HeroType, classStats)
+		s.corrected = true
+	}
+
+	if err := f.sanitizeHeroSkills(s, state); err != nil {
+		return false, err
+	}
+
+	f.sanitizeHeroStats(s, state.HeroType, state.Stats, classStats)
+	f.sanitizeHeroSkillPoints(s, state)
+
+	s.clamp(&state.Act, minAct, maxAct)
+	s.clamp(&state.Gold, 0, state.Stats.Level*goldPerLevel)
+
+	difficulty := int(state.Difficulty)
+	s.clamp(&difficulty, int(d2enum.DifficultyNormal), int(d2enum.DifficultyHell))
+	state.Difficulty = d2enum.DifficultyType(difficulty)
+
+	if _, found := state.Skills[state.LeftSkill]; !found {
+		s.set(&state.LeftSkill, 0)
+	}
+
+	if _, found := state.Skills[state.RightSkill]; !found {
+		s.set(&state.RightSkill, 0)
+	}
+
+	f.sanitizeHeroStates(s, state)
+	f.sanitizeHireling(s, state)
+
+	return s.corrected, nil
+}
+
+// sanitizeHireling removes a hireling which is not in Hireling.txt, derives the level of the
+// hireling from its experience and removes the items it cannot wear
+func (f *HeroStateFactory) sanitizeHireling(s *heroStateSanitizer, state *HeroState) {
+	hireling := state.Hireling
+	if hireling == nil {
+		return
+	}
+
+	hired := d2hireling.FindRecord(f.asset.Records.Hireling.Details, hireling.ID)
+	if hired == nil {
+		state.Hireling = nil
+		s.corrected = true
+
+		return
+	}
+
+	s.clamp(&hireling.Experience, 0, math.MaxInt32)
+	s.clamp(&hireling.Level, hired.Level, d2hireling.MaxLevel)
+
+	for hireling.Level > hired.Level &&
+		hireling.Experience < d2hireling.ExperienceForLevel(hireling.Record(f.asset.Records.Hireling.Details),
+			hireling.Level) {
+		s.set(&hireling.Level, hireling.Level-1)
+	}
+
+	minLife, maxLife := 1, hireling.Stats(f.asset.Records.Hireling.Details).Life
+	if hireling.Dead {
+		minLife, maxLife = 0, 0
+	}
+
+	s.clamp(&hireling.Life, minLife, maxLife)
+
+	for slot, codes := range hireling.Equipment {
+		if len(codes) == 0 || !hireling.CanEquip(f.asset.Records, slot, f.asset.Records.Item.All[codes[0]]) {
+			delete(hireling.Equipment, slot)
+			s.corrected = true
+		}
+	}
+}
+
+// sanitizeHeroStates removes the states which are not in States.txt, and the states which do
+// not expire. Those are auras and curses, which are given by their skills again.
+func (f *HeroStateFactory) sanitizeHeroStates(s *heroStateSanitizer, state *HeroState) {
+	states := state.States[:0]
+
+	for _, saved := range state.States {
+		if _, found := f.asset.Records.States[saved.State]; !found || saved.Remaining <= 0 {
+			s.corrected = true
+			continue
+		}
+
+		states = append(states, saved)
+	}
+
+	state.States = states
+}
+
+// sanitizeHeroSkills removes skills which do not exist or belong to another class, and
+// hydrates the remaining skills with their records.
+func (f *HeroStateFactory) sanitizeHeroSkills(s *heroStateSanitizer, state *HeroState) error {
+	if state.Skills == nil {
+		skills, err := f.CreateHeroSkillsState(f.asset.Records.Character.Stats[state.HeroType], state.HeroType)
+		if err != nil {
+			return err
+		}
+
+		state.Skills = skills
+		s.corrected = true
+	}
+
+	token := strings.ToLower(state.HeroType.GetToken3())
+
+	for skillID, skill := range state.Skills {
+		record, found := f.asset.Records.Skill.Details[skillID]
+		if skill == nil || !found || (record.Charclass != "" && record.Charclass != token) {
+			delete(state.Skills, skillID)
+			s.corrected = true
+
+			continue
+		}
+
+		if skill.Shallow == nil {
+			skill.Shallow = &shallowHeroSkill{SkillID: skillID, SkillPoints: skill.SkillPoints}
+		}
+
+		skill.SkillRecord = record
+		skill.SkillDescriptionRecord = f.asset.Records.Skill.Descriptions[record.Skilldesc]
+		skill.SkillPoints = skill.Shallow.SkillPoints
+		skill.Shallow.SkillID = skillID
+
+		if record.Maxlvl > 0 {
+			s.clamp(&skill.SkillPoints, 0, record.Maxlvl)
+		}
+
+		skill.Shallow.SkillPoints = skill.SkillPoints
+	}
+
+	return nil
+}
+
+func (f *HeroStateFactory) sanitizeHeroStats(s *heroStateSanitizer, hero d2enum.Hero, stats *HeroStatsState,
+	classStats *d2records.CharStatRecord) {
+	s.clamp(&stats.Experience, 0, f.maxExperience(hero))
+	s.set(&stats.Level, f.levelForExperience(hero, stats.Experience))
+
+	stats.NextLevelExp = f.experienceBreakpoint(hero, stats.Level)
+
+	// attributes can not be lower than the initial values of the class, and the points spent
+	// on them can not be more than the points earned by leveling up and doing quests
+	s.clamp(&stats.Strength, classStats.InitStr, stats.Strength)
+	s.clamp(&stats.Dexterity, classStats.InitDex, stats.Dexterity)
+	s.clamp(&stats.Vitality, classStats.InitVit, stats.Vitality)
+	s.clamp(&stats.Energy, classStats.InitEne, stats.Energy)
+
+	earned := (stats.Level-1)*classStats.StatPerLevel + questStatPoints
+	spent := stats.Strength - classStats.InitStr + stats.Dexterity - classStats.InitDex +
+		stats.Vitality - classStats.InitVit + stats.Energy - classStats.InitEne
+
+	if spent > earned {
+		stats.Strength, stats.Dexterity = classStats.InitStr, classStats.InitDex
+		stats.Vitality, stats.Energy = classStats.InitVit, classStats.InitEne
+		spent = 0
+		s.corrected = true
+	}
+
+	s.clamp(&stats.StatsPoints, 0, earned-spent)
+
+	maxHealth, maxMana, maxStamina := maxVitals(stats, classStats)
+
+	s.set(&stats.MaxHealth, maxHealth)
+	s.set(&stats.MaxMana, maxMana)
+	s.set(&stats.MaxStamina, maxStamina)
+
+	s.clamp(&stats.Health, 0, stats.MaxHealth)
+	s.clamp(&stats.Mana, 0, stats.MaxMana)
+
+	stats.Stamina = float64(stats.MaxStamina)
+}
+
+// sanitizeHeroSkillPoints makes sure the points invested in class skills, and the points left to
+// spend, are not more than the points earned by leveling up and doing quests.
+func (f *HeroStateFactory) sanitizeHeroSkillPoints(s *heroStateSanitizer, state *HeroState) {
+	earned := (state.Stats.Level-1)*skillPointsPerLevel + questSkillPoints
+	spent := 0
+
+	for _, skill := range state.Skills {
+		if skill.Charclass != "" {
+			spent += skill.SkillPoints
+		}
+	}
+
+	if spent > earned {
+		for _, skill := range state.Skills {
+			if skill.Charclass != "" {
+				skill.SkillPoints = 0
+				skill.Shallow.SkillPoints = 0
+			}
+		}
+
+		spent = 0
+		s.corrected = true
+	}
+
+	s.clamp(&state.Stats.SkillPoints, 0, earned-spent)
+}
+
+// levelForExperience returns the level a hero of the given class has with the given experience
+func (f *HeroStateFactory) levelForExperience(hero d2enum.Hero, experience int) int {
+	maxLevel := f.asset.Records.GetMaxLevelByHero(hero)
+	level := 1
+
+	for level < maxLevel && experience >= f.experienceBreakpoint(hero, level) {
+		level++
+	}
+
+	return level
+}
+
+// maxExperience returns the experience needed to reach the maximum level of the given class
+func (f *HeroStateFactory) maxExperience(hero d2enum.Hero) int {
+	return f.experienceBreakpoint(hero, f.asset.Records.GetMaxLevelByHero(hero)-1)
+}
+
+// experienceBreakpoint returns the experience needed to advance from the given level, levels
+// without a breakpoint record can not be advanced from
+func (f *HeroStateFactory) experienceBreakpoint(hero d2enum.Hero, level int) int {
+	breakpoint, found := f.asset.Records.Character.Experience[level]
+	if !found || breakpoint == nil {
+		return math.MaxInt32
+	}
+
+	return breakpoint.HeroBreakpoints[hero]
+}
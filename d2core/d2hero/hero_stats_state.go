@@ -54,3 +54,16 @@ func (f *HeroStateFactory) CreateHeroStatsState(heroClass d2enum.Hero, classStat
 
 	return &result
 }
+
+// maxVitals returns the maximum life, mana and stamina of a hero, from its attributes and the
+// life, mana and stamina it gained by leveling up
+func maxVitals(stats *HeroStatsState, classStats *d2records.CharStatRecord) (life, mana, stamina int) {
+	levelsGained := stats.Level - 1
+
+	life = stats.Vitality*classStats.LifePerVit + levelsGained*classStats.LifePerLevel
+	mana = stats.Energy*classStats.ManaPerEne + levelsGained*classStats.ManaPerLevel
+	stamina = classStats.InitStamina + (stats.Vitality-classStats.InitVit)*classStats.StaminaPerVit +
+		levelsGained*classStats.StaminaPerLevel
+
+	return life, mana, stamina
+}
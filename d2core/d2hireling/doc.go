@@ -0,0 +1,4 @@
+// Package d2hireling implements the mercenaries of Hireling.txt: the offers of the town NPCs,
+// the stats and skills of a hireling at its level, its experience, the items it can wear and
+// the gold it costs to hire and resurrect.
+package d2hireling
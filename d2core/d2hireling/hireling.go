@@ -0,0 +1,350 @@
+package d2hireling
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+)
+
+const (
+	// MaxLevel is the highest level a hireling reaches
+	MaxLevel = 98
+
+	// the Dmg/Lvl column of Hireling.txt is in eighths, Resist/Lvl in quarters and the
+	// Lvl/Lvl columns of the skills in 32nds
+	damagePerLevelDivisor     = 8
+	resistPerLevelDivisor     = 4
+	skillLevelPerLevelDivisor = 32
+
+	// a town NPC offers this many hirelings, which are up to offerLevelSpread levels below
+	// the level of the player
+	offerCount       = 4
+	offerLevelSpread = 2
+
+	// resurrecting a hireling costs what hiring it costs, up to maxResurrectPrice gold
+	maxResurrectPrice = 50000
+
+	numSkills = 6
+)
+
+// Hireling is a mercenary hired by a player, as it is saved in the hero state of the player
+type Hireling struct {
+	ID         int                              `json:"id"`   // the Id of the row of Hireling.txt it was hired from
+	Name       string                           `json:"name"` // the key of its name in the string tables
+	Level      int                              `json:"level"`
+	Experience int                              `json:"experience"`
+	Life       int                              `json:"life"`
+	Dead       bool                             `json:"dead,omitempty"`
+	Equipment  map[d2enum.EquippedSlot][]string `json:"equipment,omitempty"` // the codes of the worn items
+}
+
+// Stats are the stats of a hireling at its level, without its equipment
+type Stats struct {
+	Life         int
+	Defense      int
+	Strength     int
+	Dexterity    int
+	AttackRating int
+	MinDamage    int
+	MaxDamage    int
+	Resistance   int // the resistance to all elements
+}
+
+// Skill is a skill of Hireling.txt a hireling uses at its level
+type Skill struct {
+	Name   string
+	Mode   int // the animation mode of the skill
+	Chance int // the chance of using the skill, against the DefaultChance of a normal attack
+	Level  int
+}
+
+// FindRecord returns the row of Hireling.txt with the given Id, or nil if there is none
+func FindRecord(records d2records.Hirelings, id int) *d2records.HirelingRecord {
+	for _, record := range records {
+		if record.ID == id {
+			return record
+		}
+	}
+
+	return nil
+}
+
+// Record returns the row of Hireling.txt the hireling is at. Hirelings of the same Class and
+// SubType have rows for higher levels, a hireling uses the row of the highest level it
+// reached.
+func (h *Hireling) Record(records d2records.Hirelings) *d2records.HirelingRecord {
+	hired := FindRecord(records, h.ID)
+	if hired == nil {
+		return nil
+	}
+
+	result := hired
+
+	for _, record := range records {
+		if record.Class != hired.Class || record.SubType != hired.SubType || record.Level > h.Level {
+			continue
+		}
+
+		if record.Level > result.Level || result.Level > h.Level {
+			result = record
+		}
+	}
+
+	return result
+}
+
+// Stats returns the stats of the hireling at its level
+func (h *Hireling) Stats(records d2records.Hirelings) Stats {
+	record := h.Record(records)
+	if record == nil {
+		return Stats{}
+	}
+
+	levels := d2math.MaxInt(h.Level-record.Level, 0)
+
+	return Stats{
+		Life:         record.HP + record.HPPerLvl*levels,
+		Defense:      record.Defense + record.DefPerLvl*levels,
+		Strength:     record.Str + record.StrPerLvl*levels,
+		Dexterity:    record.Dex + record.DexPerLvl*levels,
+		AttackRating: record.AR + record.ARPerLvl*levels,
+		MinDamage:    record.DmgMin + record.DmgPerLvl*levels/damagePerLevelDivisor,
+		MaxDamage:    record.DmgMax + record.DmgPerLvl*levels/damagePerLevelDivisor,
+		Resistance:   record.Resist + record.ResistPerLvl*levels/resistPerLevelDivisor,
+	}
+}
+
+// Skills returns the skills of the hireling at its level
+func (h *Hireling) Skills(records d2records.Hirelings) []Skill {
+	record := h.Record(records)
+	if record == nil {
+		return nil
+	}
+
+	levels := d2math.MaxInt(h.Level-record.Level, 0)
+
+	columns := [numSkills]struct {
+		name                                        string
+		mode, chance, chancePerLevel, level, perLvl int
+	}{
+		{record.Skill1, record.Mode1, record.Chance1, record.ChancePerLevel1, record.Level1, record.LvlPerLvl1},
+		{record.Skill2, record.Mode2, record.Chance2, record.ChancePerLevel2, record.Level2, record.LvlPerLvl2},
+		{record.Skill3, record.Mode3, record.Chance3, record.ChancePerLevel3, record.Level3, record.LvlPerLvl3},
+		{record.Skill4, record.Mode4, record.Chance4, record.ChancePerLevel4, record.Level4, record.LvlPerLvl4},
+		{record.Skill5, record.Mode5, record.Chance5, record.ChancePerLevel5, record.Level5, record.LvlPerLvl5},
+		{record.Skill6, record.Mode6, record.Chance6, record.ChancePerLevel6, record.Level6, record.LvlPerLvl6},
+	}
+
+	skills := make([]Skill, 0, numSkills)
+
+	for _, column := range columns {
+		if column.name == "" {
+			continue
+		}
+
+		skills = append(skills, Skill{
+			Name:   column.name,
+			Mode:   column.mode,
+			Chance: column.chance + column.chancePerLevel*levels,
+			Level:  d2math.MaxInt(column.level+column.perLvl*levels/skillLevelPerLevelDivisor, 1),
+		})
+	}
+
+	return skills
+}
+
+// ExperienceForLevel returns the experience a hireling of the row needs to reach a level
+func ExperienceForLevel(record *d2records.HirelingRecord, level int) int {
+	return record.ExpPerLvl * level * level * (level + 1)
+}
+
+// AddExperience adds experience to the hireling, which levels up once it has the experience of
+// the next level. The life of the hireling is restored when it levels up. The number of levels
+// gained is returned.
+func (h *Hireling) AddExperience(records d2records.Hirelings, experience int) int {
+	if h.Dead || experience <= 0 {
+		return 0
+	}
+
+	h.Experience += experience
+	gained := 0
+
+	for h.Level < MaxLevel {
+		record := h.Record(records)
+		if record == nil || h.Experience < ExperienceForLevel(record, h.Level+1) {
+			break
+		}
+
+		h.Level++
+		gained++
+	}
+
+	if gained > 0 {
+		h.Life = h.Stats(records).Life
+	}
+
+	return gained
+}
+
+// HirePrice returns the gold it costs to hire the hireling. The Gold of Hireling.txt is the
+// price at the level of the row, the price rises with the level of the hireling.
+func (h *Hireling) HirePrice(records d2records.Hirelings) int {
+	record := h.Record(records)
+	if record == nil {
+		return 0
+	}
+
+	return record.Gold * h.Level / d2math.MaxInt(record.Level, 1)
+}
+
+// ResurrectPrice returns the gold it costs to resurrect the hireling
+func (h *Hireling) ResurrectPrice(records d2records.Hirelings) int {
+	return d2math.MinInt(h.HirePrice(records), maxResurrectPrice)
+}
+
+// Resurrect brings the hireling back to life, with full life
+func (h *Hireling) Resurrect(records d2records.Hirelings) {
+	h.Dead = false
+	h.Life = h.Stats(records).Life
+}
+
+// Offers returns the hirelings a town NPC offers for hire on the difficulty. The seller is the
+// hcIdx of the NPC in MonStats.txt. The offered hirelings are of the rows of the highest
+// level the player reached, and their levels are close to the level of the player.
+func Offers(records d2records.Hirelings, seller int, difficulty d2enum.DifficultyType, playerLevel int,
+	rng *rand.Rand) []*Hireling {
+	rows := offerRows(records, seller, difficulty, playerLevel)
+	if len(rows) == 0 {
+		return nil
+	}
+
+	offers := make([]*Hireling, offerCount)
+
+	for idx := range offers {
+		record := rows[rng.Intn(len(rows))]
+		level := d2math.ClampInt(playerLevel-rng.Intn(offerLevelSpread+1), record.Level, MaxLevel)
+
+		hireling := &Hireling{
+			ID:         record.ID,
+			Name:       pickName(record.NameFirst, record.NameLast, rng),
+			Level:      level,
+			Experience: ExperienceForLevel(record, level),
+		}
+		hireling.Life = hireling.Stats(records).Life
+
+		offers[idx] = hireling
+	}
+
+	return offers
+}
+
+// offerRows returns the rows of Hireling.txt of the seller on the difficulty, one for every
+// kind of hireling: the row of the highest level up to the level of the player, or the row of
+// the lowest level if the player is below all of them
+func offerRows(records d2records.Hirelings, seller int, difficulty d2enum.DifficultyType,
+	playerLevel int) []*d2records.HirelingRecord {
+	kinds := make(map[string]*d2records.HirelingRecord)
+
+	for _, record := range records {
+		if record.Seller != seller || record.Difficulty != int(difficulty)+1 {
+			continue
+		}
+
+		kind := fmt.Sprintf("%d/%s", record.Class, record.SubType)
+
+		current, found := kinds[kind]
+
+		switch {
+		case !found:
+			kinds[kind] = record
+		case record.Level <= playerLevel && (current.Level > playerLevel || record.Level > current.Level):
+			kinds[kind] = record
+		case current.Level > playerLevel && record.Level < current.Level:
+			kinds[kind] = record
+		}
+	}
+
+	rows := make([]*d2records.HirelingRecord, 0, len(kinds))
+	for _, record := range kinds {
+		rows = append(rows, record)
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].ID < rows[j].ID
+	})
+
+	return rows
+}
+
+// pickName picks one of the names of a hireling. The names are the keys from NameFirst to
+// NameLast of the string tables, like merc01 to merc41.
+func pickName(first, last string, rng *rand.Rand) string {
+	prefix := strings.TrimRight(first, "0123456789")
+	digits := first[len(prefix):]
+
+	from, errFirst := strconv.Atoi(digits)
+	to, errLast := strconv.Atoi(strings.TrimPrefix(last, prefix))
+
+	if errFirst != nil || errLast != nil || to < from {
+		return first
+	}
+
+	return fmt.Sprintf("%s%0*d", prefix, len(digits), from+rng.Intn(to-from+1))
+}
+
+// CanEquip returns true if the hireling can wear an item in a slot. Hirelings wear helms,
+// armor, the weapons of the WType columns of Hireling.txt and shields, as far as their row
+// allows it and they meet the requirements of the item.
+func (h *Hireling) CanEquip(records *d2records.RecordManager, slot d2enum.EquippedSlot,
+	item *d2records.ItemCommonRecord) bool {
+	record := h.Record(records.Hireling.Details)
+	if record == nil || item == nil {
+		return false
+	}
+
+	stats := h.Stats(records.Hireling.Details)
+	if item.RequiredLevel > h.Level || item.RequiredStrength > stats.Strength ||
+		item.RequiredDexterity > stats.Dexterity {
+		return false
+	}
+
+	itemTypes := records.FindEquivalentTypesByItemCommonRecord(item)
+
+	switch slot {
+	case d2enum.EquippedSlotHead:
+		return record.Head != 0 && containsType(itemTypes, "helm")
+	case d2enum.EquippedSlotTorso:
+		return record.Torso != 0 && containsType(itemTypes, "tors")
+	case d2enum.EquippedSlotRightArm:
+		if record.Weapon == 0 {
+			return false
+		}
+
+		if record.WType1 == "" && record.WType2 == "" {
+			return containsType(itemTypes, "weap")
+		}
+
+		return record.WType1 != "" && containsType(itemTypes, record.WType1) ||
+			record.WType2 != "" && containsType(itemTypes, record.WType2)
+	case d2enum.EquippedSlotLeftArm:
+		return record.Shield != 0 && containsType(itemTypes, "shie")
+	}
+
+	return false
+}
+
+func containsType(itemTypes []string, code string) bool {
+	for _, itemType := range itemTypes {
+		if itemType == code {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,74 @@
+package d2hireling
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+)
+
+func testRecords() d2records.Hirelings {
+	return d2records.Hirelings{
+		{Hireling: "Rogue Scout", SubType: "Fire", ID: 1, Class: 271, Difficulty: 1, Level: 3, Seller: 150,
+			NameFirst: "merc01", NameLast: "merc03", Gold: 300, ExpPerLvl: 100, HP: 40, HPPerLvl: 10, Dex: 20,
+			DexPerLvl: 2, DmgMin: 1, DmgMax: 3, DmgPerLvl: 8, Resist: 0, ResistPerLvl: 4,
+			Skill1: "Fire Arrow", Chance1: 10, ChancePerLevel1: 1, Level1: 1, LvlPerLvl1: 32},
+		{Hireling: "Rogue Scout", SubType: "Fire", ID: 2, Class: 271, Difficulty: 1, Level: 10, Seller: 150,
+			NameFirst: "merc01", NameLast: "merc03", Gold: 600, ExpPerLvl: 110, HP: 120, HPPerLvl: 12,
+			Skill1: "Fire Arrow", Level1: 6},
+		{Hireling: "Rogue Scout", SubType: "Fire", ID: 3, Class: 271, Difficulty: 2, Level: 30, Seller: 150,
+			NameFirst: "merc01", NameLast: "merc03", Gold: 2000, ExpPerLvl: 120, HP: 400},
+	}
+}
+
+func TestOffers(t *testing.T) {
+	records := testRecords()
+	rng := rand.New(rand.NewSource(1)) //nolint:gosec // deterministic test
+
+	offers := Offers(records, 150, d2enum.DifficultyNormal, 5, rng)
+	if len(offers) != offerCount {
+		t.Fatalf("expected %d offers, got %d", offerCount, len(offers))
+	}
+
+	for _, offer := range offers {
+		if offer.ID != 1 || offer.Level < 3 || offer.Level > 5 {
+			t.Errorf("expected a hireling of the first row close to level 5, got %+v", offer)
+		}
+
+		if offer.Name < "merc01" || offer.Name > "merc03" || len(offer.Name) != len("merc01") {
+			t.Errorf("unexpected name %q", offer.Name)
+		}
+	}
+
+	if offers := Offers(records, 198, d2enum.DifficultyNormal, 5, rng); offers != nil {
+		t.Errorf("expected no offers of another seller, got %v", offers)
+	}
+}
+
+func TestHireling_AddExperience(t *testing.T) {
+	records := testRecords()
+	hireling := &Hireling{ID: 1, Level: 5, Experience: ExperienceForLevel(records[0], 5)}
+
+	stats := hireling.Stats(records)
+	if stats.Life != 60 || stats.Dexterity != 24 || stats.MinDamage != 3 || stats.Resistance != 2 {
+		t.Errorf("unexpected stats at level 5: %+v", stats)
+	}
+
+	if skills := hireling.Skills(records); len(skills) != 1 || skills[0].Level != 3 || skills[0].Chance != 12 {
+		t.Errorf("unexpected skills at level 5: %+v", skills)
+	}
+
+	// from level 10 on, the hireling uses the second row
+	if gained := hireling.AddExperience(records, ExperienceForLevel(records[0], 10)); gained != 5 {
+		t.Fatalf("expected 5 levels, got %d", gained)
+	}
+
+	if record := hireling.Record(records); record.ID != 2 || hireling.Life != 120 {
+		t.Errorf("expected the row of level 10 and full life, got row %d and %d life", record.ID, hireling.Life)
+	}
+
+	if price := hireling.HirePrice(records); price != 600 {
+		t.Errorf("expected a price of 600, got %d", price)
+	}
+}
@@ -1,14 +1,154 @@
 package d2inventory
 
-// CharacterEquipment stores equipments of a character
-type CharacterEquipment struct {
-	Head      *InventoryItemArmor  `json:"head"`      // Head
-	Torso     *InventoryItemArmor  `json:"torso"`     // TR
-	Legs      *InventoryItemArmor  `json:"legs"`      // Legs
-	RightArm  *InventoryItemArmor  `json:"rightArm"`  // RA
-	LeftArm   *InventoryItemArmor  `json:"leftArm"`   // LA
-	LeftHand  *InventoryItemWeapon `json:"leftHand"`  // LH
-	RightHand *InventoryItemWeapon `json:"rightHand"` // RH
-	Shield    *InventoryItemArmor  `json:"shield"`    // SH
-	// S1-S8?
+import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2item"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2item/diablo2item"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2stats"
+)
+
+const weaponClassHandToHand = "hth"
+
+// CharacterEquipment stores the items a character wears, by equipped slot. It adapts the items to
+// the layers and the weapon class of the composite of the character.
+type CharacterEquipment map[d2enum.EquippedSlot]*diablo2item.Item
+
+// NewCharacterEquipment returns the equipment of the equipped items among the given items
+func NewCharacterEquipment(items ...*diablo2item.Item) CharacterEquipment {
+	equipment := make(CharacterEquipment)
+
+	for _, item := range items {
+		if item != nil && item.Location == d2enum.ItemLocationEquipped {
+			equipment[item.SlotType()] = item
+		}
+	}
+
+	return equipment
+}
+
+// Items returns the worn items, without the items of the weapon swap
+func (e CharacterEquipment) Items() []d2item.Item {
+	items := make([]d2item.Item, 0, len(e))
+
+	for _, slot := range activeSlots() {
+		if item := e[slot]; item != nil {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+// StatList returns the given stats of a character with the stats of the worn items
+func (e CharacterEquipment) StatList(base d2stats.StatList) d2stats.StatList {
+	list := base.Clone()
+
+	for _, slot := range activeSlots() {
+		if item := e[slot]; item != nil && item.StatList() != nil {
+			list.AppendStatList(item.StatList())
+		}
+	}
+
+	return list.ReduceStats()
+}
+
+// CompositeLayers returns the layers of the composite of a character wearing the equipment
+func (e CharacterEquipment) CompositeLayers() *[d2enum.CompositeTypeMax]string {
+	layers := &[d2enum.CompositeTypeMax]string{
+		d2enum.CompositeTypeHead:     d2enum.ArmorClassLite,
+		d2enum.CompositeTypeTorso:    d2enum.ArmorClassLite,
+		d2enum.CompositeTypeLegs:     d2enum.ArmorClassLite,
+		d2enum.CompositeTypeRightArm: d2enum.ArmorClassLite,
+		d2enum.CompositeTypeLeftArm:  d2enum.ArmorClassLite,
+	}
+
+	if helm := e[d2enum.EquippedSlotHead]; helm != nil {
+		layers[d2enum.CompositeTypeHead] = graphicsCode(helm)
+	}
+
+	if armor := e[d2enum.EquippedSlotTorso]; armor != nil {
+		record := armor.CommonRecord()
+
+		layers[d2enum.CompositeTypeTorso] = armorClass(record.AnimTorso)
+		layers[d2enum.CompositeTypeLegs] = armorClass(record.AnimLegs)
+		layers[d2enum.CompositeTypeRightArm] = armorClass(record.AnimRightArm)
+		layers[d2enum.CompositeTypeLeftArm] = armorClass(record.AnimLeftArm)
+	}
+
+	if weapon := e.weapon(d2enum.EquippedSlotRightArm); weapon != nil {
+		layers[d2enum.CompositeTypeRightHand] = graphicsCode(weapon)
+	}
+
+	// bows and the second weapon of a character wielding two are in the left hand
+	if weapon := e.weapon(d2enum.EquippedSlotLeftArm); weapon != nil {
+		layers[d2enum.CompositeTypeLeftHand] = graphicsCode(weapon)
+	} else if shield := e[d2enum.EquippedSlotLeftArm]; shield != nil {
+		layers[d2enum.CompositeTypeShield] = graphicsCode(shield)
+	}
+
+	return layers
+}
+
+// Weapon returns the weapon a character wearing the equipment attacks with, the weapon in the
+// right arm, or else a bow in the left arm
+func (e CharacterEquipment) Weapon() *diablo2item.Item {
+	if weapon := e.weapon(d2enum.EquippedSlotRightArm); weapon != nil {
+		return weapon
+	}
+
+	return e.weapon(d2enum.EquippedSlotLeftArm)
+}
+
+// WeaponClass returns the weapon class of the animations of a character wearing the equipment
+func (e CharacterEquipment) WeaponClass() string {
+	if weapon := e.Weapon(); weapon != nil && weapon.CommonRecord().WeaponClass != "" {
+		return weapon.CommonRecord().WeaponClass
+	}
+
+	return weaponClassHandToHand
+}
+
+func (e CharacterEquipment) weapon(slot d2enum.EquippedSlot) *diablo2item.Item {
+	if item := e[slot]; item != nil && item.GetInventoryItemType() == d2enum.InventoryItemTypeWeapon {
+		return item
+	}
+
+	return nil
+}
+
+// activeSlots returns the equipped slots a character wears items in, the weapon swap is not worn
+func activeSlots() []d2enum.EquippedSlot {
+	return []d2enum.EquippedSlot{
+		d2enum.EquippedSlotHead,
+		d2enum.EquippedSlotTorso,
+		d2enum.EquippedSlotLegs,
+		d2enum.EquippedSlotRightArm,
+		d2enum.EquippedSlotLeftArm,
+		d2enum.EquippedSlotLeftHand,
+		d2enum.EquippedSlotRightHand,
+		d2enum.EquippedSlotNeck,
+		d2enum.EquippedSlotBelt,
+		d2enum.EquippedSlotGloves,
+	}
+}
+
+// graphicsCode returns the code of the graphics of a worn item
+func graphicsCode(item *diablo2item.Item) string {
+	record := item.CommonRecord()
+	if record.AlternateGfx != "" {
+		return record.AlternateGfx
+	}
+
+	return record.Code
+}
+
+// armorClass returns the armor class of the layers of the composite of the ArmType.txt index of
+// body armor
+func armorClass(index int) string {
+	classes := []string{d2enum.ArmorClassLite, d2enum.ArmorClassMedium, d2enum.ArmorClassHeavy}
+	if index < 0 || index >= len(classes) {
+		return d2enum.ArmorClassLite
+	}
+
+	return classes[index]
 }
@@ -0,0 +1,150 @@
+package d2inventory
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2util"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2asset"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2item/diablo2item"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2stats/diablo2stats"
+)
+
+// testEquipmentFactories creates item and stat factories on empty records, which are returned for
+// the test to fill in
+func testEquipmentFactories(t *testing.T) (*d2records.RecordManager, *diablo2item.ItemFactory,
+	*diablo2stats.StatFactory) {
+	asset, err := d2asset.NewAssetManager(d2util.LogLevelNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	items, err := diablo2item.NewItemFactory(asset)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := diablo2stats.NewStatFactory(asset)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return asset.Records, items, stats
+}
+
+func testEquippedItem(t *testing.T, factory *diablo2item.ItemFactory, slot d2enum.EquippedSlot,
+	codes ...string) *diablo2item.Item {
+	item, err := factory.NewItem(codes...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	item.Location = d2enum.ItemLocationEquipped
+	item.SetSlotType(slot)
+
+	return item
+}
+
+func TestCharacterEquipment(t *testing.T) {
+	records, factory, stats := testEquipmentFactories(t)
+
+	axe := &d2records.ItemCommonRecord{Code: "hax", Type: "axe", WeaponClass: "1hs", Durability: 28}
+	bow := &d2records.ItemCommonRecord{Code: "sbw", Type: "bow", WeaponClass: "bow", Durability: 20}
+	helm := &d2records.ItemCommonRecord{Code: "cap", Type: "helm", AlternateGfx: "cap", Durability: 12}
+	armor := &d2records.ItemCommonRecord{Code: "plt", Type: "tors", AnimTorso: 2, AnimLegs: 1, AnimRightArm: 2,
+		AnimLeftArm: 2, Durability: 60}
+	shield := &d2records.ItemCommonRecord{Code: "buc", Type: "shie", Durability: 12}
+
+	records.Item.All = d2records.CommonItems{}
+	for _, record := range []*d2records.ItemCommonRecord{axe, bow, helm, armor, shield} {
+		records.Item.All[record.Code] = record
+	}
+
+	records.Item.Types = map[string]*d2records.ItemTypeRecord{
+		"axe": {Code: "axe"}, "bow": {Code: "bow"}, "helm": {Code: "helm"}, "tors": {Code: "tors"},
+		"shie": {Code: "shie"},
+	}
+	records.Item.Equivalency = d2records.ItemEquivalenceMap{
+		"weap": {axe, bow},
+		"armo": {helm, armor, shield},
+	}
+	records.Item.Magic.Suffix = d2records.MagicSuffix{
+		"of Strength": {Index: 7, Name: "of Strength", Modifiers: []*d2records.ItemAffixCommonModifier{{Code: "str",
+			Min: 5, Max: 5}}},
+	}
+	records.Item.Stats = map[string]*d2records.ItemStatCostRecord{
+		"strength": {Name: "strength", Index: 0, DescFnID: 1},
+		"level":    {Name: "level", Index: 12},
+	}
+	records.Properties = map[string]*d2records.PropertyRecord{
+		"str": {Code: "str", Stats: [7]*d2records.PropertyStatRecord{{FunctionID: 1, StatCode: "strength"}}},
+	}
+
+	bare := NewCharacterEquipment()
+	if layers := bare.CompositeLayers(); layers[d2enum.CompositeTypeTorso] != d2enum.ArmorClassLite ||
+		layers[d2enum.CompositeTypeRightHand] != "" || bare.WeaponClass() != weaponClassHandToHand {
+		t.Errorf("expected a character without equipment to be bare handed, got %v with %s", *layers,
+			bare.WeaponClass())
+	}
+
+	stored, err := factory.NewItem("hax")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	equipment := NewCharacterEquipment(
+		testEquippedItem(t, factory, d2enum.EquippedSlotHead, "cap", "of Strength"),
+		testEquippedItem(t, factory, d2enum.EquippedSlotTorso, "plt"),
+		testEquippedItem(t, factory, d2enum.EquippedSlotRightArm, "hax"),
+		testEquippedItem(t, factory, d2enum.EquippedSlotLeftArm, "buc"),
+		testEquippedItem(t, factory, d2enum.EquippedSlotRightArmSwap, "hax", "of Strength"),
+		stored,
+	)
+
+	expected := [d2enum.CompositeTypeMax]string{
+		d2enum.CompositeTypeHead:      "cap",
+		d2enum.CompositeTypeTorso:     d2enum.ArmorClassHeavy,
+		d2enum.CompositeTypeLegs:      d2enum.ArmorClassMedium,
+		d2enum.CompositeTypeRightArm:  d2enum.ArmorClassHeavy,
+		d2enum.CompositeTypeLeftArm:   d2enum.ArmorClassHeavy,
+		d2enum.CompositeTypeRightHand: "hax",
+		d2enum.CompositeTypeShield:    "buc",
+	}
+
+	if layers := equipment.CompositeLayers(); !reflect.DeepEqual(*layers, expected) || equipment.WeaponClass() != "1hs" ||
+		len(equipment.Items()) != 4 {
+		t.Errorf("expected the worn items to be on the composite, got %v with %s", *layers, equipment.WeaponClass())
+	}
+
+	list := equipment.StatList(stats.NewStatList(stats.NewStat("strength", 10))).Stats()
+	if len(list) != 1 || list[0].Values()[0].Int() != 15 {
+		t.Errorf("expected the strength of the helm to be added, got %v", list)
+	}
+
+	equipment[d2enum.EquippedSlotRightArm] = nil
+	equipment[d2enum.EquippedSlotLeftArm] = testEquippedItem(t, factory, d2enum.EquippedSlotLeftArm, "sbw")
+
+	if layers := equipment.CompositeLayers(); layers[d2enum.CompositeTypeLeftHand] != "sbw" ||
+		layers[d2enum.CompositeTypeShield] != "" || equipment.WeaponClass() != "bow" {
+		t.Errorf("expected the bow in the left hand, got %v with %s", *layers, equipment.WeaponClass())
+	}
+}
+
+func TestSavedEquipment_Slots(t *testing.T) {
+	staff := &SavedEquipmentItem{ItemCode: "sst"}
+	saved := &SavedEquipment{Head: &SavedEquipmentItem{ItemCode: "cap"}, RightHand: staff, LeftHand: staff}
+
+	expected := map[d2enum.EquippedSlot]string{d2enum.EquippedSlotHead: "cap", d2enum.EquippedSlotRightArm: "sst"}
+	if slots := saved.Slots(); !reflect.DeepEqual(slots, expected) {
+		t.Errorf("expected the two handed staff to be one item, got %v", slots)
+	}
+
+	saved = &SavedEquipment{RightHand: &SavedEquipmentItem{ItemCode: "hax"}, Shield: &SavedEquipmentItem{ItemCode: "buc"}}
+
+	expected = map[d2enum.EquippedSlot]string{d2enum.EquippedSlotRightArm: "hax", d2enum.EquippedSlotLeftArm: "buc"}
+	if slots := saved.Slots(); !reflect.DeepEqual(slots, expected) {
+		t.Errorf("expected the shield in the left arm, got %v", slots)
+	}
+}
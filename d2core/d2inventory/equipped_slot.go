@@ -0,0 +1,50 @@
+package d2inventory
+
+import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2item/diablo2item"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+)
+
+// slotItemTypes returns the types of ItemTypes.txt of the items worn in the equipped slots. The
+// equivalent types of an item include the types it is a kind of, like weap for an axe.
+func slotItemTypes() map[d2enum.EquippedSlot][]string {
+	arms := []string{"weap", "shie", "misl"}
+
+	return map[d2enum.EquippedSlot][]string{
+		d2enum.EquippedSlotHead:         {"helm"},
+		d2enum.EquippedSlotNeck:         {"amul"},
+		d2enum.EquippedSlotTorso:        {"tors"},
+		d2enum.EquippedSlotRightArm:     arms,
+		d2enum.EquippedSlotLeftArm:      arms,
+		d2enum.EquippedSlotRightArmSwap: arms,
+		d2enum.EquippedSlotLeftArmSwap:  arms,
+		d2enum.EquippedSlotRightHand:    {"ring"},
+		d2enum.EquippedSlotLeftHand:     {"ring"},
+		d2enum.EquippedSlotBelt:         {"belt"},
+		d2enum.EquippedSlotLegs:         {"boot"},
+		d2enum.EquippedSlotGloves:       {"glov"},
+	}
+}
+
+// FitsSlot returns true if a character of the class can wear the item in the equipped slot. The
+// item has to be of a type worn in the slot, and the items of a class are only worn by it.
+func FitsSlot(records *d2records.RecordManager, item *diablo2item.Item, slot d2enum.EquippedSlot,
+	class d2enum.Hero) bool {
+	if typeRecord := item.TypeRecord(); typeRecord != nil && typeRecord.Class != d2enum.HeroNone &&
+		typeRecord.Class != class {
+		return false
+	}
+
+	itemTypes := records.FindEquivalentTypesByItemCommonRecord(item.CommonRecord())
+
+	for _, slotType := range slotItemTypes()[slot] {
+		for _, itemType := range itemTypes {
+			if itemType == slotType {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -4,5 +4,37 @@ import (
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
 )
 
-// HeroObjects map contains the hero type to CharacterEquipments
-type HeroObjects map[d2enum.Hero]CharacterEquipment
+// HeroObjects map contains the hero type to the codes of the items it starts with, by equipped slot
+type HeroObjects map[d2enum.Hero]map[d2enum.EquippedSlot]string
+
+// DefaultHeroObjects returns the items the heroes of each class start with
+func DefaultHeroObjects() HeroObjects {
+	return HeroObjects{
+		d2enum.HeroBarbarian: {
+			d2enum.EquippedSlotRightArm: "hax",
+			d2enum.EquippedSlotLeftArm:  "buc",
+		},
+		d2enum.HeroNecromancer: {
+			d2enum.EquippedSlotRightArm: "wnd",
+		},
+		d2enum.HeroPaladin: {
+			d2enum.EquippedSlotRightArm: "ssd",
+			d2enum.EquippedSlotLeftArm:  "buc",
+		},
+		d2enum.HeroAssassin: {
+			d2enum.EquippedSlotRightArm: "ktr",
+			d2enum.EquippedSlotLeftArm:  "buc",
+		},
+		d2enum.HeroSorceress: {
+			d2enum.EquippedSlotRightArm: "sst",
+		},
+		d2enum.HeroAmazon: {
+			d2enum.EquippedSlotRightArm: "jav",
+			d2enum.EquippedSlotLeftArm:  "buc",
+		},
+		d2enum.HeroDruid: {
+			d2enum.EquippedSlotRightArm: "clb",
+			d2enum.EquippedSlotLeftArm:  "buc",
+		},
+	}
+}
@@ -0,0 +1,45 @@
+package d2inventory
+
+import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+)
+
+// SavedEquipment is the equipment of the saves made before the items of a hero were saved. It
+// only has the codes of the worn armor and weapons, the legs and arms were layers of the
+// composite.
+type SavedEquipment struct {
+	Head      *SavedEquipmentItem `json:"head"`
+	Torso     *SavedEquipmentItem `json:"torso"`
+	LeftHand  *SavedEquipmentItem `json:"leftHand"`
+	RightHand *SavedEquipmentItem `json:"rightHand"`
+	Shield    *SavedEquipmentItem `json:"shield"`
+}
+
+// SavedEquipmentItem is an item of the SavedEquipment
+type SavedEquipmentItem struct {
+	ItemCode string `json:"itemCode"`
+}
+
+// Slots returns the codes of the saved items by the equipped slot they are worn in now
+func (e *SavedEquipment) Slots() map[d2enum.EquippedSlot]string {
+	slots := make(map[d2enum.EquippedSlot]string)
+
+	for slot, item := range map[d2enum.EquippedSlot]*SavedEquipmentItem{
+		d2enum.EquippedSlotHead:     e.Head,
+		d2enum.EquippedSlotTorso:    e.Torso,
+		d2enum.EquippedSlotRightArm: e.RightHand,
+		d2enum.EquippedSlotLeftArm:  e.Shield,
+	} {
+		if item != nil && item.ItemCode != "" {
+			slots[slot] = item.ItemCode
+		}
+	}
+
+	// a two handed weapon was in both hands of the equipment, but is only one item
+	if _, found := slots[d2enum.EquippedSlotLeftArm]; !found && e.LeftHand != nil &&
+		e.LeftHand.ItemCode != "" && (e.RightHand == nil || e.LeftHand.ItemCode != e.RightHand.ItemCode) {
+		slots[d2enum.EquippedSlotLeftArm] = e.LeftHand.ItemCode
+	}
+
+	return slots
+}
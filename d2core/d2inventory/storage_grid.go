@@ -0,0 +1,73 @@
+package d2inventory
+
+// the sizes of the storage panels of a character, in grid cells
+const (
+	InventoryWidth  = 10
+	InventoryHeight = 4
+	StashWidth      = 6
+	StashHeight     = 8
+	CubeWidth       = 3
+	CubeHeight      = 4
+	BeltWidth       = 4 // the boxes of a row of the belt
+	BeltHeight      = 4 // the rows of the largest belt, a character without a belt has one row
+)
+
+// StorageGrid keeps the cells of a storage panel which are taken by items, like the inventory,
+// the stash or the cube of a character
+type StorageGrid struct {
+	width, height int
+	taken         []bool
+}
+
+// NewStorageGrid returns an empty grid of the given size in cells
+func NewStorageGrid(width, height int) *StorageGrid {
+	return &StorageGrid{width: width, height: height, taken: make([]bool, width*height)}
+}
+
+// Fits returns true if an item of the given size can be put at the cell. The item has to lie
+// within the grid, and may not overlap the items put into the grid before.
+func (g *StorageGrid) Fits(x, y, width, height int) bool {
+	if x < 0 || y < 0 || width < 1 || height < 1 || x+width > g.width || y+height > g.height {
+		return false
+	}
+
+	for cellY := y; cellY < y+height; cellY++ {
+		for cellX := x; cellX < x+width; cellX++ {
+			if g.taken[cellY*g.width+cellX] {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// Put takes the cells of an item of the given size at the cell, it returns false and takes
+// nothing if the item does not fit
+func (g *StorageGrid) Put(x, y, width, height int) bool {
+	if !g.Fits(x, y, width, height) {
+		return false
+	}
+
+	for cellY := y; cellY < y+height; cellY++ {
+		for cellX := x; cellX < x+width; cellX++ {
+			g.taken[cellY*g.width+cellX] = true
+		}
+	}
+
+	return true
+}
+
+// FreeCell returns the first cell an item of the given size fits at, walking the rows from the
+// top left to the bottom right
+func (g *StorageGrid) FreeCell(width, height int) (x, y int, found bool) {
+	for y = 0; y < g.height; y++ {
+		for x = 0; x < g.width; x++ {
+			if g.Fits(x, y, width, height) {
+				return x, y, true
+			}
+		}
+	}
+
+	return 0, 0, false
+}
@@ -0,0 +1,46 @@
+package d2inventory
+
+import "testing"
+
+func TestStorageGrid(t *testing.T) {
+	grid := NewStorageGrid(InventoryWidth, InventoryHeight)
+
+	// a two by three armor in the top left corner
+	if !grid.Put(0, 0, 2, 3) {
+		t.Fatal("expected the armor to fit into the empty grid")
+	}
+
+	tests := []struct {
+		x, y, width, height int
+		fits                bool
+	}{
+		{2, 0, 1, 1, true},   // next to the armor
+		{1, 2, 1, 1, false},  // on the armor
+		{0, 3, 2, 1, true},   // below the armor
+		{9, 3, 1, 1, true},   // the bottom right cell
+		{9, 0, 2, 1, false},  // past the right edge
+		{8, 2, 1, 3, false},  // past the bottom edge
+		{-1, 0, 1, 1, false}, // before the left edge
+	}
+
+	for _, test := range tests {
+		if fits := grid.Fits(test.x, test.y, test.width, test.height); fits != test.fits {
+			t.Errorf("item of %dx%d at %d,%d: expected fits %t, got %t", test.width, test.height, test.x, test.y,
+				test.fits, fits)
+		}
+	}
+
+	if x, y, found := grid.FreeCell(2, 4); !found || x != 2 || y != 0 {
+		t.Errorf("expected a free place for a two by four item at 2,0, got %d,%d (%t)", x, y, found)
+	}
+
+	grid.Put(0, 3, 2, 1)
+
+	for x := 2; x < InventoryWidth; x += 2 {
+		grid.Put(x, 0, 2, 4)
+	}
+
+	if _, _, found := grid.FreeCell(1, 1); found {
+		t.Error("expected no free place in the full grid")
+	}
+}
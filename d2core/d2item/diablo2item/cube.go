@@ -0,0 +1,520 @@
+package diablo2item
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+)
+
+// the special item codes of the inputs and outputs of CubeMain.txt
+const (
+	cubeAnyItem = "any"
+	cubeUseItem = "useitem"
+	cubeUseType = "usetype"
+)
+
+// the qualifiers of the inputs and outputs of CubeMain.txt, besides the qualities
+const (
+	cubeNoSockets   = "nos"
+	cubeSockets     = "sock="
+	cubeEthereal    = "eth"
+	cubeNotEthereal = "noe"
+	cubeBasic       = "bas"
+	cubeExceptional = "exc"
+	cubeElite       = "eli"
+	cubeUpgrade     = "upg"
+	cubeNoRuneword  = "nru"
+	cubeKeepMods    = "mod"
+	cubeUnsocket    = "uns"
+	cubeRemoveGems  = "rem"
+	cubeRegenerate  = "reg"
+	cubeRepair      = "rep"
+)
+
+const (
+	// cubeVersionExpansion is the Version of CubeMain.txt of the recipes of the expansion
+	cubeVersionExpansion = 100
+
+	// cubeLevelScale is the divisor of the plvl and ilvl columns, which are percentages
+	cubeLevelScale = 100
+
+	// cubeModChance is the total of the chance of a mod of an output
+	cubeModChance = 100
+)
+
+// ErrNoCubeRecipe is returned when the items in the Horadric Cube do not match any recipe
+var ErrNoCubeRecipe = errors.New("the items do not match any cube recipe")
+
+// cubeQualities returns the qualifiers of CubeMain.txt for the item qualities
+func cubeQualities() map[string]d2enum.ItemQuality {
+	return map[string]d2enum.ItemQuality{
+		"low": d2enum.LowQuality,
+		"nor": d2enum.Normal,
+		"hiq": d2enum.Superior,
+		"mag": d2enum.Magic,
+		"set": d2enum.Set,
+		"rar": d2enum.Rare,
+		"uni": d2enum.Unique,
+		"crf": d2enum.Crafted,
+		"tmp": d2enum.Tempered,
+	}
+}
+
+// CubeContext is the state of the player transmuting items, which recipes depend on
+type CubeContext struct {
+	Hero        d2enum.Hero
+	Difficulty  d2enum.DifficultyType
+	PlayerLevel int
+	Expansion   bool
+	Ladder      bool
+}
+
+// CubeMatch is a recipe matched by the items in the Horadric Cube. Inputs holds the items
+// matched by each input of the recipe.
+type CubeMatch struct {
+	Recipe *d2records.CubeRecipeRecord
+	Inputs [][]*Item
+}
+
+// Transmutation is the result of transmuting the items in the Horadric Cube. The consumed items
+// are removed from the cube and the outputs are put in their place.
+type Transmutation struct {
+	Recipe   *d2records.CubeRecipeRecord
+	Consumed []*Item
+	Outputs  []*Item
+}
+
+// MatchCubeRecipe returns the first recipe of CubeMain.txt which is matched by exactly the given
+// items, or nil if there is none. Every item counts as one input, stacks are not counted.
+func MatchCubeRecipe(recipes d2records.CubeRecipes, items []*Item, ctx CubeContext) *CubeMatch {
+	for _, recipe := range recipes {
+		if !ctx.allows(recipe) {
+			continue
+		}
+
+		if recipe.NumInputs > 0 && recipe.NumInputs != len(items) {
+			continue
+		}
+
+		remaining := make([]int, len(recipe.Inputs))
+		for idx := range recipe.Inputs {
+			if recipe.Inputs[idx].Code != "" {
+				remaining[idx] = recipe.Inputs[idx].Count
+			}
+		}
+
+		inputs := make([][]*Item, len(recipe.Inputs))
+
+		if assignCubeInputs(recipe.Inputs, items, remaining, inputs) {
+			return &CubeMatch{Recipe: recipe, Inputs: inputs}
+		}
+	}
+
+	return nil
+}
+
+// First returns the item matched by the first input of the recipe, which the useitem, usetype
+// and ilvl columns refer to
+func (m *CubeMatch) First() *Item {
+	for _, items := range m.Inputs {
+		if len(items) > 0 {
+			return items[0]
+		}
+	}
+
+	return nil
+}
+
+// allows returns true if the recipe is enabled for the player
+func (ctx *CubeContext) allows(recipe *d2records.CubeRecipeRecord) bool {
+	switch {
+	case !recipe.Enabled, recipe.MinDiff > int(ctx.Difficulty), recipe.Ladder && !ctx.Ladder,
+		recipe.Version >= cubeVersionExpansion && !ctx.Expansion:
+		return false
+	}
+
+	classSpecific := false
+
+	for _, class := range recipe.Class {
+		if class == d2enum.HeroNone {
+			continue
+		}
+
+		if class == ctx.Hero {
+			return true
+		}
+
+		classSpecific = true
+	}
+
+	return !classSpecific
+}
+
+// assignCubeInputs assigns each item to an input of a recipe it matches, until the counts of all
+// inputs are met. The assignment backtracks, as an item may match several inputs.
+func assignCubeInputs(inputs []d2records.CubeRecipeItem, items []*Item, remaining []int, assigned [][]*Item) bool {
+	if len(items) == 0 {
+		for _, count := range remaining {
+			if count > 0 {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	item := items[0]
+
+	for idx := range inputs {
+		if remaining[idx] == 0 || !item.matchesCubeInput(&inputs[idx]) {
+			continue
+		}
+
+		remaining[idx]--
+		assigned[idx] = append(assigned[idx], item)
+
+		if assignCubeInputs(inputs, items[1:], remaining, assigned) {
+			return true
+		}
+
+		remaining[idx]++
+		assigned[idx] = assigned[idx][:len(assigned[idx])-1]
+	}
+
+	return false
+}
+
+// matchesCubeInput returns true if the item has the code or the type of an input of a recipe,
+// and all of its qualifiers
+func (i *Item) matchesCubeInput(input *d2records.CubeRecipeItem) bool {
+	if !i.matchesCubeCode(input.Code) {
+		return false
+	}
+
+	for _, param := range input.Params {
+		if !i.matchesCubeQualifier(param) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (i *Item) matchesCubeCode(code string) bool {
+	if code == cubeAnyItem || code == i.CommonCode {
+		return true
+	}
+
+	for _, itemType := range i.factory.asset.Records.FindEquivalentTypesByItemCommonRecord(i.CommonRecord()) {
+		if itemType == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchesCubeQualifier returns true if the item has the qualifier of an input. The upg qualifier
+// matches the items which have a base of a higher tier. Unknown qualifiers match all items.
+func (i *Item) matchesCubeQualifier(param string) bool {
+	if quality, found := cubeQualities()[param]; found {
+		return i.Quality() == quality
+	}
+
+	record := i.CommonRecord()
+
+	switch param {
+	case cubeNoSockets:
+		return i.attributes.numSockets == 0
+	case cubeEthereal:
+		return i.attributes.ethereal
+	case cubeNotEthereal:
+		return !i.attributes.ethereal
+	case cubeBasic:
+		return i.CommonCode == record.NormalCode
+	case cubeExceptional:
+		return i.CommonCode == record.UberCode
+	case cubeElite:
+		return i.CommonCode == record.UltraCode
+	case cubeUpgrade:
+		return nextTierCode(record) != ""
+	case cubeNoRuneword:
+		return i.RunewordCode == ""
+	}
+
+	if sockets, found := cubeQualifierValue(param, cubeSockets); found {
+		return i.attributes.numSockets == sockets
+	}
+
+	return true
+}
+
+// cubeQualifierValue returns the number of a qualifier like sock=3
+func cubeQualifierValue(param, prefix string) (int, bool) {
+	if !strings.HasPrefix(param, prefix) {
+		return 0, false
+	}
+
+	value, err := strconv.Atoi(strings.TrimPrefix(param, prefix))
+	if err != nil {
+		return 0, false
+	}
+
+	return value, true
+}
+
+// nextTierCode returns the code of the exceptional version of a normal item and the elite
+// version of an exceptional item
+func nextTierCode(record *d2records.ItemCommonRecord) string {
+	var code string
+
+	switch record.Code {
+	case record.NormalCode:
+		code = record.UberCode
+	case record.UberCode:
+		code = record.UltraCode
+	}
+
+	if code == record.Code {
+		return ""
+	}
+
+	return code
+}
+
+// Transmute transmutes the items in the Horadric Cube with the first recipe they match. All the
+// matched items are consumed, except the first input of a recipe with a useitem output, which
+// is modified in place. ErrNoCubeRecipe is returned if no recipe matches.
+func (f *ItemFactory) Transmute(recipes d2records.CubeRecipes, items []*Item,
+	ctx CubeContext) (*Transmutation, error) {
+	match := MatchCubeRecipe(recipes, items, ctx)
+	if match == nil {
+		return nil, ErrNoCubeRecipe
+	}
+
+	first := match.First()
+	if first == nil {
+		return nil, ErrNoCubeRecipe
+	}
+
+	inputLevel := first.ItemLevel()
+
+	result := &Transmutation{
+		Recipe:   match.Recipe,
+		Consumed: make([]*Item, 0),
+		Outputs:  make([]*Item, 0),
+	}
+
+	for idx := range match.Recipe.Outputs {
+		output := &match.Recipe.Outputs[idx]
+		if output.Item.Code == "" {
+			continue
+		}
+
+		outputs, err := f.cubeOutput(output, first, inputLevel, ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		result.Outputs = append(result.Outputs, outputs...)
+	}
+
+	for _, input := range match.Inputs {
+		for _, item := range input {
+			if !containsItem(result.Outputs, item) {
+				result.Consumed = append(result.Consumed, item)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// cubeOutput creates the items of an output of a recipe. The item of a useitem output is the
+// first input, the other outputs create new items.
+func (f *ItemFactory) cubeOutput(output *d2records.CubeRecipeResult, first *Item, inputLevel int,
+	ctx CubeContext) ([]*Item, error) {
+	result := make([]*Item, 0)
+
+	if output.Item.Code == cubeUseItem {
+		return f.applyCubeOutput(output, first, first, inputLevel, ctx), nil
+	}
+
+	for count := 0; count < output.Item.Count; count++ {
+		var item *Item
+
+		if output.Item.Code == cubeUseType {
+			item = &Item{factory: f, CommonCode: first.CommonCode}
+			item.SetSeed(f.itemSeed())
+		} else if item = f.ItemFromTreasure(&d2records.Treasure{Code: output.Item.Code}); item == nil {
+			return nil, fmt.Errorf("cannot create the cube output %s", output.Item.Code)
+		}
+
+		result = append(result, f.applyCubeOutput(output, item, first, inputLevel, ctx)...)
+	}
+
+	return result, nil
+}
+
+// applyCubeOutput applies the qualifiers, the level and the mods of an output of a recipe to an
+// item. The gems removed from the item by the rem qualifier are returned after the item.
+func (f *ItemFactory) applyCubeOutput(output *d2records.CubeRecipeResult, item, first *Item,
+	inputLevel int, ctx CubeContext) []*Item {
+	result := []*Item{item}
+	params := make(map[string]bool)
+	quality := d2enum.ItemQuality(0)
+
+	for _, param := range output.Item.Params {
+		params[param] = true
+
+		if found, ok := cubeQualities()[param]; ok {
+			quality = found
+		}
+	}
+
+	record := item.CommonRecord()
+
+	switch {
+	case params[cubeExceptional] && record.UberCode != "":
+		item.CommonCode = record.UberCode
+	case params[cubeElite] && record.UltraCode != "":
+		item.CommonCode = record.UltraCode
+	case params[cubeUpgrade] && nextTierCode(record) != "":
+		item.CommonCode = nextTierCode(record)
+	}
+
+	if params[cubeKeepMods] && item != first {
+		item.UniqueCode, item.SetCode, item.SetItemCode = first.UniqueCode, first.SetCode, first.SetItemCode
+		item.PrefixCodes = append([]string(nil), first.PrefixCodes...)
+		item.SuffixCodes = append([]string(nil), first.SuffixCodes...)
+	}
+
+	if params[cubeRegenerate] && quality == 0 {
+		quality = item.Quality()
+		item.SetSeed(f.itemSeed())
+		item.rand.Seed(item.Seed)
+	}
+
+	if quality != 0 {
+		item.UniqueCode, item.SetCode, item.SetItemCode = "", "", ""
+		item.PrefixCodes, item.SuffixCodes = nil, nil
+		item.applyDropModifier(cubeDropModifier(quality))
+	}
+
+	item.reinit()
+
+	if quality == d2enum.Crafted {
+		item.attributes.crafted = true
+	}
+
+	if params[cubeEthereal] {
+		item.attributes.ethereal = true
+	}
+
+	if params[cubeRepair] {
+		item.attributes.currentDurability = item.attributes.durability.max
+	}
+
+	if params[cubeUnsocket] || params[cubeRemoveGems] {
+		removed := item.removeSockets()
+
+		if params[cubeRemoveGems] {
+			result = append(result, removed...)
+		}
+	}
+
+	for _, param := range output.Item.Params {
+		if sockets, found := cubeQualifierValue(param, cubeSockets); found {
+			item.SetNumSockets(sockets)
+		}
+	}
+
+	level := output.Level + output.PLevel*ctx.PlayerLevel/cubeLevelScale + output.ILevel*inputLevel/cubeLevelScale
+	if level > 0 {
+		item.attributes.baseItemLevel = level
+	}
+
+	item.addCubeMods(output.Properties)
+	item.updateStatList()
+
+	return result
+}
+
+// cubeDropModifier returns the drop modifier creating items of a quality. Crafted and tempered
+// items get the affixes of rare items.
+func cubeDropModifier(quality d2enum.ItemQuality) dropModifier {
+	switch quality {
+	case d2enum.Magic:
+		return dropModifierMagic
+	case d2enum.Set:
+		return dropModifierSet
+	case d2enum.Rare, d2enum.Crafted, d2enum.Tempered:
+		return dropModifierRare
+	case d2enum.Unique:
+		return dropModifierUnique
+	default:
+		return dropModifierNone
+	}
+}
+
+// reinit creates the properties and the attributes of an item again after its codes changed. The
+// attributes which are not derived from the records are kept.
+func (i *Item) reinit() {
+	if i.attributes == nil {
+		i.init()
+		return
+	}
+
+	kept := *i.attributes
+	cubeProperties := i.properties[PropertyPoolCube]
+
+	i.properties = nil
+	i.init()
+
+	if cubeProperties != nil {
+		i.properties[PropertyPoolCube] = cubeProperties
+	}
+
+	i.attributes.baseItemLevel = kept.baseItemLevel
+	i.attributes.numSockets = kept.numSockets
+	i.attributes.ethereal = kept.ethereal
+	i.attributes.identitified = kept.identitified
+	i.attributes.crafted = kept.crafted
+	i.attributes.personalization = kept.personalization
+	i.attributes.currentDurability = kept.currentDurability
+}
+
+// addCubeMods rolls the mods of an output of a recipe onto the item
+func (i *Item) addCubeMods(mods []d2records.CubeRecipeItemProperty) {
+	for idx := range mods {
+		mod := &mods[idx]
+		if mod.Code == "" || (mod.Chance > 0 && i.rand.Intn(cubeModChance) >= mod.Chance) {
+			continue
+		}
+
+		property := i.factory.newProperty(i.rand, mod.Code, mod.Param, mod.Min, mod.Max)
+		if property == nil {
+			continue
+		}
+
+		if i.properties == nil {
+			i.properties = make(map[PropertyPool][]*Property)
+		}
+
+		i.properties[PropertyPoolCube] = append(i.properties[PropertyPoolCube], property)
+	}
+}
+
+func containsItem(items []*Item, item *Item) bool {
+	for _, found := range items {
+		if found == item {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,137 @@
+package diablo2item
+
+import (
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+)
+
+func testCubeRecipe(numInputs int, inputs []d2records.CubeRecipeItem,
+	output d2records.CubeRecipeResult) *d2records.CubeRecipeRecord {
+	recipe := &d2records.CubeRecipeRecord{
+		Enabled:   true,
+		NumInputs: numInputs,
+		Inputs:    make([]d2records.CubeRecipeItem, 7),
+		Outputs:   make([]d2records.CubeRecipeResult, 3),
+	}
+
+	copy(recipe.Inputs, inputs)
+	recipe.Outputs[0] = output
+
+	return recipe
+}
+
+func testCubeItems(t *testing.T, factory *ItemFactory, codes ...string) []*Item {
+	items := make([]*Item, len(codes))
+
+	for idx, code := range codes {
+		item, err := factory.NewItem(code)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		items[idx] = item
+	}
+
+	return items
+}
+
+func TestItemFactory_Transmute(t *testing.T) {
+	factory := newTestItemFactory(t)
+	records := factory.asset.Records
+
+	axeRecord := &d2records.ItemCommonRecord{Code: "hax", Type: "axe", Level: 3, NormalCode: "hax", UberCode: "9ha",
+		UltraCode: "7ha"}
+	exceptionalRecord := &d2records.ItemCommonRecord{Code: "9ha", Type: "axe", Level: 31, NormalCode: "hax",
+		UberCode: "9ha", UltraCode: "7ha", GemSockets: 2}
+	chipped := &d2records.ItemCommonRecord{Code: "gcv", Type: "gem", Level: 1}
+	flawed := &d2records.ItemCommonRecord{Code: "gfv", Type: "gem", Level: 6}
+
+	records.Item.All = map[string]*d2records.ItemCommonRecord{
+		axeRecord.Code: axeRecord, exceptionalRecord.Code: exceptionalRecord, chipped.Code: chipped, flawed.Code: flawed,
+	}
+	records.Item.Equivalency = d2records.ItemEquivalenceMap{
+		"axe":  {axeRecord, exceptionalRecord},
+		"weap": {axeRecord, exceptionalRecord},
+		"gem":  {chipped, flawed},
+	}
+
+	ctx := CubeContext{Hero: d2enum.HeroPaladin, PlayerLevel: 20}
+
+	upgradeGems := testCubeRecipe(3,
+		[]d2records.CubeRecipeItem{{Code: "gcv", Count: 3}},
+		d2records.CubeRecipeResult{Item: d2records.CubeRecipeItem{Code: "gfv", Count: 1}})
+
+	upgradeAxe := testCubeRecipe(2,
+		[]d2records.CubeRecipeItem{{Code: "weap", Params: []string{"nor", "bas"}, Count: 1}, {Code: "gem", Count: 1}},
+		d2records.CubeRecipeResult{Item: d2records.CubeRecipeItem{Code: "useitem", Params: []string{"upg", "sock=2"},
+			Count: 1}})
+
+	rerollAxe := testCubeRecipe(2,
+		[]d2records.CubeRecipeItem{{Code: "axe", Params: []string{"nos", "upg"}, Count: 1}, {Code: "any", Count: 1}},
+		d2records.CubeRecipeResult{Item: d2records.CubeRecipeItem{Code: "usetype", Count: 2}, Level: 5, PLevel: 50})
+	rerollAxe.Class = []d2enum.Hero{d2enum.HeroBarbarian}
+
+	recipes := d2records.CubeRecipes{upgradeGems, upgradeAxe, rerollAxe}
+
+	if _, err := factory.Transmute(recipes, testCubeItems(t, factory, "gcv", "gcv"), ctx); err != ErrNoCubeRecipe {
+		t.Errorf("expected no recipe for two chipped gems, got %v", err)
+	}
+
+	gems, err := factory.Transmute(recipes, testCubeItems(t, factory, "gcv", "gcv", "gcv"), ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if gems.Recipe != upgradeGems || len(gems.Consumed) != 3 || len(gems.Outputs) != 1 ||
+		gems.Outputs[0].CommonCode != "gfv" {
+		t.Errorf("unexpected transmutation of three chipped gems: %+v", gems)
+	}
+
+	// the gem is listed first, the inputs are assigned regardless of the order of the items
+	items := testCubeItems(t, factory, "gcv", "hax")
+
+	axe, err := factory.Transmute(recipes, items, ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if axe.Recipe != upgradeAxe || len(axe.Consumed) != 1 || axe.Consumed[0] != items[0] ||
+		len(axe.Outputs) != 1 || axe.Outputs[0] != items[1] {
+		t.Fatalf("expected the axe to be upgraded in place: %+v", axe)
+	}
+
+	if items[1].CommonCode != "9ha" || items[1].attributes.numSockets != 2 {
+		t.Errorf("expected an exceptional axe with two sockets, got %s with %d sockets", items[1].CommonCode,
+			items[1].attributes.numSockets)
+	}
+
+	// the exceptional axe is not a basic item, and the class specific recipe is only matched by
+	// a barbarian
+	if match := MatchCubeRecipe(recipes, testCubeItems(t, factory, "9ha", "gcv"), ctx); match != nil {
+		t.Errorf("expected no recipe for an exceptional axe, got %+v", match.Recipe)
+	}
+
+	ctx.Hero = d2enum.HeroBarbarian
+
+	if match := MatchCubeRecipe(recipes, []*Item{items[1], items[0]}, ctx); match != nil {
+		t.Errorf("expected no recipe for a socketed exceptional axe, got %+v", match.Recipe)
+	}
+
+	reroll, err := factory.Transmute(recipes, testCubeItems(t, factory, "9ha", "gcv"), ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if reroll.Recipe != rerollAxe || len(reroll.Consumed) != 2 || len(reroll.Outputs) != 2 {
+		t.Fatalf("unexpected transmutation of the barbarian: %+v", reroll)
+	}
+
+	for _, output := range reroll.Outputs {
+		if output.CommonCode != "9ha" || output.ItemLevel() != 15 {
+			t.Errorf("expected an exceptional axe of level 15, got %s of level %d", output.CommonCode,
+				output.ItemLevel())
+		}
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
 
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2item"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2stats"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2ui"
@@ -25,6 +26,10 @@ const (
 	PropertyPoolUnique
 	PropertyPoolSetItem
 	PropertyPoolSet
+	PropertyPoolCube
+	PropertyPoolSocket
+	PropertyPoolRuneword
+	PropertyPoolSaved // the properties of a saved item, loaded by ItemFactory.Deserialize
 )
 
 // for handling special cases
@@ -32,6 +37,10 @@ const (
 	jewelItemCode          = "jew"
 	propertyEthereal       = "ethereal"
 	propertyIndestructable = "indestruct"
+
+	// the stat of the enhanced defense of an item, in percent
+	armorPercentStatName = "item_armor_percent"
+	percent              = 100
 )
 
 const (
@@ -62,13 +71,14 @@ type Item struct {
 
 	slotType d2enum.EquippedSlot
 
-	TypeCode    string
-	CommonCode  string
-	UniqueCode  string
-	SetCode     string
-	SetItemCode string
-	PrefixCodes []string
-	SuffixCodes []string
+	TypeCode     string
+	CommonCode   string
+	UniqueCode   string
+	SetCode      string
+	SetItemCode  string
+	RunewordCode string
+	PrefixCodes  []string
+	SuffixCodes  []string
 
 	properties      map[PropertyPool][]*Property
 	statContext     d2item.StatContext
@@ -81,7 +91,12 @@ type Item struct {
 	GridX int
 	GridY int
 
-	sockets []*d2item.Item // there will be checks for handling the craziness this might entail
+	Location d2enum.ItemLocation
+	Storage  d2enum.ItemStorage
+
+	Ear *Ear // the ear of a player killed by another player
+
+	sockets []*Item // the gems, runes and jewels inserted into the item
 }
 
 // nolint:structcheck,unused // WIP
@@ -139,7 +154,7 @@ func (i *Item) Label() string {
 		return d2ui.ColorTokenize(str, d2ui.ColorTokenSetItem)
 	}
 
-	if i.UniqueRecord() != nil {
+	if i.UniqueRecord() != nil || i.RunewordRecord() != nil {
 		return d2ui.ColorTokenize(str, d2ui.ColorTokenUniqueItem)
 	}
 
@@ -185,6 +200,42 @@ func (i *Item) ItemLevel() int {
 	return i.attributes.baseItemLevel
 }
 
+// Durability returns the current and the maximum durability of the item. Items without
+// durability have a maximum of zero.
+func (i *Item) Durability() (current, max int) {
+	if !i.attributes.durable || i.attributes.indestructable {
+		return 0, 0
+	}
+
+	return i.attributes.currentDurability, i.attributes.durability.max
+}
+
+// SetDurability sets the current durability of the item, limited by its maximum durability
+func (i *Item) SetDurability(durability int) {
+	i.attributes.currentDurability = d2math.ClampInt(durability, 0, i.attributes.durability.max)
+}
+
+// Quality returns the quality of the item. Items without a unique, set or crafted record are
+// magic with one or two affixes and rare with more.
+func (i *Item) Quality() d2enum.ItemQuality {
+	numAffixes := len(i.PrefixCodes) + len(i.SuffixCodes)
+
+	switch {
+	case i.attributes != nil && i.attributes.crafted:
+		return d2enum.Crafted
+	case i.UniqueCode != "":
+		return d2enum.Unique
+	case i.SetItemCode != "":
+		return d2enum.Set
+	case numAffixes > maxAffixesOnMagicItem:
+		return d2enum.Rare
+	case numAffixes > 0:
+		return d2enum.Magic
+	default:
+		return d2enum.Normal
+	}
+}
+
 // TypeRecord returns the ItemTypeRecord of the item
 func (i *Item) TypeRecord() *d2records.ItemTypeRecord {
 	return i.factory.asset.Records.Item.Types[i.TypeCode]
@@ -243,11 +294,53 @@ func (i *Item) SlotType() d2enum.EquippedSlot {
 	return i.slotType
 }
 
+// SetSlotType sets the slot the item is equipped in
+func (i *Item) SetSlotType(slot d2enum.EquippedSlot) {
+	i.slotType = slot
+}
+
 // StatList returns the evaluated stat list
 func (i *Item) StatList() d2stats.StatList {
 	return i.statList
 }
 
+// Defense returns the defense of the item, with the enhanced defense of its properties
+func (i *Item) Defense() int {
+	defense := i.attributes.defense
+
+	if i.statList != nil {
+		for _, stat := range i.statList.Stats() {
+			if stat.Name() == armorPercentStatName && len(stat.Values()) > 0 {
+				defense = defense * (percent + stat.Values()[0].Int()) / percent
+			}
+		}
+	}
+
+	return defense
+}
+
+// Requirements returns the level, strength and dexterity a character needs to use the item.
+// The unique, set and affix records of the item may raise the level of the base item.
+func (i *Item) Requirements() (level, strength, dexterity int) {
+	level = i.attributes.requiredLevel
+
+	if record := i.UniqueRecord(); record != nil && record.RequiredLevel > level {
+		level = record.RequiredLevel
+	}
+
+	if record := i.SetItemRecord(); record != nil && record.RequiredLevel > level {
+		level = record.RequiredLevel
+	}
+
+	for _, affix := range append(i.PrefixRecords(), i.SuffixRecords()...) {
+		if affix != nil && affix.LevelReq > level {
+			level = affix.LevelReq
+		}
+	}
+
+	return level, i.attributes.requiredStrength, i.attributes.requiredDexterity
+}
+
 // Description returns the full description string for the item
 func (i *Item) Description() string {
 	return ""
@@ -354,7 +447,7 @@ func (i *Item) pickMagicAffixes(mod dropModifier) {
 	}
 
 	prefixes := i.factory.asset.Records.Item.Magic.Prefix
-	suffixes := i.factory.asset.Records.Item.Magic.Prefix
+	suffixes := i.factory.asset.Records.Item.Magic.Suffix
 
 	i.PrefixCodes = i.pickRandomAffixes(numPrefixes, totalAffixes, prefixes)
 	i.SuffixCodes = i.pickRandomAffixes(numSuffixes, totalAffixes, suffixes)
@@ -399,8 +492,13 @@ func (i *Item) init() *Item {
 		i.SetSeed(0)
 	}
 
+	if i.TypeCode == "" {
+		i.TypeCode = i.CommonRecord().Type
+	}
+
 	i.generateAllProperties()
 	i.updateItemAttributes()
+	i.updateStatList()
 
 	return i
 }
@@ -420,6 +518,8 @@ func (i *Item) generateAllProperties() {
 		PropertyPoolUnique,
 		PropertyPoolSetItem,
 		PropertyPoolSet,
+		PropertyPoolSocket,
+		PropertyPoolRuneword,
 	}
 
 	for _, pool := range pools {
@@ -444,6 +544,12 @@ func (i *Item) generateProperties(pool PropertyPool) {
 			props = generated
 		}
 	case PropertyPoolSet: // https://github.com/OpenDiablo2/OpenDiablo2/issues/817
+	case PropertyPoolSocket:
+		props = i.generateSocketProperties()
+	case PropertyPoolRuneword:
+		if record := i.RunewordRecord(); record != nil {
+			props = i.generateItemProperties(record.Properties)
+		}
 	}
 
 	if props == nil {
@@ -504,6 +610,7 @@ func (i *Item) updateItemAttributes() {
 		requiredDexterity: r.RequiredDexterity,
 		durable:           !r.NoDurability,
 		throwable:         r.Throwable,
+		currentDurability: r.Durability,
 	}
 
 	def, minDef, maxDef := 0, r.MinAC, r.MaxAC
@@ -549,7 +656,7 @@ func (i *Item) generateAffixProperties(pool PropertyPool) []*Property {
 				paramInt = 0
 			}
 
-			prop := i.factory.NewProperty(mod.Code, paramInt, mod.Min, mod.Max)
+			prop := i.factory.newProperty(i.rand, mod.Code, paramInt, mod.Min, mod.Max)
 			if prop == nil {
 				continue
 			}
@@ -578,33 +685,7 @@ func (i *Item) generateSetItemProperties() []*Property {
 }
 
 func (i *Item) generateItemProperties(properties []*d2records.PropertyDescriptor) []*Property {
-	result := make([]*Property, 0)
-
-	for propIdx := range properties {
-		setProp := properties[propIdx]
-
-		// like with unique records, the property param is sometimes a skill name
-		// as a string, not an integer index
-		paramStr := getStringComponent(setProp.Parameter)
-		paramInt := getNumericComponent(setProp.Parameter)
-
-		if paramStr != "" {
-			for skillID := range i.factory.asset.Records.Skill.Details {
-				if i.factory.asset.Records.Skill.Details[skillID].Skill == paramStr {
-					paramInt = skillID
-				}
-			}
-		}
-
-		prop := i.factory.NewProperty(setProp.Code, paramInt, setProp.Min, setProp.Max)
-		if prop == nil {
-			continue
-		}
-
-		result = append(result, prop)
-	}
-
-	return result
+	return i.factory.newProperties(i.rand, properties)
 }
 
 func (i *Item) generateName() {
@@ -618,6 +699,11 @@ func (i *Item) generateName() {
 		return
 	}
 
+	if i.RunewordRecord() != nil {
+		i.name = i.factory.asset.TranslateString(i.RunewordRecord().Name)
+		return
+	}
+
 	name := i.factory.asset.TranslateString(i.CommonRecord().NameString)
 
 	numAffixes := 0
@@ -665,9 +751,13 @@ func (i *Item) generateName() {
 	i.name = name
 }
 
-// GetStatStrings is a test function for getting all stat strings
-func (i *Item) GetStatStrings() []string {
-	result := make([]string, 0)
+// updateStatList merges the stats of all the properties of the item, including the properties
+// of its sockets and runeword, into its stat list
+func (i *Item) updateStatList() {
+	i.statList = i.factory.stat.NewStatList(i.propertyStats()...).ReduceStats()
+}
+
+func (i *Item) propertyStats() []d2stats.Stat {
 	stats := make([]d2stats.Stat, 0)
 
 	for pool := range i.properties {
@@ -689,6 +779,14 @@ func (i *Item) GetStatStrings() []string {
 		}
 	}
 
+	return stats
+}
+
+// GetStatStrings is a test function for getting all stat strings
+func (i *Item) GetStatStrings() []string {
+	result := make([]string, 0)
+	stats := i.propertyStats()
+
 	if len(stats) > 0 {
 		stats = i.factory.stat.NewStatList(stats...).ReduceStats().Stats()
 	}
@@ -747,19 +845,17 @@ func (i *Item) GetInventoryItemName() string {
 
 // GetInventoryItemType returns whether the item is a weapon, armor, or misc item
 func (i *Item) GetInventoryItemType() d2enum.InventoryItemType {
-	typeCode := i.TypeRecord().Code
-
 	armorEquiv := i.factory.asset.Records.Item.Equivalency["armo"]
 	weaponEquiv := i.factory.asset.Records.Item.Equivalency["weap"]
 
 	for idx := range armorEquiv {
-		if armorEquiv[idx].Code == typeCode {
+		if armorEquiv[idx].Code == i.CommonCode {
 			return d2enum.InventoryItemTypeArmor
 		}
 	}
 
 	for idx := range weaponEquiv {
-		if weaponEquiv[idx].Code == typeCode {
+		if weaponEquiv[idx].Code == i.CommonCode {
 			return d2enum.InventoryItemTypeWeapon
 		}
 	}
@@ -778,9 +874,23 @@ func (i *Item) GetItemCode() string {
 	return i.CommonRecord().Code
 }
 
-// Serialize the item to a byte slize
-func (i *Item) Serialize() []byte {
-	panic("item serialization not yet implemented")
+// Codes returns the codes of the item, the item is created again from them by
+// ItemFactory.NewItem
+func (i *Item) Codes() []string {
+	codes := []string{i.CommonCode}
+
+	if i.SetItemCode != "" {
+		codes = append(codes, i.SetItemCode)
+	}
+
+	if i.UniqueCode != "" {
+		codes = append(codes, i.UniqueCode)
+	}
+
+	codes = append(codes, i.PrefixCodes...)
+	codes = append(codes, i.SuffixCodes...)
+
+	return codes
 }
 
 // InventoryGridSlot returns the inventory grid slot x and y
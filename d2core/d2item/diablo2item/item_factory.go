@@ -2,10 +2,12 @@ package diablo2item
 
 import (
 	"errors"
+	"math"
 	"math/rand"
 	"regexp"
 	"strconv"
 
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2stats"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2stats/diablo2stats"
 
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
@@ -70,16 +72,32 @@ type ItemFactory struct {
 	Seed   int64
 }
 
-// SetSeed sets the item generator seed
+// SetSeed sets the item generator seed. The items generated after setting the same seed are
+// the same.
 func (f *ItemFactory) SetSeed(seed int64) {
-	if f.rand == nil || f.source == nil {
-		// nolint:gosec // we're not concerned with crypto-strong randomness
-		f.rand = rand.New(rand.NewSource(seed))
-	}
+	f.source = rand.NewSource(seed)
+	// nolint:gosec // we're not concerned with crypto-strong randomness
+	f.rand = rand.New(f.source)
 
 	f.Seed = seed
 }
 
+// itemSeed returns the seed of a new item. The seed is saved as the 32 bit fingerprint of the
+// item, so it fits in 32 bits.
+func (f *ItemFactory) itemSeed() int64 {
+	return int64(f.rand.Uint32())
+}
+
+// TreasureClass returns the record of a treasure class, the records of TreasureClassEx.txt
+// take precedence over the records of TreasureClass.txt
+func (f *ItemFactory) TreasureClass(name string) *d2records.TreasureClassRecord {
+	if record, found := f.asset.Records.Item.Treasure.Expansion[name]; found {
+		return record
+	}
+
+	return f.asset.Records.Item.Treasure.Normal[name]
+}
+
 // NewItem creates a new item instance from the given codes
 func (f *ItemFactory) NewItem(codes ...string) (*Item, error) {
 	var common, set, unique string
@@ -145,6 +163,12 @@ func (f *ItemFactory) NewItem(codes ...string) (*Item, error) {
 
 // NewProperty creates a property
 func (f *ItemFactory) NewProperty(code string, values ...int) *Property {
+	return f.newProperty(f.rand, code, values...)
+}
+
+// newProperty creates a property which rolls its values with the given rand, items roll their
+// properties with the rand of their seed so the same seed rolls the same values
+func (f *ItemFactory) newProperty(rnd *rand.Rand, code string, values ...int) *Property {
 	record := f.asset.Records.Properties[code]
 
 	if record == nil {
@@ -154,12 +178,57 @@ func (f *ItemFactory) NewProperty(code string, values ...int) *Property {
 	result := &Property{
 		factory:     f,
 		record:      record,
+		rand:        rnd,
 		inputParams: values,
 	}
 
 	return result.init()
 }
 
+// PropertyStatList returns the stats of the properties of the given descriptors, like the set
+// bonuses of Sets.txt and SetItems.txt which are not part of any item
+func (f *ItemFactory) PropertyStatList(descriptors []*d2records.PropertyDescriptor) d2stats.StatList {
+	stats := make([]d2stats.Stat, 0)
+
+	for _, prop := range f.newProperties(f.rand, descriptors) {
+		stats = append(stats, prop.stats...)
+	}
+
+	return f.stat.NewStatList(stats...).ReduceStats()
+}
+
+func (f *ItemFactory) newProperties(rnd *rand.Rand, descriptors []*d2records.PropertyDescriptor) []*Property {
+	result := make([]*Property, 0)
+
+	for _, descriptor := range descriptors {
+		if descriptor == nil {
+			continue
+		}
+
+		// like with unique records, the property param is sometimes a skill name
+		// as a string, not an integer index
+		paramStr := getStringComponent(descriptor.Parameter)
+		paramInt := getNumericComponent(descriptor.Parameter)
+
+		if paramStr != "" {
+			for skillID := range f.asset.Records.Skill.Details {
+				if f.asset.Records.Skill.Details[skillID].Skill == paramStr {
+					paramInt = skillID
+				}
+			}
+		}
+
+		prop := f.newProperty(rnd, descriptor.Code, paramInt, descriptor.Min, descriptor.Max)
+		if prop == nil {
+			continue
+		}
+
+		result = append(result, prop)
+	}
+
+	return result
+}
+
 func (f *ItemFactory) rollDropModifier(tcr *d2records.TreasureClassRecord) dropModifier {
 	modMap := map[int]dropModifier{
 		0: dropModifierNone,
@@ -196,10 +265,33 @@ func (f *ItemFactory) rollDropModifier(tcr *d2records.TreasureClassRecord) dropM
 	return dropModifierNone
 }
 
-func (f *ItemFactory) rollTreasurePick(tcr *d2records.TreasureClassRecord) *d2records.Treasure {
+// noDropFrequency returns the NoDrop frequency of a treasure class for the given number of
+// players. The chance of no drop is raised to the power of the players, as in the original
+// game, where every two players in the game count as one more player for the drops.
+func noDropFrequency(tcr *d2records.TreasureClassRecord, players int) int {
+	if tcr.FreqNoDrop <= 0 || players <= 1 {
+		return tcr.FreqNoDrop
+	}
+
+	total := 0
+	for idx := range tcr.Treasures {
+		total += tcr.Treasures[idx].Probability
+	}
+
+	if total <= 0 {
+		return tcr.FreqNoDrop
+	}
+
+	exponent := float64((players + 1) / 2) //nolint:gomnd // two players count as one
+	chance := math.Pow(float64(tcr.FreqNoDrop)/float64(tcr.FreqNoDrop+total), exponent)
+
+	return int(chance / (1 - chance) * float64(total))
+}
+
+func (f *ItemFactory) rollTreasurePick(tcr *d2records.TreasureClassRecord, players int) *d2records.Treasure {
 	// treasure probabilities
 	tprob := make([]int, len(tcr.Treasures)+1)
-	total := tcr.FreqNoDrop
+	total := noDropFrequency(tcr, players)
 	tprob[0] = total
 
 	for idx := range tcr.Treasures {
@@ -207,6 +299,10 @@ func (f *ItemFactory) rollTreasurePick(tcr *d2records.TreasureClassRecord) *d2re
 		tprob[idx+1] = total
 	}
 
+	if total <= 0 {
+		return nil
+	}
+
 	roll := f.rand.Intn(total)
 
 	for idx := range tprob {
@@ -224,6 +320,12 @@ func (f *ItemFactory) rollTreasurePick(tcr *d2records.TreasureClassRecord) *d2re
 
 // ItemsFromTreasureClass rolls for and creates items using a treasure class record
 func (f *ItemFactory) ItemsFromTreasureClass(tcr *d2records.TreasureClassRecord) []*Item {
+	return f.ItemsFromTreasureClassForPlayers(tcr, 1)
+}
+
+// ItemsFromTreasureClassForPlayers rolls for and creates items using a treasure class record,
+// with the NoDrop frequencies lowered for the number of players in the game
+func (f *ItemFactory) ItemsFromTreasureClassForPlayers(tcr *d2records.TreasureClassRecord, players int) []*Item {
 	result := make([]*Item, 0)
 
 	treasurePicks := make([]*d2records.Treasure, 0)
@@ -245,7 +347,7 @@ func (f *ItemFactory) ItemsFromTreasureClass(tcr *d2records.TreasureClassRecord)
 	} else {
 		// for N picks, we roll for a treasure and append to our treasures if it isn't a NoDrop
 		for picksLeft := tcr.NumPicks; picksLeft > 0; picksLeft-- {
-			rolledTreasure := f.rollTreasurePick(tcr)
+			rolledTreasure := f.rollTreasurePick(tcr, players)
 
 			if rolledTreasure == nil {
 				continue
@@ -260,9 +362,9 @@ func (f *ItemFactory) ItemsFromTreasureClass(tcr *d2records.TreasureClassRecord)
 	// case we will roll that treasure class, eventually getting a slice of items
 	for idx := range treasurePicks {
 		picked := treasurePicks[idx]
-		if record, found := f.asset.Records.Item.Treasure.Normal[picked.Code]; found {
+		if record := f.TreasureClass(picked.Code); record != nil {
 			// the code is for a treasure class, we roll again using that TC
-			itemSlice := f.ItemsFromTreasureClass(record)
+			itemSlice := f.ItemsFromTreasureClassForPlayers(record, players)
 			for itemIdx := range itemSlice {
 				itemSlice[itemIdx].applyDropModifier(f.rollDropModifier(tcr))
 				itemSlice[itemIdx].init()
@@ -284,10 +386,10 @@ func (f *ItemFactory) ItemsFromTreasureClass(tcr *d2records.TreasureClassRecord)
 
 // ItemFromTreasure rolls for a f.rand.m item using the Treasure struct (from d2datadict)
 func (f *ItemFactory) ItemFromTreasure(treasure *d2records.Treasure) *Item {
-	result := &Item{
-		// nolint:gosec // we're not concerned with crypto-strong randomness
-		rand: rand.New(rand.NewSource(f.Seed)),
-	}
+	result := &Item{factory: f}
+
+	// every item gets a seed of its own, so the items of a drop differ
+	result.SetSeed(f.itemSeed())
 
 	// in this case, the treasure code is a code used by an ItemCommonRecord
 	commonRecord := f.asset.Records.Item.All[treasure.Code]
@@ -0,0 +1,97 @@
+package diablo2item
+
+import (
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2util"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2asset"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+)
+
+// newTestItemFactory creates an item factory without records, the tests set the records they
+// need on its asset manager
+func newTestItemFactory(t *testing.T) *ItemFactory {
+	asset, err := d2asset.NewAssetManager(d2util.LogLevelNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	factory, err := NewItemFactory(asset)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return factory
+}
+
+func testTreasureClass() *d2records.TreasureClassRecord {
+	return &d2records.TreasureClassRecord{
+		Name:       "test",
+		NumPicks:   1,
+		FreqNoDrop: 100,
+		Treasures:  []*d2records.Treasure{{Code: "hax", Probability: 60}, {Code: "buc", Probability: 40}},
+	}
+}
+
+func TestNoDropFrequency(t *testing.T) {
+	tcr := testTreasureClass()
+
+	tests := []struct {
+		players int
+		noDrop  int
+	}{
+		{1, 100},
+		{2, 100},
+		{3, 33}, // (100/200)^2 = 1/4 no drop, 100/3 against the 100 of the treasures
+		{8, 6},  // (100/200)^4 = 1/16 no drop, 100/15 against the 100 of the treasures
+	}
+
+	for _, test := range tests {
+		if noDrop := noDropFrequency(tcr, test.players); noDrop != test.noDrop {
+			t.Errorf("%d players: expected NoDrop %d, got %d", test.players, test.noDrop, noDrop)
+		}
+	}
+}
+
+func TestItemFactory_RollTreasurePick(t *testing.T) {
+	tcr := testTreasureClass()
+
+	roll := func(seed int64, players int) []string {
+		factory := &ItemFactory{}
+		factory.SetSeed(seed)
+
+		codes := make([]string, 0)
+
+		for idx := 0; idx < 100; idx++ {
+			code := ""
+			if treasure := factory.rollTreasurePick(tcr, players); treasure != nil {
+				code = treasure.Code
+			}
+
+			codes = append(codes, code)
+		}
+
+		return codes
+	}
+
+	first, second := roll(5, 1), roll(5, 1)
+	for idx := range first {
+		if first[idx] != second[idx] {
+			t.Fatalf("expected the same picks for the same seed, pick %d differs", idx)
+		}
+	}
+
+	count := func(codes []string) (drops int) {
+		for _, code := range codes {
+			if code != "" {
+				drops++
+			}
+		}
+
+		return drops
+	}
+
+	if single, full := count(roll(1, 1)), count(roll(1, 8)); full <= single {
+		t.Errorf("expected more drops with 8 players, got %d and %d", single, full)
+	}
+}
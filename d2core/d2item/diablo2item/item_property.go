@@ -74,6 +74,7 @@ const (
 type Property struct {
 	factory      *ItemFactory
 	record       *d2records.PropertyRecord
+	rand         *rand.Rand // the rand of the item the property is rolled for
 	stats        []d2stats.Stat
 	PropertyType PropertyType
 
@@ -192,8 +193,7 @@ func (p *Property) fnValuesToStat(iscRecord *d2records.ItemStatCostRecord) d2sta
 		min, max = max, min
 	}
 
-	// nolint:gosec // not concerned with crypto-strong randomness
-	statValue = float64(rand.Intn(max-min+1) + min)
+	statValue = float64(p.rand.Intn(max-min+1) + min)
 
 	return p.factory.stat.NewStat(iscRecord.Name, statValue, propParam)
 }
@@ -209,8 +209,7 @@ func (p *Property) fnComputeInteger() int {
 		min, max = p.inputParams[0], p.inputParams[1]
 	}
 
-	// nolint:gosec // not concerned with crypto-strong randomness
-	statValue := rand.Intn(max-min+1) + min
+	statValue := p.rand.Intn(max-min+1) + min
 
 	return statValue
 }
@@ -250,8 +249,7 @@ func (p *Property) fnClassSkillTab(iscRecord *d2records.ItemStatCostRecord) d2st
 	skillTabIdx := float64(param % skillTabsPerClass)
 	classIdx := float64(param / skillTabsPerClass)
 
-	// nolint:gosec // not concerned with crypto-strong randomness
-	level := float64(rand.Intn(max-min+1) + min)
+	level := float64(p.rand.Intn(max-min+1) + min)
 
 	return p.factory.stat.NewStat(iscRecord.Name, level, classIdx, skillTabIdx)
 }
@@ -282,8 +280,7 @@ func (p *Property) fnRandomSkill(iscRecord *d2records.ItemStatCostRecord) d2stat
 	default:
 		skillLevel = float64(p.inputParams[0])
 		min, max := p.inputParams[1], p.inputParams[2]
-		// nolint:gosec // not concerned with crypto-strong randomness
-		skillID = float64(rand.Intn(max-min+1) + min)
+		skillID = float64(p.rand.Intn(max-min+1) + min)
 	}
 
 	return p.factory.stat.NewStat(iscRecord.Name, skillLevel, skillID, invalidHeroIndex)
@@ -327,8 +324,7 @@ func (p *Property) fnBoolean() bool {
 		min, max = p.inputParams[0], p.inputParams[1]
 	}
 
-	// nolint:gosec // not concerned with crypto-strong randomness
-	statValue := rand.Intn(max-min+1) + min
+	statValue := p.rand.Intn(max-min+1) + min
 
 	return statValue > 0
 }
@@ -354,8 +350,7 @@ func (p *Property) fnClassSkills(
 		min, max = p.inputParams[0], p.inputParams[1]
 	}
 
-	// nolint:gosec // not concerned with crypto-strong randomness
-	statValue := rand.Intn(max-min+1) + min
+	statValue := p.rand.Intn(max-min+1) + min
 	classIdx = propStatRecord.Value
 
 	return p.factory.stat.NewStat(iscRecord.Name, float64(statValue), float64(classIdx))
@@ -627,3 +627,16 @@ func TestNewProperty(t *testing.T) { //nolint:funlen // it's mostly test-case de
 		}
 	}
 }
+
+func TestNewProperty_Seed(t *testing.T) {
+	roll := func() int {
+		rnd := rand.New(rand.NewSource(42)) // nolint:gosec // not concerned with crypto-strong randomness
+		prop := testItemFactory.newProperty(rnd, "ac%", 1, 1000)
+
+		return prop.stats[0].Values()[0].Int()
+	}
+
+	if first, second := roll(), roll(); first != second {
+		t.Errorf("expected the same seed to roll the same value, got %d and %d", first, second)
+	}
+}
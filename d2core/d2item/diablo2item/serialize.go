@@ -0,0 +1,950 @@
+package diablo2item
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2datautils"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2stats"
+)
+
+// the identifier every saved item starts with, and the version of the saved items
+const (
+	itemIdentifier = "JM"
+	itemVersion    = 101
+)
+
+// the bits of the flags of a saved item
+const (
+	itemFlagIdentified   = 4
+	itemFlagSocketed     = 11
+	itemFlagEar          = 16
+	itemFlagSimple       = 21
+	itemFlagEthereal     = 22
+	itemFlagPersonalized = 24
+	itemFlagRuneword     = 26
+)
+
+// the number of bits of the fields of a saved item
+const (
+	bitsPerByte         = 8
+	bitsFlags           = 32
+	bitsVersion         = 10
+	bitsLocation        = 3
+	bitsSlot            = 4
+	bitsGrid            = 4
+	bitsStorage         = 3
+	bitsEarClass        = 3
+	bitsEarLevel        = 7
+	bitsNameChar        = 7
+	bitsNumSocketed     = 3
+	bitsFingerprint     = 32
+	bitsItemLevel       = 7
+	bitsQuality         = 4
+	bitsPicture         = 3
+	bitsClassSpecific   = 11
+	bitsLowQuality      = 3
+	bitsAffix           = 11
+	bitsRareName        = 8
+	bitsSetItem         = 12
+	bitsUnique          = 12
+	bitsRuneword        = 12
+	bitsRunewordPadding = 4
+	bitsTome            = 5
+	bitsTimestamp       = 1
+	bitsDefense         = 11
+	bitsMaxDurability   = 8
+	bitsDurability      = 9
+	bitsQuantity        = 9
+	bitsNumSockets      = 4
+	bitsSetLists        = 5
+	bitsStatID          = 9
+	bitsSkillTab        = 3
+	bitsSkillLevel      = 6
+	bitsCharges         = 8
+)
+
+const (
+	itemCodeLength   = 4
+	maxNameLength    = 15
+	statListEnd      = 0x1ff
+	rareAffixSlots   = 3
+	earItemCode      = "ear"
+	tomeOfTownPortal = "tbk"
+	tomeOfIdentify   = "ibk"
+	defenseStatName  = "armorclass"
+)
+
+// the descfunc values of ItemStatCost.txt with more than one value, which are saved in the param
+// of the stat
+const (
+	descFnClassSkillTab = 14
+	descFnSkillOnEvent  = 15
+	descFnCharges       = 24
+	descFnClassSkill    = 27
+)
+
+// itemFlags are the flags of a saved item
+type itemFlags uint32
+
+func (flags itemFlags) has(bit int) bool {
+	return flags&(1<<bit) != 0
+}
+
+func (flags itemFlags) with(bit int, set bool) itemFlags {
+	if set {
+		flags |= 1 << bit
+	}
+
+	return flags
+}
+
+var errTruncatedItem = errors.New("the item data is truncated")
+
+// Ear is the ear of a player killed by another player
+type Ear struct {
+	Hero  d2enum.Hero
+	Level int
+	Name  string
+}
+
+// savedHeroes returns the heroes in the order of the classes of saved items
+func savedHeroes() []d2enum.Hero {
+	return []d2enum.Hero{
+		d2enum.HeroAmazon,
+		d2enum.HeroSorceress,
+		d2enum.HeroNecromancer,
+		d2enum.HeroPaladin,
+		d2enum.HeroBarbarian,
+		d2enum.HeroDruid,
+		d2enum.HeroAssassin,
+	}
+}
+
+// savedClassTokens returns the charclass tokens of Skills.txt in the order of the classes of
+// saved items
+func savedClassTokens() []string {
+	return []string{"ama", "sor", "nec", "pal", "bar", "dru", "ass"}
+}
+
+// savedSlots returns the equipped slots in the order of the body locations of saved items
+func savedSlots() []d2enum.EquippedSlot {
+	return []d2enum.EquippedSlot{
+		d2enum.EquippedSlotNone,
+		d2enum.EquippedSlotHead,
+		d2enum.EquippedSlotNeck,
+		d2enum.EquippedSlotTorso,
+		d2enum.EquippedSlotRightArm,
+		d2enum.EquippedSlotLeftArm,
+		d2enum.EquippedSlotRightHand,
+		d2enum.EquippedSlotLeftHand,
+		d2enum.EquippedSlotBelt,
+		d2enum.EquippedSlotLegs,
+		d2enum.EquippedSlotGloves,
+		d2enum.EquippedSlotRightArmSwap,
+		d2enum.EquippedSlotLeftArmSwap,
+	}
+}
+
+// pairedStats returns the stats which are saved right after another stat, without a stat id of
+// their own
+func pairedStats() map[string][]string {
+	return map[string][]string{
+		"item_maxdamage_percent": {"item_mindamage_percent"},
+		"firemindam":             {"firemaxdam"},
+		"lightmindam":            {"lightmaxdam"},
+		"magicmindam":            {"magicmaxdam"},
+		"coldmindam":             {"coldmaxdam", "coldlength"},
+		"poisonmindam":           {"poisonmaxdam", "poisonlength"},
+	}
+}
+
+func indexOf(count int, matches func(idx int) bool) int {
+	for idx := 0; idx < count; idx++ {
+		if matches(idx) {
+			return idx
+		}
+	}
+
+	return 0
+}
+
+// itemWriter writes the bits of saved items, least significant bit first
+type itemWriter struct {
+	*d2datautils.StreamWriter
+	bits int
+}
+
+func (w *itemWriter) push(value, bits int) {
+	w.PushBits32(uint32(value), bits)
+	w.bits += bits
+}
+
+func (w *itemWriter) pushBool(value bool) {
+	w.PushBit(value)
+	w.bits++
+}
+
+func (w *itemWriter) pushName(name string) {
+	if len(name) > maxNameLength {
+		name = name[:maxNameLength]
+	}
+
+	for idx := 0; idx < len(name); idx++ {
+		w.push(int(name[idx]), bitsNameChar)
+	}
+
+	w.push(0, bitsNameChar)
+}
+
+func (w *itemWriter) align() {
+	if remaining := w.bits % bitsPerByte; remaining != 0 {
+		w.push(0, bitsPerByte-remaining)
+	}
+}
+
+// itemReader reads the bits of saved items, reading past the end of the data fails
+type itemReader struct {
+	*d2datautils.BitMuncher
+	size int
+	err  error
+}
+
+func (r *itemReader) read(bits int) int {
+	if r.err != nil {
+		return 0
+	}
+
+	if r.Offset()+bits > r.size {
+		r.err = errTruncatedItem
+		return 0
+	}
+
+	return int(r.GetBits(bits))
+}
+
+func (r *itemReader) readBool() bool {
+	return r.read(1) == 1
+}
+
+func (r *itemReader) readName() string {
+	var name strings.Builder
+
+	for idx := 0; idx <= maxNameLength; idx++ {
+		char := r.read(bitsNameChar)
+		if char == 0 {
+			break
+		}
+
+		name.WriteByte(byte(char))
+	}
+
+	return name.String()
+}
+
+func (r *itemReader) align() {
+	if remaining := r.Offset() % bitsPerByte; remaining != 0 {
+		r.read(bitsPerByte - remaining)
+	}
+}
+
+// Serialize returns the item, and the items inserted into its sockets, in the format of the
+// items of the original saves
+func (i *Item) Serialize() []byte {
+	w := &itemWriter{StreamWriter: d2datautils.CreateStreamWriter()}
+
+	i.serialize(w)
+
+	return w.GetBytes()
+}
+
+func (i *Item) serialize(w *itemWriter) {
+	record := i.CommonRecord()
+	simple := i.Ear == nil && record.CompactSave
+
+	flags := itemFlags(0).
+		with(itemFlagIdentified, i.attributes.identitified).
+		with(itemFlagSocketed, i.attributes.numSockets > 0).
+		with(itemFlagEar, i.Ear != nil).
+		with(itemFlagSimple, simple).
+		with(itemFlagEthereal, i.attributes.ethereal).
+		with(itemFlagPersonalized, i.attributes.personalization != "").
+		with(itemFlagRuneword, i.RunewordRecord() != nil)
+
+	for idx := range itemIdentifier {
+		w.push(int(itemIdentifier[idx]), bitsPerByte)
+	}
+
+	w.push(int(flags), bitsFlags)
+
+	slots := savedSlots()
+
+	w.push(itemVersion, bitsVersion)
+	w.push(int(i.Location), bitsLocation)
+	w.push(indexOf(len(slots), func(idx int) bool { return slots[idx] == i.slotType }), bitsSlot)
+	w.push(i.GridX, bitsGrid)
+	w.push(i.GridY, bitsGrid)
+	w.push(int(i.Storage), bitsStorage)
+
+	if i.Ear != nil {
+		heroes := savedHeroes()
+
+		w.push(indexOf(len(heroes), func(idx int) bool { return heroes[idx] == i.Ear.Hero }), bitsEarClass)
+		w.push(i.Ear.Level, bitsEarLevel)
+		w.pushName(i.Ear.Name)
+		w.align()
+
+		return
+	}
+
+	code := fmt.Sprintf("%-*s", itemCodeLength, i.CommonCode)
+	for idx := 0; idx < itemCodeLength; idx++ {
+		w.push(int(code[idx]), bitsPerByte)
+	}
+
+	w.push(len(i.sockets), bitsNumSocketed)
+
+	if !simple {
+		i.serializeExtended(w, record)
+	}
+
+	w.align()
+
+	for _, socketed := range i.sockets {
+		socketed.serialize(w)
+	}
+}
+
+func (i *Item) serializeExtended(w *itemWriter, record *d2records.ItemCommonRecord) {
+	quality := i.Quality()
+
+	w.push(int(uint32(i.Seed)), bitsFingerprint)
+	w.push(d2math.MinInt(i.attributes.baseItemLevel, 1<<bitsItemLevel-1), bitsItemLevel)
+	w.push(int(quality), bitsQuality)
+	w.pushBool(false) // the item has the inventory picture of its record
+	w.pushBool(false) // the item is not class specific
+
+	i.serializeQuality(w, quality)
+
+	if runeword := i.RunewordRecord(); runeword != nil {
+		w.push(runeword.Index, bitsRuneword)
+		w.push(0, bitsRunewordPadding)
+	}
+
+	if i.attributes.personalization != "" {
+		w.pushName(i.attributes.personalization)
+	}
+
+	if i.CommonCode == tomeOfTownPortal || i.CommonCode == tomeOfIdentify {
+		w.push(0, bitsTome)
+	}
+
+	w.push(0, bitsTimestamp)
+
+	if record.MaxAC > 0 {
+		w.push(d2math.ClampInt(i.attributes.defense+i.defenseSaveAdd(), 0, 1<<bitsDefense-1), bitsDefense)
+	}
+
+	if !record.NoDurability {
+		w.push(d2math.ClampInt(i.attributes.durability.max, 0, 1<<bitsMaxDurability-1), bitsMaxDurability)
+
+		if i.attributes.durability.max > 0 {
+			w.push(d2math.ClampInt(i.attributes.currentDurability, 0, 1<<bitsDurability-1), bitsDurability)
+		}
+	}
+
+	if record.Stackable {
+		w.push(d2math.ClampInt(i.attributes.currentStackSize, 0, 1<<bitsQuantity-1), bitsQuantity)
+	}
+
+	if i.attributes.numSockets > 0 {
+		w.push(i.attributes.numSockets, bitsNumSockets)
+	}
+
+	if quality == d2enum.Set {
+		w.push(0, bitsSetLists) // the set bonuses are given by the set records
+	}
+
+	i.serializeStats(w, i.savedStats())
+
+	if i.RunewordRecord() != nil {
+		i.serializeStats(w, i.poolStats(PropertyPoolRuneword))
+	}
+}
+
+func (i *Item) serializeQuality(w *itemWriter, quality d2enum.ItemQuality) {
+	switch quality {
+	case d2enum.LowQuality, d2enum.Superior:
+		w.push(0, bitsLowQuality)
+	case d2enum.Magic:
+		w.push(affixID(i.PrefixRecords(), 0), bitsAffix)
+		w.push(affixID(i.SuffixRecords(), 0), bitsAffix)
+	case d2enum.Set:
+		w.push(i.SetItemRecord().Index, bitsSetItem)
+	case d2enum.Unique:
+		w.push(i.UniqueRecord().Index, bitsUnique)
+	case d2enum.Rare, d2enum.Crafted:
+		prefix, suffix := i.rareNameIndices()
+
+		w.push(prefix, bitsRareName)
+		w.push(suffix, bitsRareName)
+
+		prefixes, suffixes := i.PrefixRecords(), i.SuffixRecords()
+
+		for idx := 0; idx < rareAffixSlots; idx++ {
+			for _, affixes := range [][]*d2records.ItemAffixCommonRecord{prefixes, suffixes} {
+				w.pushBool(idx < len(affixes))
+
+				if idx < len(affixes) {
+					w.push(affixID(affixes, idx), bitsAffix)
+				}
+			}
+		}
+	}
+}
+
+// affixID returns the id of an affix of saved items, which is zero for no affix
+func affixID(affixes []*d2records.ItemAffixCommonRecord, idx int) int {
+	if idx >= len(affixes) || affixes[idx] == nil {
+		return 0
+	}
+
+	return affixes[idx].Index + 1
+}
+
+// rareNameIndices returns the indices of the rare prefix and the rare suffix of the name of a rare
+// item, which are picked like generateName picks them
+func (i *Item) rareNameIndices() (prefix, suffix int) {
+	numPrefix, numSuffix := len(i.factory.asset.Records.Item.Rare.Prefix), len(i.factory.asset.Records.Item.Rare.Suffix)
+	if numPrefix == 0 || numSuffix == 0 {
+		return 0, 0
+	}
+
+	// nolint:gosec // not concerned with crypto-strong randomness
+	names := rand.New(rand.NewSource(i.Seed))
+
+	return names.Intn(numPrefix), names.Intn(numSuffix)
+}
+
+func (i *Item) defenseSaveAdd() int {
+	if record := i.factory.asset.Records.Item.Stats[defenseStatName]; record != nil {
+		return record.SaveAdd
+	}
+
+	return 0
+}
+
+// savedStats returns the stats saved in the stat list of the item. The stats given by the items
+// inserted into the sockets are saved with these items, the stats of the runeword are saved
+// in a stat list of their own.
+func (i *Item) savedStats() []d2stats.Stat {
+	stats := make([]d2stats.Stat, 0)
+
+	for pool := range i.properties {
+		if pool != PropertyPoolSocket && pool != PropertyPoolRuneword {
+			stats = append(stats, i.poolStats(pool)...)
+		}
+	}
+
+	return stats
+}
+
+func (i *Item) poolStats(pool PropertyPool) []d2stats.Stat {
+	stats := make([]d2stats.Stat, 0)
+
+	for _, property := range i.properties[pool] {
+		if property != nil {
+			stats = append(stats, property.stats...)
+		}
+	}
+
+	return stats
+}
+
+func (i *Item) serializeStats(w *itemWriter, stats []d2stats.Stat) {
+	records := i.factory.asset.Records.Item.Stats
+	stats = i.factory.stat.NewStatList(stats...).ReduceStats().Stats()
+
+	saved := make([]d2stats.Stat, 0, len(stats))
+	byName := make(map[string]d2stats.Stat)
+
+	for _, stat := range stats {
+		if record := records[stat.Name()]; record != nil && record.SaveBits > 0 && len(stat.Values()) > 0 {
+			saved = append(saved, stat)
+			byName[stat.Name()] = stat
+		}
+	}
+
+	sort.SliceStable(saved, func(a, b int) bool {
+		return records[saved[a].Name()].Index < records[saved[b].Name()].Index
+	})
+
+	paired := pairedStats()
+	leaders := make(map[string]string)
+
+	for leader, followers := range paired {
+		for _, follower := range followers {
+			leaders[follower] = leader
+		}
+	}
+
+	written := make(map[string]bool)
+
+	for _, stat := range saved {
+		name := stat.Name()
+		if leader, found := leaders[name]; found {
+			name = leader
+		}
+
+		followers, isPaired := paired[name]
+
+		switch {
+		case !isPaired:
+			value, param := savedStatValues(records[name], stat.Values())
+
+			w.push(records[name].Index, bitsStatID)
+			writeStat(w, records[name], value, param)
+		case !written[name] && records[name] != nil:
+			written[name] = true
+
+			w.push(records[name].Index, bitsStatID)
+
+			for _, statName := range append([]string{name}, followers...) {
+				value := 0
+				if pairedStat := byName[statName]; pairedStat != nil {
+					value = pairedStat.Values()[0].Int()
+				}
+
+				if records[statName] != nil {
+					writeStat(w, records[statName], value, 0)
+				}
+			}
+		}
+	}
+
+	w.push(statListEnd, bitsStatID)
+}
+
+func writeStat(w *itemWriter, record *d2records.ItemStatCostRecord, value, param int) {
+	w.push(param, record.SaveParamBits)
+	w.push(d2math.ClampInt(value+record.SaveAdd, 0, 1<<record.SaveBits-1), record.SaveBits)
+}
+
+// savedStatValues returns the value and the param a stat is saved with
+func savedStatValues(record *d2records.ItemStatCostRecord, values []d2stats.StatValue) (value, param int) {
+	ints := make([]int, len(values))
+	for idx := range values {
+		ints[idx] = values[idx].Int()
+	}
+
+	// nolint:gomnd // the indices of the values of the descfunc
+	switch {
+	case record.DescFnID == descFnClassSkillTab && len(ints) > 2:
+		return ints[0], ints[2] | ints[1]<<bitsSkillTab
+	case record.DescFnID == descFnSkillOnEvent && len(ints) > 2:
+		return ints[0], ints[1] | ints[2]<<bitsSkillLevel
+	case record.DescFnID == descFnCharges && len(ints) > 3:
+		return ints[2] | ints[3]<<bitsCharges, ints[0] | ints[1]<<bitsSkillLevel
+	case len(ints) > 1:
+		return ints[0], ints[1]
+	default:
+		return ints[0], 0
+	}
+}
+
+// statValues returns the values of a stat saved with a value and a param
+func (f *ItemFactory) statValues(record *d2records.ItemStatCostRecord, value, param int) []float64 {
+	skillLevelMask, chargesMask := 1<<bitsSkillLevel-1, 1<<bitsCharges-1
+
+	switch {
+	case record.DescFnID == descFnClassSkillTab:
+		return []float64{float64(value), float64(param >> bitsSkillTab), float64(param & (1<<bitsSkillTab - 1))}
+	case record.DescFnID == descFnSkillOnEvent:
+		return []float64{float64(value), float64(param & skillLevelMask), float64(param >> bitsSkillLevel)}
+	case record.DescFnID == descFnCharges:
+		return []float64{float64(param & skillLevelMask), float64(param >> bitsSkillLevel),
+			float64(value & chargesMask), float64(value >> bitsCharges)}
+	case record.DescFnID == descFnClassSkill:
+		return []float64{float64(value), float64(param), f.skillClass(param)}
+	case record.SaveParamBits > 0:
+		return []float64{float64(value), float64(param)}
+	default:
+		return []float64{float64(value)}
+	}
+}
+
+// skillClass returns the class of a skill in the order of the classes of saved items
+func (f *ItemFactory) skillClass(skillID int) float64 {
+	record := f.asset.Records.Skill.Details[skillID]
+	if record == nil {
+		return invalidHeroIndex
+	}
+
+	for idx, token := range savedClassTokens() {
+		if record.Charclass == token {
+			return float64(idx)
+		}
+	}
+
+	return invalidHeroIndex
+}
+
+// Deserialize creates an item, and the items inserted into its sockets, from the data of an item
+// of the original saves
+func (f *ItemFactory) Deserialize(data []byte) (*Item, error) {
+	r := &itemReader{BitMuncher: d2datautils.CreateBitMuncher(data, 0), size: len(data) * bitsPerByte}
+
+	return f.deserialize(r)
+}
+
+// DeserializeList creates the given number of items, each with the items inserted into its
+// sockets, from the start of an item list of the original saves. It returns the items and the
+// number of bytes they were read from.
+func (f *ItemFactory) DeserializeList(data []byte, count int) (items []*Item, size int, err error) {
+	r := &itemReader{BitMuncher: d2datautils.CreateBitMuncher(data, 0), size: len(data) * bitsPerByte}
+	items = make([]*Item, 0, count)
+
+	for idx := 0; idx < count; idx++ {
+		item, err := f.deserialize(r)
+		if err != nil {
+			return nil, 0, fmt.Errorf("item %d: %v", idx, err)
+		}
+
+		items = append(items, item)
+	}
+
+	return items, r.Offset() / bitsPerByte, nil
+}
+
+func (f *ItemFactory) deserialize(r *itemReader) (*Item, error) {
+	identifier := []byte{byte(r.read(bitsPerByte)), byte(r.read(bitsPerByte))}
+	if r.err == nil && string(identifier) != itemIdentifier {
+		return nil, fmt.Errorf("unexpected item identifier %q", identifier)
+	}
+
+	flags := itemFlags(r.read(bitsFlags))
+
+	r.read(bitsVersion)
+
+	location := d2enum.ItemLocation(r.read(bitsLocation))
+	slot, gridX, gridY := r.read(bitsSlot), r.read(bitsGrid), r.read(bitsGrid)
+	storage := d2enum.ItemStorage(r.read(bitsStorage))
+
+	var (
+		item *Item
+		err  error
+	)
+
+	if flags.has(itemFlagEar) {
+		item, err = f.deserializeEar(r)
+	} else {
+		item, err = f.deserializeItem(r, flags)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if slots := savedSlots(); slot < len(slots) {
+		item.slotType = slots[slot]
+	}
+
+	item.Location, item.Storage = location, storage
+	item.GridX, item.GridY = gridX, gridY
+	item.attributes.identitified = flags.has(itemFlagIdentified)
+	item.attributes.ethereal = flags.has(itemFlagEthereal)
+
+	return item, nil
+}
+
+func (f *ItemFactory) deserializeEar(r *itemReader) (*Item, error) {
+	hero, level := r.read(bitsEarClass), r.read(bitsEarLevel)
+	name := r.readName()
+
+	r.align()
+
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	item, err := f.NewItem(earItemCode)
+	if err != nil {
+		return nil, err
+	}
+
+	item.Ear = &Ear{Level: level, Name: name}
+
+	if heroes := savedHeroes(); hero < len(heroes) {
+		item.Ear.Hero = heroes[hero]
+	}
+
+	return item, nil
+}
+
+func (f *ItemFactory) deserializeItem(r *itemReader, flags itemFlags) (*Item, error) {
+	code := make([]byte, itemCodeLength)
+	for idx := range code {
+		code[idx] = byte(r.read(bitsPerByte))
+	}
+
+	numSocketed := r.read(bitsNumSocketed)
+
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	item := &Item{factory: f, CommonCode: strings.TrimSpace(string(code))}
+
+	record := item.CommonRecord()
+	if record == nil {
+		return nil, fmt.Errorf("unknown item code %q", item.CommonCode)
+	}
+
+	if flags.has(itemFlagSimple) {
+		item.init()
+	} else if err := item.deserializeExtended(r, record, flags); err != nil {
+		return nil, err
+	}
+
+	r.align()
+
+	for idx := 0; idx < numSocketed; idx++ {
+		socketed, err := f.deserialize(r)
+		if err != nil {
+			return nil, err
+		}
+
+		item.sockets = append(item.sockets, socketed)
+	}
+
+	if len(item.sockets) > 0 {
+		item.generateProperties(PropertyPoolSocket)
+		item.updateStatList()
+	}
+
+	return item, r.err
+}
+
+// deserializeExtended reads the fields of an item which is not a simple item. The item is created
+// from its codes, then the attributes and the properties it was saved with replace the rolled
+// ones.
+func (i *Item) deserializeExtended(r *itemReader, record *d2records.ItemCommonRecord, flags itemFlags) error {
+	i.SetSeed(int64(r.read(bitsFingerprint)))
+
+	level := r.read(bitsItemLevel)
+	quality := d2enum.ItemQuality(r.read(bitsQuality))
+
+	if r.readBool() {
+		r.read(bitsPicture)
+	}
+
+	if r.readBool() {
+		r.read(bitsClassSpecific)
+	}
+
+	i.deserializeQuality(r, quality)
+
+	if flags.has(itemFlagRuneword) {
+		index := r.read(bitsRuneword)
+		r.read(bitsRunewordPadding)
+
+		for name, runeword := range i.factory.asset.Records.Item.Runewords {
+			if runeword.Index == index {
+				i.RunewordCode = name
+			}
+		}
+	}
+
+	personalization := ""
+	if flags.has(itemFlagPersonalized) {
+		personalization = r.readName()
+	}
+
+	if i.CommonCode == tomeOfTownPortal || i.CommonCode == tomeOfIdentify {
+		r.read(bitsTome)
+	}
+
+	r.read(bitsTimestamp)
+
+	if r.err != nil {
+		return r.err
+	}
+
+	i.init()
+
+	i.attributes.baseItemLevel = level
+	i.attributes.crafted = quality == d2enum.Crafted
+	i.attributes.personalization = personalization
+
+	i.deserializeAttributes(r, record, flags)
+
+	return i.deserializeProperties(r, quality)
+}
+
+func (i *Item) deserializeQuality(r *itemReader, quality d2enum.ItemQuality) {
+	magic := i.factory.asset.Records.Item.Magic
+
+	switch quality {
+	case d2enum.LowQuality, d2enum.Superior:
+		r.read(bitsLowQuality)
+	case d2enum.Magic:
+		i.PrefixCodes = affixCodes(magic.Prefix, r.read(bitsAffix))
+		i.SuffixCodes = affixCodes(magic.Suffix, r.read(bitsAffix))
+	case d2enum.Set:
+		index := r.read(bitsSetItem)
+
+		for name, setItem := range i.factory.asset.Records.Item.SetItems {
+			if setItem.Index == index {
+				i.SetItemCode, i.SetCode = name, setItem.SetKey
+			}
+		}
+	case d2enum.Unique:
+		index := r.read(bitsUnique)
+
+		for name, unique := range i.factory.asset.Records.Item.Unique {
+			if unique.Index == index {
+				i.UniqueCode = name
+			}
+		}
+	case d2enum.Rare, d2enum.Crafted:
+		// the name of the item is picked again from its fingerprint
+		r.read(bitsRareName)
+		r.read(bitsRareName)
+
+		for idx := 0; idx < rareAffixSlots; idx++ {
+			if r.readBool() {
+				i.PrefixCodes = append(i.PrefixCodes, affixCodes(magic.Prefix, r.read(bitsAffix))...)
+			}
+
+			if r.readBool() {
+				i.SuffixCodes = append(i.SuffixCodes, affixCodes(magic.Suffix, r.read(bitsAffix))...)
+			}
+		}
+	}
+}
+
+// affixCodes returns the code of the affix of an affix id of saved items, or no code for no
+// affix or an unknown affix
+func affixCodes(affixes map[string]*d2records.ItemAffixCommonRecord, id int) []string {
+	for name, affix := range affixes {
+		if id > 0 && affix.Index == id-1 {
+			return []string{name}
+		}
+	}
+
+	return nil
+}
+
+func (i *Item) deserializeAttributes(r *itemReader, record *d2records.ItemCommonRecord, flags itemFlags) {
+	if record.MaxAC > 0 {
+		i.attributes.defense = r.read(bitsDefense) - i.defenseSaveAdd()
+	}
+
+	if !record.NoDurability {
+		i.attributes.durability.max = r.read(bitsMaxDurability)
+		i.attributes.currentDurability = 0
+
+		if i.attributes.durability.max > 0 {
+			i.attributes.currentDurability = r.read(bitsDurability)
+		}
+	}
+
+	if record.Stackable {
+		i.attributes.currentStackSize = r.read(bitsQuantity)
+	}
+
+	if flags.has(itemFlagSocketed) {
+		i.attributes.numSockets = r.read(bitsNumSockets)
+	}
+}
+
+func (i *Item) deserializeProperties(r *itemReader, quality d2enum.ItemQuality) error {
+	numSetLists := 0
+
+	if quality == d2enum.Set {
+		for setLists := r.read(bitsSetLists); setLists > 0; setLists >>= 1 {
+			numSetLists += setLists & 1
+		}
+	}
+
+	saved := i.factory.deserializeStats(r)
+
+	// the set bonuses are given by the set records
+	for idx := 0; idx < numSetLists; idx++ {
+		i.factory.deserializeStats(r)
+	}
+
+	i.properties = map[PropertyPool][]*Property{PropertyPoolSaved: {i.factory.savedProperty(saved)}}
+
+	if i.RunewordCode != "" {
+		i.properties[PropertyPoolRuneword] = []*Property{i.factory.savedProperty(i.factory.deserializeStats(r))}
+	}
+
+	i.updateStatList()
+
+	return r.err
+}
+
+// savedProperty returns a property giving the stats an item was saved with
+func (f *ItemFactory) savedProperty(stats []d2stats.Stat) *Property {
+	return &Property{factory: f, stats: stats, PropertyType: PropertyComputeStats}
+}
+
+func (f *ItemFactory) deserializeStats(r *itemReader) []d2stats.Stat {
+	records := make(map[int]*d2records.ItemStatCostRecord)
+	for _, record := range f.asset.Records.Item.Stats {
+		records[record.Index] = record
+	}
+
+	paired := pairedStats()
+	stats := make([]d2stats.Stat, 0)
+
+	for r.err == nil {
+		id := r.read(bitsStatID)
+		if id == statListEnd || r.err != nil {
+			break
+		}
+
+		record := records[id]
+		if record == nil {
+			r.err = fmt.Errorf("unknown item stat %d", id)
+			break
+		}
+
+		param, value := readStat(r, record)
+
+		followers, isPaired := paired[record.Name]
+		if !isPaired {
+			stats = append(stats, f.stat.NewStat(record.Name, f.statValues(record, value, param)...))
+			continue
+		}
+
+		// the paired stats which are not given by the item are saved as zero
+		for _, name := range append([]string{record.Name}, followers...) {
+			if pairedRecord := f.asset.Records.Item.Stats[name]; pairedRecord != nil {
+				if name != record.Name {
+					_, value = readStat(r, pairedRecord)
+				}
+
+				if value != 0 {
+					stats = append(stats, f.stat.NewStat(name, float64(value)))
+				}
+			}
+		}
+	}
+
+	return stats
+}
+
+func readStat(r *itemReader, record *d2records.ItemStatCostRecord) (param, value int) {
+	param = r.read(record.SaveParamBits)
+	value = r.read(record.SaveBits) - record.SaveAdd
+
+	return param, value
+}
@@ -0,0 +1,162 @@
+package diablo2item
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+)
+
+func testItemStats(item *Item) map[string]int {
+	stats := make(map[string]int)
+	for _, stat := range item.StatList().Stats() {
+		stats[stat.Name()] = stat.Values()[0].Int()
+	}
+
+	return stats
+}
+
+func TestItem_Serialize(t *testing.T) {
+	factory := newTestItemFactory(t)
+	records := factory.asset.Records
+
+	axeRecord := &d2records.ItemCommonRecord{Code: "hax", Type: "axe", Level: 3, Durability: 28, GemSockets: 2}
+	helmRecord := &d2records.ItemCommonRecord{Code: "cap", Type: "helm", Level: 1, MinAC: 3, MaxAC: 5, Durability: 12}
+	el := &d2records.ItemCommonRecord{Code: "r01", Type: "rune", NoDurability: true, CompactSave: true}
+	eld := &d2records.ItemCommonRecord{Code: "r02", Type: "rune", NoDurability: true, CompactSave: true}
+	earRecord := &d2records.ItemCommonRecord{Code: "ear", Type: "play", NoDurability: true}
+
+	records.Item.All = map[string]*d2records.ItemCommonRecord{
+		axeRecord.Code: axeRecord, helmRecord.Code: helmRecord, el.Code: el, eld.Code: eld, earRecord.Code: earRecord,
+	}
+	records.Item.Types = map[string]*d2records.ItemTypeRecord{
+		"axe":  {Code: "axe", MaxSock1: 2},
+		"helm": {Code: "helm"},
+		"rune": {Code: "rune", Gem: true},
+		"play": {Code: "play"},
+	}
+	records.Item.Equivalency = d2records.ItemEquivalenceMap{"weap": {axeRecord}, "rune": {el, eld}}
+	records.Item.Gems = d2records.Gems{
+		"El Rune":  {Name: "El Rune", Code: "r01", WeaponMod1Code: "str", WeaponMod1Min: 1, WeaponMod1Max: 1},
+		"Eld Rune": {Name: "Eld Rune", Code: "r02", WeaponMod1Code: "str", WeaponMod1Min: 2, WeaponMod1Max: 2},
+	}
+	records.Item.Runewords = d2records.Runewords{
+		"Runeword1": {
+			Index:    1,
+			Name:     "Runeword1",
+			Complete: true,
+			ItemTypes: struct {
+				Include []string
+				Exclude []string
+			}{Include: []string{"weap"}},
+			Runes:      []string{"r01", "r02"},
+			Properties: []*d2records.RunewordProperty{{Code: "dmg%", Min: 20, Max: 20}},
+		},
+	}
+	records.Item.Magic.Prefix = d2records.MagicPrefix{
+		"Sturdy": {Index: 3, Name: "Sturdy", Modifiers: []*d2records.ItemAffixCommonModifier{{Code: "ac%", Min: 10,
+			Max: 20}}},
+	}
+	records.Item.Magic.Suffix = d2records.MagicSuffix{
+		"of Strength": {Index: 7, Name: "of Strength", Modifiers: []*d2records.ItemAffixCommonModifier{{Code: "str",
+			Min: 1, Max: 5}}},
+	}
+	records.Item.Stats = map[string]*d2records.ItemStatCostRecord{
+		"strength":               {Name: "strength", Index: 0, DescFnID: 1, SaveBits: 8, SaveAdd: 32},
+		"armorclass":             {Name: "armorclass", Index: 31, DescFnID: 1, SaveBits: 11, SaveAdd: 10},
+		"item_armor_percent":     {Name: "item_armor_percent", Index: 16, DescFnID: 4, SaveBits: 9},
+		"item_maxdamage_percent": {Name: "item_maxdamage_percent", Index: 17, DescFnID: 4, SaveBits: 9},
+		"item_mindamage_percent": {Name: "item_mindamage_percent", Index: 18, DescFnID: 4, SaveBits: 9},
+	}
+	records.Properties = map[string]*d2records.PropertyRecord{
+		"str": {Code: "str", Stats: [7]*d2records.PropertyStatRecord{{FunctionID: 1, StatCode: "strength"}}},
+		"ac%": {Code: "ac%", Stats: [7]*d2records.PropertyStatRecord{{FunctionID: 2, StatCode: "item_armor_percent"}}},
+		"dmg%": {Code: "dmg%", Stats: [7]*d2records.PropertyStatRecord{
+			{FunctionID: 1, StatCode: "item_maxdamage_percent"},
+			{FunctionID: 3, StatCode: "item_mindamage_percent"},
+		}},
+	}
+
+	roundTrip := func(item *Item) *Item {
+		data := item.Serialize()
+
+		loaded, err := factory.Deserialize(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if again := loaded.Serialize(); !reflect.DeepEqual(again, data) {
+			t.Errorf("expected the loaded %s to be saved the same, got %v and %v", item.CommonCode, data, again)
+		}
+
+		if _, err := factory.Deserialize(data[:len(data)-1]); err == nil {
+			t.Errorf("expected an error loading the truncated %s", item.CommonCode)
+		}
+
+		return loaded
+	}
+
+	helm, err := factory.NewItem("cap", "Sturdy", "of Strength")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	helm.SetSeed(12345)
+	helm.Identify().SetDurability(7)
+	helm.Location, helm.Storage, helm.GridX, helm.GridY = d2enum.ItemLocationStored, d2enum.ItemStorageStash, 3, 2
+	helm.attributes.personalization = "Player"
+
+	loaded := roundTrip(helm)
+
+	if !reflect.DeepEqual(loaded.Codes(), helm.Codes()) || loaded.Seed != helm.Seed ||
+		!reflect.DeepEqual(testItemStats(loaded), testItemStats(helm)) {
+		t.Errorf("expected the magic helm to be loaded, got %v with %v", loaded.Codes(), testItemStats(loaded))
+	}
+
+	if current, max := loaded.Durability(); current != 7 || max != 12 || loaded.attributes.defense !=
+		helm.attributes.defense || loaded.Storage != d2enum.ItemStorageStash || loaded.GridX != 3 ||
+		loaded.attributes.personalization != "Player" || !loaded.attributes.identitified {
+		t.Errorf("expected the attributes of the helm to be loaded, got %+v", loaded.attributes)
+	}
+
+	axe, err := factory.NewItem("hax")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	axe.SetNumSockets(2)
+
+	for _, code := range []string{"r01", "r02"} {
+		if err := axe.Insert(testCubeItems(t, factory, code)[0]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	axe.Location = d2enum.ItemLocationEquipped
+	axe.SetSlotType(d2enum.EquippedSlotRightArm)
+
+	loaded = roundTrip(axe)
+
+	if loaded.RunewordCode != "Runeword1" || len(loaded.Sockets()) != 2 || loaded.NumSockets() != 2 ||
+		loaded.Sockets()[1].CommonCode != "r02" || loaded.SlotType() != d2enum.EquippedSlotRightArm {
+		t.Errorf("expected the runeword axe to be loaded, got %q with %d sockets", loaded.RunewordCode,
+			len(loaded.Sockets()))
+	}
+
+	if stats := testItemStats(loaded); !reflect.DeepEqual(stats, testItemStats(axe)) || stats["strength"] != 3 ||
+		stats["item_mindamage_percent"] != 20 {
+		t.Errorf("expected the stats of the runes and the runeword, got %v", stats)
+	}
+
+	ear, err := factory.NewItem("ear")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ear.Ear = &Ear{Hero: d2enum.HeroDruid, Level: 42, Name: "Killed"}
+
+	if loaded := roundTrip(ear); !reflect.DeepEqual(loaded.Ear, ear.Ear) {
+		t.Errorf("expected the ear to be loaded, got %+v", loaded.Ear)
+	}
+}
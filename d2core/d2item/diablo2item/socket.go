@@ -0,0 +1,268 @@
+package diablo2item
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+)
+
+// the GemApplyType values of Weapons.txt, Armor.txt and Misc.txt, which select the mods of
+// Gems.txt applied by the gems and runes inserted into an item
+const (
+	gemApplyWeapon = iota
+	gemApplyArmor
+	gemApplyShield
+)
+
+// the item levels from which the MaxSock25 and MaxSock40 columns of ItemTypes.txt apply
+const (
+	socketLevelMedium = 26
+	socketLevelHigh   = 41
+)
+
+// RunewordRecord returns the RuneRecord of the runeword of the item
+func (i *Item) RunewordRecord() *d2records.RuneRecord {
+	return i.factory.asset.Records.Item.Runewords[i.RunewordCode]
+}
+
+// GemRecord returns the GemRecord of a gem, rune or jewel, or nil if the item is not in Gems.txt
+func (i *Item) GemRecord() *d2records.GemRecord {
+	for _, record := range i.factory.asset.Records.Item.Gems {
+		if record.Code == i.CommonCode {
+			return record
+		}
+	}
+
+	return nil
+}
+
+// IsSocketable returns true if the item can be inserted into the sockets of another item
+func (i *Item) IsSocketable() bool {
+	if i.GemRecord() != nil {
+		return true
+	}
+
+	typeRecord := i.TypeRecord()
+
+	return typeRecord != nil && typeRecord.Gem
+}
+
+// NumSockets returns the number of sockets of the item
+func (i *Item) NumSockets() int {
+	return i.attributes.numSockets
+}
+
+// Sockets returns the items inserted into the sockets of the item
+func (i *Item) Sockets() []*Item {
+	return i.sockets
+}
+
+// MaxSockets returns the maximum number of sockets of the item. The sockets of the item record
+// are limited by the sockets of its item type for the item level.
+func (i *Item) MaxSockets() int {
+	maxSockets := i.CommonRecord().GemSockets
+
+	typeRecord := i.TypeRecord()
+	if typeRecord == nil {
+		return maxSockets
+	}
+
+	typeSockets := typeRecord.MaxSock1
+
+	switch level := i.ItemLevel(); {
+	case level >= socketLevelHigh:
+		typeSockets = typeRecord.MaxSock40
+	case level >= socketLevelMedium:
+		typeSockets = typeRecord.MaxSock25
+	}
+
+	if typeSockets > 0 {
+		maxSockets = d2math.MinInt(maxSockets, typeSockets)
+	}
+
+	return maxSockets
+}
+
+// SetNumSockets sets the number of sockets of the item. The number is limited by the maximum
+// sockets of the item, and cannot be lower than the number of items already inserted. The
+// number of sockets set is returned.
+func (i *Item) SetNumSockets(count int) int {
+	count = d2math.MaxInt(d2math.MinInt(count, i.MaxSockets()), len(i.sockets))
+	i.attributes.numSockets = count
+
+	return count
+}
+
+// Insert inserts a gem, rune or jewel into the next free socket of the item. The runeword
+// spelled by the runes is applied once all the sockets are filled.
+func (i *Item) Insert(socketable *Item) error {
+	switch {
+	case !socketable.IsSocketable():
+		return fmt.Errorf("%s cannot be inserted into a socket", socketable.CommonCode)
+	case len(i.sockets) >= i.attributes.numSockets:
+		return fmt.Errorf("%s has no free socket", i.CommonCode)
+	}
+
+	i.sockets = append(i.sockets, socketable)
+
+	if len(i.sockets) == i.attributes.numSockets {
+		if record := i.findRuneword(); record != nil {
+			i.RunewordCode = record.Name
+			i.generateProperties(PropertyPoolRuneword)
+			i.generateName()
+		}
+	}
+
+	i.generateProperties(PropertyPoolSocket)
+	i.updateStatList()
+
+	return nil
+}
+
+// removeSockets removes the items inserted into the sockets of the item, breaking its runeword,
+// and returns them
+func (i *Item) removeSockets() []*Item {
+	removed := i.sockets
+
+	i.sockets = nil
+	i.RunewordCode = ""
+
+	delete(i.properties, PropertyPoolSocket)
+	delete(i.properties, PropertyPoolRuneword)
+
+	i.generateName()
+	i.updateStatList()
+
+	return removed
+}
+
+// generateSocketProperties returns the properties the inserted items give the item. Gems and
+// runes give the mods of Gems.txt for the kind of the item, jewels give their own properties.
+func (i *Item) generateSocketProperties() []*Property {
+	if len(i.sockets) == 0 {
+		return nil
+	}
+
+	result := make([]*Property, 0)
+
+	for _, socketed := range i.sockets {
+		if record := socketed.GemRecord(); record != nil {
+			result = append(result, i.generateItemProperties(gemMods(record, i.CommonRecord().GemApplyType))...)
+			continue
+		}
+
+		pools := []PropertyPool{PropertyPoolPrefix, PropertyPoolSuffix, PropertyPoolUnique, PropertyPoolSaved}
+
+		for _, pool := range pools {
+			result = append(result, socketed.properties[pool]...)
+		}
+	}
+
+	return result
+}
+
+// gemMods returns the weapon, helm or shield mods of a gem
+func gemMods(record *d2records.GemRecord, applyType int) []*d2records.PropertyDescriptor {
+	type mod struct {
+		code            string
+		param, min, max int
+	}
+
+	var mods []mod
+
+	switch applyType {
+	case gemApplyArmor:
+		mods = []mod{
+			{record.HelmMod1Code, record.HelmMod1Param, record.HelmMod1Min, record.HelmMod1Max},
+			{record.HelmMod2Code, record.HelmMod2Param, record.HelmMod2Min, record.HelmMod2Max},
+			{record.HelmMod3Code, record.HelmMod3Param, record.HelmMod3Min, record.HelmMod3Max},
+		}
+	case gemApplyShield:
+		mods = []mod{
+			{record.ShieldMod1Code, record.ShieldMod1Param, record.ShieldMod1Min, record.ShieldMod1Max},
+			{record.ShieldMod2Code, record.ShieldMod2Param, record.ShieldMod2Min, record.ShieldMod2Max},
+			{record.ShieldMod3Code, record.ShieldMod3Param, record.ShieldMod3Min, record.ShieldMod3Max},
+		}
+	default:
+		mods = []mod{
+			{record.WeaponMod1Code, record.WeaponMod1Param, record.WeaponMod1Min, record.WeaponMod1Max},
+			{record.WeaponMod2Code, record.WeaponMod2Param, record.WeaponMod2Min, record.WeaponMod2Max},
+			{record.WeaponMod3Code, record.WeaponMod3Param, record.WeaponMod3Min, record.WeaponMod3Max},
+		}
+	}
+
+	result := make([]*d2records.PropertyDescriptor, 0)
+
+	for _, m := range mods {
+		if m.code == "" {
+			continue
+		}
+
+		result = append(result, &d2records.PropertyDescriptor{
+			Code:      m.code,
+			Parameter: strconv.Itoa(m.param),
+			Min:       m.min,
+			Max:       m.max,
+		})
+	}
+
+	return result
+}
+
+// findRuneword returns the runeword of Runes.txt spelled by the runes in the sockets of the
+// item, if the item is a normal item of one of the types of the runeword. Ladder only runewords
+// are not spelled.
+func (i *Item) findRuneword() *d2records.RuneRecord {
+	if i.Quality() != d2enum.Normal {
+		return nil
+	}
+
+	itemTypes := i.factory.asset.Records.FindEquivalentTypesByItemCommonRecord(i.CommonRecord())
+
+	var found *d2records.RuneRecord
+
+	for _, record := range i.factory.asset.Records.Item.Runewords {
+		if !record.Complete || record.Server || !i.spells(record.Runes) ||
+			!containsAnyType(itemTypes, record.ItemTypes.Include) ||
+			containsAnyType(itemTypes, record.ItemTypes.Exclude) {
+			continue
+		}
+
+		// the runewords are kept in a map, the lowest name is picked for the same result every time
+		if found == nil || record.Name < found.Name {
+			found = record
+		}
+	}
+
+	return found
+}
+
+// spells returns true if the sockets of the item hold exactly the given runes, in order
+func (i *Item) spells(runes []string) bool {
+	if len(runes) != len(i.sockets) {
+		return false
+	}
+
+	for idx, code := range runes {
+		if i.sockets[idx].CommonCode != code {
+			return false
+		}
+	}
+
+	return true
+}
+
+func containsAnyType(itemTypes, codes []string) bool {
+	for _, itemType := range itemTypes {
+		for _, code := range codes {
+			if itemType == code {
+				return true
+			}
+		}
+	}
+
+	return false
+}
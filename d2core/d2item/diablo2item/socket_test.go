@@ -0,0 +1,92 @@
+package diablo2item
+
+import (
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+)
+
+func TestItem_Insert(t *testing.T) {
+	factory := newTestItemFactory(t)
+	records := factory.asset.Records
+
+	axeRecord := &d2records.ItemCommonRecord{Code: "hax", Type: "axe", Level: 3, GemSockets: 3}
+	el := &d2records.ItemCommonRecord{Code: "r01", Type: "rune"}
+	eld := &d2records.ItemCommonRecord{Code: "r02", Type: "rune"}
+
+	records.Item.All = map[string]*d2records.ItemCommonRecord{axeRecord.Code: axeRecord, el.Code: el, eld.Code: eld}
+	records.Item.Types = map[string]*d2records.ItemTypeRecord{
+		"axe":  {Code: "axe", MaxSock1: 2},
+		"rune": {Code: "rune", Gem: true},
+	}
+	records.Item.Equivalency = d2records.ItemEquivalenceMap{
+		"axe":  {axeRecord},
+		"weap": {axeRecord},
+		"rune": {el, eld},
+	}
+	records.Item.Gems = d2records.Gems{
+		"El Rune":  {Name: "El Rune", Code: "r01", WeaponMod1Code: "allstats", WeaponMod1Min: 1, WeaponMod1Max: 1},
+		"Eld Rune": {Name: "Eld Rune", Code: "r02", WeaponMod1Code: "allstats", WeaponMod1Min: 2, WeaponMod1Max: 2},
+	}
+	records.Item.Runewords = d2records.Runewords{
+		"Runeword1": {
+			Name:     "Runeword1",
+			Complete: true,
+			ItemTypes: struct {
+				Include []string
+				Exclude []string
+			}{Include: []string{"weap"}},
+			Runes:      []string{"r01", "r02"},
+			Properties: []*d2records.RunewordProperty{{Code: "ac%", Min: 10, Max: 10}},
+		},
+	}
+	records.Item.Stats = itemStatCosts
+	records.Properties = properties
+
+	insert := func(runes ...string) *Item {
+		axe, err := factory.NewItem("hax")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the sockets of the axe are limited by its item type
+		if sockets := axe.SetNumSockets(5); sockets != 2 {
+			t.Fatalf("expected 2 sockets, got %d", sockets)
+		}
+
+		for _, code := range runes {
+			if err := axe.Insert(testCubeItems(t, factory, code)[0]); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		return axe
+	}
+
+	axe := insert("r01", "r02")
+
+	if axe.RunewordCode != "Runeword1" {
+		t.Errorf("expected the runes to spell Runeword1, got %q", axe.RunewordCode)
+	}
+
+	stats := make(map[string]int)
+	for _, stat := range axe.StatList().Stats() {
+		stats[stat.Name()] = stat.Values()[0].Int()
+	}
+
+	if stats["strength"] != 3 || stats["item_armor_percent"] != 10 {
+		t.Errorf("expected the stats of the runes and the runeword, got %v", stats)
+	}
+
+	if err := axe.Insert(testCubeItems(t, factory, "r01")[0]); err == nil {
+		t.Error("expected an error inserting into a full item")
+	}
+
+	if err := insert().Insert(testCubeItems(t, factory, "hax")[0]); err == nil {
+		t.Error("expected an error inserting an axe")
+	}
+
+	if reversed := insert("r02", "r01"); reversed.RunewordCode != "" {
+		t.Errorf("expected no runeword for the runes in the wrong order, got %q", reversed.RunewordCode)
+	}
+}
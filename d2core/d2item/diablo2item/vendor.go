@@ -0,0 +1,279 @@
+package diablo2item
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+)
+
+const (
+	// vendorLevelBonus is how many levels above the level of the player the base items sold by
+	// vendors may be
+	vendorLevelBonus = 5
+
+	// the chances of the qualities of a gambled item, out of gambleChanceTotal. Items which are
+	// neither unique, set nor rare are magic.
+	gambleChanceTotal  = 10000
+	gambleUniqueChance = 5
+	gambleSetChance    = 10
+	gambleRareChance   = 1000
+
+	// the prices of MagicPrefix.txt and MagicSuffix.txt are scaled by 1024
+	affixPriceScale = 1024
+)
+
+// vendorAliases returns the vendor columns of Weapons.txt, Armor.txt and Misc.txt which are not
+// spelled like the ID of the NPC in MonStats.txt
+func vendorAliases() map[string]string {
+	return map[string]string{
+		"hratli": "Hralti",
+	}
+}
+
+// VendorContext is what the stock and the prices of a vendor depend on
+type VendorContext struct {
+	NPC         *d2records.NPCRecord
+	Difficulty  d2enum.DifficultyType
+	PlayerLevel int
+}
+
+// vendorParams returns the vendor parameters of an item record for the NPC, or nil if the NPC
+// does not sell the item
+func (ctx *VendorContext) vendorParams(record *d2records.ItemCommonRecord) *d2records.ItemVendorParams {
+	name := ctx.NPC.Name
+	if alias, found := vendorAliases()[strings.ToLower(name)]; found {
+		name = alias
+	}
+
+	for column, params := range record.Vendors {
+		if strings.EqualFold(column, name) {
+			return params
+		}
+	}
+
+	return nil
+}
+
+// sellsTier returns true if the item record is of a tier sold on the difficulty. Exceptional
+// items are sold from nightmare on, elite items in hell.
+func (ctx *VendorContext) sellsTier(record *d2records.ItemCommonRecord) bool {
+	switch record.Code {
+	case record.NormalCode:
+		return true
+	case record.UberCode:
+		return ctx.Difficulty >= d2enum.DifficultyNightmare
+	case record.UltraCode:
+		return ctx.Difficulty >= d2enum.DifficultyHell
+	default:
+		return true
+	}
+}
+
+// sells returns true if a base item may be offered by the vendor
+func (ctx *VendorContext) sells(record *d2records.ItemCommonRecord) bool {
+	return record.Spawnable && record.Quest == 0 && ctx.sellsTier(record) &&
+		record.Level <= ctx.PlayerLevel+vendorLevelBonus
+}
+
+// sortedItemRecords returns the item records ordered by code, so the same seed gives the same
+// items
+func (f *ItemFactory) sortedItemRecords() []*d2records.ItemCommonRecord {
+	records := make([]*d2records.ItemCommonRecord, 0, len(f.asset.Records.Item.All))
+
+	for _, record := range f.asset.Records.Item.All {
+		records = append(records, record)
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Code < records[j].Code
+	})
+
+	return records
+}
+
+// VendorStock rolls the items a vendor sells. Every item of Weapons.txt, Armor.txt and
+// Misc.txt is stocked between the minimum and the maximum of the vendor as a normal item, and
+// between the magic minimum and maximum as a magic item.
+func (f *ItemFactory) VendorStock(ctx VendorContext) []*Item {
+	stock := make([]*Item, 0)
+
+	for _, record := range f.sortedItemRecords() {
+		params := ctx.vendorParams(record)
+		if params == nil || !ctx.sells(record) {
+			continue
+		}
+
+		for count := f.rollRange(params.Min, params.Max); count > 0; count-- {
+			stock = append(stock, f.vendorItem(record, dropModifierNone))
+		}
+
+		for count := f.rollRange(params.MagicMin, params.MagicMax); count > 0; count-- {
+			stock = append(stock, f.vendorItem(record, dropModifierMagic))
+		}
+	}
+
+	return stock
+}
+
+// GambleStock returns the base items of Gamble.txt a player can gamble for, ordered by code
+func (f *ItemFactory) GambleStock(ctx VendorContext) []*Item {
+	stock := make([]*Item, 0)
+
+	for _, record := range f.sortedItemRecords() {
+		if !f.gambleable(record.Code) || !ctx.sellsTier(record) || record.Level > ctx.PlayerLevel {
+			continue
+		}
+
+		stock = append(stock, f.vendorItem(record, dropModifierNone))
+	}
+
+	return stock
+}
+
+func (f *ItemFactory) gambleable(code string) bool {
+	for _, record := range f.asset.Records.Gamble {
+		if record.Code == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Gamble creates the item a player gets for gambling on the given base item. The item is
+// magic, rare, set or unique.
+func (f *ItemFactory) Gamble(base *Item) *Item {
+	modifier := dropModifierMagic
+
+	switch roll := f.rand.Intn(gambleChanceTotal); {
+	case roll < gambleUniqueChance:
+		modifier = dropModifierUnique
+	case roll < gambleUniqueChance+gambleSetChance:
+		modifier = dropModifierSet
+	case roll < gambleUniqueChance+gambleSetChance+gambleRareChance:
+		modifier = dropModifierRare
+	}
+
+	return f.vendorItem(base.CommonRecord(), modifier)
+}
+
+func (f *ItemFactory) vendorItem(record *d2records.ItemCommonRecord, modifier dropModifier) *Item {
+	item := &Item{factory: f, CommonCode: record.Code}
+	item.SetSeed(f.itemSeed())
+	item.applyDropModifier(modifier)
+
+	return item.init()
+}
+
+// rollRange returns a random number from min to max, or 0 if max is 0
+func (f *ItemFactory) rollRange(min, max int) int {
+	if max <= 0 {
+		return 0
+	}
+
+	if max <= min {
+		return max
+	}
+
+	return min + f.rand.Intn(max-min+1)
+}
+
+// StorePage returns the name of the page of StorePage.txt a vendor shows the item on, or an
+// empty string if its item type has no store page
+func (i *Item) StorePage() string {
+	typeRecord := i.TypeRecord()
+	if typeRecord == nil {
+		return ""
+	}
+
+	for _, record := range i.factory.asset.Records.Item.StorePages {
+		if record.Code == typeRecord.StorePage {
+			return record.StorePage
+		}
+	}
+
+	return ""
+}
+
+// Price returns the base price of the item: the cost of its base item, scaled by the costs of
+// its affixes, unique or set item
+func (i *Item) Price() int {
+	price := i.CommonRecord().Cost
+
+	for _, affix := range append(i.PrefixRecords(), i.SuffixRecords()...) {
+		if affix.PriceScale > 0 {
+			price = price * affix.PriceScale / affixPriceScale
+		}
+
+		price += affix.PriceAdd
+	}
+
+	if record := i.UniqueRecord(); record != nil {
+		price = price*d2math.MaxInt(record.CostMultiplier, 1) + record.CostAdd
+	}
+
+	if record := i.SetItemRecord(); record != nil {
+		price = price*d2math.MaxInt(record.CostMult, 1) + record.CostAdd
+	}
+
+	return d2math.MaxInt(price, 1)
+}
+
+// BuyPrice returns the price a player pays the vendor for an item, from the sell multiplier of
+// NPC.txt
+func (ctx *VendorContext) BuyPrice(item *Item) int {
+	return d2math.MaxInt(int(float64(item.Price())*ctx.NPC.Multipliers.Sell), 1)
+}
+
+// SellPrice returns the gold a player gets from the vendor for an item, from the buy
+// multiplier of NPC.txt. A damaged item is worth less, and the vendor pays no more than the
+// maximum of NPC.txt for the difficulty.
+func (ctx *VendorContext) SellPrice(item *Item) int {
+	price := float64(item.Price()) * ctx.NPC.Multipliers.Buy
+
+	if current, max := item.Durability(); max > 0 {
+		price = price * float64(current) / float64(max)
+	}
+
+	maxBuy := ctx.NPC.MaxBuy.Normal
+
+	switch ctx.Difficulty {
+	case d2enum.DifficultyNightmare:
+		maxBuy = ctx.NPC.MaxBuy.Nightmare
+	case d2enum.DifficultyHell:
+		maxBuy = ctx.NPC.MaxBuy.Hell
+	}
+
+	if maxBuy > 0 {
+		price = math.Min(price, float64(maxBuy))
+	}
+
+	return d2math.MaxInt(int(price), 1)
+}
+
+// RepairPrice returns the price of repairing the lost durability of an item, from the repair
+// multiplier of NPC.txt. Items without lost durability are repaired for free.
+func (ctx *VendorContext) RepairPrice(item *Item) int {
+	current, max := item.Durability()
+	if current >= max {
+		return 0
+	}
+
+	price := float64(item.Price()) * ctx.NPC.Multipliers.Repair * float64(max-current) / float64(max)
+
+	return d2math.MaxInt(int(price), 1)
+}
+
+// GamblePrice returns the price of gambling on a base item, the gamble cost of its item record
+// or the price of the base item if there is none
+func (ctx *VendorContext) GamblePrice(item *Item) int {
+	if cost := item.CommonRecord().GambleCost; cost > 0 {
+		return cost
+	}
+
+	return ctx.BuyPrice(item)
+}
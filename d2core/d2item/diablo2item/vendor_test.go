@@ -0,0 +1,91 @@
+package diablo2item
+
+import (
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+)
+
+func TestItemFactory_VendorStock(t *testing.T) {
+	factory := newTestItemFactory(t)
+	records := factory.asset.Records
+
+	vendors := map[string]*d2records.ItemVendorParams{"Charsi": {Min: 1, Max: 1}}
+
+	axeRecord := &d2records.ItemCommonRecord{Code: "hax", Type: "axe", Level: 3, Cost: 100, Durability: 20,
+		Spawnable: true, NormalCode: "hax", UberCode: "9ha", UltraCode: "7ha", Vendors: vendors}
+	exceptionalRecord := &d2records.ItemCommonRecord{Code: "9ha", Type: "axe", Level: 3, Cost: 1000, Spawnable: true,
+		NormalCode: "hax", UberCode: "9ha", UltraCode: "7ha", Vendors: vendors}
+	helm := &d2records.ItemCommonRecord{Code: "cap", Type: "helm", Level: 1, Cost: 50, GambleCost: 5000}
+
+	records.Item.All = map[string]*d2records.ItemCommonRecord{
+		axeRecord.Code: axeRecord, exceptionalRecord.Code: exceptionalRecord, helm.Code: helm,
+	}
+	records.Item.Types = map[string]*d2records.ItemTypeRecord{
+		"axe":  {Code: "axe", Rare: true, StorePage: "weap"},
+		"helm": {Code: "helm", Rare: true},
+	}
+	records.Item.Magic.Prefix = map[string]*d2records.ItemAffixCommonRecord{
+		"Sturdy": {Name: "Sturdy", PriceScale: 2048},
+	}
+	records.Item.StorePages = d2records.StorePages{"Weapons": {StorePage: "Weapons", Code: "weap"}}
+	records.Gamble = d2records.Gamble{"Cap": {Name: "Cap", Code: "cap"}}
+	npc := &d2records.NPCRecord{Name: "charsi", Multipliers: &d2records.CostMultiplier{Buy: 0.25, Sell: 1.5,
+		Repair: 0.5}}
+	npc.MaxBuy.Normal = 20
+
+	ctx := VendorContext{NPC: npc, Difficulty: d2enum.DifficultyNormal, PlayerLevel: 1}
+
+	stock := factory.VendorStock(ctx)
+	if len(stock) != 1 || stock[0].CommonCode != "hax" {
+		t.Fatalf("expected one normal axe, got %v", stock)
+	}
+
+	axe := stock[0]
+
+	if page := axe.StorePage(); page != "Weapons" {
+		t.Errorf("expected the axe on the weapons page, got %q", page)
+	}
+
+	if price := ctx.BuyPrice(axe); price != 150 {
+		t.Errorf("expected a buy price of 150, got %d", price)
+	}
+
+	if price := ctx.SellPrice(axe); price != 20 {
+		t.Errorf("expected the sell price to be limited to 20, got %d", price)
+	}
+
+	if price := ctx.RepairPrice(axe); price != 0 {
+		t.Errorf("expected a free repair of an undamaged axe, got %d", price)
+	}
+
+	axe.SetDurability(10)
+
+	if price := ctx.RepairPrice(axe); price != 25 {
+		t.Errorf("expected a repair price of 25, got %d", price)
+	}
+
+	ctx.Difficulty = d2enum.DifficultyNightmare
+	if stock := factory.VendorStock(ctx); len(stock) != 2 {
+		t.Errorf("expected the exceptional axe to be sold in nightmare, got %v", stock)
+	}
+
+	gamble := factory.GambleStock(ctx)
+	if len(gamble) != 1 || ctx.GamblePrice(gamble[0]) != 5000 {
+		t.Fatalf("expected a cap to gamble on for 5000, got %v", gamble)
+	}
+
+	if item := factory.Gamble(gamble[0]); item.CommonCode != "cap" {
+		t.Errorf("expected to gamble for a cap, got %s", item.CommonCode)
+	}
+
+	sturdy, err := factory.NewItem("cap", "Sturdy")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if price := sturdy.Price(); price != 100 {
+		t.Errorf("expected the prefix to double the price of the cap, got %d", price)
+	}
+}
@@ -47,7 +47,7 @@ const (
 
 // CreateMapEngine creates a new instance of the map engine and returns a pointer to it.
 func CreateMapEngine(l d2util.LogLevel, asset *d2asset.AssetManager) *MapEngine {
-	entity, _ := d2mapentity.NewMapEntityFactory(asset)
+	entity, _ := d2mapentity.NewMapEntityFactory(asset, l)
 	stamp := d2mapstamp.NewStampFactory(asset, l, entity)
 
 	engine := &MapEngine{
@@ -182,7 +182,7 @@ func (m *MapEngine) PlaceStamp(stamp *d2mapstamp.Stamp, tileOffsetX, tileOffsetY
 			stampTile := *stamp.Tile(x, y)
 			m.tiles[targetTileIndex].RegionType = stamp.RegionID()
 			m.tiles[targetTileIndex].Components = stampTile
-			m.tiles[targetTileIndex].PrepareTile(x, y, m)
+			m.tiles[targetTileIndex].PrepareTile(x+xMin, y+yMin, m)
 		}
 	}
 
@@ -80,18 +80,21 @@ func (t *MapTile) PrepareTile(x, y int, me *MapEngine) {
 }
 
 // Walker's Alias Method for weighted random selection with xorshifting for random numbers
-// Selects a random tile from the slice, rest of args just used for seeding
+// Selects a random tile from the slice, rest of args just used for seeding, the same map
+// coordinates and seed always select the same tile
 func getRandomTile(tiles []d2dt1.Tile, x, y int, seed int64) byte {
-	var tileSeed uint64
-	tileSeed = uint64(seed) + uint64(x)
-	tileSeed *= uint64(y)
-
 	const (
+		primeX    = 0x9E3779B97F4A7C15
+		primeY    = 0xC2B2AE3D27D4EB4F
 		xorshiftA = 13
 		xorshiftB = 17
 		xorshiftC = 5
 	)
 
+	// the coordinates are spread over all bits, so neighbouring tiles and the first row and
+	// column do not end up with the same seed
+	tileSeed := uint64(seed) ^ (uint64(x)+1)*primeX ^ (uint64(y)+1)*primeY
+
 	tileSeed ^= tileSeed << xorshiftA
 	tileSeed ^= tileSeed >> xorshiftB
 	tileSeed ^= tileSeed << xorshiftC
@@ -1,48 +1,308 @@
 package d2mapengine
 
 import (
+	"container/heap"
 	"math"
 
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math/d2vector"
 )
 
-// PathFind finds a path between given start and dest positions and returns the positions of the path
+// PathMover selects which sub tile flags block movement when searching for a path
+type PathMover int
+
+// Path movers
+const (
+	// PathMoverPlayer is blocked by BlockWalk and BlockPlayerWalk
+	PathMoverPlayer PathMover = iota
+	// PathMoverMonster is blocked by BlockWalk only
+	PathMoverMonster
+	// PathMoverMissile is blocked by BlockLOS only
+	PathMoverMissile
+)
+
+const (
+	// maxPathSearchNodes caps the number of sub tiles expanded by a single search. When the
+	// cap is hit the path to the closest sub tile found so far is returned instead.
+	maxPathSearchNodes = 4000
+
+	costStraight = 1.0
+	costDiagonal = math.Sqrt2
+
+	subTileCenter = 0.5
+)
+
+// PathFind finds a walkable path for a player between the given start and dest positions and
+// returns the waypoints of the path, excluding the start position.
 func (m *MapEngine) PathFind(start, dest d2vector.Position) []d2vector.Position {
+	return m.PathFindFor(start, dest, PathMoverPlayer)
+}
+
+// PathFindFor finds a path between the given start and dest positions for the given kind of
+// mover. The grid path is smoothed so that only the waypoints where the path changes direction
+// are returned. If dest can not be reached, the path leads to the closest reachable sub tile.
+func (m *MapEngine) PathFindFor(start, dest d2vector.Position, mover PathMover) []d2vector.Position {
 	points := make([]d2vector.Position, 0)
-	_, point := m.checkLos(start, dest)
-	points = append(points, point)
+
+	if m.lineWalkable(start.X(), start.Y(), dest.X(), dest.Y(), mover) {
+		return append(points, dest)
+	}
+
+	cells, reached := m.searchPath(start, dest, mover)
+	if len(cells) < 2 { //nolint:gomnd // start and at least one more cell
+		return points
+	}
+
+	for _, cell := range m.smoothPath(cells, mover) {
+		points = append(points, d2vector.NewPosition(float64(cell.x)+subTileCenter, float64(cell.y)+subTileCenter))
+	}
+
+	if reached {
+		points[len(points)-1] = dest
+	}
 
 	return points
 }
 
-// checkLos finds out if there is a clear line of sight between two points
-func (m *MapEngine) checkLos(start, end d2vector.Position) (bool, d2vector.Position) {
-	dv := d2vector.Position{Vector: *end.Clone()}
-	dv.Subtract(&start.Vector)
-	dx := dv.X()
-	dy := dv.Y()
-	N := math.Max(math.Abs(dx), math.Abs(dy))
+// IsWalkable returns true if the sub tile at the given coordinates can be entered by the given mover
+func (m *MapEngine) IsWalkable(subX, subY int, mover PathMover) bool {
+	if subX < 0 || subY < 0 || subX >= m.size.Width*subtilesPerTile || subY >= m.size.Height*subtilesPerTile {
+		return false
+	}
 
-	var divN float64
-	if N == 0 {
-		divN = 0.0
-	} else {
-		divN = 1.0 / N // nolint:gomnd // we're just taking inverse...
+	flags := m.SubTileAt(subX, subY)
+
+	switch mover {
+	case PathMoverPlayer:
+		return !flags.BlockWalk && !flags.BlockPlayerWalk
+	case PathMoverMonster:
+		return !flags.BlockWalk
+	case PathMoverMissile:
+		return !flags.BlockLOS
 	}
 
-	xstep := dx * divN
-	ystep := dy * divN
-	x := start.X()
-	y := start.Y()
+	return false
+}
 
-	for i := 0; i <= int(N); i++ {
-		x += xstep
-		y += ystep
+// HasLineOfSight returns true if nothing blocks line of sight between the two positions
+func (m *MapEngine) HasLineOfSight(start, end d2vector.Position) bool {
+	return m.lineWalkable(start.X(), start.Y(), end.X(), end.Y(), PathMoverMissile)
+}
+
+type pathCell struct {
+	x, y int
+}
+
+type pathNode struct {
+	cell   pathCell
+	parent *pathNode
+	g, f   float64
+	index  int
+	closed bool
+}
+
+// pathQueue is a min-heap of path nodes ordered by their estimated total cost
+type pathQueue []*pathNode
+
+func (q pathQueue) Len() int { return len(q) }
+
+func (q pathQueue) Less(i, j int) bool { return q[i].f < q[j].f }
+
+func (q pathQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *pathQueue) Push(x interface{}) {
+	node := x.(*pathNode)
+	node.index = len(*q)
+	*q = append(*q, node)
+}
+
+func (q *pathQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	node := old[n-1]
+	old[n-1] = nil
+	*q = old[:n-1]
+
+	return node
+}
+
+// octile distance heuristic for 8-way movement
+func pathHeuristic(a, b pathCell) float64 {
+	dx := math.Abs(float64(a.x - b.x))
+	dy := math.Abs(float64(a.y - b.y))
+
+	return costStraight*(dx+dy) + (costDiagonal-2*costStraight)*math.Min(dx, dy) //nolint:gomnd // octile
+}
+
+// searchPath runs an A* search over the sub tile grid. It returns the cells from start to the
+// goal (or to the closest cell found when the goal is unreachable) and whether the goal was reached.
+func (m *MapEngine) searchPath(start, dest d2vector.Position, mover PathMover) ([]pathCell, bool) {
+	from := pathCell{int(math.Floor(start.X())), int(math.Floor(start.Y()))}
+	to := pathCell{int(math.Floor(dest.X())), int(math.Floor(dest.Y()))}
+
+	nodes := make(map[pathCell]*pathNode)
+	open := &pathQueue{}
+
+	first := &pathNode{cell: from, f: pathHeuristic(from, to)}
+	nodes[from] = first
+	heap.Push(open, first)
+
+	best := first
+	bestH := first.f
+
+	for expanded := 0; open.Len() > 0 && expanded < maxPathSearchNodes; expanded++ {
+		current := heap.Pop(open).(*pathNode)
+		current.closed = true
+
+		if current.cell == to {
+			return current.cells(), true
+		}
 
-		if m.SubTileAt(int(math.Floor(x)), int(math.Floor(y))).BlockWalk {
-			return false, d2vector.NewPosition(x-xstep, y-ystep)
+		if h := current.f - current.g; h < bestH {
+			best, bestH = current, h
 		}
+
+		m.expandPathNode(current, to, mover, nodes, open)
+	}
+
+	return best.cells(), false
+}
+
+func (m *MapEngine) expandPathNode(current *pathNode, to pathCell, mover PathMover,
+	nodes map[pathCell]*pathNode, open *pathQueue) {
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			if dx == 0 && dy == 0 {
+				continue
+			}
+
+			next := pathCell{current.cell.x + dx, current.cell.y + dy}
+			if !m.IsWalkable(next.x, next.y, mover) {
+				continue
+			}
+
+			cost := costStraight
+
+			if dx != 0 && dy != 0 {
+				// don't cut corners of blocked sub tiles
+				if !m.IsWalkable(current.cell.x+dx, current.cell.y, mover) ||
+					!m.IsWalkable(current.cell.x, current.cell.y+dy, mover) {
+					continue
+				}
+
+				cost = costDiagonal
+			}
+
+			g := current.g + cost
+			node, found := nodes[next]
+
+			switch {
+			case !found:
+				node = &pathNode{cell: next, parent: current, g: g, f: g + pathHeuristic(next, to)}
+				nodes[next] = node
+				heap.Push(open, node)
+			case !node.closed && g < node.g:
+				node.parent = current
+				node.f += g - node.g
+				node.g = g
+				heap.Fix(open, node.index)
+			}
+		}
+	}
+}
+
+// cells walks the parents of the node and returns the cells from the start to this node
+func (n *pathNode) cells() []pathCell {
+	count := 0
+
+	for node := n; node != nil; node = node.parent {
+		count++
+	}
+
+	result := make([]pathCell, count)
+
+	for node := n; node != nil; node = node.parent {
+		count--
+		result[count] = node.cell
+	}
+
+	return result
+}
+
+// smoothPath removes every cell which can be skipped by walking in a straight line from the
+// previous waypoint. The start cell is not part of the result.
+func (m *MapEngine) smoothPath(cells []pathCell, mover PathMover) []pathCell {
+	result := make([]pathCell, 0)
+	anchor := cells[0]
+
+	for idx := 1; idx < len(cells)-1; idx++ {
+		next := cells[idx+1]
+
+		if m.lineWalkable(float64(anchor.x)+subTileCenter, float64(anchor.y)+subTileCenter,
+			float64(next.x)+subTileCenter, float64(next.y)+subTileCenter, mover) {
+			continue
+		}
+
+		anchor = cells[idx]
+		result = append(result, anchor)
+	}
+
+	return append(result, cells[len(cells)-1])
+}
+
+// lineWalkable traverses every sub tile touched by the line between the two points and returns
+// false if any of them blocks the given mover. The sub tile of the start point is not checked,
+// so that entities standing on the edge of a blocked sub tile can still move away from it.
+func (m *MapEngine) lineWalkable(x0, y0, x1, y1 float64, mover PathMover) bool {
+	cellX, cellY := int(math.Floor(x0)), int(math.Floor(y0))
+	endX, endY := int(math.Floor(x1)), int(math.Floor(y1))
+
+	stepX, tMaxX, tDeltaX := lineTraversalAxis(x0, x1)
+	stepY, tMaxY, tDeltaY := lineTraversalAxis(y0, y1)
+
+	for cellX != endX || cellY != endY {
+		switch {
+		case math.Abs(tMaxX-tMaxY) < 1e-9: //nolint:gomnd // passing exactly through a corner
+			if !m.IsWalkable(cellX+stepX, cellY, mover) || !m.IsWalkable(cellX, cellY+stepY, mover) {
+				return false
+			}
+
+			cellX, cellY = cellX+stepX, cellY+stepY
+			tMaxX, tMaxY = tMaxX+tDeltaX, tMaxY+tDeltaY
+		case tMaxX < tMaxY:
+			cellX += stepX
+			tMaxX += tDeltaX
+		default:
+			cellY += stepY
+			tMaxY += tDeltaY
+		}
+
+		if !m.IsWalkable(cellX, cellY, mover) {
+			return false
+		}
+
+		if tMaxX > 1 && tMaxY > 1 {
+			break
+		}
+	}
+
+	return m.IsWalkable(endX, endY, mover)
+}
+
+// lineTraversalAxis returns the step direction, the distance (as a fraction of the line) to the
+// first cell boundary and the distance between cell boundaries along one axis of a line.
+func lineTraversalAxis(from, to float64) (step int, tMax, tDelta float64) {
+	delta := to - from
+
+	switch {
+	case delta > 0:
+		return 1, (math.Floor(from) + 1 - from) / delta, 1 / delta
+	case delta < 0:
+		return -1, (from - math.Floor(from)) / -delta, 1 / -delta
 	}
 
-	return true, end
+	return 0, math.Inf(1), math.Inf(1)
 }
@@ -0,0 +1,133 @@
+package d2mapengine
+
+import (
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2geom"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math/d2vector"
+)
+
+// testMap creates a map engine with an empty walkable grid of the given size in tiles
+func testMap(width, height int) *MapEngine {
+	return &MapEngine{
+		size:  d2geom.Size{Width: width, Height: height},
+		tiles: make([]MapTile, width*height),
+	}
+}
+
+func blockColumn(m *MapEngine, x, fromY, toY int, player bool) {
+	for y := fromY; y <= toY; y++ {
+		if player {
+			m.SubTileAt(x, y).BlockPlayerWalk = true
+		} else {
+			m.SubTileAt(x, y).BlockWalk = true
+		}
+	}
+}
+
+func assertPathWalkable(t *testing.T, m *MapEngine, start d2vector.Position, path []d2vector.Position, mover PathMover) {
+	from := start
+
+	for idx := range path {
+		if !m.lineWalkable(from.X(), from.Y(), path[idx].X(), path[idx].Y(), mover) {
+			t.Fatalf("path segment %d from %s to %s is blocked", idx, from.Vector, path[idx].Vector)
+		}
+
+		from = path[idx]
+	}
+}
+
+func TestPathFind_StraightLine(t *testing.T) {
+	m := testMap(4, 4)
+	start := d2vector.NewPosition(1.5, 1.5)
+	dest := d2vector.NewPosition(15.5, 12.5)
+
+	path := m.PathFind(start, dest)
+
+	if len(path) != 1 {
+		t.Fatalf("expected a single waypoint, got %d", len(path))
+	}
+
+	if !path[0].Equals(&dest.Vector) {
+		t.Errorf("expected waypoint %s, got %s", dest.Vector, path[0].Vector)
+	}
+}
+
+func TestPathFind_AroundWall(t *testing.T) {
+	m := testMap(4, 4)
+	blockColumn(m, 10, 0, 17, false)
+
+	start := d2vector.NewPosition(5.5, 5.5)
+	dest := d2vector.NewPosition(15.5, 5.5)
+
+	path := m.PathFind(start, dest)
+
+	if len(path) < 2 {
+		t.Fatalf("expected the path to go around the wall, got %d waypoints", len(path))
+	}
+
+	if last := path[len(path)-1]; !last.Equals(&dest.Vector) {
+		t.Errorf("expected path to end at %s, got %s", dest.Vector, last.Vector)
+	}
+
+	assertPathWalkable(t, m, start, path, PathMoverPlayer)
+}
+
+func TestPathFind_PlayerAndMonsterFlags(t *testing.T) {
+	m := testMap(4, 4)
+	blockColumn(m, 10, 0, 19, true)
+
+	start := d2vector.NewPosition(5.5, 5.5)
+	dest := d2vector.NewPosition(15.5, 5.5)
+
+	monsterPath := m.PathFindFor(start, dest, PathMoverMonster)
+	if len(monsterPath) != 1 || !monsterPath[0].Equals(&dest.Vector) {
+		t.Errorf("expected monster to walk straight through BlockPlayerWalk, got %v", monsterPath)
+	}
+
+	playerPath := m.PathFindFor(start, dest, PathMoverPlayer)
+	if len(playerPath) == 0 {
+		t.Fatal("expected player to walk towards the wall")
+	}
+
+	if last := playerPath[len(playerPath)-1]; last.X() >= 10 {
+		t.Errorf("expected player path to stop before the wall, ended at %s", last.Vector)
+	}
+}
+
+func TestPathFind_Unreachable(t *testing.T) {
+	m := testMap(4, 4)
+	blockColumn(m, 12, 0, 19, false)
+
+	start := d2vector.NewPosition(2.5, 2.5)
+	dest := d2vector.NewPosition(17.5, 2.5)
+
+	path := m.PathFind(start, dest)
+
+	if len(path) == 0 {
+		t.Fatal("expected a partial path towards the destination")
+	}
+
+	last := path[len(path)-1]
+
+	if int(last.X()) != 11 {
+		t.Errorf("expected partial path to end next to the wall, ended at %s", last.Vector)
+	}
+
+	assertPathWalkable(t, m, start, path, PathMoverPlayer)
+}
+
+func TestLineWalkable_NoCornerCutting(t *testing.T) {
+	m := testMap(1, 1)
+	m.SubTileAt(1, 0).BlockWalk = true
+	m.SubTileAt(0, 1).BlockWalk = true
+
+	if m.lineWalkable(0.5, 0.5, 1.5, 1.5, PathMoverMonster) {
+		t.Error("expected diagonal line between two blocked sub tiles to be blocked")
+	}
+
+	path := m.PathFindFor(d2vector.NewPosition(0.5, 0.5), d2vector.NewPosition(1.5, 1.5), PathMoverMonster)
+	if len(path) != 0 {
+		t.Errorf("expected no path through blocked corner, got %v", path)
+	}
+}
@@ -9,11 +9,13 @@ import (
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2interface"
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math/d2vector"
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2resource"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2util"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2asset"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
-	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2inventory"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2item/diablo2item"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2state"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2stats/diablo2stats"
 )
 
 const (
@@ -22,14 +24,19 @@ const (
 	millisecondsPerSecond = 1000.0
 )
 
-// NewMapEntityFactory creates a MapEntityFactory instance with the given asset manager
-func NewMapEntityFactory(asset *d2asset.AssetManager) (*MapEntityFactory, error) {
+// NewMapEntityFactory creates a MapEntityFactory instance with the given asset manager and log level
+func NewMapEntityFactory(asset *d2asset.AssetManager, l d2util.LogLevel) (*MapEntityFactory, error) {
 	itemFactory, err := diablo2item.NewItemFactory(asset)
 	if err != nil {
 		return nil, err
 	}
 
-	stateFactory, err := d2hero.NewHeroStateFactory(asset)
+	stateFactory, err := d2hero.NewHeroStateFactory(asset, l)
+	if err != nil {
+		return nil, err
+	}
+
+	statFactory, err := diablo2stats.NewStatFactory(asset)
 	if err != nil {
 		return nil, err
 	}
@@ -38,6 +45,7 @@ func NewMapEntityFactory(asset *d2asset.AssetManager) (*MapEntityFactory, error)
 		stateFactory,
 		asset,
 		itemFactory,
+		statFactory,
 	}
 
 	return entityFactory, nil
@@ -48,6 +56,7 @@ type MapEntityFactory struct {
 	*d2hero.HeroStateFactory
 	asset *d2asset.AssetManager
 	item  *diablo2item.ItemFactory
+	stat  *diablo2stats.StatFactory
 }
 
 // NewAnimatedEntity creates an instance of AnimatedEntity
@@ -61,20 +70,12 @@ func NewAnimatedEntity(x, y int, animation d2interface.Animation) *AnimatedEntit
 	return entity
 }
 
-// NewPlayer creates a new player entity and returns a pointer to it.
+// NewPlayer creates a new player entity and returns a pointer to it. The player wears the
+// equipped items among the given items.
 func (f *MapEntityFactory) NewPlayer(id, name string, x, y, direction int, heroType d2enum.Hero,
-	stats *d2hero.HeroStatsState, skills map[int]*d2hero.HeroSkill, equipment *d2inventory.CharacterEquipment,
+	stats *d2hero.HeroStatsState, skills map[int]*d2hero.HeroSkill, items []*d2hero.HeroItem,
 	leftSkill, rightSkill, gold int) *Player {
-	layerEquipment := &[d2enum.CompositeTypeMax]string{
-		d2enum.CompositeTypeHead:      equipment.Head.GetArmorClass(),
-		d2enum.CompositeTypeTorso:     equipment.Torso.GetArmorClass(),
-		d2enum.CompositeTypeLegs:      equipment.Legs.GetArmorClass(),
-		d2enum.CompositeTypeRightArm:  equipment.RightArm.GetArmorClass(),
-		d2enum.CompositeTypeLeftArm:   equipment.LeftArm.GetArmorClass(),
-		d2enum.CompositeTypeRightHand: equipment.RightHand.GetItemCode(),
-		d2enum.CompositeTypeLeftHand:  equipment.LeftHand.GetItemCode(),
-		d2enum.CompositeTypeShield:    equipment.Shield.GetItemCode(),
-	}
+	equipment := d2hero.EquippedItems(items)
 
 	composite, err := f.asset.LoadComposite(d2enum.ObjectTypePlayer, heroType.GetToken(),
 		d2resource.PaletteUnits)
@@ -91,6 +92,7 @@ func (f *MapEntityFactory) NewPlayer(id, name string, x, y, direction int, heroT
 		mapEntity:  newMapEntity(x, y),
 		composite:  composite,
 		Equipment:  equipment,
+		Items:      items,
 		Stats:      heroState.Stats,
 		Skills:     heroState.Skills,
 		LeftSkill:  heroState.Skills[leftSkill],
@@ -102,13 +104,24 @@ func (f *MapEntityFactory) NewPlayer(id, name string, x, y, direction int, heroT
 		isInTown:     true,
 		isRunning:    false,
 		Gold:         gold,
+		States:       d2state.NewManager(),
 		Act:          1,
+		stat:         f.stat,
+	}
+
+	for _, heroItem := range items {
+		if heroItem.Item != nil {
+			heroItem.Item.SetContext(result)
+		}
 	}
 
+	result.StatEngine = f.NewStatEngine(heroType, result.States)
+	result.UpdateStats()
+
 	result.mapEntity.uuid = id
 	result.SetSpeed(baseWalkSpeed)
 	result.mapEntity.directioner = result.rotate
-	err = composite.SetMode(d2enum.PlayerAnimationModeTownNeutral, equipment.RightHand.GetWeaponClass())
+	err = composite.SetMode(d2enum.PlayerAnimationModeTownNeutral, equipment.WeaponClass())
 
 	if err != nil {
 		panic(err)
@@ -116,7 +129,7 @@ func (f *MapEntityFactory) NewPlayer(id, name string, x, y, direction int, heroT
 
 	composite.SetDirection(direction)
 
-	if err := composite.Equip(layerEquipment); err != nil {
+	if err := composite.Equip(equipment.CompositeLayers()); err != nil {
 		fmt.Printf("failed to equip, err: %v\n", err)
 	}
 
@@ -159,6 +172,28 @@ func (f *MapEntityFactory) NewItem(x, y int, codes ...string) (*Item, error) {
 		return nil, err
 	}
 
+	return f.newItemEntity(x, y, item)
+}
+
+// NewItemWithID creates the map entity of an item the server keeps on the ground, from the
+// data of the item in the format of a saved hero
+func (f *MapEntityFactory) NewItemWithID(id string, x, y int, data []byte) (*Item, error) {
+	item, err := f.item.Deserialize(data)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := f.newItemEntity(x, y, item)
+	if err != nil {
+		return nil, err
+	}
+
+	result.uuid = id
+
+	return result, nil
+}
+
+func (f *MapEntityFactory) newItemEntity(x, y int, item *diablo2item.Item) (*Item, error) {
 	filename := item.CommonRecord().FlippyFile
 	filepath := fmt.Sprintf("%s/%s.DC6", d2resource.ItemGraphics, filename)
 	animation, err := f.asset.LoadAnimation(filepath, d2resource.PaletteUnits)
@@ -181,14 +216,24 @@ func (f *MapEntityFactory) NewItem(x, y int, codes ...string) (*Item, error) {
 
 // NewNPC creates a new NPC and returns a pointer to it.
 func (f *MapEntityFactory) NewNPC(x, y int, monstat *d2records.MonStatRecord, direction int) (*NPC, error) {
+	return f.NewNPCWithID(uuid.New().String(), x, y, monstat, direction)
+}
+
+// NewNPCWithID creates a new NPC with the given ID. The server and the clients create the
+// NPCs of a map with the same IDs, so the NPCs can be referred to in packets.
+func (f *MapEntityFactory) NewNPCWithID(id string, x, y int, monstat *d2records.MonStatRecord,
+	direction int) (*NPC, error) {
 	// https://github.com/OpenDiablo2/OpenDiablo2/issues/803
 	result := &NPC{
 		mapEntity:     newMapEntity(x, y),
 		HasPaths:      false,
 		monstatRecord: monstat,
 		monstatEx:     f.asset.Records.Monster.Stats2[monstat.ExtraDataKey],
+		States:        d2state.NewManager(),
 	}
 
+	result.mapEntity.uuid = id
+
 	var equipment [16]string
 
 	for compType, opts := range result.monstatEx.EquipmentOptions {
@@ -263,10 +308,17 @@ func (f *MapEntityFactory) NewCastOverlay(x, y int, overlayRecord *d2records.Ove
 
 // NewObject creates an instance of AnimatedComposite
 func (f *MapEntityFactory) NewObject(x, y int, objectRec *d2records.ObjectDetailRecord,
+	palettePath string) (*Object, error) {
+	return f.NewObjectWithID(uuid.New().String(), x, y, objectRec, palettePath)
+}
+
+// NewObjectWithID creates a new object with the given ID. The server and the clients create the
+// objects of a map with the same IDs, so the objects can be referred to in packets.
+func (f *MapEntityFactory) NewObjectWithID(id string, x, y int, objectRec *d2records.ObjectDetailRecord,
 	palettePath string) (*Object, error) {
 	locX, locY := float64(x), float64(y)
 	entity := &Object{
-		uuid:         uuid.New().String(),
+		uuid:         id,
 		objectRecord: objectRec,
 		Position:     d2vector.NewPosition(locX, locY),
 		name:         f.asset.TranslateString(objectRec.Name),
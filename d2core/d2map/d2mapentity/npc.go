@@ -10,6 +10,7 @@ import (
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math/d2vector"
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2path"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2asset"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2state"
 )
 
 // NPC is a passive complex entity with which the player can interact.
@@ -26,6 +27,18 @@ type NPC struct {
 	monstatEx     *d2records.MonStat2Record
 	HasPaths      bool
 	isDone        bool
+	isActing      bool // a get hit, attack or cast animation plays once
+	isDead        bool
+	properties    MonsterProperties
+	States        *d2state.Manager // the states of States.txt the monster is in, like curses
+}
+
+// MonsterProperties are the properties a monster is spawned with by the population of a level
+type MonsterProperties struct {
+	Level    int // the monster level, 0 for the level of MonStats.txt
+	Rank     d2enum.MonsterRank
+	Mods     []int  // the IDs of the MonUMod.txt modifiers of a champion or unique monster
+	LeaderID string // the ID of the unique monster a minion follows
 }
 
 const (
@@ -98,6 +111,22 @@ func (v *NPC) Advance(tickTime float64) {
 		return
 	}
 
+	switch {
+	case v.isDead:
+		if v.composite.GetPlayedCount() >= 1 {
+			v.setMode(d2enum.MonsterAnimationModeDead)
+		}
+
+		return
+	case v.isActing:
+		if v.composite.GetPlayedCount() >= 1 {
+			v.isActing = false
+			v.setMode(d2enum.MonsterAnimationModeNeutral)
+		}
+
+		return
+	}
+
 	if v.HasPaths && v.wait() {
 		// If at the target, set target to the next path.
 		v.isDone = false
@@ -146,6 +175,10 @@ func (v *NPC) next() {
 
 // rotate sets direction and changes animation
 func (v *NPC) rotate(direction int) {
+	if v.isDead || v.isActing {
+		return
+	}
+
 	var newMode d2enum.MonsterAnimationMode
 	if !v.atTarget() {
 		newMode = d2enum.MonsterAnimationModeWalk
@@ -164,6 +197,82 @@ func (v *NPC) rotate(direction int) {
 	}
 }
 
+// MonStats returns the MonStats.txt record of the NPC
+func (v *NPC) MonStats() *d2records.MonStatRecord {
+	return v.monstatRecord
+}
+
+// Properties returns the properties the NPC was spawned with
+func (v *NPC) Properties() MonsterProperties {
+	return v.properties
+}
+
+// SetProperties sets the properties of a spawned monster. Unique monsters are named, the name
+// makes them selectable.
+func (v *NPC) SetProperties(properties MonsterProperties, name string) {
+	v.properties = properties
+
+	if name != "" {
+		v.name = name
+	}
+}
+
+// GetHit stops the NPC and plays its hit recovery animation
+func (v *NPC) GetHit() {
+	v.playOnce(d2enum.MonsterAnimationModeGetHit)
+}
+
+// Act stops the NPC, turns it to the target and plays the animation of an action once, like
+// an attack or a cast
+func (v *NPC) Act(mode d2enum.MonsterAnimationMode, target d2vector.Position) {
+	if v.isDead {
+		return
+	}
+
+	v.composite.SetDirection(v.Position.DirectionTo(target.Vector))
+	v.playOnce(mode)
+}
+
+func (v *NPC) playOnce(mode d2enum.MonsterAnimationMode) {
+	if v.isDead {
+		return
+	}
+
+	v.StopMoving()
+	v.isActing = true
+	v.setMode(mode)
+}
+
+// Die plays the death animation of the NPC, its corpse stays on the map afterwards
+func (v *NPC) Die() {
+	v.StopMoving()
+	v.HasPaths = false
+	v.isDead = true
+	v.setMode(d2enum.MonsterAnimationModeDeath)
+}
+
+// Resurrect brings a dead NPC back to life
+func (v *NPC) Resurrect() {
+	v.isDead = false
+	v.isActing = false
+	v.setMode(d2enum.MonsterAnimationModeNeutral)
+}
+
+// IsDead returns true if the NPC has been killed
+func (v *NPC) IsDead() bool {
+	return v.isDead
+}
+
+func (v *NPC) setMode(mode d2enum.MonsterAnimationMode) {
+	if v.composite.GetAnimationMode() == mode.String() {
+		return
+	}
+
+	if err := v.composite.SetMode(mode, v.composite.GetWeaponClass()); err != nil {
+		return
+	}
+}
+
 // Selectable returns true if the object can be highlighted/selected.
 func (v *NPC) Selectable() bool {
 	// is there something handy that determines selectable npc's?
@@ -23,6 +23,7 @@ type Object struct {
 	objectRecord *d2records.ObjectDetailRecord
 	drawLayer    int
 	name         string
+	operated     bool
 }
 
 // setMode changes the graphical mode of this animated entity
@@ -77,6 +78,36 @@ func (ob *Object) Selectable() bool {
 	return ob.objectRecord.Selectable[mode]
 }
 
+// ObjectRecord returns the Objects.txt record of the object
+func (ob *Object) ObjectRecord() *d2records.ObjectDetailRecord {
+	return ob.objectRecord
+}
+
+// Operate opens the object, like a chest which is clicked by a player. The object stays open
+// afterwards.
+func (ob *Object) Operate() error {
+	if ob.operated {
+		return nil
+	}
+
+	ob.operated = true
+
+	if ob.objectRecord.HasAnimationMode[d2enum.ObjectAnimationModeOpened] {
+		return ob.setMode(d2enum.ObjectAnimationModeOpened, 0, false)
+	}
+
+	if ob.objectRecord.HasAnimationMode[d2enum.ObjectAnimationModeOperating] {
+		return ob.setMode(d2enum.ObjectAnimationModeOperating, 0, false)
+	}
+
+	return nil
+}
+
+// IsOperated returns true if the object has been opened
+func (ob *Object) IsOperated() bool {
+	return ob.operated
+}
+
 // Render draws this animated entity onto the target
 func (ob *Object) Render(target d2interface.Surface) {
 	renderOffset := ob.Position.RenderOffset()
@@ -9,6 +9,8 @@ import (
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2asset"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2inventory"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2state"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2stats/diablo2stats"
 )
 
 // Player is the player character entity.
@@ -17,20 +19,26 @@ type Player struct {
 	name              string
 	animationMode     string
 	composite         *d2asset.Composite
-	Equipment         *d2inventory.CharacterEquipment
+	Equipment         d2inventory.CharacterEquipment `json:"-"` // the worn items among the Items
 	Stats             *d2hero.HeroStatsState
 	Skills            map[int]*d2hero.HeroSkill
 	LeftSkill         *d2hero.HeroSkill
 	RightSkill        *d2hero.HeroSkill
 	Class             d2enum.Hero
 	Gold              int
+	States            *d2state.Manager   // the states of States.txt the player is in, like shrine buffs
+	Items             []*d2hero.HeroItem // the items the player owns, with where they are kept
+	StatEngine        *d2hero.StatEngine // the final stats of the player, with its items, skills and states
 	lastPathSize      int
 	isInTown          bool
 	isRunToggled      bool
 	isRunning         bool
 	isCasting         bool
+	isGettingHit      bool
+	isDead            bool
 	onFinishedCasting func()
 	Act               int
+	stat              *diablo2stats.StatFactory
 }
 
 // run speed should be walkspeed * 1.5, since in the original game it is 6 yards walk and 9 yards run.
@@ -95,6 +103,10 @@ func (p *Player) Advance(tickTime float64) {
 		fmt.Printf("failed to set animationMode to: %d, err: %v\n", p.GetAnimationMode(), err)
 	}
 
+	if p.isGettingHit && p.composite.GetPlayedCount() >= 1 {
+		p.isGettingHit = false
+	}
+
 	if p.IsCasting() {
 		if p.composite.GetPlayedCount() >= 1 {
 			p.isCasting = false
@@ -131,8 +143,8 @@ func (p *Player) Advance(tickTime float64) {
 	// if i Remember correctly)
 	const magicStaminaDrainDivisor = 5
 
-	// Drain and regenerate Stamina
-	if p.IsRunning() && !p.atTarget() && !p.IsInTown() {
+	// Drain and regenerate Stamina, the stamina shrine stops the drain while it lasts
+	if p.IsRunning() && !p.atTarget() && !p.IsInTown() && p.States.Stat("staminarecoverybonus") == 0 {
 		p.Stats.Stamina -= staminaDrain * tickTime / magicStaminaDrainDivisor
 		if p.Stats.Stamina <= 0 {
 			p.SetSpeed(baseWalkSpeed)
@@ -163,6 +175,19 @@ func (p *Player) Render(target d2interface.Surface) {
 
 // GetAnimationMode returns the current animation mode based on what the player is doing and where they are.
 func (p *Player) GetAnimationMode() d2enum.PlayerAnimationMode {
+	if p.isDead {
+		if p.composite.GetAnimationMode() == d2enum.PlayerAnimationModeDeath.String() && p.composite.GetPlayedCount() >= 1 ||
+			p.composite.GetAnimationMode() == d2enum.PlayerAnimationModeDead.String() {
+			return d2enum.PlayerAnimationModeDead
+		}
+
+		return d2enum.PlayerAnimationModeDeath
+	}
+
+	if p.isGettingHit {
+		return d2enum.PlayerAnimationModeGetHit
+	}
+
 	if p.IsRunning() && !p.atTarget() {
 		return d2enum.PlayerAnimationModeRun
 	}
@@ -240,6 +265,35 @@ func (p *Player) StartCasting(animMode d2enum.PlayerAnimationMode, onFinishedCas
 	}
 }
 
+// GetHit stops the player and plays its hit recovery animation
+func (p *Player) GetHit() {
+	if p.isDead {
+		return
+	}
+
+	p.StopMoving()
+	p.isGettingHit = true
+}
+
+// Die plays the death animation of the player, its corpse stays on the map afterwards
+func (p *Player) Die() {
+	p.StopMoving()
+	p.isCasting = false
+	p.onFinishedCasting = nil
+	p.isGettingHit = false
+	p.isDead = true
+}
+
+// Revive brings a dead player back to life, when it respawns
+func (p *Player) Revive() {
+	p.isDead = false
+}
+
+// IsDead returns true if the player has been killed
+func (p *Player) IsDead() bool {
+	return p.isDead
+}
+
 // Selectable returns true if the player is in town.
 func (p *Player) Selectable() bool {
 	// Players are selectable when in town
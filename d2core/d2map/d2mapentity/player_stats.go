@@ -0,0 +1,79 @@
+package d2mapentity
+
+import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2item"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2stats"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2stats/diablo2stats"
+)
+
+// static check that the player is the context of the stats of its items
+var _ d2item.StatContext = &Player{}
+
+// EquippedItems returns the items the player wears
+func (p *Player) EquippedItems() []d2item.Item {
+	return p.Equipment.Items()
+}
+
+// CarriedItems returns the items the player has, but does not wear
+func (p *Player) CarriedItems() []d2item.Item {
+	worn := make(map[d2item.Item]bool)
+
+	for _, item := range p.EquippedItems() {
+		worn[item] = true
+	}
+
+	items := make([]d2item.Item, 0, len(p.Items))
+
+	for _, heroItem := range p.Items {
+		if heroItem.Item != nil && !worn[heroItem.Item] {
+			items = append(items, heroItem.Item)
+		}
+	}
+
+	return items
+}
+
+// BaseStatList returns the stats of the player without its items: its level and attributes
+func (p *Player) BaseStatList() d2stats.StatList {
+	if p.stat == nil || p.Stats == nil {
+		return &diablo2stats.Diablo2StatList{}
+	}
+
+	list := p.stat.NewStatList()
+
+	for _, base := range []struct {
+		key   string
+		value int
+	}{
+		{"level", p.Stats.Level},
+		{"strength", p.Stats.Strength},
+		{"dexterity", p.Stats.Dexterity},
+		{"vitality", p.Stats.Vitality},
+		{"energy", p.Stats.Energy},
+	} {
+		if stat := p.stat.NewStat(base.key, float64(base.value)); stat != nil {
+			list.Push(stat)
+		}
+	}
+
+	return list
+}
+
+// StatList returns the stats of the player with the stats of the items it wears, so the affixes,
+// uniques and sets of the items count for the player. With a stat engine, the set bonuses,
+// passive skills, states and derived stats count too.
+func (p *Player) StatList() d2stats.StatList {
+	if p.StatEngine == nil {
+		return p.Equipment.StatList(p.BaseStatList())
+	}
+
+	return p.StatEngine.StatList()
+}
+
+// UpdateStats derives the final stats of the player again, after its attributes, skills,
+// items or states changed
+func (p *Player) UpdateStats() {
+	if p.StatEngine != nil && p.Stats != nil {
+		p.StatEngine.Update(p.Stats, p.Skills, p.Equipment)
+	}
+}
@@ -4,7 +4,6 @@ package d2mapgen
 // is experiemental, and mapgen will likely change dramatically in the future.
 
 import (
-	"math/rand"
 	"strings"
 
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
@@ -24,6 +23,7 @@ const (
 )
 
 const (
+	townLevelID               = 1
 	wildernessDetailsRecordID = 2
 )
 
@@ -36,7 +36,7 @@ const (
 
 // GenerateAct1Overworld generates the map and entities for the first town and surrounding area.
 func (g *MapGenerator) GenerateAct1Overworld() {
-	rand.Seed(g.engine.Seed())
+	g.seedLevel(townLevelID)
 
 	wilderness1Details := g.asset.Records.GetLevelDetails(wildernessDetailsRecordID)
 
@@ -49,6 +49,7 @@ func (g *MapGenerator) GenerateAct1Overworld() {
 	townSize := townStamp.Size()
 
 	g.Infof("Region Path: %s", townStamp.RegionPath())
+	g.seedLevel(wildernessDetailsRecordID)
 
 	switch {
 	case strings.Contains(townStamp.RegionPath(), "E1"):
@@ -82,7 +83,7 @@ func (g *MapGenerator) GenerateAct1Overworld() {
 	}
 }
 
-// nolint:gosec,gomnd // we dont need crypto-strong randomness, mapgen will get a refactor soon
+// nolint:gomnd // mapgen will get a refactor soon
 func (g *MapGenerator) generateWilderness1TownEast(startX, startY int) {
 	levelDetails := g.asset.Records.GetLevelDetails(wildernessDetailsRecordID)
 
@@ -126,20 +127,20 @@ func (g *MapGenerator) generateWilderness1TownEast(startX, startY int) {
 
 	// Draw the north and south fence
 	for i := 0; i < 9; i++ {
-		g.engine.PlaceStamp(fenceNorthStamp[rand.Intn(3)], startX+(i*9), startY)
-		g.engine.PlaceStamp(fenceSouthStamp[rand.Intn(3)], startX+(i*9),
+		g.engine.PlaceStamp(fenceNorthStamp[g.rng.Intn(3)], startX+(i*9), startY)
+		g.engine.PlaceStamp(fenceSouthStamp[g.rng.Intn(3)], startX+(i*9),
 			startY+(levelDetails.SizeYNormal+6))
 	}
 
 	// West fence
 	for i := 1; i < 6; i++ {
-		g.engine.PlaceStamp(fenceWestStamp[rand.Intn(3)], startX,
+		g.engine.PlaceStamp(fenceWestStamp[g.rng.Intn(3)], startX,
 			startY+(levelDetails.SizeYNormal+6)-(i*9))
 	}
 
 	// East Fence
 	for i := 1; i < 10; i++ {
-		g.engine.PlaceStamp(fenceEastStamp[rand.Intn(3)], startX+levelDetails.SizeXNormal, startY+(i*9))
+		g.engine.PlaceStamp(fenceEastStamp[g.rng.Intn(3)], startX+levelDetails.SizeXNormal, startY+(i*9))
 	}
 
 	g.engine.PlaceStamp(fenceSouthWestStamp, startX, startY+levelDetails.SizeYNormal+6)
@@ -148,7 +149,7 @@ func (g *MapGenerator) generateWilderness1TownEast(startX, startY int) {
 	g.engine.PlaceStamp(fenceSouthEastStamp, startX+levelDetails.SizeXNormal, startY+levelDetails.SizeYNormal+6)
 }
 
-// nolint:gosec,gomnd // we dont need crypto-strong randomness, mapgen will get a refactor soon
+// nolint:gomnd // mapgen will get a refactor soon
 func (g *MapGenerator) generateWilderness1TownSouth(startX, startY int) {
 	levelDetails := g.asset.Records.GetLevelDetails(wildernessDetailsRecordID)
 
@@ -184,17 +185,17 @@ func (g *MapGenerator) generateWilderness1TownSouth(startX, startY int) {
 
 	// Draw the north fence
 	for i := 0; i < 4; i++ {
-		g.engine.PlaceStamp(fenceNorthStamp[rand.Intn(3)], startX+(i*9)+5, startY-6)
+		g.engine.PlaceStamp(fenceNorthStamp[g.rng.Intn(3)], startX+(i*9)+5, startY-6)
 	}
 
 	// Draw the west fence
 	for i := 0; i < 8; i++ {
-		g.engine.PlaceStamp(fenceWestStamp[rand.Intn(3)], startX, startY+(i*9)+3)
+		g.engine.PlaceStamp(fenceWestStamp[g.rng.Intn(3)], startX, startY+(i*9)+3)
 	}
 
 	// Draw the south fence
 	for i := 1; i < 9; i++ {
-		g.engine.PlaceStamp(fenceSouthStamp[rand.Intn(3)], startX+(i*9), startY+(8*9)+3)
+		g.engine.PlaceStamp(fenceSouthStamp[g.rng.Intn(3)], startX+(i*9), startY+(8*9)+3)
 	}
 
 	g.engine.PlaceStamp(fenceNorthWestStamp, startX, startY-6)
@@ -202,7 +203,7 @@ func (g *MapGenerator) generateWilderness1TownSouth(startX, startY int) {
 	g.engine.PlaceStamp(fenceWaterBorderSouthEast, startX+(9*9)-4, startY+(8*9)+1)
 }
 
-// nolint:gosec,gomnd // we dont need crypto-strong randomness, mapgen will get a refactor soon
+// nolint:gomnd // mapgen will get a refactor soon
 func (g *MapGenerator) generateWilderness1TownWest(startX, startY int) {
 	levelDetails := g.asset.Records.GetLevelDetails(wildernessDetailsRecordID)
 
@@ -238,20 +239,20 @@ func (g *MapGenerator) generateWilderness1TownWest(startX, startY int) {
 	// Draw the north and south fences
 	for i := 0; i < 9; i++ {
 		if i > 0 && i < 8 {
-			g.engine.PlaceStamp(fenceNorthStamp[rand.Intn(3)], startX+(i*9)-1, startY-15)
+			g.engine.PlaceStamp(fenceNorthStamp[g.rng.Intn(3)], startX+(i*9)-1, startY-15)
 		}
 
-		g.engine.PlaceStamp(fenceSouthStamp[rand.Intn(3)], startX+(i*9)-1, startY+levelDetails.SizeYNormal-12)
+		g.engine.PlaceStamp(fenceSouthStamp[g.rng.Intn(3)], startX+(i*9)-1, startY+levelDetails.SizeYNormal-12)
 	}
 
 	// Draw the east fence
 	for i := 0; i < 6; i++ {
-		g.engine.PlaceStamp(fenceEastStamp[rand.Intn(3)], startX+levelDetails.SizeXNormal-9, startY+(i*9)-6)
+		g.engine.PlaceStamp(fenceEastStamp[g.rng.Intn(3)], startX+levelDetails.SizeXNormal-9, startY+(i*9)-6)
 	}
 
 	// Draw the west fence
 	for i := 0; i < 9; i++ {
-		g.engine.PlaceStamp(fenceWestStamp[rand.Intn(3)], startX, startY+(i*9)-6)
+		g.engine.PlaceStamp(fenceWestStamp[g.rng.Intn(3)], startX, startY+(i*9)-6)
 	}
 
 	// Draw the west fence
@@ -269,14 +270,14 @@ func (g *MapGenerator) generateWilderness1TownWest(startX, startY int) {
 	g.generateWilderness1Contents(areaRect)
 }
 
-// nolint:gosec,gomnd // we dont need crypto-strong randomness, mapgen will get a refactor soon
+// nolint:gomnd // mapgen will get a refactor soon
 func (g *MapGenerator) generateWilderness1Contents(rect d2geom.Rectangle) {
 	levelDetails := g.asset.Records.GetLevelDetails(wildernessDetailsRecordID)
 
 	denOfEvil := g.loadPreset(d2wilderness.DenOfEvilEntrance, 0)
 	denOfEvilLoc := d2geom.Point{
-		X: rect.Left + (rect.Width / 2) + rand.Intn(10),
-		Y: rect.Top + (rect.Height / 2) + rand.Intn(10),
+		X: rect.Left + (rect.Width / 2) + g.rng.Intn(10),
+		Y: rect.Top + (rect.Height / 2) + g.rng.Intn(10),
 	}
 
 	// Fill in the grass
@@ -285,7 +286,7 @@ func (g *MapGenerator) generateWilderness1Contents(rect d2geom.Rectangle) {
 			tile := g.engine.Tile(rect.Left+x, rect.Top+y)
 			tile.RegionType = d2enum.RegionIdType(levelDetails.LevelType)
 			tile.Components.Floors = []d2ds1.FloorShadowRecord{{Prop1: 1, Style: 0, Sequence: 0}} // wildernessGrass
-			tile.PrepareTile(x, y, g.engine)
+			tile.PrepareTile(rect.Left+x, rect.Top+y, g.engine)
 		}
 	}
 
@@ -315,11 +316,11 @@ func (g *MapGenerator) generateWilderness1Contents(rect d2geom.Rectangle) {
 
 	numPlaced := 0
 	for numPlaced < 25 {
-		stamp := stuff[rand.Intn(len(stuff))]
+		stamp := stuff[g.rng.Intn(len(stuff))]
 
 		stampRect := d2geom.Rectangle{
-			Left:   rect.Left + rand.Intn(rect.Width) - stamp.Size().Width,
-			Top:    rect.Top + rand.Intn(rect.Height) - stamp.Size().Height,
+			Left:   rect.Left + g.rng.Intn(rect.Width) - stamp.Size().Width,
+			Top:    rect.Top + g.rng.Intn(rect.Height) - stamp.Size().Height,
 			Width:  stamp.Size().Width,
 			Height: stamp.Size().Height,
 		}
@@ -0,0 +1,161 @@
+package d2mapgen
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2geom"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+)
+
+const subTilesPerTile = 5
+
+// LevelsOnMap returns the IDs of the levels which share a map with the given level. The act 1
+// town and the Blood Moor are generated together, every other level has a map of its own.
+func LevelsOnMap(levelID int) []int {
+	if levelID == townLevelID || levelID == wildernessDetailsRecordID {
+		return []int{townLevelID, wildernessDetailsRecordID}
+	}
+
+	return []int{levelID}
+}
+
+// GenerateLevel generates the map of a level with the generator of its DrlgType in Levels.txt
+// and returns the warps to the linked levels. The warps between levels on the same map are
+// left out. The monsters of the levels are spawned on the map afterwards.
+func (g *MapGenerator) GenerateLevel(levelID int, difficulty d2enum.DifficultyType) ([]Warp, error) {
+	details := g.asset.Records.GetLevelDetails(levelID)
+	if details == nil {
+		return nil, fmt.Errorf("unknown level %d", levelID)
+	}
+
+	var (
+		warps []Warp
+		err   error
+	)
+
+	switch {
+	case len(LevelsOnMap(levelID)) > 1:
+		warps = g.generateOverworld()
+	case details.LevelGenerationType == d2enum.LevelTypeRandomMaze:
+		warps, err = g.GenerateMaze(levelID, difficulty)
+	case details.LevelGenerationType == d2enum.LevelTypePreset:
+		warps, err = g.generatePresetLevel(details)
+	default:
+		err = fmt.Errorf("level %d can not be generated", levelID)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	// the monsters are spawned with the random number generator of the level, so the server and
+	// the clients spawn the same monsters
+	for _, id := range LevelsOnMap(levelID) {
+		g.populateLevel(g.asset.Records.GetLevelDetails(id), difficulty, warps)
+	}
+
+	return warps, nil
+}
+
+func (g *MapGenerator) generateOverworld() []Warp {
+	g.GenerateAct1Overworld()
+
+	warps := make([]Warp, 0)
+
+	for _, levelID := range LevelsOnMap(townLevelID) {
+		for _, warp := range g.findWarps(g.asset.Records.GetLevelDetails(levelID)) {
+			if len(LevelsOnMap(warp.LevelID)) == 1 {
+				warps = append(warps, warp)
+			}
+		}
+	}
+
+	return warps
+}
+
+// generatePresetLevel generates a level which is a single preset of LvlPrest.txt, like
+// Tristram
+func (g *MapGenerator) generatePresetLevel(details *d2records.LevelDetailRecord) ([]Warp, error) {
+	var preset *d2records.LevelPresetRecord
+
+	for _, id := range g.presetIDs() {
+		if record := g.asset.Records.Level.Presets[id]; record.LevelID == details.ID {
+			preset = &record
+			break
+		}
+	}
+
+	if preset == nil {
+		return nil, fmt.Errorf("level %d has no preset", details.ID)
+	}
+
+	g.seedLevel(details.ID)
+
+	region := d2enum.RegionIdType(details.LevelType)
+	stamp := g.engine.LoadStamp(region, preset.DefinitionID, autoFileIndex)
+
+	if stamp == nil {
+		return nil, fmt.Errorf("could not load the preset %d of level %d", preset.DefinitionID, details.ID)
+	}
+
+	// the map is reset before the tiles of the preset files are added
+	g.engine.ResetMap(region, stamp.Size().Width, stamp.Size().Height)
+
+	for _, file := range preset.Files {
+		g.engine.AddDS1(file)
+	}
+
+	g.engine.PlaceStamp(stamp, 0, 0)
+
+	return g.findWarps(details), nil
+}
+
+// presetIDs returns the IDs of the records of LvlPrest.txt in order, so the first preset of a
+// level is always the same
+func (g *MapGenerator) presetIDs() []int {
+	ids := make([]int, 0, len(g.asset.Records.Level.Presets))
+
+	for id := range g.asset.Records.Level.Presets {
+		ids = append(ids, id)
+	}
+
+	sort.Ints(ids)
+
+	return ids
+}
+
+// newWarp returns a warp on the given tile. The select area and the exit of the warp are
+// offsets from the tile in LvlWarp.txt, a warp without a record is used from its own tile.
+func (g *MapGenerator) newWarp(levelID, warpID, tileX, tileY int) Warp {
+	x, y := tileX*subTilesPerTile, tileY*subTilesPerTile
+
+	warp := Warp{
+		LevelID: levelID,
+		WarpID:  warpID,
+		TileX:   tileX,
+		TileY:   tileY,
+		Area:    d2geom.Rectangle{Left: x, Top: y, Width: subTilesPerTile, Height: subTilesPerTile},
+		ExitX:   x + subTilesPerTile/2,
+		ExitY:   y + subTilesPerTile/2,
+	}
+
+	if record, found := g.asset.Records.Level.Warp[warpID]; found {
+		warp.Area = d2geom.Rectangle{Left: x + record.SelectX, Top: y + record.SelectY,
+			Width: record.SelectDX, Height: record.SelectDY}
+		warp.ExitX, warp.ExitY = x+record.ExitWalkX, y+record.ExitWalkY
+	}
+
+	return warp
+}
+
+// Distance returns the distance of the given position to the select area of the warp, in sub
+// tiles. It is 0 inside of the area.
+func (w *Warp) Distance(x, y float64) float64 {
+	dx := math.Max(math.Max(float64(w.Area.Left)-x, x-float64(w.Area.Right())), 0)
+	dy := math.Max(math.Max(float64(w.Area.Top)-y, y-float64(w.Area.Bottom())), 0)
+
+	return math.Hypot(dx, dy)
+}
@@ -0,0 +1,53 @@
+package d2mapgen
+
+import (
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+)
+
+func TestMapGenerator_NewWarp(t *testing.T) {
+	asset := testAssetManager(t)
+	asset.Records.Level.Warp = d2records.LevelWarps{
+		3: {ID: 3, SelectX: -2, SelectY: 1, SelectDX: 4, SelectDY: 3, ExitWalkX: 6, ExitWalkY: 7},
+	}
+
+	generator := &MapGenerator{asset: asset}
+
+	warp := generator.newWarp(8, 3, 10, 20)
+	if warp.Area.Left != 48 || warp.Area.Top != 101 || warp.ExitX != 56 || warp.ExitY != 107 {
+		t.Errorf("unexpected warp %+v", warp)
+	}
+
+	tests := []struct {
+		x, y     float64
+		distance float64
+	}{
+		{50, 102, 0},
+		{52, 104, 0},
+		{55, 104, 3},
+		{48, 108, 4},
+	}
+
+	for _, test := range tests {
+		if distance := warp.Distance(test.x, test.y); distance != test.distance {
+			t.Errorf("expected a distance of %g from (%g, %g), got %g", test.distance, test.x, test.y, distance)
+		}
+	}
+
+	// a warp without a record is used from its own tile
+	warp = generator.newWarp(8, 4, 10, 20)
+	if warp.Distance(52, 102) != 0 || warp.ExitX != 52 || warp.ExitY != 102 {
+		t.Errorf("unexpected warp without a record %+v", warp)
+	}
+}
+
+func TestLevelsOnMap(t *testing.T) {
+	if levels := LevelsOnMap(wildernessDetailsRecordID); len(levels) != 2 || levels[0] != townLevelID {
+		t.Errorf("expected the Blood Moor to share the map of the town, got %v", levels)
+	}
+
+	if levels := LevelsOnMap(8); len(levels) != 1 || levels[0] != 8 {
+		t.Errorf("expected the Den of Evil to have a map of its own, got %v", levels)
+	}
+}
@@ -1,6 +1,8 @@
 package d2mapgen
 
 import (
+	"math/rand"
+
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2asset"
 
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
@@ -32,16 +34,47 @@ func NewMapGenerator(a *d2asset.AssetManager, l d2util.LogLevel, e *d2mapengine.
 type MapGenerator struct {
 	asset  *d2asset.AssetManager
 	engine *d2mapengine.MapEngine
+	rng    *rand.Rand // The random number generator of the level being generated
 
 	*d2util.Logger
 }
 
+// seedLevel resets the random number generator for the given level. The seed is derived from
+// the map engine seed and the level ID, so every level is generated the same way regardless of
+// the order in which the levels are generated.
+func (g *MapGenerator) seedLevel(levelID int) {
+	g.rng = rand.New(rand.NewSource(levelSeed(g.engine.Seed(), levelID))) //nolint:gosec // reproducible, not secure
+	g.engine.SetRandomSource(g.rng)
+}
+
+// levelSeed mixes the game seed and a level ID into the seed of the level (splitmix64)
+func levelSeed(seed int64, levelID int) int64 {
+	const (
+		golden = 0x9E3779B97F4A7C15
+		mixA   = 0xBF58476D1CE4E5B9
+		mixB   = 0x94D049BB133111EB
+		shiftA = 30
+		shiftB = 27
+		shiftC = 31
+	)
+
+	z := uint64(seed) + uint64(levelID)*golden
+	z = (z ^ (z >> shiftA)) * mixA
+	z = (z ^ (z >> shiftB)) * mixB
+
+	return int64(z ^ (z >> shiftC))
+}
+
 func (g *MapGenerator) loadPreset(id, index int) *d2mapstamp.Stamp {
+	return g.loadRegionPreset(d2enum.RegionAct1Wilderness, id, index)
+}
+
+func (g *MapGenerator) loadRegionPreset(region d2enum.RegionIdType, id, index int) *d2mapstamp.Stamp {
 	for _, file := range g.asset.Records.LevelPreset(id).Files {
 		g.engine.AddDS1(file)
 	}
 
-	return g.engine.LoadStamp(d2enum.RegionAct1Wilderness, id, index)
+	return g.engine.LoadStamp(region, id, index)
 }
 
 func areaEmpty(mapEngine *d2mapengine.MapEngine, rect d2geom.Rectangle) bool {
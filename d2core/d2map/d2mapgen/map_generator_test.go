@@ -0,0 +1,227 @@
+package d2mapgen
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2datautils"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2loader/asset/types"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2util"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2asset"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapengine"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapgen/d2wilderness"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+)
+
+const (
+	testTilesPath = "data/global/tiles"
+	testDT1       = "test/act1.dt1"
+	testTownSize  = 40
+	testStampSize = 6
+	testAreaSize  = 72
+)
+
+// testDT1Data returns a DT1 with floor tiles of the styles 0 to 2, every style has several
+// tiles with different rarities, so the selected tiles depend on the seed
+func testDT1Data() []byte {
+	const (
+		headerBytes = 260
+		tileSize    = 96
+		tilesStart  = 276
+		styles      = 3
+		rarities    = 3
+	)
+
+	sw := d2datautils.CreateStreamWriter()
+	sw.PushInt32(7)
+	sw.PushInt32(6)
+	sw.PushBytes(make([]byte, headerBytes)...)
+	sw.PushInt32(styles * rarities)
+	sw.PushInt32(tilesStart)
+
+	for style := 0; style < styles; style++ {
+		for rarity := 1; rarity <= rarities; rarity++ {
+			tile := d2datautils.CreateStreamWriter()
+			tile.PushBytes(make([]byte, 20)...) // direction, roof height, flags, height, width, unknown
+			tile.PushInt32(0)                   // floor
+			tile.PushInt32(int32(style))
+			tile.PushInt32(0) // sequence
+			tile.PushInt32(int32(rarity))
+
+			sw.PushBytes(tile.GetBytes()...)
+			sw.PushBytes(make([]byte, tileSize-len(tile.GetBytes()))...)
+		}
+	}
+
+	return sw.GetBytes()
+}
+
+// testDS1Data returns a version 4 DS1 with a single floor layer of the given style
+func testDS1Data(width, height, style int) []byte {
+	const styleShift = 20
+
+	sw := d2datautils.CreateStreamWriter()
+	sw.PushInt32(4)
+	sw.PushInt32(int32(width - 1))
+	sw.PushInt32(int32(height - 1))
+	sw.PushInt32(0) // files
+	sw.PushInt32(0) // walls
+
+	for idx := 0; idx < width*height; idx++ {
+		sw.PushUint32(1 | uint32(style)<<styleShift) // floor
+	}
+
+	for idx := 0; idx < width*height; idx++ {
+		sw.PushUint32(0) // shadow
+	}
+
+	sw.PushInt32(0) // objects
+
+	return sw.GetBytes()
+}
+
+// testAssetManager writes the level files of a small act 1 overworld to a directory and returns
+// an asset manager with the matching records
+func testAssetManager(t *testing.T) *d2asset.AssetManager {
+	dir, err := ioutil.TempDir("", "d2mapgen")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	files := map[string][]byte{
+		testDT1:            testDT1Data(),
+		"test/townE1a.ds1": testDS1Data(testTownSize, testTownSize, 1),
+		"test/townE1b.ds1": testDS1Data(testTownSize, testTownSize, 2),
+	}
+
+	presets := make(d2records.LevelPresets)
+
+	for id := 0; id <= d2wilderness.DenOfEvilEntrance; id++ {
+		preset := d2records.LevelPresetRecord{DefinitionID: id}
+
+		for idx := range preset.Files {
+			preset.Files[idx] = fmt.Sprintf("test/preset%d_%d.ds1", id, idx)
+			files[preset.Files[idx]] = testDS1Data(testStampSize, testStampSize, 1+(id+idx)%2)
+		}
+
+		presets[id] = preset
+	}
+
+	presets[presetB] = d2records.LevelPresetRecord{
+		DefinitionID: presetB,
+		Files:        [6]string{"test/townE1a.ds1", "test/townE1b.ds1"},
+	}
+
+	for name, data := range files {
+		path := filepath.Join(dir, testTilesPath, name)
+
+		if err = os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+
+		if err = ioutil.WriteFile(path, data, os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	asset, err := d2asset.NewAssetManager(d2util.LogLevelNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = asset.AddSource(dir, types.AssetSourceFileSystem); err != nil {
+		t.Fatal(err)
+	}
+
+	levelType := &d2records.LevelTypeRecord{}
+	levelType.Files[0] = testDT1
+
+	asset.Records.Level.Types = d2records.LevelTypes{levelType, levelType, levelType}
+	asset.Records.Level.Presets = presets
+	// the records are looked up by index, like the records loaded from Levels.txt
+	asset.Records.Level.Details = d2records.LevelDetails{
+		0:           {},
+		townLevelID: {ID: townLevelID, LevelType: 1},
+		wildernessDetailsRecordID: {
+			ID:          wildernessDetailsRecordID,
+			LevelType:   2,
+			SizeXNormal: testAreaSize,
+			SizeYNormal: testAreaSize,
+		},
+	}
+
+	return asset
+}
+
+func generateTestOverworld(t *testing.T, asset *d2asset.AssetManager, seed int64) *d2mapengine.MapEngine {
+	engine := d2mapengine.CreateMapEngine(d2util.LogLevelNone, asset)
+	engine.SetSeed(seed)
+
+	generator, err := NewMapGenerator(asset, d2util.LogLevelNone, engine)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	generator.GenerateAct1Overworld()
+
+	return engine
+}
+
+// entityPositions returns the sorted positions of the map entities, the IDs of the entities
+// are random and can not be compared
+func entityPositions(engine *d2mapengine.MapEngine) []string {
+	positions := make([]string, 0, len(engine.Entities()))
+
+	for _, entity := range engine.Entities() {
+		positions = append(positions, entity.GetPosition().String())
+	}
+
+	sort.Strings(positions)
+
+	return positions
+}
+
+func TestMapGenerator_GenerateAct1Overworld_Reproducible(t *testing.T) {
+	asset := testAssetManager(t)
+
+	first := generateTestOverworld(t, asset, 42)
+	second := generateTestOverworld(t, asset, 42)
+
+	if first.Size() != second.Size() {
+		t.Fatalf("map sizes differ: %v and %v", first.Size(), second.Size())
+	}
+
+	firstTiles, secondTiles := *first.Tiles(), *second.Tiles()
+	for idx := range firstTiles {
+		if !reflect.DeepEqual(firstTiles[idx], secondTiles[idx]) {
+			x, y := idx%first.Size().Width, idx/first.Size().Width
+			t.Fatalf("tiles at %d,%d differ: %+v and %+v", x, y, firstTiles[idx], secondTiles[idx])
+		}
+	}
+
+	if !reflect.DeepEqual(entityPositions(first), entityPositions(second)) {
+		t.Fatal("entities differ")
+	}
+
+	other := generateTestOverworld(t, asset, 7)
+	if reflect.DeepEqual(*first.Tiles(), *other.Tiles()) {
+		t.Error("expected a different map for a different seed")
+	}
+}
+
+func TestLevelSeed(t *testing.T) {
+	if levelSeed(42, 1) != levelSeed(42, 1) {
+		t.Error("expected the same level seed for the same game seed and level")
+	}
+
+	if levelSeed(42, 1) == levelSeed(42, 2) || levelSeed(42, 1) == levelSeed(43, 1) {
+		t.Error("expected different level seeds for different levels and game seeds")
+	}
+}
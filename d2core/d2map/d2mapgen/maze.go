@@ -0,0 +1,468 @@
+package d2mapgen
+
+import (
+	"fmt"
+	"math/bits"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2geom"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapstamp"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+)
+
+const (
+	mazeMergeChance    = 1000 // Merge in LvlMaze.txt is a chance in thousandths
+	substitutionChance = 100  // the Prob columns of LvlSub.txt are a chance in percent
+	substitutionThemes = 5
+	maxLevelLinks      = 8
+	warpStyle          = 30 // the main index of the special tiles which link to other levels
+)
+
+// Warp is a link from a generated level to another level
+type Warp struct {
+	LevelID      int              // The ID of the linked level in Levels.txt
+	WarpID       int              // The ID of the warp graphics in LvlWarp.txt
+	TileX, TileY int              // The position of the warp, in tiles
+	Area         d2geom.Rectangle // The area a player uses the warp from, in sub tiles
+	ExitX, ExitY int              // The position a player arrives at through the warp, in sub tiles
+}
+
+// mazeExits is the set of directions in which a maze room is connected to its neighbours
+type mazeExits byte
+
+const (
+	mazeNorth mazeExits = 1 << iota
+	mazeEast
+	mazeSouth
+	mazeWest
+)
+
+func (e mazeExits) String() string {
+	var result strings.Builder
+
+	for _, direction := range mazeDirections() {
+		if e&direction.exit != 0 {
+			result.WriteByte(direction.letter)
+		}
+	}
+
+	return result.String()
+}
+
+type mazeDirection struct {
+	exit     mazeExits
+	opposite mazeExits
+	letter   byte
+	dx, dy   int
+}
+
+func mazeDirections() []mazeDirection {
+	return []mazeDirection{
+		{exit: mazeNorth, opposite: mazeSouth, letter: 'N', dy: -1},
+		{exit: mazeEast, opposite: mazeWest, letter: 'E', dx: 1},
+		{exit: mazeSouth, opposite: mazeNorth, letter: 'S', dy: 1},
+		{exit: mazeWest, opposite: mazeEast, letter: 'W', dx: -1},
+	}
+}
+
+type mazeRoomKind int
+
+const (
+	mazeRoom     mazeRoomKind = iota
+	mazeEntrance              // the room with the warp to the previous level
+	mazeExit                  // the room with the warp to the next level
+)
+
+// mazePreset is a LvlPrest.txt row which can be used as a maze room
+type mazePreset struct {
+	id    int
+	kind  mazeRoomKind
+	exits mazeExits
+}
+
+type mazeCell struct {
+	position d2geom.Point
+	kind     mazeRoomKind
+	exits    mazeExits
+}
+
+type placedStamp struct {
+	stamp *d2mapstamp.Stamp
+	x, y  int
+}
+
+// GenerateMaze generates a maze level, like the Den of Evil, the caves or the catacombs. The
+// rooms are presets of the level type, connected through matching exits. It returns the warps
+// to the linked levels.
+func (g *MapGenerator) GenerateMaze(levelID int, difficulty d2enum.DifficultyType) ([]Warp, error) {
+	details := g.asset.Records.GetLevelDetails(levelID)
+	maze := g.asset.Records.Level.Maze[levelID]
+
+	if details == nil || maze == nil {
+		return nil, fmt.Errorf("level %d is not a maze", levelID)
+	}
+
+	g.seedLevel(levelID)
+
+	region := d2enum.RegionIdType(details.LevelType)
+	presets := g.mazePresets(region)
+	links := levelLinks(details)
+	linkCount := 0
+
+	for _, link := range links {
+		if link > 0 {
+			linkCount++
+		}
+	}
+
+	cells := g.mazeLayout(mazeRooms(maze, difficulty), maze.Merge, linkCount > 1)
+
+	columns, rows := 0, 0
+
+	for _, cell := range cells {
+		columns = d2math.MaxInt(columns, cell.position.X+1)
+		rows = d2math.MaxInt(rows, cell.position.Y+1)
+	}
+
+	g.engine.ResetMap(region, columns*maze.SizeX, rows*maze.SizeY)
+
+	placed := make([]placedStamp, 0, len(cells))
+
+	for _, cell := range cells {
+		id, err := g.pickMazePreset(presets, cell)
+		if err != nil {
+			return nil, err
+		}
+
+		stamp := g.loadRegionPreset(region, id, autoFileIndex)
+		if stamp == nil {
+			return nil, fmt.Errorf("could not load the maze room preset %d", id)
+		}
+
+		x, y := cell.position.X*maze.SizeX, cell.position.Y*maze.SizeY
+		g.engine.PlaceStamp(stamp, x, y)
+
+		placed = append(placed, placedStamp{stamp: stamp, x: x, y: y})
+	}
+
+	g.applySubstitutions(details, region, placed)
+
+	return g.findWarps(details), nil
+}
+
+func mazeRooms(maze *d2records.LevelMazeDetailRecord, difficulty d2enum.DifficultyType) int {
+	switch difficulty {
+	case d2enum.DifficultyNightmare:
+		return maze.NumRoomsNightmare
+	case d2enum.DifficultyHell:
+		return maze.NumRoomsHell
+	}
+
+	return maze.NumRoomsNormal
+}
+
+// mazePresets returns the room presets of a level type, ordered by their ID
+func (g *MapGenerator) mazePresets(region d2enum.RegionIdType) []mazePreset {
+	levelTypeName := g.asset.Records.Level.Types[region].Name
+	presets := make([]mazePreset, 0)
+
+	for _, id := range g.presetIDs() {
+		record := g.asset.Records.Level.Presets[id]
+		if preset, ok := parseMazePreset(levelTypeName, &record); ok {
+			presets = append(presets, preset)
+		}
+	}
+
+	return presets
+}
+
+// parseMazePreset reads the kind and the exits of a maze room from the name of its preset. The
+// names of the rooms of a level type look like "Act 1 - Cave 1 NW", "Act 1 - Cave Prev E" and
+// "Act 1 - Cave Next S".
+func parseMazePreset(levelTypeName string, record *d2records.LevelPresetRecord) (mazePreset, bool) {
+	const nameFields = 2
+
+	prefix := levelTypeName + " "
+	if !strings.HasPrefix(record.Name, prefix) {
+		return mazePreset{}, false
+	}
+
+	fields := strings.Fields(strings.TrimPrefix(record.Name, prefix))
+	if len(fields) != nameFields {
+		return mazePreset{}, false
+	}
+
+	preset := mazePreset{id: record.DefinitionID}
+
+	switch fields[0] {
+	case "Prev":
+		preset.kind = mazeEntrance
+	case "Next", "Down":
+		preset.kind = mazeExit
+	default:
+		if _, err := strconv.Atoi(fields[0]); err != nil {
+			return mazePreset{}, false
+		}
+	}
+
+	for _, letter := range []byte(fields[1]) {
+		exit := mazeExits(0)
+
+		for _, direction := range mazeDirections() {
+			if direction.letter == letter {
+				exit = direction.exit
+			}
+		}
+
+		if exit == 0 || preset.exits&exit != 0 {
+			return mazePreset{}, false
+		}
+
+		preset.exits |= exit
+	}
+
+	return preset, true
+}
+
+// pickMazePreset picks a room preset with the kind and the exits of the cell. When the level
+// type has no entrance or exit room with these exits, an ordinary room is used.
+func (g *MapGenerator) pickMazePreset(presets []mazePreset, cell *mazeCell) (int, error) {
+	for _, kind := range []mazeRoomKind{cell.kind, mazeRoom} {
+		candidates := make([]int, 0)
+
+		for idx := range presets {
+			if presets[idx].kind == kind && presets[idx].exits == cell.exits {
+				candidates = append(candidates, presets[idx].id)
+			}
+		}
+
+		if len(candidates) > 0 {
+			return candidates[g.rng.Intn(len(candidates))], nil
+		}
+	}
+
+	return 0, fmt.Errorf("no maze room preset with the exits %s", cell.exits)
+}
+
+// mazeLayout builds a tree of rooms on a grid, starting with the entrance. The entrance and the
+// exit are dead ends, the other neighbouring rooms are connected with the merge chance. The
+// positions of the rooms start at 0,0.
+func (g *MapGenerator) mazeLayout(rooms, merge int, hasExit bool) []*mazeCell {
+	directions := mazeDirections()
+	entrance := &mazeCell{kind: mazeEntrance}
+	cells := []*mazeCell{entrance}
+	grid := map[d2geom.Point]*mazeCell{entrance.position: entrance}
+
+	for len(cells) < rooms {
+		parent := cells[g.rng.Intn(len(cells))]
+		direction := directions[g.rng.Intn(len(directions))]
+		position := d2geom.Point{X: parent.position.X + direction.dx, Y: parent.position.Y + direction.dy}
+
+		if _, found := grid[position]; found || (parent.kind == mazeEntrance && parent.exits != 0) {
+			continue
+		}
+
+		cell := &mazeCell{position: position, exits: direction.opposite}
+		parent.exits |= direction.exit
+		cells = append(cells, cell)
+		grid[position] = cell
+	}
+
+	if exit := farthestDeadEnd(cells, grid); hasExit && exit != nil {
+		exit.kind = mazeExit
+	}
+
+	for _, cell := range cells {
+		// east and south, so every pair of neighbours is only tried once
+		for _, direction := range directions[1:3] {
+			position := d2geom.Point{X: cell.position.X + direction.dx, Y: cell.position.Y + direction.dy}
+			neighbour, found := grid[position]
+
+			if !found || cell.kind != mazeRoom || neighbour.kind != mazeRoom || cell.exits&direction.exit != 0 {
+				continue
+			}
+
+			if g.rng.Intn(mazeMergeChance) < merge {
+				cell.exits |= direction.exit
+				neighbour.exits |= direction.opposite
+			}
+		}
+	}
+
+	left, top := 0, 0
+
+	for _, cell := range cells {
+		left = d2math.MinInt(left, cell.position.X)
+		top = d2math.MinInt(top, cell.position.Y)
+	}
+
+	for _, cell := range cells {
+		cell.position.X -= left
+		cell.position.Y -= top
+	}
+
+	return cells
+}
+
+// farthestDeadEnd returns the room with a single exit which is the farthest away from the
+// entrance, the first cell
+func farthestDeadEnd(cells []*mazeCell, grid map[d2geom.Point]*mazeCell) *mazeCell {
+	var farthest *mazeCell
+
+	distance := map[*mazeCell]int{cells[0]: 0}
+	queue := []*mazeCell{cells[0]}
+
+	for len(queue) > 0 {
+		cell := queue[0]
+		queue = queue[1:]
+
+		deadEnd := cell.kind == mazeRoom && bits.OnesCount8(uint8(cell.exits)) == 1
+		if deadEnd && (farthest == nil || distance[cell] > distance[farthest]) {
+			farthest = cell
+		}
+
+		for _, direction := range mazeDirections() {
+			if cell.exits&direction.exit == 0 {
+				continue
+			}
+
+			neighbour := grid[d2geom.Point{X: cell.position.X + direction.dx, Y: cell.position.Y + direction.dy}]
+			if _, visited := distance[neighbour]; !visited {
+				distance[neighbour] = distance[cell] + 1
+				queue = append(queue, neighbour)
+			}
+		}
+	}
+
+	return farthest
+}
+
+// applySubstitutions replaces substitution groups of the placed rooms with the DS1 files of the
+// LvlSub.txt rows of the level sub type. The columns of the sub theme of the level are the number
+// of tries of a row, the chance of every try and the maximum number of substitutions.
+func (g *MapGenerator) applySubstitutions(details *d2records.LevelDetailRecord, region d2enum.RegionIdType,
+	placed []placedStamp) {
+	if details.SubType < 0 || details.SubTheme < 0 || details.SubTheme >= substitutionThemes {
+		return
+	}
+
+	areas := make([]d2geom.Rectangle, 0)
+
+	for idx := range placed {
+		for _, group := range placed[idx].stamp.SubstitutionGroups() {
+			areas = append(areas, d2geom.Rectangle{
+				Left:   placed[idx].x + int(group.TileX),
+				Top:    placed[idx].y + int(group.TileY),
+				Width:  int(group.WidthInTiles),
+				Height: int(group.HeightInTiles),
+			})
+		}
+	}
+
+	for _, record := range g.substitutions(details.SubType) {
+		chance, trials, maximum := substitutionRates(record, details.SubTheme)
+
+		var stamp *d2mapstamp.Stamp
+
+		for trial, count := 0, 0; trial < trials && count < maximum && len(areas) > 0; trial++ {
+			if g.rng.Intn(substitutionChance) >= chance {
+				continue
+			}
+
+			if stamp == nil {
+				g.engine.AddDS1(record.File)
+
+				if stamp = g.engine.LoadSubstitutionStamp(region, record.File); stamp == nil {
+					break
+				}
+			}
+
+			idx := g.rng.Intn(len(areas))
+			if stamp.Size().Width > areas[idx].Width || stamp.Size().Height > areas[idx].Height {
+				continue
+			}
+
+			g.engine.PlaceStamp(stamp, areas[idx].Left, areas[idx].Top)
+			areas = append(areas[:idx], areas[idx+1:]...)
+			count++
+		}
+	}
+}
+
+// substitutions returns the LvlSub.txt rows of a sub type, in the order of the file
+func (g *MapGenerator) substitutions(subType int) []*d2records.LevelSubstitutionRecord {
+	keys := make([]int, 0)
+
+	for key, record := range g.asset.Records.Level.Sub {
+		if record.ID == subType {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Ints(keys)
+
+	records := make([]*d2records.LevelSubstitutionRecord, len(keys))
+
+	for idx, key := range keys {
+		records[idx] = g.asset.Records.Level.Sub[key]
+	}
+
+	return records
+}
+
+func substitutionRates(record *d2records.LevelSubstitutionRecord, theme int) (chance, trials, maximum int) {
+	chances := [substitutionThemes]int{record.ChanceSpawn0, record.ChanceSpawn1, record.ChanceSpawn2,
+		record.ChanceSpawn3, record.ChanceSpawn4}
+	tries := [substitutionThemes]int{record.ChanceFloor0, record.ChanceFloor1, record.ChanceFloor2,
+		record.ChanceFloor3, record.ChanceFloor4}
+	maximums := [substitutionThemes]int{record.GridMax0, record.GridMax1, record.GridMax2,
+		record.GridMax3, record.GridMax4}
+
+	return chances[theme], tries[theme], maximums[theme]
+}
+
+// findWarps returns the warps on the special tiles of the level. The sub index of a special tile
+// with the warp main index is the Vis column of the linked level in Levels.txt. Only the tiles of
+// the region of the level are searched, as the act 1 town and the Blood Moor share a map.
+func (g *MapGenerator) findWarps(details *d2records.LevelDetailRecord) []Warp {
+	links := levelLinks(details)
+	warpIDs := [maxLevelLinks]int{details.WarpGraphicsID0, details.WarpGraphicsID1, details.WarpGraphicsID2,
+		details.WarpGraphicsID3, details.WarpGraphicsID4, details.WarpGraphicsID5, details.WarpGraphicsID6,
+		details.WarpGraphicsID7}
+	region := d2enum.RegionIdType(details.LevelType)
+	found := [maxLevelLinks]bool{}
+	warps := make([]Warp, 0)
+
+	for y := 0; y < g.engine.Size().Height; y++ {
+		for x := 0; x < g.engine.Size().Width; x++ {
+			tile := g.engine.Tile(x, y)
+			if tile.RegionType != region {
+				continue
+			}
+
+			for _, wall := range tile.Components.Walls {
+				vis := int(wall.Sequence)
+
+				if !wall.Type.Special() || wall.Style != warpStyle || vis >= maxLevelLinks || found[vis] || links[vis] <= 0 {
+					continue
+				}
+
+				found[vis] = true
+
+				warps = append(warps, g.newWarp(links[vis], warpIDs[vis], x, y))
+			}
+		}
+	}
+
+	return warps
+}
+
+func levelLinks(details *d2records.LevelDetailRecord) [maxLevelLinks]int {
+	return [maxLevelLinks]int{details.LevelLinkID0, details.LevelLinkID1, details.LevelLinkID2,
+		details.LevelLinkID3, details.LevelLinkID4, details.LevelLinkID5, details.LevelLinkID6,
+		details.LevelLinkID7}
+}
@@ -0,0 +1,77 @@
+package d2mapgen
+
+import (
+	"math/bits"
+	"math/rand"
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2geom"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+)
+
+func TestParseMazePreset(t *testing.T) {
+	tests := []struct {
+		name  string
+		ok    bool
+		kind  mazeRoomKind
+		exits mazeExits
+	}{
+		{"Act 1 - Cave 1 NW", true, mazeRoom, mazeNorth | mazeWest},
+		{"Act 1 - Cave Prev E", true, mazeEntrance, mazeEast},
+		{"Act 1 - Cave Down S", true, mazeExit, mazeSouth},
+		{"Act 1 - Cave Den Of Evil", false, mazeRoom, 0},
+		{"Act 1 - Cave 2 NN", false, mazeRoom, 0},
+		{"Act 1 - Crypt 1 N", false, mazeRoom, 0},
+	}
+
+	for _, test := range tests {
+		preset, ok := parseMazePreset("Act 1 - Cave", &d2records.LevelPresetRecord{Name: test.name})
+		if ok != test.ok || preset.kind != test.kind || preset.exits != test.exits {
+			t.Errorf("%s: unexpected preset %+v, %v", test.name, preset, ok)
+		}
+	}
+}
+
+func TestMazeLayout(t *testing.T) {
+	const rooms = 12
+
+	generator := &MapGenerator{rng: rand.New(rand.NewSource(1))}
+	cells := generator.mazeLayout(rooms, mazeMergeChance/2, true)
+
+	if len(cells) != rooms {
+		t.Fatalf("expected %d rooms, got %d", rooms, len(cells))
+	}
+
+	grid := make(map[d2geom.Point]*mazeCell)
+	kinds := make(map[mazeRoomKind]int)
+
+	for _, cell := range cells {
+		if cell.position.X < 0 || cell.position.Y < 0 {
+			t.Errorf("room at negative position %v", cell.position)
+		}
+
+		grid[cell.position] = cell
+		kinds[cell.kind]++
+
+		if cell.kind != mazeRoom && bits.OnesCount8(uint8(cell.exits)) != 1 {
+			t.Errorf("expected the entrance and the exit to be dead ends, got exits %s", cell.exits)
+		}
+	}
+
+	if kinds[mazeEntrance] != 1 || kinds[mazeExit] != 1 {
+		t.Errorf("expected one entrance and one exit, got %v", kinds)
+	}
+
+	for _, cell := range cells {
+		for _, direction := range mazeDirections() {
+			if cell.exits&direction.exit == 0 {
+				continue
+			}
+
+			neighbour := grid[d2geom.Point{X: cell.position.X + direction.dx, Y: cell.position.Y + direction.dy}]
+			if neighbour == nil || neighbour.exits&direction.opposite == 0 {
+				t.Errorf("exit %c of the room at %v has no matching exit", direction.letter, cell.position)
+			}
+		}
+	}
+}
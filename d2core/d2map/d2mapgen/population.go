@@ -0,0 +1,556 @@
+package d2mapgen
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2geom"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapengine"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapentity"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+)
+
+const (
+	// MonDen is a chance in 100000ths that a pack spawns on a tile
+	monsterDensityScale = 100000
+
+	// the members of a pack are placed this far (in sub tiles) around the centre of the pack
+	packSpread    = 4
+	placeAttempts = 12
+
+	// champion and unique monsters are a few levels above the monsters of their area
+	championLevelBonus = 2
+	uniqueLevelBonus   = 3
+
+	percent = 100
+)
+
+// superUniqueLevels returns the levels the super unique monsters of SuperUniques.txt are spawned
+// in, by their key
+func superUniqueLevels() map[string]int {
+	return map[string]int{
+		"Bishibosh":         3,  // Cold Plains
+		"Rakanishu":         4,  // Stony Field
+		"Treehead WoodFist": 5,  // Dark Wood
+		"Corpsefire":        8,  // Den of Evil
+		"Coldcrow":          9,  // Cave Level 1
+		"Bonebreaker":       18, // Crypt
+		"The Countess":      25, // Tower Cellar Level 5
+		"Pitspawn Fouldog":  30, // Jail Level 2
+		"Boneash":           33, // Cathedral
+		"Griswold":          38, // Tristram
+	}
+}
+
+// monsterSpawn is a monster placed by the population of a level
+type monsterSpawn struct {
+	id         string
+	record     *d2records.MonStatRecord
+	x, y       int // sub tiles
+	properties d2mapentity.MonsterProperties
+	name       string
+}
+
+// population places the monsters of a single level
+type population struct {
+	generator  *MapGenerator
+	details    *d2records.LevelDetailRecord
+	difficulty d2enum.DifficultyType
+	warps      []Warp
+	occupied   map[d2geom.Point]bool
+	spawns     []monsterSpawn
+}
+
+// populateLevel spawns the monsters of a level on the generated map
+func (g *MapGenerator) populateLevel(details *d2records.LevelDetailRecord, difficulty d2enum.DifficultyType,
+	warps []Warp) {
+	for _, spawn := range g.levelSpawns(details, difficulty, warps) {
+		npc, err := g.engine.NewNPCWithID(spawn.id, spawn.x, spawn.y, spawn.record, 0)
+		if err != nil {
+			g.Errorf("could not spawn monster %s: %v", spawn.id, err)
+			continue
+		}
+
+		npc.SetProperties(spawn.properties, spawn.name)
+		g.engine.AddEntity(npc)
+	}
+}
+
+// levelSpawns returns the monsters of a level. A pack of monsters spawns on each tile of the
+// level with the chance of MonDen, between MonUMin and MonUMax of the packs are champion or
+// unique packs, and the super unique monsters of the level are added to them.
+func (g *MapGenerator) levelSpawns(details *d2records.LevelDetailRecord, difficulty d2enum.DifficultyType,
+	warps []Warp) []monsterSpawn {
+	p := &population{
+		generator:  g,
+		details:    details,
+		difficulty: difficulty,
+		warps:      warps,
+		occupied:   make(map[d2geom.Point]bool),
+		spawns:     make([]monsterSpawn, 0),
+	}
+
+	for _, entity := range g.engine.Entities() {
+		position := entity.GetPosition()
+		p.occupied[d2geom.Point{X: int(position.X()), Y: int(position.Y())}] = true
+	}
+
+	tiles := p.levelTiles()
+	if len(tiles) == 0 {
+		return p.spawns
+	}
+
+	p.spawnPacks(tiles)
+	p.spawnSuperUniques(tiles)
+
+	return p.spawns
+}
+
+// levelTiles returns the tiles of the map which belong to the level
+func (p *population) levelTiles() []d2geom.Point {
+	engine := p.generator.engine
+	region := d2enum.RegionIdType(p.details.LevelType)
+	tiles := make([]d2geom.Point, 0)
+
+	for y := 0; y < engine.Size().Height; y++ {
+		for x := 0; x < engine.Size().Width; x++ {
+			if engine.Tile(x, y).RegionType == region {
+				tiles = append(tiles, d2geom.Point{X: x, Y: y})
+			}
+		}
+	}
+
+	return tiles
+}
+
+func (p *population) spawnPacks(tiles []d2geom.Point) {
+	rng := p.generator.rng
+	types := p.monsterTypes(p.monsterIDs())
+	density := p.byDifficulty(p.details.MonsterDensityNormal, p.details.MonsterDensityNightmare,
+		p.details.MonsterDensityHell)
+
+	if len(types) == 0 || density <= 0 {
+		return
+	}
+
+	centres := make([]d2geom.Point, 0)
+
+	for _, tile := range tiles {
+		if rng.Intn(monsterDensityScale) >= density {
+			continue
+		}
+
+		centre := d2geom.Point{X: tile.X*subTilesPerTile + subTilesPerTile/2, Y: tile.Y*subTilesPerTile + subTilesPerTile/2}
+		if p.isFree(centre.X, centre.Y) {
+			centres = append(centres, centre)
+		}
+	}
+
+	uniqueMin := p.byDifficulty(p.details.MonsterUniqueMinNormal, p.details.MonsterUniqueMinNightmare,
+		p.details.MonsterUniqueMinHell)
+	uniqueMax := p.byDifficulty(p.details.MonsterUniqueMaxNormal, p.details.MonsterUniqueMaxNightmare,
+		p.details.MonsterUniqueMaxHell)
+	special := make(map[int]bool)
+
+	for _, idx := range rng.Perm(len(centres))[:d2math.MinInt(p.between(uniqueMin, uniqueMax), len(centres))] {
+		special[idx] = true
+	}
+
+	// on normal the champions and uniques are picked from umon1-10
+	specialTypes := types
+	if p.difficulty == d2enum.DifficultyNormal {
+		if uniqueTypes := p.monsterTypes(p.uniqueMonsterIDs()); len(uniqueTypes) > 0 {
+			specialTypes = uniqueTypes
+		}
+	}
+
+	for idx, centre := range centres {
+		switch {
+		case !special[idx]:
+			p.spawnPack(types[rng.Intn(len(types))], centre)
+		case rng.Intn(percent) < p.constant(d2enum.ChampionChance):
+			p.spawnChampionPack(specialTypes[rng.Intn(len(specialTypes))], centre)
+		default:
+			p.spawnUniquePack(specialTypes[rng.Intn(len(specialTypes))], centre)
+		}
+	}
+}
+
+// spawnPack spawns a pack of regular monsters with the minions of their type
+func (p *population) spawnPack(record *d2records.MonStatRecord, centre d2geom.Point) {
+	properties := d2mapentity.MonsterProperties{Level: p.monsterLevel(record), Rank: d2enum.MonsterRankNormal}
+
+	for count := p.between(record.MinionGroupMin, record.MinionGroupMax); count > 0; count-- {
+		p.place(record, centre, properties, "")
+	}
+
+	p.spawnParty(record, centre, "")
+}
+
+// spawnParty spawns the minions of MonStats.txt which come with a monster, like the fallen
+// of a fallen shaman
+func (p *population) spawnParty(record *d2records.MonStatRecord, centre d2geom.Point, leaderID string) {
+	stats := p.generator.asset.Records.Monster.Stats
+
+	for _, minionID := range []string{record.MinionId1, record.MinionId2} {
+		minion := stats[minionID]
+		if minion == nil {
+			continue
+		}
+
+		properties := d2mapentity.MonsterProperties{Level: p.monsterLevel(minion), Rank: d2enum.MonsterRankNormal,
+			LeaderID: leaderID}
+
+		if leaderID != "" {
+			properties.Rank = d2enum.MonsterRankMinion
+		}
+
+		for count := p.between(record.MinionPartyMin, record.MinionPartyMax); count > 0; count-- {
+			p.place(minion, centre, properties, "")
+		}
+	}
+}
+
+// spawnChampionPack spawns a pack of champions, which share a champion modifier
+func (p *population) spawnChampionPack(record *d2records.MonStatRecord, centre d2geom.Point) {
+	properties := d2mapentity.MonsterProperties{
+		Level: p.monsterLevel(record) + championLevelBonus,
+		Rank:  d2enum.MonsterRankChampion,
+		Mods:  p.pickMods(1, true),
+	}
+
+	for count := p.between(record.MinionGroupMin, record.MinionGroupMax); count > 0; count-- {
+		p.place(record, centre, properties, "")
+	}
+}
+
+// spawnUniquePack spawns a unique monster with a random name and modifiers, followed by
+// minions of its type
+func (p *population) spawnUniquePack(record *d2records.MonStatRecord, centre d2geom.Point) {
+	properties := d2mapentity.MonsterProperties{
+		Level: p.monsterLevel(record) + uniqueLevelBonus,
+		Rank:  d2enum.MonsterRankUnique,
+		Mods:  p.pickMods(int(p.difficulty)+1, false), // one more modifier on each difficulty
+	}
+
+	leaderID, placed := p.place(record, centre, properties, p.uniqueName())
+	if !placed {
+		return
+	}
+
+	p.spawnMinions(record, centre, leaderID, p.between(record.MinionGroupMin, record.MinionGroupMax)-1)
+}
+
+func (p *population) spawnMinions(record *d2records.MonStatRecord, centre d2geom.Point, leaderID string, count int) {
+	properties := d2mapentity.MonsterProperties{Level: p.monsterLevel(record), Rank: d2enum.MonsterRankMinion,
+		LeaderID: leaderID}
+
+	for ; count > 0; count-- {
+		p.place(record, centre, properties, "")
+	}
+
+	p.spawnParty(record, centre, leaderID)
+}
+
+// spawnSuperUniques spawns the super unique monsters of the level on random tiles of the level
+func (p *population) spawnSuperUniques(tiles []d2geom.Point) {
+	records := p.generator.asset.Records
+	keys := make([]string, 0)
+
+	for key, levelID := range superUniqueLevels() {
+		if levelID == p.details.ID {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		superUnique := records.Monster.Unique.Super[key]
+		if superUnique == nil || records.Monster.Stats[superUnique.Class] == nil {
+			continue
+		}
+
+		record := records.Monster.Stats[superUnique.Class]
+		properties := d2mapentity.MonsterProperties{
+			Level: p.monsterLevel(record) + uniqueLevelBonus,
+			Rank:  d2enum.MonsterRankSuperUnique,
+			Mods:  make([]int, 0),
+		}
+
+		for _, mod := range superUnique.Mod {
+			if mod != 0 {
+				properties.Mods = append(properties.Mods, mod)
+			}
+		}
+
+		name := p.generator.asset.TranslateString(superUnique.Name)
+
+		for attempt := 0; attempt < placeAttempts; attempt++ {
+			tile := tiles[p.generator.rng.Intn(len(tiles))]
+			centre := d2geom.Point{X: tile.X*subTilesPerTile + subTilesPerTile/2, Y: tile.Y*subTilesPerTile + subTilesPerTile/2}
+
+			if leaderID, placed := p.place(record, centre, properties, name); placed {
+				p.spawnMinions(record, centre, leaderID, p.between(superUnique.MinGrp, superUnique.MaxGrp))
+				break
+			}
+		}
+	}
+}
+
+// place places a monster on a free sub tile around the centre of its pack
+func (p *population) place(record *d2records.MonStatRecord, centre d2geom.Point,
+	properties d2mapentity.MonsterProperties, name string) (string, bool) {
+	rng := p.generator.rng
+
+	for attempt := 0; attempt < placeAttempts; attempt++ {
+		x := centre.X + rng.Intn(2*packSpread+1) - packSpread
+		y := centre.Y + rng.Intn(2*packSpread+1) - packSpread
+
+		if !p.isFree(x, y) {
+			continue
+		}
+
+		id := fmt.Sprintf("%s@%d,%d", record.Key, x, y)
+
+		p.occupied[d2geom.Point{X: x, Y: y}] = true
+		p.spawns = append(p.spawns, monsterSpawn{id: id, record: record, x: x, y: y, properties: properties,
+			name: name})
+
+		return id, true
+	}
+
+	return "", false
+}
+
+// isFree returns true if a monster can be placed on the sub tile. The sub tile has to be
+// walkable, unoccupied, on the level and not too close to a warp.
+func (p *population) isFree(x, y int) bool {
+	engine := p.generator.engine
+
+	if p.occupied[d2geom.Point{X: x, Y: y}] || !engine.IsWalkable(x, y, d2mapengine.PathMoverMonster) {
+		return false
+	}
+
+	if engine.Tile(x/subTilesPerTile, y/subTilesPerTile).RegionType != d2enum.RegionIdType(p.details.LevelType) {
+		return false
+	}
+
+	for idx := range p.warps {
+		if p.warps[idx].Distance(float64(x), float64(y)) < float64(p.details.WarpClearanceDistance) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// monsterIDs returns the monster types of the level for the difficulty, mon1-10 on normal and
+// nmon1-10 on nightmare and hell
+func (p *population) monsterIDs() []string {
+	d := p.details
+
+	if p.difficulty == d2enum.DifficultyNormal {
+		return []string{d.MonsterID1Normal, d.MonsterID2Normal, d.MonsterID3Normal, d.MonsterID4Normal,
+			d.MonsterID5Normal, d.MonsterID6Normal, d.MonsterID7Normal, d.MonsterID8Normal, d.MonsterID9Normal,
+			d.MonsterID10Normal}
+	}
+
+	return []string{d.MonsterID1Nightmare, d.MonsterID2Nightmare, d.MonsterID3Nightmare, d.MonsterID4Nightmare,
+		d.MonsterID5Nightmare, d.MonsterID6Nightmare, d.MonsterID7Nightmare, d.MonsterID8Nightmare,
+		d.MonsterID9Nightmare, d.MonsterID10Nightmare}
+}
+
+func (p *population) uniqueMonsterIDs() []string {
+	d := p.details
+
+	return []string{d.MonsterUniqueID1, d.MonsterUniqueID2, d.MonsterUniqueID3, d.MonsterUniqueID4,
+		d.MonsterUniqueID5, d.MonsterUniqueID6, d.MonsterUniqueID7, d.MonsterUniqueID8, d.MonsterUniqueID9,
+		d.MonsterUniqueID10}
+}
+
+// monsterTypes returns the enabled and killable monsters of the given IDs. At most NumMon of
+// them are picked at random.
+func (p *population) monsterTypes(ids []string) []*d2records.MonStatRecord {
+	types := make([]*d2records.MonStatRecord, 0)
+
+	for _, id := range ids {
+		if record := p.generator.asset.Records.Monster.Stats[id]; record != nil && record.Enabled && record.IsKillable {
+			types = append(types, record)
+		}
+	}
+
+	p.generator.rng.Shuffle(len(types), func(i, j int) {
+		types[i], types[j] = types[j], types[i]
+	})
+
+	if p.details.NumMonsterTypes > 0 && len(types) > p.details.NumMonsterTypes {
+		types = types[:p.details.NumMonsterTypes]
+	}
+
+	return types
+}
+
+// monsterLevel returns the level of a monster. On nightmare and hell the monsters take the area
+// level of Levels.txt, unless they are bosses.
+func (p *population) monsterLevel(record *d2records.MonStatRecord) int {
+	if p.difficulty == d2enum.DifficultyNormal || record.IsSpecialBoss {
+		return p.byDifficulty(record.LevelNormal, record.LevelNightmare, record.LevelHell)
+	}
+
+	d := p.details
+	if level := p.byDifficulty(d.MonsterLevelNormalEx, d.MonsterLevelNightmareEx, d.MonsterLevelHellEx); level > 0 {
+		return level
+	}
+
+	return p.byDifficulty(d.MonsterLevelNormal, d.MonsterLevelNightmare, d.MonsterLevelHell)
+}
+
+// pickMods picks the given number of distinct modifiers of MonUMod.txt, weighted by their pick
+// frequency for champions or uniques on the difficulty
+func (p *population) pickMods(count int, champion bool) []int {
+	mods := make([]*d2records.MonUModRecord, 0)
+
+	for _, mod := range p.generator.asset.Records.Monster.Unique.Mods {
+		if mod.Enabled && mod.Champion == champion && p.pickFrequency(mod, champion) > 0 {
+			mods = append(mods, mod)
+		}
+	}
+
+	sort.Slice(mods, func(i, j int) bool {
+		return mods[i].ID < mods[j].ID
+	})
+
+	picked := make([]int, 0, count)
+	excluded := make(map[string]bool)
+
+	for ; count > 0; count-- {
+		total := 0
+
+		for _, mod := range mods {
+			if !excluded[mod.Name] {
+				total += p.pickFrequency(mod, champion)
+			}
+		}
+
+		if total == 0 {
+			break
+		}
+
+		roll := p.generator.rng.Intn(total)
+
+		for _, mod := range mods {
+			if excluded[mod.Name] {
+				continue
+			}
+
+			if roll -= p.pickFrequency(mod, champion); roll < 0 {
+				picked = append(picked, mod.ID)
+				excluded[mod.Name], excluded[mod.Exclude1], excluded[mod.Exclude2] = true, true, true
+
+				break
+			}
+		}
+	}
+
+	return picked
+}
+
+func (p *population) pickFrequency(mod *d2records.MonUModRecord, champion bool) int {
+	frequencies := mod.PickFrequencies.Normal
+
+	switch p.difficulty {
+	case d2enum.DifficultyNightmare:
+		frequencies = mod.PickFrequencies.Nightmare
+	case d2enum.DifficultyHell:
+		frequencies = mod.PickFrequencies.Hell
+	}
+
+	if frequencies == nil {
+		return 0
+	}
+
+	if champion {
+		return frequencies.Champion
+	}
+
+	return frequencies.Unique
+}
+
+// uniqueName returns a random name of a unique monster, made of a prefix and a suffix of
+// UniquePrefix.txt and UniqueSuffix.txt and an appellation of UniqueAppellation.txt
+func (p *population) uniqueName() string {
+	records := p.generator.asset.Records
+	parts := make([]string, 0)
+
+	for _, keys := range [][]string{
+		sortedKeys(records.Monster.Name.Prefix),
+		sortedKeys(records.Monster.Name.Suffix),
+		sortedAppellations(records.Monster.Unique.Appellations),
+	} {
+		if len(keys) == 0 {
+			return ""
+		}
+
+		parts = append(parts, p.generator.asset.TranslateString(keys[p.generator.rng.Intn(len(keys))]))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func sortedKeys(affixes d2records.UniqueMonsterAffixes) []string {
+	keys := make([]string, 0, len(affixes))
+
+	for key := range affixes {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func sortedAppellations(appellations d2records.UniqueAppellations) []string {
+	keys := make([]string, 0, len(appellations))
+
+	for key := range appellations {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// constant returns a constant of MonUMod.txt, or 0 if it is missing
+func (p *population) constant(index d2enum.MonUModConstIndex) int {
+	constants := p.generator.asset.Records.Monster.Unique.Constants
+	if int(index) >= len(constants) {
+		return 0
+	}
+
+	return constants[index]
+}
+
+// between returns a random number from min to max, both included
+func (p *population) between(min, max int) int {
+	if max <= min {
+		return min
+	}
+
+	return min + p.generator.rng.Intn(max-min+1)
+}
+
+func (p *population) byDifficulty(normal, nightmare, hell int) int {
+	switch p.difficulty {
+	case d2enum.DifficultyNightmare:
+		return nightmare
+	case d2enum.DifficultyHell:
+		return hell
+	default:
+		return normal
+	}
+}
@@ -0,0 +1,76 @@
+package d2mapgen
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapengine"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+)
+
+func TestMapGenerator_LevelSpawns(t *testing.T) {
+	asset := testAssetManager(t)
+	engine := generateTestOverworld(t, asset, 42)
+
+	asset.Records.Monster.Stats = d2records.MonStats{
+		"zombie1": {Key: "zombie1", Enabled: true, IsKillable: true, MinionGroupMin: 2, MinionGroupMax: 3,
+			LevelNormal: 1},
+	}
+	asset.Records.Monster.Unique.Mods = d2records.MonsterUniqueModifiers{
+		"extra strong": {Name: "extra strong", ID: 5, Enabled: true, PickFrequencies: struct {
+			Normal    *d2records.PickFreq
+			Nightmare *d2records.PickFreq
+			Hell      *d2records.PickFreq
+		}{Normal: &d2records.PickFreq{Unique: 1}}},
+	}
+
+	details := *asset.Records.GetLevelDetails(wildernessDetailsRecordID)
+	details.MonsterID1Normal = "zombie1"
+	details.MonsterDensityNormal = monsterDensityScale / 10
+	details.MonsterUniqueMinNormal, details.MonsterUniqueMaxNormal = 1, 1
+
+	spawn := func() []monsterSpawn {
+		generator := &MapGenerator{asset: asset, engine: engine, rng: rand.New(rand.NewSource(1))}
+		return generator.levelSpawns(&details, d2enum.DifficultyNormal, nil)
+	}
+
+	spawns := spawn()
+	if len(spawns) == 0 {
+		t.Fatal("expected monsters to spawn")
+	}
+
+	if !reflect.DeepEqual(spawns, spawn()) {
+		t.Error("expected the same monsters for the same seed")
+	}
+
+	positions := make(map[string]bool)
+	ranks := make(map[d2enum.MonsterRank]int)
+
+	for _, s := range spawns {
+		if positions[s.id] {
+			t.Errorf("two monsters on the same sub tile: %s", s.id)
+		}
+
+		positions[s.id] = true
+		ranks[s.properties.Rank]++
+
+		if !engine.IsWalkable(s.x, s.y, d2mapengine.PathMoverMonster) {
+			t.Errorf("monster %s on a sub tile which is not walkable", s.id)
+		}
+
+		if region := engine.Tile(s.x/subTilesPerTile, s.y/subTilesPerTile).RegionType; int(region) != details.LevelType {
+			t.Errorf("monster %s outside of the level, in region %d", s.id, region)
+		}
+
+		if s.properties.Rank == d2enum.MonsterRankUnique &&
+			(s.properties.Level != 1+uniqueLevelBonus || !reflect.DeepEqual(s.properties.Mods, []int{5})) {
+			t.Errorf("unexpected properties of the unique monster: %+v", s.properties)
+		}
+	}
+
+	if ranks[d2enum.MonsterRankUnique] != 1 {
+		t.Errorf("expected one unique monster, got %v", ranks)
+	}
+}
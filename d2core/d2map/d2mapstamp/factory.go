@@ -10,6 +10,7 @@ import (
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2fileformats/d2ds1"
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2util"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2asset"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
 )
 
 const logPrefix = "Map Stamp"
@@ -19,6 +20,7 @@ func NewStampFactory(asset *d2asset.AssetManager, l d2util.LogLevel, entity *d2m
 	result := &StampFactory{
 		asset:  asset,
 		entity: entity,
+		rng:    rand.New(rand.NewSource(0)), //nolint:gosec // map generation needs reproducible randomness
 	}
 
 	result.Logger = d2util.NewLogger()
@@ -33,19 +35,64 @@ func NewStampFactory(asset *d2asset.AssetManager, l d2util.LogLevel, entity *d2m
 type StampFactory struct {
 	asset  *d2asset.AssetManager
 	entity *d2mapentity.MapEntityFactory
+	rng    *rand.Rand
 
 	*d2util.Logger
 }
 
+// SetRandomSource sets the random number generator used to pick the level file of a stamp, so
+// that the same seed always picks the same files.
+func (f *StampFactory) SetRandomSource(rng *rand.Rand) {
+	f.rng = rng
+}
+
 // LoadStamp loads the Stamp data from file, using the given level type, level preset index, and
 // level file index.
 func (f *StampFactory) LoadStamp(levelType d2enum.RegionIdType, levelPreset, fileIndex int) *Stamp {
+	stamp := f.newStamp(levelType, f.asset.Records.Level.Presets[levelPreset])
+	if stamp == nil {
+		return nil
+	}
+
+	var levelFilesToPick []string
+
+	for _, fileRecord := range stamp.levelPreset.Files {
+		if fileRecord != "" && fileRecord != "0" {
+			levelFilesToPick = append(levelFilesToPick, fileRecord)
+		}
+	}
+
+	levelIndex := int(math.Round(float64(len(levelFilesToPick)-1) * f.rng.Float64()))
+	if fileIndex >= 0 && fileIndex < len(levelFilesToPick) {
+		levelIndex = fileIndex
+	}
+
+	if levelFilesToPick == nil {
+		panic("no level files to pick from")
+	}
+
+	return f.loadDS1(stamp, levelFilesToPick[levelIndex])
+}
+
+// LoadSubstitutionStamp loads a stamp that is not part of a level preset, like the DS1 files of
+// the level substitutions in LvlSub.txt.
+func (f *StampFactory) LoadSubstitutionStamp(levelType d2enum.RegionIdType, file string) *Stamp {
+	stamp := f.newStamp(levelType, d2records.LevelPresetRecord{})
+	if stamp == nil {
+		return nil
+	}
+
+	return f.loadDS1(stamp, file)
+}
+
+// newStamp creates a stamp with the tiles of the level type
+func (f *StampFactory) newStamp(levelType d2enum.RegionIdType, levelPreset d2records.LevelPresetRecord) *Stamp {
 	stamp := &Stamp{
 		factory:     f,
 		entity:      f.entity,
 		regionID:    levelType,
 		levelType:   *f.asset.Records.Level.Types[levelType],
-		levelPreset: f.asset.Records.Level.Presets[levelPreset],
+		levelPreset: levelPreset,
 	}
 
 	for _, levelTypeDt1 := range &stamp.levelType.Files {
@@ -62,25 +109,12 @@ func (f *StampFactory) LoadStamp(levelType d2enum.RegionIdType, levelPreset, fil
 		stamp.tiles = append(stamp.tiles, dt1.Tiles...)
 	}
 
-	var levelFilesToPick []string
-
-	for _, fileRecord := range stamp.levelPreset.Files {
-		if fileRecord != "" && fileRecord != "0" {
-			levelFilesToPick = append(levelFilesToPick, fileRecord)
-		}
-	}
-
-	// nolint:gosec // not a big deal for now
-	levelIndex := int(math.Round(float64(len(levelFilesToPick)-1) * rand.Float64()))
-	if fileIndex >= 0 && fileIndex < len(levelFilesToPick) {
-		levelIndex = fileIndex
-	}
-
-	if levelFilesToPick == nil {
-		panic("no level files to pick from")
-	}
+	return stamp
+}
 
-	stamp.regionPath = levelFilesToPick[levelIndex]
+// loadDS1 loads the DS1 file of a stamp
+func (f *StampFactory) loadDS1(stamp *Stamp, regionPath string) *Stamp {
+	stamp.regionPath = regionPath
 	fileData, err := f.asset.LoadFile("/data/global/tiles/" + stamp.regionPath)
 
 	if err != nil {
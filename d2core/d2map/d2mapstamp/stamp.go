@@ -1,6 +1,8 @@
 package d2mapstamp
 
 import (
+	"fmt"
+
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2fileformats/d2ds1"
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2fileformats/d2dt1"
@@ -59,6 +61,11 @@ func (mr *Stamp) Tile(x, y int) *d2ds1.TileRecord {
 	return &mr.ds1.Tiles[y][x]
 }
 
+// SubstitutionGroups returns the areas of the stamp which can be replaced by level substitutions.
+func (mr *Stamp) SubstitutionGroups() []d2ds1.SubstitutionGroup {
+	return mr.ds1.SubstitutionGroups
+}
+
 // TileData returns the tile data for the tile with given style, sequence and type.
 func (mr *Stamp) TileData(style, sequence int32, tileType d2enum.TileType) *d2dt1.Tile {
 	for idx := range mr.tiles {
@@ -85,7 +92,8 @@ func (mr *Stamp) Entities(tileOffsetX, tileOffsetY int) []d2interface.MapEntity
 				// Temorary use of Lookup.
 				// nolint:gomnd // constant modifier
 				npcX, npcY := (tileOffsetX*5)+object.X, (tileOffsetY*5)+object.Y
-				npc, err := mr.entity.NewNPC(npcX, npcY, monstat, 0)
+				npcID := fmt.Sprintf("%s@%d,%d", monstat.Key, npcX, npcY)
+				npc, err := mr.entity.NewNPCWithID(npcID, npcX, npcY, monstat, 0)
 
 				if err == nil {
 					npc.SetPaths(convertPaths(tileOffsetX, tileOffsetY, object.Paths))
@@ -107,8 +115,9 @@ func (mr *Stamp) Entities(tileOffsetX, tileOffsetY int) []d2interface.MapEntity
 
 			if objectRecord != nil {
 				// nolint:gomnd // constant
-				entity, err := mr.entity.NewObject((tileOffsetX*5)+object.X,
-					(tileOffsetY*5)+object.Y, objectRecord, d2resource.PaletteUnits)
+				objectX, objectY := (tileOffsetX*5)+object.X, (tileOffsetY*5)+object.Y
+				objectID := fmt.Sprintf("%s@%d,%d", objectRecord.Name, objectX, objectY)
+				entity, err := mr.entity.NewObjectWithID(objectID, objectX, objectY, objectRecord, d2resource.PaletteUnits)
 
 				if err != nil {
 					panic(err)
@@ -0,0 +1,143 @@
+package d2monsterai
+
+import (
+	"math"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+)
+
+// Behavior is the core behaviour of a group of AIs of MonAI.txt
+type Behavior int
+
+// Behaviors
+const (
+	// BehaviorIdle never acts, like the NPCs in town
+	BehaviorIdle Behavior = iota
+	// BehaviorMelee approaches its target and attacks it, like zombies
+	BehaviorMelee
+	// BehaviorRanged keeps its distance to its target and shoots at it, like skeleton archers
+	BehaviorRanged
+	// BehaviorFallen attacks like BehaviorMelee, but flees when another fallen dies nearby
+	BehaviorFallen
+	// BehaviorShaman shoots at its target and resurrects the dead fallen around it
+	BehaviorShaman
+)
+
+const (
+	// TicksPerSecond is the rate monsters are advanced at, the frame rate of the original game
+	TicksPerSecond = 25
+
+	// defaultAggroRange is the aggro range (in sub tiles) of monsters without an `aidist`
+	defaultAggroRange = 35
+
+	meleeRange        = 4.0  // sub tiles
+	minRangedRange    = 20.0 // sub tiles
+	rangedMinDistance = 10.0 // a ranged monster backs off from targets closer than this (sub tiles)
+)
+
+// aiBehaviors returns the behaviours of the AIs which do not follow from the flags of their
+// monsters
+func aiBehaviors() map[string]Behavior {
+	return map[string]Behavior{
+		"Idle":          BehaviorIdle,
+		"NpcStationary": BehaviorIdle,
+		"Npc":           BehaviorIdle,
+		"Fallen":        BehaviorFallen,
+		"FallenShaman":  BehaviorShaman,
+		"CorruptArcher": BehaviorRanged,
+		"SkeletonBow":   BehaviorRanged,
+	}
+}
+
+// Params are the parameters of the AI of a monster
+type Params struct {
+	Behavior    Behavior
+	AggroRange  float64 // the distance (in sub tiles) a monster notices targets at
+	AttackRange float64 // the distance (in sub tiles) a monster attacks from
+	Delay       int     // the ticks between two decisions of the AI
+	Speed       float64 // sub tiles per second
+	Parameters  [8]int  // the `aip` columns of MonStats.txt
+}
+
+// NewParams returns the parameters of the AI of a monster on the given difficulty. Monsters
+// with an AI which is missing from MonAI.txt are idle.
+func NewParams(record *d2records.MonStatRecord, ai d2records.MonsterAI, difficulty d2enum.DifficultyType) Params {
+	params := Params{
+		Behavior:   behaviorOf(record, ai),
+		AggroRange: float64(byDifficulty(difficulty, record.AiDistanceNormal, record.AiDistanceNightmare, record.AiDistanceHell)),
+		Delay:      byDifficulty(difficulty, record.AiDelayNormal, record.AiDelayNightmare, record.AiDelayHell),
+		Speed:      record.WalkSpeed(),
+		Parameters: [8]int{
+			byDifficulty(difficulty, record.AiParameterNormal1, record.AiParameterNightmare1, record.AiParameterHell1),
+			byDifficulty(difficulty, record.AiParameterNormal2, record.AiParameterNightmare2, record.AiParameterHell2),
+			byDifficulty(difficulty, record.AiParameterNormal3, record.AiParameterNightmare3, record.AiParameterHell3),
+			byDifficulty(difficulty, record.AiParameterNormal4, record.AiParameterNightmare4, record.AiParameterHell4),
+			byDifficulty(difficulty, record.AiParameterNormal5, record.AiParameterNightmare5, record.AiParameterHell5),
+			byDifficulty(difficulty, record.AiParameterNormal6, record.AiParameterNightmare6, record.AiParameterHell6),
+			byDifficulty(difficulty, record.AiParameterNormal7, record.AiParameterNightmare7, record.AiParameterHell7),
+			byDifficulty(difficulty, record.AiParameterNormal8, record.AiParameterNightmare8, record.AiParameterHell8),
+		},
+	}
+
+	if params.AggroRange <= 0 {
+		params.AggroRange = defaultAggroRange
+	}
+
+	params.AttackRange = meleeRange
+	if params.Behavior == BehaviorRanged || params.Behavior == BehaviorShaman {
+		params.AttackRange = params.AggroRange
+		if params.AttackRange < minRangedRange {
+			params.AttackRange = minRangedRange
+		}
+	}
+
+	return params
+}
+
+// NewFollowerParams returns the parameters of the AI of a follower of a player, like a hireling.
+// A follower fights like a melee or a ranged monster, whatever the AI of its monster.
+func NewFollowerParams(record *d2records.MonStatRecord, difficulty d2enum.DifficultyType) Params {
+	params := NewParams(record, nil, difficulty)
+	params.Behavior = BehaviorMelee
+	params.AttackRange = meleeRange
+
+	if record.IsRanged {
+		params.Behavior = BehaviorRanged
+		params.AttackRange = math.Max(params.AggroRange, minRangedRange)
+	}
+
+	return params
+}
+
+func behaviorOf(record *d2records.MonStatRecord, ai d2records.MonsterAI) Behavior {
+	if record.IsNpc || !record.IsKillable {
+		return BehaviorIdle
+	}
+
+	if _, found := ai[record.AiKey]; !found {
+		return BehaviorIdle
+	}
+
+	if behavior, found := aiBehaviors()[record.AiKey]; found {
+		return behavior
+	}
+
+	if record.IsRanged {
+		return BehaviorRanged
+	}
+
+	return BehaviorMelee
+}
+
+// byDifficulty returns the value of a column of a record for the difficulty
+func byDifficulty(difficulty d2enum.DifficultyType, normal, nightmare, hell int) int {
+	switch difficulty {
+	case d2enum.DifficultyNightmare:
+		return nightmare
+	case d2enum.DifficultyHell:
+		return hell
+	default:
+		return normal
+	}
+}
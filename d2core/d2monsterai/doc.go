@@ -0,0 +1,4 @@
+// Package d2monsterai decides what monsters do, based on the AI of their MonStats.txt record.
+// Monsters are advanced in fixed ticks and only see the world through an interface, so the AI
+// runs on the server without a renderer and can be tested deterministically.
+package d2monsterai
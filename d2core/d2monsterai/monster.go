@@ -0,0 +1,370 @@
+package d2monsterai
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math/d2vector"
+)
+
+const (
+	// attackTicks is how long an attack keeps a monster busy
+	attackTicks = TicksPerSecond / 2
+
+	// a fleeing monster runs this far (in sub tiles) away and does not attack in the meantime
+	fleeDistance = 20.0
+	fleeTicks    = 2 * TicksPerSecond
+
+	// resurrectTicks is the time between two resurrections of a shaman
+	resurrectTicks = 4 * TicksPerSecond
+
+	// leashFactor is how much farther than its aggro range a monster follows its target
+	leashFactor = 2
+
+	// repathDistance is how far (in sub tiles) the destination of a monster has to move before
+	// a new path is searched
+	repathDistance = 2.0
+
+	// a follower stays within followDistance (in sub tiles) of its leader while it has nothing
+	// to attack, and returns to its leader when it gets farther away than followLeash
+	followDistance = 10.0
+	followLeash    = 40.0
+)
+
+// Unit is an entity the AI of a monster sees
+type Unit struct {
+	ID       string
+	Position d2vector.Position
+	Dead     bool
+	Behavior Behavior // the behaviour of a monster, for allies
+}
+
+// World is the map a monster is on, as seen by its AI
+type World interface {
+	// Targets returns the entities monsters attack
+	Targets() []Unit
+	// Allies returns the monsters on the map, including the dead ones
+	Allies() []Unit
+	// PathFind returns the waypoints of a walkable path from start to dest
+	PathFind(start, dest d2vector.Position) []d2vector.Position
+}
+
+// Action is what the AI of a monster decided to do in a tick
+type Action struct {
+	Type     d2enum.MonsterActionType
+	TargetID string              // the target of an attack or a resurrection
+	Path     []d2vector.Position // the waypoints of a move
+}
+
+// Monster is the AI state of a single monster
+type Monster struct {
+	Params
+	ID       string
+	Position d2vector.Position
+	LeaderID string // the ally a follower, like a hireling, follows
+
+	path      []d2vector.Position
+	rng       *rand.Rand
+	wait      int // ticks until the next decision
+	cooldown  int // ticks until a shaman can resurrect again
+	fleeing   int // ticks the monster keeps fleeing
+	targetID  string
+	knownDead map[string]bool
+}
+
+// NewMonster returns the AI of a monster at the given position. The decisions of monsters
+// created with the same parameters and random source are the same.
+func NewMonster(id string, position d2vector.Position, params Params, rng *rand.Rand) *Monster {
+	monster := &Monster{
+		Params:    params,
+		ID:        id,
+		Position:  position,
+		rng:       rng,
+		knownDead: make(map[string]bool),
+	}
+
+	// monsters which were spawned together do not act in the same tick
+	monster.wait = rng.Intn(monster.delay() + 1)
+
+	return monster
+}
+
+// Destination returns the position the monster is moving to
+func (m *Monster) Destination() d2vector.Position {
+	if len(m.path) == 0 {
+		return m.Position
+	}
+
+	return m.path[len(m.path)-1]
+}
+
+// Reset clears the path and the target of the monster, when it died or was resurrected
+func (m *Monster) Reset() {
+	m.path = nil
+	m.targetID = ""
+	m.fleeing = 0
+	m.wait = m.delay()
+}
+
+func (m *Monster) delay() int {
+	if m.Delay < 1 {
+		return 1
+	}
+
+	return m.Delay
+}
+
+// Tick advances the monster by one tick. The monster moves along its path, and the AI
+// decides what the monster does next every Delay ticks.
+func (m *Monster) Tick(world World) Action {
+	m.step()
+
+	if m.cooldown > 0 {
+		m.cooldown--
+	}
+
+	if m.fleeing > 0 {
+		m.fleeing--
+	}
+
+	if m.wait > 0 {
+		m.wait--
+		return Action{}
+	}
+
+	m.wait = m.delay()
+
+	return m.decide(world)
+}
+
+// step moves the monster along its path by the distance of one tick
+func (m *Monster) step() {
+	remaining := m.Speed / TicksPerSecond
+
+	for len(m.path) > 0 && remaining > 0 {
+		to := m.path[0]
+		distance := m.Position.Distance(&to.Vector)
+
+		if remaining < distance {
+			step := to.Vector.Clone()
+			step.Subtract(&m.Position.Vector)
+			step.Scale(remaining / distance)
+			step.Add(&m.Position.Vector)
+
+			m.Position = d2vector.NewPosition(step.X(), step.Y())
+
+			return
+		}
+
+		remaining -= distance
+		m.Position = to
+		m.path = m.path[1:]
+	}
+}
+
+func (m *Monster) decide(world World) Action {
+	if m.Behavior == BehaviorIdle {
+		return Action{}
+	}
+
+	if action, fled := m.fleeFromDeadAllies(world); fled {
+		return action
+	}
+
+	if m.fleeing > 0 {
+		return Action{}
+	}
+
+	if m.Behavior == BehaviorShaman && m.cooldown == 0 {
+		if ally, found := m.resurrectTarget(world); found {
+			m.cooldown = resurrectTicks
+			m.path = nil
+
+			return Action{Type: d2enum.MonsterActionResurrect, TargetID: ally.ID}
+		}
+	}
+
+	leader, following := m.leader(world)
+	if following && m.Position.Distance(&leader.Position.Vector) > followLeash {
+		m.targetID = ""
+		return m.follow(world, leader.Position)
+	}
+
+	target, found := m.findTarget(world)
+	if !found {
+		m.targetID = ""
+
+		if following && m.Position.Distance(&leader.Position.Vector) > followDistance {
+			return m.follow(world, leader.Position)
+		}
+
+		return Action{}
+	}
+
+	m.targetID = target.ID
+	distance := m.Position.Distance(&target.Position.Vector)
+	ranged := m.Behavior == BehaviorRanged || m.Behavior == BehaviorShaman
+
+	switch {
+	case ranged && distance < rangedMinDistance:
+		return m.moveAway(world, target.Position, fleeDistance)
+	case distance <= m.AttackRange:
+		m.path = nil
+		m.wait += attackTicks
+
+		return Action{Type: d2enum.MonsterActionAttack, TargetID: target.ID}
+	}
+
+	return m.approach(world, target.Position)
+}
+
+// findTarget returns the target of the monster. The monster keeps its target until the target
+// dies or gets out of the leash range, then it picks the closest target in its aggro range.
+func (m *Monster) findTarget(world World) (Unit, bool) {
+	var closest Unit
+
+	closestDistance := m.AggroRange
+	found := false
+
+	for _, target := range world.Targets() {
+		if target.Dead {
+			continue
+		}
+
+		distance := m.Position.Distance(&target.Position.Vector)
+
+		if target.ID == m.targetID && distance <= m.AggroRange*leashFactor {
+			return target, true
+		}
+
+		if distance < closestDistance || distance == closestDistance && found && target.ID < closest.ID {
+			closest, closestDistance, found = target, distance, true
+		}
+	}
+
+	return closest, found
+}
+
+// approach moves the monster into the attack range of the target position
+func (m *Monster) approach(world World, target d2vector.Position) Action {
+	offset := m.Position.Vector.Clone()
+	offset.Subtract(&target.Vector)
+	offset.SetLength(m.AttackRange / 2) //nolint:gomnd // well inside of the attack range
+	offset.Add(&target.Vector)
+
+	return m.moveTo(world, d2vector.NewPosition(offset.X(), offset.Y()))
+}
+
+// leader returns the ally the monster follows, if it has a living one
+func (m *Monster) leader(world World) (Unit, bool) {
+	if m.LeaderID == "" {
+		return Unit{}, false
+	}
+
+	for _, ally := range world.Allies() {
+		if ally.ID == m.LeaderID && !ally.Dead {
+			return ally, true
+		}
+	}
+
+	return Unit{}, false
+}
+
+// follow moves the monster close to its leader
+func (m *Monster) follow(world World, leader d2vector.Position) Action {
+	offset := m.Position.Vector.Clone()
+	offset.Subtract(&leader.Vector)
+	offset.SetLength(followDistance / 2) //nolint:gomnd // well inside of the follow distance
+	offset.Add(&leader.Vector)
+
+	return m.moveTo(world, d2vector.NewPosition(offset.X(), offset.Y()))
+}
+
+// moveAway moves the monster the given distance away from a position
+func (m *Monster) moveAway(world World, from d2vector.Position, distance float64) Action {
+	direction := m.Position.Vector.Clone()
+	direction.Subtract(&from.Vector)
+
+	if direction.IsZero() {
+		angle := m.rng.Float64() * 2 * math.Pi
+		direction = d2vector.NewVector(math.Cos(angle), math.Sin(angle))
+	}
+
+	direction.SetLength(distance)
+	direction.Add(&m.Position.Vector)
+
+	return m.moveTo(world, d2vector.NewPosition(direction.X(), direction.Y()))
+}
+
+func (m *Monster) moveTo(world World, dest d2vector.Position) Action {
+	if len(m.path) > 0 {
+		current := m.Destination()
+		if current.Distance(&dest.Vector) < repathDistance {
+			return Action{}
+		}
+	}
+
+	path := world.PathFind(m.Position, dest)
+	if len(path) == 0 {
+		return Action{}
+	}
+
+	m.path = path
+
+	return Action{Type: d2enum.MonsterActionMove, Path: path}
+}
+
+// fleeFromDeadAllies makes a fallen flee from a fallen which died in its aggro range
+func (m *Monster) fleeFromDeadAllies(world World) (Action, bool) {
+	fleeFrom := make([]d2vector.Position, 0)
+
+	for _, ally := range world.Allies() {
+		if !ally.Dead {
+			delete(m.knownDead, ally.ID)
+			continue
+		}
+
+		if ally.ID == m.ID || m.knownDead[ally.ID] {
+			continue
+		}
+
+		m.knownDead[ally.ID] = true
+
+		if m.Behavior == BehaviorFallen && ally.Behavior == BehaviorFallen &&
+			m.Position.Distance(&ally.Position.Vector) <= m.AggroRange {
+			fleeFrom = append(fleeFrom, ally.Position)
+		}
+	}
+
+	if len(fleeFrom) == 0 {
+		return Action{}, false
+	}
+
+	m.fleeing = fleeTicks
+	m.targetID = ""
+	m.path = nil
+
+	return m.moveAway(world, fleeFrom[0], fleeDistance), true
+}
+
+// resurrectTarget returns the closest dead fallen in the aggro range of a shaman
+func (m *Monster) resurrectTarget(world World) (Unit, bool) {
+	var closest Unit
+
+	closestDistance := m.AggroRange
+	found := false
+
+	for _, ally := range world.Allies() {
+		if !ally.Dead || ally.ID == m.ID || ally.Behavior != BehaviorFallen {
+			continue
+		}
+
+		distance := m.Position.Distance(&ally.Position.Vector)
+		if distance < closestDistance || distance == closestDistance && found && ally.ID < closest.ID {
+			closest, closestDistance, found = ally, distance, true
+		}
+	}
+
+	return closest, found
+}
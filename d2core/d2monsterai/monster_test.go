@@ -0,0 +1,232 @@
+package d2monsterai
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math/d2vector"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+)
+
+// testWorld is an open map without walls
+type testWorld struct {
+	targets []Unit
+	allies  []Unit
+}
+
+func (w *testWorld) Targets() []Unit {
+	return w.targets
+}
+
+func (w *testWorld) Allies() []Unit {
+	return w.allies
+}
+
+func (w *testWorld) PathFind(_, dest d2vector.Position) []d2vector.Position {
+	return []d2vector.Position{dest}
+}
+
+func testParams(behavior Behavior) Params {
+	params := Params{Behavior: behavior, AggroRange: 30, AttackRange: meleeRange, Delay: 5, Speed: 10}
+	if behavior == BehaviorRanged || behavior == BehaviorShaman {
+		params.AttackRange = params.AggroRange
+	}
+
+	return params
+}
+
+func newTestMonster(id string, x float64, behavior Behavior) *Monster {
+	return NewMonster(id, d2vector.NewPosition(x, 0), testParams(behavior), rand.New(rand.NewSource(1)))
+}
+
+// tickUntil ticks the monster until it takes an action of the given type, and fails the test
+// if it takes none within the given number of ticks
+func tickUntil(t *testing.T, monster *Monster, world World, actionType d2enum.MonsterActionType, ticks int) Action {
+	t.Helper()
+
+	for tick := 0; tick < ticks; tick++ {
+		if action := monster.Tick(world); action.Type == actionType {
+			return action
+		}
+	}
+
+	t.Fatalf("%s took no action %d in %d ticks", monster.ID, actionType, ticks)
+
+	return Action{}
+}
+
+func TestNewParams(t *testing.T) {
+	ai := d2records.MonsterAI{"Zombie": {AI: "Zombie"}, "FallenShaman": {AI: "FallenShaman"}}
+
+	tests := []struct {
+		record   d2records.MonStatRecord
+		behavior Behavior
+	}{
+		{d2records.MonStatRecord{AiKey: "Zombie", IsKillable: true}, BehaviorMelee},
+		{d2records.MonStatRecord{AiKey: "Zombie", IsKillable: true, IsRanged: true}, BehaviorRanged},
+		{d2records.MonStatRecord{AiKey: "FallenShaman", IsKillable: true}, BehaviorShaman},
+		{d2records.MonStatRecord{AiKey: "Zombie", IsKillable: true, IsNpc: true}, BehaviorIdle},
+		{d2records.MonStatRecord{AiKey: "Unknown", IsKillable: true}, BehaviorIdle},
+	}
+
+	for _, test := range tests {
+		record := test.record
+		if params := NewParams(&record, ai, d2enum.DifficultyNormal); params.Behavior != test.behavior {
+			t.Errorf("%+v: expected behavior %d, got %d", test.record, test.behavior, params.Behavior)
+		}
+	}
+
+	record := d2records.MonStatRecord{AiKey: "Zombie", IsKillable: true, AiDistanceHell: 50, AiDelayHell: 3}
+	params := NewParams(&record, ai, d2enum.DifficultyHell)
+
+	if params.AggroRange != 50 || params.Delay != 3 || params.AttackRange != meleeRange {
+		t.Errorf("unexpected params on hell: %+v", params)
+	}
+
+	if params = NewParams(&record, ai, d2enum.DifficultyNormal); params.AggroRange != defaultAggroRange {
+		t.Errorf("expected the default aggro range, got %v", params.AggroRange)
+	}
+
+	// a monster with the walk velocity of the players in CharStats.txt walks about as fast as the
+	// players, 9 sub tiles per second
+	record.SpeedBase = 6
+
+	if params = NewParams(&record, ai, d2enum.DifficultyNormal); params.Speed != 9.375 {
+		t.Errorf("expected a walk speed of 9.375 sub tiles per second, got %v", params.Speed)
+	}
+}
+
+func TestMonster_ApproachAndAttack(t *testing.T) {
+	world := &testWorld{targets: []Unit{{ID: "player", Position: d2vector.NewPosition(20, 0)}}}
+	monster := newTestMonster("zombie", 0, BehaviorMelee)
+
+	move := tickUntil(t, monster, world, d2enum.MonsterActionMove, 10)
+	if len(move.Path) == 0 {
+		t.Fatal("expected a path to the target")
+	}
+
+	attack := tickUntil(t, monster, world, d2enum.MonsterActionAttack, 3*TicksPerSecond)
+	if attack.TargetID != "player" {
+		t.Errorf("expected an attack on the player, got %+v", attack)
+	}
+
+	if distance := monster.Position.Distance(&world.targets[0].Position.Vector); distance > meleeRange {
+		t.Errorf("expected the monster in melee range, it is %v away", distance)
+	}
+}
+
+func TestMonster_IgnoresTargetsOutOfRange(t *testing.T) {
+	world := &testWorld{targets: []Unit{{ID: "player", Position: d2vector.NewPosition(100, 0)}}}
+	monster := newTestMonster("zombie", 0, BehaviorMelee)
+
+	for tick := 0; tick < 2*TicksPerSecond; tick++ {
+		if action := monster.Tick(world); action.Type != d2enum.MonsterActionNone {
+			t.Fatalf("expected no action, got %+v", action)
+		}
+	}
+}
+
+func TestMonster_RangedKeepsDistance(t *testing.T) {
+	world := &testWorld{targets: []Unit{{ID: "player", Position: d2vector.NewPosition(5, 0)}}}
+	monster := newTestMonster("archer", 0, BehaviorRanged)
+
+	move := tickUntil(t, monster, world, d2enum.MonsterActionMove, 10)
+	if dest := move.Path[len(move.Path)-1]; dest.X() >= 0 {
+		t.Errorf("expected the archer to back off, it moves to %s", dest)
+	}
+
+	attack := tickUntil(t, monster, world, d2enum.MonsterActionAttack, 3*TicksPerSecond)
+	if attack.TargetID != "player" {
+		t.Errorf("expected an attack on the player, got %+v", attack)
+	}
+}
+
+func TestMonster_FallenFlees(t *testing.T) {
+	world := &testWorld{
+		targets: []Unit{{ID: "player", Position: d2vector.NewPosition(2, 0)}},
+		allies:  []Unit{{ID: "other", Position: d2vector.NewPosition(-2, 0), Behavior: BehaviorFallen}},
+	}
+	monster := newTestMonster("fallen", 0, BehaviorFallen)
+
+	tickUntil(t, monster, world, d2enum.MonsterActionAttack, 10)
+
+	world.allies[0].Dead = true
+
+	move := tickUntil(t, monster, world, d2enum.MonsterActionMove, attackTicks+10)
+	if dest := move.Path[len(move.Path)-1]; dest.X() <= 0 {
+		t.Errorf("expected the fallen to flee from the dead fallen, it moves to %s", dest)
+	}
+
+	for tick := 0; tick < fleeTicks-2*monster.Delay; tick++ {
+		if action := monster.Tick(world); action.Type == d2enum.MonsterActionAttack {
+			t.Fatal("expected the fallen not to attack while fleeing")
+		}
+	}
+}
+
+func TestMonster_ShamanResurrects(t *testing.T) {
+	world := &testWorld{
+		allies: []Unit{
+			{ID: "fallen2", Position: d2vector.NewPosition(10, 0), Behavior: BehaviorFallen, Dead: true},
+			{ID: "fallen1", Position: d2vector.NewPosition(5, 0), Behavior: BehaviorFallen, Dead: true},
+			{ID: "zombie", Position: d2vector.NewPosition(1, 0), Behavior: BehaviorMelee, Dead: true},
+		},
+	}
+	monster := newTestMonster("shaman", 0, BehaviorShaman)
+
+	action := tickUntil(t, monster, world, d2enum.MonsterActionResurrect, 10)
+	if action.TargetID != "fallen1" {
+		t.Errorf("expected the closest fallen to be resurrected, got %s", action.TargetID)
+	}
+
+	for tick := 0; tick < resurrectTicks-monster.Delay; tick++ {
+		if action := monster.Tick(world); action.Type == d2enum.MonsterActionResurrect {
+			t.Fatal("expected the shaman to wait before the next resurrection")
+		}
+	}
+}
+
+func TestMonster_FollowsLeader(t *testing.T) {
+	world := &testWorld{allies: []Unit{{ID: "player", Position: d2vector.NewPosition(30, 0)}}}
+	hireling := newTestMonster("hireling", 0, BehaviorMelee)
+	hireling.LeaderID = "player"
+
+	action := tickUntil(t, hireling, world, d2enum.MonsterActionMove, 10)
+	if dest := action.Path[len(action.Path)-1]; dest.Distance(&world.allies[0].Position.Vector) > followDistance {
+		t.Errorf("expected the hireling to move next to its leader, got %s", dest)
+	}
+
+	// a target close to the hireling is attacked, a target close to a leader far away is not
+	world.targets = []Unit{{ID: "zombie", Position: d2vector.NewPosition(-2, 0)}}
+	hireling = newTestMonster("hireling", 0, BehaviorMelee)
+	hireling.LeaderID = "player"
+
+	tickUntil(t, hireling, world, d2enum.MonsterActionAttack, 10)
+
+	world.allies[0].Position = d2vector.NewPosition(100, 0)
+
+	action = tickUntil(t, hireling, world, d2enum.MonsterActionMove, 2*TicksPerSecond)
+	if dest := action.Path[len(action.Path)-1]; dest.Distance(&world.allies[0].Position.Vector) > followDistance {
+		t.Errorf("expected the hireling to return to its leader, got %s", dest)
+	}
+}
+
+func TestMonster_Deterministic(t *testing.T) {
+	run := func() []Action {
+		world := &testWorld{targets: []Unit{{ID: "player", Position: d2vector.NewPosition(25, 10)}}}
+		monster := NewMonster("zombie", d2vector.NewPosition(0, 0), testParams(BehaviorMelee), rand.New(rand.NewSource(42)))
+		actions := make([]Action, 0)
+
+		for tick := 0; tick < 4*TicksPerSecond; tick++ {
+			actions = append(actions, monster.Tick(world))
+		}
+
+		return actions
+	}
+
+	if !reflect.DeepEqual(run(), run()) {
+		t.Error("expected the same actions for the same seed")
+	}
+}
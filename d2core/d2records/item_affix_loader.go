@@ -83,8 +83,11 @@ func createItemAffixRecords(
 	records := make(map[string]*ItemAffixCommonRecord)
 	groups := make(ItemAffixGroups)
 
+	i := 0
+
 	for d.Next() {
 		affix := &ItemAffixCommonRecord{
+			Index:          i,
 			Name:           d.String("Name"),
 			Version:        d.Number("version"),
 			Type:           subType,
@@ -144,6 +147,8 @@ func createItemAffixRecords(
 		group.AddMember(affix)
 
 		records[affix.Name] = affix
+
+		i++
 	}
 
 	if d.Err != nil {
@@ -34,6 +34,7 @@ type ItemAffixCommonRecord struct {
 	Class          string
 	TransformColor string
 
+	Index   int // Line number in file, the index of the affix in saved items
 	Version int
 	Type    d2enum.ItemAffixSubType
 
@@ -16,6 +16,7 @@ func levelMazeDetailsLoader(r *RecordManager, d *d2txt.DataDictionary) error {
 			NumRoomsHell:      d.Number("Rooms(H)"),
 			SizeX:             d.Number("SizeX"),
 			SizeY:             d.Number("SizeY"),
+			Merge:             d.Number("Merge"),
 		}
 		records[record.LevelID] = record
 	}
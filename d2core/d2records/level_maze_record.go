@@ -26,8 +26,10 @@ type LevelMazeDetailRecord struct {
 	SizeY int // SizeY
 
 	// Possibly related to how adjacent .ds1s are connected with each other,
-	// but what the different values are for is unknown.
-	// Merge int // Merge
+	// but what the different values are for is unknown. The maze generator
+	// uses it as the chance, in thousandths, that two neighbouring rooms
+	// which are not connected yet are connected as well.
+	Merge int // Merge
 
 	// Included in the original Diablo II beta tests and in the demo version.
 	// Beta
@@ -2,6 +2,16 @@ package d2records
 
 import "github.com/OpenDiablo2/OpenDiablo2/d2common/d2calculation"
 
+const (
+	// velocityDivisor converts the velocities of the txt files, which are in 1/16 of a sub tile
+	// per frame, to sub tiles per frame
+	velocityDivisor = 16
+
+	// framesPerSecond is the frame rate of the original game, the durations of the txt files
+	// are in frames
+	framesPerSecond = 25
+)
+
 // Missiles stores all of the MissileRecords
 type Missiles map[int]*MissileRecord
 
@@ -199,3 +209,9 @@ type MissileRecord struct {
 	HalfDamageForTwoHander bool // if true, damage is halved when a two-handed weapon is used
 
 }
+
+// Reach returns how far (in sub tiles) the missile flies with its velocity. The Range of a missile
+// is not a distance, it is the lifetime of the missile in frames.
+func (m *MissileRecord) Reach() float64 {
+	return float64(m.Velocity*m.Range) / velocityDivisor
+}
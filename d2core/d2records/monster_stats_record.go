@@ -676,3 +676,9 @@ type (
 
 	}
 )
+
+// WalkSpeed returns the speed (in sub tiles per second) the monster walks at. The Velocity of
+// MonStats.txt is in 1/16 of a sub tile per frame of the game, which runs at 25 frames per second.
+func (r *MonStatRecord) WalkSpeed() float64 {
+	return float64(r.SpeedBase) * framesPerSecond / velocityDivisor
+}
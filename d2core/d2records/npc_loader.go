@@ -10,7 +10,7 @@ func npcLoader(r *RecordManager, d *d2txt.DataDictionary) error {
 	for d.Next() {
 		record := &NPCRecord{
 			Name: d.String("npc"),
-			Multipliers: &costMultiplier{
+			Multipliers: &CostMultiplier{
 				Buy:    float64(d.Number("buy mult")) / costDivisor,
 				Sell:   float64(d.Number("sell mult")) / costDivisor,
 				Repair: float64(d.Number("rep mult")) / costDivisor,
@@ -26,11 +26,11 @@ func npcLoader(r *RecordManager, d *d2txt.DataDictionary) error {
 			},
 		}
 
-		record.QuestMultipliers = make(map[int]*costMultiplier)
+		record.QuestMultipliers = make(map[int]*CostMultiplier)
 
 		if flagStr := d.String("questflag A"); flagStr != "" {
 			flag := d.Number("questflag A")
-			record.QuestMultipliers[flag] = &costMultiplier{
+			record.QuestMultipliers[flag] = &CostMultiplier{
 				float64(d.Number("questbuymult A")) / costDivisor,
 				float64(d.Number("questsellmult A")) / costDivisor,
 				float64(d.Number("questrepmult A")) / costDivisor,
@@ -39,7 +39,7 @@ func npcLoader(r *RecordManager, d *d2txt.DataDictionary) error {
 
 		if flagStr := d.String("questflag B"); flagStr != "" {
 			flag := d.Number("questflag B")
-			record.QuestMultipliers[flag] = &costMultiplier{
+			record.QuestMultipliers[flag] = &CostMultiplier{
 				float64(d.Number("questbuymult B")) / costDivisor,
 				float64(d.Number("questsellmult B")) / costDivisor,
 				float64(d.Number("questrepmult B")) / costDivisor,
@@ -48,7 +48,7 @@ func npcLoader(r *RecordManager, d *d2txt.DataDictionary) error {
 
 		if flagStr := d.String("questflag C"); flagStr != "" {
 			flag := d.Number("questflag C")
-			record.QuestMultipliers[flag] = &costMultiplier{
+			record.QuestMultipliers[flag] = &CostMultiplier{
 				float64(d.Number("questbuymult C")) / costDivisor,
 				float64(d.Number("questsellmult C")) / costDivisor,
 				float64(d.Number("questrepmult C")) / costDivisor,
@@ -13,9 +13,9 @@ type NPCRecord struct {
 	// Name is an ID pointer to row of this npc in monstats.txt
 	Name string
 
-	Multipliers *costMultiplier
+	Multipliers *CostMultiplier
 
-	QuestMultipliers map[int]*costMultiplier
+	QuestMultipliers map[int]*CostMultiplier
 
 	// MaxBuy is the maximum amount of gold an NPC will pay for an item for the corresponding
 	// difficulty
@@ -26,7 +26,8 @@ type NPCRecord struct {
 	}
 }
 
-type costMultiplier struct {
+// CostMultiplier holds the price multipliers of an NPC
+type CostMultiplier struct {
 	// Buy is a percentage of base item price used when an item is bought by NPC
 	Buy float64
 
@@ -28,8 +28,11 @@ const (
 func runewordLoader(r *RecordManager, d *d2txt.DataDictionary) error {
 	records := make(map[string]*RuneRecord)
 
+	i := 0
+
 	for d.Next() {
 		record := &RuneRecord{
+			Index:    i,
 			Name:     d.String("name"),
 			RuneName: d.String("Rune Name"),
 			Complete: d.Bool("complete"),
@@ -68,7 +71,7 @@ func runewordLoader(r *RecordManager, d *d2txt.DataDictionary) error {
 
 		for idx := 0; idx < numRunewordProperties; idx++ {
 			codeColumn := fmt.Sprintf(fmtRunewordPropCode, idx+1)
-			if code := codeColumn; code != "" {
+			if code := d.String(codeColumn); code != "" {
 				prop := &RunewordProperty{
 					code,
 					d.String(fmt.Sprintf(fmtRunewordPropParam, idx+1)),
@@ -81,6 +84,8 @@ func runewordLoader(r *RecordManager, d *d2txt.DataDictionary) error {
 		}
 
 		records[record.Name] = record
+
+		i++
 	}
 
 	if d.Err != nil {
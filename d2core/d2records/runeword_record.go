@@ -6,6 +6,7 @@ type Runewords map[string]*RuneRecord
 // RuneRecord is a representation of a single row of runes.txt. It defines
 // runewords available in the game.
 type RuneRecord struct {
+	Index    int // Line number in file, the index of the runeword in saved items
 	Name     string
 	RuneName string // More of a note - the actual name should be read from the TBL files.
 	Complete bool   // An enabled/disabled flag. Only "Complete" runewords work in game.
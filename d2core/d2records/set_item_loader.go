@@ -28,8 +28,11 @@ type SetItemProperty = PropertyDescriptor
 func setItemLoader(r *RecordManager, d *d2txt.DataDictionary) error {
 	records := make(map[string]*SetItemRecord)
 
+	i := 0
+
 	for d.Next() {
 		record := &SetItemRecord{
+			Index:                     i,
 			SetItemKey:                d.String("index"),
 			SetKey:                    d.String("set"),
 			ItemCode:                  d.String("item"),
@@ -84,8 +87,12 @@ func setItemLoader(r *RecordManager, d *d2txt.DataDictionary) error {
 		}
 
 		record.Properties = props
+		record.SetPropertiesLevel1 = bonus1
+		record.SetPropertiesLevel2 = bonus2
 
 		records[record.SetItemKey] = record
+
+		i++
 	}
 
 	if d.Err != nil {
@@ -5,6 +5,10 @@ type SetItems map[string]*SetItemRecord
 
 // SetItemRecord represents a set item
 type SetItemRecord struct {
+	// Index
+	// line number in file, the index of the set item in saved items
+	Index int
+
 	// SetItemKey (index)
 	// string key to item's name in a .tbl file
 	SetItemKey string
@@ -91,9 +95,12 @@ type SetItemRecord struct {
 	// Properties are a propert code, parameter, min, max for generating an item propert
 	Properties [numPropertiesOnSetItem]*SetItemProperty
 
-	// SetPropertiesLevel1 is the first version of bonus properties for the set
+	// SetPropertiesLevel1 is the first version of bonus properties for the set (aprop1a to
+	// aprop5a). With AddFn 1 the bonus at index n is given when the n-th other item of the set
+	// is worn, with AddFn 2 when n+2 items of the set are worn.
 	SetPropertiesLevel1 [numBonusPropertiesOnSetItem]*SetItemProperty
 
-	// SetPropertiesLevel2 is the second version of bonus properties for the set
+	// SetPropertiesLevel2 is the second version of bonus properties for the set (aprop1b to
+	// aprop5b)
 	SetPropertiesLevel2 [numBonusPropertiesOnSetItem]*SetItemProperty
 }
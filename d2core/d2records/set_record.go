@@ -22,7 +22,9 @@ type SetRecord struct {
 	// (reference only, not loaded into game).
 	Level int
 
-	// Properties contains the partial and full set bonus properties.
+	// Properties contains the partial and full set bonus properties. PartialA and PartialB are
+	// indexed by the number of worn items of the set, from 2 to 5, and are nil where the set has
+	// no bonus.
 	Properties struct {
 		PartialA []*SetProperty
 		PartialB []*SetProperty
@@ -8,8 +8,11 @@ import (
 func uniqueItemsLoader(r *RecordManager, d *d2txt.DataDictionary) error {
 	records := make(UniqueItems)
 
+	i := 0
+
 	for d.Next() {
 		record := &UniqueItemRecord{
+			Index:   i,
 			Name:    d.String("index"),
 			Version: d.Number("version"),
 			Enabled: d.Number("enabled") == 1,
@@ -115,6 +118,8 @@ func uniqueItemsLoader(r *RecordManager, d *d2txt.DataDictionary) error {
 			},
 		}
 
+		i++
+
 		if record.Name == "" {
 			continue
 		}
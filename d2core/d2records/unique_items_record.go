@@ -7,6 +7,8 @@ type UniqueItems map[string]*UniqueItemRecord
 type UniqueItemRecord struct {
 	Properties [12]*UniqueItemProperty
 
+	Index int // Line number in file, the index of the unique item in saved items
+
 	Name                  string
 	Code                  string // three letter code, points to a record in Weapons, Armor, or Misc
 	TypeDescription       string
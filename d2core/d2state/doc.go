@@ -0,0 +1,3 @@
+// Package d2state keeps the states of States.txt an entity is in, like the buffs of shrines,
+// auras and curses, along with the stats they give and the time they expire.
+package d2state
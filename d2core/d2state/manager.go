@@ -0,0 +1,194 @@
+package d2state
+
+import (
+	"sort"
+	"time"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2stats"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2stats/diablo2stats"
+)
+
+// State is a state of States.txt an entity is in
+type State struct {
+	Record  *d2records.StateRecord
+	Stats   map[string]int // the values of the stats of ItemStatCost.txt given by the state
+	Expires time.Time      // the state does not expire if zero
+}
+
+// Name returns the name of the state in States.txt
+func (s *State) Name() string {
+	return s.Record.State
+}
+
+func (s *State) expired(now time.Time) bool {
+	return !s.Expires.IsZero() && !now.Before(s.Expires)
+}
+
+// SavedState is a state an entity is in when it is saved, like in the HeroState of a player
+type SavedState struct {
+	State     string         `json:"state"`
+	Stats     map[string]int `json:"stats,omitempty"`
+	Remaining float64        `json:"remaining"` // the seconds until the state expires, zero if it does not
+}
+
+// Record returns the record of States.txt of a state. States which are missing from States.txt
+// get a record of their own, so they can be kept all the same.
+func Record(records d2records.States, name string) *d2records.StateRecord {
+	if record, found := records[name]; found {
+		return record
+	}
+
+	return &d2records.StateRecord{State: name}
+}
+
+// Manager keeps the states of an entity. An entity is in a state once, and in one state of a
+// group of States.txt.
+type Manager struct {
+	states map[string]*State
+}
+
+// NewManager creates a Manager without states
+func NewManager() *Manager {
+	return &Manager{states: make(map[string]*State)}
+}
+
+// Set puts the entity in a state until it expires. The state replaces the state of the same
+// name and the states of the same group, the names of the replaced states of other names are
+// returned.
+func (m *Manager) Set(record *d2records.StateRecord, stats map[string]int, expires time.Time) []string {
+	replaced := make([]string, 0)
+
+	if record.Group != 0 {
+		for name, state := range m.states {
+			if name != record.State && state.Record.Group == record.Group {
+				delete(m.states, name)
+				replaced = append(replaced, name)
+			}
+		}
+	}
+
+	if stats == nil {
+		stats = make(map[string]int)
+	}
+
+	m.states[record.State] = &State{Record: record, Stats: stats, Expires: expires}
+
+	sort.Strings(replaced)
+
+	return replaced
+}
+
+// Remove removes a state, it returns false if the entity is not in the state
+func (m *Manager) Remove(name string) bool {
+	if _, found := m.states[name]; !found {
+		return false
+	}
+
+	delete(m.states, name)
+
+	return true
+}
+
+// Expire removes the states which expired at the given time and returns their names
+func (m *Manager) Expire(now time.Time) []string {
+	expired := make([]string, 0)
+
+	for name, state := range m.states {
+		if state.expired(now) {
+			delete(m.states, name)
+			expired = append(expired, name)
+		}
+	}
+
+	sort.Strings(expired)
+
+	return expired
+}
+
+// Get returns a state of the entity, or nil if the entity is not in the state
+func (m *Manager) Get(name string) *State {
+	return m.states[name]
+}
+
+// Has returns true if the entity is in a state
+func (m *Manager) Has(name string) bool {
+	return m.states[name] != nil
+}
+
+// States returns the states of the entity, ordered by name
+func (m *Manager) States() []*State {
+	states := make([]*State, 0, len(m.states))
+
+	for _, state := range m.states {
+		states = append(states, state)
+	}
+
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].Name() < states[j].Name()
+	})
+
+	return states
+}
+
+// Stat returns the total value of a stat given by the states
+func (m *Manager) Stat(name string) int {
+	total := 0
+
+	for _, state := range m.states {
+		total += state.Stats[name]
+	}
+
+	return total
+}
+
+// Save returns the states of the entity, with the time they have left at the given time
+func (m *Manager) Save(now time.Time) []SavedState {
+	saved := make([]SavedState, 0, len(m.states))
+
+	for _, state := range m.States() {
+		remaining := 0.0
+		if !state.Expires.IsZero() {
+			remaining = state.Expires.Sub(now).Seconds()
+		}
+
+		saved = append(saved, SavedState{State: state.Name(), Stats: state.Stats, Remaining: remaining})
+	}
+
+	return saved
+}
+
+// Load puts the entity in the saved states, which expire after the time they had left
+func (m *Manager) Load(records d2records.States, saved []SavedState, now time.Time) {
+	for idx := range saved {
+		expires := time.Time{}
+		if saved[idx].Remaining > 0 {
+			expires = now.Add(time.Duration(saved[idx].Remaining * float64(time.Second)))
+		}
+
+		m.Set(Record(records, saved[idx].State), saved[idx].Stats, expires)
+	}
+}
+
+// StatList returns the stats given by the states, merged into one stat list. Stats which are
+// not in ItemStatCost.txt are left out.
+func (m *Manager) StatList(factory *diablo2stats.StatFactory) d2stats.StatList {
+	stats := make([]d2stats.Stat, 0)
+
+	for _, state := range m.States() {
+		names := make([]string, 0, len(state.Stats))
+		for name := range state.Stats {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		for _, name := range names {
+			if stat := factory.NewStat(name, float64(state.Stats[name])); stat != nil {
+				stats = append(stats, stat)
+			}
+		}
+	}
+
+	return factory.NewStatList(stats...).ReduceStats()
+}
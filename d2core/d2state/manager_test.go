@@ -0,0 +1,68 @@
+package d2state
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2util"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2asset"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2stats/diablo2stats"
+)
+
+func TestManager(t *testing.T) {
+	now := time.Now()
+	armor := &d2records.StateRecord{State: "shrine_armor", Group: 1}
+	fire := &d2records.StateRecord{State: "shrine_resist_fire", Group: 1}
+	aura := &d2records.StateRecord{State: "resistfire"}
+
+	manager := NewManager()
+	manager.Set(armor, map[string]int{"item_armor_percent": 100}, now.Add(time.Second))
+	manager.Set(aura, map[string]int{"fireresist": 30}, time.Time{})
+
+	if replaced := manager.Set(fire, map[string]int{"fireresist": 50}, now.Add(time.Minute)); !reflect.DeepEqual(replaced,
+		[]string{"shrine_armor"}) {
+		t.Errorf("expected the state of the same group to be replaced, got %v", replaced)
+	}
+
+	if manager.Has("shrine_armor") || manager.Stat("fireresist") != 80 {
+		t.Errorf("unexpected states: %v", manager.States())
+	}
+
+	asset, err := d2asset.NewAssetManager(d2util.LogLevelNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	asset.Records.Item.Stats = map[string]*d2records.ItemStatCostRecord{"fireresist": {Name: "fireresist"}}
+
+	factory, err := diablo2stats.NewStatFactory(asset)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stats := manager.StatList(factory).Stats()
+	if len(stats) != 1 || stats[0].Values()[0].Int() != 80 {
+		t.Errorf("expected the resistances of the states to be merged, got %v", stats)
+	}
+
+	saved := manager.Save(now)
+
+	loaded := NewManager()
+	loaded.Load(d2records.States{fire.State: fire}, saved, now)
+
+	if state := loaded.Get("shrine_resist_fire"); state == nil || state.Record != fire ||
+		!state.Expires.Equal(now.Add(time.Minute)) || loaded.Get("resistfire") == nil ||
+		!loaded.Get("resistfire").Expires.IsZero() {
+		t.Errorf("expected the saved states to be loaded, got %v", saved)
+	}
+
+	if expired := manager.Expire(now.Add(time.Minute)); !reflect.DeepEqual(expired, []string{"shrine_resist_fire"}) {
+		t.Errorf("expected the shrine to expire, got %v", expired)
+	}
+
+	if !manager.Has("resistfire") {
+		t.Error("expected the state without a duration to stay")
+	}
+}
@@ -1,36 +1,136 @@
 package d2gamescreen
 
 import (
-	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2video"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2interface"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2resource"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2util"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2asset"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2screen"
 )
 
-// BlizzardIntro represents the Blizzard Intro screen
+// BlizzardIntro represents the Blizzard Intro screen, which plays the Blizzard North video
+// before the main menu
 type BlizzardIntro struct {
-	asset        *d2asset.AssetManager
-	videoDecoder *d2video.BinkDecoder
+	asset         *d2asset.AssetManager
+	renderer      d2interface.Renderer
+	navigator     d2interface.Navigator
+	inputManager  d2interface.InputManager
+	audioProvider d2interface.AudioProvider
+	video         *videoPlayer
+	done          bool
+
+	*d2util.Logger
 }
 
 // CreateBlizzardIntro creates a Blizzard Intro screen
-func CreateBlizzardIntro(asset *d2asset.AssetManager) *BlizzardIntro {
-	return &BlizzardIntro{
-		asset: asset,
+func CreateBlizzardIntro(
+	navigator d2interface.Navigator,
+	asset *d2asset.AssetManager,
+	renderer d2interface.Renderer,
+	inputManager d2interface.InputManager,
+	audioProvider d2interface.AudioProvider,
+	l d2util.LogLevel) *BlizzardIntro {
+	intro := &BlizzardIntro{
+		asset:         asset,
+		renderer:      renderer,
+		navigator:     navigator,
+		inputManager:  inputManager,
+		audioProvider: audioProvider,
 	}
+
+	intro.Logger = d2util.NewLogger()
+	intro.Logger.SetPrefix(logPrefix)
+	intro.Logger.SetLevel(l)
+
+	return intro
 }
 
 // OnLoad loads the resources for the Blizzard Intro screen
 func (v *BlizzardIntro) OnLoad(loading d2screen.LoadingState) {
-	videoBytes, err := v.asset.LoadFile("/data/local/video/BlizNorth640x480.bik")
+	v.audioProvider.PlayBGM("")
+
+	video, err := newVideoPlayer(v.asset, v.renderer, v.audioProvider, d2resource.BlizzardIntro)
 	if err != nil {
-		loading.Error(err)
-		return
+		// the main menu is shown when the video can not be played
+		v.Error(err.Error())
 	}
 
+	v.video = video
+
 	loading.Progress(fiftyPercent)
 
-	v.videoDecoder, err = d2video.CreateBinkDecoder(videoBytes)
+	if err := v.inputManager.BindHandler(v); err != nil {
+		v.Error(err.Error())
+	}
+}
+
+// OnUnload stops the video and releases the input handler of the Blizzard Intro screen
+func (v *BlizzardIntro) OnUnload() error {
+	if err := v.inputManager.UnbindHandler(v); err != nil {
+		return err
+	}
+
+	if v.video != nil {
+		return v.video.stop()
+	}
+
+	return nil
+}
+
+// Advance plays the video and goes to the main menu when it has ended
+func (v *BlizzardIntro) Advance(elapsed float64) error {
+	if v.video == nil {
+		v.finish()
+		return nil
+	}
+
+	playing, err := v.video.advance(elapsed)
 	if err != nil {
-		loading.Error(err)
+		v.Error(err.Error())
+	}
+
+	if !playing {
+		v.finish()
 	}
+
+	return nil
+}
+
+// Render renders the video of the Blizzard Intro screen
+func (v *BlizzardIntro) Render(screen d2interface.Surface) {
+	if v.video != nil {
+		v.video.render(screen)
+	}
+}
+
+// OnKeyUp skips the video with the keys which skip it in the retail game
+func (v *BlizzardIntro) OnKeyUp(event d2interface.KeyEvent) bool {
+	switch event.Key() {
+	case d2enum.KeyEscape, d2enum.KeyEnter, d2enum.KeySpace:
+		v.finish()
+		return true
+	}
+
+	return false
+}
+
+// OnMouseButtonDown skips the video
+func (v *BlizzardIntro) OnMouseButtonDown(event d2interface.MouseEvent) bool {
+	if event.Button() == d2enum.MouseButtonLeft {
+		v.finish()
+		return true
+	}
+
+	return false
+}
+
+func (v *BlizzardIntro) finish() {
+	if v.done {
+		return
+	}
+
+	v.done = true
+
+	v.navigator.ToMainMenu()
 }
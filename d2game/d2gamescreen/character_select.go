@@ -35,12 +35,12 @@ func CreateCharacterSelect(
 	l d2util.LogLevel,
 	connectionHost string,
 ) (*CharacterSelect, error) {
-	playerStateFactory, err := d2hero.NewHeroStateFactory(asset)
+	playerStateFactory, err := d2hero.NewHeroStateFactory(asset, l)
 	if err != nil {
 		return nil, err
 	}
 
-	entityFactory, err := d2mapentity.NewMapEntityFactory(asset)
+	entityFactory, err := d2mapentity.NewMapEntityFactory(asset, l)
 	if err != nil {
 		return nil, err
 	}
@@ -337,15 +337,11 @@ func (v *CharacterSelect) updateCharacterBoxes() {
 		v.characterStatsLabel[i].SetText(d2ui.ColorTokenize(heroInfo, d2ui.ColorTokenWhite))
 		v.characterExpLabel[i].SetText(d2ui.ColorTokenize(expText, d2ui.ColorTokenGreen))
 
-		heroType := v.gameStates[idx].HeroType
-		equipment := v.DefaultHeroItems[heroType]
-
-		// https://github.com/OpenDiablo2/OpenDiablo2/issues/791
 		v.characterImage[i] = v.NewPlayer("", "", 0, 0, 0,
 			v.gameStates[idx].HeroType,
 			v.gameStates[idx].Stats,
 			v.gameStates[idx].Skills,
-			&equipment,
+			v.gameStates[idx].Items,
 			v.gameStates[idx].LeftSkill,
 			v.gameStates[idx].RightSkill,
 			v.gameStates[idx].Gold,
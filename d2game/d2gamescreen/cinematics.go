@@ -1,7 +1,6 @@
 package d2gamescreen
 
 import (
-	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2video"
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2interface"
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2resource"
@@ -65,7 +64,7 @@ type Cinematics struct {
 	renderer      d2interface.Renderer
 	navigator     d2interface.Navigator
 	uiManager     *d2ui.UIManager
-	videoDecoder  *d2video.BinkDecoder
+	video         *videoPlayer
 	audioProvider d2interface.AudioProvider
 
 	*d2util.Logger
@@ -170,21 +169,69 @@ func (v *Cinematics) onEndCreditExpBtnClicked() {
 }
 
 func (v *Cinematics) playVideo(path string) {
-	videoBytes, err := v.asset.LoadFile(path)
+	video, err := newVideoPlayer(v.asset, v.renderer, v.audioProvider, path)
 	if err != nil {
 		v.Error(err.Error())
 		return
 	}
 
-	v.videoDecoder, err = d2video.CreateBinkDecoder(videoBytes)
+	v.video = video
+
+	v.setButtonsVisible(false)
+}
+
+func (v *Cinematics) stopVideo() {
+	if err := v.video.stop(); err != nil {
+		v.Error(err.Error())
+	}
+
+	v.video = nil
+
+	v.setButtonsVisible(true)
+}
+
+func (v *Cinematics) setButtonsVisible(visible bool) {
+	for _, btn := range []*d2ui.Button{
+		v.a1Btn, v.a2Btn, v.a3Btn, v.a4Btn, v.a5Btn, v.endCreditClassBtn, v.endCreditExpBtn, v.cinematicsExitBtn,
+	} {
+		btn.SetVisible(visible)
+	}
+}
+
+// Advance plays the selected video
+func (v *Cinematics) Advance(elapsed float64) error {
+	if v.video == nil {
+		return nil
+	}
+
+	playing, err := v.video.advance(elapsed)
 	if err != nil {
 		v.Error(err.Error())
-		return
 	}
+
+	if !playing {
+		v.stopVideo()
+	}
+
+	return nil
+}
+
+// OnUnload stops the playing video
+func (v *Cinematics) OnUnload() error {
+	if v.video != nil {
+		return v.video.stop()
+	}
+
+	return nil
 }
 
 // Render renders the credits screen
 func (v *Cinematics) Render(screen d2interface.Surface) {
+	if v.video != nil {
+		v.video.render(screen)
+		return
+	}
+
 	v.background.RenderSegmented(screen, 4, 3, 0)
 	v.cinematicsBackground.RenderSegmented(screen, 2, 2, 0)
 	v.cinematicsLabel.Render(screen)
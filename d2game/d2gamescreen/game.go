@@ -17,6 +17,7 @@ import (
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2interface"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2audio"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapentity"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapgen"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2maprenderer"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2screen"
 	"github.com/OpenDiablo2/OpenDiablo2/d2game/d2player"
@@ -27,12 +28,24 @@ import (
 const hideZoneTextAfterSeconds = 2.0
 
 const (
-	moveErrStr         = "failed to send MovePlayer packet to the server, playerId: %s, x: %g, x: %g\n"
-	bindControlsErrStr = "failed to add gameControls as input handler for player: %s\n"
-	castErrStr         = "failed to send CastSkill packet to the server, playerId: %s, skillId: %d, x: %g, x: %g\n"
-	spawnItemErrStr    = "failed to send SpawnItem packet to the server: (%d, %d) %+v"
+	moveErrStr          = "failed to send MovePlayer packet to the server, playerId: %s, x: %g, x: %g\n"
+	bindControlsErrStr  = "failed to add gameControls as input handler for player: %s\n"
+	castErrStr          = "failed to send CastSkill packet to the server, playerId: %s, skillId: %d, x: %g, x: %g\n"
+	spawnItemErrStr     = "failed to send SpawnItem packet to the server: (%d, %d) %+v"
+	changeLevelErrStr   = "failed to send ChangeLevel packet to the server, playerId: %s, levelId: %d: %v"
+	operateObjectErrStr = "failed to send OperateObject packet to the server, playerId: %s, objectId: %s: %v"
+	pickupItemErrStr    = "failed to send PickupItem packet to the server, playerId: %s, itemId: %s: %v"
+	spendPointErrStr    = "failed to send SpendPoint packet to the server, playerId: %s, skillId: %d: %v"
 )
 
+// warpReachDistance is how close (in sub tiles) the local player has to get to a warp it walks
+// to, before it uses the warp
+const warpReachDistance = 2.0
+
+// objectReachDistance is how close (in sub tiles) the local player has to get to an object it
+// walks to, before it operates the object
+const objectReachDistance = 3.0
+
 const (
 	black50alpha = 0x0000007f // rgba
 )
@@ -112,6 +125,9 @@ type Game struct {
 	localPlayer          *d2mapentity.Player
 	lastRegionType       d2enum.RegionIdType
 	ticksSinceLevelCheck float64
+	targetWarp           *d2mapgen.Warp      // The warp the local player walks to
+	targetObject         *d2mapentity.Object // The object the local player walks to
+	targetItem           *d2mapentity.Item   // The item on the ground the local player walks to
 	escapeMenu           *d2player.EscapeMenu
 	soundEngine          *d2audio.SoundEngine
 	soundEnv             d2audio.SoundEnvironment
@@ -231,6 +247,10 @@ func (v *Game) Advance(elapsed float64) error {
 		v.gameClient.MapEngine.Advance(elapsed)
 	}
 
+	v.useTargetWarp()
+	v.useTargetObject()
+	v.useTargetItem()
+
 	if v.gameControls != nil {
 		if err := v.gameControls.Advance(elapsed); err != nil {
 			return err
@@ -323,6 +343,11 @@ func (v *Game) OnPlayerMove(targetX, targetY float64) {
 
 	playerID, worldX, worldY := v.gameClient.PlayerID, worldPosition.X(), worldPosition.Y()
 
+	target := d2vector.NewPositionTile(targetX, targetY)
+	v.targetWarp = v.gameClient.WarpAt(target.X(), target.Y())
+	v.targetObject = v.gameClient.ObjectAt(target.X(), target.Y())
+	v.targetItem = v.gameClient.ItemAt(target.X(), target.Y())
+
 	createMovePlayerPacket, err := d2netpacket.CreateMovePlayerPacket(playerID, worldX, worldY, targetX, targetY)
 	if err != nil {
 		v.Errorf("MovePlayerPacket: %v", err)
@@ -335,6 +360,76 @@ func (v *Game) OnPlayerMove(targetX, targetY float64) {
 	}
 }
 
+// useTargetWarp asks the server to move the local player to the level of the warp it walked to
+func (v *Game) useTargetWarp() {
+	if v.targetWarp == nil || v.localPlayer == nil {
+		return
+	}
+
+	if v.targetWarp.Distance(v.localPlayer.Position.X(), v.localPlayer.Position.Y()) > warpReachDistance {
+		return
+	}
+
+	levelID := v.targetWarp.LevelID
+	v.targetWarp = nil
+
+	// the server decides the difficulty and the position the player arrives at
+	packet, err := d2netpacket.CreateChangeLevelPacket(v.gameClient.PlayerID, levelID, d2enum.DifficultyNormal, 0, 0)
+	if err == nil {
+		err = v.gameClient.SendPacketToServer(packet)
+	}
+
+	if err != nil {
+		v.Errorf(changeLevelErrStr, v.gameClient.PlayerID, levelID, err)
+	}
+}
+
+// useTargetObject asks the server to operate the object the local player walked to
+func (v *Game) useTargetObject() {
+	if v.targetObject == nil || v.localPlayer == nil {
+		return
+	}
+
+	if v.targetObject.Position.Distance(&v.localPlayer.Position.Vector) > objectReachDistance {
+		return
+	}
+
+	objectID := v.targetObject.ID()
+	v.targetObject = nil
+
+	packet, err := d2netpacket.CreateOperateObjectPacket(v.gameClient.PlayerID, objectID)
+	if err == nil {
+		err = v.gameClient.SendPacketToServer(packet)
+	}
+
+	if err != nil {
+		v.Errorf(operateObjectErrStr, v.gameClient.PlayerID, objectID, err)
+	}
+}
+
+// useTargetItem asks the server to give the local player the item on the ground it walked to
+func (v *Game) useTargetItem() {
+	if v.targetItem == nil || v.localPlayer == nil {
+		return
+	}
+
+	if v.targetItem.Position.Distance(&v.localPlayer.Position.Vector) > objectReachDistance {
+		return
+	}
+
+	itemID := v.targetItem.ID()
+	v.targetItem = nil
+
+	packet, err := d2netpacket.CreatePickupItemPacket(v.gameClient.PlayerID, itemID)
+	if err == nil {
+		err = v.gameClient.SendPacketToServer(packet)
+	}
+
+	if err != nil {
+		v.Errorf(pickupItemErrStr, v.gameClient.PlayerID, itemID, err)
+	}
+}
+
 // OnPlayerSave instructs the server to save our player data
 func (v *Game) OnPlayerSave() error {
 	playerState := v.gameClient.Players[v.gameClient.PlayerID]
@@ -366,6 +461,28 @@ func (v *Game) OnPlayerCast(skillID int, targetX, targetY float64) {
 	}
 }
 
+// OnPlayerSpendStatPoint asks the server to spend a stat point on the attribute
+func (v *Game) OnPlayerSpendStatPoint(attribute d2enum.Attribute) {
+	v.spendPoint(attribute, 0)
+}
+
+// OnPlayerSpendSkillPoint asks the server to spend a skill point on the skill
+func (v *Game) OnPlayerSpendSkillPoint(skillID int) {
+	v.spendPoint(d2enum.AttributeStrength, skillID)
+}
+
+// spendPoint sends a SpendPoint packet, the attribute is only used if no skill is given
+func (v *Game) spendPoint(attribute d2enum.Attribute, skillID int) {
+	packet, err := d2netpacket.CreateSpendPointPacket(v.gameClient.PlayerID, attribute, skillID)
+	if err == nil {
+		err = v.gameClient.SendPacketToServer(packet)
+	}
+
+	if err != nil {
+		v.Errorf(spendPointErrStr, v.gameClient.PlayerID, skillID, err)
+	}
+}
+
 func (v *Game) debugSpawnItemAtPlayer(codes ...string) {
 	if v.localPlayer == nil {
 		return
@@ -97,7 +97,7 @@ func CreateMainMenu(
 	l d2util.LogLevel,
 	errorMessageOptional ...string,
 ) (*MainMenu, error) {
-	heroStateFactory, err := d2hero.NewHeroStateFactory(asset)
+	heroStateFactory, err := d2hero.NewHeroStateFactory(asset, l)
 	if err != nil {
 		return nil, err
 	}
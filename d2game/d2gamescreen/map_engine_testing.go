@@ -98,7 +98,7 @@ func CreateMapEngineTest(currentRegion,
 	l d2util.LogLevel,
 	screen *d2screen.ScreenManager,
 ) (*MapEngineTest, error) {
-	heroStateFactory, err := d2hero.NewHeroStateFactory(asset)
+	heroStateFactory, err := d2hero.NewHeroStateFactory(asset, l)
 	if err != nil {
 		return nil, err
 	}
@@ -5,8 +5,6 @@ import (
 
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
 
-	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2inventory"
-
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2interface"
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2resource"
@@ -281,28 +279,22 @@ func CreateSelectHeroClass(
 	l d2util.LogLevel,
 	connectionHost string,
 ) (*SelectHeroClass, error) {
-	playerStateFactory, err := d2hero.NewHeroStateFactory(asset)
-	if err != nil {
-		return nil, err
-	}
-
-	inventoryItemFactory, err := d2inventory.NewInventoryItemFactory(asset)
+	playerStateFactory, err := d2hero.NewHeroStateFactory(asset, l)
 	if err != nil {
 		return nil, err
 	}
 
 	selectHeroClass := &SelectHeroClass{
-		asset:                asset,
-		heroRenderInfo:       make(map[d2enum.Hero]*HeroRenderInfo),
-		selectedHero:         d2enum.HeroNone,
-		connectionType:       connectionType,
-		connectionHost:       connectionHost,
-		audioProvider:        audioProvider,
-		renderer:             renderer,
-		navigator:            navigator,
-		uiManager:            ui,
-		HeroStateFactory:     playerStateFactory,
-		InventoryItemFactory: inventoryItemFactory,
+		asset:            asset,
+		heroRenderInfo:   make(map[d2enum.Hero]*HeroRenderInfo),
+		selectedHero:     d2enum.HeroNone,
+		connectionType:   connectionType,
+		connectionHost:   connectionHost,
+		audioProvider:    audioProvider,
+		renderer:         renderer,
+		navigator:        navigator,
+		uiManager:        ui,
+		HeroStateFactory: playerStateFactory,
 	}
 
 	selectHeroClass.Logger = d2util.NewLogger()
@@ -326,7 +318,6 @@ type SelectHeroClass struct {
 	heroNameTextbox *d2ui.TextBox
 	heroNameLabel   *d2ui.Label
 	heroRenderInfo  map[d2enum.Hero]*HeroRenderInfo
-	*d2inventory.InventoryItemFactory
 	*d2hero.HeroStateFactory
 	selectedHero       d2enum.Hero
 	exitButton         *d2ui.Button
@@ -510,7 +501,6 @@ func (v *SelectHeroClass) onOkButtonClicked() {
 		return
 	}
 
-	playerState.Equipment = v.InventoryItemFactory.DefaultHeroItems[v.selectedHero]
 	v.navigator.ToCreateGame(playerState.FilePath, v.connectionType, v.connectionHost)
 }
 
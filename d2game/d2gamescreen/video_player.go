@@ -0,0 +1,97 @@
+package d2gamescreen
+
+import (
+	"io"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2video"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2interface"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2util"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2asset"
+)
+
+const videoBackground = 0x000000ff // rgba
+
+// videoPlayer plays a Bink video in the middle of the screen, with the sound of its first
+// audio track
+type videoPlayer struct {
+	decoder *d2video.BinkDecoder
+	surface d2interface.Surface
+	audio   d2interface.AudioStream
+	time    float64
+}
+
+// newVideoPlayer loads the video at the given path and starts playing it
+func newVideoPlayer(asset *d2asset.AssetManager, renderer d2interface.Renderer,
+	audioProvider d2interface.AudioProvider, path string) (*videoPlayer, error) {
+	videoBytes, err := asset.LoadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder, err := d2video.CreateBinkDecoder(videoBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	player := &videoPlayer{
+		decoder: decoder,
+		surface: renderer.NewSurface(int(decoder.VideoWidth), int(decoder.VideoHeight)),
+	}
+
+	if len(decoder.AudioTracks) > 0 {
+		track := decoder.AudioTracks[0]
+
+		player.audio, err = audioProvider.CreateAudioStream(int(track.AudioSampleRateHz), int(track.AudioChannels))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return player, nil
+}
+
+// advance decodes the frames of the elapsed time, shows the last one and queues their sound. It
+// returns false when the video has ended.
+func (p *videoPlayer) advance(elapsed float64) (bool, error) {
+	frameTime := float64(p.decoder.FrameTimeMS) / millisecondsPerSecond
+
+	p.time += elapsed
+
+	for p.time >= frameTime {
+		p.time -= frameTime
+
+		frame, err := p.decoder.GetNextFrame()
+		if err == io.EOF {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+
+		if p.audio != nil && len(frame.Audio) > 0 {
+			p.audio.QueueSamples(frame.Audio[0])
+		}
+
+		p.surface.ReplacePixels(frame.Image.Pix)
+	}
+
+	return true, nil
+}
+
+// render renders the video in the middle of a black screen
+func (p *videoPlayer) render(screen d2interface.Surface) {
+	width, height := p.surface.GetSize()
+
+	screen.DrawRect(screenWidth, screenHeight, d2util.Color(videoBackground))
+	screen.PushTranslation((screenWidth-width)/2, (screenHeight-height)/2)
+	screen.Render(p.surface)
+	screen.Pop()
+}
+
+// stop stops the sound of the video
+func (p *videoPlayer) stop() error {
+	if p.audio == nil {
+		return nil
+	}
+
+	return p.audio.Close()
+}
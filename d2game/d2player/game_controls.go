@@ -172,11 +172,11 @@ func NewGameControls(
 	}
 	inventoryRecord := asset.Records.Layout.Inventory[inventoryRecordKey]
 
-	heroStatsPanel := NewHeroStatsPanel(asset, ui, hero.Name(), hero.Class, l, hero.Stats)
+	heroStatsPanel := NewHeroStatsPanel(asset, ui, hero.Name(), hero.Class, l, hero.Stats, hero.StatEngine)
 
 	questLog := NewQuestLog(asset, ui, l, audioProvider, hero.Act)
 
-	inventory, err := NewInventory(asset, ui, l, hero.Gold, inventoryRecord)
+	inventory, err := NewInventory(asset, ui, l, hero.Gold, hero.Items, inventoryRecord)
 	if err != nil {
 		return nil, err
 	}
@@ -185,7 +185,7 @@ func NewGameControls(
 
 	miniPanel := newMiniPanel(asset, ui, l, isSinglePlayer)
 
-	heroState, err := d2hero.NewHeroStateFactory(asset)
+	heroState, err := d2hero.NewHeroStateFactory(asset, l)
 	if err != nil {
 		return nil, err
 	}
@@ -248,6 +248,8 @@ func NewGameControls(
 	gc.questLog.SetOnCloseCb(gc.onCloseQuestLog)
 	gc.inventory.SetOnCloseCb(gc.onCloseInventory)
 	gc.skilltree.SetOnCloseCb(gc.onCloseSkilltree)
+	gc.heroStatsPanel.SetOnSpendPointCb(gc.inputListener.OnPlayerSpendStatPoint)
+	gc.skilltree.SetOnSpendPointCb(gc.inputListener.OnPlayerSpendSkillPoint)
 
 	gc.escapeMenu.SetOnCloseCb(gc.hud.miniPanel.restoreDisabled)
 	gc.HelpOverlay.SetOnCloseCb(gc.hud.miniPanel.restoreDisabled)
@@ -538,6 +540,10 @@ func (g *GameControls) OnMouseButtonDown(event d2interface.MouseEvent) bool {
 		return false
 	}
 
+	if event.Button() == d2enum.MouseButtonLeft && g.skilltree.HandleClick(mx, my) {
+		return false
+	}
+
 	px, py := g.mapRenderer.ScreenToWorld(mx, my)
 	px = truncateFloat64(px)
 	py = truncateFloat64(py)
@@ -54,6 +54,14 @@ const (
 	labelResPoisLine2X, labelResPoisLine2Y   = 310, 476
 )
 
+// the stats of ItemStatCost.txt of the resistances on the panel
+const (
+	statFireResist      = "fireresist"
+	statColdResist      = "coldresist"
+	statLightningResist = "lightresist"
+	statPoisonResist    = "poisonresist"
+)
+
 const (
 	heroStatsCloseButtonX, heroStatsCloseButtonY = 208, 453
 	addStatSocketOffsetX, addStatSocketOffsetY   = -3, 34
@@ -91,15 +99,22 @@ type StatsPanelLabels struct {
 	MaxMana      *d2ui.Label
 	MaxStamina   *d2ui.Label
 	Stamina      *d2ui.Label
+	Defense      *d2ui.Label
+	ResFire      *d2ui.Label
+	ResCold      *d2ui.Label
+	ResLight     *d2ui.Label
+	ResPoison    *d2ui.Label
 }
 
-// NewHeroStatsPanel creates a new hero status panel
+// NewHeroStatsPanel creates a new hero status panel. The panel shows the attributes, life, mana
+// and stamina of the stat engine, which adds the items, skills and states of the hero to them.
 func NewHeroStatsPanel(asset *d2asset.AssetManager,
 	ui *d2ui.UIManager,
 	heroName string,
 	heroClass d2enum.Hero,
 	l d2util.LogLevel,
-	heroState *d2hero.HeroStatsState) *HeroStatsPanel {
+	heroState *d2hero.HeroStatsState,
+	stats *d2hero.StatEngine) *HeroStatsPanel {
 	originX := 0
 	originY := 0
 
@@ -109,6 +124,7 @@ func NewHeroStatsPanel(asset *d2asset.AssetManager,
 		originX:   originX,
 		originY:   originY,
 		heroState: heroState,
+		stats:     stats,
 		heroName:  heroName,
 		heroClass: heroClass,
 		labels:    &StatsPanelLabels{},
@@ -127,10 +143,12 @@ type HeroStatsPanel struct {
 	uiManager       *d2ui.UIManager
 	panel           *d2ui.Sprite
 	heroState       *d2hero.HeroStatsState
+	stats           *d2hero.StatEngine
 	heroName        string
 	heroClass       d2enum.Hero
 	labels          *StatsPanelLabels
 	onCloseCb       func()
+	onSpendPointCb  func(attribute d2enum.Attribute)
 	panelGroup      *d2ui.WidgetGroup
 	newStatPoints   *d2ui.WidgetGroup
 	remainingPoints *d2ui.Label
@@ -202,22 +220,14 @@ func (s *HeroStatsPanel) loadNewStatPoints() {
 	s.newStatPoints.AddWidget(s.remainingPoints)
 
 	buttons := []struct {
-		x  int
-		y  int
-		cb func()
+		x         int
+		y         int
+		attribute d2enum.Attribute
 	}{
-		{205, 140, func() {
-			s.heroState.Strength++
-		}},
-		{205, 201, func() {
-			s.heroState.Dexterity++
-		}},
-		{205, 286, func() {
-			s.heroState.Vitality++
-		}},
-		{205, 347, func() {
-			s.heroState.Energy++
-		}},
+		{205, 140, d2enum.AttributeStrength},
+		{205, 201, d2enum.AttributeDexterity},
+		{205, 286, d2enum.AttributeVitality},
+		{205, 347, d2enum.AttributeEnergy},
 	}
 
 	var socket *d2ui.Sprite
@@ -237,12 +247,11 @@ func (s *HeroStatsPanel) loadNewStatPoints() {
 
 		button = s.uiManager.NewButton(d2ui.ButtonTypeAddSkill, d2resource.PaletteSky)
 		button.SetPosition(i.x, i.y)
+		// the server spends the point, the panel shows the stats it sends back
 		button.OnActivated(func() {
-			currentValue.cb()
-			s.heroState.StatsPoints--
-			s.remainingPoints.SetText(strconv.Itoa(s.heroState.StatsPoints))
-			s.setStatValues()
-			s.setLayout()
+			if s.onSpendPointCb != nil {
+				s.onSpendPointCb(currentValue.attribute)
+			}
 		})
 		s.newStatPoints.AddWidget(button)
 	}
@@ -286,6 +295,11 @@ func (s *HeroStatsPanel) SetOnCloseCb(cb func()) {
 	s.onCloseCb = cb
 }
 
+// SetOnSpendPointCb sets the callback run when the player spends a stat point on an attribute
+func (s *HeroStatsPanel) SetOnSpendPointCb(cb func(attribute d2enum.Attribute)) {
+	s.onSpendPointCb = cb
+}
+
 // Advance updates labels on the panel
 func (s *HeroStatsPanel) Advance(elapsed float64) {
 	if !s.isOpen {
@@ -293,6 +307,8 @@ func (s *HeroStatsPanel) Advance(elapsed float64) {
 	}
 
 	s.setStatValues()
+	s.remainingPoints.SetText(strconv.Itoa(s.heroState.StatsPoints))
+	s.setLayout()
 }
 
 func (s *HeroStatsPanel) renderStaticMenu(target d2interface.Surface) {
@@ -410,6 +426,11 @@ func (s *HeroStatsPanel) initStatValueLabels() {
 		{&s.labels.Health, s.heroState.Health, 370, 320},
 		{&s.labels.MaxMana, s.heroState.MaxMana, 330, 355},
 		{&s.labels.Mana, s.heroState.Mana, 370, 355},
+		{&s.labels.Defense, s.defense(), 370, 257},
+		{&s.labels.ResFire, s.resistance(statFireResist), 370, 396},
+		{&s.labels.ResLight, s.resistance(statLightningResist), 370, 420},
+		{&s.labels.ResCold, s.resistance(statColdResist), 370, 444},
+		{&s.labels.ResPoison, s.resistance(statPoisonResist), 370, 468},
 	}
 
 	for _, cfg := range valueLabelConfigs {
@@ -422,19 +443,55 @@ func (s *HeroStatsPanel) setStatValues() {
 	s.labels.Experience.SetText(strconv.Itoa(s.heroState.Experience))
 	s.labels.NextLevelExp.SetText(strconv.Itoa(s.heroState.NextLevelExp))
 
-	s.labels.Strength.SetText(strconv.Itoa(s.heroState.Strength))
-	s.labels.Dexterity.SetText(strconv.Itoa(s.heroState.Dexterity))
-	s.labels.Vitality.SetText(strconv.Itoa(s.heroState.Vitality))
-	s.labels.Energy.SetText(strconv.Itoa(s.heroState.Energy))
+	strength, dexterity := s.heroState.Strength, s.heroState.Dexterity
+	vitality, energy := s.heroState.Vitality, s.heroState.Energy
+	maxHealth, maxMana, maxStamina := s.heroState.MaxHealth, s.heroState.MaxMana, s.heroState.MaxStamina
+
+	if s.stats != nil {
+		strength = s.stats.Attribute(d2enum.AttributeStrength)
+		dexterity = s.stats.Attribute(d2enum.AttributeDexterity)
+		vitality = s.stats.Attribute(d2enum.AttributeVitality)
+		energy = s.stats.Attribute(d2enum.AttributeEnergy)
+		maxHealth, maxMana, maxStamina = s.stats.MaxLife(), s.stats.MaxMana(), s.stats.MaxStamina()
+	}
+
+	s.labels.Strength.SetText(strconv.Itoa(strength))
+	s.labels.Dexterity.SetText(strconv.Itoa(dexterity))
+	s.labels.Vitality.SetText(strconv.Itoa(vitality))
+	s.labels.Energy.SetText(strconv.Itoa(energy))
 
-	s.labels.MaxHealth.SetText(strconv.Itoa(s.heroState.MaxHealth))
+	s.labels.MaxHealth.SetText(strconv.Itoa(maxHealth))
 	s.labels.Health.SetText(strconv.Itoa(s.heroState.Health))
 
-	s.labels.MaxStamina.SetText(strconv.Itoa(s.heroState.MaxStamina))
+	s.labels.MaxStamina.SetText(strconv.Itoa(maxStamina))
 	s.labels.Stamina.SetText(strconv.Itoa(int(s.heroState.Stamina)))
 
-	s.labels.MaxMana.SetText(strconv.Itoa(s.heroState.MaxMana))
+	s.labels.MaxMana.SetText(strconv.Itoa(maxMana))
 	s.labels.Mana.SetText(strconv.Itoa(s.heroState.Mana))
+
+	s.labels.Defense.SetText(strconv.Itoa(s.defense()))
+	s.labels.ResFire.SetText(strconv.Itoa(s.resistance(statFireResist)))
+	s.labels.ResCold.SetText(strconv.Itoa(s.resistance(statColdResist)))
+	s.labels.ResLight.SetText(strconv.Itoa(s.resistance(statLightningResist)))
+	s.labels.ResPoison.SetText(strconv.Itoa(s.resistance(statPoisonResist)))
+}
+
+// defense returns the final defense of the hero
+func (s *HeroStatsPanel) defense() int {
+	if s.stats == nil {
+		return 0
+	}
+
+	return s.stats.Defense()
+}
+
+// resistance returns the final resistance of the hero for a resistance stat of ItemStatCost.txt
+func (s *HeroStatsPanel) resistance(stat string) int {
+	if s.stats == nil {
+		return 0
+	}
+
+	return s.stats.Resistance(stat)
 }
 
 func (s *HeroStatsPanel) createStatValueLabel(stat, x, y int) *d2ui.Label {
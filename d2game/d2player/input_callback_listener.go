@@ -1,6 +1,10 @@
 package d2player
 
+import "github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+
 type inputCallbackListener interface {
 	OnPlayerMove(x, y float64)
 	OnPlayerCast(skillID int, x, y float64)
+	OnPlayerSpendStatPoint(attribute d2enum.Attribute)
+	OnPlayerSpendSkillPoint(skillID int)
 }
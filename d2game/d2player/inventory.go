@@ -10,7 +10,7 @@ import (
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2resource"
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2util"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2asset"
-	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2item/diablo2item"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2ui"
 )
 
@@ -32,26 +32,22 @@ func NewInventory(asset *d2asset.AssetManager,
 	ui *d2ui.UIManager,
 	l d2util.LogLevel,
 	gold int,
+	items []*d2hero.HeroItem,
 	record *d2records.InventoryRecord) (*Inventory, error) {
 	itemTooltip := ui.NewTooltip(d2resource.FontFormal11, d2resource.PaletteStatic, d2ui.TooltipXCenter, d2ui.TooltipYBottom)
 
-	itemFactory, err := diablo2item.NewItemFactory(asset)
-	if err != nil {
-		return nil, fmt.Errorf("during creating new item factory: %s", err)
-	}
-
 	mgp := NewMoveGoldPanel(asset, ui, gold, l)
 
 	inventory := &Inventory{
 		asset:       asset,
 		uiManager:   ui,
-		item:        itemFactory,
 		grid:        NewItemGrid(asset, ui, l, record),
 		originX:     record.Panel.Left,
 		itemTooltip: itemTooltip,
 		// originY: record.Panel.Top,
 		originY:       0, // expansion data has these all offset by +60 ...
 		gold:          gold,
+		items:         items,
 		moveGoldPanel: mgp,
 	}
 
@@ -67,7 +63,6 @@ func NewInventory(asset *d2asset.AssetManager,
 // Inventory represents the inventory
 type Inventory struct {
 	asset         *d2asset.AssetManager
-	item          *diablo2item.ItemFactory
 	uiManager     *d2ui.UIManager
 	panel         *d2ui.Sprite
 	goldLabel     *d2ui.Label
@@ -84,6 +79,7 @@ type Inventory struct {
 	isOpen        bool
 	onCloseCb     func()
 	gold          int
+	items         []*d2hero.HeroItem
 	moveGoldPanel *MoveGoldPanel
 
 	*d2util.Logger
@@ -143,57 +139,40 @@ func (g *Inventory) Load() {
 	g.goldLabel.SetPosition(invGoldLabelX, invGoldLabelY)
 	g.panelGroup.AddWidget(g.goldLabel)
 
-	// https://github.com/OpenDiablo2/OpenDiablo2/issues/795
-	testInventoryCodes := [][]string{
-		{"kit", "Crimson", "of the Bat", "of Frost"},
-		{"rin", "Steel", "of Shock"},
-		{"jav"},
-		{"buc"},
-	}
-
-	inventoryItems := make([]InventoryItem, 0)
+	g.loadItems()
 
-	for idx := range testInventoryCodes {
-		item, itemErr := g.item.NewItem(testInventoryCodes[idx]...)
-		if itemErr != nil {
-			continue
-		}
-
-		item.Identify()
-		inventoryItems = append(inventoryItems, item)
-	}
+	g.moveGoldPanel.Load()
 
-	// https://github.com/OpenDiablo2/OpenDiablo2/issues/795
-	testEquippedItemCodes := map[d2enum.EquippedSlot][]string{
-		d2enum.EquippedSlotLeftArm:   {"wnd"},
-		d2enum.EquippedSlotRightArm:  {"buc"},
-		d2enum.EquippedSlotHead:      {"crn"},
-		d2enum.EquippedSlotTorso:     {"plt"},
-		d2enum.EquippedSlotLegs:      {"vbt"},
-		d2enum.EquippedSlotBelt:      {"vbl"},
-		d2enum.EquippedSlotGloves:    {"lgl"},
-		d2enum.EquippedSlotLeftHand:  {"rin"},
-		d2enum.EquippedSlotRightHand: {"rin"},
-		d2enum.EquippedSlotNeck:      {"amu"},
-	}
+	g.panelGroup.SetVisible(false)
+}
 
-	for slot := range testEquippedItemCodes {
-		item, itemErr := g.item.NewItem(testEquippedItemCodes[slot]...)
-		if itemErr != nil {
+// loadItems puts the items of the hero which are kept in the inventory panel into the grid and
+// the equipped slots. The belt, the stash, the cube and the weapon swap have no panel yet.
+func (g *Inventory) loadItems() {
+	for _, heroItem := range g.items {
+		item := heroItem.Item
+		if item == nil {
 			continue
 		}
 
-		g.grid.ChangeEquippedSlot(slot, item)
-	}
+		switch {
+		case item.Location == d2enum.ItemLocationEquipped:
+			if _, found := g.grid.equipmentSlots[item.SlotType()]; found {
+				g.grid.ChangeEquippedSlot(item.SlotType(), item)
+			}
+		case item.Location == d2enum.ItemLocationStored && item.Storage == d2enum.ItemStorageInventory:
+			if g.grid.Set(item.GridX, item.GridY, item) == nil {
+				continue
+			}
 
-	_, err = g.grid.Add(inventoryItems...)
-	if err != nil {
-		g.Errorf("could not add items to the inventory, err: %v", err.Error())
+			// the items the player got from the server are put in the first free place
+			if _, err := g.grid.Add(item); err != nil {
+				g.Errorf("could not add item %s to the inventory, err: %v", heroItem.ID, err)
+			}
+		}
 	}
 
-	g.moveGoldPanel.Load()
-
-	g.panelGroup.SetVisible(false)
+	g.grid.Load()
 }
 
 // Open opens the inventory
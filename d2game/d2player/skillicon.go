@@ -91,6 +91,18 @@ func (si *skillIcon) renderSpriteLabel(target d2interface.Surface) {
 	si.lvlLabel.Render(target)
 }
 
+// contains returns true if the given screen position is on the icon
+func (si *skillIcon) contains(x, y int) bool {
+	w, h, err := si.sprite.GetFrameSize(si.skill.IconCel)
+	if err != nil {
+		return false
+	}
+
+	iconX, iconY := si.GetPosition()
+
+	return x >= iconX && x < iconX+w && y > iconY-h && y <= iconY
+}
+
 func (si *skillIcon) Render(target d2interface.Surface) {
 	si.renderSprite(target)
 	si.renderSpriteLabel(target)
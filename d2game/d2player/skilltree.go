@@ -136,6 +136,7 @@ type skillTree struct {
 	originY         int
 	selectedTab     int
 	onCloseCb       func()
+	onSpendPointCb  func(skillID int)
 	panelGroup      *d2ui.WidgetGroup
 	iconGroup       *d2ui.WidgetGroup
 	panel           *d2ui.CustomWidget
@@ -402,6 +403,34 @@ func (s *skillTree) SetOnCloseCb(cb func()) {
 	s.onCloseCb = cb
 }
 
+// SetOnSpendPointCb sets the callback run when the player spends a skill point on a skill
+func (s *skillTree) SetOnSpendPointCb(cb func(skillID int)) {
+	s.onSpendPointCb = cb
+}
+
+// HandleClick spends a skill point on the skill of the open tab at the given position. The
+// server spends the point, the tree shows the skills it sends back. Returns false if no skill
+// is at the given position.
+func (s *skillTree) HandleClick(x, y int) bool {
+	if !s.isOpen {
+		return false
+	}
+
+	for _, si := range s.skillIcons {
+		if !si.GetVisible() || !si.contains(x, y) {
+			continue
+		}
+
+		if s.stats.SkillPoints > 0 && s.onSpendPointCb != nil {
+			s.onSpendPointCb(si.skill.ID)
+		}
+
+		return true
+	}
+
+	return false
+}
+
 func (s *skillTree) setTab(tab int) {
 	s.selectedTab = tab
 	s.closeButton.SetPosition(s.tab[tab].closeButtonPosX, skillCloseButtonY)
@@ -507,6 +536,7 @@ func (s *skillTree) renderTab(target d2interface.Surface, tab int) {
 
 // Render the skill tree panel
 func (s *skillTree) Render(target d2interface.Surface) {
+	s.remainingPoints.SetText(strconv.Itoa(s.stats.SkillPoints))
 	s.renderTabCommon(target)
 	s.renderTab(target, s.selectedTab)
 }
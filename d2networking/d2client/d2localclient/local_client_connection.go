@@ -38,9 +38,15 @@ func (l *LocalClientConnection) GetConnectionType() d2clientconnectiontype.Clien
 	return d2clientconnectiontype.Local
 }
 
-// SendPacketToClient passes a packet to the game client for processing.
+// SendPacketToClient passes a copy of a packet to the game client for processing, so the
+// client never changes the state of the server through the packet.
 func (l *LocalClientConnection) SendPacketToClient(packet d2netpacket.NetPacket) error {
-	return l.clientListener.OnPacketReceived(packet)
+	copied, err := d2netpacket.CopyPacket(packet)
+	if err != nil {
+		return err
+	}
+
+	return l.clientListener.OnPacketReceived(copied)
 }
 
 // Create constructs a new LocalClientConnection and returns
@@ -49,7 +55,7 @@ func Create(
 	asset *d2asset.AssetManager,
 	l d2util.LogLevel,
 	openNetworkServer bool) (*LocalClientConnection, error) {
-	heroStateFactory, err := d2hero.NewHeroStateFactory(asset)
+	heroStateFactory, err := d2hero.NewHeroStateFactory(asset, l)
 	if err != nil {
 		return nil, err
 	}
@@ -100,9 +106,14 @@ func (l *LocalClientConnection) Close() error {
 	return nil
 }
 
-// SendPacketToServer calls d2server.OnPacketReceived with the given packet.
+// SendPacketToServer calls d2server.OnPacketReceived with a copy of the given packet.
 func (l *LocalClientConnection) SendPacketToServer(packet d2netpacket.NetPacket) error {
-	return l.gameServer.OnPacketReceived(l, packet)
+	copied, err := d2netpacket.CopyPacket(packet)
+	if err != nil {
+		return err
+	}
+
+	return l.gameServer.OnPacketReceived(l, copied)
 }
 
 // SetClientListener sets LocalClientConnection.clientListener to the given value.
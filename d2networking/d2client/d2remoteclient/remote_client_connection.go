@@ -1,7 +1,6 @@
 package d2remoteclient
 
 import (
-	"encoding/json"
 	"fmt"
 	"io"
 	"net"
@@ -28,6 +27,8 @@ type RemoteClientConnection struct {
 	clientListener d2networking.ClientListener // The GameClient
 	uniqueID       string                      // Unique ID generated on construction
 	tcpConnection  *net.TCPConn                // UDP connection to the server
+	codec          d2netpacket.Codec           // The wire format used to talk to the server
+	encoder        *d2netpacket.PacketEncoder  // Encodes packets sent to the server
 	active         bool                        // The connection is currently open
 
 	*d2util.Logger
@@ -36,7 +37,7 @@ type RemoteClientConnection struct {
 // Create constructs a new RemoteClientConnection
 // and returns a pointer to it.
 func Create(l d2util.LogLevel, asset *d2asset.AssetManager) (*RemoteClientConnection, error) {
-	heroStateFactory, err := d2hero.NewHeroStateFactory(asset)
+	heroStateFactory, err := d2hero.NewHeroStateFactory(asset, l)
 	if err != nil {
 		return nil, err
 	}
@@ -45,6 +46,7 @@ func Create(l d2util.LogLevel, asset *d2asset.AssetManager) (*RemoteClientConnec
 		asset:     asset,
 		heroState: heroStateFactory,
 		uniqueID:  uuid.New().String(),
+		codec:     d2netpacket.CodecBinary,
 	}
 
 	result.Logger = d2util.NewLogger()
@@ -72,14 +74,23 @@ func (r *RemoteClientConnection) Open(connectionString, saveFilePath string) err
 		return err
 	}
 
+	r.encoder = d2netpacket.NewPacketEncoder(r.codec, r.tcpConnection)
+
 	r.active = true
 	go r.serverListener()
 
 	r.Infof("Connected to server at %s", r.tcpConnection.RemoteAddr().String())
 
 	gameState := r.heroState.LoadHeroState(saveFilePath)
+	heroKey := ""
+
+	if gameState != nil {
+		if heroKey, err = r.heroState.RemoteKey(gameState); err != nil {
+			return err
+		}
+	}
 
-	packet, err := d2netpacket.CreatePlayerConnectionRequestPacket(r.GetUniqueID(), gameState)
+	packet, err := d2netpacket.CreatePlayerConnectionRequestPacket(r.GetUniqueID(), gameState, heroKey)
 	if err != nil {
 		r.Errorf("PlayerConnectionRequestPacket: %v", err)
 	}
@@ -124,33 +135,29 @@ func (r RemoteClientConnection) GetConnectionType() d2clientconnectiontype.Clien
 	return d2clientconnectiontype.LANClient
 }
 
+// SetCodec sets the wire format used to talk to the server. It has to be called before Open.
+func (r *RemoteClientConnection) SetCodec(codec d2netpacket.Codec) {
+	r.codec = codec
+}
+
 // SetClientListener sets RemoteClientConnection.clientListener to the given value.
 func (r *RemoteClientConnection) SetClientListener(listener d2networking.ClientListener) {
 	r.clientListener = listener
 }
 
-// SendPacketToServer compresses the JSON encoding of a NetPacket and
+// SendPacketToServer encodes a NetPacket with the codec of the connection and
 // sends it to the server.
 func (r *RemoteClientConnection) SendPacketToServer(packet d2netpacket.NetPacket) error {
-	encoder := json.NewEncoder(r.tcpConnection)
-
-	err := encoder.Encode(packet)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return r.encoder.Encode(packet)
 }
 
 // serverListener runs a while loop, reading from the GameServer's TCP
 // connection.
 func (r *RemoteClientConnection) serverListener() {
-	decoder := json.NewDecoder(r.tcpConnection)
+	decoder := d2netpacket.NewPacketDecoder(r.tcpConnection)
 
 	for {
-		var packet d2netpacket.NetPacket
-
-		err := decoder.Decode(&packet)
+		packet, err := decoder.Decode()
 		if err != nil {
 			switch err {
 			case io.EOF:
@@ -162,7 +169,7 @@ func (r *RemoteClientConnection) serverListener() {
 			return // allow the connection to close
 		}
 
-		p, err := r.decodeToPacket(packet.PacketType, string(packet.PacketData))
+		p, err := r.decodeToPacket(packet)
 		if err != nil {
 			r.Errorf("%v %v", packet.PacketType, err)
 		}
@@ -182,52 +189,76 @@ func (r *RemoteClientConnection) bytesToJSON(buffer []byte) (string, d2netpacket
 		return "", 0, err
 	}
 
-	return string(packet.PacketData), packet.PacketType, nil
+	data, err := packet.Data()
+	if err != nil {
+		return "", 0, err
+	}
+
+	return string(data), packet.PacketType, nil
 }
 
-// decodeToPacket unmarshals the JSON string into the correct struct
-// and returns a NetPacket declaring that struct.
+// decodeToPacket checks that the packet unmarshals into the struct of its type and returns the
+// packet, which keeps the unmarshalled struct of a packet read with the binary codec.
 // nolint:gocyclo,funlen // switch statement on packet type makes sense, no need to change
-func (r *RemoteClientConnection) decodeToPacket(
-	t d2netpackettype.NetPacketType,
-	data string) (d2netpacket.NetPacket, error) {
-	var (
-		np  = d2netpacket.NetPacket{}
-		err error
-		p   interface{}
-	)
-
-	switch t {
+func (r *RemoteClientConnection) decodeToPacket(packet d2netpacket.NetPacket) (d2netpacket.NetPacket, error) {
+	var err error
+
+	switch packet.PacketType {
 	case d2netpackettype.GenerateMap:
-		p, err = d2netpacket.UnmarshalGenerateMap([]byte(data))
+		_, err = d2netpacket.UnmarshalGenerateMap(packet)
 	case d2netpackettype.MovePlayer:
-		p, err = d2netpacket.UnmarshalMovePlayer([]byte(data))
+		_, err = d2netpacket.UnmarshalMovePlayer(packet)
 	case d2netpackettype.UpdateServerInfo:
-		p, err = d2netpacket.UnmarshalUpdateServerInfo([]byte(data))
+		_, err = d2netpacket.UnmarshalUpdateServerInfo(packet)
 	case d2netpackettype.AddPlayer:
-		p, err = d2netpacket.UnmarshalAddPlayer([]byte(data))
+		_, err = d2netpacket.UnmarshalAddPlayer(packet)
 	case d2netpackettype.CastSkill:
-		p, err = d2netpacket.UnmarshalCast([]byte(data))
+		_, err = d2netpacket.UnmarshalCast(packet)
 	case d2netpackettype.Ping:
-		p, err = d2netpacket.UnmarshalPing([]byte(data))
+		_, err = d2netpacket.UnmarshalPing(packet)
 	case d2netpackettype.PlayerDisconnectionNotification:
-		p, err = d2netpacket.UnmarshalPlayerDisconnectionRequest([]byte(data))
+		_, err = d2netpacket.UnmarshalPlayerDisconnectionRequest(packet)
 	case d2netpackettype.ServerClosed:
-		p, err = d2netpacket.UnmarshalServerClosed([]byte(data))
+		_, err = d2netpacket.UnmarshalServerClosed(packet)
+	case d2netpackettype.ChangeLevel:
+		_, err = d2netpacket.UnmarshalChangeLevel(packet)
+	case d2netpackettype.DamageEntity:
+		_, err = d2netpacket.UnmarshalDamageEntity(packet)
+	case d2netpackettype.KillEntity:
+		_, err = d2netpacket.UnmarshalKillEntity(packet)
+	case d2netpackettype.MoveMonster:
+		_, err = d2netpacket.UnmarshalMoveMonster(packet)
+	case d2netpackettype.MonsterAction:
+		_, err = d2netpacket.UnmarshalMonsterAction(packet)
+	case d2netpackettype.OperateObject:
+		_, err = d2netpacket.UnmarshalOperateObject(packet)
+	case d2netpackettype.SetState:
+		_, err = d2netpacket.UnmarshalSetState(packet)
+	case d2netpackettype.SetVitals:
+		_, err = d2netpacket.UnmarshalSetVitals(packet)
+	case d2netpackettype.VendorInventory:
+		_, err = d2netpacket.UnmarshalVendorInventory(packet)
+	case d2netpackettype.TradeResult:
+		_, err = d2netpacket.UnmarshalTradeResult(packet)
+	case d2netpackettype.HirelingOffers:
+		_, err = d2netpacket.UnmarshalHirelingOffers(packet)
+	case d2netpackettype.UpdateHireling:
+		_, err = d2netpacket.UnmarshalUpdateHireling(packet)
+	case d2netpackettype.SpawnHireling:
+		_, err = d2netpacket.UnmarshalSpawnHireling(packet)
+	case d2netpackettype.UpdateStats:
+		_, err = d2netpacket.UnmarshalUpdateStats(packet)
+	case d2netpackettype.SpawnItem:
+		_, err = d2netpacket.UnmarshalSpawnItem(packet)
+	case d2netpackettype.PickupItem:
+		_, err = d2netpacket.UnmarshalPickupItem(packet)
 	default:
-		err = fmt.Errorf("RemoteClientConnection: unrecognized packet type: %v", t)
+		err = fmt.Errorf("RemoteClientConnection: unrecognized packet type: %v", packet.PacketType)
 	}
 
 	if err != nil {
-		return np, err
+		return d2netpacket.NetPacket{}, err
 	}
 
-	mp, marshalErr := d2netpacket.MarshalPacket(p)
-	if marshalErr != nil {
-		r.Errorf("MarshalPacket: %v", marshalErr)
-	}
-
-	np = d2netpacket.NetPacket{PacketType: t, PacketData: mp}
-
-	return np, nil
+	return packet, nil
 }
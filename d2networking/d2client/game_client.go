@@ -3,6 +3,7 @@ package d2client
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
 
@@ -13,11 +14,13 @@ import (
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math"
 
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2interface"
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math/d2vector"
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2util"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapengine"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapentity"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2state"
 	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2client/d2clientconnectiontype"
 	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2client/d2localclient"
 	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2client/d2remoteclient"
@@ -30,6 +33,10 @@ const logPrefix = "Game Client"
 
 const (
 	numSubtilesPerTile = 5
+	startLevelID       = 1 // the Rogue Encampment
+
+	// objectSelectDistance is how far (in sub tiles) from an object a click selects it
+	objectSelectDistance = 2.0
 )
 
 // GameClient manages a connection to d2server.GameServer
@@ -39,13 +46,18 @@ type GameClient struct {
 	connectionType   d2clientconnectiontype.ClientConnectionType // Type of connection (local or remote)
 	asset            *d2asset.AssetManager
 	scriptEngine     *d2script.ScriptEngine
-	GameState        *d2hero.HeroState              // local player state
-	MapEngine        *d2mapengine.MapEngine         // Map and entities
-	mapGen           *d2mapgen.MapGenerator         // map generator
-	PlayerID         string                         // ID of the local player
-	Players          map[string]*d2mapentity.Player // IDs of the other players
-	Seed             int64                          // Map seed
-	RegenMap         bool                           // Regenerate tile cache on render (map has changed)
+	GameState        *d2hero.HeroState                  // local player state
+	MapEngine        *d2mapengine.MapEngine             // Map and entities
+	mapGen           *d2mapgen.MapGenerator             // map generator
+	PlayerID         string                             // ID of the local player
+	Players          map[string]*d2mapentity.Player     // IDs of the other players
+	Warps            []d2mapgen.Warp                    // Warps to the levels linked to the map
+	Seed             int64                              // Map seed
+	difficulty       d2enum.DifficultyType              // Difficulty of the game
+	RegenMap         bool                               // Regenerate tile cache on render (map has changed)
+	Vendor           *d2netpacket.VendorInventoryPacket // The vendor window the local player has open
+	HirelingOffers   *d2netpacket.HirelingOffersPacket  // The hirelings offered to the local player
+	Hirelings        map[string]*d2mapentity.NPC        // The hirelings on the map, by the IDs of their owners
 
 	*d2util.Logger
 }
@@ -59,6 +71,7 @@ func Create(connectionType d2clientconnectiontype.ClientConnectionType,
 		asset:          asset,
 		MapEngine:      d2mapengine.CreateMapEngine(l, asset),
 		Players:        make(map[string]*d2mapentity.Player),
+		Hirelings:      make(map[string]*d2mapentity.NPC),
 		connectionType: connectionType,
 		scriptEngine:   scriptEngine,
 	}
@@ -155,6 +168,10 @@ func (g *GameClient) OnPacketReceived(packet d2netpacket.NetPacket) error {
 		if err := g.handleSpawnItemPacket(packet); err != nil {
 			return err
 		}
+	case d2netpackettype.PickupItem:
+		if err := g.handlePickupItemPacket(packet); err != nil {
+			return err
+		}
 	case d2netpackettype.Ping:
 		if err := g.handlePingPacket(); err != nil {
 			g.Errorf("GameClient: error responding to server ping: %s", err)
@@ -170,6 +187,62 @@ func (g *GameClient) OnPacketReceived(packet d2netpacket.NetPacket) error {
 	case d2netpackettype.ServerFull:
 		g.Infof("Server is full") // need to be verified
 		os.Exit(0)
+	case d2netpackettype.ChangeLevel:
+		if err := g.handleChangeLevelPacket(packet); err != nil {
+			return err
+		}
+	case d2netpackettype.DamageEntity:
+		if err := g.handleDamageEntityPacket(packet); err != nil {
+			return err
+		}
+	case d2netpackettype.KillEntity:
+		if err := g.handleKillEntityPacket(packet); err != nil {
+			return err
+		}
+	case d2netpackettype.MoveMonster:
+		if err := g.handleMoveMonsterPacket(packet); err != nil {
+			return err
+		}
+	case d2netpackettype.MonsterAction:
+		if err := g.handleMonsterActionPacket(packet); err != nil {
+			return err
+		}
+	case d2netpackettype.OperateObject:
+		if err := g.handleOperateObjectPacket(packet); err != nil {
+			return err
+		}
+	case d2netpackettype.SetState:
+		if err := g.handleSetStatePacket(packet); err != nil {
+			return err
+		}
+	case d2netpackettype.SetVitals:
+		if err := g.handleSetVitalsPacket(packet); err != nil {
+			return err
+		}
+	case d2netpackettype.VendorInventory:
+		if err := g.handleVendorInventoryPacket(packet); err != nil {
+			return err
+		}
+	case d2netpackettype.TradeResult:
+		if err := g.handleTradeResultPacket(packet); err != nil {
+			return err
+		}
+	case d2netpackettype.HirelingOffers:
+		if err := g.handleHirelingOffersPacket(packet); err != nil {
+			return err
+		}
+	case d2netpackettype.UpdateHireling:
+		if err := g.handleUpdateHirelingPacket(packet); err != nil {
+			return err
+		}
+	case d2netpackettype.SpawnHireling:
+		if err := g.handleSpawnHirelingPacket(packet); err != nil {
+			return err
+		}
+	case d2netpackettype.UpdateStats:
+		if err := g.handleUpdateStatsPacket(packet); err != nil {
+			return err
+		}
 	default:
 		g.Fatalf("Invalid packet type: %d", packet.PacketType)
 	}
@@ -184,13 +257,17 @@ func (g *GameClient) SendPacketToServer(packet d2netpacket.NetPacket) error {
 }
 
 func (g *GameClient) handleGenerateMapPacket(packet d2netpacket.NetPacket) error {
-	mapData, err := d2netpacket.UnmarshalGenerateMap(packet.PacketData)
+	mapData, err := d2netpacket.UnmarshalGenerateMap(packet)
 	if err != nil {
 		return err
 	}
 
+	g.difficulty = mapData.Difficulty
+
 	if mapData.RegionType == d2enum.RegionAct1Town {
-		g.mapGen.GenerateAct1Overworld()
+		if g.Warps, err = g.mapGen.GenerateLevel(startLevelID, mapData.Difficulty); err != nil {
+			return err
+		}
 	}
 
 	g.RegenMap = true
@@ -199,7 +276,7 @@ func (g *GameClient) handleGenerateMapPacket(packet d2netpacket.NetPacket) error
 }
 
 func (g *GameClient) handleUpdateServerInfoPacket(packet d2netpacket.NetPacket) error {
-	serverInfo, err := d2netpacket.UnmarshalUpdateServerInfo(packet.PacketData)
+	serverInfo, err := d2netpacket.UnmarshalUpdateServerInfo(packet)
 	if err != nil {
 		return err
 	}
@@ -213,15 +290,21 @@ func (g *GameClient) handleUpdateServerInfoPacket(packet d2netpacket.NetPacket)
 }
 
 func (g *GameClient) handleAddPlayerPacket(packet d2netpacket.NetPacket) error {
-	player, err := d2netpacket.UnmarshalAddPlayer(packet.PacketData)
+	player, err := d2netpacket.UnmarshalAddPlayer(packet)
 	if err != nil {
 		return err
 	}
 
 	d2hero.HydrateSkills(player.Skills, g.asset)
 
+	items, err := g.MapEngine.LoadItems(player.Items)
+	if err != nil {
+		g.Errorf("failed to load the items of player %s: %v", player.ID, err)
+	}
+
 	newPlayer := g.MapEngine.NewPlayer(player.ID, player.Name, player.X, player.Y, 0,
-		player.HeroType, player.Stats, player.Skills, &player.Equipment, player.LeftSkill, player.RightSkill, player.Gold)
+		player.HeroType, player.Stats, player.Skills, items, player.LeftSkill, player.RightSkill, player.Gold)
+	newPlayer.StatEngine.SetDifficulty(g.difficulty)
 
 	g.Players[newPlayer.ID()] = newPlayer
 	g.MapEngine.AddEntity(newPlayer)
@@ -230,12 +313,12 @@ func (g *GameClient) handleAddPlayerPacket(packet d2netpacket.NetPacket) error {
 }
 
 func (g *GameClient) handleSpawnItemPacket(packet d2netpacket.NetPacket) error {
-	item, err := d2netpacket.UnmarshalSpawnItem(packet.PacketData)
+	item, err := d2netpacket.UnmarshalSpawnItem(packet)
 	if err != nil {
 		return err
 	}
 
-	itemEntity, err := g.MapEngine.NewItem(item.X, item.Y, item.Codes...)
+	itemEntity, err := g.MapEngine.NewItemWithID(item.ItemID, item.X, item.Y, item.Data)
 
 	if err == nil {
 		g.MapEngine.AddEntity(itemEntity)
@@ -244,8 +327,37 @@ func (g *GameClient) handleSpawnItemPacket(packet d2netpacket.NetPacket) error {
 	return err
 }
 
+// handlePickupItemPacket removes an item a player picked up from the ground. The local player
+// keeps the item, the client puts it in the first free place of the inventory.
+func (g *GameClient) handlePickupItemPacket(packet d2netpacket.NetPacket) error {
+	pickupItem, err := d2netpacket.UnmarshalPickupItem(packet)
+	if err != nil {
+		return err
+	}
+
+	itemEntity, ok := g.findEntity(pickupItem.ItemID).(*d2mapentity.Item)
+	if !ok {
+		return nil
+	}
+
+	g.MapEngine.RemoveEntity(itemEntity)
+
+	player, ok := g.Players[pickupItem.PlayerID]
+	if !ok || pickupItem.PlayerID != g.PlayerID {
+		return nil
+	}
+
+	item := itemEntity.Item
+	item.Location, item.Storage = d2enum.ItemLocationStored, d2enum.ItemStorageInventory
+	item.SetSlotType(d2enum.EquippedSlotNone)
+
+	player.Items = append(player.Items, &d2hero.HeroItem{ID: pickupItem.ItemID, Item: item})
+
+	return nil
+}
+
 func (g *GameClient) handleMovePlayerPacket(packet d2netpacket.NetPacket) error {
-	movePlayer, err := d2netpacket.UnmarshalMovePlayer(packet.PacketData)
+	movePlayer, err := d2netpacket.UnmarshalMovePlayer(packet)
 	if err != nil {
 		return err
 	}
@@ -279,7 +391,7 @@ func (g *GameClient) handleMovePlayerPacket(packet d2netpacket.NetPacket) error
 }
 
 func (g *GameClient) handleCastSkillPacket(packet d2netpacket.NetPacket) error {
-	playerCast, err := d2netpacket.UnmarshalCast(packet.PacketData)
+	playerCast, err := d2netpacket.UnmarshalCast(packet)
 	if err != nil {
 		return err
 	}
@@ -448,7 +560,7 @@ func (g *GameClient) handlePingPacket() error {
 }
 
 func (g *GameClient) handlePlayerDisconnectionPacket(packet d2netpacket.NetPacket) error {
-	disconnectPacket, err := d2netpacket.UnmarshalPlayerDisconnectionRequest(packet.PacketData)
+	disconnectPacket, err := d2netpacket.UnmarshalPlayerDisconnectionRequest(packet)
 	if err != nil {
 		return err
 	}
@@ -456,6 +568,489 @@ func (g *GameClient) handlePlayerDisconnectionPacket(packet d2netpacket.NetPacke
 	player := g.Players[disconnectPacket.ID]
 	g.MapEngine.RemoveEntity(player)
 	delete(g.Players, disconnectPacket.ID)
+	g.removeHireling(disconnectPacket.ID)
+
+	return nil
+}
+
+func (g *GameClient) handleChangeLevelPacket(packet d2netpacket.NetPacket) error {
+	changeLevel, err := d2netpacket.UnmarshalChangeLevel(packet)
+	if err != nil {
+		return err
+	}
+
+	player, found := g.Players[changeLevel.PlayerID]
+
+	// another player left the map, the server sends an AddPlayerPacket when a player enters it
+	if changeLevel.PlayerID != g.PlayerID {
+		if found {
+			g.MapEngine.RemoveEntity(player)
+			delete(g.Players, changeLevel.PlayerID)
+		}
+
+		g.removeHireling(changeLevel.PlayerID)
+
+		return nil
+	}
+
+	if !found {
+		return fmt.Errorf("the local player %s does not exist", g.PlayerID)
+	}
+
+	if g.Warps, err = g.mapGen.GenerateLevel(changeLevel.LevelID, changeLevel.Difficulty); err != nil {
+		return err
+	}
+
+	g.difficulty = changeLevel.Difficulty
+
+	// the map has been reset, the server sends the other players and the hirelings on the new map
+	for id := range g.Players {
+		if id != g.PlayerID {
+			delete(g.Players, id)
+		}
+	}
+
+	g.Hirelings = make(map[string]*d2mapentity.NPC)
+
+	player.Position = d2vector.NewPositionTile(changeLevel.X, changeLevel.Y)
+	player.StopMoving()
+
+	if tile := g.MapEngine.TileAt(int(changeLevel.X), int(changeLevel.Y)); tile != nil {
+		player.SetIsInTown(tile.RegionType == d2enum.RegionAct1Town)
+	}
+
+	g.MapEngine.AddEntity(player)
+	g.RegenMap = true
+
+	return nil
+}
+
+// findEntity returns the player or map entity with the given ID, or nil if there is none
+func (g *GameClient) findEntity(id string) d2interface.MapEntity {
+	if player, found := g.Players[id]; found {
+		return player
+	}
+
+	for _, entity := range g.MapEngine.Entities() {
+		if entity.ID() == id {
+			return entity
+		}
+	}
+
+	return nil
+}
+
+func (g *GameClient) handleDamageEntityPacket(packet d2netpacket.NetPacket) error {
+	damageEntity, err := d2netpacket.UnmarshalDamageEntity(packet)
+	if err != nil {
+		return err
+	}
+
+	switch entity := g.findEntity(damageEntity.EntityID).(type) {
+	case *d2mapentity.Player:
+		entity.Stats.Health = damageEntity.Life
+
+		if damageEntity.HitRecovery {
+			entity.GetHit()
+		}
+	case *d2mapentity.NPC:
+		if damageEntity.HitRecovery {
+			entity.GetHit()
+		}
+	}
+
+	return nil
+}
+
+func (g *GameClient) handleKillEntityPacket(packet d2netpacket.NetPacket) error {
+	killEntity, err := d2netpacket.UnmarshalKillEntity(packet)
+	if err != nil {
+		return err
+	}
+
+	switch entity := g.findEntity(killEntity.EntityID).(type) {
+	case *d2mapentity.Player:
+		entity.Stats.Health = 0
+		entity.Die()
+	case *d2mapentity.NPC:
+		entity.Die()
+	default:
+		g.Warningf("GameClient: unknown entity %s was killed", killEntity.EntityID)
+	}
+
+	return nil
+}
+
+func (g *GameClient) handleMoveMonsterPacket(packet d2netpacket.NetPacket) error {
+	moveMonster, err := d2netpacket.UnmarshalMoveMonster(packet)
+	if err != nil {
+		return err
+	}
+
+	monster, ok := g.findEntity(moveMonster.MonsterID).(*d2mapentity.NPC)
+	if !ok {
+		return nil
+	}
+
+	// the AI of the server moves the monster instead of the paths of the map
+	monster.HasPaths = false
+	monster.Position = d2vector.NewPositionTile(moveMonster.StartX, moveMonster.StartY)
+
+	dest := d2vector.NewPositionTile(moveMonster.DestX, moveMonster.DestY)
+	path := g.MapEngine.PathFindFor(monster.Position, dest, d2mapengine.PathMoverMonster)
+
+	if len(path) > 0 {
+		monster.SetPath(path, nil)
+	}
+
+	return nil
+}
+
+func (g *GameClient) handleMonsterActionPacket(packet d2netpacket.NetPacket) error {
+	monsterAction, err := d2netpacket.UnmarshalMonsterAction(packet)
+	if err != nil {
+		return err
+	}
+
+	monster, ok := g.findEntity(monsterAction.MonsterID).(*d2mapentity.NPC)
+	if !ok {
+		return nil
+	}
+
+	target := g.findEntity(monsterAction.TargetID)
+	if target == nil {
+		return nil
+	}
+
+	switch monsterAction.Action {
+	case d2enum.MonsterActionAttack:
+		monster.HasPaths = false
+		monster.Act(d2enum.MonsterAnimationModeAttack1, target.GetPosition())
+	case d2enum.MonsterActionResurrect:
+		monster.Act(d2enum.MonsterAnimationModeSkill1, target.GetPosition())
+
+		if npc, ok := target.(*d2mapentity.NPC); ok {
+			npc.Resurrect()
+		}
+	}
+
+	return nil
+}
+
+func (g *GameClient) handleOperateObjectPacket(packet d2netpacket.NetPacket) error {
+	operateObject, err := d2netpacket.UnmarshalOperateObject(packet)
+	if err != nil {
+		return err
+	}
+
+	object, ok := g.findEntity(operateObject.ObjectID).(*d2mapentity.Object)
+	if !ok {
+		return nil
+	}
+
+	return object.Operate()
+}
+
+// entityStates returns the states of a player or a monster, or nil for other entities
+func entityStates(entity d2interface.MapEntity) *d2state.Manager {
+	switch entity := entity.(type) {
+	case *d2mapentity.Player:
+		return entity.States
+	case *d2mapentity.NPC:
+		return entity.States
+	default:
+		return nil
+	}
+}
+
+// updateEntityStats derives the final stats of a player again, after its states changed
+func updateEntityStats(entity d2interface.MapEntity) {
+	if player, ok := entity.(*d2mapentity.Player); ok {
+		player.UpdateStats()
+	}
+}
+
+// handleSetStatePacket puts an entity in a state or removes it from the state. The cast overlay
+// of States.txt plays when the entity enters the state, the removal overlay when it leaves.
+func (g *GameClient) handleSetStatePacket(packet d2netpacket.NetPacket) error {
+	setState, err := d2netpacket.UnmarshalSetState(packet)
+	if err != nil {
+		return err
+	}
+
+	entity := g.findEntity(setState.EntityID)
+
+	states := entityStates(entity)
+	if states == nil {
+		return nil
+	}
+
+	record := d2state.Record(g.asset.Records.States, setState.State)
+	overlay := record.CastOverlay
+
+	if setState.Removed {
+		if !states.Remove(setState.State) {
+			return nil
+		}
+
+		updateEntityStats(entity)
+
+		overlay = record.RemOverlay
+	} else {
+		expires := time.Time{}
+		if setState.Duration > 0 {
+			expires = time.Now().Add(time.Duration(setState.Duration * float64(time.Second)))
+		}
+
+		entered := !states.Has(setState.State)
+		states.Set(record, setState.Stats, expires)
+		updateEntityStats(entity)
+
+		if !entered {
+			return nil
+		}
+	}
+
+	position := entity.GetPosition()
+
+	return g.playCastOverlay(g.asset.Records.Layout.Overlays[overlay], int(position.X()), int(position.Y()))
+}
+
+func (g *GameClient) handleSetVitalsPacket(packet d2netpacket.NetPacket) error {
+	setVitals, err := d2netpacket.UnmarshalSetVitals(packet)
+	if err != nil {
+		return err
+	}
+
+	if player, ok := g.findEntity(setVitals.EntityID).(*d2mapentity.Player); ok {
+		player.Stats.Health = setVitals.Life
+		player.Stats.Mana = setVitals.Mana
+
+		// the server sends the vitals of a dead player when it respawns
+		if player.IsDead() && setVitals.Life > 0 {
+			player.Revive()
+		}
+	}
+
+	return nil
+}
+
+func (g *GameClient) handleVendorInventoryPacket(packet d2netpacket.NetPacket) error {
+	vendorInventory, err := d2netpacket.UnmarshalVendorInventory(packet)
+	if err != nil {
+		return err
+	}
+
+	g.Vendor = &vendorInventory
+
+	return nil
+}
+
+// handleTradeResultPacket applies a trade at the vendor. The gold of the player is always taken
+// from the server, even when the trade was refused.
+func (g *GameClient) handleTradeResultPacket(packet d2netpacket.NetPacket) error {
+	tradeResult, err := d2netpacket.UnmarshalTradeResult(packet)
+	if err != nil {
+		return err
+	}
+
+	if player, ok := g.Players[tradeResult.PlayerID]; ok {
+		player.Gold = tradeResult.Gold
+	}
+
+	if g.GameState != nil && tradeResult.PlayerID == g.PlayerID {
+		g.GameState.Gold = tradeResult.Gold
+	}
+
+	if tradeResult.Error != "" {
+		g.Infof("trade of item %s refused: %s", tradeResult.ItemID, tradeResult.Error)
+		return nil
+	}
+
+	if g.Vendor == nil || tradeResult.Action != d2enum.TradeBuy {
+		return nil
+	}
+
+	for idx := range g.Vendor.Items {
+		if g.Vendor.Items[idx].ID == tradeResult.ItemID {
+			g.Vendor.Items = append(g.Vendor.Items[:idx], g.Vendor.Items[idx+1:]...)
+			break
+		}
+	}
+
+	return nil
+}
+
+func (g *GameClient) handleHirelingOffersPacket(packet d2netpacket.NetPacket) error {
+	hirelingOffers, err := d2netpacket.UnmarshalHirelingOffers(packet)
+	if err != nil {
+		return err
+	}
+
+	g.HirelingOffers = &hirelingOffers
+
+	return nil
+}
+
+// handleUpdateHirelingPacket takes the hireling and the gold of the local player from the
+// server, even when the action was refused
+func (g *GameClient) handleUpdateHirelingPacket(packet d2netpacket.NetPacket) error {
+	updateHireling, err := d2netpacket.UnmarshalUpdateHireling(packet)
+	if err != nil {
+		return err
+	}
+
+	if updateHireling.PlayerID != g.PlayerID || g.GameState == nil {
+		return nil
+	}
+
+	g.GameState.Gold = updateHireling.Gold
+	g.GameState.Hireling = updateHireling.Hireling
+
+	if player, ok := g.Players[g.PlayerID]; ok {
+		player.Gold = updateHireling.Gold
+	}
+
+	if updateHireling.Error != "" {
+		g.Infof("hireling action refused: %s", updateHireling.Error)
+		return nil
+	}
+
+	if updateHireling.Action == d2enum.HirelingHire {
+		g.HirelingOffers = nil
+	}
+
+	return nil
+}
+
+// handleSpawnHirelingPacket adds the hireling of a player to the map, replacing the hireling
+// the player had
+func (g *GameClient) handleSpawnHirelingPacket(packet d2netpacket.NetPacket) error {
+	spawnHireling, err := d2netpacket.UnmarshalSpawnHireling(packet)
+	if err != nil {
+		return err
+	}
+
+	monstat := g.asset.Records.Monster.Stats[spawnHireling.Monster]
+	if monstat == nil {
+		return fmt.Errorf("no monstat entry for the hireling \"%s\"", spawnHireling.Monster)
+	}
+
+	g.removeHireling(spawnHireling.OwnerID)
+
+	x := int(spawnHireling.X * numSubtilesPerTile)
+	y := int(spawnHireling.Y * numSubtilesPerTile)
+
+	hireling, err := g.MapEngine.NewNPCWithID(spawnHireling.EntityID, x, y, monstat, 0)
+	if err != nil {
+		return err
+	}
+
+	// the AI of the server moves the hireling
+	hireling.HasPaths = false
+
+	g.MapEngine.AddEntity(hireling)
+	g.Hirelings[spawnHireling.OwnerID] = hireling
+
+	return nil
+}
+
+// handleUpdateStatsPacket takes the stats and the skill points of a player from the server,
+// even when the point the local player spent was refused
+func (g *GameClient) handleUpdateStatsPacket(packet d2netpacket.NetPacket) error {
+	updateStats, err := d2netpacket.UnmarshalUpdateStats(packet)
+	if err != nil {
+		return err
+	}
+
+	player, found := g.Players[updateStats.PlayerID]
+	if !found || updateStats.Stats == nil {
+		return nil
+	}
+
+	if updateStats.Error != "" {
+		g.Infof("point refused: %s", updateStats.Error)
+	}
+
+	// the stamina is not sent, the stamina gained with a level or vitality is added to it
+	stamina := player.Stats.Stamina + float64(updateStats.Stats.MaxStamina-player.Stats.MaxStamina)
+	if updateStats.Stats.Level > player.Stats.Level {
+		stamina = float64(updateStats.Stats.MaxStamina)
+	}
+
+	*player.Stats = *updateStats.Stats
+	player.Stats.Stamina = stamina
+	player.Stats.NextLevelExp = g.asset.Records.GetExperienceBreakpoint(player.Class, player.Stats.Level)
+
+	for skillID, points := range updateStats.Skills {
+		if skill, found := player.Skills[skillID]; found && skill != nil {
+			skill.SkillPoints = points
+
+			if skill.Shallow != nil {
+				skill.Shallow.SkillPoints = points
+			}
+		}
+	}
+
+	player.UpdateStats()
+
+	return nil
+}
+
+// removeHireling removes the hireling of a player from the map
+func (g *GameClient) removeHireling(ownerID string) {
+	if hireling, found := g.Hirelings[ownerID]; found {
+		g.MapEngine.RemoveEntity(hireling)
+		delete(g.Hirelings, ownerID)
+	}
+}
+
+// ObjectAt returns the selectable object at the given position (in sub tiles), or nil if there
+// is none
+func (g *GameClient) ObjectAt(x, y float64) *d2mapentity.Object {
+	position := d2vector.NewPosition(x, y)
+
+	for _, entity := range g.MapEngine.Entities() {
+		object, ok := entity.(*d2mapentity.Object)
+		if !ok || object.IsOperated() || !object.Selectable() {
+			continue
+		}
+
+		if object.Position.Distance(&position.Vector) <= objectSelectDistance {
+			return object
+		}
+	}
+
+	return nil
+}
+
+// ItemAt returns the item on the ground at the given position (in sub tiles), or nil if there
+// is none
+func (g *GameClient) ItemAt(x, y float64) *d2mapentity.Item {
+	position := d2vector.NewPosition(x, y)
+
+	for _, entity := range g.MapEngine.Entities() {
+		item, ok := entity.(*d2mapentity.Item)
+		if !ok {
+			continue
+		}
+
+		if item.Position.Distance(&position.Vector) <= objectSelectDistance {
+			return item
+		}
+	}
+
+	return nil
+}
+
+// WarpAt returns the warp which is used from the given position (in sub tiles), or nil if
+// there is none
+func (g *GameClient) WarpAt(x, y float64) *d2mapgen.Warp {
+	for idx := range g.Warps {
+		if g.Warps[idx].Distance(x, y) == 0 {
+			return &g.Warps[idx]
+		}
+	}
 
 	return nil
 }
@@ -0,0 +1,172 @@
+package d2netpacket
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ProtocolVersion is the version of the network protocol. Clients send it in the
+// PlayerConnectionRequestPacket, the server refuses clients with a different version.
+// Increase it whenever the layout of a packet changes.
+const ProtocolVersion = 15
+
+// Codec is the wire format used to send NetPackets over a stream connection
+type Codec int
+
+// Codecs
+const (
+	// CodecJSON sends every packet as a JSON document
+	CodecJSON Codec = iota
+	// CodecBinary sends every packet as a length prefixed binary frame
+	CodecBinary
+)
+
+const (
+	// binaryFrameMagic is the first byte of every binary frame. It can never start a JSON
+	// document, which lets the receiving side detect the codec of a connection.
+	binaryFrameMagic = 0xD2
+
+	// binaryFrameHeaderSize is the size of the magic byte and the frame length
+	binaryFrameHeaderSize = 5
+
+	// maxBinaryFrameSize limits the memory a single frame can make the receiver allocate
+	maxBinaryFrameSize = 1 << 20
+)
+
+func (c Codec) String() string {
+	switch c {
+	case CodecJSON:
+		return "JSON"
+	case CodecBinary:
+		return "Binary"
+	}
+
+	return fmt.Sprintf("Codec(%d)", int(c))
+}
+
+// PacketEncoder writes NetPackets to a stream using a Codec
+type PacketEncoder struct {
+	codec  Codec
+	writer io.Writer
+	json   *json.Encoder
+}
+
+// NewPacketEncoder creates a PacketEncoder which writes to w using the given codec
+func NewPacketEncoder(codec Codec, w io.Writer) *PacketEncoder {
+	return &PacketEncoder{
+		codec:  codec,
+		writer: w,
+		json:   json.NewEncoder(w),
+	}
+}
+
+// Codec returns the codec of the encoder
+func (e *PacketEncoder) Codec() Codec {
+	return e.codec
+}
+
+// Encode writes the packet to the stream
+func (e *PacketEncoder) Encode(packet NetPacket) error {
+	if e.codec == CodecJSON {
+		data, err := packet.Data()
+		if err != nil {
+			return err
+		}
+
+		return e.json.Encode(NetPacket{PacketType: packet.PacketType, PacketData: data})
+	}
+
+	data, err := MarshalBinaryPacket(packet)
+	if err != nil {
+		return err
+	}
+
+	frame := make([]byte, binaryFrameHeaderSize, binaryFrameHeaderSize+len(data))
+	frame[0] = binaryFrameMagic
+	binary.LittleEndian.PutUint32(frame[1:], uint32(len(data)))
+	frame = append(frame, data...)
+
+	_, err = e.writer.Write(frame)
+
+	return err
+}
+
+// PacketDecoder reads NetPackets from a stream. The codec is detected from the first
+// packet and is the same for all following packets.
+type PacketDecoder struct {
+	codec    Codec
+	detected bool
+	reader   *bufio.Reader
+	json     *json.Decoder
+}
+
+// NewPacketDecoder creates a PacketDecoder which reads from r
+func NewPacketDecoder(r io.Reader) *PacketDecoder {
+	return &PacketDecoder{reader: bufio.NewReader(r)}
+}
+
+// Codec returns the codec of the stream. It is only valid after the first packet was decoded.
+func (d *PacketDecoder) Codec() Codec {
+	return d.codec
+}
+
+// Decode reads the next packet from the stream
+func (d *PacketDecoder) Decode() (NetPacket, error) {
+	if !d.detected {
+		first, err := d.reader.Peek(1)
+		if err != nil {
+			return NetPacket{}, err
+		}
+
+		d.detected = true
+
+		if first[0] == binaryFrameMagic {
+			d.codec = CodecBinary
+		} else {
+			d.codec = CodecJSON
+			d.json = json.NewDecoder(d.reader)
+		}
+	}
+
+	if d.codec == CodecJSON {
+		var packet NetPacket
+		err := d.json.Decode(&packet)
+
+		return packet, err
+	}
+
+	return d.decodeBinaryFrame()
+}
+
+func (d *PacketDecoder) decodeBinaryFrame() (NetPacket, error) {
+	header := make([]byte, binaryFrameHeaderSize)
+
+	if _, err := io.ReadFull(d.reader, header); err != nil {
+		return NetPacket{}, err
+	}
+
+	if header[0] != binaryFrameMagic {
+		return NetPacket{}, errors.New("invalid binary packet frame")
+	}
+
+	size := binary.LittleEndian.Uint32(header[1:])
+	if size == 0 || size > maxBinaryFrameSize {
+		return NetPacket{}, fmt.Errorf("invalid binary packet frame size %d", size)
+	}
+
+	frame := make([]byte, size)
+
+	if _, err := io.ReadFull(d.reader, frame); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+
+		return NetPacket{}, err
+	}
+
+	return UnmarshalBinaryPacket(frame)
+}
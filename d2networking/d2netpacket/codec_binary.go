@@ -0,0 +1,664 @@
+package d2netpacket
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2datautils"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+// MarshalBinaryPacket encodes the packet type and the packet struct to the binary wire format,
+// field by field. The packet struct of a packet read as JSON is unmarshalled first.
+func MarshalBinaryPacket(packet NetPacket) ([]byte, error) {
+	body, err := packetBody(packet)
+	if err != nil {
+		return nil, fmt.Errorf("binary codec: %s: %v", packet.PacketType, err)
+	}
+
+	w := &binaryWriter{d2datautils.CreateStreamWriter()}
+	w.PushBytes(byte(packet.PacketType))
+
+	if err := marshalBinaryBody(w, body); err != nil {
+		return nil, fmt.Errorf("binary codec: %s: %v", packet.PacketType, err)
+	}
+
+	return w.GetBytes(), nil
+}
+
+// UnmarshalBinaryPacket decodes a packet from the binary wire format. The NetPacket holds the
+// packet struct, which the Unmarshal functions of this package return without decoding JSON.
+func UnmarshalBinaryPacket(data []byte) (NetPacket, error) {
+	if len(data) == 0 {
+		return NetPacket{}, errors.New("binary codec: empty packet")
+	}
+
+	packetType := d2netpackettype.NetPacketType(data[0])
+	r := &binaryReader{reader: d2datautils.CreateStreamReader(data[1:])}
+
+	body, err := unmarshalBinaryBody(r, packetType)
+	if err == nil {
+		err = r.err
+	}
+
+	if err != nil {
+		return NetPacket{PacketType: packetType}, fmt.Errorf("binary codec: %s: %v", packetType, err)
+	}
+
+	return newNetPacket(packetType, body), nil
+}
+
+// packetBody returns the packet struct of the packet, unmarshalled from the JSON data of a
+// packet read as JSON
+// nolint:gocyclo,funlen // switch statement on packet type makes sense, no need to change
+func packetBody(packet NetPacket) (interface{}, error) {
+	if packet.body != nil {
+		return packet.body, nil
+	}
+
+	switch packet.PacketType {
+	case d2netpackettype.UpdateServerInfo:
+		return UnmarshalUpdateServerInfo(packet)
+	case d2netpackettype.GenerateMap:
+		return UnmarshalGenerateMap(packet)
+	case d2netpackettype.AddPlayer:
+		return UnmarshalAddPlayer(packet)
+	case d2netpackettype.MovePlayer:
+		return UnmarshalMovePlayer(packet)
+	case d2netpackettype.PlayerConnectionRequest:
+		return UnmarshalPlayerConnectionRequest(packet)
+	case d2netpackettype.PlayerDisconnectionNotification:
+		return UnmarshalPlayerDisconnectionRequest(packet)
+	case d2netpackettype.Ping:
+		return UnmarshalPing(packet)
+	case d2netpackettype.Pong:
+		return UnmarshalPong(packet)
+	case d2netpackettype.ServerClosed:
+		return UnmarshalServerClosed(packet)
+	case d2netpackettype.CastSkill:
+		return UnmarshalCast(packet)
+	case d2netpackettype.SpawnItem:
+		return UnmarshalSpawnItem(packet)
+	case d2netpackettype.SavePlayer:
+		return UnmarshalSavePlayer(packet)
+	case d2netpackettype.ServerFull:
+		return UnmarshalServerFull(packet)
+	case d2netpackettype.ChangeLevel:
+		return UnmarshalChangeLevel(packet)
+	case d2netpackettype.DamageEntity:
+		return UnmarshalDamageEntity(packet)
+	case d2netpackettype.KillEntity:
+		return UnmarshalKillEntity(packet)
+	case d2netpackettype.MoveMonster:
+		return UnmarshalMoveMonster(packet)
+	case d2netpackettype.MonsterAction:
+		return UnmarshalMonsterAction(packet)
+	case d2netpackettype.OperateObject:
+		return UnmarshalOperateObject(packet)
+	case d2netpackettype.SetState:
+		return UnmarshalSetState(packet)
+	case d2netpackettype.SetVitals:
+		return UnmarshalSetVitals(packet)
+	case d2netpackettype.OpenVendor:
+		return UnmarshalOpenVendor(packet)
+	case d2netpackettype.VendorInventory:
+		return UnmarshalVendorInventory(packet)
+	case d2netpackettype.TradeItem:
+		return UnmarshalTradeItem(packet)
+	case d2netpackettype.TradeResult:
+		return UnmarshalTradeResult(packet)
+	case d2netpackettype.HirelingAction:
+		return UnmarshalHirelingAction(packet)
+	case d2netpackettype.HirelingOffers:
+		return UnmarshalHirelingOffers(packet)
+	case d2netpackettype.UpdateHireling:
+		return UnmarshalUpdateHireling(packet)
+	case d2netpackettype.SpawnHireling:
+		return UnmarshalSpawnHireling(packet)
+	case d2netpackettype.SpendPoint:
+		return UnmarshalSpendPoint(packet)
+	case d2netpackettype.UpdateStats:
+		return UnmarshalUpdateStats(packet)
+	case d2netpackettype.PickupItem:
+		return UnmarshalPickupItem(packet)
+	}
+
+	return nil, errors.New("unknown packet type")
+}
+
+// nolint:gocyclo,funlen // switch statement on packet type makes sense, no need to change
+func marshalBinaryBody(w *binaryWriter, body interface{}) error {
+	switch p := body.(type) {
+	case UpdateServerInfoPacket:
+		w.PushInt64(p.Seed)
+		w.pushString(p.PlayerID)
+	case GenerateMapPacket:
+		w.PushInt32(int32(p.RegionType))
+		w.PushBytes(byte(p.Difficulty))
+	case AddPlayerPacket:
+		w.pushAddPlayer(&p)
+	case MovePlayerPacket:
+		w.pushString(p.PlayerID)
+		w.pushFloat64(p.StartX, p.StartY, p.DestX, p.DestY)
+	case PlayerConnectionRequestPacket:
+		w.pushString(p.ID)
+		w.PushUint32(uint32(p.ProtocolVersion))
+		w.pushHeroState(p.PlayerState)
+		w.pushString(p.HeroKey)
+	case PlayerDisconnectRequestPacket:
+		w.pushString(p.ID)
+		w.pushHeroState(p.PlayerState)
+	case PingPacket:
+		w.pushTime(p.TS)
+	case PongPacket:
+		w.pushString(p.ID)
+		w.pushTime(p.TS)
+	case ServerClosedPacket:
+		w.pushTime(p.TS)
+	case CastPacket:
+		w.pushString(p.SourceEntityID)
+		w.PushInt32(int32(p.SkillID))
+		w.pushFloat64(p.TargetX, p.TargetY)
+		w.pushString(p.TargetEntityID)
+	case SpawnItemPacket:
+		w.pushString(p.ItemID)
+		w.PushInt32(int32(p.X))
+		w.PushInt32(int32(p.Y))
+		w.PushUint16(uint16(len(p.Codes)))
+
+		for idx := range p.Codes {
+			w.pushString(p.Codes[idx])
+		}
+
+		w.pushData(p.Data)
+	case SavePlayerPacket:
+		w.PushBytes(byte(p.Difficulty))
+		w.pushPlayer(p.Player)
+	case ServerFullPacket:
+	case ChangeLevelPacket:
+		w.pushString(p.PlayerID)
+		w.PushInt32(int32(p.LevelID))
+		w.PushBytes(byte(p.Difficulty))
+		w.pushFloat64(p.X, p.Y)
+	case DamageEntityPacket:
+		w.pushString(p.EntityID)
+		w.pushString(p.SourceID)
+		w.PushInt32(int32(p.Damage))
+		w.PushInt32(int32(p.Life))
+		w.pushBool(p.HitRecovery)
+	case KillEntityPacket:
+		w.pushString(p.EntityID)
+		w.pushString(p.KillerID)
+	case MoveMonsterPacket:
+		w.pushString(p.MonsterID)
+		w.pushFloat64(p.StartX, p.StartY, p.DestX, p.DestY)
+	case MonsterActionPacket:
+		w.pushString(p.MonsterID)
+		w.PushInt32(int32(p.Action))
+		w.pushString(p.TargetID)
+	case OperateObjectPacket:
+		w.pushString(p.PlayerID)
+		w.pushString(p.ObjectID)
+	case SetStatePacket:
+		w.pushString(p.EntityID)
+		w.pushString(p.State)
+		w.pushStats(p.Stats)
+		w.pushFloat64(p.Duration)
+		w.pushBool(p.Removed)
+	case SetVitalsPacket:
+		w.pushString(p.EntityID)
+		w.PushInt32(int32(p.Life))
+		w.PushInt32(int32(p.Mana))
+	case OpenVendorPacket:
+		w.pushString(p.PlayerID)
+		w.pushString(p.NPCID)
+		w.pushBool(p.Gamble)
+	case VendorInventoryPacket:
+		w.pushString(p.NPCID)
+		w.pushBool(p.Gamble)
+		w.PushUint16(uint16(len(p.Items)))
+
+		for idx := range p.Items {
+			w.pushString(p.Items[idx].ID)
+			w.pushStrings(p.Items[idx].Codes)
+			w.PushInt32(int32(p.Items[idx].Price))
+			w.pushString(p.Items[idx].Page)
+		}
+	case TradeItemPacket:
+		w.pushString(p.PlayerID)
+		w.PushBytes(byte(p.Action))
+		w.pushString(p.ItemID)
+	case TradeResultPacket:
+		w.pushString(p.PlayerID)
+		w.PushBytes(byte(p.Action))
+		w.pushString(p.ItemID)
+		w.pushStrings(p.Codes)
+		w.PushInt32(int32(p.Gold))
+		w.pushString(p.Error)
+	case HirelingActionPacket:
+		w.pushString(p.PlayerID)
+		w.PushBytes(byte(p.Action))
+		w.pushString(p.NPCID)
+		w.pushString(p.OfferID)
+		w.pushString(p.ItemID)
+		w.PushBytes(byte(p.Slot))
+	case HirelingOffersPacket:
+		w.pushString(p.NPCID)
+		w.PushUint16(uint16(len(p.Offers)))
+
+		for idx := range p.Offers {
+			w.pushString(p.Offers[idx].ID)
+			w.pushHireling(&p.Offers[idx].Hireling)
+			w.PushInt32(int32(p.Offers[idx].Price))
+		}
+	case UpdateHirelingPacket:
+		w.pushString(p.PlayerID)
+		w.PushBytes(byte(p.Action))
+		w.pushHireling(p.Hireling)
+		w.PushInt32(int32(p.Gold))
+		w.pushString(p.ItemID)
+		w.pushStrings(p.Codes)
+		w.pushString(p.Error)
+	case SpawnHirelingPacket:
+		w.pushString(p.OwnerID)
+		w.pushString(p.EntityID)
+		w.pushString(p.Monster)
+		w.pushString(p.Name)
+		w.pushFloat64(p.X, p.Y)
+	case SpendPointPacket:
+		w.pushString(p.PlayerID)
+		w.PushBytes(byte(p.Attribute))
+		w.PushInt32(int32(p.SkillID))
+	case UpdateStatsPacket:
+		w.pushString(p.PlayerID)
+		w.pushHeroStats(p.Stats)
+		w.pushSkillPoints(p.Skills)
+		w.pushString(p.Error)
+	case PickupItemPacket:
+		w.pushString(p.PlayerID)
+		w.pushString(p.ItemID)
+	default:
+		return fmt.Errorf("unknown packet struct %T", body)
+	}
+
+	return nil
+}
+
+// nolint:gocyclo,funlen // switch statement on packet type makes sense, no need to change
+func unmarshalBinaryBody(r *binaryReader, packetType d2netpackettype.NetPacketType) (interface{}, error) {
+	switch packetType {
+	case d2netpackettype.UpdateServerInfo:
+		return UpdateServerInfoPacket{Seed: int64(r.readUint64()), PlayerID: r.readString()}, nil
+	case d2netpackettype.GenerateMap:
+		return GenerateMapPacket{
+			RegionType: d2enum.RegionIdType(int32(r.readUint32())),
+			Difficulty: d2enum.DifficultyType(r.readByte()),
+		}, nil
+	case d2netpackettype.AddPlayer:
+		return r.readAddPlayer(), nil
+	case d2netpackettype.MovePlayer:
+		return MovePlayerPacket{
+			PlayerID: r.readString(),
+			StartX:   r.readFloat64(),
+			StartY:   r.readFloat64(),
+			DestX:    r.readFloat64(),
+			DestY:    r.readFloat64(),
+		}, nil
+	case d2netpackettype.PlayerConnectionRequest:
+		return PlayerConnectionRequestPacket{
+			ID:              r.readString(),
+			ProtocolVersion: int(r.readUint32()),
+			PlayerState:     r.readHeroState(),
+			HeroKey:         r.readString(),
+		}, nil
+	case d2netpackettype.PlayerDisconnectionNotification:
+		return PlayerDisconnectRequestPacket{ID: r.readString(), PlayerState: r.readHeroState()}, nil
+	case d2netpackettype.Ping:
+		return PingPacket{TS: r.readTime()}, nil
+	case d2netpackettype.Pong:
+		return PongPacket{ID: r.readString(), TS: r.readTime()}, nil
+	case d2netpackettype.ServerClosed:
+		return ServerClosedPacket{TS: r.readTime()}, nil
+	case d2netpackettype.CastSkill:
+		return CastPacket{
+			SourceEntityID: r.readString(),
+			SkillID:        int(int32(r.readUint32())),
+			TargetX:        r.readFloat64(),
+			TargetY:        r.readFloat64(),
+			TargetEntityID: r.readString(),
+		}, nil
+	case d2netpackettype.SpawnItem:
+		p := SpawnItemPacket{ItemID: r.readString(), X: int(int32(r.readUint32())), Y: int(int32(r.readUint32()))}
+		p.Codes = make([]string, r.readUint16())
+
+		for idx := range p.Codes {
+			p.Codes[idx] = r.readString()
+		}
+
+		p.Data = r.readData()
+
+		return p, nil
+	case d2netpackettype.SavePlayer:
+		return SavePlayerPacket{Difficulty: d2enum.DifficultyType(r.readByte()), Player: r.readPlayer()}, nil
+	case d2netpackettype.ServerFull:
+		return ServerFullPacket{}, nil
+	case d2netpackettype.ChangeLevel:
+		return ChangeLevelPacket{
+			PlayerID:   r.readString(),
+			LevelID:    int(int32(r.readUint32())),
+			Difficulty: d2enum.DifficultyType(r.readByte()),
+			X:          r.readFloat64(),
+			Y:          r.readFloat64(),
+		}, nil
+	case d2netpackettype.DamageEntity:
+		return DamageEntityPacket{
+			EntityID:    r.readString(),
+			SourceID:    r.readString(),
+			Damage:      int(int32(r.readUint32())),
+			Life:        int(int32(r.readUint32())),
+			HitRecovery: r.readBool(),
+		}, nil
+	case d2netpackettype.KillEntity:
+		return KillEntityPacket{EntityID: r.readString(), KillerID: r.readString()}, nil
+	case d2netpackettype.MoveMonster:
+		return MoveMonsterPacket{
+			MonsterID: r.readString(),
+			StartX:    r.readFloat64(),
+			StartY:    r.readFloat64(),
+			DestX:     r.readFloat64(),
+			DestY:     r.readFloat64(),
+		}, nil
+	case d2netpackettype.MonsterAction:
+		return MonsterActionPacket{
+			MonsterID: r.readString(),
+			Action:    d2enum.MonsterActionType(int32(r.readUint32())),
+			TargetID:  r.readString(),
+		}, nil
+	case d2netpackettype.OperateObject:
+		return OperateObjectPacket{PlayerID: r.readString(), ObjectID: r.readString()}, nil
+	case d2netpackettype.SetState:
+		return SetStatePacket{
+			EntityID: r.readString(),
+			State:    r.readString(),
+			Stats:    r.readStats(),
+			Duration: r.readFloat64(),
+			Removed:  r.readBool(),
+		}, nil
+	case d2netpackettype.SetVitals:
+		return SetVitalsPacket{
+			EntityID: r.readString(),
+			Life:     int(int32(r.readUint32())),
+			Mana:     int(int32(r.readUint32())),
+		}, nil
+	case d2netpackettype.OpenVendor:
+		return OpenVendorPacket{PlayerID: r.readString(), NPCID: r.readString(), Gamble: r.readBool()}, nil
+	case d2netpackettype.VendorInventory:
+		p := VendorInventoryPacket{NPCID: r.readString(), Gamble: r.readBool()}
+		p.Items = make([]VendorItem, r.readUint16())
+
+		for idx := range p.Items {
+			p.Items[idx] = VendorItem{
+				ID:    r.readString(),
+				Codes: r.readStrings(),
+				Price: int(int32(r.readUint32())),
+				Page:  r.readString(),
+			}
+		}
+
+		return p, nil
+	case d2netpackettype.TradeItem:
+		return TradeItemPacket{
+			PlayerID: r.readString(),
+			Action:   d2enum.TradeAction(r.readByte()),
+			ItemID:   r.readString(),
+		}, nil
+	case d2netpackettype.TradeResult:
+		return TradeResultPacket{
+			PlayerID: r.readString(),
+			Action:   d2enum.TradeAction(r.readByte()),
+			ItemID:   r.readString(),
+			Codes:    r.readStrings(),
+			Gold:     int(int32(r.readUint32())),
+			Error:    r.readString(),
+		}, nil
+	case d2netpackettype.HirelingAction:
+		return HirelingActionPacket{
+			PlayerID: r.readString(),
+			Action:   d2enum.HirelingAction(r.readByte()),
+			NPCID:    r.readString(),
+			OfferID:  r.readString(),
+			ItemID:   r.readString(),
+			Slot:     d2enum.EquippedSlot(r.readByte()),
+		}, nil
+	case d2netpackettype.HirelingOffers:
+		p := HirelingOffersPacket{NPCID: r.readString()}
+		p.Offers = make([]HirelingOffer, r.readUint16())
+
+		for idx := range p.Offers {
+			p.Offers[idx].ID = r.readString()
+
+			if hireling := r.readHireling(); hireling != nil {
+				p.Offers[idx].Hireling = *hireling
+			}
+
+			p.Offers[idx].Price = int(int32(r.readUint32()))
+		}
+
+		return p, nil
+	case d2netpackettype.UpdateHireling:
+		return UpdateHirelingPacket{
+			PlayerID: r.readString(),
+			Action:   d2enum.HirelingAction(r.readByte()),
+			Hireling: r.readHireling(),
+			Gold:     int(int32(r.readUint32())),
+			ItemID:   r.readString(),
+			Codes:    r.readStrings(),
+			Error:    r.readString(),
+		}, nil
+	case d2netpackettype.SpawnHireling:
+		return SpawnHirelingPacket{
+			OwnerID:  r.readString(),
+			EntityID: r.readString(),
+			Monster:  r.readString(),
+			Name:     r.readString(),
+			X:        r.readFloat64(),
+			Y:        r.readFloat64(),
+		}, nil
+	case d2netpackettype.SpendPoint:
+		return SpendPointPacket{
+			PlayerID:  r.readString(),
+			Attribute: d2enum.Attribute(r.readByte()),
+			SkillID:   int(int32(r.readUint32())),
+		}, nil
+	case d2netpackettype.UpdateStats:
+		return UpdateStatsPacket{
+			PlayerID: r.readString(),
+			Stats:    r.readHeroStats(),
+			Skills:   r.readSkillPoints(),
+			Error:    r.readString(),
+		}, nil
+	case d2netpackettype.PickupItem:
+		return PickupItemPacket{PlayerID: r.readString(), ItemID: r.readString()}, nil
+	}
+
+	return nil, errors.New("unknown packet type")
+}
+
+type binaryWriter struct {
+	*d2datautils.StreamWriter
+}
+
+func (w *binaryWriter) pushString(s string) {
+	w.PushUint16(uint16(len(s)))
+	w.PushBytes([]byte(s)...)
+}
+
+func (w *binaryWriter) pushBool(b bool) {
+	if b {
+		w.PushBytes(1)
+		return
+	}
+
+	w.PushBytes(0)
+}
+
+func (w *binaryWriter) pushStrings(s []string) {
+	w.PushUint16(uint16(len(s)))
+
+	for idx := range s {
+		w.pushString(s[idx])
+	}
+}
+
+func (w *binaryWriter) pushFloat64(values ...float64) {
+	for _, v := range values {
+		w.PushUint64(math.Float64bits(v))
+	}
+}
+
+func (w *binaryWriter) pushTime(t time.Time) {
+	w.PushInt64(t.UnixNano())
+}
+
+// pushStats pushes the stats ordered by name, so the same stats are always encoded the same
+func (w *binaryWriter) pushStats(stats map[string]int) {
+	names := make([]string, 0, len(stats))
+	for name := range stats {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	w.PushUint16(uint16(len(names)))
+
+	for _, name := range names {
+		w.pushString(name)
+		w.PushInt32(int32(stats[name]))
+	}
+}
+
+// binaryReader reads values from a binary packet body. The first error is kept and all
+// following reads return zero values, so the error only has to be checked once at the end.
+type binaryReader struct {
+	reader *d2datautils.StreamReader
+	err    error
+}
+
+func (r *binaryReader) readBytes(count int) []byte {
+	if r.err != nil || count == 0 {
+		return nil
+	}
+
+	b, err := r.reader.ReadBytes(count)
+	if err != nil {
+		r.err = errors.New("unexpected end of packet")
+	}
+
+	return b
+}
+
+func (r *binaryReader) readByte() byte {
+	if b := r.readBytes(1); b != nil {
+		return b[0]
+	}
+
+	return 0
+}
+
+func (r *binaryReader) readUint16() uint16 {
+	if r.err != nil {
+		return 0
+	}
+
+	v, err := r.reader.ReadUInt16()
+	if err != nil {
+		r.err = errors.New("unexpected end of packet")
+	}
+
+	return v
+}
+
+func (r *binaryReader) readUint32() uint32 {
+	if r.err != nil {
+		return 0
+	}
+
+	v, err := r.reader.ReadUInt32()
+	if err != nil {
+		r.err = errors.New("unexpected end of packet")
+	}
+
+	return v
+}
+
+func (r *binaryReader) readUint64() uint64 {
+	if r.err != nil {
+		return 0
+	}
+
+	v, err := r.reader.ReadUInt64()
+	if err != nil {
+		r.err = errors.New("unexpected end of packet")
+	}
+
+	return v
+}
+
+// readFloat64 fails on NaN and infinite values, which no coordinate or duration of a packet
+// can have
+func (r *binaryReader) readFloat64() float64 {
+	v := math.Float64frombits(r.readUint64())
+	if r.err == nil && (math.IsNaN(v) || math.IsInf(v, 0)) {
+		r.err = fmt.Errorf("float value is not finite: %g", v)
+		return 0
+	}
+
+	return v
+}
+
+func (r *binaryReader) readString() string {
+	return string(r.readBytes(int(r.readUint16())))
+}
+
+func (r *binaryReader) readBool() bool {
+	return r.readByte() != 0
+}
+
+// readStrings returns nil for an empty list, like the list is left out of the JSON encoding
+func (r *binaryReader) readStrings() []string {
+	count := r.readUint16()
+	if count == 0 {
+		return nil
+	}
+
+	s := make([]string, count)
+	for idx := range s {
+		s[idx] = r.readString()
+	}
+
+	return s
+}
+
+func (r *binaryReader) readTime() time.Time {
+	return time.Unix(0, int64(r.readUint64()))
+}
+
+func (r *binaryReader) readStats() map[string]int {
+	count := int(r.readUint16())
+	if count == 0 {
+		return nil
+	}
+
+	stats := make(map[string]int, count)
+
+	for idx := 0; idx < count; idx++ {
+		name := r.readString()
+		stats[name] = int(int32(r.readUint32()))
+	}
+
+	return stats
+}
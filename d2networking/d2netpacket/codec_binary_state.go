@@ -0,0 +1,414 @@
+package d2netpacket
+
+import (
+	"sort"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hireling"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapentity"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2state"
+)
+
+// The binary encoding of the hero states, stats, skills, items and hirelings carried by packets.
+// Pointers and maps are preceded by a flag telling if they are set, so nil values decode to nil.
+
+func (w *binaryWriter) pushAddPlayer(p *AddPlayerPacket) {
+	w.pushString(p.ID)
+	w.pushString(p.Name)
+	w.PushInt32(int32(p.X))
+	w.PushInt32(int32(p.Y))
+	w.PushBytes(byte(p.HeroType))
+	w.PushInt32(int32(p.LeftSkill))
+	w.PushInt32(int32(p.RightSkill))
+	w.PushInt32(int32(p.Gold))
+	w.pushHeroStats(p.Stats)
+	w.pushSkills(p.Skills)
+	w.pushHeroItems(p.Items)
+}
+
+// pushHeroState pushes the hero state sent by a client. The legacy Equipment is not sent, the
+// hero states are migrated to items when they are loaded.
+func (w *binaryWriter) pushHeroState(state *d2hero.HeroState) {
+	w.pushBool(state != nil)
+
+	if state == nil {
+		return
+	}
+
+	w.pushString(state.HeroName)
+	w.PushBytes(byte(state.HeroType))
+	w.PushInt32(int32(state.Act))
+	w.pushHeroStats(state.Stats)
+	w.pushSkills(state.Skills)
+	w.pushFloat64(state.X, state.Y)
+	w.PushInt32(int32(state.LeftSkill))
+	w.PushInt32(int32(state.RightSkill))
+	w.PushInt32(int32(state.Gold))
+	w.PushBytes(byte(state.Difficulty))
+	w.pushSavedStates(state.States)
+	w.pushHireling(state.Hireling)
+	w.pushHeroItems(state.Items)
+	w.pushHeroProgress(state.Progress)
+}
+
+// pushPlayer pushes the parts of a player a client saves
+func (w *binaryWriter) pushPlayer(player *d2mapentity.Player) {
+	w.pushBool(player != nil)
+
+	if player == nil {
+		return
+	}
+
+	w.PushBytes(byte(player.Class))
+	w.PushInt32(int32(player.Act))
+	w.PushInt32(int32(player.Gold))
+	w.pushHeroStats(player.Stats)
+	w.pushSkills(player.Skills)
+	w.pushSkill(player.LeftSkill)
+	w.pushSkill(player.RightSkill)
+	w.pushHeroItems(player.Items)
+}
+
+// pushHeroStats pushes the stats which are sent in the JSON encoding, Stamina and NextLevelExp
+// are computed by the receiver
+func (w *binaryWriter) pushHeroStats(stats *d2hero.HeroStatsState) {
+	w.pushBool(stats != nil)
+
+	if stats == nil {
+		return
+	}
+
+	w.PushInt32(int32(stats.Level))
+	w.PushUint32(uint32(stats.Experience))
+
+	for _, v := range []int{
+		stats.Strength, stats.Energy, stats.Dexterity, stats.Vitality, stats.StatsPoints, stats.SkillPoints,
+		stats.Health, stats.MaxHealth, stats.Mana, stats.MaxMana, stats.MaxStamina,
+	} {
+		w.PushInt32(int32(v))
+	}
+}
+
+// pushSkill pushes the ID and the points of the skill, like the JSON encoding of a HeroSkill
+func (w *binaryWriter) pushSkill(skill *d2hero.HeroSkill) {
+	w.pushBool(skill != nil && skill.Shallow != nil)
+
+	if skill == nil || skill.Shallow == nil {
+		return
+	}
+
+	w.PushInt32(int32(skill.Shallow.SkillID))
+	w.PushInt32(int32(skill.Shallow.SkillPoints))
+}
+
+// pushSkills pushes the skills ordered by key, so the same skills are always encoded the same
+func (w *binaryWriter) pushSkills(skills map[int]*d2hero.HeroSkill) {
+	keys := make([]int, 0, len(skills))
+	for key := range skills {
+		keys = append(keys, key)
+	}
+
+	sort.Ints(keys)
+
+	w.PushUint16(uint16(len(keys)))
+
+	for _, key := range keys {
+		w.PushInt32(int32(key))
+		w.pushSkill(skills[key])
+	}
+}
+
+// pushSkillPoints pushes the skill points ordered by skill ID
+func (w *binaryWriter) pushSkillPoints(skills map[int]int) {
+	keys := make([]int, 0, len(skills))
+	for key := range skills {
+		keys = append(keys, key)
+	}
+
+	sort.Ints(keys)
+
+	w.PushUint16(uint16(len(keys)))
+
+	for _, key := range keys {
+		w.PushInt32(int32(key))
+		w.PushInt32(int32(skills[key]))
+	}
+}
+
+// pushHeroItems pushes the ID and the D2S data of the items, serializing the loaded items
+func (w *binaryWriter) pushHeroItems(items []*d2hero.HeroItem) {
+	w.PushUint16(uint16(len(items)))
+
+	for _, item := range items {
+		if item == nil {
+			w.pushString("")
+			w.pushData(nil)
+
+			continue
+		}
+
+		data := item.Data
+		if item.Item != nil {
+			data = item.Item.Serialize()
+		}
+
+		w.pushString(item.ID)
+		w.pushData(data)
+	}
+}
+
+func (w *binaryWriter) pushData(data []byte) {
+	w.PushUint32(uint32(len(data)))
+	w.PushBytes(data...)
+}
+
+func (w *binaryWriter) pushSavedStates(states []d2state.SavedState) {
+	w.PushUint16(uint16(len(states)))
+
+	for idx := range states {
+		w.pushString(states[idx].State)
+		w.pushStats(states[idx].Stats)
+		w.pushFloat64(states[idx].Remaining)
+	}
+}
+
+// pushHireling pushes the hireling with its equipment ordered by slot
+func (w *binaryWriter) pushHireling(hireling *d2hireling.Hireling) {
+	w.pushBool(hireling != nil)
+
+	if hireling == nil {
+		return
+	}
+
+	w.PushInt32(int32(hireling.ID))
+	w.pushString(hireling.Name)
+	w.PushInt32(int32(hireling.Level))
+	w.PushUint32(uint32(hireling.Experience))
+	w.PushInt32(int32(hireling.Life))
+	w.pushBool(hireling.Dead)
+
+	slots := make([]int, 0, len(hireling.Equipment))
+	for slot := range hireling.Equipment {
+		slots = append(slots, int(slot))
+	}
+
+	sort.Ints(slots)
+
+	w.PushUint16(uint16(len(slots)))
+
+	for _, slot := range slots {
+		w.PushBytes(byte(slot))
+		w.pushStrings(hireling.Equipment[d2enum.EquippedSlot(slot)])
+	}
+}
+
+func (w *binaryWriter) pushHeroProgress(progress *d2hero.HeroProgress) {
+	w.pushBool(progress != nil)
+
+	if progress == nil {
+		return
+	}
+
+	w.pushData(progress.Quests)
+	w.pushData(progress.Waypoints)
+	w.pushData(progress.NPCs)
+	w.pushData(progress.ItemLists)
+}
+
+func (r *binaryReader) readAddPlayer() AddPlayerPacket {
+	return AddPlayerPacket{
+		ID:         r.readString(),
+		Name:       r.readString(),
+		X:          int(int32(r.readUint32())),
+		Y:          int(int32(r.readUint32())),
+		HeroType:   d2enum.Hero(r.readByte()),
+		LeftSkill:  int(int32(r.readUint32())),
+		RightSkill: int(int32(r.readUint32())),
+		Gold:       int(int32(r.readUint32())),
+		Stats:      r.readHeroStats(),
+		Skills:     r.readSkills(),
+		Items:      r.readHeroItems(),
+	}
+}
+
+func (r *binaryReader) readHeroState() *d2hero.HeroState {
+	if !r.readBool() {
+		return nil
+	}
+
+	return &d2hero.HeroState{
+		HeroName:   r.readString(),
+		HeroType:   d2enum.Hero(r.readByte()),
+		Act:        int(int32(r.readUint32())),
+		Stats:      r.readHeroStats(),
+		Skills:     r.readSkills(),
+		X:          r.readFloat64(),
+		Y:          r.readFloat64(),
+		LeftSkill:  int(int32(r.readUint32())),
+		RightSkill: int(int32(r.readUint32())),
+		Gold:       int(int32(r.readUint32())),
+		Difficulty: d2enum.DifficultyType(r.readByte()),
+		States:     r.readSavedStates(),
+		Hireling:   r.readHireling(),
+		Items:      r.readHeroItems(),
+		Progress:   r.readHeroProgress(),
+	}
+}
+
+func (r *binaryReader) readPlayer() *d2mapentity.Player {
+	if !r.readBool() {
+		return nil
+	}
+
+	return &d2mapentity.Player{
+		Class:      d2enum.Hero(r.readByte()),
+		Act:        int(int32(r.readUint32())),
+		Gold:       int(int32(r.readUint32())),
+		Stats:      r.readHeroStats(),
+		Skills:     r.readSkills(),
+		LeftSkill:  r.readSkill(),
+		RightSkill: r.readSkill(),
+		Items:      r.readHeroItems(),
+	}
+}
+
+func (r *binaryReader) readHeroStats() *d2hero.HeroStatsState {
+	if !r.readBool() {
+		return nil
+	}
+
+	return &d2hero.HeroStatsState{
+		Level:       int(int32(r.readUint32())),
+		Experience:  int(r.readUint32()),
+		Strength:    int(int32(r.readUint32())),
+		Energy:      int(int32(r.readUint32())),
+		Dexterity:   int(int32(r.readUint32())),
+		Vitality:    int(int32(r.readUint32())),
+		StatsPoints: int(int32(r.readUint32())),
+		SkillPoints: int(int32(r.readUint32())),
+		Health:      int(int32(r.readUint32())),
+		MaxHealth:   int(int32(r.readUint32())),
+		Mana:        int(int32(r.readUint32())),
+		MaxMana:     int(int32(r.readUint32())),
+		MaxStamina:  int(int32(r.readUint32())),
+	}
+}
+
+func (r *binaryReader) readSkill() *d2hero.HeroSkill {
+	if !r.readBool() {
+		return nil
+	}
+
+	return d2hero.NewShallowHeroSkill(int(int32(r.readUint32())), int(int32(r.readUint32())))
+}
+
+// readSkills returns nil for no skills, like readStats
+func (r *binaryReader) readSkills() map[int]*d2hero.HeroSkill {
+	count := int(r.readUint16())
+	if count == 0 {
+		return nil
+	}
+
+	skills := make(map[int]*d2hero.HeroSkill, count)
+
+	for idx := 0; idx < count; idx++ {
+		key := int(int32(r.readUint32()))
+		skills[key] = r.readSkill()
+	}
+
+	return skills
+}
+
+func (r *binaryReader) readSkillPoints() map[int]int {
+	count := int(r.readUint16())
+	if count == 0 {
+		return nil
+	}
+
+	skills := make(map[int]int, count)
+
+	for idx := 0; idx < count; idx++ {
+		key := int(int32(r.readUint32()))
+		skills[key] = int(int32(r.readUint32()))
+	}
+
+	return skills
+}
+
+// readHeroItems returns the items with their D2S data, the receiver loads them with its records
+func (r *binaryReader) readHeroItems() []*d2hero.HeroItem {
+	count := int(r.readUint16())
+	if count == 0 {
+		return nil
+	}
+
+	items := make([]*d2hero.HeroItem, count)
+
+	for idx := range items {
+		items[idx] = &d2hero.HeroItem{ID: r.readString(), Data: r.readData()}
+	}
+
+	return items
+}
+
+func (r *binaryReader) readData() []byte {
+	return r.readBytes(int(r.readUint32()))
+}
+
+func (r *binaryReader) readSavedStates() []d2state.SavedState {
+	count := int(r.readUint16())
+	if count == 0 {
+		return nil
+	}
+
+	states := make([]d2state.SavedState, count)
+
+	for idx := range states {
+		states[idx] = d2state.SavedState{State: r.readString(), Stats: r.readStats(), Remaining: r.readFloat64()}
+	}
+
+	return states
+}
+
+func (r *binaryReader) readHireling() *d2hireling.Hireling {
+	if !r.readBool() {
+		return nil
+	}
+
+	hireling := &d2hireling.Hireling{
+		ID:         int(int32(r.readUint32())),
+		Name:       r.readString(),
+		Level:      int(int32(r.readUint32())),
+		Experience: int(r.readUint32()),
+		Life:       int(int32(r.readUint32())),
+		Dead:       r.readBool(),
+	}
+
+	count := int(r.readUint16())
+	if count == 0 {
+		return hireling
+	}
+
+	hireling.Equipment = make(map[d2enum.EquippedSlot][]string, count)
+
+	for idx := 0; idx < count; idx++ {
+		slot := d2enum.EquippedSlot(r.readByte())
+		hireling.Equipment[slot] = r.readStrings()
+	}
+
+	return hireling
+}
+
+func (r *binaryReader) readHeroProgress() *d2hero.HeroProgress {
+	if !r.readBool() {
+		return nil
+	}
+
+	return &d2hero.HeroProgress{
+		Quests:    r.readData(),
+		Waypoints: r.readData(),
+		NPCs:      r.readData(),
+		ItemLists: r.readData(),
+	}
+}
@@ -0,0 +1,275 @@
+package d2netpacket
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hireling"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapentity"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2state"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+func testHeroState() *d2hero.HeroState {
+	return &d2hero.HeroState{
+		HeroName:   "Tester",
+		HeroType:   d2enum.HeroSorceress,
+		Act:        2,
+		Stats:      &d2hero.HeroStatsState{Level: 12, Strength: 25, MaxHealth: 120},
+		X:          12.5,
+		Y:          40,
+		LeftSkill:  36,
+		Gold:       1234,
+		Difficulty: d2enum.DifficultyNightmare,
+		Skills:     map[int]*d2hero.HeroSkill{36: d2hero.NewShallowHeroSkill(36, 3)},
+		States:     []d2state.SavedState{{State: "shrine_armor", Stats: map[string]int{"armorclass": 100}, Remaining: 12.5}},
+		Hireling: &d2hireling.Hireling{ID: 3, Name: "merc04", Level: 12, Life: 140,
+			Equipment: map[d2enum.EquippedSlot][]string{d2enum.EquippedSlotHead: {"cap"}}},
+		Items:    []*d2hero.HeroItem{{ID: "item", Data: []byte{0x4a, 0x4d, 0x10}}},
+		Progress: &d2hero.HeroProgress{Quests: []byte{1, 2}, Waypoints: []byte{3}, NPCs: []byte{4}, ItemLists: []byte{5}},
+	}
+}
+
+// nolint:funlen // one packet of every type
+func testPackets(t *testing.T) map[d2netpackettype.NetPacketType]NetPacket {
+	packets := make(map[d2netpackettype.NetPacketType]NetPacket)
+
+	add := func(p NetPacket, err error) {
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		packets[p.PacketType] = p
+	}
+
+	skills := map[int]*d2hero.HeroSkill{36: d2hero.NewShallowHeroSkill(36, 3)}
+
+	add(CreateUpdateServerInfoPacket(-42, "player"))
+	add(CreateGenerateMapPacket(d2enum.RegionAct1Wilderness, d2enum.DifficultyHell))
+	add(CreateAddPlayerPacket("player", "Tester", 12, -3, d2enum.HeroSorceress,
+		&d2hero.HeroStatsState{Level: 3, Vitality: 10}, skills, 36, 0, 99,
+		[]*d2hero.HeroItem{{ID: "item", Data: []byte{0x4a, 0x4d, 0x10}}}))
+	add(CreateMovePlayerPacket("player", 1.5, 2.25, -3, 400.125))
+	add(CreatePlayerConnectionRequestPacket("player", testHeroState(), "key"))
+	add(CreatePlayerDisconnectRequestPacket("player"))
+	add(CreatePingPacket())
+	add(CreatePongPacket("player"))
+	add(CreateServerClosedPacket())
+	add(CreateCastPacket("player", 36, 10.5, -2))
+	add(CreateSpawnItemPacket(4, 5, "hax", "mag"))
+	add(CreateGroundItemPacket("item", -4, 5, []byte{0x4a, 0x4d, 0x10}))
+	add(CreateSavePlayerPacket(&d2mapentity.Player{Class: d2enum.HeroSorceress, Act: 1, Gold: 20,
+		Stats: &d2hero.HeroStatsState{Level: 3}, Skills: skills, LeftSkill: skills[36],
+		Items: []*d2hero.HeroItem{{ID: "item", Data: []byte{0x4a, 0x4d}}}}, d2enum.DifficultyHell))
+	add(CreateServerFullPacket())
+	add(CreateChangeLevelPacket("player", 8, d2enum.DifficultyNightmare, 12.5, -4))
+	add(CreateDamageEntityPacket("fallen1@10,20", "player", 12, 3, true))
+	add(CreateKillEntityPacket("fallen1@10,20", "player"))
+	add(CreateMoveMonsterPacket("fallen1@10,20", 2, 4, 6.5, 8))
+	add(CreateMonsterActionPacket("fallenshaman1@12,20", d2enum.MonsterActionResurrect, "fallen1@10,20"))
+	add(CreateOperateObjectPacket("player", "chest@40,52"))
+	add(CreateSetStatePacket("player", "shrine_combat", map[string]int{"item_tohit_percent": 200,
+		"item_maxdamage_percent": 200}, 96))
+	add(CreateSetVitalsPacket("player", 120, -3))
+	add(CreateOpenVendorPacket("player", "charsi@50,60", true))
+	add(CreateVendorInventoryPacket("charsi@50,60", false, []VendorItem{{ID: "item1", Codes: []string{"hax", "Sturdy"},
+		Price: 250, Page: "Weapons"}}))
+	add(CreateTradeItemPacket("player", d2enum.TradeRepair, "item1"))
+	add(CreateTradeResultPacket("player", d2enum.TradeGamble, "item2", []string{"cap"}, 1200))
+
+	hireling := &d2hireling.Hireling{ID: 3, Name: "merc04", Level: 12, Experience: 1500, Life: 140,
+		Equipment: map[d2enum.EquippedSlot][]string{d2enum.EquippedSlotHead: {"cap"}}}
+
+	add(CreateHirelingActionPacket("player", d2enum.HirelingEquip, "", "", "item1", d2enum.EquippedSlotHead))
+	add(CreateHirelingOffersPacket("kashya@20,30", []HirelingOffer{{ID: "offer1", Hireling: *hireling, Price: 900}}))
+	add(CreateUpdateHirelingPacket("player", d2enum.HirelingUnequip, hireling, 300, "item2", []string{"hax"}))
+	add(CreateSpawnHirelingPacket("player", "hireling-player", "roguehire", "merc04", 12.5, -3))
+	add(CreateSpendPointPacket("player", d2enum.AttributeVitality, 36))
+	add(CreateUpdateStatsPacket("player", &d2hero.HeroStatsState{Level: 4, Experience: 2500, StatsPoints: 10},
+		map[int]int{36: 2}, "no skill points left"))
+	add(CreatePickupItemPacket("player", "item"))
+
+	return packets
+}
+
+// decodeJSON decodes the packet data into a generic value, so that packets can be
+// compared independent of the field order and time zone of the encoded JSON
+func decodeJSON(t *testing.T, packet NetPacket) interface{} {
+	var v interface{}
+
+	data, err := packet.Data()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatal(err)
+	}
+
+	if m, ok := v.(map[string]interface{}); ok {
+		if ts, ok := m["ts"].(string); ok {
+			parsed, err := time.Parse(time.RFC3339Nano, ts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			m["ts"] = parsed.UnixNano()
+		}
+	}
+
+	return v
+}
+
+func TestBinaryCodec_AllPacketTypes(t *testing.T) {
+	packets := testPackets(t)
+
+	for packetType := d2netpackettype.UpdateServerInfo; packetType <= d2netpackettype.UpdateStats; packetType++ {
+		packet, found := packets[packetType]
+		if !found {
+			t.Errorf("no test packet for %s", packetType)
+			continue
+		}
+
+		data, err := MarshalBinaryPacket(packet)
+		if err != nil {
+			t.Errorf("%s: %v", packetType, err)
+			continue
+		}
+
+		decoded, err := UnmarshalBinaryPacket(data)
+		if err != nil {
+			t.Errorf("%s: %v", packetType, err)
+			continue
+		}
+
+		if decoded.PacketType != packetType {
+			t.Errorf("expected packet type %s, got %s", packetType, decoded.PacketType)
+		}
+
+		if expected, actual := decodeJSON(t, packet), decodeJSON(t, decoded); !reflect.DeepEqual(expected, actual) {
+			t.Errorf("%s: expected %v, got %v", packetType, expected, actual)
+		}
+
+		// a packet read as JSON only holds the JSON data, it is encoded the same
+		jsonData, err := packet.Data()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if fromJSON, err := MarshalBinaryPacket(NetPacket{PacketType: packetType, PacketData: jsonData}); err != nil {
+			t.Errorf("%s: %v", packetType, err)
+		} else if !bytes.Equal(fromJSON, data) {
+			t.Errorf("%s: expected the packet read as JSON to be encoded the same", packetType)
+		}
+	}
+}
+
+func TestBinaryCodec_Truncated(t *testing.T) {
+	packet, err := CreateMovePlayerPacket("player", 1, 2, 3, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := MarshalBinaryPacket(packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := UnmarshalBinaryPacket(data[:len(data)-1]); err == nil {
+		t.Error("expected an error for a truncated packet")
+	}
+}
+
+func TestBinaryCodec_NotFinite(t *testing.T) {
+	for _, value := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+		packet, err := CreateMovePlayerPacket("player", 1, 2, value, 4)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := MarshalBinaryPacket(packet)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := UnmarshalBinaryPacket(data); err == nil {
+			t.Errorf("expected an error for a move to %g", value)
+		}
+	}
+}
+
+func TestPacketEncoderDecoder(t *testing.T) {
+	for _, codec := range []Codec{CodecJSON, CodecBinary} {
+		var stream bytes.Buffer
+
+		encoder := NewPacketEncoder(codec, &stream)
+		sent := testPackets(t)
+
+		for packetType := d2netpackettype.UpdateServerInfo; packetType <= d2netpackettype.UpdateStats; packetType++ {
+			if err := encoder.Encode(sent[packetType]); err != nil {
+				t.Fatalf("%s: %v", codec, err)
+			}
+		}
+
+		decoder := NewPacketDecoder(&stream)
+
+		for packetType := d2netpackettype.UpdateServerInfo; packetType <= d2netpackettype.UpdateStats; packetType++ {
+			received, err := decoder.Decode()
+			if err != nil {
+				t.Fatalf("%s: %v", codec, err)
+			}
+
+			if received.PacketType != packetType {
+				t.Errorf("%s: expected packet type %s, got %s", codec, packetType, received.PacketType)
+			}
+		}
+
+		if decoder.Codec() != codec {
+			t.Errorf("expected decoder to detect codec %s, got %s", codec, decoder.Codec())
+		}
+	}
+}
+
+// BenchmarkMovePlayer measures sending a packet and reading it on the other side
+func BenchmarkMovePlayer(b *testing.B) {
+	packet, err := CreateMovePlayerPacket("0f2ba6b1-8b2c-4b6c-9c3c-5d1e2f3a4b5c", 101.25, 88, 110.5, 93)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for _, codec := range []Codec{CodecJSON, CodecBinary} {
+		var stream bytes.Buffer
+
+		encoder := NewPacketEncoder(codec, &stream)
+		decoder := NewPacketDecoder(&stream)
+
+		b.Run(codec.String(), func(b *testing.B) {
+			size := 0
+
+			for i := 0; i < b.N; i++ {
+				if err := encoder.Encode(packet); err != nil {
+					b.Fatal(err)
+				}
+
+				size = stream.Len()
+
+				received, err := decoder.Decode()
+				if err != nil {
+					b.Fatal(err)
+				}
+
+				if _, err := UnmarshalMovePlayer(received); err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			b.ReportMetric(float64(size), "bytes/packet")
+		})
+	}
+}
@@ -31,6 +31,25 @@ const (
 	SpawnItem                                            // Sent by server
 	SavePlayer                                           // Sent by the client, saves the player
 	ServerFull                                           // Sent by server when server has reached max connections
+	ChangeLevel                                          // Sent by client or server, moves a player to another level
+	DamageEntity                                         // Sent by server, an entity took damage
+	KillEntity                                           // Sent by server, an entity died
+	MoveMonster                                          // Sent by server, moves a monster along a path
+	MonsterAction                                        // Sent by server, a monster attacks or resurrects another
+	OperateObject                                        // Sent by client or server, a player opens an object
+	SetState                                             // Sent by server, an entity is put in or leaves a state
+	SetVitals                                            // Sent by server, sets the life and mana of a player
+	OpenVendor                                           // Sent by client, opens the trade or gamble window of an NPC
+	VendorInventory                                      // Sent by server, the items offered by a vendor
+	TradeItem                                            // Sent by client, buys, sells, repairs or gambles an item
+	TradeResult                                          // Sent by server, the outcome of a trade
+	HirelingAction                                       // Sent by client, lists, hires, equips or resurrects hirelings
+	HirelingOffers                                       // Sent by server, the hirelings offered by a town NPC
+	UpdateHireling                                       // Sent by server, the hireling of a player changed
+	SpawnHireling                                        // Sent by server, a hireling enters the map
+	SpendPoint                                           // Sent by client, spends a stat or a skill point
+	UpdateStats                                          // Sent by server, the stats or skills of a player changed
+	PickupItem                                           // Sent by client or server, a player picks up an item
 
 	UnknownPacketType = 666
 )
@@ -50,6 +69,25 @@ func (n NetPacketType) String() string {
 		SpawnItem:                       "SpawnItem",
 		SavePlayer:                      "SavePlayer",
 		ServerFull:                      "ServerFull",
+		ChangeLevel:                     "ChangeLevel",
+		DamageEntity:                    "DamageEntity",
+		KillEntity:                      "KillEntity",
+		MoveMonster:                     "MoveMonster",
+		MonsterAction:                   "MonsterAction",
+		OperateObject:                   "OperateObject",
+		SetState:                        "SetState",
+		SetVitals:                       "SetVitals",
+		OpenVendor:                      "OpenVendor",
+		VendorInventory:                 "VendorInventory",
+		TradeItem:                       "TradeItem",
+		TradeResult:                     "TradeResult",
+		HirelingAction:                  "HirelingAction",
+		HirelingOffers:                  "HirelingOffers",
+		UpdateHireling:                  "UpdateHireling",
+		SpawnHireling:                   "SpawnHireling",
+		SpendPoint:                      "SpendPoint",
+		UpdateStats:                     "UpdateStats",
+		PickupItem:                      "PickupItem",
 	}
 
 	return strings[n]
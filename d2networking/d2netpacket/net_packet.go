@@ -10,9 +10,38 @@ import (
 // When decoding a packet: First the PacketType byte is read, then the
 // PacketData is unmarshalled to a struct of the type associated with
 // PacketType.
+// Packets created by the Create functions, and packets read in the binary format, hold the
+// packet struct instead of its JSON, which is only encoded when the packet is sent as JSON.
 type NetPacket struct {
 	PacketType d2netpackettype.NetPacketType `json:"packetType"`
 	PacketData json.RawMessage               `json:"packetData"`
+
+	body interface{} // the packet struct of the packet type
+}
+
+// newNetPacket returns a NetPacket which holds the given packet struct
+func newNetPacket(packetType d2netpackettype.NetPacketType, body interface{}) NetPacket {
+	return NetPacket{PacketType: packetType, body: body}
+}
+
+// Data returns the JSON encoding of the packet data
+func (p NetPacket) Data() (json.RawMessage, error) {
+	if p.PacketData != nil || p.body == nil {
+		return p.PacketData, nil
+	}
+
+	return json.Marshal(p.body)
+}
+
+// CopyPacket returns a copy of the packet which shares no state with the packet, like a packet
+// sent over a connection. The packet is copied through the binary codec.
+func CopyPacket(packet NetPacket) (NetPacket, error) {
+	data, err := MarshalBinaryPacket(packet)
+	if err != nil {
+		return NetPacket{PacketType: packet.PacketType}, err
+	}
+
+	return UnmarshalBinaryPacket(data)
 }
 
 // InspectPacketType determines the packet type from the given data
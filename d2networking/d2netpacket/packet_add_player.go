@@ -5,7 +5,6 @@ import (
 
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
-	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2inventory"
 	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
 )
 
@@ -13,17 +12,17 @@ import (
 // It is sent by the server to create the entity for a newly connected
 // player on a client.
 type AddPlayerPacket struct {
-	ID         string                         `json:"id"`
-	Name       string                         `json:"name"`
-	X          int                            `json:"x"`
-	Y          int                            `json:"y"`
-	HeroType   d2enum.Hero                    `json:"hero"`
-	Equipment  d2inventory.CharacterEquipment `json:"equipment"`
-	Stats      *d2hero.HeroStatsState         `json:"heroStats"`
-	Skills     map[int]*d2hero.HeroSkill      `json:"heroSkills"`
-	LeftSkill  int                            `json:"leftSkill"`
-	RightSkill int                            `json:"rightSkill"`
+	ID         string                    `json:"id"`
+	Name       string                    `json:"name"`
+	X          int                       `json:"x"`
+	Y          int                       `json:"y"`
+	HeroType   d2enum.Hero               `json:"hero"`
+	Stats      *d2hero.HeroStatsState    `json:"heroStats"`
+	Skills     map[int]*d2hero.HeroSkill `json:"heroSkills"`
+	LeftSkill  int                       `json:"leftSkill"`
+	RightSkill int                       `json:"rightSkill"`
 	Gold       int
+	Items      []*d2hero.HeroItem `json:"items"`
 }
 
 // CreateAddPlayerPacket returns a NetPacket which declares an
@@ -34,37 +33,33 @@ func CreateAddPlayerPacket(
 	heroType d2enum.Hero,
 	stats *d2hero.HeroStatsState,
 	skills map[int]*d2hero.HeroSkill,
-	equipment d2inventory.CharacterEquipment,
-	leftSkill, rightSkill, gold int) (NetPacket, error) {
+	leftSkill, rightSkill, gold int,
+	items []*d2hero.HeroItem) (NetPacket, error) {
 	addPlayerPacket := AddPlayerPacket{
 		ID:         id,
 		Name:       name,
 		X:          x,
 		Y:          y,
 		HeroType:   heroType,
-		Equipment:  equipment,
 		Stats:      stats,
 		Skills:     skills,
 		LeftSkill:  leftSkill,
 		RightSkill: rightSkill,
 		Gold:       gold,
+		Items:      items,
 	}
 
-	b, err := json.Marshal(addPlayerPacket)
-	if err != nil {
-		return NetPacket{PacketType: d2netpackettype.AddPlayer}, err
-	}
-
-	return NetPacket{
-		PacketType: d2netpackettype.AddPlayer,
-		PacketData: b,
-	}, nil
+	return newNetPacket(d2netpackettype.AddPlayer, addPlayerPacket), nil
 }
 
-// UnmarshalAddPlayer unmarshals the packet data into an AddPlayerPacket struct
-func UnmarshalAddPlayer(packet []byte) (AddPlayerPacket, error) {
+// UnmarshalAddPlayer unmarshals the given packet to an AddPlayerPacket struct
+func UnmarshalAddPlayer(packet NetPacket) (AddPlayerPacket, error) {
+	if p, ok := packet.body.(AddPlayerPacket); ok {
+		return p, nil
+	}
+
 	var p AddPlayerPacket
-	if err := json.Unmarshal(packet, &p); err != nil {
+	if err := json.Unmarshal(packet.PacketData, &p); err != nil {
 		return p, err
 	}
 
@@ -0,0 +1,49 @@
+package d2netpacket
+
+import (
+	"encoding/json"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+// ChangeLevelPacket moves a player entity to another level. It is sent by a client to use
+// the warp to the given level, the server answers with the level and the position (in
+// tiles) the player entity arrives at. The server also sends it to the other players on
+// the map which the player entity left.
+type ChangeLevelPacket struct {
+	PlayerID   string                `json:"playerId"`
+	LevelID    int                   `json:"levelId"`
+	Difficulty d2enum.DifficultyType `json:"difficulty"`
+	X          float64               `json:"x"`
+	Y          float64               `json:"y"`
+}
+
+// CreateChangeLevelPacket returns a NetPacket which declares a ChangeLevelPacket with the
+// given player ID, level and position.
+func CreateChangeLevelPacket(playerID string, levelID int, difficulty d2enum.DifficultyType,
+	x, y float64) (NetPacket, error) {
+	changeLevelPacket := ChangeLevelPacket{
+		PlayerID:   playerID,
+		LevelID:    levelID,
+		Difficulty: difficulty,
+		X:          x,
+		Y:          y,
+	}
+
+	return newNetPacket(d2netpackettype.ChangeLevel, changeLevelPacket), nil
+}
+
+// UnmarshalChangeLevel unmarshals the given packet to a ChangeLevelPacket struct
+func UnmarshalChangeLevel(packet NetPacket) (ChangeLevelPacket, error) {
+	if p, ok := packet.body.(ChangeLevelPacket); ok {
+		return p, nil
+	}
+
+	var p ChangeLevelPacket
+	if err := json.Unmarshal(packet.PacketData, &p); err != nil {
+		return p, err
+	}
+
+	return p, nil
+}
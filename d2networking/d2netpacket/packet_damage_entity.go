@@ -0,0 +1,45 @@
+package d2netpacket
+
+import (
+	"encoding/json"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+// DamageEntityPacket contains the damage an entity took from an attack and the life it has
+// left. It is sent by the server to the clients on the map of the entity.
+type DamageEntityPacket struct {
+	EntityID    string `json:"entityId"`
+	SourceID    string `json:"sourceId"`
+	Damage      int    `json:"damage"`
+	Life        int    `json:"life"`
+	HitRecovery bool   `json:"hitRecovery"` // the entity plays its hit recovery animation
+}
+
+// CreateDamageEntityPacket returns a NetPacket which declares a DamageEntityPacket with the
+// given entity, attacker and damage.
+func CreateDamageEntityPacket(entityID, sourceID string, damage, life int, hitRecovery bool) (NetPacket, error) {
+	damageEntityPacket := DamageEntityPacket{
+		EntityID:    entityID,
+		SourceID:    sourceID,
+		Damage:      damage,
+		Life:        life,
+		HitRecovery: hitRecovery,
+	}
+
+	return newNetPacket(d2netpackettype.DamageEntity, damageEntityPacket), nil
+}
+
+// UnmarshalDamageEntity unmarshals the given packet to a DamageEntityPacket struct
+func UnmarshalDamageEntity(packet NetPacket) (DamageEntityPacket, error) {
+	if p, ok := packet.body.(DamageEntityPacket); ok {
+		return p, nil
+	}
+
+	var p DamageEntityPacket
+	if err := json.Unmarshal(packet.PacketData, &p); err != nil {
+		return p, err
+	}
+
+	return p, nil
+}
@@ -9,33 +9,31 @@ import (
 
 // GenerateMapPacket contains an enumerable representing a region. It
 // is sent by the server to generate the map for the given region on
-// a client.
+// a client. The monsters of the map are spawned for the difficulty.
 type GenerateMapPacket struct {
-	RegionType d2enum.RegionIdType `json:"regionType"`
+	RegionType d2enum.RegionIdType   `json:"regionType"`
+	Difficulty d2enum.DifficultyType `json:"difficulty"`
 }
 
 // CreateGenerateMapPacket returns a NetPacket which declares a
-// GenerateMapPacket with the given regionType.
-func CreateGenerateMapPacket(regionType d2enum.RegionIdType) (NetPacket, error) {
+// GenerateMapPacket with the given regionType and difficulty.
+func CreateGenerateMapPacket(regionType d2enum.RegionIdType, difficulty d2enum.DifficultyType) (NetPacket, error) {
 	generateMapPacket := GenerateMapPacket{
 		RegionType: regionType,
+		Difficulty: difficulty,
 	}
 
-	b, err := json.Marshal(generateMapPacket)
-	if err != nil {
-		return NetPacket{PacketType: d2netpackettype.GenerateMap}, err
-	}
-
-	return NetPacket{
-		PacketType: d2netpackettype.GenerateMap,
-		PacketData: b,
-	}, nil
+	return newNetPacket(d2netpackettype.GenerateMap, generateMapPacket), nil
 }
 
-// UnmarshalGenerateMap unmarshals the given packet data into a GenerateMapPacket struct
-func UnmarshalGenerateMap(packet []byte) (GenerateMapPacket, error) {
+// UnmarshalGenerateMap unmarshals the given packet to a GenerateMapPacket struct
+func UnmarshalGenerateMap(packet NetPacket) (GenerateMapPacket, error) {
+	if p, ok := packet.body.(GenerateMapPacket); ok {
+		return p, nil
+	}
+
 	var p GenerateMapPacket
-	if err := json.Unmarshal(packet, &p); err != nil {
+	if err := json.Unmarshal(packet.PacketData, &p); err != nil {
 		return p, err
 	}
 
@@ -0,0 +1,51 @@
+package d2netpacket
+
+import (
+	"encoding/json"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+// HirelingActionPacket is sent by a client when its player lists the hirelings of a town NPC,
+// hires or resurrects one, or equips the hireling with an item. The server answers with a
+// HirelingOffersPacket or an UpdateHirelingPacket.
+type HirelingActionPacket struct {
+	PlayerID string                `json:"playerId"`
+	Action   d2enum.HirelingAction `json:"action"`
+	NPCID    string                `json:"npcId,omitempty"`   // the NPC to list, hire or resurrect at
+	OfferID  string                `json:"offerId,omitempty"` // the offered hireling to hire
+	ItemID   string                `json:"itemId,omitempty"`  // the item to equip
+	Slot     d2enum.EquippedSlot   `json:"slot"`              // the slot to equip or unequip
+}
+
+// CreateHirelingActionPacket returns a NetPacket which declares a HirelingActionPacket with the
+// given player and action. The NPC, offer, item and slot are only given for the actions which
+// need them.
+func CreateHirelingActionPacket(playerID string, action d2enum.HirelingAction, npcID, offerID, itemID string,
+	slot d2enum.EquippedSlot) (NetPacket, error) {
+	hirelingActionPacket := HirelingActionPacket{
+		PlayerID: playerID,
+		Action:   action,
+		NPCID:    npcID,
+		OfferID:  offerID,
+		ItemID:   itemID,
+		Slot:     slot,
+	}
+
+	return newNetPacket(d2netpackettype.HirelingAction, hirelingActionPacket), nil
+}
+
+// UnmarshalHirelingAction unmarshals the given packet to a HirelingActionPacket struct
+func UnmarshalHirelingAction(packet NetPacket) (HirelingActionPacket, error) {
+	if p, ok := packet.body.(HirelingActionPacket); ok {
+		return p, nil
+	}
+
+	var p HirelingActionPacket
+	if err := json.Unmarshal(packet.PacketData, &p); err != nil {
+		return p, err
+	}
+
+	return p, nil
+}
@@ -0,0 +1,46 @@
+package d2netpacket
+
+import (
+	"encoding/json"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hireling"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+// HirelingOffer is a hireling a town NPC offers, with the gold it costs to hire it
+type HirelingOffer struct {
+	ID       string              `json:"id"`
+	Hireling d2hireling.Hireling `json:"hireling"`
+	Price    int                 `json:"price"`
+}
+
+// HirelingOffersPacket is sent by the server when a player lists the hirelings of a town NPC
+type HirelingOffersPacket struct {
+	NPCID  string          `json:"npcId"`
+	Offers []HirelingOffer `json:"offers"`
+}
+
+// CreateHirelingOffersPacket returns a NetPacket which declares a HirelingOffersPacket with the
+// given offers of the NPC.
+func CreateHirelingOffersPacket(npcID string, offers []HirelingOffer) (NetPacket, error) {
+	hirelingOffersPacket := HirelingOffersPacket{
+		NPCID:  npcID,
+		Offers: offers,
+	}
+
+	return newNetPacket(d2netpackettype.HirelingOffers, hirelingOffersPacket), nil
+}
+
+// UnmarshalHirelingOffers unmarshals the given packet to a HirelingOffersPacket struct
+func UnmarshalHirelingOffers(packet NetPacket) (HirelingOffersPacket, error) {
+	if p, ok := packet.body.(HirelingOffersPacket); ok {
+		return p, nil
+	}
+
+	var p HirelingOffersPacket
+	if err := json.Unmarshal(packet.PacketData, &p); err != nil {
+		return p, err
+	}
+
+	return p, nil
+}
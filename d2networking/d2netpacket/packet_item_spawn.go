@@ -6,11 +6,15 @@ import (
 	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
 )
 
-// SpawnItemPacket contains the data required to create a Item entity
+// SpawnItemPacket contains the data required to create a Item entity. The server sends the
+// items it keeps on the ground by ID, with the data of the item in the format of a saved hero.
+// The debug terminal of the host asks the server for an item by its codes.
 type SpawnItemPacket struct {
-	X     int      `json:"x"`
-	Y     int      `json:"y"`
-	Codes []string `json:"codes"`
+	ItemID string   `json:"itemId,omitempty"`
+	X      int      `json:"x"`
+	Y      int      `json:"y"`
+	Codes  []string `json:"codes,omitempty"`
+	Data   []byte   `json:"data,omitempty"`
 }
 
 // CreateSpawnItemPacket returns a NetPacket which declares a
@@ -22,21 +26,30 @@ func CreateSpawnItemPacket(x, y int, codes ...string) (NetPacket, error) {
 		Codes: codes,
 	}
 
-	b, err := json.Marshal(spawnItemPacket)
-	if err != nil {
-		return NetPacket{PacketType: d2netpackettype.SpawnItem}, err
+	return newNetPacket(d2netpackettype.SpawnItem, spawnItemPacket), nil
+}
+
+// CreateGroundItemPacket returns a NetPacket which declares a SpawnItemPacket of an item the
+// server keeps on the ground, with the serialized item.
+func CreateGroundItemPacket(itemID string, x, y int, data []byte) (NetPacket, error) {
+	spawnItemPacket := SpawnItemPacket{
+		ItemID: itemID,
+		X:      x,
+		Y:      y,
+		Data:   data,
 	}
 
-	return NetPacket{
-		PacketType: d2netpackettype.SpawnItem,
-		PacketData: b,
-	}, nil
+	return newNetPacket(d2netpackettype.SpawnItem, spawnItemPacket), nil
 }
 
-// UnmarshalSpawnItem unmarshals the given data to a SpawnItemPacket struct
-func UnmarshalSpawnItem(packet []byte) (SpawnItemPacket, error) {
+// UnmarshalSpawnItem unmarshals the given packet to a SpawnItemPacket struct
+func UnmarshalSpawnItem(packet NetPacket) (SpawnItemPacket, error) {
+	if p, ok := packet.body.(SpawnItemPacket); ok {
+		return p, nil
+	}
+
 	var p SpawnItemPacket
-	if err := json.Unmarshal(packet, &p); err != nil {
+	if err := json.Unmarshal(packet.PacketData, &p); err != nil {
 		return p, err
 	}
 
@@ -0,0 +1,39 @@
+package d2netpacket
+
+import (
+	"encoding/json"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+// KillEntityPacket declares that an entity died. It is sent by the server to the clients on
+// the map of the entity, which play the death animation of the entity.
+type KillEntityPacket struct {
+	EntityID string `json:"entityId"`
+	KillerID string `json:"killerId"`
+}
+
+// CreateKillEntityPacket returns a NetPacket which declares a KillEntityPacket with the given
+// entity and the entity which killed it.
+func CreateKillEntityPacket(entityID, killerID string) (NetPacket, error) {
+	killEntityPacket := KillEntityPacket{
+		EntityID: entityID,
+		KillerID: killerID,
+	}
+
+	return newNetPacket(d2netpackettype.KillEntity, killEntityPacket), nil
+}
+
+// UnmarshalKillEntity unmarshals the given packet to a KillEntityPacket struct
+func UnmarshalKillEntity(packet NetPacket) (KillEntityPacket, error) {
+	if p, ok := packet.body.(KillEntityPacket); ok {
+		return p, nil
+	}
+
+	var p KillEntityPacket
+	if err := json.Unmarshal(packet.PacketData, &p); err != nil {
+		return p, err
+	}
+
+	return p, nil
+}
@@ -0,0 +1,42 @@
+package d2netpacket
+
+import (
+	"encoding/json"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+// MonsterActionPacket contains an action of a monster on another entity, like an attack. It is
+// sent by the server to the clients on the map of the monster, which play its animation.
+type MonsterActionPacket struct {
+	MonsterID string                   `json:"monsterId"`
+	Action    d2enum.MonsterActionType `json:"action"`
+	TargetID  string                   `json:"targetId"`
+}
+
+// CreateMonsterActionPacket returns a NetPacket which declares a MonsterActionPacket with the
+// given monster, action and target.
+func CreateMonsterActionPacket(monsterID string, action d2enum.MonsterActionType, targetID string) (NetPacket, error) {
+	monsterActionPacket := MonsterActionPacket{
+		MonsterID: monsterID,
+		Action:    action,
+		TargetID:  targetID,
+	}
+
+	return newNetPacket(d2netpackettype.MonsterAction, monsterActionPacket), nil
+}
+
+// UnmarshalMonsterAction unmarshals the given packet to a MonsterActionPacket struct
+func UnmarshalMonsterAction(packet NetPacket) (MonsterActionPacket, error) {
+	if p, ok := packet.body.(MonsterActionPacket); ok {
+		return p, nil
+	}
+
+	var p MonsterActionPacket
+	if err := json.Unmarshal(packet.PacketData, &p); err != nil {
+		return p, err
+	}
+
+	return p, nil
+}
@@ -0,0 +1,45 @@
+package d2netpacket
+
+import (
+	"encoding/json"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+// MoveMonsterPacket contains a movement of a monster which is controlled by the AI of the
+// server. The positions are in tiles, like the ones of a MovePlayerPacket.
+type MoveMonsterPacket struct {
+	MonsterID string  `json:"monsterId"`
+	StartX    float64 `json:"startX"`
+	StartY    float64 `json:"startY"`
+	DestX     float64 `json:"destX"`
+	DestY     float64 `json:"destY"`
+}
+
+// CreateMoveMonsterPacket returns a NetPacket which declares a MoveMonsterPacket with the
+// given monster and movement.
+func CreateMoveMonsterPacket(monsterID string, startX, startY, destX, destY float64) (NetPacket, error) {
+	moveMonsterPacket := MoveMonsterPacket{
+		MonsterID: monsterID,
+		StartX:    startX,
+		StartY:    startY,
+		DestX:     destX,
+		DestY:     destY,
+	}
+
+	return newNetPacket(d2netpackettype.MoveMonster, moveMonsterPacket), nil
+}
+
+// UnmarshalMoveMonster unmarshals the given packet to a MoveMonsterPacket struct
+func UnmarshalMoveMonster(packet NetPacket) (MoveMonsterPacket, error) {
+	if p, ok := packet.body.(MoveMonsterPacket); ok {
+		return p, nil
+	}
+
+	var p MoveMonsterPacket
+	if err := json.Unmarshal(packet.PacketData, &p); err != nil {
+		return p, err
+	}
+
+	return p, nil
+}
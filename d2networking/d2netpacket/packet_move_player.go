@@ -28,21 +28,17 @@ func CreateMovePlayerPacket(playerID string, startX, startY, destX, destY float6
 		DestY:    destY,
 	}
 
-	b, err := json.Marshal(movePlayerPacket)
-	if err != nil {
-		return NetPacket{PacketType: d2netpackettype.MovePlayer}, nil
-	}
-
-	return NetPacket{
-		PacketType: d2netpackettype.MovePlayer,
-		PacketData: b,
-	}, nil
+	return newNetPacket(d2netpackettype.MovePlayer, movePlayerPacket), nil
 }
 
-// UnmarshalMovePlayer unmarshals the given data to a MovePlayerPacket struct
-func UnmarshalMovePlayer(packet []byte) (MovePlayerPacket, error) {
+// UnmarshalMovePlayer unmarshals the given packet to a MovePlayerPacket struct
+func UnmarshalMovePlayer(packet NetPacket) (MovePlayerPacket, error) {
+	if p, ok := packet.body.(MovePlayerPacket); ok {
+		return p, nil
+	}
+
 	var p MovePlayerPacket
-	if err := json.Unmarshal(packet, &p); err != nil {
+	if err := json.Unmarshal(packet.PacketData, &p); err != nil {
 		return p, err
 	}
 
@@ -0,0 +1,41 @@
+package d2netpacket
+
+import (
+	"encoding/json"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+// OpenVendorPacket is sent by a client when its player opens the trade or the gamble window
+// of an NPC. The server rolls the items of the vendor and answers with a VendorInventoryPacket.
+type OpenVendorPacket struct {
+	PlayerID string `json:"playerId"`
+	NPCID    string `json:"npcId"`
+	Gamble   bool   `json:"gamble"`
+}
+
+// CreateOpenVendorPacket returns a NetPacket which declares an OpenVendorPacket with the given
+// player and NPC.
+func CreateOpenVendorPacket(playerID, npcID string, gamble bool) (NetPacket, error) {
+	openVendorPacket := OpenVendorPacket{
+		PlayerID: playerID,
+		NPCID:    npcID,
+		Gamble:   gamble,
+	}
+
+	return newNetPacket(d2netpackettype.OpenVendor, openVendorPacket), nil
+}
+
+// UnmarshalOpenVendor unmarshals the given packet to an OpenVendorPacket struct
+func UnmarshalOpenVendor(packet NetPacket) (OpenVendorPacket, error) {
+	if p, ok := packet.body.(OpenVendorPacket); ok {
+		return p, nil
+	}
+
+	var p OpenVendorPacket
+	if err := json.Unmarshal(packet.PacketData, &p); err != nil {
+		return p, err
+	}
+
+	return p, nil
+}
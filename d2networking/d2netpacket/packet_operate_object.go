@@ -0,0 +1,40 @@
+package d2netpacket
+
+import (
+	"encoding/json"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+// OperateObjectPacket is sent by a client when its player operates an object, like opening a
+// chest. The server validates the request and sends the packet to the clients on the map of the
+// object, which play the animation of the object.
+type OperateObjectPacket struct {
+	PlayerID string `json:"playerId"`
+	ObjectID string `json:"objectId"`
+}
+
+// CreateOperateObjectPacket returns a NetPacket which declares an OperateObjectPacket with the
+// given player and object.
+func CreateOperateObjectPacket(playerID, objectID string) (NetPacket, error) {
+	operateObjectPacket := OperateObjectPacket{
+		PlayerID: playerID,
+		ObjectID: objectID,
+	}
+
+	return newNetPacket(d2netpackettype.OperateObject, operateObjectPacket), nil
+}
+
+// UnmarshalOperateObject unmarshals the given packet to an OperateObjectPacket struct
+func UnmarshalOperateObject(packet NetPacket) (OperateObjectPacket, error) {
+	if p, ok := packet.body.(OperateObjectPacket); ok {
+		return p, nil
+	}
+
+	var p OperateObjectPacket
+	if err := json.Unmarshal(packet.PacketData, &p); err != nil {
+		return p, err
+	}
+
+	return p, nil
+}
@@ -0,0 +1,40 @@
+package d2netpacket
+
+import (
+	"encoding/json"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+// PickupItemPacket is sent by a client when its player picks up an item from the ground. The
+// server gives the item to the player and sends the packet to the clients on the map of the
+// item, which remove it from the ground.
+type PickupItemPacket struct {
+	PlayerID string `json:"playerId"`
+	ItemID   string `json:"itemId"`
+}
+
+// CreatePickupItemPacket returns a NetPacket which declares a PickupItemPacket with the given
+// player and item.
+func CreatePickupItemPacket(playerID, itemID string) (NetPacket, error) {
+	pickupItemPacket := PickupItemPacket{
+		PlayerID: playerID,
+		ItemID:   itemID,
+	}
+
+	return newNetPacket(d2netpackettype.PickupItem, pickupItemPacket), nil
+}
+
+// UnmarshalPickupItem unmarshals the given packet to a PickupItemPacket struct
+func UnmarshalPickupItem(packet NetPacket) (PickupItemPacket, error) {
+	if p, ok := packet.body.(PickupItemPacket); ok {
+		return p, nil
+	}
+
+	var p PickupItemPacket
+	if err := json.Unmarshal(packet.PacketData, &p); err != nil {
+		return p, err
+	}
+
+	return p, nil
+}
@@ -20,21 +20,17 @@ func CreatePingPacket() (NetPacket, error) {
 		TS: time.Now(),
 	}
 
-	b, err := json.Marshal(ping)
-	if err != nil {
-		return NetPacket{PacketType: d2netpackettype.Ping}, err
-	}
-
-	return NetPacket{
-		PacketType: d2netpackettype.Ping,
-		PacketData: b,
-	}, nil
+	return newNetPacket(d2netpackettype.Ping, ping), nil
 }
 
-// UnmarshalPing unmarshals the given data to a PingPacket struct
-func UnmarshalPing(packet []byte) (PingPacket, error) {
+// UnmarshalPing unmarshals the given packet to a PingPacket struct
+func UnmarshalPing(packet NetPacket) (PingPacket, error) {
+	if p, ok := packet.body.(PingPacket); ok {
+		return p, nil
+	}
+
 	var p PingPacket
-	if err := json.Unmarshal(packet, &p); err != nil {
+	if err := json.Unmarshal(packet.PacketData, &p); err != nil {
 		return p, err
 	}
 
@@ -28,21 +28,17 @@ func CreateCastPacket(entityID string, skillID int, targetX, targetY float64) (N
 		TargetEntityID: "", // https://github.com/OpenDiablo2/OpenDiablo2/issues/826
 	}
 
-	b, err := json.Marshal(castPacket)
-	if err != nil {
-		return NetPacket{PacketType: d2netpackettype.CastSkill}, err
-	}
-
-	return NetPacket{
-		PacketType: d2netpackettype.CastSkill,
-		PacketData: b,
-	}, nil
+	return newNetPacket(d2netpackettype.CastSkill, castPacket), nil
 }
 
-// UnmarshalCast unmarshals the given data to a CastPacket struct
-func UnmarshalCast(packet []byte) (CastPacket, error) {
+// UnmarshalCast unmarshals the given packet to a CastPacket struct
+func UnmarshalCast(packet NetPacket) (CastPacket, error) {
+	if p, ok := packet.body.(CastPacket); ok {
+		return p, nil
+	}
+
 	var p CastPacket
-	if err := json.Unmarshal(packet, &p); err != nil {
+	if err := json.Unmarshal(packet.PacketData, &p); err != nil {
 		return p, err
 	}
 
@@ -8,38 +8,39 @@ import (
 	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
 )
 
-// PlayerConnectionRequestPacket contains a player ID and game state.
+// PlayerConnectionRequestPacket contains a player ID, the protocol version
+// of the client, game state and the key of the hero on the servers it joins.
 // It is sent by a remote client to initiate a connection (join a game).
 type PlayerConnectionRequestPacket struct {
-	ID          string            `json:"id"`
-	PlayerState *d2hero.HeroState `json:"gameState"`
+	ID              string            `json:"id"`
+	ProtocolVersion int               `json:"protocolVersion"`
+	PlayerState     *d2hero.HeroState `json:"gameState"`
+	HeroKey         string            `json:"heroKey"`
 }
 
 // CreatePlayerConnectionRequestPacket returns a NetPacket which defines a
-// PlayerConnectionRequestPacket with the given ID and game state.
-func CreatePlayerConnectionRequestPacket(id string, playerState *d2hero.HeroState) (NetPacket, error) {
+// PlayerConnectionRequestPacket with the given ID, game state and hero key.
+func CreatePlayerConnectionRequestPacket(id string, playerState *d2hero.HeroState, heroKey string) (NetPacket, error) {
 	playerConnectionRequest := PlayerConnectionRequestPacket{
-		ID:          id,
-		PlayerState: playerState,
+		ID:              id,
+		ProtocolVersion: ProtocolVersion,
+		PlayerState:     playerState,
+		HeroKey:         heroKey,
 	}
 
-	b, err := json.Marshal(playerConnectionRequest)
-	if err != nil {
-		return NetPacket{PacketType: d2netpackettype.PlayerConnectionRequest}, err
-	}
-
-	return NetPacket{
-		PacketType: d2netpackettype.PlayerConnectionRequest,
-		PacketData: b,
-	}, nil
+	return newNetPacket(d2netpackettype.PlayerConnectionRequest, playerConnectionRequest), nil
 }
 
-// UnmarshalPlayerConnectionRequest unmarshals the given data to a
+// UnmarshalPlayerConnectionRequest unmarshals the given packet to a
 // PlayerConnectionRequestPacket struct
-func UnmarshalPlayerConnectionRequest(packet []byte) (PlayerConnectionRequestPacket, error) {
+func UnmarshalPlayerConnectionRequest(packet NetPacket) (PlayerConnectionRequestPacket, error) {
+	if p, ok := packet.body.(PlayerConnectionRequestPacket); ok {
+		return p, nil
+	}
+
 	var resp PlayerConnectionRequestPacket
 
-	if err := json.Unmarshal(packet, &resp); err != nil {
+	if err := json.Unmarshal(packet.PacketData, &resp); err != nil {
 		return PlayerConnectionRequestPacket{}, err
 	}
 
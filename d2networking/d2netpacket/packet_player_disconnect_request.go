@@ -22,23 +22,19 @@ func CreatePlayerDisconnectRequestPacket(id string) (NetPacket, error) {
 		ID: id,
 	}
 
-	b, err := json.Marshal(playerDisconnectRequest)
-	if err != nil {
-		return NetPacket{PacketType: d2netpackettype.PlayerDisconnectionNotification}, err
-	}
-
-	return NetPacket{
-		PacketType: d2netpackettype.PlayerDisconnectionNotification,
-		PacketData: b,
-	}, nil
+	return newNetPacket(d2netpackettype.PlayerDisconnectionNotification, playerDisconnectRequest), nil
 }
 
-// UnmarshalPlayerDisconnectionRequest unmarshals the given data to a
+// UnmarshalPlayerDisconnectionRequest unmarshals the given packet to a
 // PlayerDisconnectRequestPacket struct
-func UnmarshalPlayerDisconnectionRequest(packet []byte) (PlayerDisconnectRequestPacket, error) {
+func UnmarshalPlayerDisconnectionRequest(packet NetPacket) (PlayerDisconnectRequestPacket, error) {
+	if p, ok := packet.body.(PlayerDisconnectRequestPacket); ok {
+		return p, nil
+	}
+
 	var resp PlayerDisconnectRequestPacket
 
-	if err := json.Unmarshal(packet, &resp); err != nil {
+	if err := json.Unmarshal(packet.PacketData, &resp); err != nil {
 		return resp, err
 	}
 
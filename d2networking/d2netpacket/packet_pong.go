@@ -22,22 +22,18 @@ func CreatePongPacket(id string) (NetPacket, error) {
 		TS: time.Now(),
 	}
 
-	b, err := json.Marshal(pong)
-	if err != nil {
-		return NetPacket{PacketType: d2netpackettype.Pong}, err
-	}
-
-	return NetPacket{
-		PacketType: d2netpackettype.Pong,
-		PacketData: b,
-	}, nil
+	return newNetPacket(d2netpackettype.Pong, pong), nil
 }
 
-// UnmarshalPong unmarshals the given data to a PongPacket struct
-func UnmarshalPong(packet []byte) (PongPacket, error) {
+// UnmarshalPong unmarshals the given packet to a PongPacket struct
+func UnmarshalPong(packet NetPacket) (PongPacket, error) {
+	if p, ok := packet.body.(PongPacket); ok {
+		return p, nil
+	}
+
 	var resp PongPacket
 
-	if err := json.Unmarshal(packet, &resp); err != nil {
+	if err := json.Unmarshal(packet.PacketData, &resp); err != nil {
 		return resp, err
 	}
 
@@ -23,21 +23,17 @@ func CreateSavePlayerPacket(playerState *d2mapentity.Player, difficulty d2enum.D
 		Difficulty: difficulty,
 	}
 
-	b, err := json.Marshal(savePlayerData)
-	if err != nil {
-		return NetPacket{PacketType: d2netpackettype.SavePlayer}, err
-	}
-
-	return NetPacket{
-		PacketType: d2netpackettype.SavePlayer,
-		PacketData: b,
-	}, nil
+	return newNetPacket(d2netpackettype.SavePlayer, savePlayerData), nil
 }
 
-// UnmarshalSavePlayer unmarshalls the given data to a SavePlayerPacket struct
-func UnmarshalSavePlayer(packet []byte) (SavePlayerPacket, error) {
+// UnmarshalSavePlayer unmarshalls the given packet to a SavePlayerPacket struct
+func UnmarshalSavePlayer(packet NetPacket) (SavePlayerPacket, error) {
+	if p, ok := packet.body.(SavePlayerPacket); ok {
+		return p, nil
+	}
+
 	var p SavePlayerPacket
-	if err := json.Unmarshal(packet, &p); err != nil {
+	if err := json.Unmarshal(packet.PacketData, &p); err != nil {
 		return p, err
 	}
 
@@ -20,22 +20,18 @@ func CreateServerClosedPacket() (NetPacket, error) {
 		TS: time.Now(),
 	}
 
-	b, err := json.Marshal(serverClosed)
-	if err != nil {
-		return NetPacket{PacketType: d2netpackettype.ServerClosed}, err
-	}
-
-	return NetPacket{
-		PacketType: d2netpackettype.ServerClosed,
-		PacketData: b,
-	}, nil
+	return newNetPacket(d2netpackettype.ServerClosed, serverClosed), nil
 }
 
-// UnmarshalServerClosed unmarshals the given data to a ServerClosedPacket struct
-func UnmarshalServerClosed(packet []byte) (ServerClosedPacket, error) {
+// UnmarshalServerClosed unmarshals the given packet to a ServerClosedPacket struct
+func UnmarshalServerClosed(packet NetPacket) (ServerClosedPacket, error) {
+	if p, ok := packet.body.(ServerClosedPacket); ok {
+		return p, nil
+	}
+
 	var resp ServerClosedPacket
 
-	if err := json.Unmarshal(packet, &resp); err != nil {
+	if err := json.Unmarshal(packet.PacketData, &resp); err != nil {
 		return resp, err
 	}
 
@@ -14,22 +14,18 @@ type ServerFullPacket struct{}
 func CreateServerFullPacket() (NetPacket, error) {
 	serverClosed := ServerFullPacket{}
 
-	b, err := json.Marshal(serverClosed)
-	if err != nil {
-		return NetPacket{PacketType: d2netpackettype.ServerFull}, err
-	}
-
-	return NetPacket{
-		PacketType: d2netpackettype.ServerFull,
-		PacketData: b,
-	}, nil
+	return newNetPacket(d2netpackettype.ServerFull, serverClosed), nil
 }
 
-// UnmarshalServerFull unmarshalls the given data to a ServerFullPacket struct
-func UnmarshalServerFull(packet []byte) (ServerFullPacket, error) {
+// UnmarshalServerFull unmarshalls the given packet to a ServerFullPacket struct
+func UnmarshalServerFull(packet NetPacket) (ServerFullPacket, error) {
+	if p, ok := packet.body.(ServerFullPacket); ok {
+		return p, nil
+	}
+
 	var resp ServerFullPacket
 
-	if err := json.Unmarshal(packet, &resp); err != nil {
+	if err := json.Unmarshal(packet.PacketData, &resp); err != nil {
 		return resp, err
 	}
 
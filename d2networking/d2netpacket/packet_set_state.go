@@ -0,0 +1,52 @@
+package d2netpacket
+
+import (
+	"encoding/json"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+// SetStatePacket puts an entity in a state of States.txt, like the buff of a shrine, or removes
+// it from the state. It is sent by the server to the clients on the map of the entity.
+type SetStatePacket struct {
+	EntityID string         `json:"entityId"`
+	State    string         `json:"state"`
+	Stats    map[string]int `json:"stats,omitempty"`
+	Duration float64        `json:"duration"` // in seconds, the state does not expire if zero
+	Removed  bool           `json:"removed"`
+}
+
+// CreateSetStatePacket returns a NetPacket which declares a SetStatePacket with the given
+// entity, state, stats of the state and the seconds until the state expires.
+func CreateSetStatePacket(entityID, state string, stats map[string]int, duration float64) (NetPacket, error) {
+	return createSetStatePacket(SetStatePacket{
+		EntityID: entityID,
+		State:    state,
+		Stats:    stats,
+		Duration: duration,
+	})
+}
+
+// CreateRemoveStatePacket returns a NetPacket which declares a SetStatePacket removing the
+// given entity from the state.
+func CreateRemoveStatePacket(entityID, state string) (NetPacket, error) {
+	return createSetStatePacket(SetStatePacket{EntityID: entityID, State: state, Removed: true})
+}
+
+func createSetStatePacket(setStatePacket SetStatePacket) (NetPacket, error) {
+	return newNetPacket(d2netpackettype.SetState, setStatePacket), nil
+}
+
+// UnmarshalSetState unmarshals the given packet to a SetStatePacket struct
+func UnmarshalSetState(packet NetPacket) (SetStatePacket, error) {
+	if p, ok := packet.body.(SetStatePacket); ok {
+		return p, nil
+	}
+
+	var p SetStatePacket
+	if err := json.Unmarshal(packet.PacketData, &p); err != nil {
+		return p, err
+	}
+
+	return p, nil
+}
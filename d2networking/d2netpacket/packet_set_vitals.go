@@ -0,0 +1,41 @@
+package d2netpacket
+
+import (
+	"encoding/json"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+// SetVitalsPacket sets the life and mana of a player, like a shrine refilling them or a dead
+// player respawning. It is sent by the server to the clients on the map of the player.
+type SetVitalsPacket struct {
+	EntityID string `json:"entityId"`
+	Life     int    `json:"life"`
+	Mana     int    `json:"mana"`
+}
+
+// CreateSetVitalsPacket returns a NetPacket which declares a SetVitalsPacket with the given
+// entity, life and mana.
+func CreateSetVitalsPacket(entityID string, life, mana int) (NetPacket, error) {
+	setVitalsPacket := SetVitalsPacket{
+		EntityID: entityID,
+		Life:     life,
+		Mana:     mana,
+	}
+
+	return newNetPacket(d2netpackettype.SetVitals, setVitalsPacket), nil
+}
+
+// UnmarshalSetVitals unmarshals the given packet to a SetVitalsPacket struct
+func UnmarshalSetVitals(packet NetPacket) (SetVitalsPacket, error) {
+	if p, ok := packet.body.(SetVitalsPacket); ok {
+		return p, nil
+	}
+
+	var p SetVitalsPacket
+	if err := json.Unmarshal(packet.PacketData, &p); err != nil {
+		return p, err
+	}
+
+	return p, nil
+}
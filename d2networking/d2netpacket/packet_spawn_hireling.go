@@ -0,0 +1,48 @@
+package d2netpacket
+
+import (
+	"encoding/json"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+// SpawnHirelingPacket is sent by the server to the players on a map when a hireling enters it,
+// with its owner. The hireling is an allied NPC of the given MonStats.txt monster, it is moved
+// with MoveMonsterPackets like the monsters.
+type SpawnHirelingPacket struct {
+	OwnerID  string  `json:"ownerId"`
+	EntityID string  `json:"entityId"`
+	Monster  string  `json:"monster"` // the Id of MonStats.txt
+	Name     string  `json:"name"`    // the key of the name in the string tables
+	X        float64 `json:"x"`       // tiles
+	Y        float64 `json:"y"`       // tiles
+}
+
+// CreateSpawnHirelingPacket returns a NetPacket which declares a SpawnHirelingPacket with the
+// given hireling entity at the given position.
+func CreateSpawnHirelingPacket(ownerID, entityID, monster, name string, x, y float64) (NetPacket, error) {
+	spawnHirelingPacket := SpawnHirelingPacket{
+		OwnerID:  ownerID,
+		EntityID: entityID,
+		Monster:  monster,
+		Name:     name,
+		X:        x,
+		Y:        y,
+	}
+
+	return newNetPacket(d2netpackettype.SpawnHireling, spawnHirelingPacket), nil
+}
+
+// UnmarshalSpawnHireling unmarshals the given packet to a SpawnHirelingPacket struct
+func UnmarshalSpawnHireling(packet NetPacket) (SpawnHirelingPacket, error) {
+	if p, ok := packet.body.(SpawnHirelingPacket); ok {
+		return p, nil
+	}
+
+	var p SpawnHirelingPacket
+	if err := json.Unmarshal(packet.PacketData, &p); err != nil {
+		return p, err
+	}
+
+	return p, nil
+}
@@ -0,0 +1,42 @@
+package d2netpacket
+
+import (
+	"encoding/json"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+// SpendPointPacket is sent by a client when its player spends a skill point on a skill, or
+// else a stat point on an attribute. The server answers with an UpdateStatsPacket.
+type SpendPointPacket struct {
+	PlayerID  string           `json:"playerId"`
+	Attribute d2enum.Attribute `json:"attribute"`
+	SkillID   int              `json:"skillId,omitempty"`
+}
+
+// CreateSpendPointPacket returns a NetPacket which declares a SpendPointPacket with the given
+// player and attribute or skill. The attribute is only used when no skill is given.
+func CreateSpendPointPacket(playerID string, attribute d2enum.Attribute, skillID int) (NetPacket, error) {
+	spendPointPacket := SpendPointPacket{
+		PlayerID:  playerID,
+		Attribute: attribute,
+		SkillID:   skillID,
+	}
+
+	return newNetPacket(d2netpackettype.SpendPoint, spendPointPacket), nil
+}
+
+// UnmarshalSpendPoint unmarshals the given packet to a SpendPointPacket struct
+func UnmarshalSpendPoint(packet NetPacket) (SpendPointPacket, error) {
+	if p, ok := packet.body.(SpendPointPacket); ok {
+		return p, nil
+	}
+
+	var p SpendPointPacket
+	if err := json.Unmarshal(packet.PacketData, &p); err != nil {
+		return p, err
+	}
+
+	return p, nil
+}
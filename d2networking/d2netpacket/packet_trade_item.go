@@ -0,0 +1,43 @@
+package d2netpacket
+
+import (
+	"encoding/json"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+// TradeItemPacket is sent by a client when its player buys, sells, repairs or gambles for an
+// item at the vendor it opened. The server checks the gold of the player, the client only
+// learns the outcome from the TradeResultPacket.
+type TradeItemPacket struct {
+	PlayerID string             `json:"playerId"`
+	Action   d2enum.TradeAction `json:"action"`
+	ItemID   string             `json:"itemId"`
+}
+
+// CreateTradeItemPacket returns a NetPacket which declares a TradeItemPacket with the given
+// player, action and item.
+func CreateTradeItemPacket(playerID string, action d2enum.TradeAction, itemID string) (NetPacket, error) {
+	tradeItemPacket := TradeItemPacket{
+		PlayerID: playerID,
+		Action:   action,
+		ItemID:   itemID,
+	}
+
+	return newNetPacket(d2netpackettype.TradeItem, tradeItemPacket), nil
+}
+
+// UnmarshalTradeItem unmarshals the given packet to a TradeItemPacket struct
+func UnmarshalTradeItem(packet NetPacket) (TradeItemPacket, error) {
+	if p, ok := packet.body.(TradeItemPacket); ok {
+		return p, nil
+	}
+
+	var p TradeItemPacket
+	if err := json.Unmarshal(packet.PacketData, &p); err != nil {
+		return p, err
+	}
+
+	return p, nil
+}
@@ -0,0 +1,64 @@
+package d2netpacket
+
+import (
+	"encoding/json"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+// TradeResultPacket is sent by the server in answer to a TradeItemPacket. It holds the item the
+// player got, and the gold the player has after the trade. A trade which was refused has an
+// error and leaves the gold as it was.
+type TradeResultPacket struct {
+	PlayerID string             `json:"playerId"`
+	Action   d2enum.TradeAction `json:"action"`
+	ItemID   string             `json:"itemId"`
+	Codes    []string           `json:"codes,omitempty"`
+	Gold     int                `json:"gold"`
+	Error    string             `json:"error,omitempty"`
+}
+
+// CreateTradeResultPacket returns a NetPacket which declares a TradeResultPacket for a trade
+// which went through.
+func CreateTradeResultPacket(playerID string, action d2enum.TradeAction, itemID string, codes []string,
+	gold int) (NetPacket, error) {
+	return createTradeResultPacket(TradeResultPacket{
+		PlayerID: playerID,
+		Action:   action,
+		ItemID:   itemID,
+		Codes:    codes,
+		Gold:     gold,
+	})
+}
+
+// CreateTradeErrorPacket returns a NetPacket which declares a TradeResultPacket for a trade
+// which was refused.
+func CreateTradeErrorPacket(playerID string, action d2enum.TradeAction, itemID string, gold int,
+	reason string) (NetPacket, error) {
+	return createTradeResultPacket(TradeResultPacket{
+		PlayerID: playerID,
+		Action:   action,
+		ItemID:   itemID,
+		Gold:     gold,
+		Error:    reason,
+	})
+}
+
+func createTradeResultPacket(tradeResultPacket TradeResultPacket) (NetPacket, error) {
+	return newNetPacket(d2netpackettype.TradeResult, tradeResultPacket), nil
+}
+
+// UnmarshalTradeResult unmarshals the given packet to a TradeResultPacket struct
+func UnmarshalTradeResult(packet NetPacket) (TradeResultPacket, error) {
+	if p, ok := packet.body.(TradeResultPacket); ok {
+		return p, nil
+	}
+
+	var p TradeResultPacket
+	if err := json.Unmarshal(packet.PacketData, &p); err != nil {
+		return p, err
+	}
+
+	return p, nil
+}
@@ -0,0 +1,68 @@
+package d2netpacket
+
+import (
+	"encoding/json"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hireling"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+// UpdateHirelingPacket is sent by the server to the owner of a hireling, in answer to a
+// HirelingActionPacket and whenever the hireling levels up or dies. It holds the hireling as it
+// is saved and the gold of the player. An item the player got back from the hireling is given
+// with its ID and codes. A refused action has an error and leaves everything as it was.
+type UpdateHirelingPacket struct {
+	PlayerID string                `json:"playerId"`
+	Action   d2enum.HirelingAction `json:"action"`
+	Hireling *d2hireling.Hireling  `json:"hireling,omitempty"`
+	Gold     int                   `json:"gold"`
+	ItemID   string                `json:"itemId,omitempty"`
+	Codes    []string              `json:"codes,omitempty"`
+	Error    string                `json:"error,omitempty"`
+}
+
+// CreateUpdateHirelingPacket returns a NetPacket which declares an UpdateHirelingPacket with the
+// hireling of the player, and the item the player got back from it if any.
+func CreateUpdateHirelingPacket(playerID string, action d2enum.HirelingAction, hireling *d2hireling.Hireling,
+	gold int, itemID string, codes []string) (NetPacket, error) {
+	return createUpdateHirelingPacket(UpdateHirelingPacket{
+		PlayerID: playerID,
+		Action:   action,
+		Hireling: hireling,
+		Gold:     gold,
+		ItemID:   itemID,
+		Codes:    codes,
+	})
+}
+
+// CreateHirelingErrorPacket returns a NetPacket which declares an UpdateHirelingPacket for an
+// action which was refused.
+func CreateHirelingErrorPacket(playerID string, action d2enum.HirelingAction, hireling *d2hireling.Hireling,
+	gold int, reason string) (NetPacket, error) {
+	return createUpdateHirelingPacket(UpdateHirelingPacket{
+		PlayerID: playerID,
+		Action:   action,
+		Hireling: hireling,
+		Gold:     gold,
+		Error:    reason,
+	})
+}
+
+func createUpdateHirelingPacket(update UpdateHirelingPacket) (NetPacket, error) {
+	return newNetPacket(d2netpackettype.UpdateHireling, update), nil
+}
+
+// UnmarshalUpdateHireling unmarshals the given packet to an UpdateHirelingPacket struct
+func UnmarshalUpdateHireling(packet NetPacket) (UpdateHirelingPacket, error) {
+	if p, ok := packet.body.(UpdateHirelingPacket); ok {
+		return p, nil
+	}
+
+	var p UpdateHirelingPacket
+	if err := json.Unmarshal(packet.PacketData, &p); err != nil {
+		return p, err
+	}
+
+	return p, nil
+}
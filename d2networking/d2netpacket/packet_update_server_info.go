@@ -21,22 +21,18 @@ func CreateUpdateServerInfoPacket(seed int64, playerID string) (NetPacket, error
 		PlayerID: playerID,
 	}
 
-	b, err := json.Marshal(updateServerInfo)
-	if err != nil {
-		return NetPacket{PacketType: d2netpackettype.UpdateServerInfo}, err
-	}
-
-	return NetPacket{
-		PacketType: d2netpackettype.UpdateServerInfo,
-		PacketData: b,
-	}, nil
+	return newNetPacket(d2netpackettype.UpdateServerInfo, updateServerInfo), nil
 }
 
-// UnmarshalUpdateServerInfo unmarshals the data to a UpdateServerInfoPacket struct
-func UnmarshalUpdateServerInfo(packet []byte) (UpdateServerInfoPacket, error) {
+// UnmarshalUpdateServerInfo unmarshals the given packet to a UpdateServerInfoPacket struct
+func UnmarshalUpdateServerInfo(packet NetPacket) (UpdateServerInfoPacket, error) {
+	if p, ok := packet.body.(UpdateServerInfoPacket); ok {
+		return p, nil
+	}
+
 	var resp UpdateServerInfoPacket
 
-	if err := json.Unmarshal(packet, &resp); err != nil {
+	if err := json.Unmarshal(packet.PacketData, &resp); err != nil {
 		return resp, err
 	}
 
@@ -0,0 +1,47 @@
+package d2netpacket
+
+import (
+	"encoding/json"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+// UpdateStatsPacket is sent by the server when a player gains experience or levels up, and in
+// answer to a SpendPointPacket. It holds the stats of the player as they are saved, and the
+// points of its skills by skill ID. A refused point has an error and leaves the stats as they
+// were.
+type UpdateStatsPacket struct {
+	PlayerID string                 `json:"playerId"`
+	Stats    *d2hero.HeroStatsState `json:"stats"`
+	Skills   map[int]int            `json:"skills,omitempty"`
+	Error    string                 `json:"error,omitempty"`
+}
+
+// CreateUpdateStatsPacket returns a NetPacket which declares an UpdateStatsPacket with the
+// stats and the skill points of the player, and the reason a point was refused if any.
+func CreateUpdateStatsPacket(playerID string, stats *d2hero.HeroStatsState, skills map[int]int,
+	reason string) (NetPacket, error) {
+	updateStatsPacket := UpdateStatsPacket{
+		PlayerID: playerID,
+		Stats:    stats,
+		Skills:   skills,
+		Error:    reason,
+	}
+
+	return newNetPacket(d2netpackettype.UpdateStats, updateStatsPacket), nil
+}
+
+// UnmarshalUpdateStats unmarshals the given packet to an UpdateStatsPacket struct
+func UnmarshalUpdateStats(packet NetPacket) (UpdateStatsPacket, error) {
+	if p, ok := packet.body.(UpdateStatsPacket); ok {
+		return p, nil
+	}
+
+	var p UpdateStatsPacket
+	if err := json.Unmarshal(packet.PacketData, &p); err != nil {
+		return p, err
+	}
+
+	return p, nil
+}
@@ -0,0 +1,50 @@
+package d2netpacket
+
+import (
+	"encoding/json"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+// VendorItem is an item offered by a vendor, with the price the player pays for it and the
+// page of StorePage.txt it is shown on
+type VendorItem struct {
+	ID    string   `json:"id"`
+	Codes []string `json:"codes"`
+	Price int      `json:"price"`
+	Page  string   `json:"page"`
+}
+
+// VendorInventoryPacket is sent by the server when a player opens the trade or the gamble
+// window of an NPC, it holds the items the NPC offers for this visit.
+type VendorInventoryPacket struct {
+	NPCID  string       `json:"npcId"`
+	Gamble bool         `json:"gamble"`
+	Items  []VendorItem `json:"items"`
+}
+
+// CreateVendorInventoryPacket returns a NetPacket which declares a VendorInventoryPacket with the
+// given items of the NPC.
+func CreateVendorInventoryPacket(npcID string, gamble bool, items []VendorItem) (NetPacket, error) {
+	vendorInventoryPacket := VendorInventoryPacket{
+		NPCID:  npcID,
+		Gamble: gamble,
+		Items:  items,
+	}
+
+	return newNetPacket(d2netpackettype.VendorInventory, vendorInventoryPacket), nil
+}
+
+// UnmarshalVendorInventory unmarshals the given packet to a VendorInventoryPacket struct
+func UnmarshalVendorInventory(packet NetPacket) (VendorInventoryPacket, error) {
+	if p, ok := packet.body.(VendorInventoryPacket); ok {
+		return p, nil
+	}
+
+	var p VendorInventoryPacket
+	if err := json.Unmarshal(packet.PacketData, &p); err != nil {
+		return p, err
+	}
+
+	return p, nil
+}
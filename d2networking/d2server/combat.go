@@ -0,0 +1,776 @@
+package d2server
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math/d2vector"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2combat"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapengine"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapentity"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2monsterai"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2state"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+)
+
+const (
+	// an entity plays its hit recovery animation when a single hit takes at least this
+	// fraction of its maximum life
+	playerHitRecoveryDivisor  = 12
+	monsterHitRecoveryDivisor = 8
+
+	// meleeRange is how far (in sub tiles) a melee attack reaches, including the latency
+	// between the client and the server
+	meleeRange = 2 * subtilesPerTile
+
+	// entityRadius is the radius (in sub tiles) of an entity which is hit by missiles
+	entityRadius = 2.0
+
+	// fullSourceDamage is the SrcDam of Skills.txt which adds the full weapon damage
+	fullSourceDamage = 128
+
+	// respawnDelay is the time a dead player lies on the map before it respawns in town
+	respawnDelay = 5 * time.Second
+
+	// the animations of AnimData.d2 play AnimationSpeed/256 frames per frame of the game, which
+	// runs at 25 frames per second
+	animationSpeedDivisor = 256
+	framesPerSecond       = 25
+
+	// defaultCastFrames is how long (in frames) a player casts a skill without an animation
+	defaultCastFrames = 8
+
+	// castSlack is how much sooner than the end of the last cast the next cast of a player may
+	// arrive, as the casts sent by a client do not arrive at the same pace
+	castSlack = 100 * time.Millisecond
+
+	// the mana costs of Skills.txt are in 256ths of a point of mana, shifted by the Manashift
+	manaShiftDivisor = 8
+
+	unarmedMinDamage = 1
+	unarmedMaxDamage = 2
+	percent          = 100
+)
+
+// combatant is an entity which can be attacked: a player, a monster or a hireling
+type combatant struct {
+	d2combat.Defender
+	id      string
+	life    int
+	maxLife int
+	mana    int                  // the mana of a player
+	maxMana int                  // the final maximum mana of a player
+	client  ClientConnection     // the client of a player
+	owner   ClientConnection     // the client of the player a hireling belongs to
+	npc     *d2mapentity.NPC     // the entity of a monster or a hireling
+	ai      *d2monsterai.Monster // the AI of a monster or a hireling
+	looted  bool                 // a monster dropped its loot
+	moved   bool                 // a monster was moved by its AI
+	states  *d2state.Manager     // the states of States.txt the combatant is in
+	stats   *d2hero.StatEngine   // the final stats of a player
+	respawn time.Time            // when a dead player respawns in town
+	casting time.Time            // until when a player casts its last skill
+
+	// the defense and resistances without the stats of the states
+	baseDefense     int
+	baseResistances map[d2combat.Element]int
+}
+
+func (c *combatant) isDead() bool {
+	return c.life <= 0
+}
+
+// isFriendly returns true for the players and their hirelings, which fight the monsters
+func (c *combatant) isFriendly() bool {
+	return c.client != nil || c.owner != nil
+}
+
+// setVitals sets the life and mana of a player, limited to its final maximum life and mana. They
+// are kept in the hero state of the player, to be saved and sent to the clients.
+func (c *combatant) setVitals(life, mana int) {
+	c.life = d2math.ClampInt(life, 0, c.maxLife)
+	c.mana = d2math.ClampInt(mana, 0, c.maxMana)
+
+	stats := c.client.GetPlayerState().Stats
+	stats.Health, stats.Mana = c.life, c.mana
+}
+
+// newPlayerCombatant creates the combatant of a player in a game of the given difficulty, in the
+// saved states of its hero state.
+// The server keeps the life and mana of the player from then on, a hero which was saved dead
+// enters the game with full life and mana.
+func newPlayerCombatant(client ClientConnection, difficulty d2enum.DifficultyType, factory *d2hero.HeroStateFactory,
+	records *d2records.RecordManager) *combatant {
+	state := client.GetPlayerState()
+	states := d2state.NewManager()
+
+	player := &combatant{
+		Defender: d2combat.Defender{
+			Level:       state.Stats.Level,
+			Resistances: make(map[d2combat.Element]int),
+		},
+		id:              client.GetUniqueID(),
+		life:            state.Stats.Health,
+		maxLife:         state.Stats.MaxHealth,
+		mana:            state.Stats.Mana,
+		maxMana:         state.Stats.MaxMana,
+		client:          client,
+		states:          states,
+		stats:           factory.NewStatEngine(state.HeroType, states),
+		baseResistances: make(map[d2combat.Element]int),
+	}
+
+	player.stats.SetDifficulty(difficulty)
+	player.states.Load(records.States, state.States, time.Now())
+	player.updateDefender()
+
+	if player.isDead() {
+		player.setVitals(player.maxLife, player.maxMana)
+	}
+
+	return player
+}
+
+func newMonsterCombatant(rng *rand.Rand, npc *d2mapentity.NPC, difficulty d2enum.DifficultyType,
+	records *d2records.RecordManager) *combatant {
+	record := npc.MonStats()
+	properties := npc.Properties()
+	params := d2monsterai.NewParams(record, records.Monster.AI, difficulty)
+	aiRNG := rand.New(rand.NewSource(rng.Int63())) //nolint:gosec // the AI does not need secure randomness
+
+	level := byDifficulty(difficulty, record.LevelNormal, record.LevelNightmare, record.LevelHell)
+	if properties.Level > 0 {
+		level = properties.Level
+	}
+
+	minLife := byDifficulty(difficulty, record.MinHPNormal, record.MinHPNightmare, record.MinHPHell)
+	maxLife := byDifficulty(difficulty, record.MaxHPNormal, record.MaxHPNightmare, record.MaxHPHell)
+
+	// the life of MonStats.txt is scaled by the level of the spawned monster
+	if values, found := monsterLevelValues(records, level, difficulty); found {
+		minLife = minLife * values.hitpoints / percent
+		maxLife = maxLife * values.hitpoints / percent
+	}
+
+	life := d2combat.DamageRange{Min: minLife, Max: maxLife}.Roll(rng)
+	if multiplier := rankLifeMultiplier(records, properties.Rank, difficulty); multiplier > 1 {
+		life *= multiplier
+	}
+
+	monster := &combatant{
+		Defender: d2combat.Defender{
+			Level:   level,
+			Defense: byDifficulty(difficulty, record.ArmorClassNormal, record.ArmorClassNightmare, record.ArmorClassHell),
+			Resistances: map[d2combat.Element]int{
+				d2combat.ElementPhysical: byDifficulty(difficulty, record.ResistancePhysicalNormal,
+					record.ResistancePhysicalNightmare, record.ResistancePhysicalHell),
+				d2combat.ElementMagic: byDifficulty(difficulty, record.ResistanceMagicNormal,
+					record.ResistanceMagicNightmare, record.ResistanceMagicHell),
+				d2combat.ElementFire: byDifficulty(difficulty, record.ResistanceFireNormal,
+					record.ResistanceFireNightmare, record.ResistanceFireHell),
+				d2combat.ElementLightning: byDifficulty(difficulty, record.ResistanceLightningNormal,
+					record.ResistanceLightningNightmare, record.ResistanceLightningHell),
+				d2combat.ElementCold: byDifficulty(difficulty, record.ResistanceColdNormal,
+					record.ResistanceColdNightmare, record.ResistanceColdHell),
+				d2combat.ElementPoison: byDifficulty(difficulty, record.ResistancePoisonNormal,
+					record.ResistancePoisonNightmare, record.ResistancePoisonHell),
+			},
+		},
+		id:      npc.ID(),
+		life:    d2math.MaxInt(life, 1),
+		maxLife: d2math.MaxInt(life, 1),
+		npc:     npc,
+		ai:      d2monsterai.NewMonster(npc.ID(), npc.GetPosition(), params, aiRNG),
+		states:  d2state.NewManager(),
+	}
+
+	monster.baseDefense = monster.Defense
+	monster.baseResistances = make(map[d2combat.Element]int, len(monster.Resistances))
+
+	for element, resistance := range monster.Resistances {
+		monster.baseResistances[element] = resistance
+	}
+
+	return monster
+}
+
+// levelValues are the values of MonLvl.txt for a monster level, in percent of the values of
+// MonStats.txt
+type levelValues struct {
+	hitpoints  int
+	damage     int
+	experience int
+}
+
+// monsterLevelValues returns the values of MonLvl.txt for a monster level
+func monsterLevelValues(records *d2records.RecordManager, level int,
+	difficulty d2enum.DifficultyType) (levelValues, bool) {
+	record := records.Monster.Levels[level]
+	if record == nil {
+		return levelValues{}, false
+	}
+
+	values := record.Ladder.Normal
+
+	switch difficulty {
+	case d2enum.DifficultyNightmare:
+		values = record.Ladder.Nightmare
+	case d2enum.DifficultyHell:
+		values = record.Ladder.Hell
+	}
+
+	return levelValues{hitpoints: values.Hitpoints, damage: values.Damage, experience: values.Experience}, true
+}
+
+// rankLifeMultiplier returns the life multiplier of MonUMod.txt for champions, uniques and
+// minions
+func rankLifeMultiplier(records *d2records.RecordManager, rank d2enum.MonsterRank,
+	difficulty d2enum.DifficultyType) int {
+	var index d2enum.MonUModConstIndex
+
+	switch rank {
+	case d2enum.MonsterRankChampion:
+		index = d2enum.ChampionHPBonus
+	case d2enum.MonsterRankUnique, d2enum.MonsterRankSuperUnique:
+		index = d2enum.UniqueHPBonus
+	case d2enum.MonsterRankMinion:
+		index = d2enum.MinionHPBonus
+	default:
+		return 1
+	}
+
+	// the constants of nightmare and hell follow the constant of normal
+	index += d2enum.MonUModConstIndex(difficulty)
+
+	return monUModConstant(records, index)
+}
+
+// monUModConstant returns a constant of MonUMod.txt, or 0 if it is missing
+func monUModConstant(records *d2records.RecordManager, index d2enum.MonUModConstIndex) int {
+	if int(index) >= len(records.Monster.Unique.Constants) {
+		return 0
+	}
+
+	return records.Monster.Unique.Constants[index]
+}
+
+// byDifficulty returns the value of a column of a record for the difficulty
+func byDifficulty(difficulty d2enum.DifficultyType, normal, nightmare, hell int) int {
+	switch difficulty {
+	case d2enum.DifficultyNightmare:
+		return nightmare
+	case d2enum.DifficultyHell:
+		return hell
+	default:
+		return normal
+	}
+}
+
+// addMonsters adds the killable monsters on the map as combatants
+func (g *GameServer) addMonsters(mapEngine *d2mapengine.MapEngine) {
+	combatants := make(map[string]*combatant)
+
+	for _, entity := range mapEngine.Entities() {
+		npc, ok := entity.(*d2mapentity.NPC)
+		if !ok || npc.MonStats() == nil || !npc.MonStats().IsKillable || npc.MonStats().IsNpc {
+			continue
+		}
+
+		combatants[npc.ID()] = newMonsterCombatant(g.rng, npc, g.difficulty, g.asset.Records)
+	}
+
+	g.combatants[mapEngine] = combatants
+}
+
+// sendMonsters sends the monsters of the map which are dead or were moved to a player entering
+// the map. The client of the player generates the monsters of the map where the map places them.
+func (g *GameServer) sendMonsters(mapEngine *d2mapengine.MapEngine, client ClientConnection) {
+	for _, monster := range g.combatants[mapEngine] {
+		if monster.ai == nil || monster.owner != nil {
+			continue
+		}
+
+		var (
+			packet d2netpacket.NetPacket
+			err    error
+		)
+
+		switch {
+		case monster.isDead():
+			packet, err = d2netpacket.CreateKillEntityPacket(monster.id, "")
+		case monster.moved:
+			start, dest := monster.ai.Position.World(), monster.ai.Destination()
+			destTile := dest.World()
+			packet, err = d2netpacket.CreateMoveMonsterPacket(monster.id, start.X(), start.Y(), destTile.X(), destTile.Y())
+		default:
+			continue
+		}
+
+		if err != nil {
+			g.Errorf("GameServer: error creating the state of monster %s: %v", monster.id, err)
+			continue
+		}
+
+		if err := client.SendPacketToClient(packet); err != nil {
+			g.Errorf("GameServer: error sending %s to client %s: %s", packet.PacketType, client.GetUniqueID(), err)
+		}
+	}
+}
+
+// playerCombatant returns the combatant of a player and the map it is on
+func (g *GameServer) playerCombatant(playerID string) (*combatant, *d2mapengine.MapEngine) {
+	mapEngine := g.mapEngines[g.playerLevels[playerID]]
+
+	return g.combatants[mapEngine][playerID], mapEngine
+}
+
+func (g *GameServer) combatantPosition(c *combatant, now time.Time) d2vector.Position {
+	if c.client != nil {
+		return g.estimatePosition(c.client, now)
+	}
+
+	return c.ai.Position
+}
+
+// handleCastSkill resolves a skill cast by a player. The cast is sent to the players on the
+// map of the player, the monsters hit by the skill take damage.
+func (g *GameServer) handleCastSkill(client ClientConnection, packet d2netpacket.NetPacket) error {
+	castPacket, err := d2netpacket.UnmarshalCast(packet)
+	if err != nil {
+		return err
+	}
+
+	playerID := client.GetUniqueID()
+	if castPacket.SourceEntityID != playerID {
+		g.Warningf("GameServer: client %s tried to cast a skill as %s", playerID, castPacket.SourceEntityID)
+		return nil
+	}
+
+	if !validCoordinates(castPacket.TargetX, castPacket.TargetY) {
+		g.Warningf("GameServer: client %s cast a skill at invalid coordinates", playerID)
+		return nil
+	}
+
+	attacker, mapEngine := g.playerCombatant(playerID)
+	if attacker == nil || attacker.isDead() {
+		return nil
+	}
+
+	skill := g.asset.Records.Skill.Details[castPacket.SkillID]
+	if skill == nil {
+		g.Warningf("GameServer: client %s cast the unknown skill %d", playerID, castPacket.SkillID)
+		return nil
+	}
+
+	state := client.GetPlayerState()
+
+	heroSkill, found := state.Skills[skill.ID]
+	if !found || heroSkill == nil || heroSkill.SkillPoints <= 0 {
+		return fmt.Errorf("client %s cast the skill %s without points in it", playerID, skill.Skill)
+	}
+
+	now := time.Now()
+	if now.Before(attacker.casting.Add(-castSlack)) {
+		g.Debugf("GameServer: client %s cast %s before its last cast ended", playerID, skill.Skill)
+		return nil
+	}
+
+	skillLevel := heroSkill.SkillPoints + attacker.stats.Stat(statAllSkills)
+
+	manaCost := skillManaCost(skill, skillLevel)
+	if manaCost > attacker.mana {
+		g.Debugf("GameServer: client %s has not enough mana to cast %s", playerID, skill.Skill)
+		return nil
+	}
+
+	attacker.casting = now.Add(g.castDuration(state, skill))
+
+	if manaCost > 0 {
+		attacker.setVitals(attacker.life, attacker.mana-manaCost)
+		g.sendVitals(mapEngine, attacker)
+	}
+
+	g.sendPacketToMap(playerID, packet)
+
+	origin := g.estimatePosition(client, now)
+	target := d2vector.NewPositionTile(castPacket.TargetX, castPacket.TargetY)
+
+	missiles := g.serverMissiles(skill)
+	if len(missiles) == 0 {
+		if !isMeleeSkill(skill) {
+			return nil
+		}
+
+		defender := g.meleeTarget(mapEngine, attacker, castPacket.TargetEntityID, origin, target, now)
+		if defender != nil {
+			g.applyAttack(mapEngine, attacker, defender, g.playerAttack(state, attacker.stats, skill, skillLevel, nil))
+		}
+
+		return nil
+	}
+
+	for _, missile := range missiles {
+		attack := g.playerAttack(state, attacker.stats, skill, skillLevel, missile)
+
+		for _, defender := range g.missileHits(mapEngine, attacker, missile, origin, target, now) {
+			g.applyAttack(mapEngine, attacker, defender, attack)
+		}
+	}
+
+	return nil
+}
+
+// skillManaCost returns the mana a skill costs at a skill level, never less than its Minmana
+func skillManaCost(skill *d2records.SkillRecord, skillLevel int) int {
+	cost := (skill.Mana + skill.Lvlmana*(skillLevel-1)) << uint(skill.Manashift) >> manaShiftDivisor
+
+	return d2math.MaxInt(cost, skill.Minmana)
+}
+
+// castDuration returns how long a player casts a skill, the length of the animation of the skill
+// of AnimData.d2 with the weapon of the player
+func (g *GameServer) castDuration(state *d2hero.HeroState, skill *d2records.SkillRecord) time.Duration {
+	frames := float64(defaultCastFrames)
+
+	weaponClass := d2hero.EquippedItems(state.Items).WeaponClass()
+	cofName := strings.ToLower(state.HeroType.GetToken() + skill.Anim.String() + weaponClass)
+
+	if records := g.asset.Records.Animation.Data[cofName]; len(records) > 0 && records[0].AnimationSpeed > 0 {
+		frames = float64(records[0].FramesPerDirection*animationSpeedDivisor) / float64(records[0].AnimationSpeed)
+	}
+
+	return time.Duration(frames * float64(time.Second) / framesPerSecond)
+}
+
+func isMeleeSkill(skill *d2records.SkillRecord) bool {
+	return skill.Range == "h2h" || skill.Range == "both"
+}
+
+// serverMissiles returns the missiles of a skill which are simulated on the server
+func (g *GameServer) serverMissiles(skill *d2records.SkillRecord) []*d2records.MissileRecord {
+	missiles := make([]*d2records.MissileRecord, 0)
+
+	for _, name := range []string{skill.Srvmissile, skill.Srvmissilea, skill.Srvmissileb, skill.Srvmissilec} {
+		if missile := g.asset.Records.GetMissileByName(name); missile != nil {
+			missiles = append(missiles, missile)
+		}
+	}
+
+	return missiles
+}
+
+// canAttack returns true if the attacker may attack the defender. Players and hirelings attack
+// monsters and monsters attack players and hirelings, there is no friendly fire.
+func canAttack(attacker, defender *combatant) bool {
+	return attacker != defender && !defender.isDead() && attacker.isFriendly() != defender.isFriendly()
+}
+
+// meleeTarget returns the combatant attacked by a melee skill, if it is in reach and in the line
+// of sight of the attacker. Without a target entity, the attack goes to the combatant closest to
+// the target position.
+func (g *GameServer) meleeTarget(mapEngine *d2mapengine.MapEngine, attacker *combatant, targetID string,
+	origin, target d2vector.Position, now time.Time) *combatant {
+	if targetID == "" {
+		closest := meleeRange + entityRadius
+
+		for _, defender := range g.combatants[mapEngine] {
+			if !canAttack(attacker, defender) {
+				continue
+			}
+
+			position := g.combatantPosition(defender, now)
+			if distance := position.Distance(&target.Vector); distance < closest &&
+				mapEngine.HasLineOfSight(origin, position) {
+				targetID, closest = defender.id, distance
+			}
+		}
+	}
+
+	defender, found := g.combatants[mapEngine][targetID]
+	if !found || !canAttack(attacker, defender) {
+		return nil
+	}
+
+	position := g.combatantPosition(defender, now)
+	if position.Distance(&origin.Vector) > meleeRange {
+		g.Debugf("GameServer: %s is out of the melee range of %s", defender.id, attacker.id)
+		return nil
+	}
+
+	if !mapEngine.HasLineOfSight(origin, position) {
+		g.Debugf("GameServer: %s is out of the line of sight of %s", defender.id, attacker.id)
+		return nil
+	}
+
+	return defender
+}
+
+// missileHits returns the combatants a missile flying from the origin towards the target
+// collides with, ordered by the distance to the origin. A missile which is destroyed upon
+// collision only hits the first one, and the walls between the origin and a combatant stop
+// the missile before it.
+func (g *GameServer) missileHits(mapEngine *d2mapengine.MapEngine, attacker *combatant,
+	missile *d2records.MissileRecord, origin, target d2vector.Position, now time.Time) []*combatant {
+	direction := target.Vector.Clone()
+	direction.Subtract(&origin.Vector)
+
+	if direction.IsZero() {
+		return nil
+	}
+
+	direction.Normalize()
+
+	type collision struct {
+		defender *combatant
+		distance float64
+	}
+
+	collisions := make([]collision, 0)
+	radius := float64(missile.Size)/2 + entityRadius //nolint:gomnd // the size is the diameter
+
+	for _, defender := range g.combatants[mapEngine] {
+		if !canAttack(attacker, defender) {
+			continue
+		}
+
+		position := g.combatantPosition(defender, now)
+		offset := position.Vector.Clone()
+		offset.Subtract(&origin.Vector)
+
+		along := offset.Dot(direction)
+		if along < 0 || along > missile.Reach() {
+			continue
+		}
+
+		closest := direction.Clone()
+		closest.Scale(along)
+
+		if closest.Distance(offset) <= radius && mapEngine.HasLineOfSight(origin, position) {
+			collisions = append(collisions, collision{defender: defender, distance: along})
+		}
+	}
+
+	sort.Slice(collisions, func(i, j int) bool {
+		return collisions[i].distance < collisions[j].distance
+	})
+
+	if missile.Collision.DestroyedUponCollision && len(collisions) > 1 {
+		collisions = collisions[:1]
+	}
+
+	hits := make([]*combatant, len(collisions))
+	for idx := range collisions {
+		hits[idx] = collisions[idx].defender
+	}
+
+	return hits
+}
+
+// playerAttack returns the attack of a player with a skill. The physical damage is the damage
+// of the weapon of the player scaled by the SrcDam of the skill, plus the damage of the skill.
+// Skills without weapon damage are spells, which always hit. A missile without damage of its
+// skill deals the damage of the missile. The skill level includes the skills the final stats of
+// the player add, which add to the attack rating and the weapon damage as well.
+func (g *GameServer) playerAttack(state *d2hero.HeroState, stats *d2hero.StatEngine, skill *d2records.SkillRecord,
+	skillLevel int, missile *d2records.MissileRecord) *d2combat.Attack {
+	weapon := g.weaponDamage(state, stats)
+	weapon.Min = weapon.Min * (percent + stats.Stat(statMinDamagePercent)) / percent
+	weapon.Max = weapon.Max * (percent + stats.Stat(statMaxDamagePercent)) / percent
+
+	return skillAttack(skill, missile, skillLevel, state.Stats.Level, stats.AttackRating(),
+		stats.Stat(statAttackRatingPercent), weapon)
+}
+
+// skillAttack returns the attack of a skill at a skill level, made by an attacker of a level
+// with an attack rating and a weapon damage. The bonus adds to the attack rating of the skill,
+// in percent.
+func skillAttack(skill *d2records.SkillRecord, missile *d2records.MissileRecord, skillLevel, level,
+	attackRating, bonus int, weapon d2combat.DamageRange) *d2combat.Attack {
+	attackRating = attackRating * (percent + skill.ToHit + skill.LevToHit*(skillLevel-1) + bonus) / percent
+
+	sourceDamage := skill.SrcDam
+	if sourceDamage == 0 && missile == nil {
+		sourceDamage = fullSourceDamage
+	}
+
+	minDamage := [5]int{skill.MinLevDam1, skill.MinLevDam2, skill.MinLevDam3, skill.MinLevDam4, skill.MinLevDam5}
+	maxDamage := [5]int{skill.MaxLevDam1, skill.MaxLevDam2, skill.MaxLevDam3, skill.MaxLevDam4, skill.MaxLevDam5}
+	minElemental := [5]int{skill.EMinLev1, skill.EMinLev2, skill.EMinLev3, skill.EMinLev4, skill.EMinLev5}
+	maxElemental := [5]int{skill.EMaxLev1, skill.EMaxLev2, skill.EMaxLev3, skill.EMaxLev4, skill.EMaxLev5}
+
+	attack := &d2combat.Attack{
+		Level:        level,
+		AttackRating: attackRating,
+		Physical: d2combat.DamageRange{
+			Min: weapon.Min*sourceDamage/fullSourceDamage +
+				d2combat.ShiftDamage(d2combat.LevelDamage(skill.MinDam, minDamage, skillLevel), skill.HitShift),
+			Max: weapon.Max*sourceDamage/fullSourceDamage +
+				d2combat.ShiftDamage(d2combat.LevelDamage(skill.MaxDam, maxDamage, skillLevel), skill.HitShift),
+		},
+		Element: d2combat.Element(skill.EType),
+		Elemental: d2combat.DamageRange{
+			Min: d2combat.ShiftDamage(d2combat.LevelDamage(skill.EMin, minElemental, skillLevel), skill.HitShift),
+			Max: d2combat.ShiftDamage(d2combat.LevelDamage(skill.EMax, maxElemental, skillLevel), skill.HitShift),
+		},
+		AlwaysHits: sourceDamage == 0,
+	}
+
+	if missile != nil && attack.Physical.Max == 0 && attack.Elemental.Max == 0 {
+		damage, elemental := &missile.Damage, &missile.ElementalDamage.Damage
+
+		attack.Physical = d2combat.DamageRange{
+			Min: d2combat.ShiftDamage(d2combat.LevelDamage(damage.MinDamage, damage.MinLevelDamage, skillLevel),
+				missile.HitShift),
+			Max: d2combat.ShiftDamage(d2combat.LevelDamage(damage.MaxDamage, damage.MaxLevelDamage, skillLevel),
+				missile.HitShift),
+		}
+		attack.Element = d2combat.Element(missile.ElementalDamage.ElementType)
+		attack.Elemental = d2combat.DamageRange{
+			Min: d2combat.ShiftDamage(d2combat.LevelDamage(elemental.MinDamage, elemental.MinLevelDamage, skillLevel),
+				missile.HitShift),
+			Max: d2combat.ShiftDamage(d2combat.LevelDamage(elemental.MaxDamage, elemental.MaxLevelDamage, skillLevel),
+				missile.HitShift),
+		}
+	}
+
+	return attack
+}
+
+// weaponDamage returns the damage of the weapon a player attacks with, with the bonus of the
+// final strength and dexterity of the player
+func (g *GameServer) weaponDamage(state *d2hero.HeroState, stats *d2hero.StatEngine) d2combat.DamageRange {
+	weapon := d2hero.EquippedItems(state.Items).Weapon()
+	if weapon == nil {
+		return d2combat.DamageRange{Min: unarmedMinDamage, Max: unarmedMaxDamage}
+	}
+
+	record := weapon.CommonRecord()
+
+	damage := d2combat.DamageRange{Min: record.MinDamage, Max: record.MaxDamage}
+	if damage.Max == 0 {
+		damage = d2combat.DamageRange{Min: record.Min2HandDamage, Max: record.Max2HandDamage}
+	}
+
+	bonus := stats.Attribute(d2enum.AttributeStrength)*record.StrengthBonus/percent +
+		stats.Attribute(d2enum.AttributeDexterity)*record.DexterityBonus/percent
+
+	return d2combat.DamageRange{
+		Min: damage.Min * (percent + bonus) / percent,
+		Max: damage.Max * (percent + bonus) / percent,
+	}
+}
+
+// applyAttack resolves an attack on a combatant. The damage is sent to the players on the map,
+// a combatant without life left dies.
+func (g *GameServer) applyAttack(mapEngine *d2mapengine.MapEngine, attacker, defender *combatant,
+	attack *d2combat.Attack) {
+	hit := d2combat.Resolve(g.rng, attack, &defender.Defender)
+	if hit.Missed {
+		return
+	}
+
+	damage := d2math.MinInt(hit.Damage(), defender.life)
+	defender.life -= damage
+
+	hitRecoveryDivisor := monsterHitRecoveryDivisor
+
+	switch {
+	case defender.client != nil:
+		defender.setVitals(defender.life, defender.mana)
+		hitRecoveryDivisor = playerHitRecoveryDivisor
+	case defender.owner != nil:
+		defender.owner.GetPlayerState().Hireling.Life = defender.life
+	}
+
+	hitRecovery := !defender.isDead() && damage*hitRecoveryDivisor >= defender.maxLife
+
+	damagePacket, err := d2netpacket.CreateDamageEntityPacket(defender.id, attacker.id, damage, defender.life, hitRecovery)
+	if err != nil {
+		g.Errorf("DamageEntityPacket: %v", err)
+		return
+	}
+
+	g.sendPacketToMapEngine(mapEngine, damagePacket)
+
+	if defender.isDead() {
+		g.killCombatant(mapEngine, defender, attacker.id)
+	}
+}
+
+// killCombatant sends the death of a combatant to the players on the map. The corpse of a dead
+// monster stays on the map, where it can be resurrected. A killed monster drops its loot and
+// gives its experience to the players near it and the hirelings on the map. A killed player
+// respawns in town after a delay.
+func (g *GameServer) killCombatant(mapEngine *d2mapengine.MapEngine, defender *combatant, killerID string) {
+	if defender.ai != nil {
+		defender.ai.Reset()
+	}
+
+	killPacket, err := d2netpacket.CreateKillEntityPacket(defender.id, killerID)
+	if err != nil {
+		g.Errorf("KillEntityPacket: %v", err)
+		return
+	}
+
+	g.sendPacketToMapEngine(mapEngine, killPacket)
+
+	switch {
+	case defender.client != nil:
+		defender.respawn = time.Now().Add(respawnDelay)
+	case defender.owner != nil:
+		g.killHireling(defender)
+	case defender.ai != nil:
+		experience := g.monsterExperience(defender)
+
+		g.dropMonsterLoot(mapEngine, defender)
+		g.addPlayerExperience(mapEngine, defender, experience)
+		g.addHirelingExperience(mapEngine, experience)
+	}
+}
+
+// respawnPlayers respawns the dead players whose respawn delay is over at the start position of
+// the town, with full life and mana. The vitals sent to the players on the town map revive the
+// player entity of the respawned player.
+func (g *GameServer) respawnPlayers() {
+	g.stateMutex.Lock()
+	defer g.stateMutex.Unlock()
+
+	now := time.Now()
+
+	for _, client := range g.connections {
+		player, _ := g.playerCombatant(client.GetUniqueID())
+		if player == nil || !player.isDead() || now.Before(player.respawn) {
+			continue
+		}
+
+		player.setVitals(player.maxLife, player.maxMana)
+
+		x, y := g.mapEngines[startLevelID].GetStartPosition()
+		if err := g.movePlayerToLevel(client, startLevelID, d2vector.NewPositionTile(x, y), now); err != nil {
+			g.Errorf("GameServer: error respawning player %s: %v", player.id, err)
+			continue
+		}
+
+		g.sendVitals(g.mapEngines[startLevelID], player)
+	}
+}
+
+// monsterExperience returns the experience a monster gives, the Exp of MonStats.txt scaled by
+// the level of the monster
+func (g *GameServer) monsterExperience(monster *combatant) int {
+	record := monster.npc.MonStats()
+	experience := byDifficulty(g.difficulty, record.ExperienceNormal, record.ExperienceNightmare, record.ExperienceHell)
+
+	if values, found := monsterLevelValues(g.asset.Records, monster.Level, g.difficulty); found {
+		experience = experience * values.experience / percent
+	}
+
+	return experience
+}
@@ -0,0 +1,75 @@
+package d2server
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math/d2vector"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2util"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2asset"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapengine"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2monsterai"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2server/d2tcpclientconnection"
+)
+
+func TestSkillManaCost(t *testing.T) {
+	// 5 mana shifted by 7 costs half of it, 2.5 mana rounded down, and the cost rises by half a
+	// point of mana per level
+	skill := &d2records.SkillRecord{Mana: 5, Lvlmana: 1, Manashift: 7, Minmana: 1}
+
+	tests := []struct {
+		skillLevel, cost int
+	}{
+		{1, 2},
+		{2, 3},
+		{5, 4},
+	}
+
+	for _, test := range tests {
+		if cost := skillManaCost(skill, test.skillLevel); cost != test.cost {
+			t.Errorf("level %d: expected a cost of %d mana, got %d", test.skillLevel, test.cost, cost)
+		}
+	}
+
+	skill.Mana, skill.Lvlmana = 0, 0
+
+	if cost := skillManaCost(skill, 1); cost != 1 {
+		t.Errorf("expected the minimum cost of 1 mana, got %d", cost)
+	}
+}
+
+func TestGameServer_MissileHits(t *testing.T) {
+	asset, err := d2asset.NewAssetManager(d2util.LogLevelNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	asset.Records.Level.Types = d2records.LevelTypes{{}}
+
+	mapEngine := d2mapengine.CreateMapEngine(d2util.LogLevelNone, asset)
+	mapEngine.ResetMap(0, 20, 4)
+
+	rng := rand.New(rand.NewSource(1)) //nolint:gosec // the AI does not need secure randomness
+	player := &combatant{id: "player", life: 1, client: d2tcpclientconnection.CreateTCPClientConnection(nil,
+		"player", d2netpacket.CodecBinary)}
+	g := &GameServer{combatants: map[*d2mapengine.MapEngine]map[string]*combatant{mapEngine: {player.id: player}}}
+
+	// the monsters stand 30 and 50 sub tiles away from the player
+	for id, x := range map[string]float64{"near": 35, "far": 55} {
+		position := d2vector.NewPosition(x, 10)
+		g.combatants[mapEngine][id] = &combatant{id: id, life: 1, ai: d2monsterai.NewMonster(id, position,
+			d2monsterai.Params{}, rng)}
+	}
+
+	// the missile flies 2 sub tiles a frame for 20 frames, 40 sub tiles
+	missile := &d2records.MissileRecord{Name: "bolt", Velocity: 32, Range: 20, Size: 1}
+	origin, target := d2vector.NewPosition(5, 10), d2vector.NewPosition(10, 10)
+
+	hits := g.missileHits(mapEngine, player, missile, origin, target, time.Now())
+	if len(hits) != 1 || hits[0].id != "near" {
+		t.Errorf("expected the missile to only reach the near monster, got %d hits", len(hits))
+	}
+}
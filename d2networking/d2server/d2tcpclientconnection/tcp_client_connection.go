@@ -2,7 +2,6 @@
 package d2tcpclientconnection
 
 import (
-	"encoding/json"
 	"net"
 
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
@@ -15,13 +14,16 @@ import (
 type TCPClientConnection struct {
 	id            string
 	tcpConnection net.Conn
+	encoder       *d2netpacket.PacketEncoder
 	playerState   *d2hero.HeroState
 }
 
-// CreateTCPClientConnection creates a new tcp client connection instance
-func CreateTCPClientConnection(tcpConnection net.Conn, id string) *TCPClientConnection {
+// CreateTCPClientConnection creates a new tcp client connection instance which sends
+// packets using the given codec
+func CreateTCPClientConnection(tcpConnection net.Conn, id string, codec d2netpacket.Codec) *TCPClientConnection {
 	return &TCPClientConnection{
 		tcpConnection: tcpConnection,
+		encoder:       d2netpacket.NewPacketEncoder(codec, tcpConnection),
 		id:            id,
 	}
 }
@@ -33,14 +35,7 @@ func (t TCPClientConnection) GetUniqueID() string {
 
 // SendPacketToClient marshals and sends (writes) NetPackets
 func (t *TCPClientConnection) SendPacketToClient(p d2netpacket.NetPacket) error {
-	encoder := json.NewEncoder(t.tcpConnection)
-
-	err := encoder.Encode(p)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return t.encoder.Encode(p)
 }
 
 // SetPlayerState sets the game client player state
@@ -4,7 +4,6 @@ package d2udpclientconnection
 import (
 	"bytes"
 	"compress/gzip"
-	"encoding/json"
 	"fmt"
 	"net"
 
@@ -61,7 +60,7 @@ func (u UDPClientConnection) GetConnectionType() d2clientconnectiontype.ClientCo
 // SendPacketToClient compresses the JSON encoding of a NetPacket and
 // sends it to the client.
 func (u *UDPClientConnection) SendPacketToClient(packet d2netpacket.NetPacket) error {
-	data, err := json.Marshal(packet.PacketData)
+	data, err := packet.Data()
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,123 @@
+package d2server
+
+import (
+	"time"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapengine"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+)
+
+// partyExperienceRange is how far (in sub tiles) from a killed monster the players share its
+// experience, about two screens
+const partyExperienceRange = 50 * subtilesPerTile
+
+// addPlayerExperience gives the experience of a killed monster to the living players near it.
+// The players in the game are one party, which shares the experience by the levels of the
+// players. The share of a player is scaled by its level difference to the monster and the
+// experience shrine it is under.
+func (g *GameServer) addPlayerExperience(mapEngine *d2mapengine.MapEngine, monster *combatant, experience int) {
+	now := time.Now()
+	monsterPosition := g.combatantPosition(monster, now)
+
+	players := make([]*combatant, 0)
+	levels := make([]int, 0)
+
+	for _, c := range g.combatants[mapEngine] {
+		if c.client == nil || c.isDead() {
+			continue
+		}
+
+		position := g.combatantPosition(c, now)
+		if position.Distance(&monsterPosition.Vector) > partyExperienceRange {
+			continue
+		}
+
+		players = append(players, c)
+		levels = append(levels, c.client.GetPlayerState().Stats.Level)
+	}
+
+	shares := d2hero.PartyExperience(experience, levels)
+
+	for idx, player := range players {
+		state := player.client.GetPlayerState()
+
+		share := d2hero.LevelDifferenceExperience(shares[idx], state.Stats.Level, monster.Level)
+		share = share * (percent + player.states.Stat(statExperiencePercent)) / percent
+
+		levelUp := g.heroStateFactory.AddExperience(state.HeroType, state.Stats, share) > 0
+		if levelUp {
+			player.updatePlayer()
+
+			// a level up refills the final life and mana of the player
+			player.setVitals(player.maxLife, player.maxMana)
+		}
+
+		if err := g.sendStats(player.client, levelUp, ""); err != nil {
+			g.Errorf("GameServer: error sending the stats of %s: %v", player.id, err)
+		}
+	}
+}
+
+// handleSpendPoint spends a stat or a skill point of a player. The stats of the player are sent
+// back, with the reason when the point could not be spent.
+func (g *GameServer) handleSpendPoint(client ClientConnection, packet d2netpacket.NetPacket) error {
+	spend, err := d2netpacket.UnmarshalSpendPoint(packet)
+	if err != nil {
+		return err
+	}
+
+	state := client.GetPlayerState()
+
+	if spend.SkillID > 0 {
+		err = g.heroStateFactory.SpendSkillPoint(state, spend.SkillID)
+	} else {
+		err = g.heroStateFactory.SpendStatPoint(state.HeroType, state.Stats, spend.Attribute)
+	}
+
+	reason := ""
+
+	if err != nil {
+		reason = err.Error()
+		g.Debugf("GameServer: refused point of player %s: %s", client.GetUniqueID(), reason)
+	} else if player, _ := g.playerCombatant(client.GetUniqueID()); player != nil {
+		maxLife, maxMana := player.maxLife, player.maxMana
+		player.updatePlayer()
+
+		// a point spent on vitality or energy adds to the current life and mana as well
+		player.setVitals(player.life+player.maxLife-maxLife, player.mana+player.maxMana-maxMana)
+	}
+
+	return g.sendStats(client, false, reason)
+}
+
+// sendStats sends the stats and the skill points of a player to its client, or to all players
+// when the player leveled up
+func (g *GameServer) sendStats(client ClientConnection, everyone bool, reason string) error {
+	state := client.GetPlayerState()
+	skills := make(map[int]int, len(state.Skills))
+
+	for skillID, skill := range state.Skills {
+		skills[skillID] = skill.SkillPoints
+	}
+
+	updatePacket, err := d2netpacket.CreateUpdateStatsPacket(client.GetUniqueID(), state.Stats, skills, reason)
+	if err != nil {
+		return err
+	}
+
+	if everyone {
+		g.sendPacketToClients(updatePacket)
+		return nil
+	}
+
+	return client.SendPacketToClient(updatePacket)
+}
+
+// updatePlayer sets the combatant of a player to the level of its hero, and derives the final
+// stats of the player again. The life and mana of the player stay the ones of the server.
+func (c *combatant) updatePlayer() {
+	c.Level = c.client.GetPlayerState().Stats.Level
+
+	c.updateDefender()
+}
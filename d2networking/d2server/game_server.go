@@ -2,9 +2,9 @@ package d2server
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"io"
+	"math/rand"
 	"net"
 	"sync"
 	"time"
@@ -12,9 +12,11 @@ import (
 	"github.com/robertkrimen/otto"
 
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math/d2vector"
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2util"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2asset"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2item/diablo2item"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapengine"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapgen"
 	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2client/d2clientconnectiontype"
@@ -31,29 +33,46 @@ const (
 	chunkSize          int = 4096 // nolint:deadcode,unused,varcheck // WIP
 	subtilesPerTile        = 5
 	middleOfTileOffset     = 3
+	startLevelID           = 1 // the Rogue Encampment
 )
 
 var (
 	errPlayerAlreadyExists = errors.New("player already exists")
 	errServerFull          = errors.New("server full") // Server currently at maximum TCP connections
+	errProtocolVersion     = errors.New("client protocol version does not match the server")
+	errInvalidHeroState    = errors.New("invalid hero state")
+	errHeroAlreadyJoined   = errors.New("hero already joined the game")
 )
 
 // GameServer manages a copy of the map and entities as well as manages packet routing and connections.
 // It can accept connections from localhost as well remote clients. It can also be started in a standalone mode.
 type GameServer struct {
 	sync.RWMutex
+	stateMutex        sync.Mutex // Guards the game state, packets and monster ticks are handled in different goroutines
 	connections       map[string]ClientConnection
 	listener          net.Listener
 	networkServer     bool
 	ctx               context.Context
 	cancel            context.CancelFunc
 	asset             *d2asset.AssetManager
-	mapEngines        []*d2mapengine.MapEngine
+	mapEngines        map[int]*d2mapengine.MapEngine // The map engines by level ID, levels may share a map
+	warps             map[int][]d2mapgen.Warp        // The warps of the maps by level ID
+	playerLevels      map[string]int                 // The level IDs of the players
+	difficulty        d2enum.DifficultyType
 	scriptEngine      *d2script.ScriptEngine
 	seed              int64
+	rng               *rand.Rand                                       // The random source of combat rolls
+	combatants        map[*d2mapengine.MapEngine]map[string]*combatant // The players and monsters by map and entity ID
+	logLevel          d2util.LogLevel
 	maxConnections    int
 	packetManagerChan chan ReceivedPacket
 	heroStateFactory  *d2hero.HeroStateFactory
+	itemFactory       *diablo2item.ItemFactory // Rolls the drops of monsters and containers
+	movements         map[string]*playerMovement
+	vendors           map[string]*vendorSession                         // The vendor windows the players have open
+	playerItems       map[string]map[string]*diablo2item.Item           // The items the players own, by item ID
+	groundItems       map[*d2mapengine.MapEngine]map[string]*groundItem // The items on the ground by map and item ID
+	hirelingOffers    map[string]*hirelingOffers                        // The hirelings offered to the players
 
 	*d2util.Logger
 }
@@ -79,12 +98,18 @@ func NewGameServer(asset *d2asset.AssetManager,
 		maxConnections = []int{8}
 	}
 
-	heroStateFactory, err := d2hero.NewHeroStateFactory(asset)
+	heroStateFactory, err := d2hero.NewHeroStateFactory(asset, l)
+	if err != nil {
+		return nil, err
+	}
+
+	itemFactory, err := diablo2item.NewItemFactory(asset)
 	if err != nil {
 		return nil, err
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
+	seed := time.Now().UnixNano()
 
 	gameServer := &GameServer{
 		ctx:               ctx,
@@ -94,29 +119,35 @@ func NewGameServer(asset *d2asset.AssetManager,
 		networkServer:     networkServer,
 		maxConnections:    maxConnections[0],
 		packetManagerChan: make(chan ReceivedPacket),
-		mapEngines:        make([]*d2mapengine.MapEngine, 0),
+		mapEngines:        make(map[int]*d2mapengine.MapEngine),
+		warps:             make(map[int][]d2mapgen.Warp),
+		playerLevels:      make(map[string]int),
+		difficulty:        d2enum.DifficultyNormal,
 		scriptEngine:      d2script.CreateScriptEngine(),
-		seed:              time.Now().UnixNano(),
+		seed:              seed,
+		rng:               rand.New(rand.NewSource(seed)), //nolint:gosec // the rolls do not need to be secure
+		combatants:        make(map[*d2mapengine.MapEngine]map[string]*combatant),
+		logLevel:          l,
 		heroStateFactory:  heroStateFactory,
+		itemFactory:       itemFactory,
+		movements:         make(map[string]*playerMovement),
+		vendors:           make(map[string]*vendorSession),
+		playerItems:       make(map[string]map[string]*diablo2item.Item),
+		groundItems:       make(map[*d2mapengine.MapEngine]map[string]*groundItem),
+		hirelingOffers:    make(map[string]*hirelingOffers),
 	}
 
+	// the drops are rolled from the seed of the game, like the maps
+	itemFactory.SetSeed(seed)
+
 	gameServer.Logger = d2util.NewLogger()
 	gameServer.Logger.SetPrefix(logPrefix)
 	gameServer.Logger.SetLevel(l)
 
-	mapEngine := d2mapengine.CreateMapEngine(l, asset)
-	mapEngine.SetSeed(gameServer.seed)
-	mapEngine.ResetMap(d2enum.RegionAct1Town, 100, 100)
-
-	mapGen, err := d2mapgen.NewMapGenerator(asset, l, mapEngine)
-	if err != nil {
+	if _, err := gameServer.levelMapEngine(startLevelID); err != nil {
 		return nil, err
 	}
 
-	mapGen.GenerateAct1Overworld()
-
-	gameServer.mapEngines = append(gameServer.mapEngines, mapEngine)
-
 	gameServer.scriptEngine.AddFunction("getMapEngines", func(call otto.FunctionCall) otto.Value {
 		val, err := gameServer.scriptEngine.ToValue(gameServer.mapEngines)
 		if err != nil {
@@ -183,6 +214,9 @@ func (g *GameServer) Stop() {
 func (g *GameServer) packetManager() {
 	defer close(g.packetManagerChan)
 
+	monsterTicker := time.NewTicker(monsterTickInterval)
+	defer monsterTicker.Stop()
+
 	for {
 		select {
 		// If the server is stopped we need to clean up the packet manager goroutine
@@ -193,6 +227,10 @@ func (g *GameServer) packetManager() {
 			if err != nil {
 				g.Errorf("failed to handle packet received from client %s: %v", p.Client.GetUniqueID(), err)
 			}
+		case <-monsterTicker.C:
+			g.advanceMonsters()
+			g.expireStates()
+			g.respawnPlayers()
 		}
 	}
 }
@@ -221,12 +259,10 @@ func (g *GameServer) handleConnection(conn net.Conn) {
 		}
 	}()
 
-	decoder := json.NewDecoder(conn)
+	decoder := d2netpacket.NewPacketDecoder(conn)
 
 	for {
-		var packet d2netpacket.NetPacket
-
-		err := decoder.Decode(&packet)
+		packet, err := decoder.Decode()
 		if err != nil {
 			switch err {
 			case io.EOF:
@@ -246,7 +282,7 @@ func (g *GameServer) handleConnection(conn net.Conn) {
 				g.Infof("Closing connection with %s: did not receive new player connection request...", conn.RemoteAddr().String())
 			}
 
-			if client, err = g.registerConnection(packet.PacketData, conn); err != nil {
+			if client, err = g.registerConnection(packet, conn, decoder.Codec()); err != nil {
 				return
 			}
 
@@ -265,12 +301,18 @@ func (g *GameServer) handleConnection(conn net.Conn) {
 	}
 }
 
-// registerConnection accepts a PlayerConnectionRequestPacket and thread safely updates the connection pool
+// registerConnection accepts a PlayerConnectionRequestPacket and thread safely updates the connection pool.
+// Packets are sent back to the client using the same codec the client used for the request.
 //
 // Errors:
 // - errServerFull
+// - errProtocolVersion
 // - errPlayerAlreadyExists
-func (g *GameServer) registerConnection(b []byte, conn net.Conn) (ClientConnection, error) {
+// - errInvalidHeroState
+func (g *GameServer) registerConnection(
+	request d2netpacket.NetPacket,
+	conn net.Conn,
+	codec d2netpacket.Codec) (ClientConnection, error) {
 	var client ClientConnection
 
 	g.Lock()
@@ -283,32 +325,59 @@ func (g *GameServer) registerConnection(b []byte, conn net.Conn) (ClientConnecti
 			g.Errorf("ServerFullPacket: %v", serverFullErr)
 		}
 
-		msf, marshalServerFullErr := d2netpacket.MarshalPacket(sf)
-		if marshalServerFullErr != nil {
-			g.Errorf("MarshalPacket: %v", marshalServerFullErr)
-		}
-
-		_, errServerFullPacket := conn.Write(msf)
+		errServerFullPacket := d2netpacket.NewPacketEncoder(codec, conn).Encode(sf)
 		g.Warningf("%v", errServerFullPacket)
 
 		return client, errServerFull
 	}
 
 	// if it is not full, unmarshal the playerConnectionRequest
-	packet, err := d2netpacket.UnmarshalPlayerConnectionRequest(b)
+	packet, err := d2netpacket.UnmarshalPlayerConnectionRequest(request)
 	if err != nil {
 		g.Errorf("Failed to unmarshal PlayerConnectionRequest: %s\n", err)
 	}
 
+	// JSON clients from before the protocol version was introduced don't send one
+	legacyClient := codec == d2netpacket.CodecJSON && packet.ProtocolVersion == 0
+	if !legacyClient && packet.ProtocolVersion != d2netpacket.ProtocolVersion {
+		g.Errorf("%v: client %d, server %d", errProtocolVersion, packet.ProtocolVersion, d2netpacket.ProtocolVersion)
+		return client, errProtocolVersion
+	}
+
 	// check to see if the player is already registered
 	if _, ok := g.connections[packet.ID]; ok {
 		g.Errorf("%v", errPlayerAlreadyExists)
 		return client, errPlayerAlreadyExists
 	}
 
+	if packet.PlayerState == nil {
+		g.Errorf("%v: client %s did not send a hero state", errInvalidHeroState, packet.ID)
+		return client, errInvalidHeroState
+	}
+
+	// the hero of a remote client is the one saved on the server, the client only picks it
+	playerState, err := g.heroStateFactory.LoadRemoteHeroState(packet.PlayerState.HeroName,
+		packet.PlayerState.HeroType, packet.HeroKey)
+	if err != nil {
+		g.Errorf("%v: client %s: %v", errInvalidHeroState, packet.ID, err)
+		return client, errInvalidHeroState
+	}
+
+	// a hero can only be played by one client at a time
+	for _, other := range g.connections {
+		if other.GetPlayerState().FilePath == playerState.FilePath {
+			g.Errorf("%v: client %s: %s", errHeroAlreadyJoined, packet.ID, playerState.HeroName)
+			return client, errHeroAlreadyJoined
+		}
+	}
+
 	// Client a new TCP Client Connection and add it to the connections map
-	client = d2tcpclientconnection.CreateTCPClientConnection(conn, packet.ID)
-	client.SetPlayerState(packet.PlayerState)
+	client = d2tcpclientconnection.CreateTCPClientConnection(conn, packet.ID, codec)
+	client.SetPlayerState(playerState)
+
+	if err := g.sanitizePlayerState(client); err != nil {
+		return client, err
+	}
 
 	g.OnClientConnected(client)
 
@@ -319,26 +388,43 @@ func (g *GameServer) registerConnection(b []byte, conn net.Conn) (ClientConnecti
 // following packets to the newly connected client: UpdateServerInfoPacket,
 // GenerateMapPacket, AddPlayerPacket.
 //
-// It also sends AddPlayerPackets for each other player entity to the new
-// player and vice versa, so all player entities exist on all clients.
+// It also sends AddPlayerPackets for each other player entity on the same
+// map to the new player and vice versa, so the player entities of a map
+// exist on all clients which are on it. The monsters of the map which are
+// dead or were moved are sent to the new player as well.
 //
 // For more information, see d2networking.d2netpacket.
 func (g *GameServer) OnClientConnected(client ClientConnection) {
+	g.stateMutex.Lock()
+	defer g.stateMutex.Unlock()
+
+	clientPlayerState := client.GetPlayerState()
+
+	// the levels are generated for the difficulty of the host
+	if client.GetConnectionType() == d2clientconnectiontype.Local && g.difficulty != clientPlayerState.Difficulty {
+		g.difficulty = clientPlayerState.Difficulty
+		g.resetLevels()
+	}
+
 	// Temporary position hack --------------------------------------------
 	// https://github.com/OpenDiablo2/OpenDiablo2/issues/829
-	sx, sy := g.mapEngines[0].GetStartPosition()
-	clientPlayerState := client.GetPlayerState()
+	sx, sy := g.mapEngines[startLevelID].GetStartPosition()
 	clientPlayerState.X = sx
 	clientPlayerState.Y = sy
 	// --------------------------------------------------------------------
 
 	g.Infof("Client connected with an id of %s", client.GetUniqueID())
 	g.connections[client.GetUniqueID()] = client
+	g.movements[client.GetUniqueID()] = newPlayerMovement(d2vector.NewPositionTile(sx, sy), nil, time.Now())
+	g.playerLevels[client.GetUniqueID()] = startLevelID
+	g.loadPlayerItems(client)
+	player := newPlayerCombatant(client, g.difficulty, g.heroStateFactory, g.asset.Records)
+	g.combatants[g.mapEngines[startLevelID]][client.GetUniqueID()] = player
 
-	g.handleClientConnection(client, sx, sy)
+	g.handleClientConnection(client)
 }
 
-func (g *GameServer) handleClientConnection(client ClientConnection, x, y float64) {
+func (g *GameServer) handleClientConnection(client ClientConnection) {
 	usi, err := d2netpacket.CreateUpdateServerInfoPacket(g.seed, client.GetUniqueID())
 	if err != nil {
 		g.Errorf("UpdateServerInfoPacket: %v", err)
@@ -349,7 +435,7 @@ func (g *GameServer) handleClientConnection(client ClientConnection, x, y float6
 		g.Errorf("GameServer: error sending UpdateServerInfoPacket to client %s: %s", client.GetUniqueID(), err)
 	}
 
-	gmp, err := d2netpacket.CreateGenerateMapPacket(d2enum.RegionAct1Town)
+	gmp, err := d2netpacket.CreateGenerateMapPacket(d2enum.RegionAct1Town, g.difficulty)
 	if err != nil {
 		g.Errorf("GenerateMapPacket: %v", err)
 	}
@@ -359,58 +445,48 @@ func (g *GameServer) handleClientConnection(client ClientConnection, x, y float6
 		g.Errorf("GameServer: error sending GenerateMapPacket to client %s: %s", client.GetUniqueID(), err)
 	}
 
-	playerState := client.GetPlayerState()
+	d2hero.HydrateSkills(client.GetPlayerState().Skills, g.asset)
 
-	// these are in subtiles
-	playerX := int(x*subtilesPerTile) + middleOfTileOffset
-	playerY := int(y*subtilesPerTile) + middleOfTileOffset
+	createPlayerPacket, err := g.createAddPlayerPacket(client)
+	if err != nil {
+		g.Errorf("AddPlayerPacket: %v", err)
+	}
 
-	d2hero.HydrateSkills(playerState.Skills, g.asset)
+	err = client.SendPacketToClient(createPlayerPacket)
+	if err != nil {
+		g.Errorf("GameServer: error sending %T to client %s: %s", createPlayerPacket, client.GetUniqueID(), err)
+	}
 
-	createPlayerPacket, err := d2netpacket.CreateAddPlayerPacket(
-		client.GetUniqueID(),
-		playerState.HeroName,
-		playerX,
-		playerY,
-		playerState.HeroType,
-		playerState.Stats,
-		playerState.Skills,
-		playerState.Equipment,
-		playerState.LeftSkill,
-		playerState.RightSkill,
-		playerState.Gold,
-	)
+	g.addPlayerToMap(client)
+
+	if player, mapEngine := g.playerCombatant(client.GetUniqueID()); player != nil {
+		g.sendMonsters(mapEngine, client)
+		g.sendStates(mapEngine, player)
+		g.spawnHireling(mapEngine, client)
+		g.sendHirelings(mapEngine, client)
+		g.sendGroundItems(mapEngine, client)
+	}
+}
+
+// addPlayerToMap sends an AddPlayerPacket of the client to every other player on the map of
+// the client, and an AddPlayerPacket of every other player on the map to the client.
+func (g *GameServer) addPlayerToMap(client ClientConnection) {
+	createPlayerPacket, err := g.createAddPlayerPacket(client)
 	if err != nil {
 		g.Errorf("AddPlayerPacket: %v", err)
 	}
 
 	for _, connection := range g.connections {
+		if connection.GetUniqueID() == client.GetUniqueID() || !g.onSameMap(client.GetUniqueID(), connection.GetUniqueID()) {
+			continue
+		}
+
 		err := connection.SendPacketToClient(createPlayerPacket)
 		if err != nil {
 			g.Errorf("GameServer: error sending %T to client %s: %s", createPlayerPacket, connection.GetUniqueID(), err)
 		}
 
-		if connection.GetUniqueID() == client.GetUniqueID() {
-			continue
-		}
-
-		conPlayerState := connection.GetPlayerState()
-		playerX := int(conPlayerState.X*subtilesPerTile) + middleOfTileOffset
-		playerY := int(conPlayerState.Y*subtilesPerTile) + middleOfTileOffset
-		app, err := d2netpacket.CreateAddPlayerPacket(
-			connection.GetUniqueID(),
-			conPlayerState.HeroName,
-			playerX,
-			playerY,
-			conPlayerState.HeroType,
-			conPlayerState.Stats,
-			conPlayerState.Skills,
-			conPlayerState.Equipment,
-			conPlayerState.LeftSkill,
-			conPlayerState.RightSkill,
-			conPlayerState.Gold,
-		)
-
+		app, err := g.createAddPlayerPacket(connection)
 		if err != nil {
 			g.Errorf("AddPlayerPacket: %v", err)
 		}
@@ -423,12 +499,44 @@ func (g *GameServer) handleClientConnection(client ClientConnection, x, y float6
 	}
 }
 
+func (g *GameServer) createAddPlayerPacket(client ClientConnection) (d2netpacket.NetPacket, error) {
+	playerState := client.GetPlayerState()
+
+	// these are in subtiles
+	playerX := int(playerState.X*subtilesPerTile) + middleOfTileOffset
+	playerY := int(playerState.Y*subtilesPerTile) + middleOfTileOffset
+
+	return d2netpacket.CreateAddPlayerPacket(
+		client.GetUniqueID(),
+		playerState.HeroName,
+		playerX,
+		playerY,
+		playerState.HeroType,
+		playerState.Stats,
+		playerState.Skills,
+		playerState.LeftSkill,
+		playerState.RightSkill,
+		playerState.Gold,
+		playerState.Items,
+	)
+}
+
 // OnClientDisconnected removes the given client from the list
 // of client connections.
 // If this client was the host, disconnects all clients and kills GameServer.
 func (g *GameServer) OnClientDisconnected(client ClientConnection) {
 	g.Infof("Client disconnected with an id of %s", client.GetUniqueID())
+
+	g.stateMutex.Lock()
 	delete(g.connections, client.GetUniqueID())
+	delete(g.movements, client.GetUniqueID())
+	delete(g.vendors, client.GetUniqueID())
+	delete(g.playerItems, client.GetUniqueID())
+	delete(g.hirelingOffers, client.GetUniqueID())
+	delete(g.combatants[g.mapEngines[g.playerLevels[client.GetUniqueID()]]], client.GetUniqueID())
+	delete(g.combatants[g.mapEngines[g.playerLevels[client.GetUniqueID()]]], hirelingEntityID(client.GetUniqueID()))
+	delete(g.playerLevels, client.GetUniqueID())
+	g.stateMutex.Unlock()
 
 	if client.GetConnectionType() == d2clientconnectiontype.Local {
 		g.Info("Host disconnected, game server shuting down")
@@ -452,37 +560,35 @@ func (g *GameServer) OnPacketReceived(client ClientConnection, packet d2netpacke
 		return errors.New("game server is nil")
 	}
 
+	// a disconnection locks the state by itself
+	if packet.PacketType != d2netpackettype.PlayerDisconnectionNotification {
+		g.stateMutex.Lock()
+		defer g.stateMutex.Unlock()
+	}
+
 	switch packet.PacketType {
 	case d2netpackettype.MovePlayer:
-		movePacket, err := d2netpacket.UnmarshalMovePlayer(packet.PacketData)
-		if err != nil {
-			return err
-		}
-
-		playerState := g.connections[client.GetUniqueID()].GetPlayerState()
-		playerState.X = movePacket.DestX
-		playerState.Y = movePacket.DestY
-
-		g.sendPacketToClients(packet)
-	case d2netpackettype.CastSkill, d2netpackettype.SpawnItem:
-		g.sendPacketToClients(packet)
+		return g.handleMovePlayer(client, packet)
+	case d2netpackettype.CastSkill:
+		return g.handleCastSkill(client, packet)
+	case d2netpackettype.SpawnItem:
+		return g.handleSpawnItem(client, packet)
+	case d2netpackettype.ChangeLevel:
+		return g.handleChangeLevel(client, packet)
+	case d2netpackettype.OperateObject:
+		return g.handleOperateObject(client, packet)
+	case d2netpackettype.PickupItem:
+		return g.handlePickupItem(client, packet)
+	case d2netpackettype.OpenVendor:
+		return g.handleOpenVendor(client, packet)
+	case d2netpackettype.TradeItem:
+		return g.handleTradeItem(client, packet)
+	case d2netpackettype.HirelingAction:
+		return g.handleHirelingAction(client, packet)
+	case d2netpackettype.SpendPoint:
+		return g.handleSpendPoint(client, packet)
 	case d2netpackettype.SavePlayer:
-		savePacket, err := d2netpacket.UnmarshalSavePlayer(packet.PacketData)
-		if err != nil {
-			return err
-		}
-
-		playerState := g.connections[client.GetUniqueID()].GetPlayerState()
-		playerState.LeftSkill = savePacket.Player.LeftSkill.Shallow.SkillID
-		playerState.RightSkill = savePacket.Player.RightSkill.Shallow.SkillID
-		playerState.Stats = savePacket.Player.Stats
-		playerState.Act = savePacket.Player.Act
-		playerState.Difficulty = savePacket.Difficulty
-
-		err = g.heroStateFactory.Save(playerState)
-		if err != nil {
-			g.Errorf("GameServer: error saving saving Player: %s", err)
-		}
+		return g.handleSavePlayer(client, packet)
 	case d2netpackettype.PlayerConnectionRequest:
 		break // prevent log message. these are handled by handleConnection
 	case d2netpackettype.PlayerDisconnectionNotification:
@@ -0,0 +1,542 @@
+package d2server
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2combat"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hireling"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2item/diablo2item"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapengine"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapentity"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2monsterai"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2state"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+)
+
+// the reasons a hireling action is refused, sent to the client in the UpdateHirelingPacket
+const (
+	hirelingErrorNoSeller    = "no hireling seller"
+	hirelingErrorNoOffer     = "unknown hireling"
+	hirelingErrorNoGold      = "not enough gold"
+	hirelingErrorNoHireling  = "no hireling"
+	hirelingErrorNotDead     = "hireling is not dead"
+	hirelingErrorNoItem      = "unknown item"
+	hirelingErrorCannotEquip = "hireling cannot equip the item"
+	hirelingErrorAction      = "unknown hireling action"
+)
+
+// skillRangeNone is the Range of Skills.txt of auras and passive skills, which hirelings do not
+// attack with
+const skillRangeNone = "none"
+
+// hirelingOffers are the hirelings a town NPC offers a player. The offers are rolled when the
+// player lists them, so an NPC offers new hirelings on every visit.
+type hirelingOffers struct {
+	npcID     string
+	hirelings map[string]*d2hireling.Hireling // the offered hirelings, by offer ID
+}
+
+// hirelingEntityID returns the ID of the entity of the hireling of a player
+func hirelingEntityID(ownerID string) string {
+	return "hireling-" + ownerID
+}
+
+// hirelingSeller returns the NPC if it sells hirelings in Hireling.txt and is within the reach
+// of the player, otherwise nil
+func (g *GameServer) hirelingSeller(client ClientConnection, mapEngine *d2mapengine.MapEngine,
+	npcID string) *d2mapentity.NPC {
+	npc := g.townNPC(client, mapEngine, npcID)
+	if npc == nil {
+		return nil
+	}
+
+	for _, record := range g.asset.Records.Hireling.Details {
+		if record.Seller == npc.MonStats().ID {
+			return npc
+		}
+	}
+
+	return nil
+}
+
+// handleHirelingAction lists the hirelings of a town NPC, hires or resurrects a hireling, or
+// equips the hireling of the player. The hireling and the gold of the player are kept in the
+// hero state of the server, the client is sent both after the action.
+func (g *GameServer) handleHirelingAction(client ClientConnection, packet d2netpacket.NetPacket) error {
+	action, err := d2netpacket.UnmarshalHirelingAction(packet)
+	if err != nil {
+		return err
+	}
+
+	player, mapEngine := g.playerCombatant(client.GetUniqueID())
+	if player == nil || player.isDead() || action.PlayerID != client.GetUniqueID() {
+		return nil
+	}
+
+	if action.Action == d2enum.HirelingList {
+		return g.listHirelings(client, mapEngine, action.NPCID)
+	}
+
+	var (
+		itemID string
+		item   *diablo2item.Item
+		reason string
+	)
+
+	switch action.Action {
+	case d2enum.HirelingHire:
+		reason = g.hireHireling(client, mapEngine, action.OfferID)
+	case d2enum.HirelingResurrect:
+		reason = g.resurrectHireling(client, mapEngine, action.NPCID)
+	case d2enum.HirelingEquip:
+		itemID, item, reason = g.equipHireling(client, action.ItemID, action.Slot)
+	case d2enum.HirelingUnequip:
+		itemID, item, reason = g.unequipHireling(client, action.Slot)
+	default:
+		reason = hirelingErrorAction
+	}
+
+	if reason != "" {
+		g.Debugf("GameServer: refused hireling action of player %s: %s", client.GetUniqueID(), reason)
+
+		errorPacket, err := d2netpacket.CreateHirelingErrorPacket(client.GetUniqueID(), action.Action,
+			client.GetPlayerState().Hireling, client.GetPlayerState().Gold, reason)
+		if err != nil {
+			return err
+		}
+
+		return client.SendPacketToClient(errorPacket)
+	}
+
+	return g.sendHireling(client, action.Action, itemID, item)
+}
+
+// sendHireling sends the hireling and the gold of a player to its client, with the item the
+// player got back from the hireling if any
+func (g *GameServer) sendHireling(client ClientConnection, action d2enum.HirelingAction, itemID string,
+	item *diablo2item.Item) error {
+	var codes []string
+	if item != nil {
+		codes = item.Codes()
+	}
+
+	updatePacket, err := d2netpacket.CreateUpdateHirelingPacket(client.GetUniqueID(), action,
+		client.GetPlayerState().Hireling, client.GetPlayerState().Gold, itemID, codes)
+	if err != nil {
+		return err
+	}
+
+	return client.SendPacketToClient(updatePacket)
+}
+
+// listHirelings rolls the hirelings a town NPC offers the player and sends them to its client
+func (g *GameServer) listHirelings(client ClientConnection, mapEngine *d2mapengine.MapEngine, npcID string) error {
+	npc := g.hirelingSeller(client, mapEngine, npcID)
+	if npc == nil {
+		return nil
+	}
+
+	records := g.asset.Records.Hireling.Details
+	hirelings := d2hireling.Offers(records, npc.MonStats().ID, g.difficulty, client.GetPlayerState().Stats.Level,
+		g.rng)
+
+	offers := &hirelingOffers{npcID: npcID, hirelings: make(map[string]*d2hireling.Hireling)}
+	packetOffers := make([]d2netpacket.HirelingOffer, len(hirelings))
+
+	for idx, hireling := range hirelings {
+		id := uuid.New().String()
+		offers.hirelings[id] = hireling
+		packetOffers[idx] = d2netpacket.HirelingOffer{ID: id, Hireling: *hireling, Price: hireling.HirePrice(records)}
+	}
+
+	g.hirelingOffers[client.GetUniqueID()] = offers
+
+	offersPacket, err := d2netpacket.CreateHirelingOffersPacket(npcID, packetOffers)
+	if err != nil {
+		return err
+	}
+
+	return client.SendPacketToClient(offersPacket)
+}
+
+// hireHireling hires a hireling offered to the player, which replaces the hireling the player
+// had, with the items it wore
+func (g *GameServer) hireHireling(client ClientConnection, mapEngine *d2mapengine.MapEngine,
+	offerID string) (reason string) {
+	offers := g.hirelingOffers[client.GetUniqueID()]
+	if offers == nil || g.hirelingSeller(client, mapEngine, offers.npcID) == nil {
+		return hirelingErrorNoSeller
+	}
+
+	hireling := offers.hirelings[offerID]
+	if hireling == nil {
+		return hirelingErrorNoOffer
+	}
+
+	playerState := client.GetPlayerState()
+
+	price := hireling.HirePrice(g.asset.Records.Hireling.Details)
+	if playerState.Gold < price {
+		return hirelingErrorNoGold
+	}
+
+	playerState.Gold -= price
+	playerState.Hireling = hireling
+
+	delete(g.hirelingOffers, client.GetUniqueID())
+	delete(g.combatants[mapEngine], hirelingEntityID(client.GetUniqueID()))
+	g.spawnHireling(mapEngine, client)
+
+	return ""
+}
+
+// resurrectHireling brings the dead hireling of the player back to life at a town NPC which
+// sells hirelings
+func (g *GameServer) resurrectHireling(client ClientConnection, mapEngine *d2mapengine.MapEngine,
+	npcID string) (reason string) {
+	if g.hirelingSeller(client, mapEngine, npcID) == nil {
+		return hirelingErrorNoSeller
+	}
+
+	playerState := client.GetPlayerState()
+
+	hireling := playerState.Hireling
+	if hireling == nil {
+		return hirelingErrorNoHireling
+	}
+
+	if !hireling.Dead {
+		return hirelingErrorNotDead
+	}
+
+	records := g.asset.Records.Hireling.Details
+
+	price := hireling.ResurrectPrice(records)
+	if playerState.Gold < price {
+		return hirelingErrorNoGold
+	}
+
+	playerState.Gold -= price
+	hireling.Resurrect(records)
+
+	delete(g.combatants[mapEngine], hirelingEntityID(client.GetUniqueID()))
+	g.spawnHireling(mapEngine, client)
+
+	return ""
+}
+
+// equipHireling puts an item the server keeps for the player on its hireling. The item the
+// hireling wore in the slot goes back to the player, its ID is returned.
+func (g *GameServer) equipHireling(client ClientConnection, itemID string,
+	slot d2enum.EquippedSlot) (returnedID string, returned *diablo2item.Item, reason string) {
+	hireling := client.GetPlayerState().Hireling
+	if hireling == nil || hireling.Dead {
+		return "", nil, hirelingErrorNoHireling
+	}
+
+	item := g.playerItems[client.GetUniqueID()][itemID]
+	if item == nil {
+		return "", nil, hirelingErrorNoItem
+	}
+
+	if !hireling.CanEquip(g.asset.Records, slot, item.CommonRecord()) {
+		return "", nil, hirelingErrorCannotEquip
+	}
+
+	returnedID, returned = g.takeHirelingItem(client, slot)
+
+	if hireling.Equipment == nil {
+		hireling.Equipment = make(map[d2enum.EquippedSlot][]string)
+	}
+
+	hireling.Equipment[slot] = item.Codes()
+	g.takeItem(client, itemID)
+
+	g.updateHirelingCombatant(client)
+
+	return returnedID, returned, ""
+}
+
+// unequipHireling takes the item the hireling of the player wears in a slot back to the player
+func (g *GameServer) unequipHireling(client ClientConnection,
+	slot d2enum.EquippedSlot) (itemID string, item *diablo2item.Item, reason string) {
+	hireling := client.GetPlayerState().Hireling
+	if hireling == nil || hireling.Dead {
+		return "", nil, hirelingErrorNoHireling
+	}
+
+	itemID, item = g.takeHirelingItem(client, slot)
+	if item == nil {
+		return "", nil, hirelingErrorNoItem
+	}
+
+	g.updateHirelingCombatant(client)
+
+	return itemID, item, ""
+}
+
+// takeHirelingItem removes the item the hireling of the player wears in a slot and keeps it for
+// the player under a new ID. Nothing is returned if the hireling wears nothing in the slot.
+func (g *GameServer) takeHirelingItem(client ClientConnection, slot d2enum.EquippedSlot) (string, *diablo2item.Item) {
+	hireling := client.GetPlayerState().Hireling
+
+	codes := hireling.Equipment[slot]
+	if len(codes) == 0 {
+		return "", nil
+	}
+
+	delete(hireling.Equipment, slot)
+
+	item, err := g.itemFactory.NewItem(codes...)
+	if err != nil {
+		g.Errorf("GameServer: error creating the item %v of the hireling of %s: %v", codes, client.GetUniqueID(), err)
+		return "", nil
+	}
+
+	itemID := uuid.New().String()
+	g.giveItem(client, itemID, item)
+
+	return itemID, item
+}
+
+// hirelingMonster returns the record of MonStats.txt of the hireling of a row of Hireling.txt,
+// its hcIdx is the Class of the row
+func (g *GameServer) hirelingMonster(record *d2records.HirelingRecord) *d2records.MonStatRecord {
+	for _, monstat := range g.asset.Records.Monster.Stats {
+		if monstat.ID == record.Class {
+			return monstat
+		}
+	}
+
+	return nil
+}
+
+// spawnHireling adds the living hireling of a player to the map, next to the player, and sends
+// it to the players on the map
+func (g *GameServer) spawnHireling(mapEngine *d2mapengine.MapEngine, owner ClientConnection) {
+	hireling := owner.GetPlayerState().Hireling
+	if hireling == nil || hireling.Dead {
+		return
+	}
+
+	record := hireling.Record(g.asset.Records.Hireling.Details)
+	if record == nil {
+		return
+	}
+
+	monstat := g.hirelingMonster(record)
+	if monstat == nil {
+		g.Warningf("GameServer: no monster for the hireling class %d", record.Class)
+		return
+	}
+
+	id := hirelingEntityID(owner.GetUniqueID())
+	position := g.estimatePosition(owner, time.Now())
+
+	npc, err := mapEngine.NewNPCWithID(id, int(position.X()), int(position.Y()), monstat, 0)
+	if err != nil {
+		g.Errorf("GameServer: error creating the hireling of %s: %v", owner.GetUniqueID(), err)
+		return
+	}
+
+	params := d2monsterai.NewFollowerParams(monstat, g.difficulty)
+	aiRNG := rand.New(rand.NewSource(g.rng.Int63())) //nolint:gosec // the AI does not need secure randomness
+
+	c := &combatant{
+		Defender:        d2combat.Defender{Resistances: make(map[d2combat.Element]int)},
+		id:              id,
+		life:            hireling.Life,
+		owner:           owner,
+		npc:             npc,
+		ai:              d2monsterai.NewMonster(id, npc.GetPosition(), params, aiRNG),
+		states:          d2state.NewManager(),
+		baseResistances: make(map[d2combat.Element]int),
+	}
+
+	c.ai.LeaderID = owner.GetUniqueID()
+	g.combatants[mapEngine][id] = c
+	g.updateHirelingCombatant(owner)
+
+	if spawnPacket, err := g.createSpawnHirelingPacket(c); err != nil {
+		g.Errorf("SpawnHirelingPacket: %v", err)
+	} else {
+		g.sendPacketToMapEngine(mapEngine, spawnPacket)
+	}
+}
+
+// sendHirelings sends the living hirelings of the other players on the map to the client
+func (g *GameServer) sendHirelings(mapEngine *d2mapengine.MapEngine, client ClientConnection) {
+	for _, c := range g.combatants[mapEngine] {
+		if c.owner == nil || c.owner == client || c.isDead() {
+			continue
+		}
+
+		spawnPacket, err := g.createSpawnHirelingPacket(c)
+		if err != nil {
+			g.Errorf("SpawnHirelingPacket: %v", err)
+			continue
+		}
+
+		if err := client.SendPacketToClient(spawnPacket); err != nil {
+			g.Errorf("GameServer: error sending %s to client %s: %s", spawnPacket.PacketType, client.GetUniqueID(), err)
+		}
+	}
+}
+
+func (g *GameServer) createSpawnHirelingPacket(c *combatant) (d2netpacket.NetPacket, error) {
+	world := c.ai.Position.World()
+
+	return d2netpacket.CreateSpawnHirelingPacket(c.owner.GetUniqueID(), c.id, c.npc.MonStats().Key,
+		c.owner.GetPlayerState().Hireling.Name, world.X(), world.Y())
+}
+
+// updateHirelingCombatant sets the combatant of the hireling of a player to the stats of the
+// hireling at its level and the items it wears
+func (g *GameServer) updateHirelingCombatant(owner ClientConnection) {
+	mapEngine := g.mapEngines[g.playerLevels[owner.GetUniqueID()]]
+
+	c := g.combatants[mapEngine][hirelingEntityID(owner.GetUniqueID())]
+	if c == nil {
+		return
+	}
+
+	hireling := owner.GetPlayerState().Hireling
+	stats := hireling.Stats(g.asset.Records.Hireling.Details)
+
+	c.Level = hireling.Level
+	c.maxLife = stats.Life
+	c.life = hireling.Life
+	c.baseDefense = stats.Defense
+
+	for _, codes := range hireling.Equipment {
+		if len(codes) == 0 {
+			continue
+		}
+
+		if record := g.asset.Records.Item.Armors[codes[0]]; record != nil {
+			c.baseDefense += (record.MinAC + record.MaxAC) / 2 //nolint:gomnd // the average of the armor class
+		}
+	}
+
+	for _, element := range []d2combat.Element{d2combat.ElementFire, d2combat.ElementLightning,
+		d2combat.ElementCold, d2combat.ElementPoison} {
+		c.baseResistances[element] = stats.Resistance
+	}
+
+	c.updateDefender()
+}
+
+// hirelingAttack returns the attack of a hireling: one of the skills of Hireling.txt picked by
+// its chance against the DefaultChance of a normal attack. The damage and the attack rating are
+// those of Hireling.txt at the level of the hireling, plus the damage of its weapon.
+func (g *GameServer) hirelingAttack(c *combatant) *d2combat.Attack {
+	records := g.asset.Records
+	hireling := c.owner.GetPlayerState().Hireling
+	stats := hireling.Stats(records.Hireling.Details)
+
+	weapon := d2combat.DamageRange{Min: stats.MinDamage, Max: stats.MaxDamage}
+	if codes := hireling.Equipment[d2enum.EquippedSlotRightArm]; len(codes) > 0 {
+		if record := records.Item.Weapons[codes[0]]; record != nil {
+			damage := d2combat.DamageRange{Min: record.MinDamage, Max: record.MaxDamage}
+			if damage.Max == 0 {
+				damage = d2combat.DamageRange{Min: record.Min2HandDamage, Max: record.Max2HandDamage}
+			}
+
+			if damage.Max == 0 {
+				damage = d2combat.DamageRange{Min: record.MinMissileDamage, Max: record.MaxMissileDamage}
+			}
+
+			weapon.Min += damage.Min
+			weapon.Max += damage.Max
+		}
+	}
+
+	skill, skillLevel := g.rollHirelingSkill(hireling)
+	if skill == nil {
+		return &d2combat.Attack{Level: hireling.Level, AttackRating: stats.AttackRating, Physical: weapon}
+	}
+
+	return skillAttack(skill, nil, skillLevel, hireling.Level, stats.AttackRating, 0, weapon)
+}
+
+// rollHirelingSkill picks the skill a hireling attacks with, or nil for a normal attack
+func (g *GameServer) rollHirelingSkill(hireling *d2hireling.Hireling) (*d2records.SkillRecord, int) {
+	records := g.asset.Records
+
+	record := hireling.Record(records.Hireling.Details)
+	if record == nil {
+		return nil, 0
+	}
+
+	type usableSkill struct {
+		record *d2records.SkillRecord
+		skill  d2hireling.Skill
+	}
+
+	total := record.DefaultChance
+	usable := make([]usableSkill, 0)
+
+	for _, skill := range hireling.Skills(records.Hireling.Details) {
+		skillRecord := records.GetSkillByName(skill.Name)
+		if skillRecord == nil || skillRecord.Range == skillRangeNone || skill.Chance <= 0 {
+			continue
+		}
+
+		total += skill.Chance
+		usable = append(usable, usableSkill{record: skillRecord, skill: skill})
+	}
+
+	if total <= 0 {
+		return nil, 0
+	}
+
+	roll := g.rng.Intn(total)
+
+	for _, u := range usable {
+		if roll < u.skill.Chance {
+			return u.record, u.skill.Level
+		}
+
+		roll -= u.skill.Chance
+	}
+
+	return nil, 0
+}
+
+// killHireling marks the hireling of a combatant as dead and sends its death to its owner. The
+// corpse stays on the map until the player leaves it, the hireling is resurrected in town.
+func (g *GameServer) killHireling(c *combatant) {
+	hireling := c.owner.GetPlayerState().Hireling
+	hireling.Dead = true
+	hireling.Life = 0
+
+	if err := g.sendHireling(c.owner, d2enum.HirelingDeath, "", nil); err != nil {
+		g.Errorf("GameServer: error sending the death of the hireling of %s: %v", c.owner.GetUniqueID(), err)
+	}
+}
+
+// addHirelingExperience gives the experience of a killed monster to the living hirelings on the
+// map, the hirelings which level up are sent to their owners
+func (g *GameServer) addHirelingExperience(mapEngine *d2mapengine.MapEngine, experience int) {
+	for _, c := range g.combatants[mapEngine] {
+		if c.owner == nil || c.isDead() {
+			continue
+		}
+
+		hireling := c.owner.GetPlayerState().Hireling
+		if hireling.AddExperience(g.asset.Records.Hireling.Details, experience) == 0 {
+			continue
+		}
+
+		g.updateHirelingCombatant(c.owner)
+
+		if err := g.sendHireling(c.owner, d2enum.HirelingLevelUp, "", nil); err != nil {
+			g.Errorf("GameServer: error sending the hireling of %s: %v", c.owner.GetUniqueID(), err)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package d2server
+
+import (
+	"time"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math/d2vector"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapengine"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapgen"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+)
+
+// warpUseTolerance is how far (in sub tiles) the position of a player entity estimated by the
+// server may be from the select area of a warp when the client uses it. It covers the latency
+// between both sides, like the slack of the start of a move.
+const warpUseTolerance = moveStartSlack
+
+// levelMapEngine returns the map engine of the given level. The map of a level is generated
+// the first time a player enters it, and is shared by the levels generated with it.
+func (g *GameServer) levelMapEngine(levelID int) (*d2mapengine.MapEngine, error) {
+	if mapEngine, found := g.mapEngines[levelID]; found {
+		return mapEngine, nil
+	}
+
+	mapEngine := d2mapengine.CreateMapEngine(g.logLevel, g.asset)
+	mapEngine.SetSeed(g.seed)
+
+	mapGen, err := d2mapgen.NewMapGenerator(g.asset, g.logLevel, mapEngine)
+	if err != nil {
+		return nil, err
+	}
+
+	warps, err := mapGen.GenerateLevel(levelID, g.difficulty)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range d2mapgen.LevelsOnMap(levelID) {
+		g.mapEngines[id] = mapEngine
+		g.warps[id] = warps
+	}
+
+	g.addMonsters(mapEngine)
+
+	return mapEngine, nil
+}
+
+// resetLevels drops the levels generated so far and generates the start level again, when the
+// difficulty changed before any player entered them
+func (g *GameServer) resetLevels() {
+	// the map engines are shared with the script engine, so the map is cleared in place
+	for levelID := range g.mapEngines {
+		delete(g.mapEngines, levelID)
+	}
+
+	g.warps = make(map[int][]d2mapgen.Warp)
+	g.combatants = make(map[*d2mapengine.MapEngine]map[string]*combatant)
+	g.groundItems = make(map[*d2mapengine.MapEngine]map[string]*groundItem)
+
+	if _, err := g.levelMapEngine(startLevelID); err != nil {
+		g.Errorf("GameServer: error generating the start level: %v", err)
+	}
+}
+
+// onSameMap returns true if both players are on the same map, so they see each other
+func (g *GameServer) onSameMap(playerID, otherPlayerID string) bool {
+	mapEngine, found := g.mapEngines[g.playerLevels[playerID]]
+
+	return found && mapEngine == g.mapEngines[g.playerLevels[otherPlayerID]]
+}
+
+// sendPacketToMap sends the packet to every player on the map of the given player
+func (g *GameServer) sendPacketToMap(playerID string, packet d2netpacket.NetPacket) {
+	if mapEngine, found := g.mapEngines[g.playerLevels[playerID]]; found {
+		g.sendPacketToMapEngine(mapEngine, packet)
+	}
+}
+
+// sendPacketToMapEngine sends the packet to every player on the map
+func (g *GameServer) sendPacketToMapEngine(mapEngine *d2mapengine.MapEngine, packet d2netpacket.NetPacket) {
+	for _, c := range g.connections {
+		if g.mapEngines[g.playerLevels[c.GetUniqueID()]] != mapEngine {
+			continue
+		}
+
+		if err := c.SendPacketToClient(packet); err != nil {
+			g.Errorf("GameServer: error sending packet: %s to client %s: %s", packet.PacketType, c.GetUniqueID(), err)
+		}
+	}
+}
+
+// findWarp returns the warp from the map of a level to the other level
+func (g *GameServer) findWarp(fromLevelID, toLevelID int) *d2mapgen.Warp {
+	warps := g.warps[fromLevelID]
+
+	for idx := range warps {
+		if warps[idx].LevelID == toLevelID {
+			return &warps[idx]
+		}
+	}
+
+	return nil
+}
+
+// arrivalPosition returns the position a player entity arrives at in a level, when it comes
+// from the other level. It is the exit of the warp back to the other level, or the start
+// position of the map if there is none.
+func (g *GameServer) arrivalPosition(levelID, fromLevelID int) d2vector.Position {
+	for _, id := range d2mapgen.LevelsOnMap(fromLevelID) {
+		if warp := g.findWarp(levelID, id); warp != nil {
+			return d2vector.NewPosition(float64(warp.ExitX), float64(warp.ExitY))
+		}
+	}
+
+	x, y := g.mapEngines[levelID].GetStartPosition()
+
+	return d2vector.NewPositionTile(x, y)
+}
+
+// handleChangeLevel moves a player to another level, if the level is linked to the level of
+// the player and the living player entity is at the warp
+func (g *GameServer) handleChangeLevel(client ClientConnection, packet d2netpacket.NetPacket) error {
+	changeLevelPacket, err := d2netpacket.UnmarshalChangeLevel(packet)
+	if err != nil {
+		return err
+	}
+
+	playerID := client.GetUniqueID()
+	if changeLevelPacket.PlayerID != playerID {
+		g.Warningf("GameServer: client %s tried to move player %s to another level", playerID, changeLevelPacket.PlayerID)
+		return nil
+	}
+
+	// a dead player respawns in town
+	if player, _ := g.playerCombatant(playerID); player != nil && player.isDead() {
+		return nil
+	}
+
+	fromLevelID, toLevelID := g.playerLevels[playerID], changeLevelPacket.LevelID
+
+	warp := g.findWarp(fromLevelID, toLevelID)
+	if warp == nil {
+		g.Warningf("GameServer: client %s tried to warp from level %d to level %d, which are not linked",
+			playerID, fromLevelID, toLevelID)
+
+		return nil
+	}
+
+	now := time.Now()
+	position := g.estimatePosition(client, now)
+
+	if warp.Distance(position.X(), position.Y()) > warpUseTolerance {
+		g.Warningf("GameServer: client %s tried to use the warp to level %d from %s", playerID, toLevelID, position.World())
+		return nil
+	}
+
+	if _, err := g.levelMapEngine(toLevelID); err != nil {
+		return err
+	}
+
+	return g.movePlayerToLevel(client, toLevelID, g.arrivalPosition(toLevelID, fromLevelID), now)
+}
+
+// movePlayerToLevel moves a player to a position in a generated level. The players on the map
+// the player left get a ChangeLevelPacket, the players on the new map get an AddPlayerPacket.
+// The player gets the monsters of the new map which are dead or were moved.
+func (g *GameServer) movePlayerToLevel(client ClientConnection, toLevelID int, arrival d2vector.Position,
+	now time.Time) error {
+	playerID := client.GetUniqueID()
+	world := arrival.World()
+
+	changedPacket, err := d2netpacket.CreateChangeLevelPacket(playerID, toLevelID, g.difficulty, world.X(), world.Y())
+	if err != nil {
+		return err
+	}
+
+	// the player itself is still on the old map and gets the packet as well
+	g.sendPacketToMap(playerID, changedPacket)
+
+	player, fromMapEngine := g.playerCombatant(playerID)
+	delete(g.combatants[fromMapEngine], playerID)
+	delete(g.combatants[fromMapEngine], hirelingEntityID(playerID))
+
+	g.playerLevels[playerID] = toLevelID
+	g.combatants[g.mapEngines[toLevelID]][playerID] = player
+	g.movements[playerID] = newPlayerMovement(arrival, nil, now)
+
+	playerState := client.GetPlayerState()
+	playerState.X = world.X()
+	playerState.Y = world.Y()
+
+	g.addPlayerToMap(client)
+	g.sendMonsters(g.mapEngines[toLevelID], client)
+	g.sendStates(g.mapEngines[toLevelID], player)
+	g.spawnHireling(g.mapEngines[toLevelID], client)
+	g.sendHirelings(g.mapEngines[toLevelID], client)
+	g.sendGroundItems(g.mapEngines[toLevelID], client)
+
+	return nil
+}
@@ -0,0 +1,264 @@
+package d2server
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math/d2vector"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2item/diablo2item"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapengine"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapentity"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2client/d2clientconnectiontype"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+)
+
+// objectReachDistance is how close (in sub tiles) a player has to be to an object to operate
+// it, including the latency between the client and the server
+const objectReachDistance = 2 * subtilesPerTile
+
+// groundItem is an item on the ground, the server keeps it until a player picks it up
+type groundItem struct {
+	item     *diablo2item.Item
+	x, y     int // the tile the item lies on
+	position d2vector.Position
+}
+
+// containerOperateFns returns the OperateFn values of Objects.txt of the containers which drop
+// items, like chests, caskets, urns and barrels
+func containerOperateFns() map[int]bool {
+	return map[int]bool{
+		1: true, // casket
+		3: true, // urn
+		4: true, // chest
+		5: true, // barrel
+	}
+}
+
+// dropOffsets returns the tile offsets the items of a drop are placed at, so they do not lie on
+// top of each other
+func dropOffsets() [][2]float64 {
+	return [][2]float64{{0, 0}, {1, 0}, {0, 1}, {-1, 0}, {0, -1}, {1, 1}, {-1, 1}, {1, -1}, {-1, -1}}
+}
+
+// monsterTreasureClass returns the treasure class of MonStats.txt of a monster for its rank on
+// the difficulty
+func monsterTreasureClass(record *d2records.MonStatRecord, rank d2enum.MonsterRank,
+	difficulty d2enum.DifficultyType) string {
+	switch rank {
+	case d2enum.MonsterRankChampion:
+		return byDifficultyString(difficulty, record.TreasureClassChampionNormal,
+			record.TreasureClassChampionNightmare, record.TreasureClassChampionHell)
+	case d2enum.MonsterRankUnique, d2enum.MonsterRankSuperUnique:
+		return byDifficultyString(difficulty, record.TreasureClass3UniqueNormal,
+			record.TreasureClass3UniqueNightmare, record.TreasureClass3UniqueHell)
+	default:
+		return byDifficultyString(difficulty, record.TreasureClassNormal, record.TreasureClassNightmare,
+			record.TreasureClassHell)
+	}
+}
+
+// chestTreasureClass returns the treasure class of the containers of an act on the difficulty
+func chestTreasureClass(act int, difficulty d2enum.DifficultyType) string {
+	return fmt.Sprintf("Act %d%s Chest A", act+1, byDifficultyString(difficulty, "", " (N)", " (H)"))
+}
+
+func byDifficultyString(difficulty d2enum.DifficultyType, normal, nightmare, hell string) string {
+	switch difficulty {
+	case d2enum.DifficultyNightmare:
+		return nightmare
+	case d2enum.DifficultyHell:
+		return hell
+	default:
+		return normal
+	}
+}
+
+// dropMonsterLoot rolls the treasure class of a killed monster. A monster drops its loot once,
+// it drops nothing after being resurrected.
+func (g *GameServer) dropMonsterLoot(mapEngine *d2mapengine.MapEngine, monster *combatant) {
+	if monster.npc == nil || monster.looted {
+		return
+	}
+
+	monster.looted = true
+
+	treasureClass := monsterTreasureClass(monster.npc.MonStats(), monster.npc.Properties().Rank, g.difficulty)
+	g.dropTreasureClass(mapEngine, treasureClass, monster.ai.Position)
+}
+
+// dropTreasureClass rolls a treasure class for the players in the game and spawns the items on
+// the ground around the given position
+func (g *GameServer) dropTreasureClass(mapEngine *d2mapengine.MapEngine, treasureClass string,
+	position d2vector.Position) {
+	record := g.itemFactory.TreasureClass(treasureClass)
+	if record == nil {
+		return
+	}
+
+	tile := position.World()
+	offsets := dropOffsets()
+
+	for idx, item := range g.itemFactory.ItemsFromTreasureClassForPlayers(record, len(g.connections)) {
+		offset := offsets[idx%len(offsets)]
+		g.dropItem(mapEngine, item, int(tile.X()+offset[0]), int(tile.Y()+offset[1]))
+	}
+}
+
+// dropItem puts an item on the ground at the given tile. The server keeps the item by a new ID
+// and sends it to the players on the map, serialized so they show the item the server rolled.
+func (g *GameServer) dropItem(mapEngine *d2mapengine.MapEngine, item *diablo2item.Item, x, y int) {
+	itemID := uuid.New().String()
+
+	if g.groundItems[mapEngine] == nil {
+		g.groundItems[mapEngine] = make(map[string]*groundItem)
+	}
+
+	g.groundItems[mapEngine][itemID] = &groundItem{
+		item:     item,
+		x:        x,
+		y:        y,
+		position: d2vector.NewPositionTile(float64(x), float64(y)),
+	}
+
+	packet, err := d2netpacket.CreateGroundItemPacket(itemID, x, y, item.Serialize())
+	if err != nil {
+		g.Errorf("GameServer: error creating the drop of item %s: %v", itemID, err)
+		return
+	}
+
+	g.sendPacketToMapEngine(mapEngine, packet)
+}
+
+// sendGroundItems sends the items lying on the ground of the map to the client
+func (g *GameServer) sendGroundItems(mapEngine *d2mapengine.MapEngine, client ClientConnection) {
+	for itemID, ground := range g.groundItems[mapEngine] {
+		packet, err := d2netpacket.CreateGroundItemPacket(itemID, ground.x, ground.y, ground.item.Serialize())
+		if err != nil {
+			g.Errorf("GameServer: error creating the drop of item %s: %v", itemID, err)
+			continue
+		}
+
+		if err := client.SendPacketToClient(packet); err != nil {
+			g.Errorf("GameServer: error sending %s to client %s: %s", packet.PacketType, client.GetUniqueID(), err)
+		}
+	}
+}
+
+// handleSpawnItem spawns the items of the debug terminal of the host. The items of the game are
+// dropped by the server, so the spawns sent by remote clients are dropped.
+func (g *GameServer) handleSpawnItem(client ClientConnection, packet d2netpacket.NetPacket) error {
+	if client.GetConnectionType() != d2clientconnectiontype.Local {
+		g.Warningf("GameServer: client %s is not allowed to spawn items", client.GetUniqueID())
+		return nil
+	}
+
+	spawnItem, err := d2netpacket.UnmarshalSpawnItem(packet)
+	if err != nil {
+		return err
+	}
+
+	mapEngine, found := g.mapEngines[g.playerLevels[client.GetUniqueID()]]
+	if !found {
+		return nil
+	}
+
+	item, err := g.itemFactory.NewItem(spawnItem.Codes...)
+	if err != nil {
+		return err
+	}
+
+	g.dropItem(mapEngine, item, spawnItem.X, spawnItem.Y)
+
+	return nil
+}
+
+// handlePickupItem gives an item on the ground to the player which picks it up, if it fits into
+// the inventory of the player. The players on the map of the item are told it is gone, the
+// client of the player keeps the item it was sent when the item dropped.
+func (g *GameServer) handlePickupItem(client ClientConnection, packet d2netpacket.NetPacket) error {
+	pickupItem, err := d2netpacket.UnmarshalPickupItem(packet)
+	if err != nil {
+		return err
+	}
+
+	player, mapEngine := g.playerCombatant(client.GetUniqueID())
+	if player == nil || player.isDead() || pickupItem.PlayerID != client.GetUniqueID() {
+		return nil
+	}
+
+	ground := g.groundItems[mapEngine][pickupItem.ItemID]
+	if ground == nil {
+		return nil
+	}
+
+	position := g.estimatePosition(client, time.Now())
+	if position.Distance(&ground.position.Vector) > objectReachDistance {
+		g.Debugf("GameServer: player %s is too far away from item %s", client.GetUniqueID(), pickupItem.ItemID)
+		return nil
+	}
+
+	if !g.hasInventorySpace(client.GetUniqueID(), ground.item) {
+		g.Debugf("GameServer: item %s does not fit into the inventory of player %s", pickupItem.ItemID,
+			client.GetUniqueID())
+		return nil
+	}
+
+	delete(g.groundItems[mapEngine], pickupItem.ItemID)
+	g.giveItem(client, pickupItem.ItemID, ground.item)
+	g.sendPacketToMapEngine(mapEngine, packet)
+
+	return nil
+}
+
+// handleOperateObject opens an object for a player. Containers drop the items of the chest
+// treasure class of their act, shrines apply their effect to the player.
+func (g *GameServer) handleOperateObject(client ClientConnection, packet d2netpacket.NetPacket) error {
+	operateObject, err := d2netpacket.UnmarshalOperateObject(packet)
+	if err != nil {
+		return err
+	}
+
+	player, mapEngine := g.playerCombatant(client.GetUniqueID())
+	if player == nil || player.isDead() || operateObject.PlayerID != client.GetUniqueID() {
+		return nil
+	}
+
+	object, ok := mapEngine.Entities()[operateObject.ObjectID].(*d2mapentity.Object)
+	if !ok || object.IsOperated() {
+		return nil
+	}
+
+	position := g.estimatePosition(client, time.Now())
+	if position.Distance(&object.Position.Vector) > objectReachDistance {
+		g.Debugf("GameServer: player %s is too far away from object %s", client.GetUniqueID(), object.ID())
+		return nil
+	}
+
+	if err := object.Operate(); err != nil {
+		return err
+	}
+
+	g.sendPacketToMapEngine(mapEngine, packet)
+
+	if object.ObjectRecord().OperateFn == shrineOperateFn {
+		g.activateShrine(mapEngine, player, object)
+		return nil
+	}
+
+	if !containerOperateFns()[object.ObjectRecord().OperateFn] {
+		return nil
+	}
+
+	details := g.asset.Records.GetLevelDetails(g.playerLevels[client.GetUniqueID()])
+	if details == nil {
+		return nil
+	}
+
+	g.dropTreasureClass(mapEngine, chestTreasureClass(details.Act, g.difficulty), object.Position)
+
+	return nil
+}
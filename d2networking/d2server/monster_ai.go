@@ -0,0 +1,228 @@
+package d2server
+
+import (
+	"time"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math/d2vector"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2combat"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapengine"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2monsterai"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+)
+
+const (
+	// monsterTickInterval is the time between two ticks of the AI of the monsters
+	monsterTickInterval = time.Second / d2monsterai.TicksPerSecond
+
+	// monsterAttackTolerance is how far (in sub tiles) a target may move out of the attack
+	// range of a monster while the monster attacks
+	monsterAttackTolerance = 2.0
+)
+
+// monsterWorld is the map of a monster as seen by its AI. The units are collected once per
+// tick and shared by the monsters on the map.
+type monsterWorld struct {
+	server    *GameServer
+	mapEngine *d2mapengine.MapEngine
+	now       time.Time
+	targets   []d2monsterai.Unit
+	allies    []d2monsterai.Unit
+}
+
+// Targets returns the players and the hirelings on the map
+func (w *monsterWorld) Targets() []d2monsterai.Unit {
+	if w.targets == nil {
+		w.targets = make([]d2monsterai.Unit, 0)
+
+		for _, c := range w.server.combatants[w.mapEngine] {
+			if c.isFriendly() {
+				w.targets = append(w.targets, d2monsterai.Unit{
+					ID:       c.id,
+					Position: w.server.combatantPosition(c, w.now),
+					Dead:     c.isDead(),
+				})
+			}
+		}
+	}
+
+	return w.targets
+}
+
+// Allies returns the monsters on the map
+func (w *monsterWorld) Allies() []d2monsterai.Unit {
+	if w.allies == nil {
+		w.allies = make([]d2monsterai.Unit, 0)
+
+		for _, c := range w.server.combatants[w.mapEngine] {
+			if !c.isFriendly() && c.ai != nil {
+				w.allies = append(w.allies, d2monsterai.Unit{
+					ID:       c.id,
+					Position: c.ai.Position,
+					Dead:     c.isDead(),
+					Behavior: c.ai.Behavior,
+				})
+			}
+		}
+	}
+
+	return w.allies
+}
+
+// PathFind returns a path for a monster
+func (w *monsterWorld) PathFind(start, dest d2vector.Position) []d2vector.Position {
+	return w.mapEngine.PathFindFor(start, dest, d2mapengine.PathMoverMonster)
+}
+
+// hirelingWorld is the map of a hireling as seen by its AI, the sides of the monsters swapped.
+// The hirelings attack the monsters and follow the players.
+type hirelingWorld struct {
+	*monsterWorld
+}
+
+// Targets returns the monsters on the map
+func (w hirelingWorld) Targets() []d2monsterai.Unit {
+	return w.monsterWorld.Allies()
+}
+
+// Allies returns the players and the hirelings on the map
+func (w hirelingWorld) Allies() []d2monsterai.Unit {
+	return w.monsterWorld.Targets()
+}
+
+// advanceMonsters advances the AI of the monsters by one tick. Only the maps with players on
+// them are advanced.
+func (g *GameServer) advanceMonsters() {
+	g.stateMutex.Lock()
+	defer g.stateMutex.Unlock()
+
+	now := time.Now()
+
+	for mapEngine, combatants := range g.combatants {
+		world := &monsterWorld{server: g, mapEngine: mapEngine, now: now}
+		if len(world.Targets()) == 0 {
+			continue
+		}
+
+		for _, monster := range combatants {
+			if monster.ai == nil || monster.isDead() {
+				continue
+			}
+
+			var action d2monsterai.Action
+			if monster.owner != nil {
+				action = monster.ai.Tick(hirelingWorld{world})
+			} else {
+				action = monster.ai.Tick(world)
+			}
+
+			monster.npc.Position = monster.ai.Position
+
+			g.handleMonsterAction(mapEngine, monster, action)
+		}
+	}
+}
+
+// handleMonsterAction sends the action of a monster to the players on its map, and resolves
+// its attacks and resurrections
+func (g *GameServer) handleMonsterAction(mapEngine *d2mapengine.MapEngine, monster *combatant,
+	action d2monsterai.Action) {
+	var (
+		packet d2netpacket.NetPacket
+		err    error
+	)
+
+	target := g.combatants[mapEngine][action.TargetID]
+
+	switch action.Type {
+	case d2enum.MonsterActionMove:
+		start, dest := monster.ai.Position.World(), monster.ai.Destination()
+		destTile := dest.World()
+		packet, err = d2netpacket.CreateMoveMonsterPacket(monster.id, start.X(), start.Y(), destTile.X(), destTile.Y())
+		monster.moved = true
+	case d2enum.MonsterActionAttack:
+		if target == nil || !canAttack(monster, target) {
+			return
+		}
+
+		packet, err = d2netpacket.CreateMonsterActionPacket(monster.id, action.Type, target.id)
+	case d2enum.MonsterActionResurrect:
+		if target == nil || target.ai == nil || target.owner != nil || !target.isDead() {
+			return
+		}
+
+		target.life = target.maxLife
+		target.ai.Reset()
+
+		packet, err = d2netpacket.CreateMonsterActionPacket(monster.id, action.Type, target.id)
+	default:
+		return
+	}
+
+	if err != nil {
+		g.Errorf("GameServer: error creating the action of monster %s: %v", monster.id, err)
+		return
+	}
+
+	g.sendPacketToMapEngine(mapEngine, packet)
+
+	if action.Type != d2enum.MonsterActionAttack {
+		return
+	}
+
+	position := g.combatantPosition(target, time.Now())
+	if position.Distance(&monster.ai.Position.Vector) > monster.ai.AttackRange+monsterAttackTolerance {
+		return
+	}
+
+	if monster.owner != nil {
+		g.applyAttack(mapEngine, monster, target, g.hirelingAttack(monster))
+	} else {
+		g.applyAttack(mapEngine, monster, target, g.monsterAttack(monster))
+	}
+}
+
+// monsterAttack returns the first attack of a monster in MonStats.txt. The elemental damage is
+// added with the chance of the first element of the monster, the physical damage is scaled by
+// the level and the rank of the monster.
+func (g *GameServer) monsterAttack(monster *combatant) *d2combat.Attack {
+	record := monster.npc.MonStats()
+
+	attack := &d2combat.Attack{
+		Level: monster.Level,
+		AttackRating: byDifficulty(g.difficulty, record.AttackRatingA1Normal,
+			record.AttackRatingA1Nightmare, record.AttackRatingA1Hell),
+		Physical: d2combat.DamageRange{
+			Min: byDifficulty(g.difficulty, record.DamageMinA1Normal, record.DamageMinA1Nightmare, record.DamageMinA1Hell),
+			Max: byDifficulty(g.difficulty, record.DamageMaxA1Normal, record.DamageMaxA1Nightmare, record.DamageMaxA1Hell),
+		},
+		Element: d2combat.Element(record.ElementType1),
+	}
+
+	chance := byDifficulty(g.difficulty, record.ElementChance1Normal, record.ElementChance1Nightmare,
+		record.ElementChance1Hell)
+
+	if attack.Element != d2combat.ElementPhysical && g.rng.Intn(percent) < chance {
+		attack.Elemental = d2combat.DamageRange{
+			Min: byDifficulty(g.difficulty, record.ElementDamageMin1Normal, record.ElementDamageMin1Nightmare,
+				record.ElementDamageMin1Hell),
+			Max: byDifficulty(g.difficulty, record.ElementDamageMax1Normal, record.ElementDamageMax1Nightmare,
+				record.ElementDamageMax1Hell),
+		}
+	}
+
+	if values, found := monsterLevelValues(g.asset.Records, monster.Level, g.difficulty); found {
+		attack.Physical.Min = attack.Physical.Min * values.damage / percent
+		attack.Physical.Max = attack.Physical.Max * values.damage / percent
+	}
+
+	if monster.npc.Properties().Rank == d2enum.MonsterRankChampion {
+		damageBonus := percent + monUModConstant(g.asset.Records, d2enum.ChampionDamageBonus)
+		attack.Physical.Min = attack.Physical.Min * damageBonus / percent
+		attack.Physical.Max = attack.Physical.Max * damageBonus / percent
+		attack.AttackRating += attack.AttackRating * monUModConstant(g.asset.Records, d2enum.ChampionAttackRatingBonus) /
+			percent
+	}
+
+	return attack
+}
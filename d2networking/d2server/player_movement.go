@@ -0,0 +1,226 @@
+package d2server
+
+import (
+	"math"
+	"time"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math/d2vector"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapengine"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+)
+
+const (
+	// maxPlayerSpeed is the run speed of a player entity, in sub tiles per second
+	maxPlayerSpeed = 13.0
+
+	// moveStartSlack is how far (in sub tiles) the start of a move sent by a client may be from
+	// where the player entity can be. It covers the latency between both sides, as the client
+	// starts to move before the server accepts the move.
+	moveStartSlack = 3.0
+
+	// maxCoordinate bounds the tile coordinates sent by a client, far beyond the size of any map
+	maxCoordinate = 1 << 20
+)
+
+// validCoordinates reports whether the tile coordinates sent by a client can be turned into a
+// position. A position can not hold NaN or infinite values.
+func validCoordinates(coordinates ...float64) bool {
+	for _, coordinate := range coordinates {
+		if math.IsNaN(coordinate) || math.Abs(coordinate) > maxCoordinate {
+			return false
+		}
+	}
+
+	return true
+}
+
+// playerMovement is the last move of a player entity which was accepted by the server.
+// It is used to estimate where the player entity is while it walks along the path.
+type playerMovement struct {
+	start   d2vector.Position
+	path    []d2vector.Position
+	started time.Time
+}
+
+func newPlayerMovement(start d2vector.Position, path []d2vector.Position, started time.Time) *playerMovement {
+	return &playerMovement{
+		start:   start,
+		path:    path,
+		started: started,
+	}
+}
+
+// destination returns the position at the end of the path
+func (m *playerMovement) destination() d2vector.Position {
+	if len(m.path) == 0 {
+		return m.start
+	}
+
+	return m.path[len(m.path)-1]
+}
+
+// positionAt returns the position of the player entity at the given time, if it moved with
+// the given speed (in sub tiles per second) since the move was started.
+func (m *playerMovement) positionAt(t time.Time, speed float64) d2vector.Position {
+	remaining := t.Sub(m.started).Seconds() * speed
+	if remaining <= 0 {
+		return m.start
+	}
+
+	from := m.start
+
+	for idx := range m.path {
+		to := m.path[idx]
+		distance := from.Distance(&to.Vector)
+
+		if remaining < distance {
+			step := to.Vector.Clone()
+			step.Subtract(&from.Vector)
+			step.Scale(remaining / distance)
+			step.Add(&from.Vector)
+
+			return d2vector.NewPosition(step.X(), step.Y())
+		}
+
+		remaining -= distance
+		from = to
+	}
+
+	return from
+}
+
+// reachable reports whether the player entity can be at the given position at the given time,
+// give or take the slack (in sub tiles). The position has to be on the path of the move, and
+// no further along it than the player entity can move with the given speed since the move was
+// started.
+func (m *playerMovement) reachable(position d2vector.Position, t time.Time, speed, slack float64) bool {
+	reach := math.Max(t.Sub(m.started).Seconds(), 0)*speed + slack
+	from := m.start
+
+	if position.Distance(&from.Vector) <= slack {
+		return true
+	}
+
+	for idx := range m.path {
+		to := m.path[idx]
+
+		segment := to.Vector.Clone()
+		segment.Subtract(&from.Vector)
+		length := segment.Length()
+
+		if length > 0 {
+			offset := position.Vector.Clone()
+			offset.Subtract(&from.Vector)
+
+			// the closest point of the segment to the position, which the player entity can reach
+			along := d2math.Clamp(offset.Dot(segment)/length, 0, math.Min(length, reach))
+			segment.Scale(along / length)
+			segment.Add(&from.Vector)
+
+			if position.Distance(segment) <= slack {
+				return true
+			}
+		}
+
+		if reach -= length; reach <= 0 {
+			return false
+		}
+
+		from = to
+	}
+
+	return false
+}
+
+// handleMovePlayer validates a move requested by a client against the map of the server. The
+// start of the move is checked against the position estimated by the server, and the path to
+// the destination is searched on the server, so a player can not walk through walls or faster
+// than it can run. The corrected move is sent to all clients, including the one which sent it.
+func (g *GameServer) handleMovePlayer(client ClientConnection, packet d2netpacket.NetPacket) error {
+	movePacket, err := d2netpacket.UnmarshalMovePlayer(packet)
+	if err != nil {
+		return err
+	}
+
+	playerID := client.GetUniqueID()
+	if movePacket.PlayerID != playerID {
+		g.Warningf("GameServer: client %s tried to move player %s", playerID, movePacket.PlayerID)
+		return nil
+	}
+
+	if !validCoordinates(movePacket.StartX, movePacket.StartY, movePacket.DestX, movePacket.DestY) {
+		g.Warningf("GameServer: client %s sent a move with invalid coordinates", playerID)
+		return nil
+	}
+
+	if player, _ := g.playerCombatant(playerID); player != nil && player.isDead() {
+		return nil
+	}
+
+	now := time.Now()
+	mapEngine := g.mapEngines[g.playerLevels[playerID]]
+	start := g.validateMoveStart(client, movePacket, now)
+	path := mapEngine.PathFind(start, d2vector.NewPositionTile(movePacket.DestX, movePacket.DestY))
+	movement := newPlayerMovement(start, path, now)
+
+	g.movements[playerID] = movement
+
+	dest := movement.destination()
+	startPosition, destPosition := start.World(), dest.World()
+
+	playerState := g.connections[playerID].GetPlayerState()
+	playerState.X = destPosition.X()
+	playerState.Y = destPosition.Y()
+
+	correctedPacket, err := d2netpacket.CreateMovePlayerPacket(playerID,
+		startPosition.X(), startPosition.Y(), destPosition.X(), destPosition.Y())
+	if err != nil {
+		return err
+	}
+
+	g.sendPacketToMap(playerID, correctedPacket)
+
+	return nil
+}
+
+// validateMoveStart returns the position a move of the client starts at. The start sent by the
+// client is used if it is walkable and the player entity can have run there since the last move
+// of the client which was accepted by the server.
+func (g *GameServer) validateMoveStart(client ClientConnection, movePacket d2netpacket.MovePlayerPacket,
+	now time.Time) d2vector.Position {
+	movement := g.lastMovement(client, now)
+	requested := d2vector.NewPositionTile(movePacket.StartX, movePacket.StartY)
+
+	mapEngine := g.mapEngines[g.playerLevels[client.GetUniqueID()]]
+	walkable := mapEngine.IsWalkable(int(requested.X()), int(requested.Y()), d2mapengine.PathMoverPlayer)
+
+	if walkable && movement.reachable(requested, now, maxPlayerSpeed, moveStartSlack) {
+		return requested
+	}
+
+	estimated := movement.positionAt(now, maxPlayerSpeed)
+
+	g.Warningf("GameServer: corrected the position of client %s from %s to %s",
+		client.GetUniqueID(), requested.World(), estimated.World())
+
+	return estimated
+}
+
+// estimatePosition returns the position of the player entity of the client at the given time,
+// as estimated from the last move of the client which was accepted by the server.
+func (g *GameServer) estimatePosition(client ClientConnection, now time.Time) d2vector.Position {
+	return g.lastMovement(client, now).positionAt(now, maxPlayerSpeed)
+}
+
+// lastMovement returns the last move of the client which was accepted by the server. A player
+// entity which has not moved yet stands at the position of its player state.
+func (g *GameServer) lastMovement(client ClientConnection, now time.Time) *playerMovement {
+	if movement, found := g.movements[client.GetUniqueID()]; found {
+		return movement
+	}
+
+	playerState := client.GetPlayerState()
+
+	return newPlayerMovement(d2vector.NewPositionTile(playerState.X, playerState.Y), nil, now)
+}
@@ -0,0 +1,65 @@
+package d2server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math/d2vector"
+)
+
+func TestPlayerMovement_PositionAt(t *testing.T) {
+	started := time.Unix(0, 0)
+	path := []d2vector.Position{d2vector.NewPosition(20, 10), d2vector.NewPosition(20, 30)}
+	movement := newPlayerMovement(d2vector.NewPosition(10, 10), path, started)
+
+	tests := []struct {
+		elapsed time.Duration
+		x, y    float64
+	}{
+		{0, 10, 10},
+		{time.Second, 15, 10},
+		{3 * time.Second, 20, 15},
+		{time.Minute, 20, 30},
+	}
+
+	for _, test := range tests {
+		position := movement.positionAt(started.Add(test.elapsed), 5)
+
+		if !position.EqualsApprox(d2vector.NewVector(test.x, test.y)) {
+			t.Errorf("after %s: expected (%g, %g), got %s", test.elapsed, test.x, test.y, position.String())
+		}
+	}
+
+	if destination := movement.destination(); !destination.EqualsApprox(d2vector.NewVector(20, 30)) {
+		t.Errorf("expected destination (20, 30), got %s", destination.String())
+	}
+}
+
+func TestPlayerMovement_Reachable(t *testing.T) {
+	started := time.Unix(0, 0)
+	path := []d2vector.Position{d2vector.NewPosition(20, 10), d2vector.NewPosition(20, 30)}
+	movement := newPlayerMovement(d2vector.NewPosition(10, 10), path, started)
+
+	tests := []struct {
+		elapsed   time.Duration
+		x, y      float64
+		reachable bool
+	}{
+		{0, 11, 10, true},
+		{0, 15, 10, false},
+		{time.Second, 15, 11, true},
+		{time.Second, 20, 15, false},
+		{3 * time.Second, 20, 15, true},
+		{3 * time.Second, 15, 15, false},
+		{time.Minute, 20, 30, true},
+		{time.Minute, 20, 40, false},
+	}
+
+	for _, test := range tests {
+		position := d2vector.NewPosition(test.x, test.y)
+
+		if reachable := movement.reachable(position, started.Add(test.elapsed), 5, 2); reachable != test.reachable {
+			t.Errorf("after %s: expected (%g, %g) to be reachable %t", test.elapsed, test.x, test.y, test.reachable)
+		}
+	}
+}
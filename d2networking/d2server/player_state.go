@@ -0,0 +1,323 @@
+package d2server
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2inventory"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2item/diablo2item"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+)
+
+// sanitizePlayerState makes the hero state sent by a connecting client consistent with the
+// game records, so the server never starts from stats the client could not have earned.
+func (g *GameServer) sanitizePlayerState(client ClientConnection) error {
+	corrected, err := g.heroStateFactory.SanitizeHeroState(client.GetPlayerState())
+	if err != nil {
+		g.Errorf("%v: client %s: %v", errInvalidHeroState, client.GetUniqueID(), err)
+		return errInvalidHeroState
+	}
+
+	if corrected {
+		g.Warningf("GameServer: corrected the hero state of client %s", client.GetUniqueID())
+	}
+
+	return nil
+}
+
+// handleSavePlayer saves the hero state owned by the server. Only the choices a player can make
+// on the client are taken from the packet: the selected skills and where the items are kept.
+// The stats, life and mana of the player are the ones of the server.
+func (g *GameServer) handleSavePlayer(client ClientConnection, packet d2netpacket.NetPacket) error {
+	savePacket, err := d2netpacket.UnmarshalSavePlayer(packet)
+	if err != nil {
+		return err
+	}
+
+	if savePacket.Player == nil {
+		return errors.New("save player packet without a player")
+	}
+
+	playerState := g.connections[client.GetUniqueID()].GetPlayerState()
+
+	playerState.LeftSkill = selectedSkill(playerState, savePacket.Player.LeftSkill, playerState.LeftSkill)
+	playerState.RightSkill = selectedSkill(playerState, savePacket.Player.RightSkill, playerState.RightSkill)
+
+	g.moveItems(client, savePacket.Player.Items)
+	playerState.Items = g.heroItems(client.GetUniqueID())
+
+	player, _ := g.playerCombatant(client.GetUniqueID())
+
+	// the timed states are saved with the time they have left, the sanitizer drops the others
+	if player != nil {
+		playerState.States = player.states.Save(time.Now())
+	}
+
+	// derive life, mana and stamina from the new attributes
+	if _, err := g.heroStateFactory.SanitizeHeroState(playerState); err != nil {
+		return err
+	}
+
+	// the items the player moved change its final stats, the life and mana clamped by the
+	// sanitizer are set back to the ones of the server
+	if player != nil {
+		player.updatePlayer()
+	}
+
+	if err := g.heroStateFactory.Save(playerState); err != nil {
+		g.Errorf("GameServer: error saving saving Player: %s", err)
+	}
+
+	return nil
+}
+
+// selectedSkill returns the ID of the selected skill if the hero knows it, otherwise current
+func selectedSkill(playerState *d2hero.HeroState, selected *d2hero.HeroSkill, current int) int {
+	if selected == nil || selected.Shallow == nil {
+		return current
+	}
+
+	if _, found := playerState.Skills[selected.Shallow.SkillID]; !found {
+		return current
+	}
+
+	return selected.Shallow.SkillID
+}
+
+// loadPlayerItems keeps the items of the hero of a connecting client on the server, by item ID
+func (g *GameServer) loadPlayerItems(client ClientConnection) {
+	playerState := client.GetPlayerState()
+
+	items, err := g.heroStateFactory.LoadItems(playerState.Items)
+	if err != nil {
+		g.Warningf("GameServer: dropped the items of client %s which could not be loaded: %v", client.GetUniqueID(), err)
+	}
+
+	g.playerItems[client.GetUniqueID()] = make(map[string]*diablo2item.Item, len(items))
+
+	for _, heroItem := range items {
+		g.playerItems[client.GetUniqueID()][heroItem.ID] = heroItem.Item
+	}
+
+	playerState.Items = g.heroItems(client.GetUniqueID())
+}
+
+// giveItem keeps an item for the player in the first free place of its inventory, the callers
+// check the item fits with hasInventorySpace
+func (g *GameServer) giveItem(client ClientConnection, itemID string, item *diablo2item.Item) {
+	width, height := item.InventoryGridSize()
+	item.GridX, item.GridY, _ = g.inventoryGrid(client.GetUniqueID()).FreeCell(width, height)
+
+	item.Location, item.Storage = d2enum.ItemLocationStored, d2enum.ItemStorageInventory
+	item.SetSlotType(d2enum.EquippedSlotNone)
+
+	g.playerItems[client.GetUniqueID()][itemID] = item
+	client.GetPlayerState().Items = g.heroItems(client.GetUniqueID())
+	g.updateItemStats(client)
+}
+
+// inventoryGrid returns the inventory of the player, with the cells taken by the items the
+// server keeps in it
+func (g *GameServer) inventoryGrid(playerID string) *d2inventory.StorageGrid {
+	grid := d2inventory.NewStorageGrid(d2inventory.InventoryWidth, d2inventory.InventoryHeight)
+
+	for _, item := range g.playerItems[playerID] {
+		if item.Location != d2enum.ItemLocationStored || item.Storage != d2enum.ItemStorageInventory {
+			continue
+		}
+
+		width, height := item.InventoryGridSize()
+		grid.Put(item.GridX, item.GridY, width, height)
+	}
+
+	return grid
+}
+
+// hasInventorySpace returns true if the item fits into a free place of the inventory of the
+// player
+func (g *GameServer) hasInventorySpace(playerID string, item *diablo2item.Item) bool {
+	width, height := item.InventoryGridSize()
+	_, _, found := g.inventoryGrid(playerID).FreeCell(width, height)
+
+	return found
+}
+
+// takeItem removes an item the server keeps for the player
+func (g *GameServer) takeItem(client ClientConnection, itemID string) {
+	delete(g.playerItems[client.GetUniqueID()], itemID)
+	client.GetPlayerState().Items = g.heroItems(client.GetUniqueID())
+	g.updateItemStats(client)
+}
+
+// updateItemStats derives the final stats of a player again, after the items it wears changed
+func (g *GameServer) updateItemStats(client ClientConnection) {
+	if player, _ := g.playerCombatant(client.GetUniqueID()); player != nil {
+		player.updatePlayer()
+	}
+}
+
+// heroItems returns the items the server keeps for the player, ordered by ID, to be saved with
+// the hero
+func (g *GameServer) heroItems(playerID string) []*d2hero.HeroItem {
+	ids := make([]string, 0, len(g.playerItems[playerID]))
+
+	for itemID := range g.playerItems[playerID] {
+		ids = append(ids, itemID)
+	}
+
+	sort.Strings(ids)
+
+	items := make([]*d2hero.HeroItem, len(ids))
+
+	for idx, itemID := range ids {
+		items[idx] = &d2hero.HeroItem{ID: itemID, Item: g.playerItems[playerID][itemID]}
+	}
+
+	return items
+}
+
+// moveItems applies where the client keeps the items of the player. Only the positions of the
+// items the server keeps for the player are taken, the items themselves always stay the ones
+// of the server. An item stays where it is when the player can not wear it in the slot it is
+// moved to, or its place is outside of the storage panel or taken by another item.
+func (g *GameServer) moveItems(client ClientConnection, moved []*d2hero.HeroItem) {
+	playerID := client.GetUniqueID()
+	positions := make(map[string]*diablo2item.Item)
+
+	for _, heroItem := range moved {
+		if heroItem == nil || g.playerItems[playerID][heroItem.ID] == nil {
+			continue
+		}
+
+		position, err := g.itemFactory.Deserialize(heroItem.Data)
+		if err != nil {
+			g.Warningf("GameServer: client %s moved item %s: %v", playerID, heroItem.ID, err)
+			continue
+		}
+
+		positions[heroItem.ID] = position
+	}
+
+	places := newItemPlaces(g.beltRows(playerID))
+
+	// the items which stay keep their places, the moved items are placed in the order of their IDs
+	for _, heroItem := range g.heroItems(playerID) {
+		if positions[heroItem.ID] == nil {
+			places.take(heroItem.Item, heroItem.Item)
+		}
+	}
+
+	for _, heroItem := range g.heroItems(playerID) {
+		item, position := heroItem.Item, positions[heroItem.ID]
+		if position == nil {
+			continue
+		}
+
+		if !g.canPlaceItem(client, item, position) || !places.take(item, position) {
+			g.Warningf("GameServer: refused the move of item %s of client %s", heroItem.ID, playerID)
+			places.take(item, item)
+
+			continue
+		}
+
+		item.Location, item.Storage = position.Location, position.Storage
+		item.GridX, item.GridY = position.GridX, position.GridY
+		item.SetSlotType(position.SlotType())
+	}
+}
+
+// canPlaceItem returns true if the item may be kept at the location of the position: worn items
+// have to fit the slot and the player has to meet their requirements, only the items of the
+// belt types of ItemTypes.txt are kept in the belt
+func (g *GameServer) canPlaceItem(client ClientConnection, item, position *diablo2item.Item) bool {
+	switch position.Location {
+	case d2enum.ItemLocationStored:
+		return true
+	case d2enum.ItemLocationBelt:
+		return item.TypeRecord() != nil && item.TypeRecord().Beltable
+	case d2enum.ItemLocationEquipped:
+		playerState := client.GetPlayerState()
+		if !d2inventory.FitsSlot(g.asset.Records, item, position.SlotType(), playerState.HeroType) {
+			return false
+		}
+
+		strength, dexterity := playerState.Stats.Strength, playerState.Stats.Dexterity
+
+		// the attributes the worn items add count towards the requirements
+		if player, _ := g.playerCombatant(client.GetUniqueID()); player != nil && player.stats != nil {
+			strength = player.stats.Attribute(d2enum.AttributeStrength)
+			dexterity = player.stats.Attribute(d2enum.AttributeDexterity)
+		}
+
+		requiredLevel, requiredStrength, requiredDexterity := item.Requirements()
+
+		return requiredLevel <= playerState.Stats.Level && requiredStrength <= strength &&
+			requiredDexterity <= dexterity
+	default:
+		return false
+	}
+}
+
+// beltRows returns the rows of the belt of the player. Items.txt does not tell which row of
+// Belts.txt a belt is, so a worn belt holds the rows of the largest belt.
+func (g *GameServer) beltRows(playerID string) int {
+	for _, item := range g.playerItems[playerID] {
+		if item.Location == d2enum.ItemLocationEquipped && item.SlotType() == d2enum.EquippedSlotBelt {
+			return d2inventory.BeltHeight
+		}
+	}
+
+	return 1
+}
+
+// itemPlaces keeps the places of a player which are taken by items: the cells of the storage
+// panels, the boxes of the belt and the equipped slots
+type itemPlaces struct {
+	storages map[d2enum.ItemStorage]*d2inventory.StorageGrid
+	belt     *d2inventory.StorageGrid
+	slots    map[d2enum.EquippedSlot]bool
+}
+
+func newItemPlaces(beltRows int) *itemPlaces {
+	return &itemPlaces{
+		storages: map[d2enum.ItemStorage]*d2inventory.StorageGrid{
+			d2enum.ItemStorageInventory: d2inventory.NewStorageGrid(d2inventory.InventoryWidth,
+				d2inventory.InventoryHeight),
+			d2enum.ItemStorageStash: d2inventory.NewStorageGrid(d2inventory.StashWidth, d2inventory.StashHeight),
+			d2enum.ItemStorageCube:  d2inventory.NewStorageGrid(d2inventory.CubeWidth, d2inventory.CubeHeight),
+		},
+		belt:  d2inventory.NewStorageGrid(d2inventory.BeltWidth, beltRows),
+		slots: make(map[d2enum.EquippedSlot]bool),
+	}
+}
+
+// take takes the place of the position for the item, it returns false if the place does not
+// exist or is taken. The box of an item in the belt is its column plus its row times the width
+// of the belt.
+func (p *itemPlaces) take(item, position *diablo2item.Item) bool {
+	switch position.Location {
+	case d2enum.ItemLocationStored:
+		grid := p.storages[position.Storage]
+		width, height := item.InventoryGridSize()
+
+		return grid != nil && grid.Put(position.GridX, position.GridY, width, height)
+	case d2enum.ItemLocationBelt:
+		box := position.GridX
+
+		return box >= 0 && p.belt.Put(box%d2inventory.BeltWidth, box/d2inventory.BeltWidth, 1, 1)
+	case d2enum.ItemLocationEquipped:
+		slot := position.SlotType()
+		if slot == d2enum.EquippedSlotNone || p.slots[slot] {
+			return false
+		}
+
+		p.slots[slot] = true
+
+		return true
+	default:
+		return false
+	}
+}
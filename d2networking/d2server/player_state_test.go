@@ -0,0 +1,67 @@
+package d2server
+
+import (
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2util"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2asset"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2item/diablo2item"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+)
+
+func TestItemPlaces_Take(t *testing.T) {
+	asset, err := d2asset.NewAssetManager(d2util.LogLevelNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	factory, err := diablo2item.NewItemFactory(asset)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	asset.Records.Item.All = d2records.CommonItems{
+		"cap": {Code: "cap", Type: "helm", InventoryWidth: 2, InventoryHeight: 2},
+	}
+	asset.Records.Item.Types = map[string]*d2records.ItemTypeRecord{"helm": {Code: "helm"}}
+
+	place := func(location d2enum.ItemLocation, storage d2enum.ItemStorage, slot d2enum.EquippedSlot,
+		x, y int) *diablo2item.Item {
+		item, err := factory.NewItem("cap")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		item.Location, item.Storage, item.GridX, item.GridY = location, storage, x, y
+		item.SetSlotType(slot)
+
+		return item
+	}
+
+	places := newItemPlaces(1)
+	stored, belt, equipped := d2enum.ItemLocationStored, d2enum.ItemLocationBelt, d2enum.ItemLocationEquipped
+	inventory, stash, none := d2enum.ItemStorageInventory, d2enum.ItemStorageStash, d2enum.ItemStorageNone
+
+	tests := []struct {
+		name     string
+		position *diablo2item.Item
+		taken    bool
+	}{
+		{"inventory", place(stored, inventory, d2enum.EquippedSlotNone, 0, 0), true},
+		{"overlapping", place(stored, inventory, d2enum.EquippedSlotNone, 1, 1), false},
+		{"past the edge", place(stored, inventory, d2enum.EquippedSlotNone, 9, 0), false},
+		{"stash", place(stored, stash, d2enum.EquippedSlotNone, 4, 6), true},
+		{"no storage", place(stored, none, d2enum.EquippedSlotNone, 0, 0), false},
+		{"belt", place(belt, none, d2enum.EquippedSlotNone, 3, 0), true},
+		{"second belt row", place(belt, none, d2enum.EquippedSlotNone, 5, 0), false},
+		{"head", place(equipped, none, d2enum.EquippedSlotHead, 0, 0), true},
+		{"head again", place(equipped, none, d2enum.EquippedSlotHead, 0, 0), false},
+	}
+
+	for _, test := range tests {
+		if taken := places.take(test.position, test.position); taken != test.taken {
+			t.Errorf("%s: expected taken %t, got %t", test.name, test.taken, taken)
+		}
+	}
+}
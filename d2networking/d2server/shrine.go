@@ -0,0 +1,200 @@
+package d2server
+
+import (
+	"sort"
+	"time"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapengine"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapentity"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+)
+
+const (
+	// shrineOperateFn is the OperateFn of Objects.txt of the shrines
+	shrineOperateFn = 2
+
+	// shrineFramesPerSecond is the frame rate of the durations of Shrines.txt
+	shrineFramesPerSecond = 25
+
+	// shrinePotionCount is the number of throwing potions dropped by exploding and poison shrines
+	shrinePotionCount = 4
+)
+
+// shrineStates returns the states of States.txt of the shrines which give a timed buff. A player
+// has one shrine buff at a time.
+func shrineStates() map[d2enum.ShrineType]string {
+	return map[d2enum.ShrineType]string{
+		d2enum.ShrineArmor:           "shrine_armor",
+		d2enum.ShrineCombat:          "shrine_combat",
+		d2enum.ShrineResistFire:      "shrine_resist_fire",
+		d2enum.ShrineResistCold:      "shrine_resist_cold",
+		d2enum.ShrineResistLightning: "shrine_resist_lightning",
+		d2enum.ShrineResistPoison:    "shrine_resist_poison",
+		d2enum.ShrineSkill:           "shrine_skill",
+		d2enum.ShrineManaRecharge:    "shrine_mana_regen",
+		d2enum.ShrineStamina:         "shrine_stamina",
+		d2enum.ShrineExperience:      "shrine_experience",
+	}
+}
+
+// shrineStats returns the stats of ItemStatCost.txt the buff of a shrine gives, from the
+// arguments of Shrines.txt
+func shrineStats(record *d2records.ShrineRecord) map[string]int {
+	switch d2enum.ShrineType(record.Code) {
+	case d2enum.ShrineArmor:
+		return map[string]int{statArmorPercent: record.Arg0}
+	case d2enum.ShrineCombat:
+		return map[string]int{
+			statMinDamagePercent:    record.Arg0,
+			statMaxDamagePercent:    record.Arg0,
+			statAttackRatingPercent: record.Arg1,
+		}
+	case d2enum.ShrineResistFire:
+		return map[string]int{statFireResist: record.Arg0}
+	case d2enum.ShrineResistCold:
+		return map[string]int{statColdResist: record.Arg0}
+	case d2enum.ShrineResistLightning:
+		return map[string]int{statLightningResist: record.Arg0}
+	case d2enum.ShrineResistPoison:
+		return map[string]int{statPoisonResist: record.Arg0}
+	case d2enum.ShrineSkill:
+		return map[string]int{statAllSkills: record.Arg0}
+	case d2enum.ShrineManaRecharge:
+		return map[string]int{"manarecoverybonus": record.Arg0}
+	case d2enum.ShrineStamina:
+		return map[string]int{"staminarecoverybonus": record.Arg0}
+	case d2enum.ShrineExperience:
+		return map[string]int{statExperiencePercent: record.Arg0}
+	default:
+		return nil
+	}
+}
+
+// chippedGems returns the item codes of the chipped gems dropped by gem shrines
+func chippedGems() []string {
+	return []string{"gcv", "gcy", "gcb", "gcg", "gcr", "gcw", "skc"}
+}
+
+// shrineRecord returns the record of Shrines.txt of a shrine object. A shrine with a code in
+// the first parameter of Objects.txt always has that effect, the others get a random effect of
+// the shrines which may appear in the area level of the level.
+func (g *GameServer) shrineRecord(object *d2mapentity.Object, levelID int) *d2records.ShrineRecord {
+	candidates := make([]*d2records.ShrineRecord, 0)
+
+	code := object.ObjectRecord().Parm[0]
+	areaLevel := g.areaLevel(levelID)
+
+	for _, record := range g.asset.Records.Object.Shrines {
+		switch {
+		case code > 0 && record.Code == code:
+			return record
+		case code == 0 && record.Code > 0 && record.LevelMin <= areaLevel:
+			candidates = append(candidates, record)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	// the records are kept in a map, they are ordered for the same pick with the same seed
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].Code < candidates[j].Code
+	})
+
+	return candidates[g.rng.Intn(len(candidates))]
+}
+
+// areaLevel returns the monster level of Levels.txt of a level on the difficulty
+func (g *GameServer) areaLevel(levelID int) int {
+	details := g.asset.Records.GetLevelDetails(levelID)
+	if details == nil {
+		return 0
+	}
+
+	return byDifficulty(g.difficulty, details.MonsterLevelNormal, details.MonsterLevelNightmare,
+		details.MonsterLevelHell)
+}
+
+// activateShrine applies the effect of a shrine to the player which operated it. Recharge
+// shrines refill the life and mana of the player, exchange shrines drain one of them by the
+// percent of the first argument of Shrines.txt to refill the other, booster shrines give a timed buff, which
+// replaces the buff of an earlier shrine, and magic shrines drop items.
+func (g *GameServer) activateShrine(mapEngine *d2mapengine.MapEngine, player *combatant,
+	object *d2mapentity.Object) {
+	record := g.shrineRecord(object, g.playerLevels[player.id])
+	if record == nil {
+		return
+	}
+
+	shrineType := d2enum.ShrineType(record.Code)
+
+	if state, found := shrineStates()[shrineType]; found {
+		for _, other := range shrineStates() {
+			if other != state {
+				g.removeState(mapEngine, player, other)
+			}
+		}
+
+		duration := time.Second * time.Duration(record.DurationFrames) / shrineFramesPerSecond
+		g.setState(mapEngine, player, state, shrineStats(record), duration)
+
+		return
+	}
+
+	life, mana := player.life, player.mana
+	maxLife, maxMana := player.maxLife, player.maxMana
+
+	switch shrineType {
+	case d2enum.ShrineRefilling:
+		life, mana = maxLife, maxMana
+	case d2enum.ShrineHealth:
+		life = maxLife
+	case d2enum.ShrineMana:
+		mana = maxMana
+	case d2enum.ShrineHealthExchange:
+		life = maxLife
+		mana = d2math.MaxInt(mana-maxMana*record.Arg0/percent, 0)
+	case d2enum.ShrineManaExchange:
+		mana = maxMana
+		life = d2math.MaxInt(life-maxLife*record.Arg0/percent, 1)
+	case d2enum.ShrineGem:
+		gems := chippedGems()
+		g.dropShrineItems(mapEngine, object, gems[g.rng.Intn(len(gems))], 1)
+
+		return
+	case d2enum.ShrineExploding:
+		g.dropShrineItems(mapEngine, object, "opm", shrinePotionCount)
+		return
+	case d2enum.ShrinePoison:
+		g.dropShrineItems(mapEngine, object, "gpm", shrinePotionCount)
+		return
+	default:
+		g.Debugf("GameServer: the effect of the %s is not implemented", record.ShrineName)
+		return
+	}
+
+	player.setVitals(life, mana)
+	g.sendVitals(mapEngine, player)
+}
+
+// dropShrineItems drops items of the given code on the ground around a shrine
+func (g *GameServer) dropShrineItems(mapEngine *d2mapengine.MapEngine, object *d2mapentity.Object,
+	code string, count int) {
+	tile := object.Position.World()
+	offsets := dropOffsets()
+
+	for idx := 0; idx < count; idx++ {
+		offset := offsets[idx%len(offsets)]
+
+		item, err := g.itemFactory.NewItem(code)
+		if err != nil {
+			g.Errorf("GameServer: error creating the drop of %s: %v", code, err)
+			continue
+		}
+
+		g.dropItem(mapEngine, item, int(tile.X()+offset[0]), int(tile.Y()+offset[1]))
+	}
+}
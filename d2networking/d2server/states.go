@@ -0,0 +1,188 @@
+package d2server
+
+import (
+	"time"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2combat"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapengine"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2state"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+)
+
+// the stats of ItemStatCost.txt given by states which change the attacks and the defender of
+// a combatant
+const (
+	statArmorPercent        = "item_armor_percent"
+	statArmor               = "armorclass"
+	statPhysicalResist      = "damageresist"
+	statMagicResist         = "magicresist"
+	statFireResist          = "fireresist"
+	statColdResist          = "coldresist"
+	statLightningResist     = "lightresist"
+	statPoisonResist        = "poisonresist"
+	statMinDamagePercent    = "item_mindamage_percent"
+	statMaxDamagePercent    = "item_maxdamage_percent"
+	statAttackRatingPercent = "item_tohit_percent"
+	statAllSkills           = "item_allskills"
+	statExperiencePercent   = "item_addexperience"
+)
+
+// resistanceStats returns the stats of ItemStatCost.txt which add to the resistances of a
+// combatant
+func resistanceStats() map[d2combat.Element]string {
+	return map[d2combat.Element]string{
+		d2combat.ElementPhysical:  statPhysicalResist,
+		d2combat.ElementMagic:     statMagicResist,
+		d2combat.ElementFire:      statFireResist,
+		d2combat.ElementCold:      statColdResist,
+		d2combat.ElementLightning: statLightningResist,
+		d2combat.ElementPoison:    statPoisonResist,
+	}
+}
+
+// updateDefender updates the defense and the resistances of a combatant for the stats of the
+// states it is in, like a shrine buff of a player or a curse on a monster. The final stats of a
+// player are derived again from its hero, its items and its states, which also change its
+// maximum life and mana.
+func (c *combatant) updateDefender() {
+	if c.stats != nil {
+		state := c.client.GetPlayerState()
+		c.stats.Update(state.Stats, state.Skills, d2hero.EquippedItems(state.Items))
+
+		c.Defense = c.stats.Defense()
+		for element, stat := range resistanceStats() {
+			c.Resistances[element] = c.stats.Resistance(stat)
+		}
+
+		c.maxLife, c.maxMana = c.stats.MaxLife(), c.stats.MaxMana()
+		c.setVitals(c.life, c.mana)
+
+		return
+	}
+
+	c.Defense = c.baseDefense*(percent+c.states.Stat(statArmorPercent))/percent + c.states.Stat(statArmor)
+
+	for element, stat := range resistanceStats() {
+		c.Resistances[element] = c.baseResistances[element] + c.states.Stat(stat)
+	}
+}
+
+// setState puts a combatant in a state with the given stats and sends the state to the players
+// on its map. The state expires after the duration, or is kept until it is removed if the
+// duration is zero.
+func (g *GameServer) setState(mapEngine *d2mapengine.MapEngine, c *combatant, name string,
+	stats map[string]int, duration time.Duration) {
+	expires := time.Time{}
+	if duration > 0 {
+		expires = time.Now().Add(duration)
+	}
+
+	for _, replaced := range c.states.Set(d2state.Record(g.asset.Records.States, name), stats, expires) {
+		g.sendRemoveState(mapEngine, c, replaced)
+	}
+
+	c.updateDefender()
+
+	packet, err := d2netpacket.CreateSetStatePacket(c.id, name, stats, duration.Seconds())
+	if err != nil {
+		g.Errorf("SetStatePacket: %v", err)
+		return
+	}
+
+	g.sendPacketToMapEngine(mapEngine, packet)
+}
+
+// removeState removes a combatant from a state and sends the removal to the players on its map
+func (g *GameServer) removeState(mapEngine *d2mapengine.MapEngine, c *combatant, name string) {
+	if !c.states.Remove(name) {
+		return
+	}
+
+	c.updateDefender()
+	g.sendRemoveState(mapEngine, c, name)
+}
+
+func (g *GameServer) sendRemoveState(mapEngine *d2mapengine.MapEngine, c *combatant, name string) {
+	packet, err := d2netpacket.CreateRemoveStatePacket(c.id, name)
+	if err != nil {
+		g.Errorf("SetStatePacket: %v", err)
+		return
+	}
+
+	g.sendPacketToMapEngine(mapEngine, packet)
+}
+
+// statePackets returns the SetStatePackets of the states a combatant is in
+func (g *GameServer) statePackets(c *combatant) []d2netpacket.NetPacket {
+	packets := make([]d2netpacket.NetPacket, 0)
+
+	for _, saved := range c.states.Save(time.Now()) {
+		packet, err := d2netpacket.CreateSetStatePacket(c.id, saved.State, saved.Stats, saved.Remaining)
+		if err != nil {
+			g.Errorf("SetStatePacket: %v", err)
+			continue
+		}
+
+		packets = append(packets, packet)
+	}
+
+	return packets
+}
+
+// sendStates sends the states of a player which entered a map to the players on the map, and
+// the states of the players and monsters on the map to the player
+func (g *GameServer) sendStates(mapEngine *d2mapengine.MapEngine, player *combatant) {
+	for _, packet := range g.statePackets(player) {
+		g.sendPacketToMapEngine(mapEngine, packet)
+	}
+
+	for _, c := range g.combatants[mapEngine] {
+		if c == player {
+			continue
+		}
+
+		for _, packet := range g.statePackets(c) {
+			if err := player.client.SendPacketToClient(packet); err != nil {
+				g.Errorf("GameServer: error sending %s to client %s: %s", packet.PacketType, player.id, err)
+			}
+		}
+	}
+}
+
+// expireStates removes the expired states of the combatants and sends the removals to the
+// players on their maps
+func (g *GameServer) expireStates() {
+	g.stateMutex.Lock()
+	defer g.stateMutex.Unlock()
+
+	now := time.Now()
+
+	for mapEngine, combatants := range g.combatants {
+		for _, c := range combatants {
+			expired := c.states.Expire(now)
+			if len(expired) == 0 {
+				continue
+			}
+
+			c.updateDefender()
+
+			for _, name := range expired {
+				g.sendRemoveState(mapEngine, c, name)
+			}
+		}
+	}
+}
+
+// sendVitals sends the life and mana of a player to the players on its map
+func (g *GameServer) sendVitals(mapEngine *d2mapengine.MapEngine, player *combatant) {
+	stats := player.client.GetPlayerState().Stats
+
+	packet, err := d2netpacket.CreateSetVitalsPacket(player.id, stats.Health, stats.Mana)
+	if err != nil {
+		g.Errorf("SetVitalsPacket: %v", err)
+		return
+	}
+
+	g.sendPacketToMapEngine(mapEngine, packet)
+}
@@ -0,0 +1,269 @@
+package d2server
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2item/diablo2item"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapengine"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapentity"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+)
+
+// the reasons a trade is refused, sent to the client in the TradeResultPacket
+const (
+	tradeErrorNoVendor   = "no vendor"
+	tradeErrorNoItem     = "unknown item"
+	tradeErrorNoGold     = "not enough gold"
+	tradeErrorNoSpace    = "not enough room in the inventory"
+	tradeErrorNotDamaged = "item is not damaged"
+	tradeErrorAction     = "unknown trade action"
+)
+
+// vendorSession is the trade or gamble window of an NPC a player has open. The stock is rolled
+// when the window is opened, so a vendor offers new items on every visit.
+type vendorSession struct {
+	npcID  string
+	gamble bool
+	ctx    diablo2item.VendorContext
+	stock  map[string]*diablo2item.Item // the items offered by the vendor, by item ID
+}
+
+// townNPC returns an NPC if it is within the reach of the player, otherwise nil
+func (g *GameServer) townNPC(client ClientConnection, mapEngine *d2mapengine.MapEngine,
+	npcID string) *d2mapentity.NPC {
+	npc, ok := mapEngine.Entities()[npcID].(*d2mapentity.NPC)
+	if !ok || npc.MonStats() == nil || !npc.MonStats().IsNpc {
+		return nil
+	}
+
+	position := g.estimatePosition(client, time.Now())
+	if position.Distance(&npc.Position.Vector) > objectReachDistance {
+		g.Debugf("GameServer: player %s is too far away from NPC %s", client.GetUniqueID(), npcID)
+		return nil
+	}
+
+	return npc
+}
+
+// vendorNPC returns the NPC of a vendor if it is a vendor of NPC.txt within the reach of the
+// player, otherwise nil
+func (g *GameServer) vendorNPC(client ClientConnection, mapEngine *d2mapengine.MapEngine,
+	npcID string) *d2mapentity.NPC {
+	npc := g.townNPC(client, mapEngine, npcID)
+	if npc == nil || g.asset.Records.NPCs[npc.MonStats().Key] == nil {
+		return nil
+	}
+
+	return npc
+}
+
+// handleOpenVendor rolls the items of a vendor for a player and sends them to its client
+func (g *GameServer) handleOpenVendor(client ClientConnection, packet d2netpacket.NetPacket) error {
+	openVendor, err := d2netpacket.UnmarshalOpenVendor(packet)
+	if err != nil {
+		return err
+	}
+
+	player, mapEngine := g.playerCombatant(client.GetUniqueID())
+	if player == nil || player.isDead() || openVendor.PlayerID != client.GetUniqueID() {
+		return nil
+	}
+
+	npc := g.vendorNPC(client, mapEngine, openVendor.NPCID)
+	if npc == nil {
+		return nil
+	}
+
+	session := &vendorSession{
+		npcID:  openVendor.NPCID,
+		gamble: openVendor.Gamble,
+		ctx: diablo2item.VendorContext{
+			NPC:         g.asset.Records.NPCs[npc.MonStats().Key],
+			Difficulty:  g.difficulty,
+			PlayerLevel: client.GetPlayerState().Stats.Level,
+		},
+		stock: make(map[string]*diablo2item.Item),
+	}
+
+	var stock []*diablo2item.Item
+	if session.gamble {
+		stock = g.itemFactory.GambleStock(session.ctx)
+	} else {
+		stock = g.itemFactory.VendorStock(session.ctx)
+	}
+
+	items := make([]d2netpacket.VendorItem, len(stock))
+
+	for idx, item := range stock {
+		id := uuid.New().String()
+		session.stock[id] = item
+
+		price := session.ctx.BuyPrice(item)
+		if session.gamble {
+			price = session.ctx.GamblePrice(item)
+		}
+
+		items[idx] = d2netpacket.VendorItem{ID: id, Codes: item.Codes(), Price: price, Page: item.StorePage()}
+	}
+
+	g.vendors[client.GetUniqueID()] = session
+
+	inventoryPacket, err := d2netpacket.CreateVendorInventoryPacket(session.npcID, session.gamble, items)
+	if err != nil {
+		return err
+	}
+
+	return client.SendPacketToClient(inventoryPacket)
+}
+
+// handleTradeItem buys, sells, repairs or gambles for an item at the vendor the player has
+// open. The gold of the player is kept in the hero state of the server, the client is sent the
+// gold the player has after the trade.
+func (g *GameServer) handleTradeItem(client ClientConnection, packet d2netpacket.NetPacket) error {
+	trade, err := d2netpacket.UnmarshalTradeItem(packet)
+	if err != nil {
+		return err
+	}
+
+	player, mapEngine := g.playerCombatant(client.GetUniqueID())
+	if player == nil || player.isDead() || trade.PlayerID != client.GetUniqueID() {
+		return nil
+	}
+
+	session := g.vendors[client.GetUniqueID()]
+	if session == nil || g.vendorNPC(client, mapEngine, session.npcID) == nil {
+		return g.sendTradeError(client, trade, tradeErrorNoVendor)
+	}
+
+	var (
+		itemID = trade.ItemID
+		item   *diablo2item.Item
+		reason string
+	)
+
+	switch trade.Action {
+	case d2enum.TradeBuy, d2enum.TradeGamble:
+		itemID, item, reason = g.buyItem(client, session, trade)
+	case d2enum.TradeSell:
+		item, reason = g.sellItem(client, session, trade.ItemID)
+	case d2enum.TradeRepair:
+		item, reason = g.repairItem(client, session, trade.ItemID)
+	default:
+		reason = tradeErrorAction
+	}
+
+	if reason != "" {
+		return g.sendTradeError(client, trade, reason)
+	}
+
+	resultPacket, err := d2netpacket.CreateTradeResultPacket(client.GetUniqueID(), trade.Action, itemID, item.Codes(),
+		client.GetPlayerState().Gold)
+	if err != nil {
+		return err
+	}
+
+	return client.SendPacketToClient(resultPacket)
+}
+
+// buyItem buys an item of the vendor, or gambles for an item of the base item of the vendor.
+// The item is kept by the server for the player, so it can be sold and repaired. The ID of the
+// item the player got is returned, a gambled item gets an ID of its own. Nothing is bought when
+// the item does not fit into the inventory of the player.
+func (g *GameServer) buyItem(client ClientConnection, session *vendorSession,
+	trade d2netpacket.TradeItemPacket) (itemID string, item *diablo2item.Item, reason string) {
+	item = session.stock[trade.ItemID]
+	if item == nil || session.gamble != (trade.Action == d2enum.TradeGamble) {
+		return "", nil, tradeErrorNoItem
+	}
+
+	price := session.ctx.BuyPrice(item)
+	if session.gamble {
+		price = session.ctx.GamblePrice(item)
+	}
+
+	playerState := client.GetPlayerState()
+	if playerState.Gold < price {
+		return "", nil, tradeErrorNoGold
+	}
+
+	// a gambled item is of the base item of the window, it takes the same room
+	if !g.hasInventorySpace(client.GetUniqueID(), item) {
+		return "", nil, tradeErrorNoSpace
+	}
+
+	playerState.Gold -= price
+	itemID = trade.ItemID
+
+	// the base items of a gamble window stay, the gambled item is a new item
+	if session.gamble {
+		itemID = uuid.New().String()
+		item = g.itemFactory.Gamble(item)
+	} else {
+		delete(session.stock, trade.ItemID)
+	}
+
+	g.giveItem(client, itemID, item)
+
+	return itemID, item, ""
+}
+
+// sellItem sells an item of the player to the vendor, which offers it for the rest of the
+// visit
+func (g *GameServer) sellItem(client ClientConnection, session *vendorSession,
+	itemID string) (item *diablo2item.Item, reason string) {
+	item = g.playerItems[client.GetUniqueID()][itemID]
+	if item == nil {
+		return nil, tradeErrorNoItem
+	}
+
+	client.GetPlayerState().Gold += session.ctx.SellPrice(item)
+
+	g.takeItem(client, itemID)
+
+	if !session.gamble {
+		session.stock[itemID] = item
+	}
+
+	return item, ""
+}
+
+// repairItem restores the durability of an item of the player
+func (g *GameServer) repairItem(client ClientConnection, session *vendorSession,
+	itemID string) (item *diablo2item.Item, reason string) {
+	item = g.playerItems[client.GetUniqueID()][itemID]
+	if item == nil {
+		return nil, tradeErrorNoItem
+	}
+
+	price := session.ctx.RepairPrice(item)
+	if price == 0 {
+		return nil, tradeErrorNotDamaged
+	}
+
+	playerState := client.GetPlayerState()
+	if playerState.Gold < price {
+		return nil, tradeErrorNoGold
+	}
+
+	playerState.Gold -= price
+
+	_, max := item.Durability()
+	item.SetDurability(max)
+
+	return item, ""
+}
+
+func (g *GameServer) sendTradeError(client ClientConnection, trade d2netpacket.TradeItemPacket, reason string) error {
+	g.Debugf("GameServer: refused trade of player %s: %s", client.GetUniqueID(), reason)
+
+	errorPacket, err := d2netpacket.CreateTradeErrorPacket(client.GetUniqueID(), trade.Action, trade.ItemID,
+		client.GetPlayerState().Gold, reason)
+	if err != nil {
+		return err
+	}
+
+	return client.SendPacketToClient(errorPacket)
+}
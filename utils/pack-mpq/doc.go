@@ -0,0 +1,22 @@
+// This command line utility packs the files of a directory into an mpq file.
+// The paths of the files inside the mpq are relative to the given directory,
+// so a directory created by extract-mpq can be packed again.
+//
+// Flags:
+// -o [filename] Output mpq file (default: patch.mpq)
+// -c [none|implode|zlib] Compression method (default: implode)
+// -e Encrypt the files
+// -v Enable verbose output
+//
+// Usage:
+// First run `go install pack-mpq.go` in this directory.
+// Put the modified files into a directory, using the same paths as inside the
+// original mpq (ex: mymod/data/global/excel/armor.txt), then run pack-mpq(.exe)
+// with the directory.
+//
+// pack-mpq -o patch_mymod.mpq mymod
+//
+// To use the patch mpq, copy it to the MpqPath and add it to the start of the
+// MpqLoadOrder in the OpenDiablo2 config file, so it is mounted ahead of
+// d2data.mpq and the files in it are used instead of the original ones.
+package main
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2fileformats/d2mpq"
+)
+
+func main() {
+	var (
+		outPath     string
+		compression string
+		encrypt     bool
+		verbose     bool
+	)
+
+	flag.StringVar(&outPath, "o", "patch.mpq", "output mpq file")
+	flag.StringVar(&compression, "c", "implode", "compression method: none, implode or zlib")
+	flag.BoolVar(&encrypt, "e", false, "encrypt the files")
+	flag.BoolVar(&verbose, "v", false, "verbose output")
+	flag.Parse()
+
+	if len(flag.Args()) != 1 {
+		fmt.Printf("Usage: %s [-o patch.mpq] directory\n", os.Args[0])
+		os.Exit(1)
+	}
+
+	options := d2mpq.FileOptions{Encrypt: encrypt, FixKey: encrypt}
+
+	switch strings.ToLower(compression) {
+	case "none":
+		options.Compression = d2mpq.CompressionNone
+	case "implode":
+		options.Compression = d2mpq.CompressionImplode
+	case "zlib":
+		options.Compression = d2mpq.CompressionZlib
+	default:
+		log.Fatalf("unknown compression method: %s", compression)
+	}
+
+	root := flag.Arg(0)
+	mpq := d2mpq.NewWriter()
+
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		name, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		data, err := ioutil.ReadFile(filepath.Clean(path))
+		if err != nil {
+			return err
+		}
+
+		if verbose {
+			fmt.Printf("Adding: %s\n", name)
+		}
+
+		mpq.AddFile(filepath.ToSlash(name), data, options)
+
+		return nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := mpq.Save(outPath); err != nil {
+		log.Fatal(err)
+	}
+
+	if verbose {
+		fmt.Printf("Wrote %d files to %s\n", len(mpq.Files()), outPath)
+	}
+}